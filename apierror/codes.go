@@ -0,0 +1,168 @@
+// Package apierror defines the machine-readable error codes returned in the
+// "code" field of every error response. Codes are part of the API contract:
+// clients branch on them, so existing values must never be renamed or reused.
+package apierror
+
+// Code identifies the kind of error, independent of the human-readable message
+type Code string
+
+// Request and authentication errors
+const (
+	MethodNotAllowed     Code = "METHOD_NOT_ALLOWED"     // 405 - wrong HTTP method for the endpoint
+	UnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE" // 415 - body isn't sent as application/json
+	InvalidJSON          Code = "INVALID_JSON"           // 400 - body couldn't be parsed, has unknown fields or trailing data
+	BodyTooLarge         Code = "BODY_TOO_LARGE"         // 413 - JSON body is larger than MAX_BODY_SIZE
+	Unauthorized         Code = "UNAUTHORIZED"           // 401 - missing or malformed Authorization header
+	InvalidToken         Code = "INVALID_TOKEN"          // 401 - JWT is invalid or expired
+	TokenReplayed        Code = "TOKEN_REPLAYED"         // 401 - token was first used from another IP (TOKEN_REPLAY_CHECK=block)
+	InvalidCredentials   Code = "INVALID_CREDENTIALS"    // 401 - wrong email or password on login
+	AccountDeactivated   Code = "ACCOUNT_DEACTIVATED"    // 403 - an admin deactivated the account
+	QuotaExceeded        Code = "QUOTA_EXCEEDED"         // 429 - more than API_QUOTA requests this period
+)
+
+// User errors
+const (
+	EmailRequired       Code = "EMAIL_REQUIRED"       // 400
+	PasswordRequired    Code = "PASSWORD_REQUIRED"    // 400
+	CredentialsRequired Code = "CREDENTIALS_REQUIRED" // 400 - login without email and/or password
+	EmailTaken          Code = "EMAIL_TAKEN"          // 409
+	PasswordReused      Code = "PASSWORD_REUSED"      // 400 - new password matches a recent one
+	WrongPassword       Code = "WRONG_PASSWORD"       // 401 - current password doesn't match
+)
+
+// Task errors
+const (
+	InvalidTaskID           Code = "INVALID_TASK_ID"           // 400 - missing or non-numeric ID in the path
+	TaskNotFound            Code = "TASK_NOT_FOUND"            // 404 - doesn't exist or belongs to another user
+	TitleRequired           Code = "TITLE_REQUIRED"            // 400
+	TitleTooLong            Code = "TITLE_TOO_LONG"            // 400 - longer than MAX_TITLE_LENGTH characters
+	DescriptionTooLong      Code = "DESCRIPTION_TOO_LONG"      // 400 - longer than MAX_DESCRIPTION_LENGTH characters
+	InvalidStatus           Code = "INVALID_STATUS"            // 400 - not one of the configured statuses
+	InvalidColor            Code = "INVALID_COLOR"             // 400 - not #RRGGBB or one of TASK_COLORS
+	InvalidProgress         Code = "INVALID_PROGRESS"          // 400 - progress isn't a whole number from 0 to 100
+	InvalidVisibility       Code = "INVALID_VISIBILITY"        // 400 - visibility isn't private or public
+	InvalidStatusTransition Code = "INVALID_STATUS_TRANSITION" // 409 - workflow doesn't allow the change
+	InvalidPagination       Code = "INVALID_PAGINATION"        // 400 - page or page_size isn't a positive integer
+	InvalidSort             Code = "INVALID_SORT"              // 400 - search sort isn't a sortable column
+	InvalidDateRange        Code = "INVALID_DATE_RANGE"        // 400 - range starts after it ends, or an end isn't a timestamp
+	InvalidInclude          Code = "INVALID_INCLUDE"           // 400 - include names an unknown association, or too many
+	InvalidFields           Code = "INVALID_FIELDS"            // 400 - fields names something that isn't a task field
+	InvalidScope            Code = "INVALID_SCOPE"             // 400 - scope isn't owned or all
+	InvalidSince            Code = "INVALID_SINCE"             // 400 - since is missing or isn't a timestamp
+	InvalidExportFormat     Code = "INVALID_EXPORT_FORMAT"     // 400 - ?format= isn't md
+	InvalidDueRange         Code = "INVALID_DUE_RANGE"         // 400 - range isn't today, week or overdue
+	InvalidTimezone         Code = "INVALID_TIMEZONE"          // 400 - tz isn't an IANA time zone
+	BatchIDsRequired        Code = "BATCH_IDS_REQUIRED"        // 400
+	BatchTooLarge           Code = "BATCH_TOO_LARGE"           // 400
+	InvalidDryRun           Code = "INVALID_DRY_RUN"           // 400 - dry_run isn't true or false
+	InvalidAtomic           Code = "INVALID_ATOMIC"            // 400 - atomic isn't true or false
+	NewOwnerRequired        Code = "NEW_OWNER_REQUIRED"        // 400
+	NewOwnerNotFound        Code = "NEW_OWNER_NOT_FOUND"       // 400
+	NewOwnerInactive        Code = "NEW_OWNER_INACTIVE"        // 400 - transfer to a deactivated user
+	AlreadyOwner            Code = "ALREADY_OWNER"             // 400 - transfer to the current owner
+	StreamingUnsupported    Code = "STREAMING_UNSUPPORTED"     // 500 - connection can't be flushed
+	TaskReadOnly            Code = "TASK_READ_ONLY"            // 403 - task is shared with the caller read-only
+	NotTaskOwner            Code = "NOT_TASK_OWNER"            // 403 - only the owner can change the task's visibility
+	TaskLimitReached        Code = "TASK_LIMIT_REACHED"        // 403 - the user already has MAX_TASKS_PER_USER tasks
+	StatusLimitReached      Code = "STATUS_LIMIT_REACHED"      // 409 - the owner already has TASK_STATUS_LIMITS tasks with the status
+	TaskUpdateInProgress    Code = "TASK_UPDATE_IN_PROGRESS"   // 409 - another update of the task is running (TASK_UPDATE_LOCK=reject)
+	InvalidClientID         Code = "INVALID_CLIENT_ID"         // 400 - client ID in the path isn't a UUID
+	InvalidExternalID       Code = "INVALID_EXTERNAL_ID"       // 400 - external_id is longer than 255 characters
+	TaskNotCompleted        Code = "TASK_NOT_COMPLETED"        // 409 - only completed tasks can be reopened
+	InvalidMetadata         Code = "INVALID_METADATA"          // 400 - metadata isn't a JSON object
+	MetadataTooLarge        Code = "METADATA_TOO_LARGE"        // 400 - metadata is larger than MAX_METADATA_SIZE bytes
+)
+
+// Task sharing errors
+const (
+	ShareUserRequired      Code = "SHARE_USER_REQUIRED"      // 400
+	ShareUserNotFound      Code = "SHARE_USER_NOT_FOUND"     // 400
+	ShareWithOwner         Code = "SHARE_WITH_OWNER"         // 400 - owners already have full access
+	InvalidSharePermission Code = "INVALID_SHARE_PERMISSION" // 400 - not read or write
+	InvalidUserID          Code = "INVALID_USER_ID"          // 400 - non-numeric user ID in the path
+	ShareNotFound          Code = "SHARE_NOT_FOUND"          // 404
+)
+
+// Attachment errors
+const (
+	AttachmentFileRequired   Code = "ATTACHMENT_FILE_REQUIRED"    // 400 - no multipart "file" field
+	AttachmentTooLarge       Code = "ATTACHMENT_TOO_LARGE"        // 413 - larger than ATTACHMENT_MAX_SIZE
+	AttachmentTypeNotAllowed Code = "ATTACHMENT_TYPE_NOT_ALLOWED" // 415 - not in ATTACHMENT_ALLOWED_TYPES
+	InvalidAttachmentID      Code = "INVALID_ATTACHMENT_ID"       // 400 - non-numeric attachment ID in the path
+	AttachmentNotFound       Code = "ATTACHMENT_NOT_FOUND"        // 404
+)
+
+// Checklist errors
+const (
+	ChecklistTextRequired  Code = "CHECKLIST_TEXT_REQUIRED"   // 400
+	ChecklistTextTooLong   Code = "CHECKLIST_TEXT_TOO_LONG"   // 400 - longer than 500 characters
+	InvalidChecklistItemID Code = "INVALID_CHECKLIST_ITEM_ID" // 400 - non-numeric item ID in the path
+	ChecklistItemNotFound  Code = "CHECKLIST_ITEM_NOT_FOUND"  // 404
+)
+
+// Time tracking errors
+const (
+	TimeEntryTimesRequired Code = "TIME_ENTRY_TIMES_REQUIRED" // 400 - started_at or ended_at is missing
+	InvalidTimeRange       Code = "INVALID_TIME_RANGE"        // 400 - ended_at isn't after started_at
+	TimerAlreadyRunning    Code = "TIMER_ALREADY_RUNNING"     // 409 - the caller already has a timer running
+	TimerNotRunning        Code = "TIMER_NOT_RUNNING"         // 409 - no timer of the caller's is running on the task
+)
+
+// Comment and notification errors
+const (
+	CommentBodyRequired   Code = "COMMENT_BODY_REQUIRED"   // 400
+	CommentBodyTooLong    Code = "COMMENT_BODY_TOO_LONG"   // 400 - longer than 5000 characters
+	InvalidNotificationID Code = "INVALID_NOTIFICATION_ID" // 400 - non-numeric notification ID in the path
+	NotificationNotFound  Code = "NOTIFICATION_NOT_FOUND"  // 404
+)
+
+// Organization errors
+const (
+	OrganizationTaken Code = "ORGANIZATION_TAKEN" // 409 - another organization already has the name
+	AdminRequired     Code = "ADMIN_REQUIRED"     // 403 - only organization admins may do this
+	InvalidRole       Code = "INVALID_ROLE"       // 400 - not member or admin
+	MemberNotFound    Code = "MEMBER_NOT_FOUND"   // 404 - no such user in the caller's organization
+	CannotChangeSelf  Code = "CANNOT_CHANGE_SELF" // 400 - admins can't deactivate or demote themselves
+)
+
+// Webhook errors
+const (
+	InvalidWebhookURL   Code = "INVALID_WEBHOOK_URL"   // 400
+	InvalidWebhookEvent Code = "INVALID_WEBHOOK_EVENT" // 400
+	InvalidWebhookID    Code = "INVALID_WEBHOOK_ID"    // 400
+	WebhookNotFound     Code = "WEBHOOK_NOT_FOUND"     // 404
+)
+
+// GraphQL errors
+const (
+	GraphQLSyntaxError      Code = "GRAPHQL_SYNTAX_ERROR"      // 400 - the query isn't valid GraphQL
+	GraphQLValidationFailed Code = "GRAPHQL_VALIDATION_FAILED" // 400 - the query doesn't fit the schema
+)
+
+// Server errors
+const (
+	QueryTimeout        Code = "QUERY_TIMEOUT"        // 504 - database query exceeded DB_QUERY_TIMEOUT
+	RequestTimeout      Code = "REQUEST_TIMEOUT"      // 504 - the whole request exceeded REQUEST_TIMEOUT
+	RequestCancelled    Code = "REQUEST_CANCELLED"    // 503 - client went away mid-request
+	DatabaseUnavailable Code = "DATABASE_UNAVAILABLE" // 503 - the database can't be reached
+	InternalError       Code = "INTERNAL_ERROR"       // 500 - unexpected failure, details are only logged
+	Maintenance         Code = "MAINTENANCE"          // 503 - writes are paused by MAINTENANCE_MODE
+	Overloaded          Code = "OVERLOADED"           // 503 - more than MAX_CONCURRENT_REQUESTS requests at once
+)
+
+// All lists every code, e.g. to check that message catalogs are complete
+var All = []Code{
+	MethodNotAllowed, UnsupportedMediaType, InvalidJSON, BodyTooLarge, Unauthorized, InvalidToken, TokenReplayed, InvalidCredentials, AccountDeactivated, QuotaExceeded,
+	EmailRequired, PasswordRequired, CredentialsRequired, EmailTaken, PasswordReused, WrongPassword,
+	InvalidTaskID, TaskNotFound, TitleRequired, TitleTooLong, DescriptionTooLong, InvalidStatus, InvalidColor, InvalidProgress, InvalidVisibility, InvalidStatusTransition, InvalidPagination, InvalidSort, InvalidDateRange, InvalidInclude, InvalidFields, InvalidScope, InvalidSince, InvalidExportFormat, InvalidDueRange, InvalidTimezone,
+	BatchIDsRequired, BatchTooLarge, InvalidDryRun, InvalidAtomic, NewOwnerRequired, NewOwnerNotFound, NewOwnerInactive, AlreadyOwner, StreamingUnsupported,
+	TaskReadOnly, NotTaskOwner, TaskLimitReached, StatusLimitReached, TaskUpdateInProgress, InvalidClientID, InvalidExternalID, TaskNotCompleted, InvalidMetadata, MetadataTooLarge, ShareUserRequired, ShareUserNotFound, ShareWithOwner, InvalidSharePermission, InvalidUserID, ShareNotFound,
+	AttachmentFileRequired, AttachmentTooLarge, AttachmentTypeNotAllowed, InvalidAttachmentID, AttachmentNotFound,
+	ChecklistTextRequired, ChecklistTextTooLong, InvalidChecklistItemID, ChecklistItemNotFound,
+	TimeEntryTimesRequired, InvalidTimeRange, TimerAlreadyRunning, TimerNotRunning,
+	CommentBodyRequired, CommentBodyTooLong, InvalidNotificationID, NotificationNotFound,
+	OrganizationTaken, AdminRequired, InvalidRole, MemberNotFound, CannotChangeSelf,
+	InvalidWebhookURL, InvalidWebhookEvent, InvalidWebhookID, WebhookNotFound,
+	GraphQLSyntaxError, GraphQLValidationFailed,
+	QueryTimeout, RequestTimeout, RequestCancelled, DatabaseUnavailable, InternalError, Maintenance, Overloaded,
+}
@@ -0,0 +1,49 @@
+package apierror
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// EnvelopeMediaType is the Accept value clients send to get enveloped responses
+// Data comes back as {"data": ..., "meta": {...}, "links": {...}} and errors
+// as {"errors": [...]}. Without it responses keep the original flat shape.
+const EnvelopeMediaType = "application/vnd.taskapi+json"
+
+// WantsEnvelope reports whether an Accept header asks for enveloped responses
+// accept is the raw header; q=0 explicitly refuses the envelope
+func WantsEnvelope(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != EnvelopeMediaType {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// ErrorObject is one error in an enveloped error response
+type ErrorObject struct {
+	Status string `json:"status"` // HTTP status code, as a string like JSON:API
+	Code   Code   `json:"code"`   // Machine-readable error code
+	Detail string `json:"detail"` // Human-readable (localized) message
+}
+
+// ErrorEnvelope is the enveloped form of an error response
+type ErrorEnvelope struct {
+	Errors []ErrorObject `json:"errors"`
+}
+
+// NewErrorEnvelope builds the enveloped error for a status, code and message
+func NewErrorEnvelope(status int, code Code, message string) ErrorEnvelope {
+	return ErrorEnvelope{Errors: []ErrorObject{{
+		Status: strconv.Itoa(status),
+		Code:   code,
+		Detail: message,
+	}}}
+}
@@ -0,0 +1,30 @@
+package apierror
+
+import "testing"
+
+// TestWantsEnvelope tests recognizing the envelope media type in Accept
+func TestWantsEnvelope(t *testing.T) {
+	testCases := []struct {
+		name     string
+		accept   string
+		expected bool
+	}{
+		{"no header", "", false},
+		{"plain json", "application/json", false},
+		{"wildcard", "*/*", false},
+		{"envelope", EnvelopeMediaType, true},
+		{"envelope with parameters", EnvelopeMediaType + "; charset=utf-8", true},
+		{"envelope among others", "application/json;q=0.5, " + EnvelopeMediaType, true},
+		{"case insensitive", "Application/Vnd.TaskAPI+JSON", true},
+		{"refused with q=0", EnvelopeMediaType + ";q=0", false},
+		{"malformed header", ";;,/", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := WantsEnvelope(tc.accept); got != tc.expected {
+				t.Errorf("WantsEnvelope(%q) = %v, expected %v", tc.accept, got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package apierror
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// catalog holds translated messages by language (lowercase ISO 639-1) and code
+// English isn't listed: the handlers' own messages are the English text, and
+// they keep details (like the list of valid statuses) a static catalog can't
+var catalog = map[string]map[Code]string{
+	"tr": {
+		MethodNotAllowed:         "Bu HTTP yöntemine izin verilmiyor",
+		UnsupportedMediaType:     "Content-Type application/json olmalıdır",
+		InvalidJSON:              "Geçersiz JSON",
+		BodyTooLarge:             "İstek gövdesi çok büyük",
+		Unauthorized:             "Kimlik doğrulaması gerekli",
+		InvalidToken:             "Geçersiz veya süresi dolmuş token",
+		TokenReplayed:            "Bu token başka bir IP adresinden kullanıldı; lütfen tekrar giriş yapın",
+		InvalidCredentials:       "Geçersiz e-posta veya şifre",
+		AccountDeactivated:       "Hesabınız devre dışı bırakıldı",
+		QuotaExceeded:            "Bu dönem için istek kotanızı doldurdunuz",
+		EmailRequired:            "E-posta gerekli",
+		PasswordRequired:         "Şifre gerekli",
+		CredentialsRequired:      "E-posta ve şifre gerekli",
+		EmailTaken:               "Bu e-posta ile kayıtlı bir kullanıcı zaten var",
+		PasswordReused:           "Yeni şifre son kullanılan şifrelerden biri olamaz",
+		WrongPassword:            "Mevcut şifre yanlış",
+		InvalidTaskID:            "Geçersiz görev kimliği",
+		TaskNotFound:             "Görev bulunamadı",
+		TitleRequired:            "Başlık gerekli",
+		TitleTooLong:             "Başlık çok uzun",
+		DescriptionTooLong:       "Açıklama çok uzun",
+		TaskLimitReached:         "Görev sınırına ulaştınız",
+		StatusLimitReached:       "Bu durumdaki görev sınırına ulaşıldı",
+		TaskUpdateInProgress:     "Görev şu anda başka bir istek tarafından güncelleniyor, lütfen tekrar deneyin",
+		InvalidStatus:            "Geçersiz durum",
+		InvalidColor:             "Geçersiz renk",
+		InvalidProgress:          "İlerleme 0 ile 100 arasında olmalıdır",
+		InvalidVisibility:        "Görünürlük private veya public olmalıdır",
+		InvalidStatusTransition:  "Görev bu duruma geçirilemez",
+		InvalidPagination:        "page ve page_size pozitif tam sayı olmalıdır",
+		InvalidSort:              "Geçersiz sıralama alanı",
+		InvalidDateRange:         "Geçersiz tarih aralığı",
+		InvalidInclude:           "Geçersiz include değeri. Kullanın: checklist, attachments",
+		InvalidFields:            "Geçersiz fields değeri",
+		InvalidScope:             "scope owned veya all olmalıdır",
+		InvalidSince:             "since bir RFC 3339 zaman damgası veya Unix saniyesi olmalıdır",
+		InvalidExportFormat:      "Geçersiz dışa aktarma biçimi",
+		InvalidDueRange:          "range today, week veya overdue olmalıdır",
+		InvalidTimezone:          "Geçersiz saat dilimi",
+		BatchIDsRequired:         "ids gerekli",
+		BatchTooLarge:            "Tek istekte çok fazla görev kimliği var",
+		InvalidDryRun:            "dry_run true veya false olmalıdır",
+		InvalidAtomic:            "atomic true veya false olmalıdır",
+		NewOwnerRequired:         "new_owner_id gerekli",
+		NewOwnerNotFound:         "Yeni sahip bulunamadı",
+		NewOwnerInactive:         "Yeni sahibin hesabı devre dışı",
+		AlreadyOwner:             "Görev zaten bu kullanıcıya ait",
+		StreamingUnsupported:     "Akış desteklenmiyor",
+		TaskReadOnly:             "Bu görev sizinle salt okunur olarak paylaşıldı",
+		NotTaskOwner:             "Görevin görünürlüğünü yalnızca sahibi değiştirebilir",
+		InvalidClientID:          "İstemci kimliği bir UUID olmalıdır",
+		InvalidExternalID:        "Harici kimlik 255 karakterden uzun olamaz",
+		TaskNotCompleted:         "Yalnızca tamamlanmış görevler yeniden açılabilir",
+		InvalidMetadata:          "metadata bir JSON nesnesi olmalıdır",
+		MetadataTooLarge:         "metadata çok büyük",
+		ShareUserRequired:        "user_id gerekli",
+		ShareUserNotFound:        "Kullanıcı bulunamadı",
+		ShareWithOwner:           "Görev kendi sahibiyle paylaşılamaz",
+		InvalidSharePermission:   "Geçersiz izin. Kullanın: read, write",
+		InvalidUserID:            "Geçersiz kullanıcı kimliği",
+		ShareNotFound:            "Paylaşım bulunamadı",
+		AttachmentFileRequired:   "multipart/form-data ile bir file alanı gönderilmelidir",
+		AttachmentTooLarge:       "Dosya çok büyük",
+		AttachmentTypeNotAllowed: "Bu dosya türüne izin verilmiyor",
+		InvalidAttachmentID:      "Geçersiz ek kimliği",
+		AttachmentNotFound:       "Ek bulunamadı",
+		ChecklistTextRequired:    "Kontrol listesi öğesinin metni boş olamaz",
+		ChecklistTextTooLong:     "Kontrol listesi öğesinin metni çok uzun",
+		InvalidChecklistItemID:   "Geçersiz kontrol listesi öğesi kimliği",
+		ChecklistItemNotFound:    "Kontrol listesi öğesi bulunamadı",
+		TimeEntryTimesRequired:   "started_at ve ended_at alanları gereklidir",
+		InvalidTimeRange:         "Bitiş zamanı başlangıçtan sonra olmalıdır",
+		TimerAlreadyRunning:      "Zaten çalışan bir zamanlayıcınız var",
+		TimerNotRunning:          "Bu görevde çalışan bir zamanlayıcınız yok",
+		CommentBodyRequired:      "Yorum metni boş olamaz",
+		CommentBodyTooLong:       "Yorum metni çok uzun",
+		InvalidNotificationID:    "Geçersiz bildirim kimliği",
+		NotificationNotFound:     "Bildirim bulunamadı",
+		OrganizationTaken:        "Bu isimde bir organizasyon zaten var",
+		AdminRequired:            "Bu işlem için organizasyon yöneticisi olmalısınız",
+		InvalidRole:              "Geçersiz rol. Kullanın: member, admin",
+		MemberNotFound:           "Kullanıcı organizasyonunuzda bulunamadı",
+		CannotChangeSelf:         "Kendi hesabınızı devre dışı bırakamaz veya yetkisini düşüremezsiniz",
+		InvalidWebhookURL:        "Geçerli bir http veya https URL'si gerekli",
+		InvalidWebhookEvent:      "Geçersiz olay",
+		InvalidWebhookID:         "Geçersiz webhook kimliği",
+		WebhookNotFound:          "Webhook bulunamadı",
+		GraphQLSyntaxError:       "GraphQL sorgusu okunamadı",
+		GraphQLValidationFailed:  "GraphQL sorgusu şemaya uymuyor",
+		QueryTimeout:             "Veritabanı sorgusu zaman aşımına uğradı",
+		RequestTimeout:           "İstek zaman aşımına uğradı",
+		RequestCancelled:         "İstek iptal edildi",
+		DatabaseUnavailable:      "Veritabanına şu anda ulaşılamıyor; lütfen birazdan tekrar deneyin",
+		InternalError:            "Beklenmeyen bir sunucu hatası oluştu",
+		Maintenance:              "Sistem bakımda; değişiklikler geçici olarak kapalı",
+		Overloaded:               "Sunucu şu anda çok meşgul; lütfen birazdan tekrar deneyin",
+	},
+}
+
+// Localize returns the message for code in the client's preferred language
+// acceptLanguage is the raw Accept-Language header. English, languages without
+// a catalog and codes missing from a catalog all get fallback, the English message.
+// The code itself never changes with the language.
+func Localize(acceptLanguage string, code Code, fallback string) string {
+	for _, lang := range preferredLanguages(acceptLanguage) {
+		if lang == "en" || lang == "*" {
+			return fallback
+		}
+		if message, ok := catalog[lang][code]; ok {
+			return message
+		}
+	}
+	return fallback
+}
+
+// preferredLanguages parses an Accept-Language header such as "tr-TR,tr;q=0.9,en;q=0.8"
+// into primary language subtags ordered by preference; q=0 entries are dropped
+func preferredLanguages(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		// "tr-TR" and "tr" both use the Turkish catalog
+		primary, _, _ := strings.Cut(tag, "-")
+		langs = append(langs, weighted{primary, q})
+	}
+
+	// Stable keeps the header order for equal weights
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+
+	result := make([]string, len(langs))
+	for i, l := range langs {
+		result[i] = l.lang
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package apierror
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestLocalize tests picking a message from Accept-Language
+func TestLocalize(t *testing.T) {
+	const english = "Task not found"
+	turkish := catalog["tr"][TaskNotFound]
+
+	testCases := []struct {
+		name           string
+		acceptLanguage string
+		expected       string
+	}{
+		{"no header", "", english},
+		{"english", "en-US", english},
+		{"turkish", "tr", turkish},
+		{"turkish with region", "tr-TR", turkish},
+		{"case insensitive", "TR-tr", turkish},
+		{"unknown language falls back", "xx", english},
+		{"unknown first, turkish second", "de-DE,tr;q=0.8", turkish},
+		{"english preferred over turkish", "tr;q=0.5,en;q=0.9", english},
+		{"q=0 excludes a language", "tr;q=0,de", english},
+		{"wildcard", "*", english},
+		{"malformed header", ";;,q=", english},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Localize(tc.acceptLanguage, TaskNotFound, english); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestLocalizeUnknownCode tests that codes missing from a catalog use the fallback
+func TestLocalizeUnknownCode(t *testing.T) {
+	if got := Localize("tr", Code("NOT_A_CODE"), "fallback"); got != "fallback" {
+		t.Errorf("Expected fallback, got %q", got)
+	}
+}
+
+// TestCatalogsAreComplete tests that every language translates every code
+func TestCatalogsAreComplete(t *testing.T) {
+	for lang, messages := range catalog {
+		for _, code := range All {
+			if messages[code] == "" {
+				t.Errorf("Catalog %q has no message for %s", lang, code)
+			}
+		}
+	}
+}
+
+// TestPreferredLanguages tests Accept-Language parsing and ordering
+func TestPreferredLanguages(t *testing.T) {
+	got := preferredLanguages("fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5")
+	expected := []string{"fr", "fr", "en", "de", "*"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,126 @@
+// Package cache provides a small in-memory LRU cache with per-entry expiry
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// entry is what the eviction list stores
+type entry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// LRU is a fixed-size, least-recently-used cache that is safe for concurrent use
+// Entries also expire after the TTL, which bounds how stale a value can get
+// when it's changed somewhere the cache can't see (e.g. another instance).
+type LRU[V any] struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List               // Front is the most recently used
+	entries map[string]*list.Element // key -> element in order
+	now     func() time.Time         // Swappable clock for tests
+}
+
+// New creates a cache holding at most size entries, each valid for ttl
+func New[V any](size int, ttl time.Duration) *LRU[V] {
+	return &LRU[V]{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value stored under key, if present and not expired
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+
+	e := elem.Value.(*entry[V])
+	if c.now().After(e.expiresAt) {
+		c.removeElement(elem)
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// Set stores value under key, evicting the least recently used entry when full
+func (c *LRU[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		e := elem.Value.(*entry[V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[V]{key: key, value: value, expiresAt: expiresAt})
+	if c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// GetOrSet returns the value stored under key if present and not expired
+// Otherwise it stores value, valid until expiresAt rather than for the TTL,
+// and returns it with loaded false. Both happen under one lock, so of several
+// concurrent calls for a missing key exactly one stores its value.
+func (c *LRU[V]) GetOrSet(key string, value V, expiresAt time.Time) (actual V, loaded bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		e := elem.Value.(*entry[V])
+		if !c.now().After(e.expiresAt) {
+			c.order.MoveToFront(elem)
+			return e.value, true
+		}
+		c.removeElement(elem)
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[V]{key: key, value: value, expiresAt: expiresAt})
+	if c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+	return value, false
+}
+
+// Delete removes key from the cache (a no-op if it isn't cached)
+func (c *LRU[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Len returns the number of cached entries, including expired ones not yet evicted
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// removeElement drops elem from both the list and the index; the lock must be held
+func (c *LRU[V]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*entry[V]).key)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLRUEviction tests that the least recently used entry is dropped when full
+func TestLRUEviction(t *testing.T) {
+	c := New[int](2, time.Minute)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	// Touch "a" so "b" becomes the least recently used
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("Expected a to be cached")
+	}
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("Expected b to be evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := c.Get(key); !ok || got != want {
+			t.Errorf("Expected %s=%d, got %d (found=%t)", key, want, got, ok)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", c.Len())
+	}
+}
+
+// TestLRUExpiry tests that entries stop being served after the TTL
+func TestLRUExpiry(t *testing.T) {
+	now := time.Now()
+	c := New[string](10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("key", "value")
+	if _, ok := c.Get("key"); !ok {
+		t.Fatalf("Expected key to be cached")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("key"); ok {
+		t.Errorf("Expected key to have expired")
+	}
+	if c.Len() != 0 {
+		t.Errorf("Expected expired entry to be evicted, got %d entries", c.Len())
+	}
+}
+
+// TestLRUDelete tests removing and overwriting entries
+func TestLRUDelete(t *testing.T) {
+	c := New[int](10, time.Minute)
+	c.Set("key", 1)
+	c.Set("key", 2)
+	if got, _ := c.Get("key"); got != 2 {
+		t.Errorf("Expected overwritten value 2, got %d", got)
+	}
+
+	c.Delete("key")
+	c.Delete("missing")
+	if _, ok := c.Get("key"); ok {
+		t.Errorf("Expected key to be deleted")
+	}
+}
+
+// TestLRUGetOrSet tests that only the first value is stored, until it expires
+func TestLRUGetOrSet(t *testing.T) {
+	now := time.Now()
+	c := New[string](10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	if got, loaded := c.GetOrSet("key", "first", now.Add(time.Hour)); loaded || got != "first" {
+		t.Fatalf("Expected first to be stored, got %q (loaded=%t)", got, loaded)
+	}
+	// Valid past the cache's TTL, until its own expiry
+	now = now.Add(30 * time.Minute)
+	if got, loaded := c.GetOrSet("key", "second", now.Add(time.Hour)); !loaded || got != "first" {
+		t.Errorf("Expected first to be kept, got %q (loaded=%t)", got, loaded)
+	}
+
+	now = now.Add(time.Hour)
+	if got, loaded := c.GetOrSet("key", "third", now.Add(time.Hour)); loaded || got != "third" {
+		t.Errorf("Expected the expired value to be replaced, got %q (loaded=%t)", got, loaded)
+	}
+	if c.Len() != 1 {
+		t.Errorf("Expected 1 entry, got %d", c.Len())
+	}
+}
@@ -1,12 +1,57 @@
 package config
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
+	"net/mail"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Soft validation checks that TASK_WARNINGS can enable
+const (
+	TaskWarningPastDueDate    = "past_due_date"   // due_date is already in the past
+	TaskWarningLongTitle      = "long_title"      // title is longer than TASK_WARNING_TITLE_LENGTH
+	TaskWarningDuplicateTitle = "duplicate_title" // the owner has another task with the same title
+)
+
+// Token replay checks (TOKEN_REPLAY_CHECK)
+const (
+	TokenReplayOff   = "off"   // Tokens aren't tracked
+	TokenReplayLog   = "log"   // A token used from a second IP is logged
+	TokenReplayBlock = "block" // ...and refused
+)
+
+// What an update does while another update of the same task is running (TASK_UPDATE_LOCK)
+const (
+	TaskUpdateLockOff    = "off"    // Nothing: both run, the last write wins
+	TaskUpdateLockWait   = "wait"   // It waits for the other one to finish
+	TaskUpdateLockReject = "reject" // It's refused with 409
+)
+
+// Response timestamp formats (TIMESTAMP_FORMAT)
+const (
+	TimestampFormatRFC3339 = "rfc3339" // "2025-06-22T17:30:00Z" strings
+	TimestampFormatUnix    = "unix"    // Integer seconds since the Unix epoch
+)
+
+// TaskSortFields lists the fields task listings can be sorted on (?sort=, DEFAULT_TASK_SORT)
+var TaskSortFields = []string{"created_at", "updated_at", "due_date", "title", "status", "position", "progress", "completed_at"}
+
+// TaskWarningChecks lists every soft validation check; all are enabled by default
+var TaskWarningChecks = []string{TaskWarningPastDueDate, TaskWarningLongTitle, TaskWarningDuplicateTitle}
+
 type Config struct {
 	// Database settings
 	DBHost     string
@@ -15,12 +60,239 @@ type Config struct {
 	DBPassword string
 	DBName     string
 
+	// DBReplicaHost is a read replica that task listings are read from, to
+	// take load off the primary ("" disables it and reads go to DB_HOST).
+	// It shares DB_USER, DB_PASSWORD and DB_NAME with the primary.
+	DBReplicaHost string
+	DBReplicaPort string // Defaults to DB_PORT
+
+	// DBAutoMigrate switches schema management from the versioned SQL
+	// migrations to GORM AutoMigrate (development fallback only)
+	DBAutoMigrate bool
+
+	// DBQueryTimeout bounds how long a request's database queries may run (0 disables it)
+	DBQueryTimeout time.Duration
+
+	// Queries slower than DBSlowQueryThreshold are logged at WARN with their
+	// SQL and duration (0 disables that). Outside production every query is
+	// logged. The SQL keeps its placeholders unless DBLogQueryParams is set,
+	// so values (e.g. personal data in task titles) stay out of the logs.
+	DBSlowQueryThreshold time.Duration
+	DBLogQueryParams     bool
+
+	// DBQueryCount counts the SQL queries each request runs and reports them in
+	// the X-DB-Query-Count header and the request log (never in production)
+	DBQueryCount bool
+
 	// JWT settings
 	JWTSecret string
+	JWTIssuer string // Written to and required in the "iss" claim
+	// JWTMinSecretLength is the shortest JWT_SECRET, in bytes, that tokens
+	// are signed with (0 allows any); the public default secret is shorter.
+	// Defaults to 32 in production and 0 elsewhere (see defaultJWTMinSecretLength)
+	JWTMinSecretLength int
+	// JWTPreviousSecrets are retired secrets whose tokens still validate while
+	// a rotation overlaps; new tokens are always signed with JWTSecret
+	JWTPreviousSecrets []string
+	// TokenReplayCheck remembers the IP each token ("jti") was first used
+	// from and logs or blocks its use from another one (per instance)
+	TokenReplayCheck     string
+	TokenReplayMaxTokens int // How many tokens are remembered at most
+
+	// EncryptionKey encrypts task descriptions at rest: a base64-encoded AES
+	// key of 16, 24 or 32 bytes (empty stores them as plaintext)
+	EncryptionKey string
+
+	// PasswordHistorySize is how many recent passwords (including the current
+	// one) a user can't switch back to (0 allows any password)
+	PasswordHistorySize int
+
+	// PasswordHashAlgorithm hashes new passwords: "argon2id" (default) or "bcrypt"
+	// Existing hashes of either kind keep working and are upgraded on login.
+	PasswordHashAlgorithm string
+
+	// Login lockout: after LoginMaxAttempts wrong passwords in a row the
+	// account is locked for LoginLockoutDuration (0 attempts disables lockout)
+	LoginMaxAttempts     int
+	LoginLockoutDuration time.Duration
 
 	// Server settings
 	Port string
 
+	// TrustedProxies lists the reverse proxies (IPs or CIDR ranges) whose
+	// X-Forwarded-For header is believed when working out a client's IP.
+	// Empty trusts nobody: the connection's own address is used.
+	TrustedProxies []string
+
+	// HTTP server timeouts (protect against slowloris-style connections)
+	ServerReadTimeout       time.Duration // Time to read the whole request, including the body
+	ServerReadHeaderTimeout time.Duration // Time to read the request headers
+	ServerWriteTimeout      time.Duration // Time to write the response
+	ServerIdleTimeout       time.Duration // How long keep-alive connections stay open between requests
+
+	// Requests still running after this get 504 (0 disables the timeout)
+	// Event streams and attachment uploads/downloads aren't limited.
+	RequestTimeout time.Duration
+
+	// Requests slower than this are logged at WARN level (0 logs every request)
+	SlowRequestThreshold time.Duration
+
+	// LogRedactKeys are the keys whose values are masked in every log line
+	// (key=value, "key": "value"); tokens and password hashes always are
+	LogRedactKeys []string
+
+	// Requests served at once; more get 503 right away (0 disables the limit)
+	MaxConcurrentRequests int
+
+	// Tracing: spans are sent to an OpenTelemetry collector over OTLP/HTTP
+	// (no endpoint disables tracing)
+	OTLPEndpoint    string   // Collector base URL, e.g. http://localhost:4318
+	OTLPHeaders     []string // "key=value" headers sent to the collector, e.g. for authentication
+	OTelServiceName string   // service.name reported with every span
+
+	// CORS for browser clients on other origins (no allowed origins disables CORS)
+	CORSAllowedOrigins   []string // Origins like https://app.example.com, or "*" for any
+	CORSExposedHeaders   []string // Response headers scripts may read besides the safelisted ones
+	CORSAllowCredentials bool     // Let browsers send cookies and read responses to credentialed requests
+
+	// HTTPS enforcement behind a TLS-terminating proxy (off by default for local development)
+	ForceHTTPS            bool          // Redirect requests the proxy got over plain HTTP (X-Forwarded-Proto: http) to https://
+	HSTSMaxAge            time.Duration // Strict-Transport-Security max-age sent over HTTPS with ForceHTTPS (0 sends none)
+	HSTSIncludeSubdomains bool          // Extend HSTS to every subdomain
+	HTTPSExemptPaths      []string      // Paths still served over HTTP, e.g. for health probes; a trailing / exempts the subtree
+
+	// JSON request body limits (0 disables a limit)
+	MaxBodySize  int64 // Largest accepted JSON body, in bytes
+	MaxJSONDepth int   // Deepest allowed nesting of arrays/objects
+
+	// Task workflow settings
+	TaskStatuses      []string // Allowed task statuses, in display order
+	DefaultTaskStatus string   // Status given to new tasks that don't specify one
+	TaskTransitions   []string // Allowed "from>to" status changes; empty allows any change
+	TaskReopenStatus  string   // Status POST /api/tasks/{id}/reopen moves completed tasks to; empty uses DefaultTaskStatus
+
+	// ProgressCompletesTask makes setting a task's progress to 100 also
+	// complete it (completing a task always sets its progress to 100)
+	ProgressCompletesTask bool
+
+	// TaskIDFormat is how task IDs appear in responses and URLs: "integer"
+	// (default, the sequential primary key) or "uuid" (each task's random
+	// public ID, so IDs can't be guessed or counted)
+	TaskIDFormat string
+
+	// Named task colors accepted besides #RRGGBB hex values (lowercase letters only)
+	TaskColors []string
+
+	// Task text limits, counted in characters (runes), not bytes (0 disables a limit)
+	MaxTitleLength       int // Longest allowed task title
+	MaxDescriptionLength int // Longest allowed task description
+
+	// Largest allowed task metadata object, in bytes of JSON (0 disables the limit)
+	MaxMetadataSize int
+
+	// Per-user task cap, e.g. for a free tier (0 disables it)
+	MaxTasksPerUser       int
+	TaskLimitCountDeleted bool // Count soft-deleted tasks toward the cap too
+
+	// TaskStatusLimits caps how many of a user's tasks may have a status at
+	// once, e.g. a WIP limit: "status=limit" entries, unlisted statuses are unlimited
+	TaskStatusLimits []string
+
+	// TaskHardDelete makes DELETE /api/tasks/{id} remove tasks for good, with
+	// their history, shares, attachments and checklist, instead of setting deleted_at
+	TaskHardDelete bool
+
+	// TaskUpdateLock serializes concurrent updates of the same task within
+	// this process (see the TaskUpdateLock* constants), so one doesn't
+	// silently overwrite the other
+	TaskUpdateLock string
+
+	// Soft validation: creates and updates still succeed, but the response
+	// lists these non-fatal issues under "warnings"
+	TaskWarnings           []string // Enabled checks (see the TaskWarning* constants)
+	TaskWarningTitleLength int      // long_title warns about titles longer than this, in characters
+
+	// API usage tracking, e.g. for rate plans
+	APIUsageTracking bool   // Count each user's authenticated requests per period
+	APIUsagePeriod   string // Period the count and quota cover: daily or monthly
+	APIQuota         int    // Requests allowed per user and period; more get 429 (0 disables the quota)
+
+	// How timestamps are written in responses: rfc3339 or unix
+	TimestampFormat string
+
+	// Pagination settings for task listings
+	DefaultPageSize int // Page size used when the client doesn't send page_size
+	MaxPageSize     int // Larger page_size values are clamped to this
+
+	// Order of task listings that don't send ?sort=, e.g. -created_at (newest first)
+	DefaultTaskSort string
+
+	// Most tasks GET /api/tasks/board returns per status (0 returns them all)
+	BoardBucketLimit int
+
+	// IANA time zone (e.g. Europe/Istanbul) GET /api/tasks/due computes "today"
+	// and "this week" in when the request has no ?tz=
+	DefaultTimezone string
+
+	// Webhook delivery settings
+	WebhookMaxRetries int           // Extra attempts after a failed delivery (0 disables retries)
+	WebhookTimeout    time.Duration // Time allowed for each delivery attempt
+	// Let webhooks target loopback, private and link-local addresses
+	// (only for development; in production it lets users reach internal services)
+	WebhookAllowPrivateURLs bool
+
+	// Outgoing email, e.g. due-date reminders (no host logs emails instead of sending them)
+	SMTPHost       string // SMTP server host name
+	SMTPPort       int    // 465 for implicit TLS; other ports use STARTTLS when offered
+	SMTPUsername   string // Empty skips authentication
+	SMTPPassword   string
+	SMTPFrom       string // Sender address, e.g. "Tasks <tasks@example.com>"
+	MailMaxRetries int    // Extra attempts after a failed send (0 disables retries)
+
+	// Maintenance mode settings (reloaded on SIGHUP, see ReloadMaintenance)
+	MaintenanceMode       bool          // Reject writes with 503 while reads keep working
+	MaintenanceBlockAuth  bool          // Also reject register/login during maintenance
+	MaintenanceRetryAfter time.Duration // Sent as Retry-After on rejected requests
+
+	// GetTask cache settings (per instance, off by default)
+	TaskCacheEnabled bool          // Cache tasks read by GET /api/tasks/{id}
+	TaskCacheSize    int           // Maximum number of cached tasks
+	TaskCacheTTL     time.Duration // How long a cached task may be served
+
+	// Degraded mode settings (per instance, off by default)
+	DegradedReads     bool          // Serve GET /api/tasks and /api/tasks/{id} from their last response while the database is down
+	DegradedCacheSize int           // Maximum number of responses kept for that
+	DegradedCacheTTL  time.Duration // How old a response may be and still be served
+
+	// Duplicate request collapsing (per instance, off by default)
+	CollapseDuplicateGets bool          // Let concurrent identical GET /api/tasks and /api/tasks/{id} share one response
+	CollapseMaxWait       time.Duration // How long a duplicate waits for the shared response before querying itself
+
+	// How often to log how many updates TASK_UPDATE_LOCK held up or refused
+	// and how many GETs were collapsed, when those counts changed (0 never logs them)
+	DedupStatsInterval time.Duration
+
+	// Pagination snapshot settings (per instance, off by default)
+	PageSnapshotsEnabled bool          // Let GET /api/tasks clients reuse the first page's total via ?snapshot=
+	PageSnapshotTTL      time.Duration // How long a snapshot's total may be reused
+
+	// Task attachment settings
+	AttachmentStorage      string   // Where files are kept: local or s3
+	AttachmentDir          string   // Directory for local storage
+	AttachmentMaxSize      int64    // Largest accepted file, in bytes
+	AttachmentAllowedTypes []string // Accepted content types, e.g. image/png
+
+	// S3-compatible storage settings (used when AttachmentStorage is s3)
+	S3Endpoint        string // Base URL of the object store, e.g. https://s3.eu-central-1.amazonaws.com
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+
+	// Due-date reminder settings
+	ReminderInterval time.Duration // How often to look for tasks that are due soon (0 disables reminders)
+	ReminderWindow   time.Duration // Remind about tasks due within this long from now
+
 	// Environment
 	Env string
 }
@@ -32,22 +304,544 @@ func Load() *Config {
 	}
 
 	config := &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", ""),
-		DBName:     getEnv("DB_NAME", "task_management"),
-		JWTSecret:  getEnv("JWT_SECRET", "default-secret-change-this"),
-		Port:       getEnv("PORT", "8080"),
-		Env:        getEnv("ENV", "development"),
+		DBHost:                  getEnv("DB_HOST", "localhost"),
+		DBPort:                  getEnv("DB_PORT", "5432"),
+		DBUser:                  getEnv("DB_USER", "postgres"),
+		DBPassword:              getEnv("DB_PASSWORD", ""),
+		DBName:                  getEnv("DB_NAME", "task_management"),
+		DBReplicaHost:           getEnv("DB_REPLICA_HOST", ""),
+		DBReplicaPort:           getEnv("DB_REPLICA_PORT", getEnv("DB_PORT", "5432")),
+		DBAutoMigrate:           getEnvBool("DB_AUTO_MIGRATE", false),
+		DBQueryCount:            getEnvBool("DB_QUERY_COUNT", false),
+		DBSlowQueryThreshold:    getEnvDuration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		DBLogQueryParams:        getEnvBool("DB_LOG_QUERY_PARAMS", false),
+		JWTSecret:               getEnv("JWT_SECRET", DefaultJWTSecret),
+		JWTIssuer:               getEnv("JWT_ISSUER", "task-management-api"),
+		JWTMinSecretLength:      getEnvInt("JWT_MIN_SECRET_LENGTH", defaultJWTMinSecretLength(getEnv("ENV", "development"))),
+		JWTPreviousSecrets:      getEnvList("JWT_PREVIOUS_SECRETS", nil),
+		EncryptionKey:           getEnv("ENCRYPTION_KEY", ""),
+		PasswordHistorySize:     getEnvInt("PASSWORD_HISTORY_SIZE", 5),
+		PasswordHashAlgorithm:   getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		LoginMaxAttempts:        getEnvInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginLockoutDuration:    getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		TokenReplayCheck:        getEnv("TOKEN_REPLAY_CHECK", TokenReplayOff),
+		TokenReplayMaxTokens:    getEnvInt("TOKEN_REPLAY_MAX_TOKENS", 100000),
+		Port:                    getEnv("PORT", "8080"),
+		TrustedProxies:          getEnvList("TRUSTED_PROXIES", nil),
+		ServerReadTimeout:       getEnvDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+		ServerReadHeaderTimeout: getEnvDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+		ServerWriteTimeout:      getEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		ServerIdleTimeout:       getEnvDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+		RequestTimeout:          getEnvDuration("REQUEST_TIMEOUT", 20*time.Second),
+		SlowRequestThreshold:    getEnvDuration("SLOW_REQUEST_THRESHOLD", time.Second),
+		LogRedactKeys:           getEnvList("LOG_REDACT_KEYS", []string{"password", "token", "secret", "authorization", "cookie", "api_key"}),
+		MaxConcurrentRequests:   getEnvInt("MAX_CONCURRENT_REQUESTS", 0),
+		OTLPEndpoint:            getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTLPHeaders:             getEnvList("OTEL_EXPORTER_OTLP_HEADERS", nil),
+		OTelServiceName:         getEnv("OTEL_SERVICE_NAME", "task-management-api"),
+		CORSAllowedOrigins:      getEnvList("CORS_ALLOWED_ORIGINS", nil),
+		CORSExposedHeaders:      getEnvList("CORS_EXPOSED_HEADERS", []string{"ETag", "Retry-After"}),
+		CORSAllowCredentials:    getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+		ForceHTTPS:              getEnvBool("FORCE_HTTPS", false),
+		HSTSMaxAge:              getEnvDuration("HSTS_MAX_AGE", 365*24*time.Hour),
+		HSTSIncludeSubdomains:   getEnvBool("HSTS_INCLUDE_SUBDOMAINS", false),
+		HTTPSExemptPaths:        getEnvList("HTTPS_EXEMPT_PATHS", []string{"/health"}),
+		MaxBodySize:             int64(getEnvInt("MAX_BODY_SIZE", 1<<20)),
+		MaxJSONDepth:            getEnvInt("MAX_JSON_DEPTH", 32),
+		TaskStatuses:            getEnvList("TASK_STATUSES", []string{"pending", "in_progress", "completed"}),
+		DefaultTaskStatus:       getEnv("DEFAULT_TASK_STATUS", "pending"),
+		TaskTransitions:         getEnvList("TASK_TRANSITIONS", nil),
+		TaskReopenStatus:        getEnv("TASK_REOPEN_STATUS", ""),
+		ProgressCompletesTask:   getEnvBool("PROGRESS_COMPLETES_TASK", false),
+		TaskIDFormat:            getEnv("TASK_ID_FORMAT", "integer"),
+		TaskColors:              getEnvList("TASK_COLORS", []string{"red", "orange", "yellow", "green", "blue", "purple", "pink", "gray"}),
+		MaxTitleLength:          getEnvInt("MAX_TITLE_LENGTH", 255),
+		MaxDescriptionLength:    getEnvInt("MAX_DESCRIPTION_LENGTH", 10000),
+		MaxMetadataSize:         getEnvInt("MAX_METADATA_SIZE", 16384),
+		MaxTasksPerUser:         getEnvInt("MAX_TASKS_PER_USER", 0),
+		TaskLimitCountDeleted:   getEnvBool("TASK_LIMIT_COUNT_DELETED", false),
+		TaskStatusLimits:        getEnvList("TASK_STATUS_LIMITS", nil),
+		TaskHardDelete:          getEnvBool("TASK_HARD_DELETE", false),
+		TaskUpdateLock:          getEnv("TASK_UPDATE_LOCK", TaskUpdateLockWait),
+		TaskWarnings:            getEnvList("TASK_WARNINGS", slices.Clone(TaskWarningChecks)),
+		TaskWarningTitleLength:  getEnvInt("TASK_WARNING_TITLE_LENGTH", 100),
+		APIUsageTracking:        getEnvBool("API_USAGE_TRACKING", false),
+		APIUsagePeriod:          getEnv("API_USAGE_PERIOD", "monthly"),
+		APIQuota:                getEnvInt("API_QUOTA", 0),
+		TimestampFormat:         getEnv("TIMESTAMP_FORMAT", TimestampFormatRFC3339),
+		DefaultPageSize:         getEnvInt("DEFAULT_PAGE_SIZE", 10),
+		MaxPageSize:             getEnvInt("MAX_PAGE_SIZE", 100),
+		DefaultTaskSort:         getEnv("DEFAULT_TASK_SORT", "-created_at"),
+		BoardBucketLimit:        getEnvInt("BOARD_BUCKET_LIMIT", 50),
+		DefaultTimezone:         getEnv("DEFAULT_TIMEZONE", "UTC"),
+		WebhookMaxRetries:       getEnvInt("WEBHOOK_MAX_RETRIES", 3),
+		WebhookTimeout:          getEnvDuration("WEBHOOK_TIMEOUT", 5*time.Second),
+		WebhookAllowPrivateURLs: getEnvBool("WEBHOOK_ALLOW_PRIVATE_URLS", false),
+		SMTPHost:                getEnv("SMTP_HOST", ""),
+		SMTPPort:                getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:            getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:            getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:                getEnv("SMTP_FROM", ""),
+		MailMaxRetries:          getEnvInt("MAIL_MAX_RETRIES", 3),
+		TaskCacheEnabled:        getEnvBool("TASK_CACHE_ENABLED", false),
+		TaskCacheSize:           getEnvInt("TASK_CACHE_SIZE", 1000),
+		TaskCacheTTL:            getEnvDuration("TASK_CACHE_TTL", 30*time.Second),
+		DegradedReads:           getEnvBool("DEGRADED_READS", false),
+		DegradedCacheSize:       getEnvInt("DEGRADED_CACHE_SIZE", 1000),
+		DegradedCacheTTL:        getEnvDuration("DEGRADED_CACHE_TTL", 5*time.Minute),
+		CollapseDuplicateGets:   getEnvBool("COLLAPSE_DUPLICATE_GETS", false),
+		CollapseMaxWait:         getEnvDuration("COLLAPSE_MAX_WAIT", 5*time.Second),
+		DedupStatsInterval:      getEnvDuration("DEDUP_STATS_INTERVAL", 5*time.Minute),
+		PageSnapshotsEnabled:    getEnvBool("PAGINATION_SNAPSHOTS_ENABLED", false),
+		PageSnapshotTTL:         getEnvDuration("PAGINATION_SNAPSHOT_TTL", time.Minute),
+		AttachmentStorage:       getEnv("ATTACHMENT_STORAGE", "local"),
+		AttachmentDir:           getEnv("ATTACHMENT_DIR", "uploads"),
+		AttachmentMaxSize:       int64(getEnvInt("ATTACHMENT_MAX_SIZE", 10<<20)),
+		AttachmentAllowedTypes:  getEnvList("ATTACHMENT_ALLOWED_TYPES", []string{"image/png", "image/jpeg", "image/gif", "application/pdf", "text/plain"}),
+		S3Endpoint:              getEnv("S3_ENDPOINT", ""),
+		S3Region:                getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:                getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:           getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey:       getEnv("S3_SECRET_ACCESS_KEY", ""),
+		ReminderInterval:        getEnvDuration("REMINDER_INTERVAL", time.Minute),
+		ReminderWindow:          getEnvDuration("REMINDER_WINDOW", 24*time.Hour),
+		Env:                     getEnv("ENV", "development"),
+	}
+
+	// An empty TASK_WARNINGS means the default, so "none" turns every check off
+	if slices.Equal(config.TaskWarnings, []string{"none"}) {
+		config.TaskWarnings = nil
 	}
 
+	config.loadMaintenance()
+
 	return config
 }
 
+// defaultJWTMinSecretLength is JWT_MIN_SECRET_LENGTH when it isn't set
+// Production requires a 32 byte secret; elsewhere any secret goes, so a
+// development setup keeps working with the built-in one (which is logged
+// as a warning).
+func defaultJWTMinSecretLength(env string) int {
+	if env == "production" {
+		return 32
+	}
+	return 0
+}
+
+// loadMaintenance reads the maintenance settings from the environment
+func (c *Config) loadMaintenance() {
+	c.MaintenanceMode = getEnvBool("MAINTENANCE_MODE", false)
+	c.MaintenanceBlockAuth = getEnvBool("MAINTENANCE_BLOCK_AUTH", false)
+	c.MaintenanceRetryAfter = getEnvDuration("MAINTENANCE_RETRY_AFTER", 5*time.Minute)
+}
+
+// ReloadMaintenance re-reads the maintenance settings and swaps them into the current config
+// main calls it on SIGHUP. Values in the .env file take precedence over the
+// process environment here (which can't change after startup), so maintenance
+// can be toggled by editing .env and signalling the process.
+func ReloadMaintenance() error {
+	if err := godotenv.Overload(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	next := *Get()
+	next.loadMaintenance()
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	Set(&next)
+	return nil
+}
+
+// Validate checks settings that can't be corrected with a default
+// main calls it right after Load so a misconfigured deployment fails at startup
+func (c *Config) Validate() error {
+	if c.DefaultPageSize <= 0 {
+		return fmt.Errorf("DEFAULT_PAGE_SIZE must be positive, got %d", c.DefaultPageSize)
+	}
+	if c.MaxPageSize <= 0 {
+		return fmt.Errorf("MAX_PAGE_SIZE must be positive, got %d", c.MaxPageSize)
+	}
+	if c.DefaultPageSize > c.MaxPageSize {
+		return fmt.Errorf("DEFAULT_PAGE_SIZE (%d) cannot be larger than MAX_PAGE_SIZE (%d)", c.DefaultPageSize, c.MaxPageSize)
+	}
+	if c.DefaultTaskSort != "" && !slices.Contains(TaskSortFields, strings.TrimPrefix(c.DefaultTaskSort, "-")) {
+		return fmt.Errorf("DEFAULT_TASK_SORT must be one of %s, optionally prefixed with -, got %q", strings.Join(TaskSortFields, ", "), c.DefaultTaskSort)
+	}
+	if c.BoardBucketLimit < 0 {
+		return fmt.Errorf("BOARD_BUCKET_LIMIT cannot be negative, got %d", c.BoardBucketLimit)
+	}
+	if _, err := time.LoadLocation(c.DefaultTimezone); err != nil || c.DefaultTimezone == "Local" {
+		return fmt.Errorf("DEFAULT_TIMEZONE must be an IANA time zone such as Europe/Istanbul, got %q", c.DefaultTimezone)
+	}
+	for _, color := range c.TaskColors {
+		// Names share the varchar(20) color column with hex values and must not look like one
+		if len(color) == 0 || len(color) > 20 || strings.Trim(color, "abcdefghijklmnopqrstuvwxyz") != "" {
+			return fmt.Errorf("TASK_COLORS: %q must be 1-20 lowercase letters", color)
+		}
+	}
+	if c.MaxTitleLength < 0 {
+		return fmt.Errorf("MAX_TITLE_LENGTH cannot be negative, got %d", c.MaxTitleLength)
+	}
+	if c.MaxDescriptionLength < 0 {
+		return fmt.Errorf("MAX_DESCRIPTION_LENGTH cannot be negative, got %d", c.MaxDescriptionLength)
+	}
+	if c.MaxMetadataSize < 0 {
+		return fmt.Errorf("MAX_METADATA_SIZE cannot be negative, got %d", c.MaxMetadataSize)
+	}
+	for _, proxy := range c.TrustedProxies {
+		_, prefixErr := netip.ParsePrefix(proxy)
+		_, addrErr := netip.ParseAddr(proxy)
+		if prefixErr != nil && addrErr != nil {
+			return fmt.Errorf("TRUSTED_PROXIES: %q is not an IP address or CIDR range", proxy)
+		}
+	}
+	if c.ProgressCompletesTask && !slices.Contains(c.TaskStatuses, "completed") {
+		return fmt.Errorf("PROGRESS_COMPLETES_TASK needs a completed status in TASK_STATUSES")
+	}
+	if c.TaskReopenStatus != "" && !slices.Contains(c.TaskStatuses, c.TaskReopenStatus) {
+		return fmt.Errorf("TASK_REOPEN_STATUS %q is not one of TASK_STATUSES", c.TaskReopenStatus)
+	}
+	switch c.TaskIDFormat {
+	case "", "integer", "uuid":
+	default:
+		return fmt.Errorf("TASK_ID_FORMAT must be integer or uuid, got %q", c.TaskIDFormat)
+	}
+	if c.JWTMinSecretLength < 0 {
+		return fmt.Errorf("JWT_MIN_SECRET_LENGTH cannot be negative, got %d", c.JWTMinSecretLength)
+	}
+	// Tokens couldn't be signed at all, so fail at startup rather than on every login
+	if len(c.JWTSecret) < c.JWTMinSecretLength {
+		return fmt.Errorf("JWT_SECRET must be at least %d bytes (JWT_MIN_SECRET_LENGTH), got %d", c.JWTMinSecretLength, len(c.JWTSecret))
+	}
+	if _, err := c.EncryptionKeyBytes(); err != nil {
+		return err
+	}
+	if c.DBQueryCount && c.Env == "production" {
+		return fmt.Errorf("DB_QUERY_COUNT is a development tool and can't be enabled with ENV=production")
+	}
+	if c.RequestTimeout < 0 {
+		return fmt.Errorf("REQUEST_TIMEOUT cannot be negative, got %s", c.RequestTimeout)
+	}
+	if c.DBSlowQueryThreshold < 0 {
+		return fmt.Errorf("DB_SLOW_QUERY_THRESHOLD cannot be negative, got %s", c.DBSlowQueryThreshold)
+	}
+	if c.SlowRequestThreshold < 0 {
+		return fmt.Errorf("SLOW_REQUEST_THRESHOLD cannot be negative, got %s", c.SlowRequestThreshold)
+	}
+	if c.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("MAX_CONCURRENT_REQUESTS cannot be negative, got %d", c.MaxConcurrentRequests)
+	}
+	if c.OTLPEndpoint != "" {
+		u, err := url.Parse(c.OTLPEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT: %q must be a URL like http://localhost:4318", c.OTLPEndpoint)
+		}
+	}
+	for _, header := range c.OTLPHeaders {
+		if key, _, ok := strings.Cut(header, "="); !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("OTEL_EXPORTER_OTLP_HEADERS: %q must be key=value", header)
+		}
+	}
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin == "*" {
+			// Browsers reject credentialed responses with a wildcard origin
+			if c.CORSAllowCredentials {
+				return fmt.Errorf("CORS_ALLOW_CREDENTIALS=true cannot be used with CORS_ALLOWED_ORIGINS=*: browsers reject credentials for a wildcard origin, so list the allowed origins instead")
+			}
+			continue
+		}
+		// An origin is scheme://host[:port], with no path or trailing slash
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.User != nil {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS: %q must be * or an origin like https://app.example.com", origin)
+		}
+	}
+	if c.HSTSMaxAge < 0 {
+		return fmt.Errorf("HSTS_MAX_AGE cannot be negative, got %s", c.HSTSMaxAge)
+	}
+	for _, path := range c.HTTPSExemptPaths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("HTTPS_EXEMPT_PATHS: %q must be a path starting with /", path)
+		}
+	}
+	if c.MaxBodySize < 0 {
+		return fmt.Errorf("MAX_BODY_SIZE cannot be negative, got %d", c.MaxBodySize)
+	}
+	if c.MaxJSONDepth < 0 {
+		return fmt.Errorf("MAX_JSON_DEPTH cannot be negative, got %d", c.MaxJSONDepth)
+	}
+	if c.MaxTasksPerUser < 0 {
+		return fmt.Errorf("MAX_TASKS_PER_USER cannot be negative, got %d", c.MaxTasksPerUser)
+	}
+	for _, entry := range c.TaskStatusLimits {
+		status, limit, ok := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if !ok || err != nil || n <= 0 {
+			return fmt.Errorf("TASK_STATUS_LIMITS: %q must be status=limit with a positive limit", entry)
+		}
+		if !slices.Contains(c.TaskStatuses, strings.TrimSpace(status)) {
+			return fmt.Errorf("TASK_STATUS_LIMITS: %q is not one of TASK_STATUSES", strings.TrimSpace(status))
+		}
+	}
+	switch c.TaskUpdateLock {
+	case "", TaskUpdateLockOff, TaskUpdateLockWait, TaskUpdateLockReject:
+	default:
+		return fmt.Errorf("TASK_UPDATE_LOCK must be %s, %s or %s, got %q", TaskUpdateLockOff, TaskUpdateLockWait, TaskUpdateLockReject, c.TaskUpdateLock)
+	}
+	for _, check := range c.TaskWarnings {
+		if !slices.Contains(TaskWarningChecks, check) {
+			return fmt.Errorf("TASK_WARNINGS: unknown check %q (use %s)", check, strings.Join(TaskWarningChecks, ", "))
+		}
+	}
+	if c.TaskWarningTitleLength < 0 {
+		return fmt.Errorf("TASK_WARNING_TITLE_LENGTH cannot be negative, got %d", c.TaskWarningTitleLength)
+	}
+	switch c.APIUsagePeriod {
+	case "", "daily", "monthly":
+	default:
+		return fmt.Errorf("API_USAGE_PERIOD must be daily or monthly, got %q", c.APIUsagePeriod)
+	}
+	if c.APIQuota < 0 {
+		return fmt.Errorf("API_QUOTA cannot be negative, got %d", c.APIQuota)
+	}
+	if c.APIQuota > 0 && !c.APIUsageTracking {
+		return fmt.Errorf("API_QUOTA needs API_USAGE_TRACKING=true")
+	}
+	switch c.TimestampFormat {
+	case "", TimestampFormatRFC3339, TimestampFormatUnix:
+	default:
+		return fmt.Errorf("TIMESTAMP_FORMAT must be %s or %s, got %q", TimestampFormatRFC3339, TimestampFormatUnix, c.TimestampFormat)
+	}
+	if c.WebhookMaxRetries < 0 {
+		return fmt.Errorf("WEBHOOK_MAX_RETRIES cannot be negative, got %d", c.WebhookMaxRetries)
+	}
+	if c.SMTPHost != "" {
+		if c.SMTPPort <= 0 || c.SMTPPort > 65535 {
+			return fmt.Errorf("SMTP_PORT must be between 1 and 65535, got %d", c.SMTPPort)
+		}
+		if _, err := mail.ParseAddress(c.SMTPFrom); err != nil {
+			return fmt.Errorf("SMTP_FROM must be an email address when SMTP_HOST is set, got %q", c.SMTPFrom)
+		}
+	}
+	if c.MailMaxRetries < 0 {
+		return fmt.Errorf("MAIL_MAX_RETRIES cannot be negative, got %d", c.MailMaxRetries)
+	}
+	if c.PasswordHistorySize < 0 {
+		return fmt.Errorf("PASSWORD_HISTORY_SIZE cannot be negative, got %d", c.PasswordHistorySize)
+	}
+	switch c.PasswordHashAlgorithm {
+	case "", "argon2id", "bcrypt":
+	default:
+		return fmt.Errorf("PASSWORD_HASH_ALGORITHM must be argon2id or bcrypt, got %q", c.PasswordHashAlgorithm)
+	}
+	if c.LoginMaxAttempts < 0 {
+		return fmt.Errorf("LOGIN_MAX_ATTEMPTS cannot be negative, got %d", c.LoginMaxAttempts)
+	}
+	switch c.TokenReplayCheck {
+	case "", TokenReplayOff:
+	case TokenReplayLog, TokenReplayBlock:
+		if c.TokenReplayMaxTokens <= 0 {
+			return fmt.Errorf("TOKEN_REPLAY_MAX_TOKENS must be positive, got %d", c.TokenReplayMaxTokens)
+		}
+	default:
+		return fmt.Errorf("TOKEN_REPLAY_CHECK must be %s, %s or %s, got %q", TokenReplayOff, TokenReplayLog, TokenReplayBlock, c.TokenReplayCheck)
+	}
+	if c.LoginMaxAttempts > 0 && c.LoginLockoutDuration <= 0 {
+		return fmt.Errorf("LOGIN_LOCKOUT_DURATION must be positive, got %s", c.LoginLockoutDuration)
+	}
+	if c.MaintenanceRetryAfter < 0 {
+		return fmt.Errorf("MAINTENANCE_RETRY_AFTER cannot be negative, got %s", c.MaintenanceRetryAfter)
+	}
+	if c.TaskCacheEnabled && c.TaskCacheSize <= 0 {
+		return fmt.Errorf("TASK_CACHE_SIZE must be positive, got %d", c.TaskCacheSize)
+	}
+	if c.TaskCacheEnabled && c.TaskCacheTTL <= 0 {
+		return fmt.Errorf("TASK_CACHE_TTL must be positive, got %s", c.TaskCacheTTL)
+	}
+	if c.DegradedReads && c.DegradedCacheSize <= 0 {
+		return fmt.Errorf("DEGRADED_CACHE_SIZE must be positive, got %d", c.DegradedCacheSize)
+	}
+	if c.DegradedReads && c.DegradedCacheTTL <= 0 {
+		return fmt.Errorf("DEGRADED_CACHE_TTL must be positive, got %s", c.DegradedCacheTTL)
+	}
+	if c.CollapseDuplicateGets && c.CollapseMaxWait <= 0 {
+		return fmt.Errorf("COLLAPSE_MAX_WAIT must be positive, got %s", c.CollapseMaxWait)
+	}
+	if c.DedupStatsInterval < 0 {
+		return fmt.Errorf("DEDUP_STATS_INTERVAL cannot be negative, got %s", c.DedupStatsInterval)
+	}
+	if c.PageSnapshotsEnabled && c.PageSnapshotTTL <= 0 {
+		return fmt.Errorf("PAGINATION_SNAPSHOT_TTL must be positive, got %s", c.PageSnapshotTTL)
+	}
+	switch c.AttachmentStorage {
+	case "", "local":
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" || c.S3AccessKeyID == "" || c.S3SecretAccessKey == "" {
+			return fmt.Errorf("ATTACHMENT_STORAGE=s3 needs S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY")
+		}
+	default:
+		return fmt.Errorf("ATTACHMENT_STORAGE must be local or s3, got %q", c.AttachmentStorage)
+	}
+	if c.AttachmentMaxSize < 0 {
+		return fmt.Errorf("ATTACHMENT_MAX_SIZE cannot be negative, got %d", c.AttachmentMaxSize)
+	}
+	if c.ReminderInterval < 0 {
+		return fmt.Errorf("REMINDER_INTERVAL cannot be negative, got %s", c.ReminderInterval)
+	}
+	if c.ReminderInterval > 0 && c.ReminderWindow <= 0 {
+		return fmt.Errorf("REMINDER_WINDOW must be positive, got %s", c.ReminderWindow)
+	}
+	return nil
+}
+
+// JWTVerificationSecrets lists the secrets tokens are validated with: the current one first, then the previous ones
+func (c *Config) JWTVerificationSecrets() []string {
+	return append([]string{c.JWTSecret}, c.JWTPreviousSecrets...)
+}
+
+// OTLPHeaderMap returns OTEL_EXPORTER_OTLP_HEADERS as header names and values
+func (c *Config) OTLPHeaderMap() map[string]string {
+	headers := make(map[string]string, len(c.OTLPHeaders))
+	for _, header := range c.OTLPHeaders {
+		key, value, _ := strings.Cut(header, "=")
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers
+}
+
+// EncryptionKeyBytes decodes ENCRYPTION_KEY; nil when it isn't set
+func (c *Config) EncryptionKeyBytes() ([]byte, error) {
+	if c.EncryptionKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(c.EncryptionKey)
+	if err != nil || (len(key) != 16 && len(key) != 24 && len(key) != 32) {
+		return nil, fmt.Errorf("ENCRYPTION_KEY must be a base64-encoded key of 16, 24 or 32 bytes (e.g. openssl rand -base64 32)")
+	}
+	return key, nil
+}
+
+// TaskStatusLimit returns how many of a user's tasks may have status at once
+// (TASK_STATUS_LIMITS), or 0 when the status isn't limited
+func (c *Config) TaskStatusLimit(status string) int {
+	for _, entry := range c.TaskStatusLimits {
+		name, limit, _ := strings.Cut(entry, "=")
+		if strings.TrimSpace(name) == status {
+			n, _ := strconv.Atoi(strings.TrimSpace(limit))
+			return n
+		}
+	}
+	return 0
+}
+
+// TaskWarningEnabled reports whether TASK_WARNINGS enables the given check
+func (c *Config) TaskWarningEnabled(check string) bool {
+	return slices.Contains(c.TaskWarnings, check)
+}
+
+// current holds the configuration the application is running with
+// It is loaded once at startup (see Set) so handlers don't re-read the
+// environment on every request
+var (
+	current   *Config
+	currentMu sync.RWMutex
+)
+
+// Get returns the active configuration, loading it on first use
+func Get() *Config {
+	currentMu.RLock()
+	cfg := current
+	currentMu.RUnlock()
+	if cfg != nil {
+		return cfg
+	}
+
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	if current == nil {
+		current = Load()
+	}
+	return current
+}
+
+// Set replaces the active configuration
+// main calls this once at startup; tests use it to override individual settings
+func Set(cfg *Config) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = cfg
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// getEnvBool reads a boolean environment variable (true/false, 1/0)
+// Unparseable values fall back to the default with a warning
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid value %q for %s, using default %t", value, key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvInt reads an integer environment variable
+// Unparseable values fall back to the default with a warning
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value %q for %s, using default %d", value, key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvList reads a comma-separated environment variable into a slice
+// Surrounding whitespace and empty entries are dropped
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvDuration reads a duration environment variable such as "30s" or "2m"
+// Unparseable or negative values fall back to the default with a warning
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		log.Printf("Invalid value %q for %s, using default %s", value, key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
@@ -0,0 +1,513 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestValidatePageSizes tests the pagination settings checks
+func TestValidatePageSizes(t *testing.T) {
+	testCases := []struct {
+		name            string
+		defaultPageSize int
+		maxPageSize     int
+		expectError     bool
+	}{
+		{"defaults", 10, 100, false},
+		{"default equals max", 50, 50, false},
+		{"zero default", 0, 100, true},
+		{"negative max", 10, -1, true},
+		{"default larger than max", 200, 100, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{DefaultPageSize: tc.defaultPageSize, MaxPageSize: tc.maxPageSize}
+			err := cfg.Validate()
+			if tc.expectError && err == nil {
+				t.Errorf("Expected an error, got nil")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestValidateCORS tests the CORS origin checks
+func TestValidateCORS(t *testing.T) {
+	testCases := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		expectError bool
+	}{
+		{"disabled", nil, false, false},
+		{"origins", []string{"https://app.example.com", "http://localhost:3000"}, true, false},
+		{"wildcard", []string{"*"}, false, false},
+		{"wildcard with credentials", []string{"*"}, true, true},
+		{"wildcard among origins with credentials", []string{"https://app.example.com", "*"}, true, true},
+		{"trailing slash", []string{"https://app.example.com/"}, false, true},
+		{"missing scheme", []string{"app.example.com"}, false, true},
+		{"other scheme", []string{"ftp://app.example.com"}, false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, CORSAllowedOrigins: tc.origins, CORSAllowCredentials: tc.credentials}
+			err := cfg.Validate()
+			if tc.expectError && err == nil {
+				t.Errorf("Expected an error, got nil")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestValidateDefaultTaskSort tests the DEFAULT_TASK_SORT check
+func TestValidateDefaultTaskSort(t *testing.T) {
+	testCases := []struct {
+		name        string
+		sort        string
+		expectError bool
+	}{
+		{"unset", "", false},
+		{"descending", "-created_at", false},
+		{"ascending", "title", false},
+		{"unknown field", "user_id", true},
+		{"doubled prefix", "--due_date", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, DefaultTaskSort: tc.sort}
+			err := cfg.Validate()
+			if tc.expectError && err == nil {
+				t.Errorf("Expected an error, got nil")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestValidateDBQueryCount tests that query counting can't be enabled in production
+func TestValidateDBQueryCount(t *testing.T) {
+	testCases := []struct {
+		name        string
+		env         string
+		enabled     bool
+		expectError bool
+	}{
+		{"development", "development", true, false},
+		{"production", "production", true, true},
+		{"production disabled", "production", false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, Env: tc.env, DBQueryCount: tc.enabled}
+			err := cfg.Validate()
+			if tc.expectError && err == nil {
+				t.Errorf("Expected an error, got nil")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestValidateTaskReopenStatus tests that the reopen status must be one of the task statuses
+func TestValidateTaskReopenStatus(t *testing.T) {
+	statuses := []string{"pending", "in_progress", "completed"}
+	for _, status := range []string{"", "pending", "in_progress"} {
+		cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, TaskStatuses: statuses, TaskReopenStatus: status}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", status, err)
+		}
+	}
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, TaskStatuses: statuses, TaskReopenStatus: "reopened"}
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected an unknown status to be rejected")
+	}
+}
+
+// TestValidateTaskIDFormat tests that TASK_ID_FORMAT only takes known formats
+func TestValidateTaskIDFormat(t *testing.T) {
+	for _, format := range []string{"", "integer", "uuid"} {
+		cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, TaskIDFormat: format}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", format, err)
+		}
+	}
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, TaskIDFormat: "ulid"}
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected an unknown format to be rejected")
+	}
+}
+
+// TestValidateOTLP tests the tracing exporter settings
+func TestValidateOTLP(t *testing.T) {
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, OTLPEndpoint: "http://localhost:4318", OTLPHeaders: []string{"Authorization=Bearer abc=="}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid tracing settings, got %v", err)
+	}
+	if got := cfg.OTLPHeaderMap()["Authorization"]; got != "Bearer abc==" {
+		t.Errorf("Expected the header value to keep its = signs, got %q", got)
+	}
+
+	for _, endpoint := range []string{"localhost:4318", "ftp://collector", "http://"} {
+		cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, OTLPEndpoint: endpoint}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected endpoint %q to be rejected", endpoint)
+		}
+	}
+	cfg = &Config{DefaultPageSize: 10, MaxPageSize: 100, OTLPEndpoint: "http://localhost:4318", OTLPHeaders: []string{"api-key"}}
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected a header without a value to be rejected")
+	}
+}
+
+// TestValidateSMTP tests the outgoing email settings
+func TestValidateSMTP(t *testing.T) {
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, SMTPHost: "smtp.example.com", SMTPPort: 587, SMTPFrom: "Tasks <tasks@example.com>"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid SMTP settings, got %v", err)
+	}
+
+	cfg.SMTPFrom = ""
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected SMTP_HOST without SMTP_FROM to be rejected")
+	}
+	cfg.SMTPFrom = "tasks@example.com"
+	cfg.SMTPPort = 0
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected an invalid SMTP_PORT to be rejected")
+	}
+
+	// Without a host nothing is sent, so the other settings don't matter
+	cfg = &Config{DefaultPageSize: 10, MaxPageSize: 100}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected no SMTP settings to be valid, got %v", err)
+	}
+}
+
+// TestValidateEncryptionKey tests the accepted ENCRYPTION_KEY values
+func TestValidateEncryptionKey(t *testing.T) {
+	testCases := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{"not set", "", true},
+		{"AES-256", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=", true},
+		{"AES-128", "MDEyMzQ1Njc4OWFiY2RlZg==", true},
+		{"wrong length", "c2hvcnQ=", false},
+		{"not base64", "not base64!", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, EncryptionKey: tc.key}
+			if err := cfg.Validate(); (err == nil) != tc.valid {
+				t.Errorf("Expected valid=%t, got %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+// TestValidateHTTPS tests the HTTPS enforcement settings
+func TestValidateHTTPS(t *testing.T) {
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, ForceHTTPS: true, HSTSMaxAge: time.Hour, HTTPSExemptPaths: []string{"/health", "/internal/"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid HTTPS settings, got %v", err)
+	}
+
+	cfg.HTTPSExemptPaths = []string{"health"}
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected an exempt path without a leading / to be rejected")
+	}
+	cfg.HTTPSExemptPaths = nil
+	cfg.HSTSMaxAge = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected a negative HSTS_MAX_AGE to be rejected")
+	}
+}
+
+// TestTaskStatusLimits tests parsing and validating TASK_STATUS_LIMITS
+func TestTaskStatusLimits(t *testing.T) {
+	statuses := []string{"pending", "in_progress", "completed"}
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, TaskStatuses: statuses, TaskStatusLimits: []string{"in_progress=3", " pending = 10 "}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid limits, got %v", err)
+	}
+	for status, expected := range map[string]int{"in_progress": 3, "pending": 10, "completed": 0} {
+		if got := cfg.TaskStatusLimit(status); got != expected {
+			t.Errorf("Expected limit %d for %s, got %d", expected, status, got)
+		}
+	}
+
+	for _, limits := range [][]string{{"in_progress"}, {"in_progress=0"}, {"in_progress=lots"}, {"review=2"}} {
+		cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, TaskStatuses: statuses, TaskStatusLimits: limits}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected %v to be rejected", limits)
+		}
+	}
+}
+
+// TestValidateDegradedReads tests the checks on the degraded mode cache
+func TestValidateDegradedReads(t *testing.T) {
+	valid := &Config{DefaultPageSize: 10, MaxPageSize: 100, DegradedReads: true, DegradedCacheSize: 10, DegradedCacheTTL: time.Minute}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Expected valid degraded mode settings, got %v", err)
+	}
+
+	for _, cfg := range []*Config{
+		{DefaultPageSize: 10, MaxPageSize: 100, DegradedReads: true, DegradedCacheSize: 0, DegradedCacheTTL: time.Minute},
+		{DefaultPageSize: 10, MaxPageSize: 100, DegradedReads: true, DegradedCacheSize: 10, DegradedCacheTTL: 0},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected size %d and TTL %s to be rejected", cfg.DegradedCacheSize, cfg.DegradedCacheTTL)
+		}
+	}
+
+	// Unused settings aren't checked while degraded reads are off
+	off := &Config{DefaultPageSize: 10, MaxPageSize: 100}
+	if err := off.Validate(); err != nil {
+		t.Errorf("Expected degraded reads off to be valid, got %v", err)
+	}
+}
+
+// TestValidateProgressCompletesTask tests that completing by progress needs a completed status
+func TestValidateProgressCompletesTask(t *testing.T) {
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, ProgressCompletesTask: true, TaskStatuses: []string{"pending", "completed"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid settings, got %v", err)
+	}
+
+	cfg.TaskStatuses = []string{"todo", "done"}
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected PROGRESS_COMPLETES_TASK without a completed status to be rejected")
+	}
+}
+
+// TestValidateCollapseMaxWait tests that duplicates can't be made to wait forever
+func TestValidateCollapseMaxWait(t *testing.T) {
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, CollapseDuplicateGets: true, CollapseMaxWait: time.Second}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid settings, got %v", err)
+	}
+
+	cfg.CollapseMaxWait = 0
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected COLLAPSE_MAX_WAIT=0 to be rejected")
+	}
+
+	cfg.CollapseDuplicateGets = false
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected COLLAPSE_MAX_WAIT to be ignored while collapsing is off, got %v", err)
+	}
+}
+
+// TestValidateTokenReplayCheck tests the replay check modes and their memory cap
+func TestValidateTokenReplayCheck(t *testing.T) {
+	for _, mode := range []string{"", TokenReplayOff, TokenReplayLog, TokenReplayBlock} {
+		cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, TokenReplayCheck: mode, TokenReplayMaxTokens: 10}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected TOKEN_REPLAY_CHECK=%q to be valid, got %v", mode, err)
+		}
+	}
+
+	invalid := []*Config{
+		{DefaultPageSize: 10, MaxPageSize: 100, TokenReplayCheck: "warn", TokenReplayMaxTokens: 10},
+		{DefaultPageSize: 10, MaxPageSize: 100, TokenReplayCheck: TokenReplayBlock, TokenReplayMaxTokens: 0},
+	}
+	for _, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected mode %q with %d tokens to be rejected", cfg.TokenReplayCheck, cfg.TokenReplayMaxTokens)
+		}
+	}
+
+	// The cap doesn't matter while the check is off
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, TokenReplayCheck: TokenReplayOff}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected TOKEN_REPLAY_MAX_TOKENS to be ignored while the check is off, got %v", err)
+	}
+}
+
+// TestValidateTaskUpdateLock tests the concurrent update modes
+func TestValidateTaskUpdateLock(t *testing.T) {
+	for _, mode := range []string{"", TaskUpdateLockOff, TaskUpdateLockWait, TaskUpdateLockReject} {
+		cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, TaskUpdateLock: mode}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected TASK_UPDATE_LOCK=%q to be valid, got %v", mode, err)
+		}
+	}
+
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, TaskUpdateLock: "queue"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an unknown TASK_UPDATE_LOCK to be rejected")
+	}
+}
+
+// TestValidateDefaultTimezone tests that DEFAULT_TIMEZONE must be a known zone
+func TestValidateDefaultTimezone(t *testing.T) {
+	for _, tz := range []string{"", "UTC", "Europe/Istanbul", "America/New_York"} {
+		cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, DefaultTimezone: tz}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected DEFAULT_TIMEZONE=%q to be valid, got %v", tz, err)
+		}
+	}
+	// Local depends on the server, which is what the setting is there to avoid
+	for _, tz := range []string{"Mars/Olympus", "Local", "+03:00"} {
+		cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, DefaultTimezone: tz}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected DEFAULT_TIMEZONE=%q to be rejected", tz)
+		}
+	}
+}
+
+// TestValidateDBSlowQueryThreshold tests that the slow query threshold can't be negative
+func TestValidateDBSlowQueryThreshold(t *testing.T) {
+	for _, threshold := range []time.Duration{0, 200 * time.Millisecond} {
+		cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, DBSlowQueryThreshold: threshold}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected DB_SLOW_QUERY_THRESHOLD=%s to be valid, got %v", threshold, err)
+		}
+	}
+
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, DBSlowQueryThreshold: -time.Second}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected a negative DB_SLOW_QUERY_THRESHOLD to be rejected")
+	}
+}
+
+// TestValidateJWTMinSecretLength tests that a JWT_SECRET too short to sign with is rejected at startup
+func TestValidateJWTMinSecretLength(t *testing.T) {
+	cfg := &Config{DefaultPageSize: 10, MaxPageSize: 100, JWTSecret: strings.Repeat("x", 32), JWTMinSecretLength: 32}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected a 32 byte secret to be valid, got %v", err)
+	}
+
+	cfg.JWTSecret = DefaultJWTSecret
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected the default secret to be rejected")
+	}
+
+	cfg.JWTMinSecretLength = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected any secret without a minimum, got %v", err)
+	}
+
+	cfg.JWTMinSecretLength = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected a negative JWT_MIN_SECRET_LENGTH to be rejected")
+	}
+}
+
+// TestLoadJWTMinSecretLength tests that only production requires a long
+// JWT_SECRET by default, so development starts with the built-in one
+func TestLoadJWTMinSecretLength(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_MIN_SECRET_LENGTH", "")
+
+	t.Setenv("ENV", "development")
+	cfg := Load()
+	if cfg.JWTMinSecretLength != 0 || cfg.JWTSecret != DefaultJWTSecret {
+		t.Fatalf("Expected no minimum and the default secret, got %d and %q", cfg.JWTMinSecretLength, cfg.JWTSecret)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected the default secret to be accepted in development, got %v", err)
+	}
+
+	t.Setenv("ENV", "production")
+	cfg = Load()
+	if cfg.JWTMinSecretLength != 32 {
+		t.Errorf("Expected a 32 byte minimum in production, got %d", cfg.JWTMinSecretLength)
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "JWT_SECRET") {
+		t.Errorf("Expected the default secret to be rejected in production, got %v", err)
+	}
+
+	// Setting it explicitly wins in any environment
+	t.Setenv("JWT_MIN_SECRET_LENGTH", "0")
+	if cfg := Load(); cfg.JWTMinSecretLength != 0 {
+		t.Errorf("Expected JWT_MIN_SECRET_LENGTH=0 to be kept, got %d", cfg.JWTMinSecretLength)
+	}
+}
+
+// TestLogEffective tests that every setting is logged with secrets masked
+func TestLogEffective(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	cfg := &Config{
+		DBHost:             "db.internal",
+		DBPassword:         "hunter2",
+		JWTSecret:          DefaultJWTSecret,
+		JWTPreviousSecrets: []string{"old-secret"},
+		OTLPHeaders:        []string{"Authorization=Bearer abc"},
+		RequestTimeout:     20 * time.Second,
+	}
+	cfg.LogEffective()
+
+	output := buf.String()
+	for _, secret := range []string{"hunter2", DefaultJWTSecret, "old-secret", "Bearer abc"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("Expected %q to be masked in:\n%s", secret, output)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected the configuration and a default secret warning, got:\n%s", output)
+	}
+	var logged map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &logged); err != nil {
+		t.Fatalf("Expected JSON output: %v", err)
+	}
+	expected := map[string]any{
+		"DBHost":         "db.internal",
+		"DBPassword":     "[REDACTED]",
+		"SMTPPassword":   "", // Not set, so nothing to hide
+		"RequestTimeout": float64(20 * time.Second),
+	}
+	for key, value := range expected {
+		if logged[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, logged[key])
+		}
+	}
+	if headers, _ := logged["OTLPHeaders"].([]any); len(headers) != 1 || headers[0] != "Authorization=[REDACTED]" {
+		t.Errorf("Expected header names without values, got %v", logged["OTLPHeaders"])
+	}
+	if !strings.Contains(lines[1], `"level":"WARN"`) {
+		t.Errorf("Expected a warning about the default JWT secret, got %s", lines[1])
+	}
+}
+
+// TestGetEnvInt tests reading integer settings from the environment
+func TestGetEnvInt(t *testing.T) {
+	t.Setenv("TEST_PAGE_SIZE", "25")
+	if got := getEnvInt("TEST_PAGE_SIZE", 10); got != 25 {
+		t.Errorf("Expected 25, got %d", got)
+	}
+
+	t.Setenv("TEST_PAGE_SIZE", "lots")
+	if got := getEnvInt("TEST_PAGE_SIZE", 10); got != 10 {
+		t.Errorf("Expected default 10 for an invalid value, got %d", got)
+	}
+
+	if got := getEnvInt("TEST_PAGE_SIZE_UNSET", 10); got != 10 {
+		t.Errorf("Expected default 10 for an unset variable, got %d", got)
+	}
+}
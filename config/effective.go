@@ -0,0 +1,83 @@
+package config
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// DefaultJWTSecret is the JWT_SECRET used when none is set; it's public, so
+// tokens signed with it can be forged by anyone
+const DefaultJWTSecret = "default-secret-change-this"
+
+// redacted stands in for a secret that is set
+const redacted = "[REDACTED]"
+
+// secretFields are the settings LogEffective never prints
+// Values are replaced with redacted when set and left empty when not, so the
+// log still shows whether they were picked up.
+var secretFields = map[string]bool{
+	"DBPassword":         true,
+	"JWTSecret":          true,
+	"JWTPreviousSecrets": true,
+	"EncryptionKey":      true,
+	"SMTPPassword":       true,
+	"S3AccessKeyID":      true,
+	"S3SecretAccessKey":  true,
+}
+
+// LogEffective logs every setting in one structured line, for checking at
+// startup which values are in effect
+// Secrets are masked, and so are the values of OTEL_EXPORTER_OTLP_HEADERS,
+// which usually carry credentials; only the header names are shown. Using
+// the built-in JWT secret is logged as a warning of its own.
+func (c *Config) LogEffective() {
+	slog.Info("Effective configuration", c.effectiveAttrs()...)
+
+	if c.JWTSecret == DefaultJWTSecret {
+		slog.Warn("JWT_SECRET is not set: tokens are signed with the public default secret, so anyone can forge them")
+	}
+}
+
+// effectiveAttrs returns one attribute per field, named after it, with
+// secrets masked
+func (c *Config) effectiveAttrs() []any {
+	value := reflect.ValueOf(c).Elem()
+	fields := value.Type()
+
+	attrs := make([]any, 0, fields.NumField())
+	for i := 0; i < fields.NumField(); i++ {
+		name := fields.Field(i).Name
+		field := value.Field(i)
+
+		switch {
+		case secretFields[name]:
+			attrs = append(attrs, slog.Any(name, maskSecret(field)))
+		case name == "OTLPHeaders":
+			names := make([]string, 0, len(c.OTLPHeaders))
+			for _, header := range c.OTLPHeaders {
+				key, _, _ := strings.Cut(header, "=")
+				names = append(names, strings.TrimSpace(key)+"="+redacted)
+			}
+			attrs = append(attrs, slog.Any(name, names))
+		default:
+			attrs = append(attrs, slog.Any(name, field.Interface()))
+		}
+	}
+	return attrs
+}
+
+// maskSecret replaces a set secret (or each one in a list) with redacted
+func maskSecret(field reflect.Value) any {
+	if field.Kind() == reflect.Slice {
+		masked := make([]string, field.Len())
+		for i := range masked {
+			masked[i] = redacted
+		}
+		return masked
+	}
+	if field.IsZero() {
+		return ""
+	}
+	return redacted
+}
@@ -1,8 +1,14 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 
 	"github.com/kcansari/task-management-api/config"
 	"gorm.io/driver/postgres"
@@ -12,36 +18,103 @@ import (
 
 var DB *gorm.DB
 
+// Replica is the read replica connection (DB_REPLICA_HOST), or nil when none
+// is configured. Only reads that can tolerate replication lag go to it; see
+// ReadWithContext.
+var Replica *gorm.DB
+
 func Connect(cfg *config.Config) error {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+	// Queries are logged through slog, and so redacted like every other log (LOG_REDACT_KEYS)
+	sqlLog := newSQLLogger(cfg.Env, cfg.DBSlowQueryThreshold, cfg.DBLogQueryParams)
 
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
-
+	DB, err = open(cfg, cfg.DBHost, cfg.DBPort, sqlLog)
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
+	log.Println("Database connection established successfully")
+
+	if cfg.DBReplicaHost != "" {
+		Replica, err = open(cfg, cfg.DBReplicaHost, cfg.DBReplicaPort, sqlLog)
+		if err != nil {
+			return fmt.Errorf("failed to connect to read replica: %w", err)
+		}
+		log.Printf("Read replica connection established (%s:%s)", cfg.DBReplicaHost, cfg.DBReplicaPort)
+	}
+	return nil
+}
+
+// open connects to the database server at host:port with the configured
+// credentials and pool settings
+func open(cfg *config.Config, host, port string, sqlLog logger.Interface) (*gorm.DB, error) {
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		host, port, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: sqlLog})
+	if err != nil {
+		return nil, err
+	}
 
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
 	sqlDB.SetMaxIdleConns(10)
 	sqlDB.SetMaxOpenConns(100)
-
-	log.Println("Database connection established successfully")
-	return nil
+	return db, nil
 }
 
 func GetDB() *gorm.DB {
 	return DB
 }
 
+// Connections returns the primary connection and, when one is configured,
+// the read replica's, e.g. to register callbacks on both
+func Connections() []*gorm.DB {
+	if Replica != nil {
+		return []*gorm.DB{DB, Replica}
+	}
+	return []*gorm.DB{DB}
+}
+
+// dbContextKey is the context key for a DB handle scoped to one request
+type dbContextKey struct{}
+
+// ContextWithDB returns a copy of ctx that carries db
+// WithContext prefers this handle over the global connection, which lets
+// tests run every request of a test inside their own rolled-back transaction
+func ContextWithDB(ctx context.Context, db *gorm.DB) context.Context {
+	return context.WithValue(ctx, dbContextKey{}, db)
+}
+
+// WithContext returns a DB session bound to ctx
+// Queries run through it are cancelled when ctx is done
+func WithContext(ctx context.Context) *gorm.DB {
+	if db, ok := ctx.Value(dbContextKey{}).(*gorm.DB); ok {
+		return db.WithContext(ctx)
+	}
+	return DB.WithContext(ctx)
+}
+
+// ReadWithContext is WithContext for reads that may go to the read replica
+// The replica lags behind the primary, so a row written a moment ago may not
+// be there yet: only use it for reads where that's acceptable, never to read
+// back what the same request just wrote. Without a replica, and with a handle
+// in ctx (see ContextWithDB), it's the same as WithContext.
+func ReadWithContext(ctx context.Context) *gorm.DB {
+	if _, ok := ctx.Value(dbContextKey{}).(*gorm.DB); ok || Replica == nil {
+		return WithContext(ctx)
+	}
+	return Replica.WithContext(ctx)
+}
+
 func Close() error {
+	if Replica != nil {
+		if sqlDB, err := Replica.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
 	if DB != nil {
 		sqlDB, err := DB.DB()
 		if err != nil {
@@ -52,19 +125,42 @@ func Close() error {
 	return nil
 }
 
+// HealthCheck pings the primary and, when one is configured, the read replica
 func HealthCheck() error {
 	if DB == nil {
 		return fmt.Errorf("database connection is nil")
 	}
+	if err := ping(DB); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	if Replica != nil {
+		if err := ping(Replica); err != nil {
+			return fmt.Errorf("read replica ping failed: %w", err)
+		}
+	}
+	return nil
+}
 
-	sqlDB, err := DB.DB()
+func ping(db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
 		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
+	return sqlDB.Ping()
+}
 
-	if err := sqlDB.Ping(); err != nil {
-		return fmt.Errorf("database ping failed: %w", err)
+// IsUnavailable reports whether err means the database couldn't be reached,
+// rather than a query failing on its own: the connection was refused, dropped
+// or broken at the network level
+// Errors from the request's context ending (timeouts, cancellations) don't
+// count, even though context.DeadlineExceeded looks like a network timeout.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
 	}
-
-	return nil
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
 }
@@ -11,7 +11,12 @@ func Initialize(cfg *config.Config) error {
 		return err
 	}
 
-	if err := RunMigrations(); err != nil {
+	// Versioned migrations are the default; AutoMigrate is kept as a dev fallback
+	migrate := RunMigrations
+	if cfg.DBAutoMigrate {
+		migrate = AutoMigrate
+	}
+	if err := migrate(); err != nil {
 		return err
 	}
 
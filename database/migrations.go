@@ -1,23 +1,248 @@
 package database
 
 import (
+	"embed"
 	"fmt"
 	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
 )
 
+// migrationFiles holds the numbered SQL scripts shipped with the binary
+// Each schema change is a pair of files: NNNN_name.up.sql and NNNN_name.down.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the key used with pg_advisory_xact_lock so that several
+// replicas starting at the same time don't apply the same migration twice
+const migrationLockID = 7321004
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int    // Sequence number taken from the file name prefix
+	Name    string // Human-readable part of the file name
+	Up      string // SQL applied when migrating forward
+	Down    string // SQL applied when rolling back
+}
+
+// SchemaMigration records a migration that has been applied to the database
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName keeps the conventional name used by most migration tools
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// LoadMigrations reads the embedded SQL files and returns them ordered by version
+func LoadMigrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		// Expected format: 0002_add_something.up.sql
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("unexpected migration file %q", fileName)
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		parts := strings.SplitN(base, "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("migration file %q must be named NNNN_name.%s.sql", fileName, direction)
+		}
+
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file %q has an invalid version", fileName)
+		}
+
+		contents, err := migrationFiles.ReadFile(path.Join("migrations", fileName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", fileName, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		} else if m.Name != parts[1] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, m.Name, parts[1])
+		}
+
+		if direction == "up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// RunMigrations applies every migration that hasn't been recorded in schema_migrations yet
+// Each migration runs in its own transaction together with its bookkeeping row,
+// so a failing script leaves the database at the previous version
 func RunMigrations() error {
 	if DB == nil {
 		return fmt.Errorf("database connection is not initialized")
 	}
 
 	log.Println("Running database migrations...")
-	
-	if err := DB.AutoMigrate(&models.User{}, &models.Task{}); err != nil {
+
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+
+	if err := DB.AutoMigrate(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		ran := false
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+
+			// Re-check under the lock in case another instance just applied it
+			var count int64
+			if err := tx.Model(&SchemaMigration{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+
+			log.Printf("Applying migration %04d_%s", m.Version, m.Name)
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+
+			ran = true
+			return tx.Create(&SchemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		if ran {
+			applied++
+		}
+	}
+
+	log.Printf("Database migrations completed successfully (%d applied)", applied)
+	return nil
+}
+
+// RollbackMigration reverts the most recently applied migration
+func RollbackMigration() error {
+	if DB == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+
+	var last SchemaMigration
+	if err := DB.Order("version DESC").First(&last).Error; err != nil {
+		return fmt.Errorf("no applied migrations to roll back: %w", err)
+	}
+
+	var target *Migration
+	for i := range migrations {
+		if migrations[i].Version == last.Version {
+			target = &migrations[i]
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("applied migration %d has no matching script", last.Version)
+	}
+
+	log.Printf("Rolling back migration %04d_%s", target.Version, target.Name)
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(target.Down).Error; err != nil {
+			return fmt.Errorf("failed to roll back migration %04d_%s: %w", target.Version, target.Name, err)
+		}
+		return tx.Delete(&SchemaMigration{}, "version = ?", target.Version).Error
+	})
+}
+
+// AutoMigrate syncs the schema straight from the GORM models
+// This is a development fallback (DB_AUTO_MIGRATE=true): it can't drop or rename
+// columns and keeps no history, so the versioned migrations are the source of truth
+func AutoMigrate() error {
+	if DB == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	log.Println("Running GORM AutoMigrate (development fallback)...")
+
+	if err := DB.AutoMigrate(
+		&models.Organization{},
+		&models.User{},
+		&models.Task{},
+		&models.TaskStatusHistory{},
+		&models.Webhook{},
+		&models.TaskShare{},
+		&models.PasswordHistory{},
+		&models.Attachment{},
+		&models.UserSettings{},
+		&models.APIUsage{},
+		&models.ChecklistItem{},
+		&models.TimeEntry{},
+		&models.Comment{},
+		&models.Notification{},
+	); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
-	
+
+	// Migration 0008 creates the default organization; do the same here so
+	// registrations don't race to create it
+	if _, err := models.DefaultOrganization(DB); err != nil {
+		return fmt.Errorf("failed to create default organization: %w", err)
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
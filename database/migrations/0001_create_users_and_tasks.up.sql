@@ -0,0 +1,27 @@
+-- Baseline schema. Uses IF NOT EXISTS so databases previously created by
+-- GORM AutoMigrate can adopt the versioned migrations without changes.
+CREATE TABLE IF NOT EXISTS users (
+    id         BIGSERIAL PRIMARY KEY,
+    email      TEXT NOT NULL,
+    password   TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id          BIGSERIAL PRIMARY KEY,
+    title       TEXT NOT NULL,
+    description TEXT,
+    status      VARCHAR(20) DEFAULT 'pending',
+    user_id     BIGINT NOT NULL,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ,
+    deleted_at  TIMESTAMPTZ,
+    CONSTRAINT fk_users_tasks FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks (deleted_at);
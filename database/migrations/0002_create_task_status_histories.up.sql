@@ -0,0 +1,10 @@
+CREATE TABLE task_status_histories (
+    id          BIGSERIAL PRIMARY KEY,
+    task_id     BIGINT NOT NULL REFERENCES tasks (id),
+    from_status VARCHAR(20) NOT NULL,
+    to_status   VARCHAR(20) NOT NULL,
+    user_id     BIGINT NOT NULL REFERENCES users (id),
+    created_at  TIMESTAMPTZ
+);
+
+CREATE INDEX idx_task_status_histories_task_id ON task_status_histories (task_id);
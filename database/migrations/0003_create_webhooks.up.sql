@@ -0,0 +1,13 @@
+CREATE TABLE webhooks (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users (id),
+    url        TEXT NOT NULL,
+    secret     TEXT NOT NULL,
+    events     VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webhooks_user_id ON webhooks (user_id);
+CREATE INDEX idx_webhooks_deleted_at ON webhooks (deleted_at);
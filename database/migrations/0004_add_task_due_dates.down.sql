@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_due_reminders;
+ALTER TABLE tasks DROP COLUMN IF EXISTS reminder_sent;
+ALTER TABLE tasks DROP COLUMN IF EXISTS due_date;
@@ -0,0 +1,5 @@
+ALTER TABLE tasks ADD COLUMN due_date TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN reminder_sent BOOLEAN NOT NULL DEFAULT false;
+
+-- The reminder scheduler only ever looks at tasks still waiting for a reminder
+CREATE INDEX idx_tasks_due_reminders ON tasks (due_date) WHERE reminder_sent = false AND deleted_at IS NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE task_shares (
+    id                  BIGSERIAL PRIMARY KEY,
+    task_id             BIGINT NOT NULL REFERENCES tasks (id),
+    shared_with_user_id BIGINT NOT NULL REFERENCES users (id),
+    permission          VARCHAR(10) NOT NULL,
+    created_at          TIMESTAMPTZ,
+    updated_at          TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_task_shares_task_user ON task_shares (task_id, shared_with_user_id);
+CREATE INDEX idx_task_shares_shared_with_user_id ON task_shares (shared_with_user_id);
@@ -0,0 +1,8 @@
+CREATE TABLE password_histories (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES users (id),
+    password_hash TEXT NOT NULL,
+    created_at    TIMESTAMPTZ
+);
+
+CREATE INDEX idx_password_histories_user_id ON password_histories (user_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tasks_org_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS org_id;
+DROP INDEX IF EXISTS idx_users_org_id;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+ALTER TABLE users DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,23 @@
+CREATE TABLE organizations (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_organizations_name ON organizations (name);
+
+-- Everyone registered so far becomes a member of the default organization
+INSERT INTO organizations (name, created_at, updated_at) VALUES ('Default', NOW(), NOW());
+
+ALTER TABLE users ADD COLUMN org_id BIGINT REFERENCES organizations (id);
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'member';
+UPDATE users SET org_id = (SELECT id FROM organizations WHERE name = 'Default');
+ALTER TABLE users ALTER COLUMN org_id SET NOT NULL;
+CREATE INDEX idx_users_org_id ON users (org_id);
+
+-- Tasks follow their owner (including owners that were soft-deleted)
+ALTER TABLE tasks ADD COLUMN org_id BIGINT REFERENCES organizations (id);
+UPDATE tasks SET org_id = users.org_id FROM users WHERE tasks.user_id = users.id;
+ALTER TABLE tasks ALTER COLUMN org_id SET NOT NULL;
+CREATE INDEX idx_tasks_org_id ON tasks (org_id);
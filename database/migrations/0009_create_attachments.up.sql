@@ -0,0 +1,12 @@
+CREATE TABLE attachments (
+    id           BIGSERIAL PRIMARY KEY,
+    task_id      BIGINT NOT NULL REFERENCES tasks (id),
+    filename     TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size         BIGINT NOT NULL,
+    storage_key  TEXT NOT NULL,
+    created_at   TIMESTAMPTZ
+);
+
+CREATE INDEX idx_attachments_task_id ON attachments (task_id);
+CREATE UNIQUE INDEX idx_attachments_storage_key ON attachments (storage_key);
@@ -0,0 +1,6 @@
+CREATE TABLE user_settings (
+    user_id             BIGINT PRIMARY KEY REFERENCES users (id),
+    default_task_status VARCHAR(20),
+    created_at          TIMESTAMPTZ,
+    updated_at          TIMESTAMPTZ
+);
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN last_login_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN last_login_ip VARCHAR(45);
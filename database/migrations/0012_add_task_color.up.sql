@@ -0,0 +1 @@
+ALTER TABLE tasks ADD COLUMN color VARCHAR(20) NOT NULL DEFAULT '';
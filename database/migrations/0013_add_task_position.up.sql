@@ -0,0 +1,6 @@
+ALTER TABLE tasks ADD COLUMN position DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- Existing tasks keep their creation order: IDs increase and never repeat
+UPDATE tasks SET position = id;
+
+CREATE INDEX idx_tasks_user_position ON tasks (user_id, position);
@@ -0,0 +1,6 @@
+CREATE TABLE api_usages (
+    user_id      BIGINT NOT NULL REFERENCES users (id),
+    period_start TIMESTAMPTZ NOT NULL,
+    count        BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, period_start)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS checklist_done;
+ALTER TABLE tasks DROP COLUMN IF EXISTS checklist_total;
+DROP TABLE IF EXISTS checklist_items;
@@ -0,0 +1,15 @@
+CREATE TABLE checklist_items (
+    id         BIGSERIAL PRIMARY KEY,
+    task_id    BIGINT NOT NULL REFERENCES tasks (id),
+    text       TEXT NOT NULL,
+    done       BOOLEAN NOT NULL DEFAULT FALSE,
+    position   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_checklist_items_task_position ON checklist_items (task_id, position);
+
+-- Tasks keep counts of their items so listings can show progress cheaply
+ALTER TABLE tasks ADD COLUMN checklist_total INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN checklist_done INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tasks_user_client_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS client_id;
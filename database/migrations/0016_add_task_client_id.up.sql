@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN client_id VARCHAR(36);
+
+-- Client IDs only have to be unique per owner; deleted tasks free theirs up
+CREATE UNIQUE INDEX idx_tasks_user_client_id ON tasks (user_id, client_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN external_id VARCHAR(255);
+
+-- External IDs only have to be unique per owner; deleted tasks free theirs up
+CREATE UNIQUE INDEX idx_tasks_user_external_id ON tasks (user_id, external_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS total_time_seconds;
+DROP TABLE IF EXISTS time_entries;
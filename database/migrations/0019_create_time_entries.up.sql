@@ -0,0 +1,18 @@
+CREATE TABLE time_entries (
+    id         BIGSERIAL PRIMARY KEY,
+    task_id    BIGINT NOT NULL REFERENCES tasks (id),
+    user_id    BIGINT NOT NULL REFERENCES users (id),
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at   TIMESTAMPTZ,
+    seconds    BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_time_entries_task_id ON time_entries (task_id);
+
+-- A user has at most one running timer, so two starts can't both succeed
+CREATE UNIQUE INDEX idx_time_entries_user_running ON time_entries (user_id) WHERE ended_at IS NULL;
+
+-- Tasks keep the total of their finished entries so listings can show it cheaply
+ALTER TABLE tasks ADD COLUMN total_time_seconds BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,25 @@
+CREATE TABLE comments (
+    id         BIGSERIAL PRIMARY KEY,
+    task_id    BIGINT NOT NULL REFERENCES tasks (id),
+    user_id    BIGINT NOT NULL REFERENCES users (id),
+    body       TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_comments_task_id ON comments (task_id);
+
+CREATE TABLE notifications (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES users (id),
+    type       VARCHAR(30) NOT NULL,
+    task_id    BIGINT NOT NULL REFERENCES tasks (id),
+    comment_id BIGINT REFERENCES comments (id),
+    actor_id   BIGINT NOT NULL REFERENCES users (id),
+    read_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+
+-- Each user's notifications are listed newest first
+CREATE INDEX idx_notifications_user_created ON notifications (user_id, created_at);
+CREATE INDEX idx_notifications_task_id ON notifications (task_id);
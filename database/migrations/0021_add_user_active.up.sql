@@ -0,0 +1,2 @@
+-- Admins can deactivate users; existing users stay active
+ALTER TABLE users ADD COLUMN active BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,2 @@
+-- Arbitrary JSON object clients attach to a task; NULL when there is none
+ALTER TABLE tasks ADD COLUMN metadata JSONB;
@@ -0,0 +1,6 @@
+-- Random UUID that stands in for the sequential ID with TASK_ID_FORMAT=uuid
+-- Existing tasks get one too, so the format can be switched at any time
+ALTER TABLE tasks ADD COLUMN public_id VARCHAR(36);
+UPDATE tasks SET public_id = gen_random_uuid()::text;
+ALTER TABLE tasks ALTER COLUMN public_id SET NOT NULL;
+CREATE UNIQUE INDEX idx_tasks_public_id ON tasks (public_id);
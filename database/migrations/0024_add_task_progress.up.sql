@@ -0,0 +1,3 @@
+-- Percent complete, independent of the status except that completed tasks are at 100
+ALTER TABLE tasks ADD COLUMN progress SMALLINT NOT NULL DEFAULT 0 CHECK (progress BETWEEN 0 AND 100);
+UPDATE tasks SET progress = 100 WHERE status = 'completed';
@@ -0,0 +1,11 @@
+-- When the task last moved into completed; NULL while it isn't completed
+ALTER TABLE tasks ADD COLUMN completed_at TIMESTAMPTZ;
+
+-- Tasks completed before this column existed take the time of their last
+-- move into completed, or their last update when the history doesn't have it
+UPDATE tasks SET completed_at = COALESCE(
+    (SELECT MAX(h.created_at) FROM task_status_histories h WHERE h.task_id = tasks.id AND h.to_status = 'completed'),
+    updated_at
+) WHERE status = 'completed';
+
+CREATE INDEX idx_tasks_completed_at ON tasks (completed_at);
@@ -0,0 +1,2 @@
+-- Who can read the task without a token: private (default) or public
+ALTER TABLE tasks ADD COLUMN visibility VARCHAR(10) NOT NULL DEFAULT 'private';
@@ -0,0 +1,2 @@
+-- IANA time zone response timestamps are written in; NULL writes them in UTC
+ALTER TABLE user_settings ADD COLUMN timezone VARCHAR(64);
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// queryCounterKey is the context key for the query counter of one request
+type queryCounterKey struct{}
+
+// ContextWithQueryCounter returns a copy of ctx that counts the queries run with it
+// Every request gets its own counter, so concurrent requests never see each
+// other's queries; the counter is atomic because a request may query from
+// more than one goroutine.
+func ContextWithQueryCounter(ctx context.Context) (context.Context, *atomic.Int64) {
+	counter := new(atomic.Int64)
+	return context.WithValue(ctx, queryCounterKey{}, counter), counter
+}
+
+// QueryCount returns the number of queries run so far with ctx
+// ok is false when ctx doesn't carry a counter (counting is disabled).
+func QueryCount(ctx context.Context) (count int64, ok bool) {
+	counter, ok := ctx.Value(queryCounterKey{}).(*atomic.Int64)
+	if !ok {
+		return 0, false
+	}
+	return counter.Load(), true
+}
+
+// EnableQueryCounting registers GORM callbacks that count each query against
+// the counter in its statement's context (see ContextWithQueryCounter)
+// Until this is called nothing is registered, so counting costs nothing when
+// DB_QUERY_COUNT is off. Queries without a counter (background jobs, or
+// requests outside the middleware) are not counted.
+func EnableQueryCounting(db *gorm.DB) error {
+	count := func(tx *gorm.DB) {
+		if tx.Statement == nil || tx.Statement.Context == nil {
+			return
+		}
+		if counter, ok := tx.Statement.Context.Value(queryCounterKey{}).(*atomic.Int64); ok {
+			counter.Add(1)
+		}
+	}
+
+	callbacks := db.Callback()
+	err := errors.Join(
+		callbacks.Create().After("gorm:create").Register("query_count:create", count),
+		callbacks.Query().After("gorm:query").Register("query_count:query", count),
+		callbacks.Update().After("gorm:update").Register("query_count:update", count),
+		callbacks.Delete().After("gorm:delete").Register("query_count:delete", count),
+		callbacks.Row().After("gorm:row").Register("query_count:row", count),
+		callbacks.Raw().After("gorm:raw").Register("query_count:raw", count),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register query counters: %w", err)
+	}
+	return nil
+}
@@ -14,9 +14,15 @@ func SeedData() error {
 
 	log.Println("Seeding sample data...")
 
+	org, err := models.DefaultOrganization(DB)
+	if err != nil {
+		return fmt.Errorf("failed to load default organization: %w", err)
+	}
+
 	sampleUser := models.User{
 		Email:    "test@example.com",
 		Password: "hashedpassword123",
+		OrgID:    org.ID,
 	}
 
 	if err := DB.Create(&sampleUser).Error; err != nil {
@@ -29,18 +35,21 @@ func SeedData() error {
 			Description: "Set up the basic project structure and database",
 			Status:      models.TaskStatusCompleted,
 			UserID:      sampleUser.ID,
+			OrgID:       org.ID,
 		},
 		{
 			Title:       "Implement authentication",
 			Description: "Add user registration and login functionality",
 			Status:      models.TaskStatusInProgress,
 			UserID:      sampleUser.ID,
+			OrgID:       org.ID,
 		},
 		{
 			Title:       "Create API endpoints",
 			Description: "Build REST API endpoints for task management",
 			Status:      models.TaskStatusPending,
 			UserID:      sampleUser.ID,
+			OrgID:       org.ID,
 		},
 	}
 
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/utils"
+	"gorm.io/gorm"
+)
+
+// bulkSeedBatchSize is how many tasks go into each INSERT
+// Large enough to be fast, small enough to stay under PostgreSQL's 65535
+// bind parameter limit.
+const bulkSeedBatchSize = 1000
+
+// BulkSeedOptions configures SeedBulk
+type BulkSeedOptions struct {
+	Tasks     int    // Number of tasks to create
+	UserEmail string // Owner of the tasks; created if it doesn't exist
+	Password  string // Password for a newly created user
+}
+
+// Word lists for task titles, so title searches match a realistic share of tasks
+var (
+	bulkSeedVerbs = []string{"Write", "Review", "Fix", "Plan", "Update", "Test", "Deploy", "Design", "Refactor", "Document"}
+	bulkSeedNouns = []string{"report", "login page", "invoice export", "release notes", "database backup", "onboarding flow", "API client", "dashboard", "search index", "budget"}
+)
+
+// SeedBulk creates a user and opts.Tasks tasks for load testing (-seed-bulk)
+// Unlike SeedData it is meant for benchmarking pagination and filtering: the
+// tasks get varied statuses, colors, due dates and timestamps spread over the
+// past year, and are inserted with CreateInBatches. The data is the same on
+// every run with the same count. Refuses to run when ENV is production.
+func SeedBulk(cfg *config.Config, opts BulkSeedOptions) error {
+	if DB == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+	if cfg.Env == "production" {
+		return fmt.Errorf("refusing to seed bulk data with ENV=production")
+	}
+	if opts.Tasks <= 0 {
+		return fmt.Errorf("task count must be positive, got %d", opts.Tasks)
+	}
+
+	user, err := bulkSeedUser(cfg, opts)
+	if err != nil {
+		return err
+	}
+
+	// A fixed seed makes runs comparable between benchmarks
+	rng := rand.New(rand.NewPCG(uint64(opts.Tasks), 1))
+	now := time.Now()
+	tasks := make([]models.Task, 0, opts.Tasks)
+	for i := 0; i < opts.Tasks; i++ {
+		createdAt := now.Add(-time.Duration(rng.Int64N(int64(365 * 24 * time.Hour))))
+		task := models.Task{
+			Title:       fmt.Sprintf("%s %s #%d", bulkSeedVerbs[rng.IntN(len(bulkSeedVerbs))], bulkSeedNouns[rng.IntN(len(bulkSeedNouns))], i+1),
+			Description: fmt.Sprintf("Generated by -seed-bulk for load testing (%d of %d)", i+1, opts.Tasks),
+			Status:      models.TaskStatus(cfg.TaskStatuses[rng.IntN(len(cfg.TaskStatuses))]),
+			UserID:      user.ID,
+			OrgID:       user.OrgID,
+			Position:    float64(i + 1),
+			CreatedAt:   createdAt,
+			// Updated some time between creation and now
+			UpdatedAt: createdAt.Add(time.Duration(rng.Int64N(int64(now.Sub(createdAt)) + 1))),
+		}
+		// About half the tasks have a due date, from a month ago to two months ahead
+		if rng.IntN(2) == 0 {
+			due := now.Add(time.Duration(rng.Int64N(int64(90*24*time.Hour))) - 30*24*time.Hour).Truncate(time.Minute)
+			task.DueDate = &due
+		}
+		// About a third have a color
+		if len(cfg.TaskColors) > 0 && rng.IntN(3) == 0 {
+			task.Color = cfg.TaskColors[rng.IntN(len(cfg.TaskColors))]
+		}
+		tasks = append(tasks, task)
+	}
+
+	log.Printf("Seeding %d tasks for %s in batches of %d...", opts.Tasks, user.Email, bulkSeedBatchSize)
+	start := time.Now()
+	if err := DB.CreateInBatches(tasks, bulkSeedBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to create tasks: %w", err)
+	}
+	elapsed := time.Since(start)
+	log.Printf("Seeded %d tasks in %s (%.0f tasks/s)", opts.Tasks, elapsed.Round(time.Millisecond), float64(opts.Tasks)/elapsed.Seconds())
+	return nil
+}
+
+// bulkSeedUser loads the user with opts.UserEmail, creating it if needed
+// An existing user keeps its password and organization, and new tasks are
+// added to the ones it has; a new user joins the default organization.
+func bulkSeedUser(cfg *config.Config, opts BulkSeedOptions) (*models.User, error) {
+	var user models.User
+	err := DB.Where("email = ?", opts.UserEmail).First(&user).Error
+	if err == nil {
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to look up user %s: %w", opts.UserEmail, err)
+	}
+
+	org, err := models.DefaultOrganization(DB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load default organization: %w", err)
+	}
+	hashedPassword, err := utils.HashPassword(opts.Password, cfg.PasswordHashAlgorithm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	user = models.User{
+		Email:    opts.UserEmail,
+		Password: hashedPassword,
+		Role:     models.RoleMember,
+		OrgID:    org.ID,
+	}
+	if err := DB.Create(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to create user %s: %w", opts.UserEmail, err)
+	}
+	log.Printf("Created user %s", user.Email)
+	return &user, nil
+}
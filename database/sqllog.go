@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// sqlLogger logs GORM's queries through slog, so they end up next to the
+// request log and pass the same redaction (LOG_REDACT_KEYS)
+// Queries slower than slowThreshold are logged at WARN and failed ones at
+// ERROR; with level logger.Info (outside production) every other query is
+// logged at INFO as well. Without withParams the SQL keeps its placeholders
+// ($1, $2, ...) and the values bound to them are never logged.
+type sqlLogger struct {
+	level         logger.LogLevel
+	slowThreshold time.Duration // 0 disables slow query logging
+	withParams    bool
+}
+
+// newSQLLogger returns the query logger for ENV: every query in development,
+// only slow and failed ones in production
+func newSQLLogger(env string, slowThreshold time.Duration, withParams bool) *sqlLogger {
+	level := logger.Info
+	if env == "production" {
+		level = logger.Warn
+	}
+	return &sqlLogger{level: level, slowThreshold: slowThreshold, withParams: withParams}
+}
+
+func (l *sqlLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+func (l *sqlLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(msg, data...), "caller", utils.FileWithLineNum())
+	}
+}
+
+func (l *sqlLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(msg, data...), "caller", utils.FileWithLineNum())
+	}
+}
+
+func (l *sqlLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(msg, data...), "caller", utils.FileWithLineNum())
+	}
+}
+
+// Trace logs one query once it has run
+// A missing record isn't an error worth logging: handlers turn it into a 404.
+func (l *sqlLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	var level slog.Level
+	var msg string
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= logger.Error:
+		level, msg = slog.LevelError, "query failed"
+	case l.slowThreshold != 0 && elapsed > l.slowThreshold && l.level >= logger.Warn:
+		level, msg = slog.LevelWarn, "slow query"
+	case l.level >= logger.Info:
+		level, msg = slog.LevelInfo, "query"
+	default:
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []any{
+		"sql", sql,
+		"duration_ms", float64(elapsed.Microseconds()) / 1000,
+		"rows", rows,
+		"caller", utils.FileWithLineNum(),
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	slog.Log(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter drops the values bound to a query's placeholders unless
+// DB_LOG_QUERY_PARAMS is set; GORM calls it before building the logged SQL
+func (l *sqlLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if !l.withParams {
+		return sql, nil
+	}
+	return sql, params
+}
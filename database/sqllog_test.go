@@ -0,0 +1,109 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// captureLog sends slog's output to a buffer for the rest of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// loggedQueries returns the records logged to buf, one per line
+func loggedQueries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected JSON log output: %v", err)
+		}
+		records = append(records, record)
+	}
+	buf.Reset()
+	return records
+}
+
+// TestSQLLoggerParams tests that values bound to a query are only logged when asked for
+// Not parallel: it replaces slog's default logger
+func TestSQLLoggerParams(t *testing.T) {
+	buf := captureLog(t)
+
+	type task struct {
+		ID    uint
+		Title string
+	}
+	for _, withParams := range []bool{false, true} {
+		// A dry run builds and logs the SQL without a server to run it on
+		db, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1 user=test dbname=test sslmode=disable"),
+			&gorm.Config{DisableAutomaticPing: true, DryRun: true, Logger: newSQLLogger("development", time.Second, withParams)})
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		db.Where("title = ?", "salary review for Ayşe").Find(&[]task{})
+
+		records := loggedQueries(t, buf)
+		if len(records) != 1 || records[0]["msg"] != "query" || records[0]["level"] != "INFO" {
+			t.Fatalf("Expected the query to be logged at INFO, got %v", records)
+		}
+		sql, _ := records[0]["sql"].(string)
+		if got := strings.Contains(sql, "salary review"); got != withParams {
+			t.Errorf("Expected the value to be logged: %v, got %q", withParams, sql)
+		}
+		if !withParams && !strings.Contains(sql, "$1") {
+			t.Errorf("Expected the placeholder to be kept, got %q", sql)
+		}
+	}
+}
+
+// TestSQLLoggerLevels tests which queries each environment logs
+// Not parallel: it replaces slog's default logger
+func TestSQLLoggerLevels(t *testing.T) {
+	buf := captureLog(t)
+	ctx := context.Background()
+	query := func() (string, int64) { return "SELECT 1", 1 }
+	fast, slow := time.Now(), time.Now().Add(-time.Second)
+
+	production := newSQLLogger("production", 200*time.Millisecond, false)
+	production.Trace(ctx, fast, query, nil)
+	production.Trace(ctx, fast, query, gorm.ErrRecordNotFound) // A 404, not a failure
+	if records := loggedQueries(t, buf); len(records) != 0 {
+		t.Errorf("Expected production to leave fast queries out, got %v", records)
+	}
+
+	production.Trace(ctx, slow, query, nil)
+	records := loggedQueries(t, buf)
+	if len(records) != 1 || records[0]["msg"] != "slow query" || records[0]["level"] != "WARN" {
+		t.Fatalf("Expected the slow query at WARN, got %v", records)
+	}
+	if duration, _ := records[0]["duration_ms"].(float64); duration < 1000 || records[0]["sql"] != "SELECT 1" {
+		t.Errorf("Expected the SQL and its duration, got %v", records[0])
+	}
+
+	production.Trace(ctx, fast, query, errors.New("relation does not exist"))
+	if records := loggedQueries(t, buf); len(records) != 1 || records[0]["level"] != "ERROR" || records[0]["error"] != "relation does not exist" {
+		t.Errorf("Expected the failed query at ERROR, got %v", records)
+	}
+
+	// A threshold of 0 only turns off the slow query warning
+	newSQLLogger("production", 0, false).Trace(ctx, slow, query, nil)
+	if records := loggedQueries(t, buf); len(records) != 0 {
+		t.Errorf("Expected no slow query warning with a threshold of 0, got %v", records)
+	}
+}
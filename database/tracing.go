@@ -0,0 +1,71 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kcansari/task-management-api/tracing"
+	"gorm.io/gorm"
+)
+
+// tracingSpanKey is where a statement's span waits between its callbacks
+const tracingSpanKey = "tracing:span"
+
+// EnableTracing registers GORM callbacks that record each query as a child
+// span of the span in its statement's context (see middleware.Trace)
+// That context is the request's, since handlers query through
+// WithContext(r.Context()); queries run without a span in their context
+// (background jobs, startup) aren't traced. Spans carry the SQL with its
+// placeholders, never the values bound to them.
+func EnableTracing(db *gorm.DB) error {
+	start := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			if tx.Statement == nil || tx.Statement.Context == nil {
+				return
+			}
+			name := operation
+			if tx.Statement.Table != "" {
+				name += " " + tx.Statement.Table
+			}
+			_, span := tracing.StartChild(tx.Statement.Context, name, tracing.SpanKindClient)
+			if span != nil {
+				tx.InstanceSet(tracingSpanKey, span)
+			}
+		}
+	}
+	end := func(tx *gorm.DB) {
+		value, ok := tx.InstanceGet(tracingSpanKey)
+		if !ok {
+			return
+		}
+		span := value.(*tracing.Span)
+		span.SetAttribute("db.system", tx.Dialector.Name())
+		span.SetAttribute("db.statement", tx.Statement.SQL.String())
+		span.SetAttribute("db.rows_affected", tx.RowsAffected)
+		// Not finding a record is an answer, not a failure
+		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(tx.Error)
+		}
+		span.End()
+	}
+
+	callbacks := db.Callback()
+	err := errors.Join(
+		callbacks.Create().Before("gorm:create").Register("tracing:before_create", start("INSERT")),
+		callbacks.Create().After("gorm:create").Register("tracing:after_create", end),
+		callbacks.Query().Before("gorm:query").Register("tracing:before_query", start("SELECT")),
+		callbacks.Query().After("gorm:query").Register("tracing:after_query", end),
+		callbacks.Update().Before("gorm:update").Register("tracing:before_update", start("UPDATE")),
+		callbacks.Update().After("gorm:update").Register("tracing:after_update", end),
+		callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", start("DELETE")),
+		callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", end),
+		callbacks.Row().Before("gorm:row").Register("tracing:before_row", start("ROW")),
+		callbacks.Row().After("gorm:row").Register("tracing:after_row", end),
+		callbacks.Raw().Before("gorm:raw").Register("tracing:before_raw", start("RAW")),
+		callbacks.Raw().After("gorm:raw").Register("tracing:after_raw", end),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register tracing callbacks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Package events is an in-process publish/subscribe hub for task changes
+// Handlers publish after a change is saved; live streams subscribe per user.
+// It only reaches clients connected to this instance.
+package events
+
+import (
+	"log"
+	"sync"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind before
+// further events are dropped for it
+const subscriberBuffer = 16
+
+// Event is a single change notification
+type Event struct {
+	Name string // e.g. task.created
+	Data []byte // JSON payload
+}
+
+// Broker fans events out to the subscribers of each user
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan Event]struct{}
+}
+
+// NewBroker creates an empty broker
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[uint]map[chan Event]struct{})}
+}
+
+// Default is the broker shared by the handlers
+var Default = NewBroker()
+
+// Subscribe registers for userID's events
+// The returned function unsubscribes and must be called when the subscriber goes away
+func (b *Broker) Subscribe(userID uint) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan Event]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[userID], ch)
+			if len(b.subscribers[userID]) == 0 {
+				delete(b.subscribers, userID)
+			}
+			b.mu.Unlock()
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Publish sends e to every subscriber of userID
+// It never blocks: a subscriber whose buffer is full misses the event
+func (b *Broker) Publish(userID uint, e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[userID] {
+		select {
+		case ch <- e:
+		default:
+			log.Printf("Dropping %s event for a slow subscriber of user %d", e.Name, userID)
+		}
+	}
+}
+
+// Subscribers returns how many subscribers userID currently has
+func (b *Broker) Subscribers(userID uint) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers[userID])
+}
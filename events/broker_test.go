@@ -0,0 +1,65 @@
+package events
+
+import "testing"
+
+// TestBrokerDeliversToUserSubscribers tests that events only reach the right user
+func TestBrokerDeliversToUserSubscribers(t *testing.T) {
+	b := NewBroker()
+	mine, unsubscribeMine := b.Subscribe(1)
+	defer unsubscribeMine()
+	other, unsubscribeOther := b.Subscribe(2)
+	defer unsubscribeOther()
+
+	b.Publish(1, Event{Name: "task.created", Data: []byte(`{}`)})
+
+	select {
+	case e := <-mine:
+		if e.Name != "task.created" {
+			t.Errorf("Expected task.created, got %s", e.Name)
+		}
+	default:
+		t.Errorf("Expected the subscriber to receive the event")
+	}
+
+	select {
+	case e := <-other:
+		t.Errorf("Other user received %s", e.Name)
+	default:
+	}
+}
+
+// TestBrokerUnsubscribe tests that unsubscribing removes the subscriber
+func TestBrokerUnsubscribe(t *testing.T) {
+	b := NewBroker()
+	_, unsubscribe := b.Subscribe(1)
+	_, unsubscribe2 := b.Subscribe(1)
+	if got := b.Subscribers(1); got != 2 {
+		t.Fatalf("Expected 2 subscribers, got %d", got)
+	}
+
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+	if got := b.Subscribers(1); got != 1 {
+		t.Errorf("Expected 1 subscriber, got %d", got)
+	}
+
+	unsubscribe2()
+	if got := b.Subscribers(1); got != 0 {
+		t.Errorf("Expected no subscribers, got %d", got)
+	}
+
+	// Publishing with no subscribers is a no-op
+	b.Publish(1, Event{Name: "task.updated"})
+}
+
+// TestBrokerPublishDoesNotBlock tests that a slow subscriber can't stall publishers
+func TestBrokerPublishDoesNotBlock(t *testing.T) {
+	b := NewBroker()
+	_, unsubscribe := b.Subscribe(1)
+	defer unsubscribe()
+
+	// Nobody reads, so everything past the buffer is dropped
+	for i := 0; i < subscriberBuffer*2; i++ {
+		b.Publish(1, Event{Name: "task.updated"})
+	}
+}
@@ -0,0 +1,197 @@
+// Package graphql parses GraphQL documents
+// It covers the executable part of the language that clients send: queries
+// and mutations with variables, aliases, arguments, fragments and directives.
+// Schemas and execution are up to the caller; see handlers/graphql.go.
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Document is a parsed GraphQL request document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment // Named fragments by name
+}
+
+// Operation is a query, mutation or subscription
+type Operation struct {
+	Type         string // "query", "mutation" or "subscription"
+	Name         string // "" for anonymous operations
+	Variables    []*VariableDefinition
+	Directives   []*Directive
+	SelectionSet []Selection
+}
+
+// VariableDefinition declares a variable of an operation, e.g. ($id: ID! = 1)
+type VariableDefinition struct {
+	Name    string
+	Type    string // As written, e.g. "[ID!]!"
+	Default Value  // nil without a default
+}
+
+// Selection is a *Field, *FragmentSpread or *InlineFragment
+type Selection interface {
+	isSelection()
+}
+
+// Field selects a field, e.g. renamed: task(id: 1) @include(if: $full) { title }
+type Field struct {
+	Alias        string // "" when the field isn't aliased
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection // Empty for scalar fields
+}
+
+// Key is the name the field's value gets in the response
+func (f *Field) Key() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread includes a named fragment, e.g. ...taskFields
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+}
+
+// InlineFragment groups selections, e.g. ... on Task { title }
+type InlineFragment struct {
+	TypeCondition string // "" when there's no "on Type"
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+// Fragment is a named fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+func (*Field) isSelection()          {}
+func (*FragmentSpread) isSelection() {}
+func (*InlineFragment) isSelection() {}
+
+// Argument is a named argument of a field or directive
+type Argument struct {
+	Name  string
+	Value Value
+}
+
+// Directive annotates a selection, e.g. @skip(if: true)
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+}
+
+// Value is an argument value as written in the document
+// Its concrete type is one of the *Value types below.
+type Value interface {
+	isValue()
+}
+
+// Variable refers to an operation variable, e.g. $id
+type Variable struct{ Name string }
+
+// IntValue, FloatValue, StringValue, BooleanValue and EnumValue are literals
+type (
+	IntValue     struct{ Value int64 }
+	FloatValue   struct{ Value float64 }
+	StringValue  struct{ Value string }
+	BooleanValue struct{ Value bool }
+	EnumValue    struct{ Value string }
+	NullValue    struct{}
+)
+
+// ListValue is a list literal, e.g. [1, 2]
+type ListValue struct{ Values []Value }
+
+// ObjectValue is an input object literal, e.g. {title: "Report"}
+type ObjectValue struct{ Fields []*Argument }
+
+func (*Variable) isValue()     {}
+func (*IntValue) isValue()     {}
+func (*FloatValue) isValue()   {}
+func (*StringValue) isValue()  {}
+func (*BooleanValue) isValue() {}
+func (*EnumValue) isValue()    {}
+func (*NullValue) isValue()    {}
+func (*ListValue) isValue()    {}
+func (*ObjectValue) isValue()  {}
+
+// Resolve turns a value into plain Go values, substituting variables
+// Objects become map[string]interface{}, lists []interface{}, enums strings;
+// variables are taken as is from variables (typically decoded JSON). A
+// variable missing from variables resolves to nil.
+func Resolve(value Value, variables map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case *Variable:
+		return variables[v.Name]
+	case *IntValue:
+		return v.Value
+	case *FloatValue:
+		return v.Value
+	case *StringValue:
+		return v.Value
+	case *BooleanValue:
+		return v.Value
+	case *EnumValue:
+		return v.Value
+	case *ListValue:
+		list := make([]interface{}, len(v.Values))
+		for i, item := range v.Values {
+			list[i] = Resolve(item, variables)
+		}
+		return list
+	case *ObjectValue:
+		object := make(map[string]interface{}, len(v.Fields))
+		for _, field := range v.Fields {
+			object[field.Name] = Resolve(field.Value, variables)
+		}
+		return object
+	default:
+		return nil
+	}
+}
+
+// Object is a JSON object that keeps its members in order
+// GraphQL responses list fields in the order they were selected, which a
+// map can't do.
+type Object []Member
+
+// Member is one key and value of an Object
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// MarshalJSON writes the members in order
+func (o Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, member := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(member.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(member.Value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", member.Key, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,727 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError reports where a document stopped making sense
+type SyntaxError struct {
+	Message string
+	Line    int // 1-based
+	Column  int // 1-based, in characters
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("Syntax Error: %s (line %d, column %d)", e.Message, e.Line, e.Column)
+}
+
+// tokenKind tells apart the lexical tokens of GraphQL
+type tokenKind int
+
+const (
+	tokenEOF        tokenKind = iota
+	tokenPunctuator           // ! $ ( ) ... : = @ [ ] { | }
+	tokenName                 // Names and keywords alike
+	tokenInt                  // -12
+	tokenFloat                // 1.5e3
+	tokenString               // "text" or """block"""
+)
+
+// token is one lexical token and where it starts in the source
+type token struct {
+	kind  tokenKind
+	value string // Strings are already unescaped
+	pos   int    // Byte offset
+}
+
+// parser is a recursive descent parser with one token of lookahead
+type parser struct {
+	src string
+	pos int   // Where the lexer continues
+	tok token // Current token
+}
+
+// Parse parses a GraphQL document
+// Type system definitions (schemas) aren't supported: clients only send
+// operations and fragments. Fragment names must be unique.
+func Parse(src string) (*Document, error) {
+	p := &parser{src: src}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Fragments: map[string]*Fragment{}}
+	if p.tok.kind == tokenEOF {
+		return nil, p.errorf(p.tok.pos, "the document has no operations")
+	}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunctuator, "{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, &Operation{Type: "query", SelectionSet: selections})
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			operation, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, operation)
+		case p.peek(tokenName, "fragment"):
+			pos := p.tok.pos
+			fragment, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.Fragments[fragment.Name]; ok {
+				return nil, p.errorf(pos, "there is more than one fragment named %q", fragment.Name)
+			}
+			doc.Fragments[fragment.Name] = fragment
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+// errorf builds a SyntaxError at byte offset pos
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	line, column := 1, 1
+	for _, c := range p.src[:pos] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return &SyntaxError{Message: fmt.Sprintf(format, args...), Line: line, Column: column}
+}
+
+// unexpected reports the current token as out of place
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return p.errorf(p.tok.pos, "unexpected end of document")
+	}
+	return p.errorf(p.tok.pos, "unexpected %q", p.src[p.tok.pos:p.pos])
+}
+
+// peek reports whether the current token is the given punctuator or name
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+// expect consumes the given punctuator or keyword, or fails
+func (p *parser) expect(kind tokenKind, value string) error {
+	if !p.peek(kind, value) {
+		if p.tok.kind == tokenEOF {
+			return p.errorf(p.tok.pos, "expected %q, found end of document", value)
+		}
+		return p.errorf(p.tok.pos, "expected %q, found %q", value, p.src[p.tok.pos:p.pos])
+	}
+	return p.advance()
+}
+
+// skip consumes the given punctuator if it's the current token
+func (p *parser) skip(value string) (bool, error) {
+	if !p.peek(tokenPunctuator, value) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+// parseName consumes a name
+func (p *parser) parseName() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+// parseOperation parses query/mutation/subscription Name? Variables? Directives? { ... }
+func (p *parser) parseOperation() (*Operation, error) {
+	operation := &Operation{Type: p.tok.value}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if p.tok.kind == tokenName {
+		if operation.Name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek(tokenPunctuator, "(") {
+		if operation.Variables, err = p.parseVariableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	if operation.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if operation.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+// parseVariableDefinitions parses ($name: Type = default, ...)
+func (p *parser) parseVariableDefinitions() ([]*VariableDefinition, error) {
+	if err := p.expect(tokenPunctuator, "("); err != nil {
+		return nil, err
+	}
+
+	var definitions []*VariableDefinition
+	for {
+		if len(definitions) == 0 && p.peek(tokenPunctuator, ")") {
+			return nil, p.errorf(p.tok.pos, "expected a variable definition")
+		}
+		if done, err := p.skip(")"); err != nil || done {
+			return definitions, err
+		}
+
+		if err := p.expect(tokenPunctuator, "$"); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenPunctuator, ":"); err != nil {
+			return nil, err
+		}
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		definition := &VariableDefinition{Name: name, Type: typ}
+		if hasDefault, err := p.skip("="); err != nil {
+			return nil, err
+		} else if hasDefault {
+			if definition.Default, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+		// Directives on variables are allowed by the spec but mean nothing here
+		if _, err := p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+}
+
+// parseType parses Name, [Type] or either followed by !
+func (p *parser) parseType() (string, error) {
+	var typ string
+	if list, err := p.skip("["); err != nil {
+		return "", err
+	} else if list {
+		inner, err := p.parseType()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect(tokenPunctuator, "]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		if typ, err = p.parseName(); err != nil {
+			return "", err
+		}
+	}
+
+	if nonNull, err := p.skip("!"); err != nil {
+		return "", err
+	} else if nonNull {
+		typ += "!"
+	}
+	return typ, nil
+}
+
+// parseFragment parses fragment Name on Type Directives? { ... }
+func (p *parser) parseFragment() (*Fragment, error) {
+	if err := p.expect(tokenName, "fragment"); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenName, "on") {
+		return nil, p.errorf(p.tok.pos, "a fragment can't be named \"on\"")
+	}
+
+	fragment := &Fragment{}
+	var err error
+	if fragment.Name, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenName, "on"); err != nil {
+		return nil, err
+	}
+	if fragment.TypeCondition, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if fragment.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if fragment.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+// parseSelectionSet parses { selection ... }, which can't be empty
+func (p *parser) parseSelectionSet() ([]Selection, error) {
+	if err := p.expect(tokenPunctuator, "{"); err != nil {
+		return nil, err
+	}
+
+	var selections []Selection
+	for {
+		if len(selections) == 0 && p.peek(tokenPunctuator, "}") {
+			return nil, p.errorf(p.tok.pos, "a selection set can't be empty")
+		}
+		if done, err := p.skip("}"); err != nil || done {
+			return selections, err
+		}
+
+		selection, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+}
+
+// parseSelection parses a field, a fragment spread or an inline fragment
+func (p *parser) parseSelection() (Selection, error) {
+	spread, err := p.skip("...")
+	if err != nil {
+		return nil, err
+	}
+	if !spread {
+		return p.parseField()
+	}
+
+	// ...Name is a fragment spread; "... on Type" and "... {" are inline fragments
+	if p.tok.kind == tokenName && p.tok.value != "on" {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		directives, err := p.parseDirectives()
+		if err != nil {
+			return nil, err
+		}
+		return &FragmentSpread{Name: name, Directives: directives}, nil
+	}
+
+	fragment := &InlineFragment{}
+	if p.peek(tokenName, "on") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if fragment.TypeCondition, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+	if fragment.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if fragment.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+// parseField parses alias: name(arguments) @directives { ... }
+func (p *parser) parseField() (*Field, error) {
+	field := &Field{}
+	var err error
+	if field.Name, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if aliased, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if aliased {
+		field.Alias = field.Name
+		if field.Name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+
+	if field.Arguments, err = p.parseArguments(false); err != nil {
+		return nil, err
+	}
+	if field.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunctuator, "{") {
+		if field.SelectionSet, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+// parseArguments parses an optional (name: value, ...) list
+func (p *parser) parseArguments(constant bool) ([]*Argument, error) {
+	if open, err := p.skip("("); err != nil || !open {
+		return nil, err
+	}
+
+	var arguments []*Argument
+	for {
+		if len(arguments) == 0 && p.peek(tokenPunctuator, ")") {
+			return nil, p.errorf(p.tok.pos, "expected an argument")
+		}
+		if done, err := p.skip(")"); err != nil || done {
+			return arguments, err
+		}
+
+		argument, err := p.parseArgument(constant)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+	}
+}
+
+// parseArgument parses name: value, as in arguments and object literals
+func (p *parser) parseArgument(constant bool) (*Argument, error) {
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenPunctuator, ":"); err != nil {
+		return nil, err
+	}
+	value, err := p.parseValue(constant)
+	if err != nil {
+		return nil, err
+	}
+	return &Argument{Name: name, Value: value}, nil
+}
+
+// parseDirectives parses any number of @name(arguments)
+func (p *parser) parseDirectives() ([]*Directive, error) {
+	var directives []*Directive
+	for p.peek(tokenPunctuator, "@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := p.parseArguments(false)
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, &Directive{Name: name, Arguments: arguments})
+	}
+	return directives, nil
+}
+
+// parseValue parses a value; constant values (defaults) can't use variables
+func (p *parser) parseValue(constant bool) (Value, error) {
+	tok := p.tok
+	switch {
+	case p.peek(tokenPunctuator, "$"):
+		if constant {
+			return nil, p.errorf(tok.pos, "a default value can't use variables")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		return &Variable{Name: name}, nil
+
+	case p.peek(tokenPunctuator, "["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := &ListValue{}
+		for {
+			if done, err := p.skip("]"); err != nil || done {
+				return list, err
+			}
+			item, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+			list.Values = append(list.Values, item)
+		}
+
+	case p.peek(tokenPunctuator, "{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		object := &ObjectValue{}
+		for {
+			if done, err := p.skip("}"); err != nil || done {
+				return object, err
+			}
+			field, err := p.parseArgument(constant)
+			if err != nil {
+				return nil, err
+			}
+			object.Fields = append(object.Fields, field)
+		}
+
+	case tok.kind == tokenInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, p.errorf(tok.pos, "integer %s is out of range", tok.value)
+		}
+		return &IntValue{Value: n}, p.advance()
+
+	case tok.kind == tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, p.errorf(tok.pos, "number %s is out of range", tok.value)
+		}
+		return &FloatValue{Value: f}, p.advance()
+
+	case tok.kind == tokenString:
+		return &StringValue{Value: tok.value}, p.advance()
+
+	case tok.kind == tokenName:
+		var value Value
+		switch tok.value {
+		case "true", "false":
+			value = &BooleanValue{Value: tok.value == "true"}
+		case "null":
+			value = &NullValue{}
+		default:
+			value = &EnumValue{Value: tok.value}
+		}
+		return value, p.advance()
+	}
+	return nil, p.unexpected()
+}
+
+// advance reads the next token into p.tok
+func (p *parser) advance() error {
+	// Whitespace, commas, comments and byte order marks are insignificant
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '\r' {
+				p.pos++
+			}
+		case strings.HasPrefix(p.src[p.pos:], "\uFEFF"):
+			p.pos += len("\uFEFF")
+		default:
+			return p.lex()
+		}
+	}
+	p.tok = token{kind: tokenEOF, pos: p.pos}
+	return nil
+}
+
+// lex reads the token starting at p.pos
+func (p *parser) lex() error {
+	start := p.pos
+	c := p.src[start]
+
+	switch {
+	case strings.HasPrefix(p.src[start:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokenPunctuator, value: "...", pos: start}
+		return nil
+	case strings.IndexByte("!$():=@[]{|}", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenPunctuator, value: string(c), pos: start}
+		return nil
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenName, value: p.src[start:p.pos], pos: start}
+		return nil
+	case c == '-' || isDigit(c):
+		return p.lexNumber()
+	case strings.HasPrefix(p.src[start:], `"""`):
+		return p.lexBlockString()
+	case c == '"':
+		return p.lexString()
+	}
+
+	r, _ := utf8.DecodeRuneInString(p.src[start:])
+	return p.errorf(start, "unexpected character %q", r)
+}
+
+// lexNumber reads an Int or Float: -?(0|[1-9][0-9]*)(.[0-9]+)?([eE][+-]?[0-9]+)?
+func (p *parser) lexNumber() error {
+	start := p.pos
+	kind := tokenInt
+
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	integer := p.pos
+	if !p.digits() {
+		return p.errorf(p.pos, "expected a digit")
+	}
+	if p.src[integer] == '0' && p.pos-integer > 1 {
+		return p.errorf(start, "numbers can't have leading zeros")
+	}
+
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		kind = tokenFloat
+		p.pos++
+		if !p.digits() {
+			return p.errorf(p.pos, "expected a digit after the decimal point")
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		kind = tokenFloat
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		if !p.digits() {
+			return p.errorf(p.pos, "expected a digit in the exponent")
+		}
+	}
+
+	// 12abc isn't a number followed by a name
+	if p.pos < len(p.src) && (p.src[p.pos] == '_' || p.src[p.pos] == '.' || isLetter(p.src[p.pos])) {
+		return p.errorf(p.pos, "invalid number")
+	}
+
+	p.tok = token{kind: kind, value: p.src[start:p.pos], pos: start}
+	return nil
+}
+
+// digits consumes a run of digits and reports whether there was at least one
+func (p *parser) digits() bool {
+	start := p.pos
+	for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// lexString reads a "quoted string" with JSON-style escapes
+func (p *parser) lexString() error {
+	start := p.pos
+	p.pos++ // Opening quote
+
+	var value strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			p.tok = token{kind: tokenString, value: value.String(), pos: start}
+			return nil
+		case c == '\n' || c == '\r':
+			return p.errorf(p.pos, "unterminated string")
+		case c == '\\':
+			if p.pos+1 >= len(p.src) {
+				return p.errorf(p.pos, "unterminated string")
+			}
+			escape := p.src[p.pos+1]
+			p.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				value.WriteByte(escape)
+			case 'b':
+				value.WriteByte('\b')
+			case 'f':
+				value.WriteByte('\f')
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					return p.errorf(p.pos, "invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return p.errorf(p.pos, "invalid unicode escape")
+				}
+				value.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				return p.errorf(p.pos-2, "invalid escape \\%c", escape)
+			}
+		default:
+			value.WriteByte(c)
+			p.pos++
+		}
+	}
+	return p.errorf(start, "unterminated string")
+}
+
+// lexBlockString reads a """block string""", where only \""" is an escape
+// The common indentation of its lines and blank first and last lines are
+// removed, as the spec requires.
+func (p *parser) lexBlockString() error {
+	start := p.pos
+	p.pos += 3
+
+	var raw strings.Builder
+	for p.pos < len(p.src) {
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], `\"""`):
+			raw.WriteString(`"""`)
+			p.pos += 4
+		case strings.HasPrefix(p.src[p.pos:], `"""`):
+			p.pos += 3
+			p.tok = token{kind: tokenString, value: blockStringValue(raw.String()), pos: start}
+			return nil
+		default:
+			raw.WriteByte(p.src[p.pos])
+			p.pos++
+		}
+	}
+	return p.errorf(start, "unterminated block string")
+}
+
+// blockStringValue dedents the raw contents of a block string
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(raw), "\n")
+
+	// The smallest indentation of the lines after the first that aren't blank
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			lines[i] = lines[i][min(indent, len(lines[i])):]
+		}
+	}
+
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
@@ -0,0 +1,173 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestParse tests parsing documents into operations and fragments
+func TestParse(t *testing.T) {
+	src := `
+		# Fetch a page of tasks
+		query Tasks($status: String = "pending", $page: Int!) @cached {
+			page: tasks(filter: {status: $status, tags: ["a", "b"]}, pagination: {page: $page, page_size: 10}) {
+				data { ...taskFields @include(if: true) }
+				... on TaskPage { total }
+			}
+		}
+
+		mutation { deleteTask(id: "7") }
+
+		fragment taskFields on Task {
+			id, title
+			description(format: """
+				Line one
+				  Line two
+			""")
+		}
+	`
+	doc, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(doc.Operations) != 2 || len(doc.Fragments) != 1 {
+		t.Fatalf("Expected 2 operations and 1 fragment, got %d and %d", len(doc.Operations), len(doc.Fragments))
+	}
+
+	query := doc.Operations[0]
+	if query.Type != "query" || query.Name != "Tasks" || len(query.Directives) != 1 {
+		t.Errorf("Unexpected operation %+v", query)
+	}
+	expectedVariables := []*VariableDefinition{
+		{Name: "status", Type: "String", Default: &StringValue{Value: "pending"}},
+		{Name: "page", Type: "Int!"},
+	}
+	if !reflect.DeepEqual(query.Variables, expectedVariables) {
+		t.Errorf("Unexpected variables %+v", query.Variables)
+	}
+
+	tasks := query.SelectionSet[0].(*Field)
+	if tasks.Key() != "page" || tasks.Name != "tasks" || len(tasks.Arguments) != 2 {
+		t.Fatalf("Unexpected field %+v", tasks)
+	}
+	filter := Resolve(tasks.Arguments[0].Value, map[string]interface{}{"status": "done"})
+	expectedFilter := map[string]interface{}{"status": "done", "tags": []interface{}{"a", "b"}}
+	if !reflect.DeepEqual(filter, expectedFilter) {
+		t.Errorf("Expected filter %v, got %v", expectedFilter, filter)
+	}
+
+	data := tasks.SelectionSet[0].(*Field)
+	if spread := data.SelectionSet[0].(*FragmentSpread); spread.Name != "taskFields" || spread.Directives[0].Name != "include" {
+		t.Errorf("Unexpected fragment spread %+v", spread)
+	}
+	if inline := tasks.SelectionSet[1].(*InlineFragment); inline.TypeCondition != "TaskPage" {
+		t.Errorf("Unexpected inline fragment %+v", inline)
+	}
+
+	if mutation := doc.Operations[1]; mutation.Type != "mutation" || mutation.Name != "" {
+		t.Errorf("Unexpected operation %+v", mutation)
+	}
+
+	fragment := doc.Fragments["taskFields"]
+	if fragment.TypeCondition != "Task" || len(fragment.SelectionSet) != 3 {
+		t.Fatalf("Unexpected fragment %+v", fragment)
+	}
+	format := fragment.SelectionSet[2].(*Field).Arguments[0].Value
+	if got := format.(*StringValue).Value; got != "Line one\n  Line two" {
+		t.Errorf("Expected the block string to be dedented, got %q", got)
+	}
+}
+
+// TestParseValues tests the literals arguments can hold
+func TestParseValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		literal  string
+		expected interface{}
+	}{
+		{"int", "42", int64(42)},
+		{"negative int", "-7", int64(-7)},
+		{"zero", "0", int64(0)},
+		{"float", "1.5", 1.5},
+		{"exponent", "2e3", 2000.0},
+		{"string", `"hi"`, "hi"},
+		{"escapes", `"a\"b\\c\ndé"`, "a\"b\\c\ndé"},
+		{"unicode", `"görev"`, "görev"},
+		{"empty block string", `""""""`, ""},
+		{"true", "true", true},
+		{"false", "false", false},
+		{"null", "null", nil},
+		{"enum", "HIGH", "HIGH"},
+		{"empty list", "[]", []interface{}{}},
+		{"nested", `{a: [1, {b: null}]}`, map[string]interface{}{"a": []interface{}{int64(1), map[string]interface{}{"b": nil}}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := Parse("{ field(value: " + tc.literal + ") }")
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			value := doc.Operations[0].SelectionSet[0].(*Field).Arguments[0].Value
+			if got := Resolve(value, nil); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %#v, got %#v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestParseErrors tests that malformed documents are rejected with a position
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		src    string
+		line   int
+		column int
+	}{
+		{"empty document", "  ", 1, 3},
+		{"only comments", "# nothing", 1, 10},
+		{"unclosed selection set", "{ tasks", 1, 8},
+		{"empty selection set", "{ }", 1, 3},
+		{"missing argument value", "{ task(id: ) }", 1, 12},
+		{"empty arguments", "{ task() }", 1, 8},
+		{"unterminated string", "{ task(id: \"1) }", 1, 12},
+		{"newline in string", "{ task(id: \"1\n\") }", 1, 14},
+		{"bad escape", `{ task(id: "\q") }`, 1, 13},
+		{"leading zero", "{ task(id: 01) }", 1, 12},
+		{"number followed by a name", "{ task(id: 1abc) }", 1, 13},
+		{"unknown character", "{ task ? }", 1, 8},
+		{"variable in a default value", "query($a: Int = $b) { tasks }", 1, 17},
+		{"fragment named on", "fragment on on Task { id }", 1, 10},
+		{"fragment without a type condition", "fragment f { id }", 1, 12},
+		{"duplicate fragment", "{ a } fragment f on T { id } fragment f on T { id }", 1, 30},
+		{"schema definition", "type Task { id: ID }", 1, 1},
+		{"error on a later line", "query {\n  tasks {\n    id:\n  }\n}", 4, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.src)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Expected a SyntaxError, got %v", err)
+			}
+			if syntaxErr.Line != tc.line || syntaxErr.Column != tc.column {
+				t.Errorf("Expected the error at %d:%d, got %d:%d (%s)", tc.line, tc.column, syntaxErr.Line, syntaxErr.Column, syntaxErr.Message)
+			}
+		})
+	}
+}
+
+// TestObjectMarshalJSON tests that objects keep their members in order
+func TestObjectMarshalJSON(t *testing.T) {
+	object := Object{{"title", "Report"}, {"id", 1}, {"owner", Object{{"email", "a@b.c"}}}, {"tags", nil}}
+	got, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if expected := `{"title":"Report","id":1,"owner":{"email":"a@b.c"},"tags":null}`; string(got) != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// Types of events in the activity feed
+const (
+	ActivityTaskCreated   = "created"
+	ActivityStatusChanged = "status_changed" // Any status change but into completed
+	ActivityTaskCompleted = "completed"
+	ActivityCommented     = "commented"
+)
+
+// ActivityTaskRef identifies the task an activity event happened to
+type ActivityTaskRef struct {
+	ID    any    `json:"id"` // The public UUID with TASK_ID_FORMAT=uuid
+	Title string `json:"title"`
+}
+
+// ActivityEventResponse represents one event in the activity feed
+type ActivityEventResponse struct {
+	Type       string             `json:"type"` // created, status_changed, completed or commented
+	OccurredAt Timestamp          `json:"occurred_at"`
+	Task       ActivityTaskRef    `json:"task"`
+	ActorID    uint               `json:"actor_id"`              // Who did it: the owner for created, the author for commented
+	FromStatus *models.TaskStatus `json:"from_status,omitempty"` // Status changes only
+	ToStatus   *models.TaskStatus `json:"to_status,omitempty"`   // Status changes only
+	CommentID  *uint              `json:"comment_id,omitempty"`  // Comments only
+}
+
+// PaginatedActivityResponse represents a page of the activity feed
+type PaginatedActivityResponse struct {
+	Events []ActivityEventResponse `json:"events"` // Newest first
+	PaginationMeta
+}
+
+// activityRow is one row of the merged event sources (see activitySources)
+type activityRow struct {
+	EventType  string
+	TaskID     uint
+	ActorID    uint
+	OccurredAt time.Time
+	SourceID   uint // ID of the task, status change or comment the event comes from
+	FromStatus *models.TaskStatus
+	ToStatus   *models.TaskStatus
+	Title      string
+	PublicID   string
+}
+
+// activitySources returns the SQL and arguments of the feed's events for
+// the tasks the tasks scope selects, every source shaped into the same columns
+// The sources are combined with UNION ALL and sorted and paginated by the
+// database, so only one page of events is ever loaded.
+func activitySources(db *gorm.DB, tasks func(*gorm.DB) *gorm.DB) (string, []any) {
+	taskIDs := db.Model(&models.Task{}).Scopes(tasks).Select("id")
+
+	// Literals are cast so every source's columns have the same types
+	created := db.Model(&models.Task{}).Scopes(tasks).
+		Select("CAST(? AS varchar(20)) AS event_type, id AS task_id, user_id AS actor_id, created_at AS occurred_at, id AS source_id, "+
+			"CAST(NULL AS varchar(20)) AS from_status, CAST(NULL AS varchar(20)) AS to_status", ActivityTaskCreated)
+	statusChanges := db.Model(&models.TaskStatusHistory{}).
+		Select("CAST(CASE WHEN to_status = ? THEN ? ELSE ? END AS varchar(20)) AS event_type, task_id, user_id AS actor_id, created_at AS occurred_at, id AS source_id, "+
+			"from_status, to_status", models.TaskStatusCompleted, ActivityTaskCompleted, ActivityStatusChanged).
+		Where("task_id IN (?)", taskIDs)
+	comments := db.Model(&models.Comment{}).
+		Select("CAST(? AS varchar(20)) AS event_type, task_id, user_id AS actor_id, created_at AS occurred_at, id AS source_id, "+
+			"CAST(NULL AS varchar(20)) AS from_status, CAST(NULL AS varchar(20)) AS to_status", ActivityCommented).
+		Where("task_id IN (?)", taskIDs)
+
+	return "SELECT * FROM (?) AS created UNION ALL SELECT * FROM (?) AS status_changes UNION ALL SELECT * FROM (?) AS comments",
+		[]any{created, statusChanges, comments}
+}
+
+// newActivityEventResponse converts a row of the feed to its API representation
+func newActivityEventResponse(row activityRow) ActivityEventResponse {
+	event := ActivityEventResponse{
+		Type:       row.EventType,
+		OccurredAt: newTimestamp(row.OccurredAt),
+		Task:       ActivityTaskRef{ID: taskResponseID(TaskResponse{ID: row.TaskID, PublicID: row.PublicID}), Title: row.Title},
+		ActorID:    row.ActorID,
+	}
+	switch row.EventType {
+	case ActivityStatusChanged, ActivityTaskCompleted:
+		event.FromStatus, event.ToStatus = row.FromStatus, row.ToStatus
+	case ActivityCommented:
+		commentID := row.SourceID
+		event.CommentID = &commentID
+	}
+	return event
+}
+
+// GetActivity handles GET /api/activity - The caller's task activity feed, newest first
+// It merges the creation of tasks, their status changes and comments on them,
+// for the tasks GET /api/tasks lists (?scope= included). Events of deleted
+// tasks drop out. Paginated like GET /api/tasks.
+func GetActivity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	page, pageSize, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+	includeShared, ok := parseTaskScope(w, r)
+	if !ok {
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	sources, args := activitySources(db, visibleTasks(db, user, includeShared))
+
+	var total int64
+	var rows []activityRow
+	err := db.Raw("SELECT COUNT(*) FROM ("+sources+") AS activity", args...).Scan(&total).Error
+	if err == nil {
+		// Ties (e.g. a task created with a comment in the same instant) are
+		// broken by source, so pages never overlap
+		err = db.Raw("SELECT activity.*, tasks.title, tasks.public_id FROM ("+sources+") AS activity "+
+			"JOIN tasks ON tasks.id = activity.task_id "+
+			"ORDER BY activity.occurred_at DESC, activity.event_type ASC, activity.source_id DESC LIMIT ? OFFSET ?",
+			append(args, pageSize, (page-1)*pageSize)...).Scan(&rows).Error
+	}
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch activity for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch activity")
+		return
+	}
+
+	events := make([]ActivityEventResponse, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, newActivityEventResponse(row))
+	}
+
+	meta := newPaginationMeta(page, pageSize, total)
+	if wantsEnvelope(r) {
+		w.Header().Set("Content-Type", apierror.EnvelopeMediaType)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Envelope{
+			Data:  inUserTimezone(r, events),
+			Meta:  &meta,
+			Links: newPaginationLinks(r, meta),
+		})
+		return
+	}
+	writeResponse(w, r, http.StatusOK, PaginatedActivityResponse{Events: events, PaginationMeta: meta})
+}
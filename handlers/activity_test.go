@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestGetActivity tests that the feed merges task creation, status changes
+// and comments newest first, and only for the caller's tasks
+func TestGetActivity(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-activity-owner")
+	reader := env.createUser("test-activity-reader")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Feed"})
+	env.serve(ShareTask, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/shares", task.ID), ShareTaskRequest{UserID: reader.UserID}), owner))
+
+	// Written directly so each event has its own time
+	created := time.Now().UTC().Truncate(time.Second)
+	env.tx.Create(&models.TaskStatusHistory{TaskID: task.ID, FromStatus: models.TaskStatusPending, ToStatus: models.TaskStatusInProgress, UserID: owner.UserID, CreatedAt: created.Add(time.Minute)})
+	env.tx.Create(&models.TaskStatusHistory{TaskID: task.ID, FromStatus: models.TaskStatusInProgress, ToStatus: models.TaskStatusCompleted, UserID: owner.UserID, CreatedAt: created.Add(2 * time.Minute)})
+	comment := models.Comment{TaskID: task.ID, UserID: reader.UserID, Body: "Nice", CreatedAt: created.Add(3 * time.Minute)}
+	env.tx.Create(&comment)
+
+	// Neither another user's task nor a deleted one show up
+	env.createTask(reader, CreateTaskRequest{Title: "Not mine"})
+	deleted := env.createTask(owner, CreateTaskRequest{Title: "Deleted"})
+	env.tx.Create(&models.Comment{TaskID: deleted.ID, UserID: owner.UserID, Body: "Gone"})
+	env.tx.Delete(&models.Task{}, deleted.ID)
+
+	list := func(user middleware.UserContext, query string) PaginatedActivityResponse {
+		t.Helper()
+		rr := env.serve(GetActivity, asUser(env.newRequest("GET", "/api/activity?"+query, nil), user))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var response PaginatedActivityResponse
+		env.decode(rr, &response)
+		return response
+	}
+	types := func(response PaginatedActivityResponse) []string {
+		types := make([]string, 0, len(response.Events))
+		for _, event := range response.Events {
+			types = append(types, event.Type)
+		}
+		return types
+	}
+
+	response := list(owner, "")
+	expected := []string{ActivityCommented, ActivityTaskCompleted, ActivityStatusChanged, ActivityTaskCreated}
+	if got := types(response); !slices.Equal(got, expected) || response.Total != 4 {
+		t.Fatalf("Expected %v, got %v (total %d)", expected, got, response.Total)
+	}
+
+	commented, completed, createdEvent := response.Events[0], response.Events[1], response.Events[3]
+	if commented.ActorID != reader.UserID || commented.CommentID == nil || *commented.CommentID != comment.ID || commented.ToStatus != nil {
+		t.Errorf("Unexpected comment event %+v", commented)
+	}
+	if completed.FromStatus == nil || *completed.FromStatus != models.TaskStatusInProgress || completed.ToStatus == nil || *completed.ToStatus != models.TaskStatusCompleted {
+		t.Errorf("Unexpected completion event %+v", completed)
+	}
+	if createdEvent.ActorID != owner.UserID || createdEvent.Task.Title != "Feed" || createdEvent.Task.ID != float64(task.ID) || createdEvent.CommentID != nil {
+		t.Errorf("Unexpected creation event %+v", createdEvent)
+	}
+	if !createdEvent.OccurredAt.Time().Before(completed.OccurredAt.Time()) {
+		t.Errorf("Expected the creation before the completion, got %v and %v", createdEvent.OccurredAt, completed.OccurredAt)
+	}
+
+	// Pages continue where the last one ended
+	response = list(owner, "page=2&page_size=3")
+	if got := types(response); !slices.Equal(got, []string{ActivityTaskCreated}) || response.TotalPages != 2 || response.HasNext {
+		t.Errorf("Expected the creation alone on the second page, got %v %+v", got, response.PaginationMeta)
+	}
+
+	// Tasks shared with the caller join the feed with ?scope=all, as they do the listing
+	if got := types(list(reader, "")); !slices.Equal(got, []string{ActivityTaskCreated}) {
+		t.Errorf("Expected only the reader's own task, got %v", got)
+	}
+	if response := list(reader, "scope=all"); response.Total != 5 {
+		t.Errorf("Expected the shared task's events too, got %v", types(response))
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// PaginatedUserResponse represents a page of the organization's users
+type PaginatedUserResponse struct {
+	Users []models.User `json:"users"` // Password hashes are never serialized (json:"-")
+	PaginationMeta
+}
+
+// UpdateUserRequest represents an admin's changes to a user; absent fields are left alone
+type UpdateUserRequest struct {
+	Active *bool            `json:"active"` // false deactivates the account, true reactivates it
+	Role   *models.UserRole `json:"role"`   // member or admin
+}
+
+// GetAdminUsers handles GET /api/admin/users - List the organization's users (admins only)
+// Paginated like GET /api/tasks, oldest account first; ?active=true or
+// ?active=false narrows the list.
+func GetAdminUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	if !admin.IsAdmin() {
+		writeError(w, r, http.StatusForbidden, apierror.AdminRequired, "Only organization admins can list users") // 403
+		return
+	}
+
+	page, pageSize, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	scope := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("org_id = ?", admin.OrgID)
+		switch r.URL.Query().Get("active") {
+		case "true":
+			tx = tx.Where("active = ?", true)
+		case "false":
+			tx = tx.Where("active = ?", false)
+		}
+		return tx
+	}
+
+	var total int64
+	var users []models.User
+	err := db.Model(&models.User{}).Scopes(scope).Count(&total).Error
+	if err == nil {
+		err = db.Scopes(scope).Order("id ASC").
+			Limit(pageSize).Offset((page - 1) * pageSize).
+			Find(&users).Error
+	}
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch users of organization %d: %v", admin.OrgID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch users")
+		return
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+
+	meta := newPaginationMeta(page, pageSize, total)
+	if wantsEnvelope(r) {
+		w.Header().Set("Content-Type", apierror.EnvelopeMediaType)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Envelope{
+			Data:  inUserTimezone(r, users),
+			Meta:  &meta,
+			Links: newPaginationLinks(r, meta),
+		})
+		return
+	}
+	writeResponse(w, r, http.StatusOK, PaginatedUserResponse{Users: users, PaginationMeta: meta})
+}
+
+// UpdateAdminUser handles PATCH /api/admin/users/{id} - Deactivate, reactivate or change the role of a user (admins only)
+// Any change also signs the user out everywhere (like SignOutMember): a
+// deactivated user's tokens stop working at once, and a new role only takes
+// effect in a new token. Admins can't deactivate or demote themselves, so an
+// organization can't lose its last admin by accident.
+func UpdateAdminUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "PATCH" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	if !admin.IsAdmin() {
+		writeError(w, r, http.StatusForbidden, apierror.AdminRequired, "Only organization admins can change users") // 403
+		return
+	}
+
+	// Extract the user ID from /api/admin/users/45
+	userID, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/admin/users/"), 10, 32)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidUserID, "Invalid user ID")
+		return
+	}
+
+	// Parse request body
+	var req UpdateUserRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Role != nil && !req.Role.IsValid() {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidRole, "Invalid role. Use: member, admin")
+		return
+	}
+	if uint(userID) == admin.UserID && ((req.Active != nil && !*req.Active) || (req.Role != nil && *req.Role != models.RoleAdmin)) {
+		writeError(w, r, http.StatusBadRequest, apierror.CannotChangeSelf, "You can't deactivate or demote your own account")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Users of other organizations look like missing ones
+	var user models.User
+	if err := db.Where("id = ? AND org_id = ?", userID, admin.OrgID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, apierror.MemberNotFound, "User not found in your organization")
+			return
+		}
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to load user %d: %v", userID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to update user")
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Active != nil && *req.Active != user.Active {
+		updates["active"] = *req.Active
+	}
+	if req.Role != nil && *req.Role != user.Role {
+		updates["role"] = *req.Role
+	}
+	if len(updates) > 0 {
+		updates["token_version"] = gorm.Expr("token_version + 1")
+		if err := db.Model(&user).Updates(updates).Error; err != nil {
+			if writeQueryTimeout(w, r, err) {
+				return
+			}
+			log.Printf("Failed to update user %d: %v", userID, err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to update user")
+			return
+		}
+		if err := db.First(&user, user.ID).Error; err != nil {
+			if writeQueryTimeout(w, r, err) {
+				return
+			}
+			log.Printf("Failed to reload user %d: %v", userID, err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to update user")
+			return
+		}
+		log.Printf("Admin %d changed user %d: active=%t role=%s", admin.UserID, user.ID, user.Active, user.Role)
+	}
+
+	writeResponse(w, r, http.StatusOK, user)
+}
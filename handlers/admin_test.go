@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestGetAdminUsers tests that admins list their own organization's users without password hashes
+func TestGetAdminUsers(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	admin := env.createOrgAdmin("test-admin-list")
+	member := env.addMember(admin, "test-admin-list-member")
+	outsider := env.createUser("test-admin-list-outsider")
+
+	rr := env.serve(GetAdminUsers, asUser(env.newRequest("GET", "/api/admin/users", nil), admin))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "password") || strings.Contains(rr.Body.String(), "$argon2") {
+		t.Errorf("Expected no password hashes in the response, got %s", rr.Body.String())
+	}
+	var page PaginatedUserResponse
+	env.decode(rr, &page)
+	if page.Total != 2 || len(page.Users) != 2 || page.Users[0].ID != admin.UserID || page.Users[1].ID != member.UserID {
+		t.Fatalf("Expected the admin and the member, got %+v", page)
+	}
+	if !page.Users[1].Active {
+		t.Errorf("Expected new users to be active")
+	}
+
+	// Only deactivated users
+	env.tx.Model(&models.User{}).Where("id = ?", member.UserID).Update("active", false)
+	rr = env.serve(GetAdminUsers, asUser(env.newRequest("GET", "/api/admin/users?active=false", nil), admin))
+	env.decode(rr, &page)
+	if page.Total != 1 || page.Users[0].ID != member.UserID {
+		t.Errorf("Expected only the deactivated member, got %+v", page.Users)
+	}
+
+	rr = env.serve(GetAdminUsers, asUser(env.newRequest("GET", "/api/admin/users", nil), outsider))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a member, got %d", http.StatusForbidden, rr.Code)
+	}
+}
+
+// TestUpdateAdminUser tests deactivating users, changing roles and that admins can't lock themselves out
+func TestUpdateAdminUser(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	admin := env.createOrgAdmin("test-admin-update")
+	outsider := env.createOrgAdmin("test-admin-update-outsider")
+
+	email := uniqueEmail("test-admin-update-member")
+	rr := env.serve(CreateOrganizationMember, asUser(env.newRequest("POST", "/api/organization/members", CreateMemberRequest{Email: email, Password: "memberpass123"}), admin))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to create member: status %d, body %s", rr.Code, rr.Body.String())
+	}
+	var member models.User
+	env.decode(rr, &member)
+	path := fmt.Sprintf("/api/admin/users/%d", member.ID)
+
+	// login returns the status of logging in as the member, and the token on success
+	login := func() (int, string) {
+		rr := env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: email, Password: "memberpass123"}))
+		var auth AuthResponse
+		if rr.Code == http.StatusOK {
+			env.decode(rr, &auth)
+		}
+		return rr.Code, auth.Token
+	}
+	code, token := login()
+	if code != http.StatusOK {
+		t.Fatalf("Expected the member to log in, got %d", code)
+	}
+	protected := middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	authenticate := func() int {
+		req := env.newRequest("GET", "/api/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return env.serve(protected, req).Code
+	}
+
+	// Deactivating kills existing tokens and blocks logging in
+	rr = env.serve(UpdateAdminUser, asUser(env.newRequest("PATCH", path, map[string]bool{"active": false}), admin))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var updated models.User
+	env.decode(rr, &updated)
+	if updated.Active {
+		t.Errorf("Expected the member to be deactivated")
+	}
+	if code := authenticate(); code != http.StatusUnauthorized {
+		t.Errorf("Expected the member's token to get 401, got %d", code)
+	}
+	rr = env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: email, Password: "memberpass123"}))
+	var errResp ErrorResponse
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusForbidden || errResp.Code != apierror.AccountDeactivated {
+		t.Errorf("Expected 403 %s on login, got %d %s", apierror.AccountDeactivated, rr.Code, errResp.Code)
+	}
+	// A wrong password still gets the generic error
+	rr = env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: email, Password: "wrongpass123"}))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong password, got %d", rr.Code)
+	}
+
+	// Reactivating and promoting in one go
+	rr = env.serve(UpdateAdminUser, asUser(env.newRequest("PATCH", path, map[string]interface{}{"active": true, "role": "admin"}), admin))
+	env.decode(rr, &updated)
+	if rr.Code != http.StatusOK || !updated.Active || updated.Role != models.RoleAdmin {
+		t.Errorf("Expected an active admin, got %d %+v", rr.Code, updated)
+	}
+	if code, _ := login(); code != http.StatusOK {
+		t.Errorf("Expected the reactivated member to log in, got %d", code)
+	}
+
+	selfPath := fmt.Sprintf("/api/admin/users/%d", admin.UserID)
+	testCases := []struct {
+		name           string
+		caller         middleware.UserContext
+		path           string
+		body           interface{}
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"deactivate yourself", admin, selfPath, map[string]bool{"active": false}, http.StatusBadRequest, apierror.CannotChangeSelf},
+		{"demote yourself", admin, selfPath, map[string]string{"role": "member"}, http.StatusBadRequest, apierror.CannotChangeSelf},
+		{"unknown role", admin, path, map[string]string{"role": "owner"}, http.StatusBadRequest, apierror.InvalidRole},
+		{"invalid ID", admin, "/api/admin/users/abc", map[string]bool{"active": false}, http.StatusBadRequest, apierror.InvalidUserID},
+		{"other organization", outsider, path, map[string]bool{"active": false}, http.StatusNotFound, apierror.MemberNotFound},
+		{"not an admin", middleware.UserContext{UserID: member.ID, OrgID: member.OrgID, Role: models.RoleMember}, selfPath, map[string]bool{"active": false}, http.StatusForbidden, apierror.AdminRequired},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(UpdateAdminUser, asUser(env.newRequest("PATCH", tc.path, tc.body), tc.caller))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			var errResp ErrorResponse
+			env.decode(rr, &errResp)
+			if errResp.Code != tc.expectedCode {
+				t.Errorf("Expected code %s, got %s", tc.expectedCode, errResp.Code)
+			}
+		})
+	}
+
+	// The admin is untouched
+	var self models.User
+	env.tx.First(&self, admin.UserID)
+	if !self.Active || self.Role != models.RoleAdmin {
+		t.Errorf("Expected the admin to stay an active admin, got %+v", self)
+	}
+}
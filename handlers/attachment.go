@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/storage"
+)
+
+// attachmentStorage is where uploaded files go; main sets it at startup
+var attachmentStorage storage.Storage
+
+// SetAttachmentStorage selects the backend attachments are stored in
+func SetAttachmentStorage(s storage.Storage) {
+	attachmentStorage = s
+}
+
+// AttachmentResponse represents an attachment in API responses
+type AttachmentResponse struct {
+	ID          uint      `json:"id"`
+	TaskID      any       `json:"task_id"` // The public UUID with TASK_ID_FORMAT=uuid
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"` // In bytes
+	CreatedAt   Timestamp `json:"created_at"`
+}
+
+// newAttachmentResponse converts an attachment of task to its API representation
+func newAttachmentResponse(attachment models.Attachment, task models.Task) AttachmentResponse {
+	return AttachmentResponse{
+		ID:          attachment.ID,
+		TaskID:      referencedTaskID(task),
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		CreatedAt:   newTimestamp(attachment.CreatedAt),
+	}
+}
+
+// errAttachmentTooLarge aborts an upload once it passes ATTACHMENT_MAX_SIZE
+var errAttachmentTooLarge = errors.New("attachment exceeds the maximum size")
+
+// maxSizeReader counts what's read from r and fails as soon as it exceeds max
+// The storage backend sees the error mid-stream and discards the partial file,
+// so an oversized upload is never stored or read into memory in full.
+type maxSizeReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (m *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.read += int64(n)
+	if m.read > m.max {
+		return n, errAttachmentTooLarge
+	}
+	return n, err
+}
+
+// attachmentTypeAllowed reports whether contentType is in ATTACHMENT_ALLOWED_TYPES
+func attachmentTypeAllowed(contentType string, allowed []string) bool {
+	for _, t := range allowed {
+		if strings.EqualFold(t, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// attachmentFilename reduces a client-supplied file name to its last path element
+// The name is only ever shown back to users; storage keys never contain it
+func attachmentFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
+
+// newStorageKey returns a random key for a task's new attachment
+func newStorageKey(taskID uint) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(b)), nil
+}
+
+// UploadTaskAttachment handles POST /api/tasks/{id}/attachments - Attach a file to a task
+// The body is multipart/form-data with the file in a "file" field. It's
+// streamed straight to storage, never buffered whole.
+func UploadTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/attachments
+	taskID, err := taskIDFromPath(r.URL.Path, "/attachments")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	// Check access before reading any of the upload
+	// (the lookup gets its own query timeout: the upload itself may take longer).
+	// Attachments are private to the owner, so a share doesn't count.
+	task, err := func() (models.Task, error) {
+		db, cancel := requestDB(r)
+		defer cancel()
+		return findOwnedTask(db, taskID, user)
+	}()
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+		return
+	}
+
+	// The server's read and write timeouts would cut a slow upload off; lift
+	// them for this request (not every ResponseWriter supports deadlines, which is fine)
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	cfg := config.Get()
+
+	// Cap the whole body too, leaving room for the multipart headers
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.AttachmentMaxSize+1<<20)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.AttachmentFileRequired, "Request must be multipart/form-data with a file field")
+		return
+	}
+
+	// Skip any other form fields until the file arrives
+	var part io.Reader
+	var filename, contentType string
+	for part == nil {
+		p, err := reader.NextPart()
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, apierror.AttachmentFileRequired, "Request must be multipart/form-data with a file field")
+			return
+		}
+		if p.FormName() != "file" {
+			continue
+		}
+		part = p
+		filename = attachmentFilename(p.FileName())
+		contentType, _, _ = mime.ParseMediaType(p.Header.Get("Content-Type"))
+	}
+
+	if !attachmentTypeAllowed(contentType, cfg.AttachmentAllowedTypes) {
+		writeError(w, r, http.StatusUnsupportedMediaType, apierror.AttachmentTypeNotAllowed, "File type not allowed. Use: "+strings.Join(cfg.AttachmentAllowedTypes, ", ")) // 415
+		return
+	}
+
+	key, err := newStorageKey(task.ID)
+	if err != nil {
+		log.Printf("Failed to generate storage key: %v", err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to store attachment")
+		return
+	}
+
+	body := &maxSizeReader{r: part, max: cfg.AttachmentMaxSize}
+	if err := attachmentStorage.Put(r.Context(), key, body, contentType); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.Is(err, errAttachmentTooLarge) || errors.As(err, &maxBytesErr) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, apierror.AttachmentTooLarge, fmt.Sprintf("File cannot be larger than %d bytes", cfg.AttachmentMaxSize)) // 413
+			return
+		}
+		log.Printf("Failed to store attachment for task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to store attachment")
+		return
+	}
+
+	attachment := models.Attachment{
+		TaskID:      task.ID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        body.read,
+		StorageKey:  key,
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+	if err := db.Create(&attachment).Error; err != nil {
+		// Without a row nothing points at the file any more
+		if deleteErr := attachmentStorage.Delete(r.Context(), key); deleteErr != nil {
+			log.Printf("Failed to remove orphaned attachment %s: %v", key, deleteErr)
+		}
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to save attachment for task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to store attachment")
+		return
+	}
+
+	writeResponse(w, r, http.StatusCreated, newAttachmentResponse(attachment, task))
+}
+
+// GetTaskAttachments handles GET /api/tasks/{id}/attachments - List a task's attachments
+func GetTaskAttachments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/attachments
+	taskID, err := taskIDFromPath(r.URL.Path, "/attachments")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	task, err := findOwnedTask(db, taskID, user)
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+		return
+	}
+
+	var attachments []models.Attachment
+	if err := db.Where("task_id = ?", task.ID).Order("id ASC").Find(&attachments).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch attachments for task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch attachments")
+		return
+	}
+
+	response := make([]AttachmentResponse, 0, len(attachments))
+	for _, attachment := range attachments {
+		response = append(response, newAttachmentResponse(attachment, task))
+	}
+
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// DownloadTaskAttachment handles GET /api/tasks/{id}/attachments/{attachment_id} - Download a file
+func DownloadTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Split /api/tasks/123/attachments/45 into the task and attachment IDs
+	taskPath, attachmentPath, _ := strings.Cut(r.URL.Path, "/attachments/")
+	taskID, err := taskIDFromPath(taskPath, "")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+	attachmentID, err := strconv.ParseUint(attachmentPath, 10, 32)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidAttachmentID, "Invalid attachment ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	task, err := findOwnedTask(db, taskID, user)
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+		return
+	}
+
+	var attachment models.Attachment
+	if err := db.Where("id = ? AND task_id = ?", attachmentID, task.ID).First(&attachment).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		writeError(w, r, http.StatusNotFound, apierror.AttachmentNotFound, "Attachment not found")
+		return
+	}
+
+	file, err := attachmentStorage.Open(r.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Attachment %d is missing from storage (key %s)", attachment.ID, attachment.StorageKey)
+			writeError(w, r, http.StatusNotFound, apierror.AttachmentNotFound, "Attachment not found")
+			return
+		}
+		log.Printf("Failed to open attachment %d: %v", attachment.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to download attachment")
+		return
+	}
+	defer file.Close()
+
+	// Always download rather than render, so an uploaded HTML or SVG file
+	// can't run in the API's origin
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// The server's write timeout would cut a slow download off; lift it for this response
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Failed to send attachment %d: %v", attachment.ID, err)
+	}
+}
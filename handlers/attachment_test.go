@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/storage"
+)
+
+// uploadRequest builds a multipart upload with one file field
+// An empty field name leaves the file out entirely
+func (e *testEnv) uploadRequest(path, field, filename, contentType, contents string) *http.Request {
+	e.t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if field != "" {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, field, filename))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			e.t.Fatalf("Failed to create multipart part: %v", err)
+		}
+		part.Write([]byte(contents))
+	}
+	writer.Close()
+
+	req := e.newRequest("POST", path, body.String())
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// TestTaskAttachments tests uploading, listing and downloading attachments
+// Not parallel: it overrides the global configuration and attachment storage
+func TestTaskAttachments(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.AttachmentMaxSize = 10
+		cfg.AttachmentAllowedTypes = []string{"text/plain", "image/png"}
+	})
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	previous := attachmentStorage
+	SetAttachmentStorage(store)
+	t.Cleanup(func() { SetAttachmentStorage(previous) })
+
+	env := newTestEnv(t)
+	owner := env.createUser("test-attach-owner")
+	reader := env.createUser("test-attach-reader")
+	task := env.createTask(owner, CreateTaskRequest{Title: "With files"})
+	path := fmt.Sprintf("/api/tasks/%d/attachments", task.ID)
+
+	// Even a share doesn't give access to attachments
+	rr := env.serve(ShareTask, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/shares", task.ID), ShareTaskRequest{UserID: reader.UserID}), owner))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to share task: %d %s", rr.Code, rr.Body.String())
+	}
+
+	testCases := []struct {
+		name           string
+		request        *http.Request
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"at the size limit", asUser(env.uploadRequest(path, "file", "notes.txt", "text/plain", "0123456789"), owner), http.StatusCreated, ""},
+		{"over the size limit", asUser(env.uploadRequest(path, "file", "big.txt", "text/plain", "0123456789!"), owner), http.StatusRequestEntityTooLarge, apierror.AttachmentTooLarge},
+		{"type not allowed", asUser(env.uploadRequest(path, "file", "page.html", "text/html", "<b>hi</b>"), owner), http.StatusUnsupportedMediaType, apierror.AttachmentTypeNotAllowed},
+		{"missing file field", asUser(env.uploadRequest(path, "", "", "", ""), owner), http.StatusBadRequest, apierror.AttachmentFileRequired},
+		{"wrong field name", asUser(env.uploadRequest(path, "upload", "notes.txt", "text/plain", "hi"), owner), http.StatusBadRequest, apierror.AttachmentFileRequired},
+		{"not multipart", asUser(env.newRequest("POST", path, `{"file":"x"}`), owner), http.StatusBadRequest, apierror.AttachmentFileRequired},
+		{"shared user", asUser(env.uploadRequest(path, "file", "notes.txt", "text/plain", "hi"), reader), http.StatusNotFound, apierror.TaskNotFound},
+		{"missing task", asUser(env.uploadRequest("/api/tasks/999999/attachments", "file", "notes.txt", "text/plain", "hi"), owner), http.StatusNotFound, apierror.TaskNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(UploadTaskAttachment, tc.request)
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tc.expectedCode == "" {
+				return
+			}
+
+			var response ErrorResponse
+			env.decode(rr, &response)
+			if response.Code != tc.expectedCode {
+				t.Errorf("Expected code %s, got %s", tc.expectedCode, response.Code)
+			}
+		})
+	}
+
+	// Only the upload that fit was kept
+	rr = env.serve(GetTaskAttachments, asUser(env.newRequest("GET", path, nil), owner))
+	var attachments []AttachmentResponse
+	env.decode(rr, &attachments)
+	if len(attachments) != 1 {
+		t.Fatalf("Expected 1 attachment, got %+v", attachments)
+	}
+	if attachments[0].Filename != "notes.txt" || attachments[0].Size != 10 || attachments[0].ContentType != "text/plain" {
+		t.Errorf("Unexpected attachment %+v", attachments[0])
+	}
+
+	downloadPath := fmt.Sprintf("%s/%d", path, attachments[0].ID)
+	rr = env.serve(DownloadTaskAttachment, asUser(env.newRequest("GET", downloadPath, nil), owner))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 downloading, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Body.String() != "0123456789" {
+		t.Errorf("Expected the uploaded contents, got %q", rr.Body.String())
+	}
+	if rr.Header().Get("Content-Type") != "text/plain" || !strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment") {
+		t.Errorf("Unexpected download headers %v", rr.Header())
+	}
+
+	// Anyone but the owner gets a 404, for the list and the download
+	rr = env.serve(GetTaskAttachments, asUser(env.newRequest("GET", path, nil), reader))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 listing another user's attachments, got %d", rr.Code)
+	}
+	rr = env.serve(DownloadTaskAttachment, asUser(env.newRequest("GET", downloadPath, nil), reader))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 downloading another user's attachment, got %d", rr.Code)
+	}
+
+	rr = env.serve(DownloadTaskAttachment, asUser(env.newRequest("GET", path+"/999999", nil), owner))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing attachment, got %d", rr.Code)
+	}
+	rr = env.serve(DownloadTaskAttachment, asUser(env.newRequest("GET", path+"/abc", nil), owner))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid attachment ID, got %d", rr.Code)
+	}
+}
+
+// throttledReader hands out a few bytes at a time, pausing before each read
+type throttledReader struct {
+	r     io.Reader
+	delay time.Duration
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	time.Sleep(t.delay)
+	return t.r.Read(p[:min(len(p), 4)])
+}
+
+// throttledStorage serves its files through a throttledReader
+type throttledStorage struct {
+	storage.Storage
+	delay time.Duration
+}
+
+func (s throttledStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := s.Storage.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{&throttledReader{r: file, delay: s.delay}, file}, nil
+}
+
+// TestAttachmentSlowTransfers tests that uploads and downloads outlast the
+// server's read and write timeouts
+// Not parallel: it overrides the global configuration and attachment storage
+func TestAttachmentSlowTransfers(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.AttachmentMaxSize = 1 << 10
+		cfg.AttachmentAllowedTypes = []string{"text/plain"}
+	})
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	previous := attachmentStorage
+	SetAttachmentStorage(throttledStorage{Storage: store, delay: 20 * time.Millisecond})
+	t.Cleanup(func() { SetAttachmentStorage(previous) })
+
+	env := newTestEnv(t)
+	owner := env.createUser("test-attach-slow")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Slow files"})
+	path := fmt.Sprintf("/api/tasks/%d/attachments", task.ID)
+
+	// A real server, so its deadlines apply; each transfer takes several times longer
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = asUser(r.WithContext(database.ContextWithDB(r.Context(), env.tx)), owner)
+		if r.Method == "POST" {
+			UploadTaskAttachment(w, r)
+			return
+		}
+		DownloadTaskAttachment(w, r)
+	}))
+	server.Config.ReadTimeout = 100 * time.Millisecond
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+
+	contents := strings.Repeat("0123456789", 5)
+	upload := env.uploadRequest(path, "file", "slow.txt", "text/plain", contents)
+	body, _ := io.ReadAll(upload.Body)
+	req, _ := http.NewRequest("POST", server.URL+path, &throttledReader{r: bytes.NewReader(body), delay: 5 * time.Millisecond})
+	req.Header.Set("Content-Type", upload.Header.Get("Content-Type"))
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	var attachment AttachmentResponse
+	err = json.NewDecoder(resp.Body).Decode(&attachment)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || err != nil || attachment.Size != int64(len(contents)) {
+		t.Fatalf("Expected the whole upload stored, got %d %+v (%v)", resp.StatusCode, attachment, err)
+	}
+
+	resp, err = server.Client().Get(fmt.Sprintf("%s%s/%d", server.URL, path, attachment.ID))
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	defer resp.Body.Close()
+	downloaded, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || err != nil || string(downloaded) != contents {
+		t.Errorf("Expected the whole download, got %d %q (%v)", resp.StatusCode, downloaded, err)
+	}
+}
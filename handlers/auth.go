@@ -5,11 +5,13 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/kcansari/task-management-api/apierror"
 	"github.com/kcansari/task-management-api/config"
-	"github.com/kcansari/task-management-api/database"
 	"github.com/kcansari/task-management-api/models"
 	"github.com/kcansari/task-management-api/utils"
+	"gorm.io/gorm"
 )
 
 // RegisterRequest represents the data needed to register a new user
@@ -18,23 +20,78 @@ import (
 type RegisterRequest struct {
 	Email    string `json:"email"`    // User's email address
 	Password string `json:"password"` // Plain text password (will be hashed)
+	// Optional: create a new organization with this name and become its admin
+	// Without it the user joins the default organization as a member
+	Organization string `json:"organization,omitempty"`
+	// Optional: respond with just the token and its expiry, without the user
+	TokenOnly bool `json:"token_only,omitempty"`
 }
 
 // LoginRequest represents the data needed to log in
 type LoginRequest struct {
 	Email    string `json:"email"`    // User's email address
 	Password string `json:"password"` // Plain text password to verify
+	// Optional: respond with just the token and its expiry, without the user
+	TokenOnly bool `json:"token_only,omitempty"`
+}
+
+// TokenResponse is what we send back after successful authentication when
+// the client asked for token_only
+type TokenResponse struct {
+	Token     string    `json:"token"`      // JWT token for future requests
+	ExpiresAt time.Time `json:"expires_at"` // When the token stops working, so clients can get a new one before then
 }
 
 // AuthResponse represents what we send back after successful authentication
 type AuthResponse struct {
-	Token string      `json:"token"` // JWT token for future requests
-	User  models.User `json:"user"`  // User information (without password)
+	TokenResponse
+	User models.User `json:"user"` // User information (without password)
+}
+
+// writeAuthResponse sends the token, and unless tokenOnly the user, after a
+// successful login or registration
+func writeAuthResponse(w http.ResponseWriter, status int, token string, expiresAt time.Time, user models.User, tokenOnly bool) {
+	// Clear the password field before sending user data to client
+	// The `json:"-"` tag in the model already excludes it, but this is extra safety
+	user.Password = ""
+
+	w.WriteHeader(status)
+	tokenResponse := TokenResponse{Token: token, ExpiresAt: expiresAt.UTC()}
+	if tokenOnly {
+		json.NewEncoder(w).Encode(tokenResponse)
+		return
+	}
+	json.NewEncoder(w).Encode(AuthResponse{
+		TokenResponse: tokenResponse,
+		User:          user,
+	})
 }
 
 // ErrorResponse represents an error message we send to clients
 type ErrorResponse struct {
-	Error string `json:"error"` // Human-readable error message
+	Error string        `json:"error"` // Human-readable error message
+	Code  apierror.Code `json:"code"`  // Machine-readable error code for clients to branch on
+}
+
+// writeError sends an error response with the given HTTP status and error code
+// message is the English text; it's translated when the request's
+// Accept-Language asks for a language the catalog supports. Clients that
+// asked for enveloped responses get {"errors": [...]} instead of the flat shape.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code apierror.Code, message string) {
+	message = apierror.Localize(r.Header.Get("Accept-Language"), code, message)
+	if wantsEnvelope(r) {
+		w.Header().Set("Content-Type", apierror.EnvelopeMediaType)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(apierror.NewErrorEnvelope(status, code, message))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: message,
+		Code:  code,
+	})
 }
 
 // Register handles user registration (POST /api/auth/register)
@@ -49,99 +106,126 @@ func Register(w http.ResponseWriter, r *http.Request) {
 	// HTTP methods have specific meanings: POST = create new resource
 	if r.Method != "POST" {
 		// http.StatusMethodNotAllowed = 405
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		// json.NewEncoder(w).Encode() converts a Go struct to JSON and writes to response
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		// writeError converts an ErrorResponse to JSON and writes it to the response
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Parse the JSON request body into our RegisterRequest struct
 	var req RegisterRequest
-	// json.NewDecoder(r.Body).Decode() reads JSON from request and converts to Go struct
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		// If JSON is malformed, return 400 Bad Request
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+	// decodeJSON reads JSON from the request and converts it to a Go struct
+	if !decodeJSON(w, r, &req) {
+		// If JSON is malformed, too large or has unknown fields, decodeJSON already responded
 		return
 	}
 
 	// Basic validation - check if required fields are provided
 	// strings.TrimSpace() removes leading/trailing whitespace
 	if strings.TrimSpace(req.Email) == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Email is required"})
+		writeError(w, r, http.StatusBadRequest, apierror.EmailRequired, "Email is required")
 		return
 	}
 
 	if strings.TrimSpace(req.Password) == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Password is required"})
+		writeError(w, r, http.StatusBadRequest, apierror.PasswordRequired, "Password is required")
 		return
 	}
 
 	// Check if user with this email already exists
-	// database.GetDB() returns our GORM database instance
-	db := database.GetDB()
+	// requestDB returns our GORM database instance bound to this request,
+	// so the queries are cancelled if the client disconnects or they take too long
+	db, cancel := requestDB(r)
+	defer cancel()
 	var existingUser models.User
 	// GORM's Where().First() tries to find one record matching the condition
 	// If no record found, it returns an error
 	result := db.Where("email = ?", req.Email).First(&existingUser)
 	
+	// A timed-out lookup tells us nothing about whether the user exists
+	if writeQueryTimeout(w, r, result.Error) {
+		return
+	}
+
 	// Check if we found a user (no error means user exists)
 	if result.Error == nil {
 		// User already exists - return conflict error
-		w.WriteHeader(http.StatusConflict) // 409 Conflict
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "User with this email already exists"})
+		writeError(w, r, http.StatusConflict, apierror.EmailTaken, "User with this email already exists") // 409 Conflict
 		return
 	}
 
 	// Hash the password before storing it
 	// NEVER store plain text passwords in the database!
-	hashedPassword, err := utils.HashPassword(req.Password)
+	hashedPassword, err := utils.HashPassword(req.Password, config.Get().PasswordHashAlgorithm)
 	if err != nil {
 		// If hashing fails, return internal server error
 		log.Printf("Failed to hash password: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to process password"})
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to process password")
 		return
 	}
 
+	// Organization names are unique; check before doing any work
+	orgName := strings.TrimSpace(req.Organization)
+	if orgName != "" {
+		var existingOrg models.Organization
+		err := db.Where("name = ?", orgName).First(&existingOrg).Error
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		if err == nil {
+			writeError(w, r, http.StatusConflict, apierror.OrganizationTaken, "Organization with this name already exists") // 409 Conflict
+			return
+		}
+	}
+
 	// Create a new user struct with the provided data
 	user := models.User{
 		Email:    req.Email,
 		Password: hashedPassword, // Store the hashed password, not the plain text
+		Role:     models.RoleMember,
 	}
 
-	// Save the user to the database
+	// Save the user (and their new organization, if any) to the database
 	// GORM's Create() inserts a new record and updates the struct with the generated ID
-	if err := db.Create(&user).Error; err != nil {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if orgName == "" {
+			org, err := models.DefaultOrganization(tx)
+			if err != nil {
+				return err
+			}
+			user.OrgID = org.ID
+			return tx.Create(&user).Error
+		}
+
+		// Whoever creates an organization administers it
+		org := models.Organization{Name: orgName}
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		user.OrgID = org.ID
+		user.Role = models.RoleAdmin
+		return tx.Create(&user).Error
+	})
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
 		log.Printf("Failed to create user: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to create user"})
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to create user")
 		return
 	}
 
 	// Generate a JWT token for the new user
 	// Load config to get the JWT secret key
-	cfg := config.Load()
-	token, err := utils.GenerateToken(user.ID, user.Email, cfg.JWTSecret)
+	cfg := config.Get()
+	token, expiresAt, err := utils.GenerateTokenWithExpiry(user.ID, user.Email, user.OrgID, string(user.Role), user.TokenVersion, cfg.JWTSecret, cfg.JWTIssuer)
 	if err != nil {
 		log.Printf("Failed to generate token: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to generate token"})
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to generate token")
 		return
 	}
 
-	// Clear the password field before sending user data to client
-	// The `json:"-"` tag in the model already excludes it, but this is extra safety
-	user.Password = ""
-
 	// Return success response with token and user data
-	w.WriteHeader(http.StatusCreated) // 201 Created
-	json.NewEncoder(w).Encode(AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	writeAuthResponse(w, http.StatusCreated, token, expiresAt, user, req.TokenOnly) // 201 Created
 }
 
 // Login handles user authentication (POST /api/auth/login)
@@ -151,62 +235,115 @@ func Login(w http.ResponseWriter, r *http.Request) {
 
 	// Only allow POST method
 	if r.Method != "POST" {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Parse login request
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
 	// Validate required fields
 	if strings.TrimSpace(req.Email) == "" || strings.TrimSpace(req.Password) == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Email and password are required"})
+		writeError(w, r, http.StatusBadRequest, apierror.CredentialsRequired, "Email and password are required")
 		return
 	}
 
 	// Find user by email
-	db := database.GetDB()
+	db, cancel := requestDB(r)
+	defer cancel()
 	var user models.User
 	if err := db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
 		// User not found - return generic error for security
 		// Don't reveal whether email exists or not to prevent email enumeration attacks
-		w.WriteHeader(http.StatusUnauthorized) // 401 Unauthorized
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid email or password"})
+		writeError(w, r, http.StatusUnauthorized, apierror.InvalidCredentials, "Invalid email or password") // 401 Unauthorized
+		return
+	}
+
+	// Locked accounts are rejected even with the right password
+	// The generic error doesn't tell an attacker the account exists or is locked
+	cfg := config.Get()
+	if user.IsLocked(time.Now()) {
+		log.Printf("Rejected login for locked user %d", user.ID)
+		writeError(w, r, http.StatusUnauthorized, apierror.InvalidCredentials, "Invalid email or password")
 		return
 	}
 
 	// Check if the provided password matches the stored hash
 	if !utils.CheckPassword(req.Password, user.Password) {
+		// Count the failure; enough of them in a row lock the account
+		if cfg.LoginMaxAttempts > 0 {
+			if err := recordFailedLogin(db, user.ID, cfg.LoginMaxAttempts, cfg.LoginLockoutDuration); err != nil {
+				log.Printf("Failed to record failed login for user %d: %v", user.ID, err)
+			}
+		}
 		// Password doesn't match - return same generic error
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid email or password"})
+		writeError(w, r, http.StatusUnauthorized, apierror.InvalidCredentials, "Invalid email or password")
+		return
+	}
+
+	// Deactivated accounts are only told so once the password is right, so
+	// the response reveals nothing to someone guessing
+	if !user.Active {
+		log.Printf("Rejected login for deactivated user %d", user.ID)
+		writeError(w, r, http.StatusForbidden, apierror.AccountDeactivated, "This account has been deactivated") // 403 Forbidden
 		return
 	}
 
+	// Record when and from where the user logged in, and start the failure
+	// count over, in one UPDATE
+	// This is bookkeeping: if it fails the user is still logged in
+	now := time.Now()
+	trustedProxies, err := utils.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Printf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	loginColumns := map[string]interface{}{
+		"last_login_at": now,
+		"last_login_ip": utils.ClientIP(r, trustedProxies),
+	}
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		loginColumns["failed_login_attempts"] = 0
+		loginColumns["locked_until"] = nil
+	}
+	// Upgrade a hash made with an older algorithm (e.g. bcrypt) to the
+	// configured one; the plain text password is only at hand right now
+	if utils.NeedsRehash(user.Password, cfg.PasswordHashAlgorithm) {
+		if rehashed, err := utils.HashPassword(req.Password, cfg.PasswordHashAlgorithm); err != nil {
+			log.Printf("Failed to rehash password for user %d: %v", user.ID, err)
+		} else {
+			loginColumns["password"] = rehashed
+		}
+	}
+	if err := db.Model(&user).UpdateColumns(loginColumns).Error; err != nil {
+		log.Printf("Failed to record login for user %d: %v", user.ID, err)
+	}
+
 	// Generate JWT token for successful login
-	cfg := config.Load()
-	token, err := utils.GenerateToken(user.ID, user.Email, cfg.JWTSecret)
+	token, expiresAt, err := utils.GenerateTokenWithExpiry(user.ID, user.Email, user.OrgID, string(user.Role), user.TokenVersion, cfg.JWTSecret, cfg.JWTIssuer)
 	if err != nil {
 		log.Printf("Failed to generate token: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to generate token"})
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to generate token")
 		return
 	}
 
-	// Clear password before sending response
-	user.Password = ""
-
 	// Return success response
-	w.WriteHeader(http.StatusOK) // 200 OK
-	json.NewEncoder(w).Encode(AuthResponse{
-		Token: token,
-		User:  user,
-	})
-}
\ No newline at end of file
+	writeAuthResponse(w, http.StatusOK, token, expiresAt, user, req.TokenOnly) // 200 OK
+}
+
+// recordFailedLogin counts a wrong password for a user
+// When the count reaches maxAttempts the account is locked for lockout and the
+// count starts over. A single UPDATE does both, so concurrent attempts can't
+// slip past the threshold.
+func recordFailedLogin(db *gorm.DB, userID uint, maxAttempts int, lockout time.Duration) error {
+	lockedUntil := time.Now().Add(lockout)
+	return db.Model(&models.User{}).Where("id = ?", userID).UpdateColumns(map[string]interface{}{
+		"failed_login_attempts": gorm.Expr("CASE WHEN failed_login_attempts + 1 >= ? THEN 0 ELSE failed_login_attempts + 1 END", maxAttempts),
+		"locked_until":          gorm.Expr("CASE WHEN failed_login_attempts + 1 >= ? THEN ? ELSE locked_until END", maxAttempts, lockedUntil),
+	}).Error
+}
@@ -1,38 +1,22 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/kcansari/task-management-api/config"
-	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/utils"
 )
 
-// setupTestDB initializes a test database connection
-// In a real application, you'd use a separate test database
-func setupTestDB(t *testing.T) {
-	// Load test configuration
-	cfg := config.Load()
-	
-	// Initialize database
-	if err := database.Initialize(cfg); err != nil {
-		t.Fatalf("Failed to initialize test database: %v", err)
-	}
-	
-	// Clean up any existing test data
-	// In production, you'd use database transactions that rollback
-	db := database.GetDB()
-	db.Exec("DELETE FROM tasks WHERE user_id IN (SELECT id FROM users WHERE email LIKE '%test%')")
-	db.Exec("DELETE FROM users WHERE email LIKE '%test%'")
-}
-
 // TestRegisterHandler tests the user registration endpoint
 func TestRegisterHandler(t *testing.T) {
-	// Setup test database
-	setupTestDB(t)
+	t.Parallel()
+
+	// Each test runs in its own transaction that is rolled back afterwards
+	env := newTestEnv(t)
+	registerEmail := uniqueEmail("test-register")
 
 	testCases := []struct {
 		name           string
@@ -43,7 +27,7 @@ func TestRegisterHandler(t *testing.T) {
 		{
 			name: "valid registration",
 			requestBody: RegisterRequest{
-				Email:    "test-register@example.com",
+				Email:    registerEmail,
 				Password: "testpassword123",
 			},
 			expectedStatus: http.StatusCreated, // 201
@@ -61,7 +45,7 @@ func TestRegisterHandler(t *testing.T) {
 		{
 			name: "missing password",
 			requestBody: RegisterRequest{
-				Email:    "test-nopass@example.com",
+				Email:    uniqueEmail("test-nopass"),
 				Password: "",
 			},
 			expectedStatus: http.StatusBadRequest, // 400
@@ -76,7 +60,7 @@ func TestRegisterHandler(t *testing.T) {
 		{
 			name: "duplicate email",
 			requestBody: RegisterRequest{
-				Email:    "test-register@example.com", // Same as first test
+				Email:    registerEmail, // Same as first test
 				Password: "anotherpassword",
 			},
 			expectedStatus: http.StatusConflict, // 409
@@ -103,15 +87,11 @@ func TestRegisterHandler(t *testing.T) {
 
 			// Create HTTP request
 			// httptest.NewRequest creates a test HTTP request
-			req := httptest.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(requestBody))
-			req.Header.Set("Content-Type", "application/json")
+			// The request is bound to the test transaction
+			req := env.newRequest("POST", "/api/auth/register", string(requestBody))
 
-			// Create response recorder to capture handler output
-			// httptest.NewRecorder implements http.ResponseWriter for testing
-			rr := httptest.NewRecorder()
-
-			// Call the handler
-			Register(rr, req)
+			// Call the handler and capture its output
+			rr := env.serve(Register, req)
 
 			// Check status code
 			if rr.Code != tc.expectedStatus {
@@ -151,11 +131,13 @@ func TestRegisterHandler(t *testing.T) {
 
 // TestLoginHandler tests the user login endpoint
 func TestLoginHandler(t *testing.T) {
-	// Setup test database
-	setupTestDB(t)
+	t.Parallel()
+
+	// Setup isolated test database
+	env := newTestEnv(t)
 
 	// Create a test user first
-	testEmail := "test-login@example.com"
+	testEmail := uniqueEmail("test-login")
 	testPassword := "testpassword123"
 	
 	// Register user using the handler to ensure consistent setup
@@ -163,11 +145,7 @@ func TestLoginHandler(t *testing.T) {
 		Email:    testEmail,
 		Password: testPassword,
 	}
-	registerBody, _ := json.Marshal(registerReq)
-	req := httptest.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(registerBody))
-	req.Header.Set("Content-Type", "application/json")
-	rr := httptest.NewRecorder()
-	Register(rr, req)
+	rr := env.serve(Register, env.newRequest("POST", "/api/auth/register", registerReq))
 	
 	if rr.Code != http.StatusCreated {
 		t.Fatalf("Failed to create test user: status %d", rr.Code)
@@ -200,7 +178,7 @@ func TestLoginHandler(t *testing.T) {
 		{
 			name: "non-existent user",
 			requestBody: LoginRequest{
-				Email:    "nonexistent@example.com",
+				Email:    uniqueEmail("nonexistent"),
 				Password: testPassword,
 			},
 			expectedStatus: http.StatusUnauthorized, // 401
@@ -228,18 +206,9 @@ func TestLoginHandler(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Create request
-			requestBody, err := json.Marshal(tc.requestBody)
-			if err != nil {
-				t.Fatalf("Failed to marshal request body: %v", err)
-			}
-
-			req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(requestBody))
-			req.Header.Set("Content-Type", "application/json")
-			rr := httptest.NewRecorder()
-
-			// Call handler
-			Login(rr, req)
+			// Create request and call handler
+			req := env.newRequest("POST", "/api/auth/login", tc.requestBody)
+			rr := env.serve(Login, req)
 
 			// Check status code
 			if rr.Code != tc.expectedStatus {
@@ -269,7 +238,9 @@ func TestLoginHandler(t *testing.T) {
 
 // TestMethodNotAllowed tests that auth endpoints reject non-POST methods
 func TestMethodNotAllowed(t *testing.T) {
-	setupTestDB(t)
+	t.Parallel()
+
+	// Method checks happen before any database access, so no database is needed
 
 	// Test different HTTP methods on register endpoint
 	methods := []string{"GET", "PUT", "DELETE", "PATCH"}
@@ -299,4 +270,46 @@ func TestMethodNotAllowed(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+// TestAuthTokenOnly tests expires_at and the token_only option of register and login
+func TestAuthTokenOnly(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	email := uniqueEmail("test-token-only")
+
+	// checkToken checks the token and its expiry, and returns the rest of the response
+	checkToken := func(rr *httptest.ResponseRecorder, status int) map[string]json.RawMessage {
+		t.Helper()
+		if rr.Code != status {
+			t.Fatalf("Expected status %d, got %d: %s", status, rr.Code, rr.Body.String())
+		}
+		var response TokenResponse
+		env.decode(rr, &response)
+		claims, err := utils.ValidateToken(response.Token, []string{config.Get().JWTSecret}, config.Get().JWTIssuer)
+		if err != nil {
+			t.Fatalf("Failed to validate token: %v", err)
+		}
+		if !claims.ExpiresAt.Time.Equal(response.ExpiresAt) {
+			t.Errorf("Expected expires_at %v to match the token's exp claim %v", response.ExpiresAt, claims.ExpiresAt.Time)
+		}
+		var fields map[string]json.RawMessage
+		env.decode(rr, &fields)
+		return fields
+	}
+
+	rr := env.serve(Register, env.newRequest("POST", "/api/auth/register", RegisterRequest{Email: email, Password: "testpassword123", TokenOnly: true}))
+	if fields := checkToken(rr, http.StatusCreated); len(fields) != 2 {
+		t.Errorf("Expected only token and expires_at, got %s", rr.Body.String())
+	}
+
+	rr = env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: email, Password: "testpassword123", TokenOnly: true}))
+	if fields := checkToken(rr, http.StatusOK); len(fields) != 2 {
+		t.Errorf("Expected only token and expires_at, got %s", rr.Body.String())
+	}
+
+	// The user is still sent by default
+	rr = env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: email, Password: "testpassword123"}))
+	if fields := checkToken(rr, http.StatusOK); fields["user"] == nil {
+		t.Errorf("Expected the user in the default response, got %s", rr.Body.String())
+	}
+}
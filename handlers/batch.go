@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxBatchSize limits how many tasks one batch request may touch
+const maxBatchSize = 100
+
+// BatchStatusRequest represents a status change for several tasks at once
+type BatchStatusRequest struct {
+	IDs    []TaskRef         `json:"ids"`    // Task IDs to update (required); public IDs with TASK_ID_FORMAT=uuid
+	Status models.TaskStatus `json:"status"` // New status for all of them (required)
+}
+
+// BatchStatusResponse reports the outcome of a batch status update
+type BatchStatusResponse struct {
+	Updated    int64     `json:"updated"`           // Number of tasks that now have the new status
+	UpdatedIDs []TaskRef `json:"updated_ids"`       // IDs of those tasks, in request order
+	Skipped    []TaskRef `json:"skipped"`           // IDs that don't exist, aren't owned by the user, can't make the transition, or would exceed the status's limit
+	DryRun     bool      `json:"dry_run,omitempty"` // ?dry_run=true: the counts are what would have happened, nothing changed
+}
+
+// BatchItemResult is the outcome for one ID of a batch run with ?atomic=false
+type BatchItemResult struct {
+	Index  int            `json:"index"`           // Position of the ID in the request's ids
+	ID     TaskRef        `json:"id"`              // The task ID, as in the request
+	Status int            `json:"status"`          // HTTP status the task would have got on its own, e.g. 200 or 404
+	Error  *ErrorResponse `json:"error,omitempty"` // Why it failed; omitted for 200
+}
+
+// BatchItemsResponse is the 207 Multi-Status body of a batch run with ?atomic=false
+type BatchItemsResponse struct {
+	Succeeded int               `json:"succeeded"` // Number of results with status 200
+	Failed    int               `json:"failed"`    // Number of the others
+	Results   []BatchItemResult `json:"results"`   // One per requested ID, in request order
+}
+
+// statusTransitionError means the workflow doesn't allow a task's status change
+type statusTransitionError struct {
+	From, To models.TaskStatus
+}
+
+func (e *statusTransitionError) Error() string {
+	return "cannot change status from " + string(e.From) + " to " + string(e.To)
+}
+
+// parseAtomic reads the ?atomic= parameter of a batch endpoint
+// Absent means true: the batch succeeds or fails as a whole. false processes
+// every item on its own (see BatchItemsResponse).
+func parseAtomic(w http.ResponseWriter, r *http.Request) (atomic bool, ok bool) {
+	value := r.URL.Query().Get("atomic")
+	if value == "" {
+		return true, true
+	}
+
+	atomic, err := strconv.ParseBool(value)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidAtomic, "atomic must be true or false")
+		return false, false
+	}
+	return atomic, true
+}
+
+// BatchUpdateTaskStatus handles POST /api/tasks/batch-status - Change the status of several tasks
+func BatchUpdateTaskStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// ?dry_run=true reports what would change without changing it
+	dryRun, ok := parseDryRun(w, r)
+	if !ok {
+		return
+	}
+
+	// ?atomic=false updates each task on its own and reports on each (207)
+	atomic, ok := parseAtomic(w, r)
+	if !ok {
+		return
+	}
+	if dryRun && !atomic {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidDryRun, "dry_run can't be combined with atomic=false")
+		return
+	}
+
+	// Parse request body
+	var req BatchStatusRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		writeError(w, r, http.StatusBadRequest, apierror.BatchIDsRequired, "ids is required")
+		return
+	}
+
+	if len(req.IDs) > maxBatchSize {
+		writeError(w, r, http.StatusBadRequest, apierror.BatchTooLarge, fmt.Sprintf("Too many ids (maximum is %d)", maxBatchSize))
+		return
+	}
+
+	// Same validation as a single update
+	workflow, ok := validateTaskStatus(w, r, req.Status, "Failed to update tasks")
+	if !ok {
+		return
+	}
+
+	// Public IDs are looked up first, so the rest works with sequential IDs
+	// (with their own query timeout, like each item of atomic=false)
+	err := func() error {
+		db, cancel := requestDB(r)
+		defer cancel()
+		return resolveTaskRefs(db, req.IDs, user)
+	}()
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to resolve task IDs for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to update tasks")
+		return
+	}
+
+	if !atomic {
+		batchUpdateTaskStatusItems(w, r, user, req, workflow)
+		return
+	}
+
+	// Drop duplicate IDs but keep the request order for the skipped list
+	seen := make(map[TaskRef]bool)
+	refs := make([]TaskRef, 0, len(req.IDs))
+	ids := make([]uint, 0, len(req.IDs))
+	for _, ref := range req.IDs {
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+			ids = append(ids, ref.ID)
+		}
+	}
+
+	// Concurrent updates of these tasks wait for (or are refused by) this
+	// batch, as they do for a single update; a dry run changes nothing
+	if !dryRun {
+		unlock, ok := lockTasksForUpdate(w, r, ids)
+		if !ok {
+			return
+		}
+		defer unlock()
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	response := BatchStatusResponse{UpdatedIDs: make([]TaskRef, 0), Skipped: make([]TaskRef, 0), DryRun: dryRun}
+	var updated []models.Task        // Tasks after the change, for webhook events
+	var previous []models.TaskStatus // Their status before the change
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Load the caller's tasks among the requested IDs, locked so none of
+		// them can be transferred away before the update below
+		// Tasks belonging to other users simply aren't found and end up skipped
+		owned, err := ownedTaskIDs(tx.Clauses(clause.Locking{Strength: "UPDATE"}), ids, user)
+		if err != nil {
+			return err
+		}
+		var tasks []models.Task
+		if err := tx.Where("id IN ?", owned).Find(&tasks).Error; err != nil {
+			return err
+		}
+
+		byID := make(map[uint]models.Task, len(tasks))
+		for _, task := range tasks {
+			byID[task.ID] = task
+		}
+
+		// TASK_STATUS_LIMITS: only as many tasks as there is room for may move
+		// in, the first ones in request order
+		room, limit, err := statusLimitRoom(tx, user.UserID, req.Status, config.Get())
+		if err != nil {
+			return err
+		}
+
+		// Split the IDs into tasks that may move to the new status and skipped ones
+		var eligible []uint
+		var history []models.TaskStatusHistory
+		var eligibleRefs []TaskRef
+		for _, ref := range refs {
+			task, found := byID[ref.ID]
+			if !found || !workflow.CanTransition(task.Status, req.Status) {
+				response.Skipped = append(response.Skipped, ref)
+				continue
+			}
+			if limit > 0 && task.Status != req.Status {
+				if room <= 0 {
+					response.Skipped = append(response.Skipped, ref)
+					continue
+				}
+				room--
+			}
+			eligible = append(eligible, ref.ID)
+			eligibleRefs = append(eligibleRefs, ref)
+			if task.Status != req.Status {
+				history = append(history, models.TaskStatusHistory{
+					TaskID:     ref.ID,
+					FromStatus: task.Status,
+					ToStatus:   req.Status,
+					UserID:     user.UserID,
+				})
+			}
+		}
+
+		if len(eligible) == 0 {
+			return nil
+		}
+		response.UpdatedIDs = eligibleRefs
+
+		// A dry run stops here: the selection is done, nothing is written,
+		// and with updated left empty no cache entries or webhooks are touched
+		if dryRun {
+			response.Updated = int64(len(eligible))
+			return nil
+		}
+
+		// One UPDATE for all rows; UpdateColumns skips hooks, so updated_at is set explicitly
+		// Completed tasks are always at 100% progress, like after a single update,
+		// and keep the completion time they had if they already were completed
+		now := time.Now()
+		columns := map[string]interface{}{
+			"status":       req.Status,
+			"updated_at":   now,
+			"completed_at": nil,
+		}
+		if req.Status == models.TaskStatusCompleted {
+			columns["progress"] = maxTaskProgress
+			columns["completed_at"] = gorm.Expr("CASE WHEN status = ? THEN completed_at ELSE ? END", models.TaskStatusCompleted, now)
+		}
+		result := tx.Model(&models.Task{}).Where("id IN ?", eligible).UpdateColumns(columns)
+		if result.Error != nil {
+			return result.Error
+		}
+		response.Updated = result.RowsAffected
+
+		for _, id := range eligible {
+			task := byID[id]
+			previous = append(previous, task.Status)
+			from := task.Status
+			task.Status = req.Status
+			task.UpdatedAt = now
+			completeTaskProgress(&task)
+			trackTaskCompletion(&task, from, now)
+			updated = append(updated, task)
+		}
+
+		// Record the real status changes, like a single update does
+		if len(history) > 0 {
+			return tx.Create(&history).Error
+		}
+		return nil
+	})
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to batch update tasks for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to update tasks")
+		return
+	}
+
+	// Changed tasks must be read fresh by GetTask
+	for _, task := range updated {
+		forgetCachedTaskForAll(db, task)
+	}
+
+	// Same webhook events as updating each task on its own
+	if len(updated) > 0 {
+		hooks := userWebhooks(db, user.UserID)
+		for i, task := range updated {
+			sendTaskEvents(hooks, task, taskUpdateEvents(previous[i], task)...)
+		}
+	}
+
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// batchUpdateTaskStatusItems is BatchUpdateTaskStatus with ?atomic=false
+// Every ID is handled like a single update, in its own transaction and with
+// its own query timeout, so one that fails (not found, a disallowed
+// transition, a full status, even a database error) doesn't affect the
+// others. The response is 207 Multi-Status with a result per ID; an ID listed
+// twice gets two.
+func batchUpdateTaskStatusItems(w http.ResponseWriter, r *http.Request, user middleware.UserContext, req BatchStatusRequest, workflow *models.Workflow) {
+	response := BatchItemsResponse{Results: make([]BatchItemResult, 0, len(req.IDs))}
+	for i, ref := range req.IDs {
+		result := BatchItemResult{Index: i, ID: ref, Status: http.StatusOK}
+
+		db, cancel := requestDB(r)
+		task, previous, err := lockedTaskStatusItem(r.Context(), db, user, ref.ID, req.Status, workflow)
+		if err != nil {
+			result.Status, result.Error = batchItemError(r, user, ref.ID, err)
+			response.Failed++
+		} else {
+			response.Succeeded++
+
+			// Same cache and webhook upkeep as a single update
+			forgetCachedTaskForAll(db, task)
+			publishTaskEvents(db, task, taskUpdateEvents(previous, task)...)
+		}
+		cancel()
+
+		response.Results = append(response.Results, result)
+	}
+
+	writeResponse(w, r, http.StatusMultiStatus, response)
+}
+
+// lockedTaskStatusItem is updateTaskStatusItem holding the task's update lock
+func lockedTaskStatusItem(ctx context.Context, db *gorm.DB, user middleware.UserContext, id uint, status models.TaskStatus, workflow *models.Workflow) (models.Task, models.TaskStatus, error) {
+	unlock, err := acquireTaskLock(ctx, id)
+	if err != nil {
+		return models.Task{}, "", err
+	}
+	defer unlock()
+	return updateTaskStatusItem(db, user, id, status, workflow)
+}
+
+// updateTaskStatusItem moves one of the user's tasks to status in its own transaction
+// It returns the updated task and its status before.
+func updateTaskStatusItem(db *gorm.DB, user middleware.UserContext, id uint, status models.TaskStatus, workflow *models.Workflow) (task models.Task, previous models.TaskStatus, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if task, err = findOwnedTask(tx, id, user); err != nil {
+			return err
+		}
+		previous = task.Status
+		if !workflow.CanTransition(task.Status, status) {
+			return &statusTransitionError{From: task.Status, To: status}
+		}
+		if task.Status != status {
+			if err := checkStatusLimit(tx, user.UserID, status, config.Get()); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		task.Status = status
+		task.UpdatedAt = now
+		completeTaskProgress(&task)
+		trackTaskCompletion(&task, previous, now)
+		if err := tx.Model(&task).UpdateColumns(map[string]interface{}{
+			"status":       task.Status,
+			"updated_at":   now,
+			"progress":     task.Progress,
+			"completed_at": task.CompletedAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		if task.Status == previous {
+			return nil
+		}
+		return tx.Create(&models.TaskStatusHistory{
+			TaskID:     task.ID,
+			FromStatus: previous,
+			ToStatus:   task.Status,
+			UserID:     user.UserID,
+		}).Error
+	})
+	return task, previous, err
+}
+
+// batchItemError turns the error of one batch item into its status and error,
+// the ones the item would have got as a single update
+func batchItemError(r *http.Request, user middleware.UserContext, id uint, err error) (int, *ErrorResponse) {
+	status, code, message := http.StatusInternalServerError, apierror.InternalError, "Failed to update task"
+
+	var transitionErr *statusTransitionError
+	var limitErr *statusLimitError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status, code, message = http.StatusNotFound, apierror.TaskNotFound, "Task not found"
+	case errors.As(err, &transitionErr):
+		status, code, message = http.StatusConflict, apierror.InvalidStatusTransition, "Cannot change status from "+string(transitionErr.From)+" to "+string(transitionErr.To)
+	case errors.As(err, &limitErr):
+		status, code, message = http.StatusConflict, apierror.StatusLimitReached, limitErr.message()
+	case errors.Is(err, errTaskUpdateInProgress):
+		status, code, message = http.StatusConflict, apierror.TaskUpdateInProgress, "Task is being updated by another request; try again"
+	case database.IsUnavailable(err):
+		status, code, message = http.StatusServiceUnavailable, apierror.DatabaseUnavailable, "Database is unavailable, try again shortly"
+	case errors.Is(err, context.DeadlineExceeded):
+		status, code, message = http.StatusGatewayTimeout, apierror.QueryTimeout, "Database query timed out"
+	case errors.Is(err, context.Canceled):
+		status, code, message = http.StatusServiceUnavailable, apierror.RequestCancelled, "Request was cancelled"
+	default:
+		log.Printf("Failed to batch update task %d for user %d: %v", id, user.UserID, err)
+	}
+
+	message = apierror.Localize(r.Header.Get("Accept-Language"), code, message)
+	return status, &ErrorResponse{Error: message, Code: code}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestBatchUpdateTaskStatusHandler tests changing the status of several tasks at once
+func TestBatchUpdateTaskStatusHandler(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-batch-status")
+	other := env.createUser("test-batch-other")
+
+	first := env.createTask(user, CreateTaskRequest{Title: "First"})
+	second := env.createTask(user, CreateTaskRequest{Title: "Second"})
+	done := env.createTask(user, CreateTaskRequest{Title: "Already done", Status: models.TaskStatusCompleted})
+	foreign := env.createTask(other, CreateTaskRequest{Title: "Not mine"})
+
+	testCases := []struct {
+		name           string
+		requestBody    interface{}
+		expectedStatus int
+	}{
+		{"empty id list", BatchStatusRequest{IDs: taskRefs(), Status: models.TaskStatusCompleted}, http.StatusBadRequest},
+		{"missing ids", BatchStatusRequest{Status: models.TaskStatusCompleted}, http.StatusBadRequest},
+		{"invalid status", BatchStatusRequest{IDs: taskRefs(first.ID), Status: "archived"}, http.StatusBadRequest},
+		{"missing status", BatchStatusRequest{IDs: taskRefs(first.ID)}, http.StatusBadRequest},
+		{"invalid JSON", "not-json", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status", tc.requestBody), user))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	// Age the tasks so the bump of updated_at is observable
+	past := time.Now().Add(-time.Hour)
+	if err := env.tx.Model(&models.Task{}).
+		Where("id IN ?", []uint{first.ID, second.ID, done.ID}).
+		UpdateColumn("updated_at", past).Error; err != nil {
+		t.Fatalf("Failed to age tasks: %v", err)
+	}
+
+	body := BatchStatusRequest{
+		IDs:    taskRefs(first.ID, second.ID, done.ID, foreign.ID, 999999, first.ID),
+		Status: models.TaskStatusCompleted,
+	}
+	rr := env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status", body), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response BatchStatusResponse
+	env.decode(rr, &response)
+	if response.Updated != 3 {
+		t.Errorf("Expected 3 updated tasks, got %d", response.Updated)
+	}
+	if len(response.Skipped) != 2 || response.Skipped[0].ID != foreign.ID || response.Skipped[1].ID != 999999 {
+		t.Errorf("Expected skipped [%d 999999], got %v", foreign.ID, response.Skipped)
+	}
+
+	// Every affected row has the new status and a fresh updated_at
+	var tasks []models.Task
+	env.tx.Where("id IN ?", []uint{first.ID, second.ID, done.ID}).Find(&tasks)
+	for _, task := range tasks {
+		if task.Status != models.TaskStatusCompleted {
+			t.Errorf("Task %d: expected status completed, got %s", task.ID, task.Status)
+		}
+		if !task.UpdatedAt.After(past) {
+			t.Errorf("Task %d: expected updated_at to be bumped, got %v", task.ID, task.UpdatedAt)
+		}
+	}
+
+	// The other user's task is untouched
+	var untouched models.Task
+	env.tx.First(&untouched, foreign.ID)
+	if untouched.Status != models.TaskStatusPending {
+		t.Errorf("Expected other user's task to stay pending, got %s", untouched.Status)
+	}
+
+	// Only real changes are logged: two tasks moved, the completed one didn't
+	var historyCount int64
+	env.tx.Model(&models.TaskStatusHistory{}).
+		Where("task_id IN ?", []uint{first.ID, second.ID, done.ID}).
+		Count(&historyCount)
+	if historyCount != 2 {
+		t.Errorf("Expected 2 history entries, got %d", historyCount)
+	}
+}
+
+// TestBatchUpdateTaskStatusNonAtomic tests ?atomic=false, which reports on every ID
+// Not parallel: it sets TASK_TRANSITIONS and TASK_STATUS_LIMITS
+func TestBatchUpdateTaskStatusNonAtomic(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskTransitions = []string{"pending>in_progress", "in_progress>completed"}
+		cfg.TaskStatusLimits = []string{"in_progress=2"}
+	})
+	env := newTestEnv(t)
+	user := env.createUser("test-batch-items")
+	other := env.createUser("test-batch-items-other")
+
+	first := env.createTask(user, CreateTaskRequest{Title: "First"})
+	second := env.createTask(user, CreateTaskRequest{Title: "Second"})
+	third := env.createTask(user, CreateTaskRequest{Title: "Third"})
+	done := env.createTask(user, CreateTaskRequest{Title: "Done", Status: models.TaskStatusCompleted})
+	foreign := env.createTask(other, CreateTaskRequest{Title: "Not mine"})
+
+	// The limit leaves room for two tasks: the first two move in, the third doesn't
+	body := BatchStatusRequest{
+		IDs:    taskRefs(first.ID, foreign.ID, done.ID, second.ID, third.ID),
+		Status: models.TaskStatusInProgress,
+	}
+	rr := env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status?atomic=false", body), user))
+	if rr.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected 207, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response BatchItemsResponse
+	env.decode(rr, &response)
+	if response.Succeeded != 2 || response.Failed != 3 || len(response.Results) != 5 {
+		t.Fatalf("Expected 2 successes and 3 failures, got %+v", response)
+	}
+	expected := []struct {
+		status int
+		code   apierror.Code
+	}{
+		{http.StatusOK, ""},
+		{http.StatusNotFound, apierror.TaskNotFound},
+		{http.StatusConflict, apierror.InvalidStatusTransition},
+		{http.StatusOK, ""},
+		{http.StatusConflict, apierror.StatusLimitReached},
+	}
+	for i, want := range expected {
+		result := response.Results[i]
+		if result.Index != i || result.ID != body.IDs[i] || result.Status != want.status {
+			t.Errorf("Result %d: expected index %d, id %v and status %d, got %+v", i, i, body.IDs[i], want.status, result)
+		}
+		if (want.code == "") != (result.Error == nil) || (result.Error != nil && result.Error.Code != want.code) {
+			t.Errorf("Result %d: expected error code %q, got %+v", i, want.code, result.Error)
+		}
+	}
+
+	// The successes were saved, with their history, despite the failures
+	for _, task := range []TaskResponse{first, second} {
+		var stored models.Task
+		if err := env.tx.First(&stored, task.ID).Error; err != nil {
+			t.Fatalf("Failed to load task: %v", err)
+		}
+		if stored.Status != models.TaskStatusInProgress {
+			t.Errorf("Expected task %d to be in progress, got %s", task.ID, stored.Status)
+		}
+		var history int64
+		env.tx.Model(&models.TaskStatusHistory{}).Where("task_id = ? AND to_status = ?", task.ID, models.TaskStatusInProgress).Count(&history)
+		if history != 1 {
+			t.Errorf("Expected a history entry for task %d, got %d", task.ID, history)
+		}
+	}
+	var stored models.Task
+	if err := env.tx.First(&stored, third.ID).Error; err != nil || stored.Status != models.TaskStatusPending {
+		t.Errorf("Expected the third task to stay pending, got %s (%v)", stored.Status, err)
+	}
+
+	// Bad parameters fail the whole request
+	for _, query := range []string{"atomic=maybe", "atomic=false&dry_run=true"} {
+		rr := env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status?"+query, body), user))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TaskBoard maps each configured status to the caller's tasks with that status
+type TaskBoard map[models.TaskStatus][]TaskResponse
+
+// GetTaskBoard handles GET /api/tasks/board - The caller's tasks grouped by status
+// Every configured status gets a bucket, empty ones included, holding at most
+// BOARD_BUCKET_LIMIT tasks in their manual order. GET /api/tasks/statuses
+// tells how many tasks each bucket has in total.
+func GetTaskBoard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	cfg := config.Get()
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// One query per status keeps each bucket's limit exact; there are only a
+	// handful of statuses. Like the status counts, only the caller's own tasks
+	// are on their board.
+	board := make(TaskBoard, len(cfg.TaskStatuses))
+	for _, status := range cfg.TaskStatuses {
+		query := db.Scopes(ownedBy(user)).Where("status = ?", status).
+			Order("position ASC, id ASC")
+		if cfg.BoardBucketLimit > 0 {
+			query = query.Limit(cfg.BoardBucketLimit)
+		}
+
+		var tasks []models.Task
+		if err := query.Find(&tasks).Error; err != nil {
+			if writeQueryTimeout(w, r, err) {
+				return
+			}
+			log.Printf("Failed to fetch %s tasks for user %d: %v", status, user.UserID, err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch task board")
+			return
+		}
+
+		bucket := make([]TaskResponse, 0, len(tasks)) // Encodes as [] rather than null
+		for _, task := range tasks {
+			bucket = append(bucket, newTaskResponse(task))
+		}
+		board[models.TaskStatus(status)] = bucket
+	}
+
+	writeResponse(w, r, http.StatusOK, board)
+}
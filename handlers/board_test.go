@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestGetTaskBoard tests grouping tasks by status with a per-bucket limit
+// Not parallel: it changes the global configuration
+func TestGetTaskBoard(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser("test-board")
+	other := env.createUser("test-board-other")
+	withConfig(t, func(cfg *config.Config) {
+		cfg.BoardBucketLimit = 2
+	})
+
+	var pending []TaskResponse
+	for i := 1; i <= 3; i++ {
+		pending = append(pending, env.createTask(user, CreateTaskRequest{Title: fmt.Sprintf("Pending %d", i)}))
+	}
+	done := env.createTask(user, CreateTaskRequest{Title: "Done", Status: models.TaskStatusCompleted})
+	env.createTask(other, CreateTaskRequest{Title: "Not on my board"})
+
+	rr := env.serve(GetTaskBoard, asUser(env.newRequest("GET", "/api/tasks/board", nil), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Empty buckets are arrays, not null or missing
+	var raw map[string]json.RawMessage
+	env.decode(rr, &raw)
+	if string(raw["in_progress"]) != "[]" {
+		t.Errorf("Expected an empty in_progress bucket, got %s", raw["in_progress"])
+	}
+
+	var board TaskBoard
+	env.decode(rr, &board)
+	if len(board) != 3 {
+		t.Errorf("Expected a bucket per status, got %d", len(board))
+	}
+
+	// The limit keeps the first tasks in manual order
+	bucket := board[models.TaskStatusPending]
+	if len(bucket) != 2 || bucket[0].ID != pending[0].ID || bucket[1].ID != pending[1].ID {
+		t.Errorf("Expected the first two pending tasks, got %+v", bucket)
+	}
+	if completed := board[models.TaskStatusCompleted]; len(completed) != 1 || completed[0].ID != done.ID {
+		t.Errorf("Expected only the done task as completed, got %+v", completed)
+	}
+}
@@ -0,0 +1,480 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxChecklistTextLength is the longest checklist item, in characters
+const maxChecklistTextLength = 500
+
+// ChecklistItemRequest represents a new checklist item
+type ChecklistItemRequest struct {
+	Text string `json:"text"` // What needs doing (required)
+}
+
+// UpdateChecklistItemRequest represents a change to a checklist item
+// Send {"done": true} to tick an item off, {"done": false} to untick it.
+type UpdateChecklistItemRequest struct {
+	Text Optional[string] `json:"text,omitzero"`
+	Done Optional[bool]   `json:"done,omitzero"`
+}
+
+// ReorderChecklistRequest represents a new order for some of a task's checklist items
+type ReorderChecklistRequest struct {
+	IDs []uint `json:"ids"` // Item IDs in their new order (required)
+}
+
+// ChecklistItemResponse represents a checklist item in API responses
+type ChecklistItemResponse struct {
+	ID        uint      `json:"id"`
+	TaskID    any       `json:"task_id"` // The public UUID with TASK_ID_FORMAT=uuid
+	Text      string    `json:"text"`
+	Done      bool      `json:"done"`
+	Position  float64   `json:"position"` // Items are listed in ascending position
+	CreatedAt Timestamp `json:"created_at"`
+	UpdatedAt Timestamp `json:"updated_at"`
+}
+
+// ChecklistProgress is how much of a task's checklist is done, e.g. 3 of 5
+type ChecklistProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// newChecklistItemResponse converts an item of task's checklist to its API representation
+func newChecklistItemResponse(item models.ChecklistItem, task models.Task) ChecklistItemResponse {
+	return ChecklistItemResponse{
+		ID:        item.ID,
+		TaskID:    referencedTaskID(task),
+		Text:      item.Text,
+		Done:      item.Done,
+		Position:  item.Position,
+		CreatedAt: newTimestamp(item.CreatedAt),
+		UpdatedAt: newTimestamp(item.UpdatedAt),
+	}
+}
+
+// errChecklistItemNotFound means the item doesn't exist or belongs to another task
+var errChecklistItemNotFound = errors.New("checklist item not found")
+
+// validateChecklistText writes a 400 response and returns false if text isn't a valid item
+func validateChecklistText(w http.ResponseWriter, r *http.Request, text string) bool {
+	if strings.TrimSpace(text) == "" {
+		writeError(w, r, http.StatusBadRequest, apierror.ChecklistTextRequired, "Text is required")
+		return false
+	}
+	if utf8.RuneCountInString(text) > maxChecklistTextLength {
+		writeError(w, r, http.StatusBadRequest, apierror.ChecklistTextTooLong, fmt.Sprintf("Text cannot be longer than %d characters", maxChecklistTextLength))
+		return false
+	}
+	return true
+}
+
+// checklistItemIDsFromPath splits /api/tasks/123/checklist/45 into the task and item IDs
+// It writes a 400 response and returns false if either isn't a number
+func checklistItemIDsFromPath(w http.ResponseWriter, r *http.Request) (taskID, itemID uint, ok bool) {
+	taskPath, itemPath, _ := strings.Cut(r.URL.Path, "/checklist/")
+	taskID, err := taskIDFromPath(taskPath, "")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return 0, 0, false
+	}
+	id, err := strconv.ParseUint(itemPath, 10, 32)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidChecklistItemID, "Invalid checklist item ID")
+		return 0, 0, false
+	}
+	return taskID, uint(id), true
+}
+
+// changeChecklist runs change on the caller's task inside a transaction, then saves the checklist counts
+// The task row is locked first, so concurrent changes to the same checklist
+// take turns and the counts can't be computed from a stale view. Any change
+// to the checklist is a change to the task, so updated_at moves as well.
+func changeChecklist(db *gorm.DB, taskID uint, user middleware.UserContext, change func(tx *gorm.DB, task models.Task) error) (models.Task, error) {
+	var task models.Task
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Checklists are private to the owner, like attachments
+		var err error
+		if task, err = findOwnedTask(tx.Clauses(clause.Locking{Strength: "UPDATE"}), taskID, user); err != nil {
+			return err
+		}
+
+		if err := change(tx, task); err != nil {
+			return err
+		}
+
+		var counts struct {
+			Total int
+			Done  int
+		}
+		if err := tx.Model(&models.ChecklistItem{}).
+			Select("COUNT(*) AS total, COUNT(CASE WHEN done THEN 1 END) AS done").
+			Where("task_id = ?", task.ID).
+			Scan(&counts).Error; err != nil {
+			return err
+		}
+
+		task.ChecklistTotal = counts.Total
+		task.ChecklistDone = counts.Done
+		task.UpdatedAt = time.Now()
+		return tx.Model(&task).UpdateColumns(map[string]interface{}{
+			"checklist_total": task.ChecklistTotal,
+			"checklist_done":  task.ChecklistDone,
+			"updated_at":      task.UpdatedAt,
+		}).Error
+	})
+	return task, err
+}
+
+// checklistChanged tells everyone who may hold the old task about the new checklist state
+func checklistChanged(db *gorm.DB, task models.Task) {
+	forgetCachedTaskForAll(db, task)
+	publishTaskEvents(db, task, models.WebhookEventTaskUpdated)
+}
+
+// writeChecklistError writes the response for an error from changeChecklist
+func writeChecklistError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	if writeQueryTimeout(w, r, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, errChecklistItemNotFound):
+		writeError(w, r, http.StatusNotFound, apierror.ChecklistItemNotFound, "Checklist item not found")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+	default:
+		log.Printf("Failed to %s: %v", action, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to "+action)
+	}
+}
+
+// GetChecklist handles GET /api/tasks/{id}/checklist - List a task's checklist items in order
+func GetChecklist(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/checklist
+	taskID, err := taskIDFromPath(r.URL.Path, "/checklist")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	task, err := findOwnedTask(db, taskID, user)
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+		return
+	}
+
+	var items []models.ChecklistItem
+	if err := db.Where("task_id = ?", task.ID).Order("position ASC, id ASC").Find(&items).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch checklist of task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch checklist")
+		return
+	}
+
+	response := make([]ChecklistItemResponse, 0, len(items))
+	for _, item := range items {
+		response = append(response, newChecklistItemResponse(item, task))
+	}
+
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// AddChecklistItem handles POST /api/tasks/{id}/checklist - Add an item to the end of a task's checklist
+func AddChecklistItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/checklist
+	taskID, err := taskIDFromPath(r.URL.Path, "/checklist")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	// Parse request body
+	var req ChecklistItemRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !validateChecklistText(w, r, req.Text) {
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	item := models.ChecklistItem{TaskID: taskID, Text: req.Text}
+	task, err := changeChecklist(db, taskID, user, func(tx *gorm.DB, task models.Task) error {
+		if err := tx.Model(&models.ChecklistItem{}).Where("task_id = ?", task.ID).
+			Select("COALESCE(MAX(position), 0) + 1").Scan(&item.Position).Error; err != nil {
+			return err
+		}
+		return tx.Create(&item).Error
+	})
+	if err != nil {
+		writeChecklistError(w, r, err, "add checklist item")
+		return
+	}
+
+	checklistChanged(db, task)
+	writeResponse(w, r, http.StatusCreated, newChecklistItemResponse(item, task))
+}
+
+// UpdateChecklistItem handles PATCH /api/tasks/{id}/checklist/{item_id} - Tick off, untick or rename an item
+// PUT is accepted as well; either way only the fields sent are changed.
+func UpdateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "PATCH" && r.Method != "PUT" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	taskID, itemID, ok := checklistItemIDsFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req UpdateChecklistItemRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	// Neither field can be cleared: null text counts as empty
+	if req.Text.Set {
+		text := ""
+		if req.Text.Value != nil {
+			text = *req.Text.Value
+		}
+		if !validateChecklistText(w, r, text) {
+			return
+		}
+	}
+	if req.Done.Null() {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidJSON, "done must be true or false")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	var item models.ChecklistItem
+	task, err := changeChecklist(db, taskID, user, func(tx *gorm.DB, task models.Task) error {
+		if err := tx.Where("id = ? AND task_id = ?", itemID, task.ID).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errChecklistItemNotFound
+			}
+			return err
+		}
+		if req.Text.Set {
+			item.Text = *req.Text.Value
+		}
+		if req.Done.Set {
+			item.Done = *req.Done.Value
+		}
+		return tx.Save(&item).Error
+	})
+	if err != nil {
+		writeChecklistError(w, r, err, "update checklist item")
+		return
+	}
+
+	checklistChanged(db, task)
+	writeResponse(w, r, http.StatusOK, newChecklistItemResponse(item, task))
+}
+
+// DeleteChecklistItem handles DELETE /api/tasks/{id}/checklist/{item_id} - Remove an item
+func DeleteChecklistItem(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "DELETE" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	taskID, itemID, ok := checklistItemIDsFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	task, err := changeChecklist(db, taskID, user, func(tx *gorm.DB, task models.Task) error {
+		result := tx.Where("id = ? AND task_id = ?", itemID, task.ID).Delete(&models.ChecklistItem{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errChecklistItemNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		writeChecklistError(w, r, err, "delete checklist item")
+		return
+	}
+
+	checklistChanged(db, task)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderChecklist handles POST /api/tasks/{id}/checklist/reorder - Save a new order for checklist items
+// Like POST /api/tasks/reorder, the listed items swap the positions they
+// already hold, so items that aren't listed keep their place.
+func ReorderChecklist(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/checklist/reorder
+	taskID, err := taskIDFromPath(r.URL.Path, "/checklist/reorder")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	// Parse request body
+	var req ReorderChecklistRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		writeError(w, r, http.StatusBadRequest, apierror.BatchIDsRequired, "ids is required")
+		return
+	}
+
+	if len(req.IDs) > maxBatchSize {
+		writeError(w, r, http.StatusBadRequest, apierror.BatchTooLarge, fmt.Sprintf("Too many ids (maximum is %d)", maxBatchSize))
+		return
+	}
+
+	// An item can only have one place in the order
+	seen := make(map[uint]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidChecklistItemID, fmt.Sprintf("Checklist item %d is listed more than once", id))
+			return
+		}
+		seen[id] = true
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	var items []models.ChecklistItem // The whole checklist, in its new order
+	task, err := changeChecklist(db, taskID, user, func(tx *gorm.DB, task models.Task) error {
+		var listed []models.ChecklistItem
+		if err := tx.Where("id IN ? AND task_id = ?", req.IDs, task.ID).Find(&listed).Error; err != nil {
+			return err
+		}
+		if len(listed) != len(req.IDs) {
+			return errChecklistItemNotFound
+		}
+
+		byID := make(map[uint]models.ChecklistItem, len(listed))
+		slots := make([]float64, 0, len(listed))
+		for _, item := range listed {
+			byID[item.ID] = item
+			slots = append(slots, item.Position)
+		}
+
+		// Hand the occupied positions out again in the requested order
+		slots = reorderSlots(slots)
+		for i, id := range req.IDs {
+			item := byID[id]
+			if item.Position != slots[i] {
+				if err := tx.Model(&item).Update("position", slots[i]).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Where("task_id = ?", task.ID).Order("position ASC, id ASC").Find(&items).Error
+	})
+	if err != nil {
+		writeChecklistError(w, r, err, "reorder checklist")
+		return
+	}
+
+	checklistChanged(db, task)
+
+	response := make([]ChecklistItemResponse, 0, len(items))
+	for _, item := range items {
+		response = append(response, newChecklistItemResponse(item, task))
+	}
+
+	writeResponse(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestChecklist tests adding, ticking off, reordering and deleting checklist items
+func TestChecklist(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-checklist")
+	other := env.createUser("test-checklist-other")
+	task := env.createTask(user, CreateTaskRequest{Title: "Pack for the trip"})
+	path := fmt.Sprintf("/api/tasks/%d/checklist", task.ID)
+
+	var items []ChecklistItemResponse
+	for _, text := range []string{"Passport", "Charger", "Toothbrush"} {
+		rr := env.serve(AddChecklistItem, asUser(env.newRequest("POST", path, ChecklistItemRequest{Text: text}), user))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var item ChecklistItemResponse
+		env.decode(rr, &item)
+		items = append(items, item)
+	}
+
+	// Pretend the task was last touched long ago, so the toggle visibly moves updated_at
+	past := time.Now().Add(-time.Hour)
+	env.tx.Model(&models.Task{}).Where("id = ?", task.ID).UpdateColumn("updated_at", past)
+
+	itemPath := fmt.Sprintf("%s/%d", path, items[1].ID)
+	rr := env.serve(UpdateChecklistItem, asUser(env.newRequest("PATCH", itemPath, `{"done":true}`), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var toggled ChecklistItemResponse
+	env.decode(rr, &toggled)
+	if !toggled.Done || toggled.Text != "Charger" {
+		t.Errorf("Expected Charger to be done, got %+v", toggled)
+	}
+
+	// The task shows the progress and was updated by the toggle
+	rr = env.serve(GetTask, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks/%d", task.ID), nil), user))
+	var got TaskResponse
+	env.decode(rr, &got)
+	if got.ChecklistProgress != (ChecklistProgress{Done: 1, Total: 3}) {
+		t.Errorf("Expected progress 1/3, got %+v", got.ChecklistProgress)
+	}
+	if !got.UpdatedAt.Time().After(past) {
+		t.Errorf("Expected updated_at to move past %s, got %s", past, got.UpdatedAt)
+	}
+
+	// Move the toothbrush to the top
+	reorder := ReorderChecklistRequest{IDs: []uint{items[2].ID, items[0].ID}}
+	rr = env.serve(ReorderChecklist, asUser(env.newRequest("POST", path+"/reorder", reorder), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = env.serve(DeleteChecklistItem, asUser(env.newRequest("DELETE", itemPath, nil), user))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Items come back in position order, without the deleted one
+	rr = env.serve(GetChecklist, asUser(env.newRequest("GET", path, nil), user))
+	var list []ChecklistItemResponse
+	env.decode(rr, &list)
+	if len(list) != 2 || list[0].Text != "Toothbrush" || list[1].Text != "Passport" {
+		t.Errorf("Expected Toothbrush, Passport; got %+v", list)
+	}
+
+	rr = env.serve(GetTask, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks/%d", task.ID), nil), user))
+	env.decode(rr, &got)
+	if got.ChecklistProgress != (ChecklistProgress{Done: 0, Total: 2}) {
+		t.Errorf("Expected progress 0/2 after deleting the done item, got %+v", got.ChecklistProgress)
+	}
+
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		method         string
+		path           string
+		body           interface{}
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"other users can't see the checklist", GetChecklist, "GET", path, nil, http.StatusNotFound, apierror.TaskNotFound},
+		{"other users can't add items", AddChecklistItem, "POST", path, ChecklistItemRequest{Text: "Mine now"}, http.StatusNotFound, apierror.TaskNotFound},
+		{"other users can't tick items off", UpdateChecklistItem, "PATCH", fmt.Sprintf("%s/%d", path, items[0].ID), `{"done":true}`, http.StatusNotFound, apierror.TaskNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(tc.handler, asUser(env.newRequest(tc.method, tc.path, tc.body), other))
+			var errResp ErrorResponse
+			env.decode(rr, &errResp)
+			if rr.Code != tc.expectedStatus || errResp.Code != tc.expectedCode {
+				t.Errorf("Expected %d %s, got %d %s", tc.expectedStatus, tc.expectedCode, rr.Code, errResp.Code)
+			}
+		})
+	}
+}
+
+// TestChecklistValidation tests the errors of the checklist endpoints
+func TestChecklistValidation(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-checklist-validation")
+	task := env.createTask(user, CreateTaskRequest{Title: "Validate"})
+	path := fmt.Sprintf("/api/tasks/%d/checklist", task.ID)
+
+	rr := env.serve(AddChecklistItem, asUser(env.newRequest("POST", path, ChecklistItemRequest{Text: "Item"}), user))
+	var item ChecklistItemResponse
+	env.decode(rr, &item)
+	itemPath := fmt.Sprintf("%s/%d", path, item.ID)
+
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		method         string
+		path           string
+		body           interface{}
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"blank text", AddChecklistItem, "POST", path, ChecklistItemRequest{Text: "  "}, http.StatusBadRequest, apierror.ChecklistTextRequired},
+		{"text too long", AddChecklistItem, "POST", path, ChecklistItemRequest{Text: string(make([]rune, maxChecklistTextLength+1))}, http.StatusBadRequest, apierror.ChecklistTextTooLong},
+		{"null text", UpdateChecklistItem, "PATCH", itemPath, `{"text":null}`, http.StatusBadRequest, apierror.ChecklistTextRequired},
+		{"null done", UpdateChecklistItem, "PATCH", itemPath, `{"done":null}`, http.StatusBadRequest, apierror.InvalidJSON},
+		{"bad item ID", DeleteChecklistItem, "DELETE", path + "/abc", nil, http.StatusBadRequest, apierror.InvalidChecklistItemID},
+		{"missing item", DeleteChecklistItem, "DELETE", path + "/999999", nil, http.StatusNotFound, apierror.ChecklistItemNotFound},
+		{"reorder a missing item", ReorderChecklist, "POST", path + "/reorder", ReorderChecklistRequest{IDs: []uint{item.ID, 999999}}, http.StatusNotFound, apierror.ChecklistItemNotFound},
+		{"reorder a duplicate", ReorderChecklist, "POST", path + "/reorder", ReorderChecklistRequest{IDs: []uint{item.ID, item.ID}}, http.StatusBadRequest, apierror.InvalidChecklistItemID},
+		{"missing task", GetChecklist, "GET", "/api/tasks/999999/checklist", nil, http.StatusNotFound, apierror.TaskNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(tc.handler, asUser(env.newRequest(tc.method, tc.path, tc.body), user))
+			var errResp ErrorResponse
+			env.decode(rr, &errResp)
+			if rr.Code != tc.expectedStatus || errResp.Code != tc.expectedCode {
+				t.Errorf("Expected %d %s, got %d %s", tc.expectedStatus, tc.expectedCode, rr.Code, errResp.Code)
+			}
+		})
+	}
+}
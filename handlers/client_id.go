@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// normalizeClientID checks that id is a UUID (8-4-4-4-12 hex digits) and lowercases it
+// Any UUID version is accepted: the server only needs it to be unique per user.
+func normalizeClientID(id string) (string, bool) {
+	if len(id) != 36 {
+		return "", false
+	}
+	for i, c := range id {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return "", false
+			}
+		case !strings.ContainsRune("0123456789abcdefABCDEF", c):
+			return "", false
+		}
+	}
+	return strings.ToLower(id), true
+}
+
+// findTaskByClientID loads the caller's own task with the given client ID
+func findTaskByClientID(db *gorm.DB, clientID string, user middleware.UserContext) (models.Task, error) {
+	var task models.Task
+	err := db.Scopes(ownedBy(user)).Where("client_id = ?", clientID).First(&task).Error
+	return task, err
+}
+
+// UpsertTaskByClientID handles PUT /api/tasks/by-client-id/{uuid} - Create or update a task by its client ID
+// Offline clients pick the UUID themselves, so they can save a task without
+// knowing whether it reached the server yet. The body is the same as for
+// updates; if no task has the ID yet one is created, and then title is required.
+// Client IDs are scoped to the caller: another user's task with the same
+// UUID is never touched, the caller just gets a task of their own.
+func UpsertTaskByClientID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "PUT" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	clientID, ok := normalizeClientID(strings.TrimPrefix(r.URL.Path, "/api/tasks/by-client-id/"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidClientID, "Client ID must be a UUID")
+		return
+	}
+
+	// Parse request body
+	var req UpdateTaskRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// Reject oversized text and unknown colors before loading anything
+	if !validateTaskText(w, r, req.Title.Value, req.Description.Value) {
+		return
+	}
+	if !validateTaskColor(w, r, req.Color.Value) {
+		return
+	}
+	if !validateTaskProgress(w, r, req.Progress) {
+		return
+	}
+	if !validateTaskVisibility(w, r, req.Visibility) {
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	task, err := findTaskByClientID(db, clientID, user)
+	if err == nil {
+		applyTaskUpdate(w, r, db, user, task, req) // 200 OK
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to look up client ID %s for user %d: %v", clientID, user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to save task")
+		return
+	}
+
+	// No such task yet: create it, with the same rules as POST /api/tasks
+	if !req.Title.Set || req.Title.Null() || strings.TrimSpace(*req.Title.Value) == "" {
+		writeError(w, r, http.StatusBadRequest, apierror.TitleRequired, "Title is required")
+		return
+	}
+	if req.Status.Null() {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidStatus, "Status cannot be null")
+		return
+	}
+
+	var requested models.TaskStatus
+	if req.Status.Set {
+		requested = *req.Status.Value
+	} else if req.Progress.Set && progressCompletesTask(*req.Progress.Value) {
+		requested = models.TaskStatusCompleted
+	}
+	status, ok := newTaskStatus(w, r, db, user, requested)
+	if !ok {
+		return
+	}
+
+	task = models.Task{
+		Title:    *req.Title.Value,
+		Status:   status,
+		UserID:   user.UserID,
+		OrgID:    user.OrgID,
+		DueDate:  req.DueDate.Value,
+		ClientID: &clientID,
+	}
+	if req.Description.Value != nil {
+		task.Description = *req.Description.Value
+	}
+	if req.Color.Value != nil {
+		task.Color = *req.Color.Value
+	}
+	if req.Progress.Value != nil {
+		task.Progress = uint8(*req.Progress.Value)
+	}
+	task.Visibility = models.TaskVisibilityPrivate
+	if req.Visibility.Value != nil {
+		task.Visibility = *req.Visibility.Value
+	}
+	completeTaskProgress(&task)
+	trackTaskCompletion(&task, "", time.Now())
+	if req.Metadata.Value != nil {
+		if task.Metadata, ok = newTaskMetadata(w, r, *req.Metadata.Value); !ok {
+			return
+		}
+	}
+
+	warnings := taskWarnings(db, task, true, true)
+
+	if err := insertTask(db, user, &task); err != nil {
+		// A concurrent request with the same client ID may have created the
+		// task first (the unique index rejected this one); update that instead
+		if existing, findErr := findTaskByClientID(db, clientID, user); findErr == nil {
+			applyTaskUpdate(w, r, db, user, existing, req)
+			return
+		}
+		writeInsertTaskError(w, r, err)
+		return
+	}
+
+	// Let the user's webhooks know (delivered in the background)
+	publishTaskEvents(db, task, models.WebhookEventTaskCreated)
+
+	response := newTaskResponse(task)
+	response.Warnings = warnings
+
+	writeResponse(w, r, http.StatusCreated, response) // 201 Created
+}
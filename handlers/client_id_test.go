@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// TestUpsertTaskByClientID tests creating and then updating a task by its client ID
+func TestUpsertTaskByClientID(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-client-id")
+	other := env.createUser("test-client-id-other")
+	path := "/api/tasks/by-client-id/3F2504E0-4F89-11D3-9A0C-0305E82C3301"
+
+	// The first save creates the task
+	rr := env.serve(UpsertTaskByClientID, asUser(env.newRequest("PUT", path, `{"title":"Offline draft"}`), user))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var created TaskResponse
+	env.decode(rr, &created)
+	if created.ClientID == nil || *created.ClientID != "3f2504e0-4f89-11d3-9a0c-0305e82c3301" {
+		t.Errorf("Expected the lowercased client ID, got %v", created.ClientID)
+	}
+
+	// Retrying updates the same task instead of creating a second one
+	rr = env.serve(UpsertTaskByClientID, asUser(env.newRequest("PUT", path, `{"title":"Synced","status":"in_progress"}`), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var updated TaskResponse
+	env.decode(rr, &updated)
+	if updated.ID != created.ID || updated.Title != "Synced" || updated.Status != "in_progress" {
+		t.Errorf("Expected task %d to be updated, got %+v", created.ID, updated)
+	}
+
+	// The same UUID from another user is their own task, not a takeover
+	rr = env.serve(UpsertTaskByClientID, asUser(env.newRequest("PUT", path, `{"title":"Mine"}`), other))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for another user, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var theirs TaskResponse
+	env.decode(rr, &theirs)
+	if theirs.ID == created.ID {
+		t.Errorf("Expected another user to get a separate task")
+	}
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"not a UUID", "PUT", "/api/tasks/by-client-id/not-a-uuid", `{"title":"X"}`, http.StatusBadRequest, apierror.InvalidClientID},
+		{"misplaced hyphens", "PUT", "/api/tasks/by-client-id/3f2504e04-f89-11d3-9a0c-0305e82c3301", `{"title":"X"}`, http.StatusBadRequest, apierror.InvalidClientID},
+		{"create without a title", "PUT", "/api/tasks/by-client-id/9b2c1d6e-0000-4000-8000-000000000001", `{"description":"No title"}`, http.StatusBadRequest, apierror.TitleRequired},
+		{"create with a null status", "PUT", "/api/tasks/by-client-id/9b2c1d6e-0000-4000-8000-000000000002", `{"title":"X","status":null}`, http.StatusBadRequest, apierror.InvalidStatus},
+		{"null title on update", "PUT", path, `{"title":null}`, http.StatusBadRequest, apierror.TitleRequired},
+		{"wrong method", "POST", path, `{"title":"X"}`, http.StatusMethodNotAllowed, apierror.MethodNotAllowed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(UpsertTaskByClientID, asUser(env.newRequest(tc.method, tc.path, tc.body), user))
+			var errResp ErrorResponse
+			env.decode(rr, &errResp)
+			if rr.Code != tc.expectedStatus || errResp.Code != tc.expectedCode {
+				t.Errorf("Expected %d %s, got %d %s", tc.expectedStatus, tc.expectedCode, rr.Code, errResp.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+)
+
+// normalizeTaskColor checks a task color and returns it in its stored form
+// Accepted are "#RRGGBB" hex values (stored lowercase), the names in
+// TASK_COLORS (case-insensitive, stored lowercase) and "" for no color.
+// ok is false for anything else, e.g. "#12345" or "red!".
+func normalizeTaskColor(color string, names []string) (normalized string, ok bool) {
+	if color == "" {
+		return "", true
+	}
+
+	color = strings.ToLower(color)
+	if isHexColor(color) {
+		return color, true
+	}
+	if slices.Contains(names, color) {
+		return color, true
+	}
+	return "", false
+}
+
+// isHexColor reports whether color is "#" followed by exactly six (lowercase) hex digits
+func isHexColor(color string) bool {
+	if len(color) != 7 || color[0] != '#' {
+		return false
+	}
+	for _, c := range color[1:] {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// validateTaskColor normalizes *color in place when it's being set
+// nil isn't being set and always passes. It writes a 400 response and
+// returns false for an invalid color.
+func validateTaskColor(w http.ResponseWriter, r *http.Request, color *string) bool {
+	if fieldErr := taskColorError(color); fieldErr != nil {
+		writeFieldError(w, r, *fieldErr)
+		return false
+	}
+	return true
+}
+
+// taskColorError is validateTaskColor without the response: nil when the
+// color is valid, in which case it has been normalized
+func taskColorError(color *string) *TaskFieldError {
+	if color == nil {
+		return nil
+	}
+
+	names := config.Get().TaskColors
+	normalized, ok := normalizeTaskColor(*color, names)
+	if !ok {
+		msg := "Invalid color. Use a #RRGGBB hex value"
+		if len(names) > 0 {
+			msg += " or one of: " + strings.Join(names, ", ")
+		}
+		return &TaskFieldError{"color", apierror.InvalidColor, msg}
+	}
+	*color = normalized
+	return nil
+}
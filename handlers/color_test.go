@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// TestNormalizeTaskColor tests which colors are accepted and how they're stored
+func TestNormalizeTaskColor(t *testing.T) {
+	t.Parallel()
+	names := []string{"red", "blue"}
+
+	tests := []struct {
+		color      string
+		expected   string
+		expectedOK bool
+	}{
+		{"", "", true},
+		{"#1a2b3c", "#1a2b3c", true},
+		{"#1A2B3C", "#1a2b3c", true},
+		{"red", "red", true},
+		{"Blue", "blue", true},
+		{"#12345", "", false},
+		{"#1234567", "", false},
+		{"123456", "", false},
+		{"#12345g", "", false},
+		{"#12 456", "", false},
+		{"red!", "", false},
+		{"green", "", false},
+		{" red", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.color, func(t *testing.T) {
+			normalized, ok := normalizeTaskColor(tt.color, names)
+			if ok != tt.expectedOK || normalized != tt.expected {
+				t.Errorf("normalizeTaskColor(%q) = %q, %v; expected %q, %v", tt.color, normalized, ok, tt.expected, tt.expectedOK)
+			}
+		})
+	}
+}
+
+// TestTaskColor tests setting, changing and clearing a task's color
+func TestTaskColor(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-color")
+
+	task := env.createTask(user, CreateTaskRequest{Title: "Card", Color: "#FF8800"})
+	if task.Color != "#ff8800" {
+		t.Fatalf("Expected color #ff8800, got %q", task.Color)
+	}
+
+	update := func(body string) *TaskResponse {
+		t.Helper()
+		rr := env.serve(UpdateTask, asUser(env.newRequest("PUT", fmt.Sprintf("/api/tasks/%d", task.ID), body), user))
+		if rr.Code != http.StatusOK {
+			var resp ErrorResponse
+			env.decode(rr, &resp)
+			if resp.Code != apierror.InvalidColor {
+				t.Fatalf("Expected 200 or INVALID_COLOR, got %d: %s", rr.Code, rr.Body.String())
+			}
+			return nil
+		}
+		var updated TaskResponse
+		env.decode(rr, &updated)
+		return &updated
+	}
+
+	// Updates that don't mention the color keep it
+	if updated := update(`{"title":"Renamed"}`); updated == nil || updated.Color != "#ff8800" {
+		t.Errorf("Expected the color to be kept, got %+v", updated)
+	}
+	if updated := update(`{"color":"red!"}`); updated != nil {
+		t.Errorf("Expected red! to be rejected, got %+v", updated)
+	}
+	if updated := update(`{"color":"Green"}`); updated == nil || updated.Color != "green" {
+		t.Errorf("Expected color green, got %+v", updated)
+	}
+	// An empty string clears it
+	if updated := update(`{"color":""}`); updated == nil || updated.Color != "" {
+		t.Errorf("Expected the color to be cleared, got %+v", updated)
+	}
+
+	rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", CreateTaskRequest{Title: "Bad", Color: "#12345"}), user))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for #12345, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
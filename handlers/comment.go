@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// maxCommentLength is the longest comment, in characters
+const maxCommentLength = 5000
+
+// maxCommentMentions caps how many users one comment can notify
+// Mentions past the cap are left as plain text.
+const maxCommentMentions = 20
+
+// mentionPattern finds @email mentions, e.g. "thanks @ada@example.com!"
+// The @ must start a word, so plain email addresses aren't mentions, and a
+// trailing full stop isn't taken as part of the domain.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.+-])@([\w.+-]+@[\w-]+(?:\.[\w-]+)+)`)
+
+// CommentRequest represents a new comment
+type CommentRequest struct {
+	Body string `json:"body"` // The comment text; @email mentions notify those users (required)
+}
+
+// CommentResponse represents a comment in API responses
+type CommentResponse struct {
+	ID        uint      `json:"id"`
+	TaskID    any       `json:"task_id"` // The public UUID with TASK_ID_FORMAT=uuid
+	UserID    uint      `json:"user_id"` // Author
+	Body      string    `json:"body"`
+	CreatedAt Timestamp `json:"created_at"`
+}
+
+// newCommentResponse converts a comment on task to its API representation
+func newCommentResponse(comment models.Comment, task models.Task) CommentResponse {
+	return CommentResponse{
+		ID:        comment.ID,
+		TaskID:    referencedTaskID(task),
+		UserID:    comment.UserID,
+		Body:      comment.Body,
+		CreatedAt: newTimestamp(comment.CreatedAt),
+	}
+}
+
+// parseMentions returns the distinct emails @-mentioned in body, lowercased, in order
+// Anything that doesn't look like @email is ignored.
+func parseMentions(body string) []string {
+	var emails []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := strings.ToLower(match[1])
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+		emails = append(emails, email)
+		if len(emails) == maxCommentMentions {
+			break
+		}
+	}
+	return emails
+}
+
+// mentionedUserIDs resolves emails to the users who can see task, leaving out the author
+// Like findAccessibleTask, that's the owner, users the task is shared with and
+// the organization's admins. Unknown emails and users without access are
+// dropped silently, so a mention never reveals who exists.
+func mentionedUserIDs(db *gorm.DB, task models.Task, authorID uint, emails []string) ([]uint, error) {
+	var ids []uint
+	if len(emails) == 0 {
+		return ids, nil
+	}
+	sharedWith := db.Model(&models.TaskShare{}).Select("shared_with_user_id").Where("task_id = ?", task.ID)
+	err := db.Model(&models.User{}).
+		Where("LOWER(email) IN ? AND org_id = ? AND id <> ?", emails, task.OrgID, authorID).
+		Where("id = ? OR role = ? OR id IN (?)", task.UserID, models.RoleAdmin, sharedWith).
+		Order("id ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// GetComments handles GET /api/tasks/{id}/comments - List a task's comments, oldest first
+func GetComments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/comments
+	taskID, err := taskIDFromPath(r.URL.Path, "/comments")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	task, err := findAccessibleTask(db, taskID, user, false)
+	if err != nil {
+		writeTaskAccessError(w, r, err)
+		return
+	}
+
+	var comments []models.Comment
+	if err := db.Where("task_id = ?", task.ID).Order("created_at ASC, id ASC").Find(&comments).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch comments of task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch comments")
+		return
+	}
+
+	response := make([]CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		response = append(response, newCommentResponse(comment, task))
+	}
+
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// AddComment handles POST /api/tasks/{id}/comments - Comment on a task
+// Anyone who can see the task can comment, read-only shares included. Users
+// @-mentioned by email who can see the task get a notification, saved in the
+// same transaction as the comment.
+func AddComment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/comments
+	taskID, err := taskIDFromPath(r.URL.Path, "/comments")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	// Parse request body
+	var req CommentRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		writeError(w, r, http.StatusBadRequest, apierror.CommentBodyRequired, "Body is required")
+		return
+	}
+	if utf8.RuneCountInString(req.Body) > maxCommentLength {
+		writeError(w, r, http.StatusBadRequest, apierror.CommentBodyTooLong, fmt.Sprintf("Body cannot be longer than %d characters", maxCommentLength))
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	task, err := findAccessibleTask(db, taskID, user, false)
+	if err != nil {
+		writeTaskAccessError(w, r, err)
+		return
+	}
+
+	comment := models.Comment{TaskID: task.ID, UserID: user.UserID, Body: req.Body}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		mentioned, err := mentionedUserIDs(tx, task, user.UserID, parseMentions(req.Body))
+		if err != nil || len(mentioned) == 0 {
+			return err
+		}
+		notifications := make([]models.Notification, 0, len(mentioned))
+		for _, id := range mentioned {
+			notifications = append(notifications, models.Notification{
+				UserID:    id,
+				Type:      models.NotificationTypeMention,
+				TaskID:    task.ID,
+				CommentID: &comment.ID,
+				ActorID:   user.UserID,
+			})
+		}
+		return tx.Create(&notifications).Error
+	})
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to add comment to task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to add comment")
+		return
+	}
+
+	writeResponse(w, r, http.StatusCreated, newCommentResponse(comment, task)) // 201 Created
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestParseMentions tests which @email mentions are picked out of a comment
+func TestParseMentions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{"single", "@ada@example.com please look", []string{"ada@example.com"}},
+		{"trailing punctuation", "thanks @ada@example.com.", []string{"ada@example.com"}},
+		{"lowercased and deduplicated", "@Ada@Example.com and @ada@example.com", []string{"ada@example.com"}},
+		{"several in order", "(@b@example.com, @a@example.org)", []string{"b@example.com", "a@example.org"}},
+		{"plain email is not a mention", "mail ada@example.com", nil},
+		{"no domain", "@ada please look", nil},
+		{"no top-level domain", "@ada@localhost", nil},
+		{"double at", "@@ada@example.com", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseMentions(tc.body); !slices.Equal(got, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	var many strings.Builder
+	for i := range maxCommentMentions + 5 {
+		fmt.Fprintf(&many, "@user%d@example.com ", i)
+	}
+	if got := parseMentions(many.String()); len(got) != maxCommentMentions {
+		t.Errorf("Expected mentions capped at %d, got %d", maxCommentMentions, len(got))
+	}
+}
+
+// TestCommentMentions tests that only mentioned users who can see the task are notified
+func TestCommentMentions(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-comment-owner")
+	reader := env.createUser("test-comment-reader")
+	unshared := env.createUser("test-comment-unshared")
+	outsider := env.createOrgAdmin("test-comment-outsider")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Discussed"})
+	path := fmt.Sprintf("/api/tasks/%d/comments", task.ID)
+	env.serve(ShareTask, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/shares", task.ID), ShareTaskRequest{UserID: reader.UserID}), owner))
+
+	// A read-only share can still comment, and mentioning the owner notifies them
+	body := fmt.Sprintf("@%s @%s @%s @%s @nobody@example.com @broken@",
+		strings.ToUpper(owner.Email), reader.Email, unshared.Email, outsider.Email)
+	rr := env.serve(AddComment, asUser(env.newRequest("POST", path, CommentRequest{Body: body}), reader))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var comment CommentResponse
+	env.decode(rr, &comment)
+	if comment.TaskID != float64(task.ID) || comment.UserID != reader.UserID || comment.Body != body {
+		t.Errorf("Unexpected comment %+v", comment)
+	}
+
+	var notifications []models.Notification
+	env.tx.Where("comment_id = ?", comment.ID).Find(&notifications)
+	if len(notifications) != 1 {
+		t.Fatalf("Expected only the owner to be notified, got %+v", notifications)
+	}
+	n := notifications[0]
+	if n.UserID != owner.UserID || n.Type != models.NotificationTypeMention || n.TaskID != task.ID || n.ActorID != reader.UserID {
+		t.Errorf("Unexpected notification %+v", n)
+	}
+
+	// Comments are as private as the task
+	rr = env.serve(GetComments, asUser(env.newRequest("GET", path, nil), unshared))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unshared user, got %d", http.StatusNotFound, rr.Code)
+	}
+	rr = env.serve(GetComments, asUser(env.newRequest("GET", path, nil), owner))
+	var comments []CommentResponse
+	env.decode(rr, &comments)
+	if len(comments) != 1 || comments[0].ID != comment.ID {
+		t.Errorf("Expected the one comment, got %+v", comments)
+	}
+
+	testCases := []struct {
+		name         string
+		body         interface{}
+		expectedCode apierror.Code
+	}{
+		{"empty body", CommentRequest{Body: "  "}, apierror.CommentBodyRequired},
+		{"too long", CommentRequest{Body: strings.Repeat("a", maxCommentLength+1)}, apierror.CommentBodyTooLong},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(AddComment, asUser(env.newRequest("POST", path, tc.body), owner))
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusBadRequest, rr.Code, rr.Body.String())
+			}
+			var errResp ErrorResponse
+			env.decode(rr, &errResp)
+			if errResp.Code != tc.expectedCode {
+				t.Errorf("Expected code %s, got %s", tc.expectedCode, errResp.Code)
+			}
+		})
+	}
+}
+
+// TestNotifications tests listing notifications and marking them read
+func TestNotifications(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-notify-owner")
+	writer := env.createUser("test-notify-writer")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Watched"})
+	path := fmt.Sprintf("/api/tasks/%d/comments", task.ID)
+	env.serve(ShareTask, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/shares", task.ID), ShareTaskRequest{UserID: writer.UserID, Permission: models.SharePermissionWrite}), owner))
+
+	for _, body := range []string{"first @" + owner.Email, "second @" + owner.Email, "self @" + writer.Email} {
+		rr := env.serve(AddComment, asUser(env.newRequest("POST", path, CommentRequest{Body: body}), writer))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+	}
+
+	// Mentioning yourself doesn't notify you
+	rr := env.serve(GetNotifications, asUser(env.newRequest("GET", "/api/notifications", nil), writer))
+	var page PaginatedNotificationResponse
+	env.decode(rr, &page)
+	if page.Total != 0 {
+		t.Errorf("Expected no notifications for the author, got %+v", page.Notifications)
+	}
+
+	rr = env.serve(GetNotifications, asUser(env.newRequest("GET", "/api/notifications?page_size=1", nil), owner))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	env.decode(rr, &page)
+	if page.Total != 2 || len(page.Notifications) != 1 || !page.HasNext {
+		t.Fatalf("Expected the first of 2 notifications, got %+v", page)
+	}
+	newest := page.Notifications[0]
+	if newest.ReadAt != nil || newest.ActorID != writer.UserID {
+		t.Errorf("Unexpected notification %+v", newest)
+	}
+
+	readPath := fmt.Sprintf("/api/notifications/%d/read", newest.ID)
+	rr = env.serve(MarkNotificationRead, asUser(env.newRequest("POST", readPath, nil), writer))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for another user's notification, got %d", http.StatusNotFound, rr.Code)
+	}
+	var errResp ErrorResponse
+	env.decode(rr, &errResp)
+	if errResp.Code != apierror.NotificationNotFound {
+		t.Errorf("Expected code %s, got %s", apierror.NotificationNotFound, errResp.Code)
+	}
+
+	var read NotificationResponse
+	rr = env.serve(MarkNotificationRead, asUser(env.newRequest("POST", readPath, nil), owner))
+	env.decode(rr, &read)
+	if rr.Code != http.StatusOK || read.ReadAt == nil {
+		t.Fatalf("Expected the notification marked read, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = env.serve(GetNotifications, asUser(env.newRequest("GET", "/api/notifications?unread=true", nil), owner))
+	env.decode(rr, &page)
+	if page.Total != 1 || page.Notifications[0].ID == newest.ID {
+		t.Errorf("Expected only the unread notification, got %+v", page.Notifications)
+	}
+
+	rr = env.serve(MarkNotificationRead, asUser(env.newRequest("POST", "/api/notifications/abc/read", nil), owner))
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusBadRequest || errResp.Code != apierror.InvalidNotificationID {
+		t.Errorf("Expected %s, got %d %s", apierror.InvalidNotificationID, rr.Code, errResp.Code)
+	}
+}
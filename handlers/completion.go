@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// trackTaskCompletion keeps task.CompletedAt in step with a status change
+// from previous ("" for a new task). Only the move into completed sets it, so
+// saving a task that was already completed keeps the original time; moving
+// out of completed (e.g. a reopen) clears it.
+func trackTaskCompletion(task *models.Task, previous models.TaskStatus, now time.Time) {
+	switch {
+	case task.Status != models.TaskStatusCompleted:
+		task.CompletedAt = nil
+	case previous != models.TaskStatusCompleted:
+		task.CompletedAt = &now
+	}
+}
+
+// completedRange is a range of completion times from ?completed_after= and
+// ?completed_before=; either end may be left open
+type completedRange struct {
+	After  *time.Time // Inclusive
+	Before *time.Time // Exclusive
+}
+
+// parseCompletedRange reads ?completed_after= and ?completed_before=, RFC 3339
+// timestamps or Unix seconds like ?since=. The range is half-open so that
+// consecutive ranges (e.g. weeks) don't overlap. It writes a 400 response and
+// returns false for malformed times or a range that ends before it starts.
+func parseCompletedRange(w http.ResponseWriter, r *http.Request) (completedRange, bool) {
+	var rng completedRange
+	query := r.URL.Query()
+	for _, param := range []struct {
+		name  string
+		value **time.Time
+	}{{"completed_after", &rng.After}, {"completed_before", &rng.Before}} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		t, ok := parseSince(value)
+		if !ok {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidDateRange, param.name+" must be an RFC 3339 timestamp or Unix seconds")
+			return completedRange{}, false
+		}
+		*param.value = &t
+	}
+	if rng.After != nil && rng.Before != nil && rng.Before.Before(*rng.After) {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidDateRange, "completed_after must not be after completed_before")
+		return completedRange{}, false
+	}
+	return rng, true
+}
+
+// scope limits a task query to tasks completed within the range
+// Either end leaves out tasks that aren't completed.
+func (rng completedRange) scope(tx *gorm.DB) *gorm.DB {
+	if rng.After != nil {
+		tx = tx.Where("completed_at >= ?", *rng.After)
+	}
+	if rng.Before != nil {
+		tx = tx.Where("completed_at < ?", *rng.Before)
+	}
+	return tx
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestTrackTaskCompletion tests that only the move into completed sets the completion time
+func TestTrackTaskCompletion(t *testing.T) {
+	t.Parallel()
+
+	earlier := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	now := earlier.Add(time.Hour)
+	tests := []struct {
+		name     string
+		previous models.TaskStatus
+		status   models.TaskStatus
+		want     *time.Time
+	}{
+		{"created completed", "", models.TaskStatusCompleted, &now},
+		{"created pending", "", models.TaskStatusPending, nil},
+		{"completed", models.TaskStatusInProgress, models.TaskStatusCompleted, &now},
+		{"saved while completed", models.TaskStatusCompleted, models.TaskStatusCompleted, &earlier},
+		{"reopened", models.TaskStatusCompleted, models.TaskStatusPending, nil},
+		{"still pending", models.TaskStatusPending, models.TaskStatusPending, nil},
+	}
+	for _, tt := range tests {
+		task := models.Task{Status: tt.status}
+		if tt.previous == models.TaskStatusCompleted {
+			task.CompletedAt = &earlier
+		}
+		trackTaskCompletion(&task, tt.previous, now)
+		if (task.CompletedAt == nil) != (tt.want == nil) || (tt.want != nil && !task.CompletedAt.Equal(*tt.want)) {
+			t.Errorf("%s: expected completed_at %v, got %v", tt.name, tt.want, task.CompletedAt)
+		}
+	}
+}
+
+// TestTaskCompletedAt tests completed_at through updates, batch updates and the listing filters
+// Not parallel: it sets TASK_TRANSITIONS
+func TestTaskCompletedAt(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskTransitions = nil
+	})
+	env := newTestEnv(t)
+	user := env.createUser("test-completed-at")
+
+	update := func(task TaskResponse, body string) TaskResponse {
+		t.Helper()
+		rr := env.serve(UpdateTask, asUser(env.newRequest("PATCH", fmt.Sprintf("/api/tasks/%d", task.ID), body), user))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Failed to update task with %s: %d %s", body, rr.Code, rr.Body.String())
+		}
+		var response TaskResponse
+		env.decode(rr, &response)
+		return response
+	}
+	completedAt := func(task TaskResponse) *time.Time {
+		t.Helper()
+		var stored models.Task
+		if err := env.tx.First(&stored, task.ID).Error; err != nil {
+			t.Fatalf("Failed to load task: %v", err)
+		}
+		return stored.CompletedAt
+	}
+	// Backdating the completion shows whether a later save sets it again
+	backdate := func(task TaskResponse, at time.Time) {
+		t.Helper()
+		if err := env.tx.Model(&models.Task{}).Where("id = ?", task.ID).Update("completed_at", at).Error; err != nil {
+			t.Fatalf("Failed to backdate task: %v", err)
+		}
+	}
+
+	task := env.createTask(user, CreateTaskRequest{Title: "Report"})
+	if task.CompletedAt != nil {
+		t.Fatalf("Expected no completed_at on a pending task, got %v", task.CompletedAt)
+	}
+
+	before := time.Now().Truncate(time.Second)
+	task = update(task, `{"status":"completed"}`)
+	if task.CompletedAt == nil || task.CompletedAt.Time().Before(before) {
+		t.Fatalf("Expected completed_at to be set on completion, got %v", task.CompletedAt)
+	}
+
+	// Saving a completed task again keeps its completion time
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour).UTC().Truncate(time.Second)
+	backdate(task, lastWeek)
+	task = update(task, `{"title":"Weekly report","status":"completed"}`)
+	if task.CompletedAt == nil || !task.CompletedAt.Time().Equal(lastWeek) {
+		t.Errorf("Expected completed_at to stay at %v, got %v", lastWeek, task.CompletedAt)
+	}
+
+	// Batch completing it again doesn't either
+	rr := env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status", BatchStatusRequest{
+		IDs: taskRefs(task.ID), Status: models.TaskStatusCompleted,
+	}), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to batch update: %d %s", rr.Code, rr.Body.String())
+	}
+	if at := completedAt(task); at == nil || !at.Equal(lastWeek) {
+		t.Errorf("Expected batch completion to keep completed_at at %v, got %v", lastWeek, at)
+	}
+
+	// Moving out of completed clears it
+	task = update(task, `{"status":"pending"}`)
+	if task.CompletedAt != nil || completedAt(task) != nil {
+		t.Errorf("Expected completed_at to be cleared, got %v", task.CompletedAt)
+	}
+
+	// Batch completion sets it for tasks that weren't completed
+	rr = env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status", BatchStatusRequest{
+		IDs: taskRefs(task.ID), Status: models.TaskStatusCompleted,
+	}), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to batch update: %d %s", rr.Code, rr.Body.String())
+	}
+	if at := completedAt(task); at == nil || at.Before(before) {
+		t.Errorf("Expected batch completion to set completed_at, got %v", at)
+	}
+
+	// Tasks created completed are completed from the start
+	created := env.createTask(user, CreateTaskRequest{Title: "Already done", Status: models.TaskStatusCompleted})
+	if created.CompletedAt == nil {
+		t.Errorf("Expected completed_at on a task created completed")
+	}
+
+	// Filter and sort by completion time
+	backdate(task, lastWeek)
+	twoWeeksAgo := lastWeek.Add(-7 * 24 * time.Hour)
+	backdate(created, twoWeeksAgo)
+	list := func(query string) []uint {
+		t.Helper()
+		rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks?"+query, nil), user))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Failed to list tasks with %s: %d %s", query, rr.Code, rr.Body.String())
+		}
+		var page PaginatedTaskResponse
+		env.decode(rr, &page)
+		var ids []uint
+		for _, task := range page.Tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+	after := lastWeek.Add(-time.Hour).Format(time.RFC3339)
+	if ids := list("completed_after=" + after); len(ids) != 1 || ids[0] != task.ID {
+		t.Errorf("Expected only task %d completed after %s, got %v", task.ID, after, ids)
+	}
+	if ids := list(fmt.Sprintf("completed_before=%d", lastWeek.Unix())); len(ids) != 1 || ids[0] != created.ID {
+		t.Errorf("Expected only task %d completed before %v (exclusive), got %v", created.ID, lastWeek, ids)
+	}
+	if ids := list(fmt.Sprintf("completed_after=%d&sort=-completed_at", twoWeeksAgo.Unix())); len(ids) != 2 || ids[0] != task.ID || ids[1] != created.ID {
+		t.Errorf("Expected tasks %d and %d, latest completion first, got %v", task.ID, created.ID, ids)
+	}
+
+	for _, query := range []string{"completed_after=yesterday", fmt.Sprintf("completed_after=%d&completed_before=%d", lastWeek.Unix(), twoWeeksAgo.Unix())} {
+		rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks?"+query, nil), user))
+		var errResp ErrorResponse
+		env.decode(rr, &errResp)
+		if rr.Code != http.StatusBadRequest || errResp.Code != apierror.InvalidDateRange {
+			t.Errorf("Expected 400 %s for %s, got %d %s", apierror.InvalidDateRange, query, rr.Code, errResp.Code)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/database"
+	"gorm.io/gorm"
+)
+
+// requestDB returns a database handle bound to the request's context
+// The configured DB_QUERY_TIMEOUT is applied on top, so a stuck query can't
+// hold the request forever, and a client disconnect cancels the query too.
+// Callers must call the returned cancel function (usually via defer).
+func requestDB(r *http.Request) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := queryContext(r)
+	return database.WithContext(ctx), cancel
+}
+
+// requestReadDB is requestDB for listings that may be read from the read
+// replica (DB_REPLICA_HOST), which can lag a little behind the primary
+// Never use it to read back what the request itself wrote.
+func requestReadDB(r *http.Request) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := queryContext(r)
+	return database.ReadWithContext(ctx), cancel
+}
+
+// queryContext returns r's context with DB_QUERY_TIMEOUT applied
+func queryContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if timeout := config.Get().DBQueryTimeout; timeout > 0 {
+		return context.WithTimeout(r.Context(), timeout)
+	}
+	return context.WithCancel(r.Context())
+}
+
+// writeQueryTimeout answers requests whose query failed because the request
+// context ended: 504 when the query deadline passed, 503 when the client went away.
+// Queries that failed because the database is unreachable get a 503 as well,
+// unless a read can be answered from its last response (see ServeStaleOnOutage).
+// It reports whether a response was written; other errors are left to the caller.
+func writeQueryTimeout(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case database.IsUnavailable(err):
+		if serveStale(w, r) {
+			return true
+		}
+		log.Printf("Database unavailable for %s %s: %v", r.Method, r.URL.Path, err)
+		writeError(w, r, http.StatusServiceUnavailable, apierror.DatabaseUnavailable, "Database is unavailable, try again shortly") // 503
+		return true
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, r, http.StatusGatewayTimeout, apierror.QueryTimeout, "Database query timed out") // 504
+		return true
+	case errors.Is(err, context.Canceled):
+		writeError(w, r, http.StatusServiceUnavailable, apierror.RequestCancelled, "Request was cancelled") // 503
+		return true
+	}
+	return false
+}
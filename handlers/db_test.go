@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestWriteQueryTimeout tests how context errors from GORM are mapped to responses
+func TestWriteQueryTimeout(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		expectWritten  bool
+		expectedStatus int
+	}{
+		{
+			name:           "deadline exceeded",
+			err:            fmt.Errorf("timeout: %w", context.DeadlineExceeded),
+			expectWritten:  true,
+			expectedStatus: http.StatusGatewayTimeout, // 504
+		},
+		{
+			name:           "client cancelled",
+			err:            context.Canceled,
+			expectWritten:  true,
+			expectedStatus: http.StatusServiceUnavailable, // 503
+		},
+		{
+			name:          "other error",
+			err:           errors.New("connection refused"),
+			expectWritten: false,
+		},
+		{
+			name:          "no error",
+			err:           nil,
+			expectWritten: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/tasks", nil)
+
+			written := writeQueryTimeout(rr, req, tc.err)
+
+			if written != tc.expectWritten {
+				t.Fatalf("Expected written=%t, got %t", tc.expectWritten, written)
+			}
+			if written && rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
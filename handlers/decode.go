@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+)
+
+// errJSONTooDeep means a body nests arrays/objects deeper than MAX_JSON_DEPTH
+var errJSONTooDeep = errors.New("JSON is nested too deeply")
+
+// decodeJSON reads the request body as a single JSON value into v
+// Every JSON handler goes through here so all bodies get the same protection:
+//   - bodies over MAX_BODY_SIZE bytes are rejected with 413 before being parsed
+//   - nesting deeper than MAX_JSON_DEPTH is rejected before decoding, so
+//     pathological input like [[[[...]]]] never reaches encoding/json
+//   - unknown fields are an error rather than silently ignored (catches typos)
+//   - anything after the JSON value is an error, not ignored
+//
+// On failure it writes the error response and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	cfg := config.Get()
+
+	body := r.Body
+	if cfg.MaxBodySize > 0 {
+		body = http.MaxBytesReader(w, r.Body, cfg.MaxBodySize)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, apierror.BodyTooLarge,
+				fmt.Sprintf("Request body is larger than %d bytes", tooLarge.Limit)) // 413
+			return false
+		}
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidJSON, "Failed to read request body")
+		return false
+	}
+
+	if err := checkJSONDepth(data, cfg.MaxJSONDepth); err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidJSON, fmt.Sprintf("Invalid JSON: nested deeper than %d levels", cfg.MaxJSONDepth))
+		return false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidJSON, jsonErrorMessage(err))
+		return false
+	}
+
+	// A second value, or garbage like a stray "}", must not follow the first
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidJSON, "Invalid JSON: unexpected data after the JSON value")
+		return false
+	}
+	return true
+}
+
+// jsonErrorMessage describes a decode error without echoing the body back
+// The English message names the problem so client developers can fix it;
+// the code stays INVALID_JSON either way.
+func jsonErrorMessage(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return "Invalid JSON: request body is empty"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("Invalid JSON: syntax error at byte %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fmt.Sprintf("Invalid JSON: %s must be a %s", typeErr.Field, typeErr.Type)
+	case bytes.HasPrefix([]byte(err.Error()), []byte("json: unknown field ")):
+		// encoding/json has no typed error for DisallowUnknownFields
+		return "Invalid JSON: " + err.Error()[len("json: "):]
+	default:
+		return "Invalid JSON"
+	}
+}
+
+// checkJSONDepth returns errJSONTooDeep if data nests arrays/objects deeper than maxDepth
+// It only tracks brackets (skipping those inside strings); the syntax is
+// checked by the decoder afterwards. maxDepth 0 disables the check.
+func checkJSONDepth(data []byte, maxDepth int) error {
+	if maxDepth <= 0 {
+		return nil
+	}
+
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '[' || c == '{':
+			depth++
+			if depth > maxDepth {
+				return errJSONTooDeep
+			}
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return nil
+}
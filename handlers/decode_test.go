@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+)
+
+// TestDecodeJSON tests the shared body checks every JSON handler goes through
+func TestDecodeJSON(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.MaxBodySize = 64
+		cfg.MaxJSONDepth = 3
+	})
+
+	type body struct {
+		Title string          `json:"title"`
+		Tags  json.RawMessage `json:"tags"`
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedCode   apierror.Code
+		expectedError  string
+	}{
+		{"valid body", `{"title":"Task"}`, http.StatusOK, "", ""},
+		{"surrounding whitespace", " \n{\"title\":\"Task\"}\n ", http.StatusOK, "", ""},
+		{"nesting at the limit", `{"tags":[[1]]}`, http.StatusOK, "", ""},
+		{"brackets inside strings", `{"title":"[[[{{{\"]]]"}`, http.StatusOK, "", ""},
+		{"empty body", ``, http.StatusBadRequest, apierror.InvalidJSON, "Invalid JSON: request body is empty"},
+		{"malformed", `not-json`, http.StatusBadRequest, apierror.InvalidJSON, "Invalid JSON: syntax error at byte 2"},
+		{"wrong type", `{"title":5}`, http.StatusBadRequest, apierror.InvalidJSON, "Invalid JSON: title must be a string"},
+		{"unknown field", `{"titel":"Task"}`, http.StatusBadRequest, apierror.InvalidJSON, `Invalid JSON: unknown field "titel"`},
+		{"trailing value", `{"title":"Task"}{"title":"Other"}`, http.StatusBadRequest, apierror.InvalidJSON, "Invalid JSON: unexpected data after the JSON value"},
+		{"trailing brace", `{"title":"Task"}}`, http.StatusBadRequest, apierror.InvalidJSON, "Invalid JSON: unexpected data after the JSON value"},
+		{"too deep", `{"tags":[[[1]]]}`, http.StatusBadRequest, apierror.InvalidJSON, "Invalid JSON: nested deeper than 3 levels"},
+		{"too large", `{"title":"` + strings.Repeat("a", 64) + `"}`, http.StatusRequestEntityTooLarge, apierror.BodyTooLarge, "Request body is larger than 64 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			var v body
+			if decodeJSON(rr, req, &v) {
+				rr.WriteHeader(http.StatusOK)
+			}
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK {
+				if v.Title == "" && v.Tags == nil {
+					t.Errorf("Expected the body to be decoded, got %+v", v)
+				}
+				return
+			}
+
+			var resp ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if resp.Code != tt.expectedCode || resp.Error != tt.expectedError {
+				t.Errorf("Expected %s %q, got %s %q", tt.expectedCode, tt.expectedError, resp.Code, resp.Error)
+			}
+		})
+	}
+}
+
+// TestDecodeJSONLimitsDisabled tests that 0 turns off the size and depth limits
+func TestDecodeJSONLimitsDisabled(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.MaxBodySize = 0
+		cfg.MaxJSONDepth = 0
+	})
+
+	deep := `{"tags":` + strings.Repeat("[", 100) + strings.Repeat("]", 100) + `,"title":"` + strings.Repeat("a", 2<<20) + `"}`
+	req := httptest.NewRequest("POST", "/", strings.NewReader(deep))
+	rr := httptest.NewRecorder()
+
+	var v struct {
+		Title string          `json:"title"`
+		Tags  json.RawMessage `json:"tags"`
+	}
+	if !decodeJSON(rr, req, &v) {
+		t.Fatalf("Expected the body to be accepted, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(v.Title) != 2<<20 {
+		t.Errorf("Expected a %d character title, got %d", 2<<20, len(v.Title))
+	}
+}
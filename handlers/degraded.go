@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kcansari/task-management-api/cache"
+	"github.com/kcansari/task-management-api/middleware"
+)
+
+// staleWarning marks a response served from the degraded mode cache
+// 110 is the HTTP warning code for "Response is Stale".
+const staleWarning = `110 - "Response is Stale"`
+
+// staleReads holds the last successful response of each read wrapped by
+// ServeStaleOnOutage; nil unless DEGRADED_READS is on
+// Keys include the user ID, so a response is only ever served to the user
+// it was made for.
+var staleReads *cache.LRU[staleResponse]
+
+// staleResponse is a recorded 200 response
+type staleResponse struct {
+	contentType string
+	etag        string
+	body        []byte
+	storedAt    time.Time
+}
+
+// staleKeyContextKey is the context key under which ServeStaleOnOutage puts
+// the request's cache key for serveStale
+type staleKeyContextKey struct{}
+
+// EnableStaleReads turns on serving reads from their last response while the
+// database is down, keeping up to size responses, each for ttl
+// main calls it at startup when DEGRADED_READS is set
+func EnableStaleReads(size int, ttl time.Duration) {
+	staleReads = cache.New[staleResponse](size, ttl)
+}
+
+// ServeStaleOnOutage keeps the last successful response of a GET handler so
+// it can stand in when the database can't be reached
+// Every 200 response is remembered per user and URL (and whether it was
+// enveloped). When a query of a later request fails because the database is
+// unreachable, writeQueryTimeout sends the remembered response instead of a
+// 503, marked with a Warning header and an Age of how old it is. Responses
+// older than DEGRADED_CACHE_TTL are never served. With DEGRADED_READS off it
+// does nothing.
+func ServeStaleOnOutage(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.GetUserFromContext(r)
+		if staleReads == nil || !ok || r.Method != "GET" {
+			next(w, r)
+			return
+		}
+
+		key := fmt.Sprintf("%d:%t:%s", user.UserID, wantsEnvelope(r), r.URL.RequestURI())
+		r = r.WithContext(context.WithValue(r.Context(), staleKeyContextKey{}, key))
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		// Stale responses served by this request aren't stored again, which
+		// would make them look fresh
+		if recorder.status == http.StatusOK && w.Header().Get("Warning") == "" {
+			staleReads.Set(key, staleResponse{
+				contentType: w.Header().Get("Content-Type"),
+				etag:        w.Header().Get("ETag"),
+				body:        recorder.body.Bytes(),
+				storedAt:    time.Now(),
+			})
+		}
+	}
+}
+
+// serveStale writes the remembered response for r, if ServeStaleOnOutage
+// wraps its handler and has one, and reports whether it did
+func serveStale(w http.ResponseWriter, r *http.Request) bool {
+	key, ok := r.Context().Value(staleKeyContextKey{}).(string)
+	if !ok || staleReads == nil {
+		return false
+	}
+	response, ok := staleReads.Get(key)
+	if !ok {
+		return false
+	}
+
+	w.Header().Set("Content-Type", response.contentType)
+	if response.etag != "" {
+		w.Header().Set("ETag", response.etag)
+	}
+	w.Header().Set("Warning", staleWarning)
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(response.storedAt).Seconds())))
+	w.WriteHeader(http.StatusOK)
+	w.Write(response.body)
+	return true
+}
+
+// responseRecorder passes a response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
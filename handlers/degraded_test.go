@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/middleware"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// unreachableDB returns a handle to a database that can't be reached
+// Nothing listens on port 1, so every query fails to connect, like it would
+// while Postgres is down.
+func unreachableDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1 user=test dbname=test sslmode=disable"),
+		&gorm.Config{DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Failed to open database handle: %v", err)
+	}
+	return db
+}
+
+// withDB makes req run its queries against db
+func withDB(req *http.Request, db *gorm.DB) *http.Request {
+	return req.WithContext(database.ContextWithDB(req.Context(), db))
+}
+
+// enableStaleReads turns on the degraded mode cache for the rest of the test
+func enableStaleReads(t *testing.T, ttl time.Duration) {
+	previous := staleReads
+	EnableStaleReads(100, ttl)
+	t.Cleanup(func() { staleReads = previous })
+}
+
+// expectDatabaseUnavailable checks for a 503 DATABASE_UNAVAILABLE
+func expectDatabaseUnavailable(t *testing.T, rr *httptest.ResponseRecorder) {
+	t.Helper()
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal error response: %v", err)
+	}
+	if response.Code != apierror.DatabaseUnavailable {
+		t.Errorf("Expected code %s, got %s", apierror.DatabaseUnavailable, response.Code)
+	}
+}
+
+// TestDegradedWrites tests that writes fail with 503 while the database is down
+// Not parallel: it enables the degraded mode cache
+func TestDegradedWrites(t *testing.T) {
+	enableStaleReads(t, time.Minute)
+	down := unreachableDB(t)
+	user := middleware.UserContext{UserID: 1, Email: "degraded@example.com", OrgID: 1}
+
+	// Nothing is remembered for writes, so wrapping them changes nothing
+	body := `{"title":"Written while down"}`
+	for _, handler := range []http.HandlerFunc{CreateTask, ServeStaleOnOutage(CreateTask)} {
+		req := withDB(httptest.NewRequest("POST", "/api/tasks", strings.NewReader(body)), down)
+		rr := httptest.NewRecorder()
+		handler(rr, asUser(req, user))
+		expectDatabaseUnavailable(t, rr)
+		if rr.Header().Get("Warning") != "" {
+			t.Errorf("Expected no Warning on a failed write, got %q", rr.Header().Get("Warning"))
+		}
+	}
+}
+
+// TestDegradedReads tests serving the last response of a read while the database is down
+// Not parallel: it enables the degraded mode cache
+func TestDegradedReads(t *testing.T) {
+	enableStaleReads(t, time.Minute)
+	env := newTestEnv(t)
+	down := unreachableDB(t)
+	user := env.createUser("test-degraded")
+	other := env.createUser("test-degraded-other")
+	task := env.createTask(user, CreateTaskRequest{Title: "Cached"})
+
+	getTask := ServeStaleOnOutage(GetTask)
+	getTasks := ServeStaleOnOutage(GetTasks)
+	taskPath := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	// Reads while the database is up are served normally and remembered
+	fresh := map[string]*httptest.ResponseRecorder{}
+	for path, handler := range map[string]http.HandlerFunc{taskPath: getTask, "/api/tasks?page=1": getTasks} {
+		rr := env.serve(handler, asUser(env.newRequest("GET", path, nil), user))
+		if rr.Code != http.StatusOK || rr.Header().Get("Warning") != "" {
+			t.Fatalf("Expected a fresh 200 for %s, got %d %q", path, rr.Code, rr.Header().Get("Warning"))
+		}
+		fresh[path] = rr
+	}
+
+	// Once it's down they're served stale, marked as such
+	for path, handler := range map[string]http.HandlerFunc{taskPath: getTask, "/api/tasks?page=1": getTasks} {
+		rr := env.serve(handler, asUser(withDB(env.newRequest("GET", path, nil), down), user))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected a stale 200 for %s, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+		if got := rr.Header().Get("Warning"); got != staleWarning {
+			t.Errorf("Expected Warning %q for %s, got %q", staleWarning, path, got)
+		}
+		if rr.Header().Get("Age") == "" {
+			t.Errorf("Expected an Age header for %s", path)
+		}
+		if rr.Body.String() != fresh[path].Body.String() {
+			t.Errorf("Expected the last response for %s, got %s", path, rr.Body.String())
+		}
+	}
+
+	// Only the user who made a read gets it back, and only for the same URL
+	expectDatabaseUnavailable(t, env.serve(getTask, asUser(withDB(env.newRequest("GET", taskPath, nil), down), other)))
+	expectDatabaseUnavailable(t, env.serve(getTasks, asUser(withDB(env.newRequest("GET", "/api/tasks?page=2", nil), down), user)))
+
+	// Responses older than the TTL aren't served
+	enableStaleReads(t, time.Nanosecond)
+	env.serve(getTask, asUser(env.newRequest("GET", taskPath, nil), user))
+	time.Sleep(time.Millisecond)
+	expectDatabaseUnavailable(t, env.serve(getTask, asUser(withDB(env.newRequest("GET", taskPath, nil), down), user)))
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// DryRunDeleteResponse describes the task DELETE ?dry_run=true would have deleted
+type DryRunDeleteResponse struct {
+	DryRun      bool         `json:"dry_run"`      // Always true: nothing was deleted
+	WouldDelete TaskResponse `json:"would_delete"` // The task a real DELETE removes
+}
+
+// parseDryRun reads the ?dry_run= parameter of a destructive endpoint
+// A dry run does all the validation and works out what would change, then
+// stops before writing anything. Absent means false; values strconv.ParseBool
+// doesn't understand are rejected with 400 rather than silently running for real.
+func parseDryRun(w http.ResponseWriter, r *http.Request) (dryRun bool, ok bool) {
+	value := r.URL.Query().Get("dry_run")
+	if value == "" {
+		return false, true
+	}
+
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidDryRun, "dry_run must be true or false")
+		return false, false
+	}
+	return dryRun, true
+}
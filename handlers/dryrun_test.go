@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestBatchUpdateTaskStatusDryRun tests that a dry run reports the batch outcome without writing it
+func TestBatchUpdateTaskStatusDryRun(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-dry-run-batch")
+	other := env.createUser("test-dry-run-batch-other")
+
+	first := env.createTask(user, CreateTaskRequest{Title: "First"})
+	second := env.createTask(user, CreateTaskRequest{Title: "Second"})
+	foreign := env.createTask(other, CreateTaskRequest{Title: "Not mine"})
+
+	body := BatchStatusRequest{IDs: taskRefs(first.ID, foreign.ID, second.ID), Status: models.TaskStatusCompleted}
+	rr := env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status?dry_run=true", body), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp BatchStatusResponse
+	env.decode(rr, &resp)
+	expected := BatchStatusResponse{Updated: 2, UpdatedIDs: taskRefs(first.ID, second.ID), Skipped: taskRefs(foreign.ID), DryRun: true}
+	if !reflect.DeepEqual(resp, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, resp)
+	}
+
+	// Nothing was written: statuses and history are unchanged
+	var tasks []models.Task
+	if err := env.tx.Where("id IN ?", []uint{first.ID, second.ID}).Find(&tasks).Error; err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	for _, task := range tasks {
+		if task.Status != models.TaskStatusPending {
+			t.Errorf("Expected task %d to stay pending, got %s", task.ID, task.Status)
+		}
+	}
+	var history int64
+	env.tx.Model(&models.TaskStatusHistory{}).Where("task_id IN ?", []uint{first.ID, second.ID}).Count(&history)
+	if history != 0 {
+		t.Errorf("Expected no status history, got %d entries", history)
+	}
+
+	// The real run does what the dry run predicted
+	rr = env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status", body), user))
+	var real BatchStatusResponse
+	env.decode(rr, &real)
+	if real.DryRun || real.Updated != resp.Updated || !reflect.DeepEqual(real.UpdatedIDs, resp.UpdatedIDs) {
+		t.Errorf("Expected the real run to match the dry run, got %+v", real)
+	}
+
+	// Unparseable values are rejected rather than treated as a real run
+	rr = env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status?dry_run=maybe", body), user))
+	var errResp ErrorResponse
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusBadRequest || errResp.Code != apierror.InvalidDryRun {
+		t.Errorf("Expected 400 INVALID_DRY_RUN, got %d %s", rr.Code, errResp.Code)
+	}
+}
+
+// TestDeleteTaskDryRun tests that a dry-run delete shows the task and keeps it
+func TestDeleteTaskDryRun(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-dry-run-delete")
+	other := env.createUser("test-dry-run-delete-other")
+	task := env.createTask(user, CreateTaskRequest{Title: "Keep me"})
+
+	path := fmt.Sprintf("/api/tasks/%d?dry_run=true", task.ID)
+	rr := env.serve(DeleteTask, asUser(env.newRequest("DELETE", path, nil), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp DryRunDeleteResponse
+	env.decode(rr, &resp)
+	if !resp.DryRun || resp.WouldDelete.ID != task.ID {
+		t.Errorf("Unexpected dry-run response %+v", resp)
+	}
+
+	var count int64
+	env.tx.Model(&models.Task{}).Where("id = ?", task.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the task to still exist")
+	}
+
+	// Access is checked like a real delete
+	rr = env.serve(DeleteTask, asUser(env.newRequest("DELETE", path, nil), other))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another user's task, got %d", rr.Code)
+	}
+}
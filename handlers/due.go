@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// Ranges GET /api/tasks/due?range= accepts
+const (
+	DueRangeToday   = "today"   // Due at any time today
+	DueRangeWeek    = "week"    // Due from the start of today to the end of Sunday
+	DueRangeOverdue = "overdue" // Due before now
+)
+
+// DueTasksResponse is what GET /api/tasks/due sends back
+// From and To are the window the tasks were picked with, so clients can
+// label it without doing the time zone math themselves.
+type DueTasksResponse struct {
+	Range    string         `json:"range"`
+	Timezone string         `json:"timezone"` // The IANA zone the window was computed in
+	From     *Timestamp     `json:"from"`     // Inclusive; null for overdue, which has no start
+	To       Timestamp      `json:"to"`       // Exclusive
+	Tasks    []TaskResponse `json:"tasks"`    // Earliest due date first
+	PaginationMeta
+}
+
+// dueWindow returns the due dates a range covers at now, in now's location
+// Days start at local midnight, so they are 23 or 25 hours long across a DST
+// change. A week is the calendar week, Monday to Sunday: "week" runs from
+// the start of today until the next Monday, so on a Sunday it's just today.
+// from is the zero time for overdue.
+func dueWindow(rangeName string, now time.Time) (from, to time.Time, ok bool) {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	switch rangeName {
+	case DueRangeToday:
+		return today, time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()), true
+	case DueRangeWeek:
+		// Days left in the week, today included: 7 on a Monday, 1 on a Sunday
+		daysLeft := 7 - (int(now.Weekday())+6)%7
+		return today, time.Date(year, month, day+daysLeft, 0, 0, 0, 0, now.Location()), true
+	case DueRangeOverdue:
+		return time.Time{}, now, true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// GetDueTasks handles GET /api/tasks/due?range=today|week|overdue - Tasks
+// that aren't completed and are due in a window around now
+// The window is computed in the time zone from ?tz= (an IANA name such as
+// Europe/Istanbul), or else the caller's timezone setting, or else
+// DEFAULT_TIMEZONE. Listing rules and
+// ?scope= are those of GET /api/tasks, and so is the pagination.
+func GetDueTasks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	query := r.URL.Query()
+	tz := query.Get("tz")
+	if tz == "" {
+		tz = user.Timezone
+	}
+	if tz == "" {
+		tz = config.Get().DefaultTimezone
+	}
+	location, ok := loadTimezone(tz)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTimezone, "tz must be an IANA time zone such as Europe/Istanbul")
+		return
+	}
+	if tz == "" {
+		tz = "UTC"
+	}
+
+	rangeName := query.Get("range")
+	from, to, ok := dueWindow(rangeName, time.Now().In(location))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidDueRange, "range must be today, week or overdue")
+		return
+	}
+
+	page, pageSize, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+	includeShared, ok := parseTaskScope(w, r)
+	if !ok {
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Compared in UTC, like the due dates are stored
+	window := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("status <> ? AND due_date < ?", models.TaskStatusCompleted, to.UTC())
+		if !from.IsZero() {
+			tx = tx.Where("due_date >= ?", from.UTC())
+		}
+		return tx
+	}
+
+	var total int64
+	if err := db.Model(&models.Task{}).Scopes(visibleTasks(db, user, includeShared), window).Count(&total).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to count due tasks for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch due tasks")
+		return
+	}
+
+	var tasks []models.Task
+	if err := db.Scopes(visibleTasks(db, user, includeShared), window).
+		Order("due_date ASC, id ASC").Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&tasks).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch due tasks for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch due tasks")
+		return
+	}
+
+	response := DueTasksResponse{
+		Range:          rangeName,
+		Timezone:       tz,
+		To:             newTimestamp(to),
+		Tasks:          make([]TaskResponse, 0, len(tasks)), // Encodes as [] rather than null
+		PaginationMeta: newPaginationMeta(page, pageSize, total),
+	}
+	if !from.IsZero() {
+		response.From = newOptionalTimestamp(&from)
+	}
+	for _, task := range tasks {
+		response.Tasks = append(response.Tasks, newTaskResponse(task))
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestDueWindow tests the windows of each range, including the week boundary
+func TestDueWindow(t *testing.T) {
+	t.Parallel()
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		rangeArg string
+		now      time.Time
+		from     time.Time // Zero for no start
+		to       time.Time
+	}{
+		{"today", DueRangeToday, time.Date(2025, 6, 18, 15, 30, 0, 0, istanbul),
+			time.Date(2025, 6, 18, 0, 0, 0, 0, istanbul), time.Date(2025, 6, 19, 0, 0, 0, 0, istanbul)},
+		{"today just before midnight", DueRangeToday, time.Date(2025, 6, 18, 23, 59, 59, 0, istanbul),
+			time.Date(2025, 6, 18, 0, 0, 0, 0, istanbul), time.Date(2025, 6, 19, 0, 0, 0, 0, istanbul)},
+		{"week from a Wednesday", DueRangeWeek, time.Date(2025, 6, 18, 15, 30, 0, 0, istanbul),
+			time.Date(2025, 6, 18, 0, 0, 0, 0, istanbul), time.Date(2025, 6, 23, 0, 0, 0, 0, istanbul)},
+		{"week from a Monday is seven days", DueRangeWeek, time.Date(2025, 6, 16, 0, 0, 0, 0, istanbul),
+			time.Date(2025, 6, 16, 0, 0, 0, 0, istanbul), time.Date(2025, 6, 23, 0, 0, 0, 0, istanbul)},
+		{"week from a Sunday is just today", DueRangeWeek, time.Date(2025, 6, 22, 23, 0, 0, 0, istanbul),
+			time.Date(2025, 6, 22, 0, 0, 0, 0, istanbul), time.Date(2025, 6, 23, 0, 0, 0, 0, istanbul)},
+		{"week across a month end", DueRangeWeek, time.Date(2025, 7, 31, 9, 0, 0, 0, istanbul),
+			time.Date(2025, 7, 31, 0, 0, 0, 0, istanbul), time.Date(2025, 8, 4, 0, 0, 0, 0, istanbul)},
+		{"day with a DST change is 23 hours", DueRangeToday, time.Date(2025, 3, 30, 12, 0, 0, 0, berlin),
+			time.Date(2025, 3, 30, 0, 0, 0, 0, berlin), time.Date(2025, 3, 31, 0, 0, 0, 0, berlin)},
+		{"overdue", DueRangeOverdue, time.Date(2025, 6, 18, 15, 30, 0, 0, istanbul),
+			time.Time{}, time.Date(2025, 6, 18, 15, 30, 0, 0, istanbul)},
+	}
+
+	for _, tt := range tests {
+		from, to, ok := dueWindow(tt.rangeArg, tt.now)
+		if !ok || !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("%s: expected %v - %v, got %v - %v (ok %v)", tt.name, tt.from, tt.to, from, to, ok)
+		}
+	}
+
+	if from, to, _ := dueWindow(DueRangeToday, time.Date(2025, 3, 30, 12, 0, 0, 0, berlin)); to.Sub(from) != 23*time.Hour {
+		t.Errorf("Expected the DST day to be 23 hours, got %v", to.Sub(from))
+	}
+	if _, _, ok := dueWindow("month", time.Now()); ok {
+		t.Error("Expected an unknown range to be rejected")
+	}
+}
+
+// TestGetDueTasks tests which tasks each range returns
+func TestGetDueTasks(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-due-tasks")
+
+	now := time.Now().UTC()
+	todayFrom, todayTo, _ := dueWindow(DueRangeToday, now)
+	_, weekTo, _ := dueWindow(DueRangeWeek, now)
+	due := func(title string, at time.Time, status models.TaskStatus) uint {
+		t.Helper()
+		return env.createTask(user, CreateTaskRequest{Title: title, DueDate: &at, Status: status}).ID
+	}
+
+	overdue := due("Overdue", todayFrom.Add(-time.Hour), "")
+	today := due("Today", todayTo.Add(-time.Minute), "")
+	endOfWeek := due("End of week", weekTo.Add(-time.Minute), "")
+	nextWeek := due("Next week", weekTo, "")
+	due("Done today", todayTo.Add(-time.Minute), models.TaskStatusCompleted)
+	env.createTask(user, CreateTaskRequest{Title: "No due date"})
+
+	list := func(query string) (int, DueTasksResponse) {
+		t.Helper()
+		rr := env.serve(GetDueTasks, asUser(env.newRequest("GET", "/api/tasks/due?"+query, nil), user))
+		var response DueTasksResponse
+		if rr.Code == http.StatusOK {
+			env.decode(rr, &response)
+		}
+		return rr.Code, response
+	}
+	ids := func(response DueTasksResponse) []uint {
+		ids := make([]uint, 0, len(response.Tasks))
+		for _, task := range response.Tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+	equal := func(got, want []uint) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	code, response := list("range=today&tz=UTC")
+	if code != http.StatusOK || !equal(ids(response), []uint{today}) {
+		t.Errorf("Expected only the task due today, got %d %v", code, ids(response))
+	}
+	if response.Timezone != "UTC" || response.From == nil || !response.From.Time().Equal(todayFrom) || !response.To.Time().Equal(todayTo) {
+		t.Errorf("Expected the window %v - %v in UTC, got %+v", todayFrom, todayTo, response)
+	}
+
+	// The week runs from today to the end of Sunday, however much of it is left
+	_, response = list("range=week&tz=UTC")
+	got := ids(response)
+	if len(got) == 0 || got[0] != today || got[len(got)-1] != endOfWeek {
+		t.Errorf("Expected the week to run from today's task to the end of Sunday, got %v", got)
+	}
+	for _, id := range got {
+		if id == nextWeek || id == overdue {
+			t.Errorf("Expected the week to leave out task %d, got %v", id, got)
+		}
+	}
+
+	// Today's task is overdue too in the last minute of the day
+	_, response = list("range=overdue&tz=UTC")
+	if got := ids(response); len(got) == 0 || got[0] != overdue || len(got) > 2 || response.From != nil {
+		t.Errorf("Expected the overdue task first and no start, got %v from %v", got, response.From)
+	}
+
+	for _, query := range []string{"", "range=month", "range=today&tz=Mars/Olympus", "range=today&tz=Local"} {
+		if code, _ := list(query); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %q, got %d", query, code)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestEncryptedDescription tests that descriptions are stored encrypted and
+// read back transparently
+// Not parallel: the key is process-wide.
+func TestEncryptedDescription(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser("test-encryption")
+
+	// A task saved before encryption was turned on stays readable
+	plain := env.createTask(user, CreateTaskRequest{Title: "Old", Description: "Stored before the key"})
+
+	if err := models.SetEncryptionKey([]byte("0123456789abcdef0123456789abcdef")); err != nil {
+		t.Fatalf("Failed to set key: %v", err)
+	}
+	t.Cleanup(func() { models.SetEncryptionKey(nil) })
+
+	created := env.createTask(user, CreateTaskRequest{Title: "Secret", Description: "Alarm code 1234"})
+	if created.Description != "Alarm code 1234" {
+		t.Errorf("Expected the plaintext description in the response, got %q", created.Description)
+	}
+
+	var stored string
+	env.tx.Raw("SELECT description FROM tasks WHERE id = ?", created.ID).Scan(&stored)
+	if !strings.HasPrefix(stored, "enc:v1:") || strings.Contains(stored, "1234") {
+		t.Errorf("Expected the description to be stored encrypted, got %q", stored)
+	}
+
+	for id, expected := range map[uint]string{created.ID: "Alarm code 1234", plain.ID: "Stored before the key"} {
+		rr := env.serve(GetTask, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks/%d", id), nil), user))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var response TaskResponse
+		env.decode(rr, &response)
+		if response.Description != expected {
+			t.Errorf("Expected description %q, got %q", expected, response.Description)
+		}
+	}
+
+	// Updating re-encrypts
+	rr := env.serve(UpdateTask, asUser(env.newRequest("PUT", fmt.Sprintf("/api/tasks/%d", plain.ID), `{"description":"Now secret"}`), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	env.tx.Raw("SELECT description FROM tasks WHERE id = ?", plain.ID).Scan(&stored)
+	if !strings.HasPrefix(stored, "enc:v1:") {
+		t.Errorf("Expected the updated description to be encrypted, got %q", stored)
+	}
+}
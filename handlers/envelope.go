@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// Envelope wraps a response for clients that send Accept: apierror.EnvelopeMediaType
+// The payload goes under "data"; lists add pagination under "meta" and
+// navigation under "links" instead of mixing them into the top level
+type Envelope struct {
+	Data  interface{}      `json:"data"`
+	Meta  *PaginationMeta  `json:"meta,omitempty"`
+	Links *PaginationLinks `json:"links,omitempty"`
+}
+
+// PaginationMeta describes where a page sits in a paginated list
+type PaginationMeta struct {
+	Page       int    `json:"page"`               // Current page number (1-based)
+	PageSize   int    `json:"page_size"`          // Number of items per page
+	Total      int64  `json:"total"`              // Total number of items
+	TotalPages int    `json:"total_pages"`        // Total number of pages
+	HasNext    bool   `json:"has_next"`           // Whether there's a next page
+	HasPrev    bool   `json:"has_prev"`           // Whether there's a previous page
+	Snapshot   string `json:"snapshot,omitempty"` // Token to reuse Total on later pages (?snapshot=)
+}
+
+// newPaginationMeta describes the given page of a list with total items
+// A page past the last one is empty: it has no next page, and its previous
+// page is the last one (see newPaginationLinks), or none when the list is empty.
+func newPaginationMeta(page, pageSize int, total int64) PaginationMeta {
+	// Total pages = ceiling(total / pageSize)
+	// In Go, integer division truncates, so we add (pageSize-1) to get ceiling effect
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	return PaginationMeta{
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+		HasPrev:    page > 1 && totalPages > 0,
+	}
+}
+
+// PaginationLinks holds relative URLs for moving through a paginated list
+// next and prev are left out on the last and first page
+type PaginationLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// wantsEnvelope reports whether the client asked for enveloped responses
+func wantsEnvelope(r *http.Request) bool {
+	return apierror.WantsEnvelope(r.Header.Get("Accept"))
+}
+
+// encodeResponse serializes data in the shape the client asked for
+// Flat responses are data as-is; enveloped ones wrap it as {"data": ...}.
+// Either way its timestamps are in the caller's time zone.
+func encodeResponse(r *http.Request, data interface{}) ([]byte, error) {
+	data = inUserTimezone(r, data)
+	if wantsEnvelope(r) {
+		data = Envelope{Data: data}
+	}
+	return json.Marshal(data)
+}
+
+// writeResponse sends data with the given status in the shape the client asked for
+// (see encodeResponse)
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	data = inUserTimezone(r, data)
+	if wantsEnvelope(r) {
+		w.Header().Set("Content-Type", apierror.EnvelopeMediaType)
+		data = Envelope{Data: data}
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// newPaginationLinks builds the links for a page of r's listing
+// Other query parameters (like ?shared=true) are kept on every link, and a
+// snapshot token replaces whatever ?snapshot= the request had. prev from a
+// page past the end links to the last page, not to another empty one.
+func newPaginationLinks(r *http.Request, meta PaginationMeta) *PaginationLinks {
+	pageURL := func(page int) string {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("page_size", strconv.Itoa(meta.PageSize))
+		if meta.Snapshot != "" {
+			query.Set("snapshot", meta.Snapshot)
+		}
+		return (&url.URL{Path: r.URL.Path, RawQuery: query.Encode()}).String()
+	}
+
+	links := &PaginationLinks{Self: pageURL(meta.Page)}
+	if meta.HasNext {
+		links.Next = pageURL(meta.Page + 1)
+	}
+	if meta.HasPrev {
+		links.Prev = pageURL(min(meta.Page-1, meta.TotalPages))
+	}
+	return links
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestResponseEnvelope tests the enveloped response format and that the flat one stays the default
+func TestResponseEnvelope(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-envelope")
+	for i := 0; i < 3; i++ {
+		env.createTask(user, CreateTaskRequest{Title: fmt.Sprintf("Task %d", i)})
+	}
+
+	enveloped := func(req *http.Request) *http.Request {
+		req.Header.Set("Accept", apierror.EnvelopeMediaType)
+		return asUser(req, user)
+	}
+
+	// Without the Accept header the listing keeps its flat shape
+	rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks?page=2&page_size=1", nil), user))
+	var flat map[string]json.RawMessage
+	env.decode(rr, &flat)
+	if _, ok := flat["tasks"]; !ok || flat["page"] == nil || flat["data"] != nil {
+		t.Errorf("Expected the flat listing, got %s", rr.Body.String())
+	}
+
+	// With it, pagination moves under meta and links
+	rr = env.serve(GetTasks, enveloped(env.newRequest("GET", "/api/tasks?page=2&page_size=1&shared=true", nil)))
+	if rr.Header().Get("Content-Type") != apierror.EnvelopeMediaType {
+		t.Errorf("Expected Content-Type %s, got %s", apierror.EnvelopeMediaType, rr.Header().Get("Content-Type"))
+	}
+	var list struct {
+		Data  []TaskResponse  `json:"data"`
+		Meta  PaginationMeta  `json:"meta"`
+		Links PaginationLinks `json:"links"`
+	}
+	env.decode(rr, &list)
+	if len(list.Data) != 1 || list.Meta.Page != 2 || list.Meta.Total != 3 || !list.Meta.HasNext || !list.Meta.HasPrev {
+		t.Errorf("Unexpected enveloped listing %s", rr.Body.String())
+	}
+	if list.Links.Next != "/api/tasks?page=3&page_size=1&shared=true" || list.Links.Prev != "/api/tasks?page=1&page_size=1&shared=true" {
+		t.Errorf("Unexpected links %+v", list.Links)
+	}
+
+	// Single resources go under data, without meta
+	task := list.Data[0]
+	rr = env.serve(GetTask, enveloped(env.newRequest("GET", fmt.Sprintf("/api/tasks/%d", task.ID), nil)))
+	var single map[string]json.RawMessage
+	env.decode(rr, &single)
+	var data TaskResponse
+	json.Unmarshal(single["data"], &data)
+	if data.ID != task.ID || single["meta"] != nil {
+		t.Errorf("Unexpected enveloped task %s", rr.Body.String())
+	}
+
+	rr = env.serve(CreateTask, enveloped(env.newRequest("POST", "/api/tasks", CreateTaskRequest{Title: "Created"})))
+	single = nil
+	env.decode(rr, &single)
+	if rr.Code != http.StatusCreated || single["data"] == nil {
+		t.Errorf("Expected an enveloped 201, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = env.serve(GetCurrentUser, enveloped(env.newRequest("GET", "/api/auth/me", nil)))
+	var account struct {
+		Data models.User `json:"data"`
+	}
+	env.decode(rr, &account)
+	if rr.Header().Get("Content-Type") != apierror.EnvelopeMediaType || account.Data.ID != user.UserID {
+		t.Errorf("Expected the enveloped account, got %s %s", rr.Header().Get("Content-Type"), rr.Body.String())
+	}
+
+	// Errors use the same envelope
+	rr = env.serve(GetTask, enveloped(env.newRequest("GET", "/api/tasks/999999", nil)))
+	var errorEnvelope apierror.ErrorEnvelope
+	env.decode(rr, &errorEnvelope)
+	if rr.Code != http.StatusNotFound || len(errorEnvelope.Errors) != 1 {
+		t.Fatalf("Expected one enveloped error, got %d %s", rr.Code, rr.Body.String())
+	}
+	if got := errorEnvelope.Errors[0]; got.Status != "404" || got.Code != apierror.TaskNotFound || got.Detail == "" {
+		t.Errorf("Unexpected error object %+v", got)
+	}
+}
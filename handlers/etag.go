@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// weakETag builds a weak ETag from a serialized response body
+// Hashing the representation (rather than only UpdatedAt) means the tag
+// changes whenever anything the client sees changes
+func weakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches the given ETag
+// The header may hold several comma-separated tags or "*"; If-None-Match uses
+// weak comparison, so the W/ prefix is ignored on both sides
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	target := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == target {
+			return true
+		}
+	}
+	return false
+}
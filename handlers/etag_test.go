@@ -0,0 +1,43 @@
+package handlers
+
+import "testing"
+
+// TestWeakETag tests that the ETag follows the response body
+func TestWeakETag(t *testing.T) {
+	original := weakETag([]byte(`{"id":1,"title":"Task","updated_at":"2025-06-22T17:30:00Z"}`))
+	updated := weakETag([]byte(`{"id":1,"title":"Task","updated_at":"2025-06-22T17:45:00Z"}`))
+
+	if original == updated {
+		t.Errorf("Expected ETag to change when the task changes, got %s for both", original)
+	}
+
+	if original != weakETag([]byte(`{"id":1,"title":"Task","updated_at":"2025-06-22T17:30:00Z"}`)) {
+		t.Errorf("Expected ETag to be stable for the same body")
+	}
+}
+
+// TestETagMatches tests If-None-Match parsing
+func TestETagMatches(t *testing.T) {
+	etag := `W/"abc123"`
+
+	testCases := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"empty header", "", false},
+		{"exact match", `W/"abc123"`, true},
+		{"strong form matches weakly", `"abc123"`, true},
+		{"different tag", `W/"def456"`, false},
+		{"list containing tag", `W/"def456", W/"abc123"`, true},
+		{"wildcard", "*", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := etagMatches(tc.ifNoneMatch, etag); got != tc.want {
+				t.Errorf("etagMatches(%q) = %v, want %v", tc.ifNoneMatch, got, tc.want)
+			}
+		})
+	}
+}
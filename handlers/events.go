@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/events"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/webhooks"
+	"gorm.io/gorm"
+)
+
+// TaskEventPayload is the body POSTed to webhooks and sent on task streams
+type TaskEventPayload struct {
+	Event     string       `json:"event"`     // e.g. task.created
+	Timestamp Timestamp    `json:"timestamp"` // When the event happened
+	Task      TaskResponse `json:"task"`      // The task after the change
+}
+
+// taskUpdateEvents returns the events for an update that moved a task from previousStatus
+func taskUpdateEvents(previousStatus models.TaskStatus, task models.Task) []string {
+	names := []string{models.WebhookEventTaskUpdated}
+	if task.Status == models.TaskStatusCompleted && previousStatus != models.TaskStatusCompleted {
+		names = append(names, models.WebhookEventTaskCompleted)
+	}
+	return names
+}
+
+// taskEventDeleted is streamed when a task is deleted (webhooks don't offer it)
+const taskEventDeleted = "task.deleted"
+
+// publishTaskEvents notifies the owner's live streams and webhooks about changes to task
+// Call it only after the change has been saved. Webhook deliveries happen in
+// the background, and failures are logged rather than surfaced: the change
+// itself already succeeded.
+func publishTaskEvents(db *gorm.DB, task models.Task, names ...string) {
+	for _, event := range names {
+		streamTaskEvent(task, event)
+	}
+	sendTaskEvents(userWebhooks(db, task.UserID), task, names...)
+}
+
+// streamTaskEvent publishes event to the owner's open /api/tasks/stream connections
+func streamTaskEvent(task models.Task, event string) {
+	body, err := json.Marshal(TaskEventPayload{
+		Event:     event,
+		Timestamp: newTimestamp(time.Now()),
+		Task:      newTaskResponse(task),
+	})
+	if err != nil {
+		log.Printf("Failed to encode %s event for task %d: %v", event, task.ID, err)
+		return
+	}
+	events.Default.Publish(task.UserID, events.Event{Name: event, Data: body})
+}
+
+// NotifyTaskDue tells the owner that task is due soon
+// The reminder scheduler calls it once per claimed task; the reminder goes to
+// the owner's live streams and to webhooks subscribed to task.due_soon.
+func NotifyTaskDue(task models.Task) {
+	log.Printf("Task %d for user %d is due at %s", task.ID, task.UserID, task.DueDate.Format(timestampLayout))
+	publishTaskEvents(database.GetDB(), task, models.WebhookEventTaskDueSoon)
+}
+
+// userWebhooks loads a user's webhooks; errors are logged and yield none
+func userWebhooks(db *gorm.DB, userID uint) []models.Webhook {
+	var hooks []models.Webhook
+	if err := db.Where("user_id = ?", userID).Find(&hooks).Error; err != nil {
+		log.Printf("Failed to load webhooks for user %d: %v", userID, err)
+		return nil
+	}
+	return hooks
+}
+
+// sendTaskEvents queues a delivery of each event to every hook subscribed to it
+func sendTaskEvents(hooks []models.Webhook, task models.Task, names ...string) {
+	if len(hooks) == 0 {
+		return
+	}
+
+	now := newTimestamp(time.Now())
+	for _, event := range names {
+		body, err := json.Marshal(TaskEventPayload{
+			Event:     event,
+			Timestamp: now,
+			Task:      newTaskResponse(task),
+		})
+		if err != nil {
+			log.Printf("Failed to encode %s event for task %d: %v", event, task.ID, err)
+			continue
+		}
+
+		for _, hook := range hooks {
+			if hook.Subscribes(event) {
+				webhooks.Send(webhooks.Delivery{URL: hook.URL, Secret: hook.Secret, Event: event, Body: body})
+			}
+		}
+	}
+}
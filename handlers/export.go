@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// markdownEscaper backslash-escapes the characters that are Markdown markup
+// wherever they appear in a line
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "~", `\~`, "&", `\&`,
+)
+
+// orderedListMarker matches a line that would start an ordered list, e.g. "1. "
+var orderedListMarker = regexp.MustCompile(`^(\d+)([.)])`)
+
+// escapeMarkdownLine makes one line of text render as itself in Markdown
+// Leading whitespace is dropped, since indentation would turn the line into
+// a code block, and markers that only mean something at the start of a line
+// (lists, setext headings) are escaped there.
+func escapeMarkdownLine(line string) string {
+	line = markdownEscaper.Replace(strings.TrimLeft(line, " \t"))
+	if line != "" && strings.ContainsRune("-+=", rune(line[0])) {
+		return `\` + line
+	}
+	return orderedListMarker.ReplaceAllString(line, `$1\$2`)
+}
+
+// escapeMarkdown makes text render as itself in Markdown, keeping its line breaks
+// Blank lines separate paragraphs as usual; other line breaks become hard breaks
+// (a trailing backslash), which Markdown would otherwise join into one line.
+func escapeMarkdown(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = escapeMarkdownLine(line)
+	}
+	for i := 0; i < len(lines)-1; i++ {
+		if lines[i] != "" && lines[i+1] != "" {
+			lines[i] += `\`
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// taskMarkdown renders a task and its checklist as a Markdown document
+// The title is the heading, followed by a list of the task's details, the
+// description and the checklist as task list items.
+func taskMarkdown(task models.Task, checklist []models.ChecklistItem) string {
+	var b strings.Builder
+
+	// A title is one line; line breaks in it would end the heading
+	fmt.Fprintf(&b, "# %s\n\n", escapeMarkdownLine(strings.Join(strings.Fields(task.Title), " ")))
+
+	fmt.Fprintf(&b, "- **Status:** %s\n", escapeMarkdownLine(string(task.Status)))
+	fmt.Fprintf(&b, "- **Progress:** %d%%\n", task.Progress)
+	if task.DueDate != nil {
+		fmt.Fprintf(&b, "- **Due:** %s\n", newTimestamp(*task.DueDate))
+	}
+	if task.CompletedAt != nil {
+		fmt.Fprintf(&b, "- **Completed:** %s\n", newTimestamp(*task.CompletedAt))
+	}
+	if task.Color != "" {
+		fmt.Fprintf(&b, "- **Color:** %s\n", escapeMarkdownLine(task.Color))
+	}
+
+	if description := strings.TrimSpace(task.Description); description != "" {
+		fmt.Fprintf(&b, "\n%s\n", escapeMarkdown(description))
+	}
+
+	if len(checklist) > 0 {
+		b.WriteString("\n## Checklist\n\n")
+		for _, item := range checklist {
+			box := " "
+			if item.Done {
+				box = "x"
+			}
+			fmt.Fprintf(&b, "- [%s] %s\n", box, escapeMarkdownLine(strings.Join(strings.Fields(item.Text), " ")))
+		}
+	}
+	return b.String()
+}
+
+// ExportTask handles GET /api/tasks/{id}/export?format=md - The task as a document
+// Markdown (format=md, the default) is the only format so far. Like
+// GetTask it works for the owner, users the task is shared with and admins.
+func ExportTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/export
+	taskID, err := taskIDFromPath(r.URL.Path, "/export")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "md", "markdown":
+	default:
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidExportFormat, "format must be md")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	task, err := findAccessibleTask(db, taskID, user, false)
+	if err != nil {
+		writeTaskAccessError(w, r, err)
+		return
+	}
+
+	var checklist []models.ChecklistItem
+	if err := db.Where("task_id = ?", task.ID).Order("position ASC, id ASC").Find(&checklist).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch checklist of task %d for export: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to export task")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(taskMarkdown(task, checklist)))
+}
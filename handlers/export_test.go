@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestEscapeMarkdown tests that text renders as itself in Markdown
+func TestEscapeMarkdown(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"Plain text, with a period.", "Plain text, with a period."},
+		{"*bold* _em_ `code`", `\*bold\* \_em\_ \` + "`code\\`"},
+		{"[link](http://example.com)", `\[link\](http://example.com)`},
+		{"<script>alert(1)</script>", `\<script\>alert(1)\</script\>`},
+		{"# not a heading", `\# not a heading`},
+		{"a | b ~~c~~ &amp;", `a \| b \~\~c\~\~ \&amp;`},
+		{`C:\path`, `C:\\path`},
+		{"- not a list", `\- not a list`},
+		{"+ not a list", `\+ not a list`},
+		{"1. not a list", `1\. not a list`},
+		{"2) not a list", `2\) not a list`},
+		{"    not code", "not code"},
+		{"first\nsecond\n\nnew paragraph", "first\\\nsecond\n\nnew paragraph"},
+		{"windows\r\nline", "windows\\\nline"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeMarkdown(tt.text); got != tt.expected {
+			t.Errorf("escapeMarkdown(%q) = %q, expected %q", tt.text, got, tt.expected)
+		}
+	}
+}
+
+// TestTaskMarkdown tests how a task is laid out as Markdown
+func TestTaskMarkdown(t *testing.T) {
+	t.Parallel()
+
+	due := time.Date(2025, 6, 25, 17, 0, 0, 0, time.UTC)
+	task := models.Task{
+		Title:       "Fix the *login*\npage",
+		Description: "Steps:\n1. open it",
+		Status:      models.TaskStatusInProgress,
+		Progress:    40,
+		DueDate:     &due,
+		Color:       "#ff8800",
+	}
+	checklist := []models.ChecklistItem{{Text: "Reproduce", Done: true}, {Text: "Write a [test]"}}
+
+	expected := "# Fix the \\*login\\* page\n\n" +
+		"- **Status:** in\\_progress\n" +
+		"- **Progress:** 40%\n" +
+		"- **Due:** 2025-06-25T17:00:00Z\n" +
+		"- **Color:** \\#ff8800\n" +
+		"\nSteps:\\\n1\\. open it\n" +
+		"\n## Checklist\n\n" +
+		"- [x] Reproduce\n" +
+		"- [ ] Write a \\[test\\]\n"
+	if got := taskMarkdown(task, checklist); got != expected {
+		t.Errorf("Unexpected Markdown:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	// Details that aren't set are left out
+	bare := taskMarkdown(models.Task{Title: "Bare", Status: models.TaskStatusPending}, nil)
+	if bare != "# Bare\n\n- **Status:** pending\n- **Progress:** 0%\n" {
+		t.Errorf("Unexpected Markdown for a bare task: %q", bare)
+	}
+}
+
+// TestExportTask tests exporting a task as Markdown
+func TestExportTask(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-export")
+	other := env.createUser("test-export-other")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Release notes", Description: "Draft the _notes_"})
+
+	rr := env.serve(AddChecklistItem, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/checklist", task.ID), map[string]string{"text": "Collect changes"}), owner))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to add checklist item: %d %s", rr.Code, rr.Body.String())
+	}
+
+	exportPath := fmt.Sprintf("/api/tasks/%d/export?format=md", task.ID)
+	rr = env.serve(ExportTask, asUser(env.newRequest("GET", exportPath, nil), owner))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/markdown; charset=utf-8" {
+		t.Errorf("Expected Content-Type text/markdown, got %q", got)
+	}
+	body := rr.Body.String()
+	for _, part := range []string{"# Release notes\n", "Draft the \\_notes\\_", "- [ ] Collect changes"} {
+		if !strings.Contains(body, part) {
+			t.Errorf("Expected %q in the export, got:\n%s", part, body)
+		}
+	}
+
+	// Other users can't export it
+	rr = env.serve(ExportTask, asUser(env.newRequest("GET", exportPath, nil), other))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another user, got %d", rr.Code)
+	}
+
+	// Unknown formats are rejected
+	rr = env.serve(ExportTask, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks/%d/export?format=pdf", task.ID), nil), owner))
+	var response ErrorResponse
+	env.decode(rr, &response)
+	if rr.Code != http.StatusBadRequest || response.Code != apierror.InvalidExportFormat {
+		t.Errorf("Expected 400 %s, got %d %s", apierror.InvalidExportFormat, rr.Code, response.Code)
+	}
+}
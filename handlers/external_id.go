@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// maxExternalIDLength matches the external_id column (VARCHAR(255))
+const maxExternalIDLength = 255
+
+// validateExternalID rejects external IDs that don't fit the column
+// On failure it writes the error response and returns false.
+func validateExternalID(w http.ResponseWriter, r *http.Request, externalID string) bool {
+	if fieldErr := externalIDError(externalID); fieldErr != nil {
+		writeFieldError(w, r, *fieldErr)
+		return false
+	}
+	return true
+}
+
+// externalIDError is validateExternalID without the response: nil when it fits
+func externalIDError(externalID string) *TaskFieldError {
+	if utf8.RuneCountInString(externalID) > maxExternalIDLength {
+		return &TaskFieldError{"external_id", apierror.InvalidExternalID,
+			fmt.Sprintf("External ID cannot be longer than %d characters", maxExternalIDLength)}
+	}
+	return nil
+}
+
+// findTaskByExternalID loads the caller's own task with the given external ID
+func findTaskByExternalID(db *gorm.DB, externalID string, user middleware.UserContext) (models.Task, error) {
+	var task models.Task
+	err := db.Scopes(ownedBy(user)).Where("external_id = ?", externalID).First(&task).Error
+	return task, err
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestCreateTaskExternalID tests that repeating an external_id returns the task created first
+func TestCreateTaskExternalID(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-external-id")
+	other := env.createUser("test-external-id-other")
+
+	// The first create makes the task
+	rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", `{"title":"Synced","external_id":"JIRA-42"}`), user))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var created TaskResponse
+	env.decode(rr, &created)
+	if created.ExternalID == nil || *created.ExternalID != "JIRA-42" {
+		t.Errorf("Expected external ID JIRA-42, got %v", created.ExternalID)
+	}
+
+	// Repeating it returns that task unchanged instead of a duplicate
+	rr = env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", `{"title":"Synced again","external_id":"JIRA-42"}`), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var existing TaskResponse
+	env.decode(rr, &existing)
+	if existing.ID != created.ID || existing.Title != "Synced" {
+		t.Errorf("Expected task %d unchanged, got %+v", created.ID, existing)
+	}
+
+	// The same external ID from another user is their own task
+	rr = env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", `{"title":"Mine","external_id":"JIRA-42"}`), other))
+	var theirs TaskResponse
+	env.decode(rr, &theirs)
+	if rr.Code != http.StatusCreated || theirs.ID == created.ID {
+		t.Errorf("Expected another user to get a separate task, got %d %+v", rr.Code, theirs)
+	}
+
+	// Tasks without one don't collide with each other
+	for i := 0; i < 2; i++ {
+		task := env.createTask(user, CreateTaskRequest{Title: "Local"})
+		if task.ExternalID != nil {
+			t.Errorf("Expected no external ID, got %q", *task.ExternalID)
+		}
+	}
+
+	// The unique index itself rejects a duplicate that slips past the lookup
+	duplicate := models.Task{Title: "Racing", UserID: user.UserID, OrgID: user.OrgID, ExternalID: created.ExternalID}
+	if err := insertTask(env.tx, user, &duplicate); err == nil {
+		t.Errorf("Expected the unique index to reject a duplicate external ID")
+	}
+
+	// A deleted task frees its external ID
+	env.tx.Delete(&models.Task{}, created.ID)
+	rr = env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", `{"title":"Recreated","external_id":"JIRA-42"}`), user))
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 after deleting the task, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	body := `{"title":"Too long","external_id":"` + strings.Repeat("x", 256) + `"}`
+	rr = env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", body), user))
+	var errResp ErrorResponse
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusBadRequest || errResp.Code != apierror.InvalidExternalID {
+		t.Errorf("Expected 400 INVALID_EXTERNAL_ID, got %d %s", rr.Code, errResp.Code)
+	}
+}
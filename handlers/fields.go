@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"gorm.io/gorm"
+)
+
+// taskFields maps the ?fields= values of GET /api/tasks and GET /api/tasks/{id}
+// to the task columns they're read from and their value in a TaskResponse
+var taskFields = map[string]struct {
+	columns []string
+	value   func(TaskResponse) any
+}{
+	"id":                 {[]string{"id", "public_id"}, taskResponseID},
+	"title":              {[]string{"title"}, func(t TaskResponse) any { return t.Title }},
+	"description":        {[]string{"description"}, func(t TaskResponse) any { return t.Description }},
+	"status":             {[]string{"status"}, func(t TaskResponse) any { return t.Status }},
+	"user_id":            {[]string{"user_id"}, func(t TaskResponse) any { return t.UserID }},
+	"due_date":           {[]string{"due_date"}, func(t TaskResponse) any { return t.DueDate }},
+	"color":              {[]string{"color"}, func(t TaskResponse) any { return t.Color }},
+	"progress":           {[]string{"progress"}, func(t TaskResponse) any { return t.Progress }},
+	"completed_at":       {[]string{"completed_at"}, func(t TaskResponse) any { return t.CompletedAt }},
+	"visibility":         {[]string{"visibility"}, func(t TaskResponse) any { return t.Visibility }},
+	"position":           {[]string{"position"}, func(t TaskResponse) any { return t.Position }},
+	"client_id":          {[]string{"client_id"}, func(t TaskResponse) any { return t.ClientID }},
+	"external_id":        {[]string{"external_id"}, func(t TaskResponse) any { return t.ExternalID }},
+	"created_at":         {[]string{"created_at"}, func(t TaskResponse) any { return t.CreatedAt }},
+	"updated_at":         {[]string{"updated_at"}, func(t TaskResponse) any { return t.UpdatedAt }},
+	"checklist_progress": {[]string{"checklist_done", "checklist_total"}, func(t TaskResponse) any { return t.ChecklistProgress }},
+	"total_time_seconds": {[]string{"total_time_seconds"}, func(t TaskResponse) any { return t.TotalTimeSeconds }},
+	"metadata":           {[]string{"metadata"}, func(t TaskResponse) any { return t.Metadata }},
+}
+
+// invalidFieldsMessage is the INVALID_FIELDS error message, naming the fields
+var invalidFieldsMessage = func() string {
+	names := make([]string, 0, len(taskFields))
+	for name := range taskFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "Invalid fields. Use a comma-separated list of " + strings.Join(names, ", ")
+}()
+
+// parseTaskFields reads ?fields=id,title,status
+// nil means every field. Otherwise id always comes first, whether it was asked
+// for or not, so clients can tell the tasks apart. It writes an error response
+// and returns false for names that aren't task fields, rather than leaving
+// them out of the response where a typo would go unnoticed.
+func parseTaskFields(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	value := r.URL.Query().Get("fields")
+	if value == "" {
+		return nil, true
+	}
+
+	fields := []string{"id"}
+	seen := map[string]bool{"id": true}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := taskFields[name]; !ok {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidFields, invalidFieldsMessage)
+			return nil, false
+		}
+		if !seen[name] {
+			seen[name] = true
+			fields = append(fields, name)
+		}
+	}
+	return fields, true
+}
+
+// selectTaskFields limits a task query to the columns the fields are read from
+// nil fields select every column.
+func selectTaskFields(fields []string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if fields == nil {
+			return tx
+		}
+		var columns []string
+		for _, name := range fields {
+			columns = append(columns, taskFields[name].columns...)
+		}
+		return tx.Select(columns)
+	}
+}
+
+// sparseTaskResponse keeps only the requested fields of a task response
+// Associations asked for with ?include= are kept as well.
+func sparseTaskResponse(response TaskResponse, fields []string) map[string]any {
+	sparse := make(map[string]any, len(fields)+2)
+	for _, name := range fields {
+		sparse[name] = taskFields[name].value(response)
+	}
+	if response.Checklist != nil {
+		sparse["checklist"] = response.Checklist
+	}
+	if response.Attachments != nil {
+		sparse["attachments"] = response.Attachments
+	}
+	return sparse
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// TestTaskFields tests returning only the fields asked for with ?fields= on the task and the list
+func TestTaskFields(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-fields")
+	task := env.createTask(user, CreateTaskRequest{Title: "Sparse", Description: "Not sent"})
+	taskPath := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	testCases := []struct {
+		name     string
+		list     bool // GET /api/tasks rather than GET /api/tasks/{id}
+		path     string
+		expected []string // Keys of the task, sorted
+	}{
+		{"task", false, taskPath + "?fields=title,status", []string{"id", "status", "title"}},
+		{"id is always included", false, taskPath + "?fields=title", []string{"id", "title"}},
+		{"computed field", false, taskPath + "?fields=checklist_progress,%20due_date", []string{"checklist_progress", "due_date", "id"}},
+		{"with include", false, taskPath + "?fields=title&include=checklist", []string{"checklist", "id", "title"}},
+		{"list", true, "/api/tasks?fields=title,status,title", []string{"id", "status", "title"}},
+		{"list with id only", true, "/api/tasks?fields=id", []string{"id"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := GetTask
+			if tc.list {
+				handler = GetTasks
+			}
+			rr := env.serve(handler, asUser(env.newRequest("GET", tc.path, nil), user))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var got map[string]json.RawMessage
+			if tc.list {
+				var page struct {
+					Tasks []map[string]json.RawMessage `json:"tasks"`
+					Total int64                        `json:"total"`
+				}
+				env.decode(rr, &page)
+				if len(page.Tasks) != 1 || page.Total != 1 {
+					t.Fatalf("Expected one task and the pagination fields, got %s", rr.Body.String())
+				}
+				got = page.Tasks[0]
+			} else {
+				env.decode(rr, &got)
+			}
+
+			var keys []string
+			for key := range got {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			if !slices.Equal(keys, tc.expected) {
+				t.Errorf("Expected fields %v, got %v", tc.expected, keys)
+			}
+			if string(got["id"]) != fmt.Sprint(task.ID) {
+				t.Errorf("Expected id %d, got %s", task.ID, got["id"])
+			}
+			if title, ok := got["title"]; ok && string(title) != `"Sparse"` {
+				t.Errorf("Expected the title to be read, got %s", title)
+			}
+		})
+	}
+
+	// Unknown fields are rejected rather than dropped
+	for _, path := range []string{taskPath + "?fields=title,titel", "/api/tasks?fields=password", "/api/tasks?fields=title,"} {
+		handler := GetTasks
+		if strings.HasPrefix(path, taskPath) {
+			handler = GetTask
+		}
+		rr := env.serve(handler, asUser(env.newRequest("GET", path, nil), user))
+		var errResp ErrorResponse
+		env.decode(rr, &errResp)
+		if rr.Code != http.StatusBadRequest || errResp.Code != apierror.InvalidFields {
+			t.Errorf("%s: expected 400 %s, got %d %s", path, apierror.InvalidFields, rr.Code, errResp.Code)
+		}
+	}
+}
@@ -0,0 +1,675 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/graphql"
+	"github.com/kcansari/task-management-api/middleware"
+)
+
+// maxGraphQLRootFields limits how many root fields (each one a REST call) one operation may select
+const maxGraphQLRootFields = 20
+
+// GraphQLRequest represents the body of POST /graphql
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`         // The GraphQL document (required)
+	OperationName string                 `json:"operationName"` // Which operation to run when the document has several
+	Variables     map[string]interface{} `json:"variables"`     // Values for the operation's variables
+	Extensions    json.RawMessage        `json:"extensions"`    // Sent by some clients; ignored
+}
+
+// GraphQLResponse is the standard GraphQL response
+// data is left out when the request failed before anything ran (syntax or
+// validation errors); otherwise it holds every selected root field, null
+// for those that failed.
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLError is one entry of the errors list
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLErrorLocation `json:"locations,omitempty"` // Where a syntax error is in the query
+	Path       []string               `json:"path,omitempty"`      // The root field that failed
+	Extensions GraphQLErrorExtensions `json:"extensions"`
+}
+
+// GraphQLErrorLocation is a 1-based position in the query
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLErrorExtensions carries the same code (and status) the REST API would have answered with
+type GraphQLErrorExtensions struct {
+	Code   apierror.Code `json:"code"`
+	Status int           `json:"status,omitempty"` // HTTP status of the equivalent REST request
+}
+
+// graphQLResolver resolves a field of the Query or Mutation type
+// Every task and user field is resolved by calling the REST handler for the
+// same operation, so ownership, sharing, validation, webhooks and pagination
+// work exactly as they do over REST. The field and its arguments are declared
+// in graphQLTypes, and the arguments have been checked against them.
+type graphQLResolver func(r *http.Request, args map[string]interface{}) (interface{}, *GraphQLError)
+
+// graphQLQueries resolve the fields of the Query type
+var graphQLQueries = map[string]graphQLResolver{
+	"me": func(r *http.Request, args map[string]interface{}) (interface{}, *GraphQLError) {
+		return callHandler(r, GetCurrentUser, "GET", "/api/auth/me", nil)
+	},
+	"task": func(r *http.Request, args map[string]interface{}) (interface{}, *GraphQLError) {
+		id, gqlErr := graphQLTaskID(r, args["id"])
+		if gqlErr != nil {
+			return nil, gqlErr
+		}
+		return callHandler(r, ResolvePublicTaskID(GetTask), "GET", "/api/tasks/"+id, nil)
+	},
+	// filter and pagination are merged into one TaskSearchRequest
+	"tasks": func(r *http.Request, args map[string]interface{}) (interface{}, *GraphQLError) {
+		search := map[string]interface{}{}
+		for _, name := range []string{"filter", "pagination"} {
+			if input, ok := args[name].(map[string]interface{}); ok {
+				for field, value := range input {
+					search[field] = value
+				}
+			}
+		}
+		return callHandler(r, SearchTasks, "POST", "/api/tasks/search", search)
+	},
+	"__schema": func(r *http.Request, args map[string]interface{}) (interface{}, *GraphQLError) {
+		return introspectSchema(), nil
+	},
+	"__type": func(r *http.Request, args map[string]interface{}) (interface{}, *GraphQLError) {
+		name, _ := args["name"].(string)
+		return introspectType(name), nil
+	},
+}
+
+// graphQLMutations resolve the fields of the Mutation type
+// Their handlers are wrapped in Maintenance here, as /graphql itself isn't:
+// queries keep working during maintenance, mutations don't.
+var graphQLMutations = map[string]graphQLResolver{
+	"createTask": func(r *http.Request, args map[string]interface{}) (interface{}, *GraphQLError) {
+		return callHandler(r, middleware.Maintenance(CreateTask), "POST", "/api/tasks", args["input"])
+	},
+	"updateTask": func(r *http.Request, args map[string]interface{}) (interface{}, *GraphQLError) {
+		id, gqlErr := graphQLTaskID(r, args["id"])
+		if gqlErr != nil {
+			return nil, gqlErr
+		}
+		return callHandler(r, middleware.Maintenance(ResolvePublicTaskID(UpdateTask)), "PATCH", "/api/tasks/"+id, args["input"])
+	},
+	"deleteTask": func(r *http.Request, args map[string]interface{}) (interface{}, *GraphQLError) {
+		id, gqlErr := graphQLTaskID(r, args["id"])
+		if gqlErr != nil {
+			return nil, gqlErr
+		}
+		if _, gqlErr := callHandler(r, middleware.Maintenance(ResolvePublicTaskID(DeleteTask)), "DELETE", "/api/tasks/"+id, nil); gqlErr != nil {
+			return nil, gqlErr
+		}
+		return true, nil
+	},
+}
+
+// GraphQL handles POST /graphql - Run a GraphQL query or mutation
+// The whole operation is parsed and validated before anything runs, so a
+// mistake anywhere in it changes nothing. Root fields then run one after
+// another; one that fails is null in data and explained in errors, without
+// stopping the others.
+func GraphQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req GraphQLRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	doc, err := graphql.Parse(req.Query)
+	if err != nil {
+		gqlErr := newGraphQLError(r, 0, apierror.GraphQLSyntaxError, err.Error())
+		var syntaxErr *graphql.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			gqlErr.Locations = []GraphQLErrorLocation{{Line: syntaxErr.Line, Column: syntaxErr.Column}}
+		}
+		writeGraphQLResponse(w, http.StatusBadRequest, GraphQLResponse{Errors: []GraphQLError{*gqlErr}})
+		return
+	}
+
+	operation, rootType, fields, err := planGraphQL(doc, req.OperationName, req.Variables)
+	if err != nil {
+		gqlErr := newGraphQLError(r, 0, apierror.GraphQLValidationFailed, err.Error())
+		writeGraphQLResponse(w, http.StatusBadRequest, GraphQLResponse{Errors: []GraphQLError{*gqlErr}})
+		return
+	}
+
+	roots := graphQLQueries
+	if operation.Type == "mutation" {
+		roots = graphQLMutations
+	}
+
+	var response GraphQLResponse
+	data := graphql.Object{}
+	for _, field := range fields {
+		if field.name == "__typename" {
+			data = append(data, graphql.Member{Key: field.key, Value: rootType})
+			continue
+		}
+
+		value, gqlErr := roots[field.name](r, field.arguments)
+		if gqlErr != nil {
+			gqlErr.Path = []string{field.key}
+			response.Errors = append(response.Errors, *gqlErr)
+			data = append(data, graphql.Member{Key: field.key, Value: nil})
+			continue
+		}
+		data = append(data, graphql.Member{Key: field.key, Value: projectGraphQL(value, field)})
+	}
+	response.Data = data
+
+	writeGraphQLResponse(w, http.StatusOK, response)
+}
+
+// writeGraphQLResponse sends a GraphQL response with the given status
+func writeGraphQLResponse(w http.ResponseWriter, status int, response GraphQLResponse) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// newGraphQLError builds an error in the client's language, like writeError
+func newGraphQLError(r *http.Request, status int, code apierror.Code, message string) *GraphQLError {
+	return &GraphQLError{
+		Message:    apierror.Localize(r.Header.Get("Accept-Language"), code, message),
+		Extensions: GraphQLErrorExtensions{Code: code, Status: status},
+	}
+}
+
+// graphQLTaskID turns an id argument (an ID is a string, but clients also
+// send numbers) into the task ID for a REST path
+func graphQLTaskID(r *http.Request, value interface{}) (string, *GraphQLError) {
+	var id string
+	switch v := value.(type) {
+	case string:
+		id = v
+	case int64:
+		id = strconv.FormatInt(v, 10)
+	case float64: // From JSON variables
+		if v == math.Trunc(v) && v >= 0 && v <= math.MaxUint32 {
+			id = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	if usePublicTaskIDs() {
+		// Public IDs are UUIDs; the handlers are wrapped to resolve them
+		if _, ok := normalizeClientID(id); !ok {
+			return "", newGraphQLError(r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		}
+		return id, nil
+	}
+	if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+		return "", newGraphQLError(r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+	}
+	return id, nil
+}
+
+// responseCapture is an http.ResponseWriter that keeps the response in memory
+type responseCapture struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) Header() http.Header { return c.header }
+
+func (c *responseCapture) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+}
+
+func (c *responseCapture) Write(data []byte) (int, error) {
+	c.WriteHeader(http.StatusOK)
+	return c.body.Write(data)
+}
+
+// callHandler runs a REST handler for a root field and decodes its JSON response
+// The request shares the context of the GraphQL request, and with it the
+// authenticated user and the database. body (nil for none) is sent as JSON.
+// An error response becomes a GraphQLError with the same code and status.
+func callHandler(r *http.Request, handler http.HandlerFunc, method, path string, body interface{}) (interface{}, *GraphQLError) {
+	var reader bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, newGraphQLError(r, http.StatusBadRequest, apierror.InvalidJSON, "Invalid arguments")
+		}
+		reader.Reset(data)
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), method, path, &reader)
+	if err != nil {
+		return nil, newGraphQLError(r, http.StatusInternalServerError, apierror.InternalError, "Failed to run the query")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", r.Header.Get("Accept-Language"))
+	req.RemoteAddr = r.RemoteAddr
+
+	rec := &responseCapture{header: http.Header{}}
+	handler(rec, req)
+
+	if rec.status >= 400 {
+		var errResp ErrorResponse
+		if err := json.Unmarshal(rec.body.Bytes(), &errResp); err != nil || errResp.Code == "" {
+			errResp = ErrorResponse{Error: http.StatusText(rec.status), Code: apierror.InternalError}
+		}
+		return nil, &GraphQLError{
+			Message:    errResp.Error,
+			Extensions: GraphQLErrorExtensions{Code: errResp.Code, Status: rec.status},
+		}
+	}
+	if rec.body.Len() == 0 {
+		return nil, nil // 204 No Content
+	}
+
+	var value interface{}
+	dec := json.NewDecoder(&rec.body)
+	dec.UseNumber() // Keep numbers exactly as the handler wrote them
+	if err := dec.Decode(&value); err != nil {
+		return nil, newGraphQLError(r, http.StatusInternalServerError, apierror.InternalError, "Failed to run the query")
+	}
+	return value, nil
+}
+
+// graphQLSelection is a validated field selection, with fragments expanded
+// and @skip/@include applied
+type graphQLSelection struct {
+	key       string                 // Name in the response (the alias, if any)
+	name      string                 // Field name
+	arguments map[string]interface{} // Resolved arguments
+	typeName  string                 // Object type of the field's value; "" for scalars and enums
+	fields    []*graphQLSelection    // Selected fields of objects
+
+	selections []graphql.Selection // Unexpanded selections, while planning
+}
+
+// graphQLPlanner validates an operation against the schema
+type graphQLPlanner struct {
+	doc       *graphql.Document
+	variables map[string]interface{} // Values of the operation's variables, defaults applied
+	defined   map[string]bool        // Variables the operation declares
+	spreading map[string]bool        // Fragments being expanded, to catch cycles
+}
+
+// planGraphQL picks the operation to run and validates it
+// It returns the operation, the name of its root type and the root fields.
+func planGraphQL(doc *graphql.Document, operationName string, variables map[string]interface{}) (*graphql.Operation, string, []*graphQLSelection, error) {
+	var operation *graphql.Operation
+	for _, candidate := range doc.Operations {
+		if operationName == "" || candidate.Name == operationName {
+			if operation != nil {
+				return nil, "", nil, errors.New("operationName is required when the document has several operations")
+			}
+			operation = candidate
+		}
+	}
+	if len(doc.Operations) == 0 {
+		return nil, "", nil, errors.New("the document has no operations, only fragments")
+	}
+	if operation == nil {
+		return nil, "", nil, fmt.Errorf("unknown operation %q", operationName)
+	}
+
+	var rootType string
+	switch operation.Type {
+	case "query":
+		rootType = "Query"
+	case "mutation":
+		rootType = "Mutation"
+	default:
+		return nil, "", nil, errors.New("subscriptions aren't supported; use GET /api/tasks/stream")
+	}
+
+	p := &graphQLPlanner{
+		doc:       doc,
+		variables: map[string]interface{}{},
+		defined:   map[string]bool{},
+		spreading: map[string]bool{},
+	}
+	for _, definition := range operation.Variables {
+		if p.defined[definition.Name] {
+			return nil, "", nil, fmt.Errorf("variable $%s is declared more than once", definition.Name)
+		}
+		p.defined[definition.Name] = true
+
+		if t, ok := graphQLTypes[graphQLNamedType(definition.Type)]; !ok || t.kind == "OBJECT" {
+			return nil, "", nil, fmt.Errorf("variable $%s can't be of type %s", definition.Name, definition.Type)
+		}
+		value, ok := variables[definition.Name]
+		if !ok && definition.Default != nil {
+			value = graphql.Resolve(definition.Default, nil)
+		}
+		if value == nil && strings.HasSuffix(definition.Type, "!") {
+			return nil, "", nil, fmt.Errorf("variable $%s of type %s is required", definition.Name, definition.Type)
+		}
+		p.variables[definition.Name] = value
+	}
+	if _, err := p.included(operation.Directives); err != nil {
+		return nil, "", nil, err
+	}
+
+	root := &graphQLSelection{typeName: rootType}
+	if err := p.collect(operation.SelectionSet, rootType, &root.fields); err != nil {
+		return nil, "", nil, err
+	}
+	if len(root.fields) > maxGraphQLRootFields {
+		return nil, "", nil, fmt.Errorf("an operation can select at most %d root fields", maxGraphQLRootFields)
+	}
+	if err := p.planFields(root); err != nil {
+		return nil, "", nil, err
+	}
+	return operation, rootType, root.fields, nil
+}
+
+// planFields validates the fields selected on an object, and theirs in turn
+func (p *graphQLPlanner) planFields(object *graphQLSelection) error {
+	for _, child := range object.fields {
+		field, ok := graphQLFieldOf(object.typeName, child.name)
+		if !ok {
+			return fmt.Errorf("cannot query field %q on type %q", child.name, object.typeName)
+		}
+		if err := p.resolveArguments(child, field.arguments); err != nil {
+			return err
+		}
+
+		var selections []graphql.Selection
+		for _, selection := range child.selections {
+			selections = append(selections, selection.(*graphql.Field).SelectionSet...)
+		}
+		typeName := graphQLNamedType(field.typ)
+		if graphQLTypes[typeName].kind != "OBJECT" {
+			if len(selections) > 0 {
+				return fmt.Errorf("field %q on type %q is a scalar and can't have subfields", child.name, object.typeName)
+			}
+			continue
+		}
+		if len(selections) == 0 {
+			return fmt.Errorf("field %q of type %q must have a selection of subfields", child.name, typeName)
+		}
+
+		child.typeName = typeName
+		if err := p.collect(selections, typeName, &child.fields); err != nil {
+			return err
+		}
+		if err := p.planFields(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveArguments checks the arguments of a field and resolves their values
+func (p *graphQLPlanner) resolveArguments(field *graphQLSelection, declared []graphQLField) error {
+	var arguments []*graphql.Argument
+	for _, selection := range field.selections {
+		arguments = append(arguments, selection.(*graphql.Field).Arguments...)
+	}
+
+	field.arguments = map[string]interface{}{}
+	for _, argument := range arguments {
+		i := slices.IndexFunc(declared, func(d graphQLField) bool { return d.name == argument.Name })
+		if i < 0 {
+			return fmt.Errorf("unknown argument %q on field %q", argument.Name, field.name)
+		}
+		if _, ok := field.arguments[argument.Name]; ok {
+			return fmt.Errorf("argument %q is given more than once on field %q", argument.Name, field.name)
+		}
+		value, err := p.resolve(argument.Value)
+		if err != nil {
+			return err
+		}
+		if err := checkGraphQLInput(value, declared[i].typ); err != nil {
+			return fmt.Errorf("argument %q on field %q: %w", argument.Name, field.name, err)
+		}
+		field.arguments[argument.Name] = value
+	}
+	for _, argument := range declared {
+		if strings.HasSuffix(argument.typ, "!") && field.arguments[argument.name] == nil {
+			return fmt.Errorf("field %q requires argument %q", field.name, argument.name)
+		}
+	}
+	return nil
+}
+
+// checkGraphQLInput checks an argument value against its declared type
+// Only nulls and the fields of input objects are checked: scalars are left
+// to the REST endpoint, which rejects bad values with its own error codes.
+func checkGraphQLInput(value interface{}, typ string) error {
+	if value == nil {
+		if strings.HasSuffix(typ, "!") {
+			return fmt.Errorf("%s can't be null", typ)
+		}
+		return nil
+	}
+
+	typ = strings.TrimSuffix(typ, "!")
+	if strings.HasPrefix(typ, "[") {
+		itemType := typ[1 : len(typ)-1]
+		list, ok := value.([]interface{})
+		if !ok {
+			return checkGraphQLInput(value, itemType) // A single value is a list of one
+		}
+		for _, item := range list {
+			if err := checkGraphQLInput(item, itemType); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t := graphQLTypes[typ]
+	if t.kind != "INPUT_OBJECT" {
+		return nil
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s must be an object", typ)
+	}
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		i := slices.IndexFunc(t.fields, func(f graphQLField) bool { return f.name == name })
+		if i < 0 {
+			return fmt.Errorf("%s has no field %q", typ, name)
+		}
+		if err := checkGraphQLInput(object[name], t.fields[i].typ); err != nil {
+			return fmt.Errorf("%s.%s: %w", typ, name, err)
+		}
+	}
+	for _, field := range t.fields {
+		if _, ok := object[field.name]; !ok && strings.HasSuffix(field.typ, "!") {
+			return fmt.Errorf("%s requires field %q", typ, field.name)
+		}
+	}
+	return nil
+}
+
+// collect adds the fields of a selection set on typeName to fields
+// Fragments are expanded and fields with the same response key merged, in
+// the order they first appear.
+func (p *graphQLPlanner) collect(selections []graphql.Selection, typeName string, fields *[]*graphQLSelection) error {
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *graphql.Field:
+			if ok, err := p.included(s.Directives); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			if err := p.checkVariables(s.Arguments); err != nil {
+				return err
+			}
+
+			var existing *graphQLSelection
+			for _, field := range *fields {
+				if field.key == s.Key() {
+					existing = field
+				}
+			}
+			if existing == nil {
+				*fields = append(*fields, &graphQLSelection{key: s.Key(), name: s.Name, selections: []graphql.Selection{s}})
+				continue
+			}
+			// The same field may be selected twice (typically through
+			// fragments), but a key can't mean two different things
+			if existing.name != s.Name || len(s.Arguments) > 0 || len(existing.selections[0].(*graphql.Field).Arguments) > 0 {
+				return fmt.Errorf("fields %q conflict: use different aliases", s.Key())
+			}
+			existing.selections = append(existing.selections, s)
+
+		case *graphql.FragmentSpread:
+			if ok, err := p.included(s.Directives); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			fragment, ok := p.doc.Fragments[s.Name]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", s.Name)
+			}
+			if p.spreading[s.Name] {
+				return fmt.Errorf("fragment %q spreads itself", s.Name)
+			}
+			if fragment.TypeCondition != typeName {
+				return fmt.Errorf("fragment %q on type %q can't be spread on type %q", s.Name, fragment.TypeCondition, typeName)
+			}
+			p.spreading[s.Name] = true
+			err := p.collect(fragment.SelectionSet, typeName, fields)
+			p.spreading[s.Name] = false
+			if err != nil {
+				return err
+			}
+
+		case *graphql.InlineFragment:
+			if ok, err := p.included(s.Directives); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			if s.TypeCondition != "" && s.TypeCondition != typeName {
+				return fmt.Errorf("a fragment on type %q can't be spread on type %q", s.TypeCondition, typeName)
+			}
+			if err := p.collect(s.SelectionSet, typeName, fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// included applies @skip and @include, the only directives there are
+func (p *graphQLPlanner) included(directives []*graphql.Directive) (bool, error) {
+	include := true
+	for _, directive := range directives {
+		if !slices.Contains(graphQLDirectives, directive.Name) {
+			return false, fmt.Errorf("unknown directive @%s", directive.Name)
+		}
+		if len(directive.Arguments) != 1 || directive.Arguments[0].Name != "if" {
+			return false, fmt.Errorf("directive @%s takes exactly one argument, if", directive.Name)
+		}
+		value, err := p.resolve(directive.Arguments[0].Value)
+		if err != nil {
+			return false, err
+		}
+		condition, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("the if argument of @%s must be a Boolean", directive.Name)
+		}
+		if condition == (directive.Name == "skip") {
+			include = false
+		}
+	}
+	return include, nil
+}
+
+// resolve turns an argument value into Go values, checking the variables it uses
+func (p *graphQLPlanner) resolve(value graphql.Value) (interface{}, error) {
+	if err := p.checkVariables([]*graphql.Argument{{Value: value}}); err != nil {
+		return nil, err
+	}
+	return graphql.Resolve(value, p.variables), nil
+}
+
+// checkVariables rejects arguments using variables the operation doesn't declare
+func (p *graphQLPlanner) checkVariables(arguments []*graphql.Argument) error {
+	for _, argument := range arguments {
+		switch v := argument.Value.(type) {
+		case *graphql.Variable:
+			if !p.defined[v.Name] {
+				return fmt.Errorf("variable $%s is not declared", v.Name)
+			}
+		case *graphql.ListValue:
+			for _, item := range v.Values {
+				if err := p.checkVariables([]*graphql.Argument{{Value: item}}); err != nil {
+					return err
+				}
+			}
+		case *graphql.ObjectValue:
+			if err := p.checkVariables(v.Fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// projectGraphQL keeps only the selected fields of a resolved value, in the
+// order they were selected. Lists are projected item by item, and functions
+// (used by introspection for values that are costly or recursive) are called
+// only when their field is selected.
+func projectGraphQL(value interface{}, field *graphQLSelection) interface{} {
+	if lazy, ok := value.(func() interface{}); ok {
+		value = lazy()
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = projectGraphQL(item, field)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(graphql.Object, 0, len(field.fields))
+		for _, child := range field.fields {
+			var childValue interface{}
+			switch {
+			case child.name == "__typename":
+				childValue = field.typeName
+			case child.typeName != "":
+				childValue = projectGraphQL(v[child.name], child)
+			default:
+				childValue = v[child.name]
+			}
+			object = append(object, graphql.Member{Key: child.key, Value: childValue})
+		}
+		return object
+	}
+	return value
+}
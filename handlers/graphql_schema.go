@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+)
+
+// graphQLType is a named type of the GraphQL schema
+// The object types are declared by hand and list the JSON fields of the REST
+// responses they're resolved from; graphql_test.go checks they stay in sync.
+type graphQLType struct {
+	kind        string // SCALAR, OBJECT, INPUT_OBJECT or ENUM, as in introspection
+	description string
+	fields      []graphQLField // Of objects and input objects
+	values      []string       // Of enums
+}
+
+// graphQLField is a field of an object type, an argument or an input field
+type graphQLField struct {
+	name         string
+	typ          string // As written in a schema, e.g. "[Task!]!"
+	description  string
+	arguments    []graphQLField
+	defaultValue string // GraphQL literal; "" for none
+}
+
+// graphQLTypes is the schema: every type by name
+var graphQLTypes = map[string]*graphQLType{
+	"ID":        {kind: "SCALAR", description: "A task ID: an integer, or a UUID with TASK_ID_FORMAT=uuid"},
+	"String":    {kind: "SCALAR"},
+	"Int":       {kind: "SCALAR"},
+	"Float":     {kind: "SCALAR"},
+	"Boolean":   {kind: "SCALAR"},
+	"Timestamp": {kind: "SCALAR", description: "An RFC 3339 string, or Unix seconds with TIMESTAMP_FORMAT=unix"},
+	"JSON":      {kind: "SCALAR", description: "Any JSON value"},
+
+	"Query": {kind: "OBJECT", fields: []graphQLField{
+		{name: "me", typ: "User", description: "The authenticated user, like GET /api/auth/me"},
+		{name: "task", typ: "Task", description: "One task, like GET /api/tasks/{id}",
+			arguments: []graphQLField{{name: "id", typ: "ID!"}}},
+		{name: "tasks", typ: "TaskPage", description: "A page of tasks, like POST /api/tasks/search",
+			arguments: []graphQLField{{name: "filter", typ: "TaskFilter"}, {name: "pagination", typ: "Pagination"}}},
+	}},
+	"Mutation": {kind: "OBJECT", fields: []graphQLField{
+		{name: "createTask", typ: "Task", description: "Like POST /api/tasks",
+			arguments: []graphQLField{{name: "input", typ: "CreateTaskInput!"}}},
+		{name: "updateTask", typ: "Task", description: "Like PATCH /api/tasks/{id}; null in input clears a field",
+			arguments: []graphQLField{{name: "id", typ: "ID!"}, {name: "input", typ: "UpdateTaskInput!"}}},
+		{name: "deleteTask", typ: "Boolean", description: "Like DELETE /api/tasks/{id}; true once the task is deleted",
+			arguments: []graphQLField{{name: "id", typ: "ID!"}}},
+	}},
+
+	// Task is a TaskResponse; the ?include= associations aren't offered
+	"Task": {kind: "OBJECT", fields: []graphQLField{
+		{name: "id", typ: "ID!"},
+		{name: "title", typ: "String!"},
+		{name: "description", typ: "String!"},
+		{name: "status", typ: "String!"},
+		{name: "user_id", typ: "Int!"},
+		{name: "due_date", typ: "Timestamp"},
+		{name: "color", typ: "String!"},
+		{name: "progress", typ: "Int!"},
+		{name: "completed_at", typ: "Timestamp"},
+		{name: "visibility", typ: "String!"},
+		{name: "position", typ: "Float!"},
+		{name: "client_id", typ: "String"},
+		{name: "external_id", typ: "String"},
+		{name: "created_at", typ: "Timestamp!"},
+		{name: "updated_at", typ: "Timestamp!"},
+		{name: "checklist_progress", typ: "ChecklistProgress!"},
+		{name: "total_time_seconds", typ: "Int!"},
+		{name: "metadata", typ: "JSON!"},
+		{name: "warnings", typ: "[String!]", description: "Only set by createTask and updateTask"},
+	}},
+	"ChecklistProgress": {kind: "OBJECT", fields: []graphQLField{
+		{name: "done", typ: "Int!"},
+		{name: "total", typ: "Int!"},
+	}},
+	// TaskPage is a PaginatedTaskResponse
+	"TaskPage": {kind: "OBJECT", fields: []graphQLField{
+		{name: "tasks", typ: "[Task!]!"},
+		{name: "page", typ: "Int!"},
+		{name: "page_size", typ: "Int!"},
+		{name: "total", typ: "Int!"},
+		{name: "total_pages", typ: "Int!"},
+		{name: "has_next", typ: "Boolean!"},
+		{name: "has_prev", typ: "Boolean!"},
+		{name: "snapshot", typ: "String"},
+	}},
+	// User is the GET /api/auth/me response
+	"User": {kind: "OBJECT", fields: []graphQLField{
+		{name: "id", typ: "Int!"},
+		{name: "email", typ: "String!"},
+		{name: "org_id", typ: "Int!"},
+		{name: "role", typ: "String!"},
+		{name: "active", typ: "Boolean!"},
+		{name: "created_at", typ: "Timestamp!"},
+		{name: "updated_at", typ: "Timestamp!"},
+		{name: "last_login_at", typ: "Timestamp"},
+		{name: "last_login_ip", typ: "String", description: "null until the first login"},
+	}},
+
+	// The input types are the request bodies of the REST endpoints
+	"TaskFilter": {kind: "INPUT_OBJECT", fields: []graphQLField{
+		{name: "statuses", typ: "[String!]"},
+		{name: "title_contains", typ: "String"},
+		{name: "created_between", typ: "TimeRange"},
+		{name: "shared", typ: "Boolean"},
+		{name: "sort", typ: "String"},
+	}},
+	"TimeRange": {kind: "INPUT_OBJECT", fields: []graphQLField{
+		{name: "from", typ: "Timestamp"},
+		{name: "to", typ: "Timestamp"},
+	}},
+	"Pagination": {kind: "INPUT_OBJECT", fields: []graphQLField{
+		{name: "page", typ: "Int"},
+		{name: "page_size", typ: "Int"},
+	}},
+	"CreateTaskInput": {kind: "INPUT_OBJECT", fields: []graphQLField{
+		{name: "title", typ: "String!"},
+		{name: "description", typ: "String"},
+		{name: "status", typ: "String"},
+		{name: "due_date", typ: "Timestamp"},
+		{name: "color", typ: "String"},
+		{name: "progress", typ: "Int"},
+		{name: "visibility", typ: "String"},
+		{name: "external_id", typ: "String"},
+		{name: "metadata", typ: "JSON"},
+	}},
+	"UpdateTaskInput": {kind: "INPUT_OBJECT", fields: []graphQLField{
+		{name: "title", typ: "String"},
+		{name: "description", typ: "String"},
+		{name: "status", typ: "String"},
+		{name: "due_date", typ: "Timestamp"},
+		{name: "color", typ: "String"},
+		{name: "progress", typ: "Int"},
+		{name: "visibility", typ: "String"},
+		{name: "metadata", typ: "JSON"},
+	}},
+
+	// The introspection types, as the GraphQL spec defines them
+	"__Schema": {kind: "OBJECT", fields: []graphQLField{
+		{name: "description", typ: "String"},
+		{name: "types", typ: "[__Type!]!"},
+		{name: "queryType", typ: "__Type!"},
+		{name: "mutationType", typ: "__Type"},
+		{name: "subscriptionType", typ: "__Type"},
+		{name: "directives", typ: "[__Directive!]!"},
+	}},
+	"__Type": {kind: "OBJECT", fields: []graphQLField{
+		{name: "kind", typ: "__TypeKind!"},
+		{name: "name", typ: "String"},
+		{name: "description", typ: "String"},
+		{name: "specifiedByURL", typ: "String"},
+		{name: "fields", typ: "[__Field!]", arguments: includeDeprecatedArgument},
+		{name: "interfaces", typ: "[__Type!]"},
+		{name: "possibleTypes", typ: "[__Type!]"},
+		{name: "enumValues", typ: "[__EnumValue!]", arguments: includeDeprecatedArgument},
+		{name: "inputFields", typ: "[__InputValue!]", arguments: includeDeprecatedArgument},
+		{name: "ofType", typ: "__Type"},
+		{name: "isOneOf", typ: "Boolean"},
+	}},
+	"__Field": {kind: "OBJECT", fields: []graphQLField{
+		{name: "name", typ: "String!"},
+		{name: "description", typ: "String"},
+		{name: "args", typ: "[__InputValue!]!", arguments: includeDeprecatedArgument},
+		{name: "type", typ: "__Type!"},
+		{name: "isDeprecated", typ: "Boolean!"},
+		{name: "deprecationReason", typ: "String"},
+	}},
+	"__InputValue": {kind: "OBJECT", fields: []graphQLField{
+		{name: "name", typ: "String!"},
+		{name: "description", typ: "String"},
+		{name: "type", typ: "__Type!"},
+		{name: "defaultValue", typ: "String"},
+		{name: "isDeprecated", typ: "Boolean!"},
+		{name: "deprecationReason", typ: "String"},
+	}},
+	"__EnumValue": {kind: "OBJECT", fields: []graphQLField{
+		{name: "name", typ: "String!"},
+		{name: "description", typ: "String"},
+		{name: "isDeprecated", typ: "Boolean!"},
+		{name: "deprecationReason", typ: "String"},
+	}},
+	"__Directive": {kind: "OBJECT", fields: []graphQLField{
+		{name: "name", typ: "String!"},
+		{name: "description", typ: "String"},
+		{name: "locations", typ: "[__DirectiveLocation!]!"},
+		{name: "args", typ: "[__InputValue!]!", arguments: includeDeprecatedArgument},
+		{name: "isRepeatable", typ: "Boolean!"},
+	}},
+	"__TypeKind": {kind: "ENUM", values: []string{
+		"SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL",
+	}},
+	"__DirectiveLocation": {kind: "ENUM", values: []string{
+		"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT",
+		"VARIABLE_DEFINITION", "SCHEMA", "SCALAR", "OBJECT", "FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INTERFACE",
+		"UNION", "ENUM", "ENUM_VALUE", "INPUT_OBJECT", "INPUT_FIELD_DEFINITION",
+	}},
+}
+
+// includeDeprecatedArgument is taken by the introspection lists; nothing is deprecated
+var includeDeprecatedArgument = []graphQLField{{name: "includeDeprecated", typ: "Boolean", defaultValue: "false"}}
+
+// graphQLIntrospectionFields can be selected on Query without being listed
+// among its fields, like __typename on every object type
+var graphQLIntrospectionFields = []graphQLField{
+	{name: "__schema", typ: "__Schema!"},
+	{name: "__type", typ: "__Type", arguments: []graphQLField{{name: "name", typ: "String!"}}},
+}
+
+// graphQLDirectives are the directives the executor applies
+var graphQLDirectives = []string{"skip", "include"}
+
+// graphQLFieldOf looks up field name of the object type typeName
+func graphQLFieldOf(typeName, name string) (graphQLField, bool) {
+	if name == "__typename" {
+		return graphQLField{name: name, typ: "String!"}, true
+	}
+	if typeName == "Query" {
+		for _, field := range graphQLIntrospectionFields {
+			if field.name == name {
+				return field, true
+			}
+		}
+	}
+	for _, field := range graphQLTypes[typeName].fields {
+		if field.name == name {
+			return field, true
+		}
+	}
+	return graphQLField{}, false
+}
+
+// graphQLNamedType strips the list and non-null wrappers off a type, e.g.
+// "[Task!]!" to "Task"
+func graphQLNamedType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
+
+// introspectSchema describes the schema as a __Schema
+// Types' fields are computed only when selected (see projectGraphQL), since
+// the introspection types refer to each other.
+func introspectSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"description":      nil,
+		"queryType":        introspectType("Query"),
+		"mutationType":     introspectType("Mutation"),
+		"subscriptionType": nil,
+		"types": func() interface{} {
+			names := make([]string, 0, len(graphQLTypes))
+			for name := range graphQLTypes {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			types := make([]interface{}, len(names))
+			for i, name := range names {
+				types[i] = introspectType(name)
+			}
+			return types
+		},
+		"directives": func() interface{} {
+			directives := make([]interface{}, len(graphQLDirectives))
+			for i, name := range graphQLDirectives {
+				directives[i] = map[string]interface{}{
+					"name":         name,
+					"description":  nil,
+					"locations":    []interface{}{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+					"args":         introspectInputValues([]graphQLField{{name: "if", typ: "Boolean!"}}),
+					"isRepeatable": false,
+				}
+			}
+			return directives
+		},
+	}
+}
+
+// introspectType describes a named type as a __Type; nil for unknown names
+func introspectType(name string) interface{} {
+	t, ok := graphQLTypes[name]
+	if !ok {
+		return nil
+	}
+
+	described := newIntrospectedType(t.kind)
+	described["name"] = name
+	described["description"] = optionalString(t.description)
+	switch t.kind {
+	case "OBJECT":
+		described["fields"] = func() interface{} {
+			fields := make([]interface{}, len(t.fields))
+			for i, field := range t.fields {
+				fields[i] = map[string]interface{}{
+					"name":              field.name,
+					"description":       optionalString(field.description),
+					"args":              introspectInputValues(field.arguments),
+					"type":              func() interface{} { return introspectTypeRef(field.typ) },
+					"isDeprecated":      false,
+					"deprecationReason": nil,
+				}
+			}
+			return fields
+		}
+		described["interfaces"] = []interface{}{}
+	case "INPUT_OBJECT":
+		described["inputFields"] = func() interface{} { return introspectInputValues(t.fields) }
+		described["isOneOf"] = false
+	case "ENUM":
+		values := make([]interface{}, len(t.values))
+		for i, value := range t.values {
+			values[i] = map[string]interface{}{"name": value, "description": nil, "isDeprecated": false, "deprecationReason": nil}
+		}
+		described["enumValues"] = values
+	}
+	return described
+}
+
+// introspectTypeRef describes a possibly wrapped type, e.g. [Task!]!, as a __Type
+func introspectTypeRef(typ string) interface{} {
+	var described map[string]interface{}
+	switch {
+	case strings.HasSuffix(typ, "!"):
+		described = newIntrospectedType("NON_NULL")
+		described["ofType"] = introspectTypeRef(strings.TrimSuffix(typ, "!"))
+	case strings.HasPrefix(typ, "["):
+		described = newIntrospectedType("LIST")
+		described["ofType"] = introspectTypeRef(typ[1 : len(typ)-1])
+	default:
+		return introspectType(typ)
+	}
+	return described
+}
+
+// newIntrospectedType is a __Type of the given kind with every other field null
+func newIntrospectedType(kind string) map[string]interface{} {
+	described := map[string]interface{}{"kind": kind}
+	for _, field := range graphQLTypes["__Type"].fields {
+		if field.name != "kind" {
+			described[field.name] = nil
+		}
+	}
+	return described
+}
+
+// introspectInputValues describes arguments or input fields as __InputValues
+func introspectInputValues(fields []graphQLField) []interface{} {
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		values[i] = map[string]interface{}{
+			"name":              field.name,
+			"description":       optionalString(field.description),
+			"type":              func() interface{} { return introspectTypeRef(field.typ) },
+			"defaultValue":      optionalString(field.defaultValue),
+			"isDeprecated":      false,
+			"deprecationReason": nil,
+		}
+	}
+	return values
+}
+
+// optionalString is s, or nil (null) when it's empty
+func optionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
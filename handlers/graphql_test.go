@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// graphQLResult is a GraphQL response with data kept as raw JSON to compare
+type graphQLResult struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+// runGraphQL sends a query to POST /graphql as user
+func (e *testEnv) runGraphQL(user middleware.UserContext, query string, variables map[string]interface{}) (int, graphQLResult) {
+	e.t.Helper()
+	rr := e.serve(GraphQL, asUser(e.newRequest("POST", "/graphql", GraphQLRequest{Query: query, Variables: variables}), user))
+	var result graphQLResult
+	e.decode(rr, &result)
+	return rr.Code, result
+}
+
+// TestGraphQLQueries tests the tasks, task and me queries
+func TestGraphQLQueries(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-graphql")
+	other := env.createUser("test-graphql-other")
+
+	env.createTask(user, CreateTaskRequest{Title: "Write report", Status: models.TaskStatusPending})
+	env.createTask(user, CreateTaskRequest{Title: "Review report", Status: models.TaskStatusInProgress})
+	env.createTask(user, CreateTaskRequest{Title: "Buy milk"})
+	othersTask := env.createTask(other, CreateTaskRequest{Title: "Other's report"})
+
+	testCases := []struct {
+		name         string
+		query        string
+		variables    map[string]interface{}
+		expectedData string
+		expectedCode apierror.Code // Of the only error, if any
+	}{
+		{
+			name: "filter and pagination map onto search",
+			query: `query Page($page: Int!) {
+				page: tasks(filter: {title_contains: "report", sort: "title"}, pagination: {page: $page, page_size: 1}) {
+					total has_next tasks { ...taskFields }
+				}
+			}
+			fragment taskFields on Task { title status }`,
+			variables:    map[string]interface{}{"page": 2},
+			expectedData: `{"page":{"total":2,"has_next":false,"tasks":[{"title":"Write report","status":"pending"}]}}`,
+		},
+		{
+			name:         "other users' tasks aren't listed",
+			query:        `{ tasks(filter: {title_contains: "other"}) { total } }`,
+			expectedData: `{"tasks":{"total":0}}`,
+		},
+		{
+			name:         "other users' tasks can't be fetched",
+			query:        fmt.Sprintf(`{ task(id: "%d") { title } }`, othersTask.ID),
+			expectedData: `{"task":null}`,
+			expectedCode: apierror.TaskNotFound,
+		},
+		{
+			name:         "invalid task ID",
+			query:        `{ task(id: "abc") { title } }`,
+			expectedData: `{"task":null}`,
+			expectedCode: apierror.InvalidTaskID,
+		},
+		{
+			name:         "REST validation errors are field errors",
+			query:        `{ tasks(filter: {statuses: ["sleeping"]}) { total } }`,
+			expectedData: `{"tasks":null}`,
+			expectedCode: apierror.InvalidStatus,
+		},
+		{
+			name:         "me",
+			query:        `{ me { email role __typename } __typename }`,
+			expectedData: fmt.Sprintf(`{"me":{"email":%q,"role":"member","__typename":"User"},"__typename":"Query"}`, user.Email),
+		},
+		{
+			name: "skip and include",
+			query: `query($full: Boolean = false) {
+				me { email @include(if: $full) role @skip(if: true) }
+				page: tasks @skip(if: $full) { checklist: tasks { checklist_progress { done } } }
+			}`,
+			expectedData: `{"me":{},"page":{"checklist":[{"checklist_progress":{"done":0}},{"checklist_progress":{"done":0}},{"checklist_progress":{"done":0}}]}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, result := env.runGraphQL(user, tc.query, tc.variables)
+			if status != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %+v", status, result.Errors)
+			}
+			if string(result.Data) != tc.expectedData {
+				t.Errorf("Expected data %s, got %s", tc.expectedData, result.Data)
+			}
+			if tc.expectedCode == "" {
+				if len(result.Errors) > 0 {
+					t.Errorf("Expected no errors, got %+v", result.Errors)
+				}
+				return
+			}
+			if len(result.Errors) != 1 || result.Errors[0].Extensions.Code != tc.expectedCode || len(result.Errors[0].Path) != 1 {
+				t.Errorf("Expected one %s error with a path, got %+v", tc.expectedCode, result.Errors)
+			}
+		})
+	}
+}
+
+// TestGraphQLMutations tests creating, updating and deleting tasks
+func TestGraphQLMutations(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-graphql-mutations")
+	other := env.createUser("test-graphql-mutations-other")
+
+	status, result := env.runGraphQL(user, `mutation($input: CreateTaskInput!) { created: createTask(input: $input) { id title color } }`,
+		map[string]interface{}{"input": map[string]interface{}{"title": "From GraphQL", "color": "#ff0000"}})
+	var created struct {
+		Created TaskResponse `json:"created"`
+	}
+	json.Unmarshal(result.Data, &created)
+	if status != http.StatusOK || len(result.Errors) > 0 || created.Created.Title != "From GraphQL" || created.Created.ID == 0 {
+		t.Fatalf("Expected the task to be created, got %d %s %+v", status, result.Data, result.Errors)
+	}
+	id := created.Created.ID
+
+	// null clears a field, like in PATCH /api/tasks/{id}
+	update := fmt.Sprintf(`mutation { updateTask(id: %d, input: {status: "completed", color: null}) { status color } }`, id)
+	if _, result := env.runGraphQL(user, update, nil); string(result.Data) != `{"updateTask":{"status":"completed","color":""}}` {
+		t.Errorf("Unexpected update result %s %+v", result.Data, result.Errors)
+	}
+
+	// Someone else's task can't be changed or deleted
+	for _, mutation := range []string{update, fmt.Sprintf(`mutation { deleteTask(id: "%d") }`, id)} {
+		_, result := env.runGraphQL(other, mutation, nil)
+		if len(result.Errors) != 1 || result.Errors[0].Extensions.Code != apierror.TaskNotFound {
+			t.Errorf("Expected TASK_NOT_FOUND for another user, got %s %+v", result.Data, result.Errors)
+		}
+	}
+
+	// One root field failing doesn't stop the others
+	mutation := fmt.Sprintf(`mutation { bad: createTask(input: {title: ""}) { id } deleteTask(id: "%d") }`, id)
+	_, result = env.runGraphQL(user, mutation, nil)
+	if string(result.Data) != `{"bad":null,"deleteTask":true}` || len(result.Errors) != 1 || result.Errors[0].Extensions.Code != apierror.TitleRequired {
+		t.Errorf("Unexpected result %s %+v", result.Data, result.Errors)
+	}
+	if result.Errors[0].Path[0] != "bad" || result.Errors[0].Extensions.Status != http.StatusBadRequest {
+		t.Errorf("Expected the error to point at bad with status 400, got %+v", result.Errors[0])
+	}
+
+	var count int64
+	env.tx.Model(&models.Task{}).Where("id = ?", id).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected the task to be deleted")
+	}
+}
+
+// TestGraphQLMaintenance tests that only mutations are blocked during maintenance
+// Not parallel: it changes the global configuration
+func TestGraphQLMaintenance(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) { cfg.MaintenanceMode = true })
+	env := newTestEnv(t)
+	user := env.createUser("test-graphql-maintenance")
+
+	_, result := env.runGraphQL(user, `{ tasks { total } }`, nil)
+	if len(result.Errors) > 0 {
+		t.Errorf("Expected queries to work during maintenance, got %+v", result.Errors)
+	}
+	_, result = env.runGraphQL(user, `mutation { createTask(input: {title: "Blocked"}) { id } }`, nil)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions.Code != apierror.Maintenance || result.Errors[0].Extensions.Status != http.StatusServiceUnavailable {
+		t.Errorf("Expected MAINTENANCE for a mutation, got %+v", result.Errors)
+	}
+}
+
+// TestGraphQLInvalidQueries tests that malformed queries are rejected before anything runs
+func TestGraphQLInvalidQueries(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-graphql-invalid")
+
+	testCases := []struct {
+		name         string
+		query        string
+		expectedCode apierror.Code
+	}{
+		{"syntax error", `{ tasks { total }`, apierror.GraphQLSyntaxError},
+		{"empty query", ``, apierror.GraphQLSyntaxError},
+		{"unknown root field", `{ users { id } }`, apierror.GraphQLValidationFailed},
+		{"unknown field", `{ me { email password } }`, apierror.GraphQLValidationFailed},
+		{"hidden field", `{ me { tasks { id } } }`, apierror.GraphQLValidationFailed},
+		{"missing subfields", `{ me }`, apierror.GraphQLValidationFailed},
+		{"subfields on a scalar", `{ me { email { length } } }`, apierror.GraphQLValidationFailed},
+		{"missing required argument", `{ task { id } }`, apierror.GraphQLValidationFailed},
+		{"unknown argument", `{ task(id: 1, deleted: true) { id } }`, apierror.GraphQLValidationFailed},
+		{"arguments on a nested field", `{ me { email(upper: true) } }`, apierror.GraphQLValidationFailed},
+		{"missing required variable", `query($id: ID!) { task(id: $id) { id } }`, apierror.GraphQLValidationFailed},
+		{"undeclared variable", `{ task(id: $id) { id } }`, apierror.GraphQLValidationFailed},
+		{"unknown fragment", `{ me { ...missing } }`, apierror.GraphQLValidationFailed},
+		{"fragment on the wrong type", `{ me { ...f } } fragment f on Task { id }`, apierror.GraphQLValidationFailed},
+		{"fragment cycle", `{ me { ...a } } fragment a on User { ...b } fragment b on User { ...a }`, apierror.GraphQLValidationFailed},
+		{"conflicting aliases", `{ x: me { id } x: tasks { total } }`, apierror.GraphQLValidationFailed},
+		{"unknown directive", `{ me @cached { id } }`, apierror.GraphQLValidationFailed},
+		{"unknown input field", `{ tasks(filter: {page: 2}) { total } }`, apierror.GraphQLValidationFailed},
+		{"missing required input field", `mutation { createTask(input: {color: "red"}) { id } }`, apierror.GraphQLValidationFailed},
+		{"null for a required argument", `{ task(id: null) { id } }`, apierror.GraphQLValidationFailed},
+		{"variable of an object type", `query($task: Task) { me { id } }`, apierror.GraphQLValidationFailed},
+		{"introspection on Mutation", `mutation { __schema { types { name } } }`, apierror.GraphQLValidationFailed},
+		{"several operations without a name", `query A { me { id } } query B { me { id } }`, apierror.GraphQLValidationFailed},
+		{"subscription", `subscription { tasks { total } }`, apierror.GraphQLValidationFailed},
+		{"a mutation with a mistake changes nothing", `mutation { createTask(input: {title: "Never"}) { id } deleteTask }`, apierror.GraphQLValidationFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, result := env.runGraphQL(user, tc.query, nil)
+			if status != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", status, result.Data)
+			}
+			if result.Data != nil || len(result.Errors) != 1 || result.Errors[0].Extensions.Code != tc.expectedCode {
+				t.Errorf("Expected only a %s error, got %s %+v", tc.expectedCode, result.Data, result.Errors)
+			}
+		})
+	}
+
+	var count int64
+	env.tx.Model(&models.Task{}).Where("user_id = ?", user.UserID).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no task to be created by an invalid mutation, found %d", count)
+	}
+
+	// Syntax errors say where the problem is
+	_, result := env.runGraphQL(user, "{\n  me { email ]\n}", nil)
+	if len(result.Errors) != 1 || len(result.Errors[0].Locations) != 1 || result.Errors[0].Locations[0] != (GraphQLErrorLocation{Line: 2, Column: 14}) {
+		t.Errorf("Expected the error at 2:14, got %+v", result.Errors)
+	}
+
+	// operationName picks one of several operations
+	rr := env.serve(GraphQL, asUser(env.newRequest("POST", "/graphql", GraphQLRequest{Query: `query A { a: __typename } query B { b: __typename }`, OperationName: "B"}), user))
+	if body := rr.Body.String(); rr.Code != http.StatusOK || body != `{"data":{"b":"Query"}}`+"\n" {
+		t.Errorf("Expected operation B to run, got %d %s", rr.Code, body)
+	}
+}
+
+// TestGraphQLTypesMatchREST tests that the declared object types have exactly
+// the fields of the REST responses they're resolved from
+func TestGraphQLTypesMatchREST(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-graphql-types")
+	task := env.createTask(user, CreateTaskRequest{Title: "Typed"})
+
+	testCases := []struct {
+		typeName string
+		rr       *httptest.ResponseRecorder
+		path     []string // Where the object is in the response
+		omitted  []string // Declared fields this response leaves out
+	}{
+		{"User", env.serve(GetCurrentUser, asUser(env.newRequest("GET", "/api/auth/me", nil), user)), nil, []string{"last_login_ip"}},
+		{"Task", env.serve(GetTask, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks/%d", task.ID), nil), user)), nil, []string{"warnings"}},
+		{"ChecklistProgress", env.serve(GetTask, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks/%d", task.ID), nil), user)), []string{"checklist_progress"}, nil},
+		{"TaskPage", env.serve(SearchTasks, asUser(env.newRequest("POST", "/api/tasks/search", map[string]interface{}{}), user)), nil, []string{"snapshot"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.typeName, func(t *testing.T) {
+			var object map[string]json.RawMessage
+			env.decode(tc.rr, &object)
+			for _, key := range tc.path {
+				var nested map[string]json.RawMessage
+				json.Unmarshal(object[key], &nested)
+				object = nested
+			}
+
+			declared := map[string]bool{}
+			for _, field := range graphQLTypes[tc.typeName].fields {
+				declared[field.name] = true
+				if _, ok := object[field.name]; !ok && !slices.Contains(tc.omitted, field.name) {
+					t.Errorf("%s.%s isn't in the REST response", tc.typeName, field.name)
+				}
+			}
+			for key := range object {
+				if !declared[key] {
+					t.Errorf("%s has no field for %q of the REST response", tc.typeName, key)
+				}
+			}
+		})
+	}
+}
+
+// TestGraphQLSchemaIsComplete tests that every type the schema refers to is
+// declared and every Query and Mutation field has a resolver
+func TestGraphQLSchemaIsComplete(t *testing.T) {
+	for name, typ := range graphQLTypes {
+		for _, field := range typ.fields {
+			if _, ok := graphQLTypes[graphQLNamedType(field.typ)]; !ok {
+				t.Errorf("%s.%s is of undeclared type %s", name, field.name, field.typ)
+			}
+			for _, argument := range field.arguments {
+				if _, ok := graphQLTypes[graphQLNamedType(argument.typ)]; !ok {
+					t.Errorf("Argument %s of %s.%s is of undeclared type %s", argument.name, name, field.name, argument.typ)
+				}
+			}
+		}
+	}
+
+	for typeName, resolvers := range map[string]map[string]graphQLResolver{"Query": graphQLQueries, "Mutation": graphQLMutations} {
+		for name := range resolvers {
+			if _, ok := graphQLFieldOf(typeName, name); !ok {
+				t.Errorf("Resolver %s.%s has no declared field", typeName, name)
+			}
+		}
+		for _, field := range graphQLTypes[typeName].fields {
+			if resolvers[field.name] == nil {
+				t.Errorf("%s.%s has no resolver", typeName, field.name)
+			}
+		}
+	}
+}
+
+// TestGraphQLIntrospection tests __schema and __type
+func TestGraphQLIntrospection(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-graphql-introspection")
+
+	_, result := env.runGraphQL(user, `{
+		__type(name: "User") { kind name fields { name type { kind name ofType { kind name } } } }
+		missing: __type(name: "Password") { name }
+	}`, nil)
+	var types struct {
+		Type struct {
+			Kind   string `json:"kind"`
+			Name   string `json:"name"`
+			Fields []struct {
+				Name string          `json:"name"`
+				Type json.RawMessage `json:"type"`
+			} `json:"fields"`
+		} `json:"__type"`
+		Missing *struct{} `json:"missing"`
+	}
+	json.Unmarshal(result.Data, &types)
+	if len(result.Errors) > 0 || types.Type.Kind != "OBJECT" || types.Type.Name != "User" || types.Missing != nil {
+		t.Fatalf("Unexpected result %s %+v", result.Data, result.Errors)
+	}
+	if len(types.Type.Fields) != len(graphQLTypes["User"].fields) || types.Type.Fields[1].Name != "email" {
+		t.Errorf("Expected the declared User fields, got %+v", types.Type.Fields)
+	}
+	if string(types.Type.Fields[1].Type) != `{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"String"}}` {
+		t.Errorf("Expected email to be String!, got %s", types.Type.Fields[1].Type)
+	}
+
+	// The query GraphiQL and other tools send to learn the schema
+	_, result = env.runGraphQL(user, `query IntrospectionQuery {
+		__schema {
+			queryType { name } mutationType { name } subscriptionType { name }
+			types { ...FullType }
+			directives { name description locations args { ...InputValue } }
+		}
+	}
+	fragment FullType on __Type {
+		kind name description
+		fields(includeDeprecated: true) { name description args { ...InputValue } type { ...TypeRef } isDeprecated deprecationReason }
+		inputFields { ...InputValue }
+		interfaces { ...TypeRef }
+		enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason }
+		possibleTypes { ...TypeRef }
+	}
+	fragment InputValue on __InputValue { name description type { ...TypeRef } defaultValue }
+	fragment TypeRef on __Type { kind name ofType { kind name ofType { kind name ofType { kind name } } } }`, nil)
+	var schema struct {
+		Schema struct {
+			QueryType        struct{ Name string } `json:"queryType"`
+			MutationType     struct{ Name string } `json:"mutationType"`
+			SubscriptionType *struct{}             `json:"subscriptionType"`
+			Types            []struct {
+				Name        string          `json:"name"`
+				InputFields json.RawMessage `json:"inputFields"`
+			} `json:"types"`
+			Directives []struct{ Name string } `json:"directives"`
+		} `json:"__schema"`
+	}
+	json.Unmarshal(result.Data, &schema)
+	if len(result.Errors) > 0 || schema.Schema.QueryType.Name != "Query" || schema.Schema.MutationType.Name != "Mutation" || schema.Schema.SubscriptionType != nil {
+		t.Fatalf("Unexpected result %s %+v", result.Data, result.Errors)
+	}
+	if len(schema.Schema.Types) != len(graphQLTypes) || len(schema.Schema.Directives) != 2 {
+		t.Errorf("Expected every type and both directives, got %d types and %+v", len(schema.Schema.Types), schema.Schema.Directives)
+	}
+	for _, typ := range schema.Schema.Types {
+		if typ.Name == "Pagination" && string(typ.InputFields) != `[{"name":"page","description":null,"type":{"kind":"SCALAR","name":"Int","ofType":null},"defaultValue":null},{"name":"page_size","description":null,"type":{"kind":"SCALAR","name":"Int","ofType":null},"defaultValue":null}]` {
+			t.Errorf("Unexpected Pagination input fields %s", typ.InputFields)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// hardDeleteTask removes a task for good, with everything that hangs off it
+// (TASK_HARD_DELETE). The rows referencing the task go first, since their
+// foreign keys would block the delete, all in one transaction. The attachment
+// files are removed once it has committed: a failed delete keeps them, and a
+// file that can't be removed is only logged, as nothing points at it any more.
+func hardDeleteTask(ctx context.Context, db *gorm.DB, task models.Task) error {
+	var storageKeys []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Attachment{}).Where("task_id = ?", task.ID).Pluck("storage_key", &storageKeys).Error; err != nil {
+			return err
+		}
+		for _, dependent := range []interface{}{&models.Notification{}, &models.Comment{}, &models.Attachment{}, &models.ChecklistItem{}, &models.TimeEntry{}, &models.TaskShare{}, &models.TaskStatusHistory{}} {
+			if err := tx.Where("task_id = ?", task.ID).Delete(dependent).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&task).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range storageKeys {
+		if err := attachmentStorage.Delete(ctx, key); err != nil {
+			log.Printf("Failed to remove attachment %s of deleted task %d: %v", key, task.ID, err)
+		}
+	}
+	return nil
+}
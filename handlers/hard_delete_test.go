@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/storage"
+)
+
+// TestDeleteTaskModes tests soft deletes (the default) against TASK_HARD_DELETE
+// Not parallel: it overrides the global configuration and attachment storage
+func TestDeleteTaskModes(t *testing.T) {
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	previous := attachmentStorage
+	SetAttachmentStorage(store)
+	t.Cleanup(func() { SetAttachmentStorage(previous) })
+
+	testCases := []struct {
+		name       string
+		hardDelete bool
+	}{
+		{"soft delete", false},
+		{"hard delete", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withConfig(t, func(cfg *config.Config) { cfg.TaskHardDelete = tc.hardDelete })
+			env := newTestEnv(t)
+			owner := env.createUser("test-delete-mode")
+			reader := env.createUser("test-delete-mode-reader")
+			task := env.createTask(owner, CreateTaskRequest{Title: "Delete me"})
+			path := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+			// Give the task a bit of everything that references it
+			setup := []*http.Request{
+				asUser(env.newRequest("PATCH", path, `{"status":"in_progress"}`), owner),
+				asUser(env.newRequest("POST", path+"/shares", ShareTaskRequest{UserID: reader.UserID}), owner),
+				asUser(env.newRequest("POST", path+"/checklist", ChecklistItemRequest{Text: "Step one"}), owner),
+				asUser(env.uploadRequest(path+"/attachments", "file", "notes.txt", "text/plain", "hi"), owner),
+			}
+			for i, handler := range []http.HandlerFunc{UpdateTask, ShareTask, AddChecklistItem, UploadTaskAttachment} {
+				if rr := env.serve(handler, setup[i]); rr.Code >= 300 {
+					t.Fatalf("Setup request %d failed: %d %s", i, rr.Code, rr.Body.String())
+				}
+			}
+			var attachment models.Attachment
+			env.tx.Where("task_id = ?", task.ID).First(&attachment)
+
+			rr := env.serve(DeleteTask, asUser(env.newRequest("DELETE", path, nil), owner))
+			if rr.Code != http.StatusNoContent {
+				t.Fatalf("Expected status 204, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			// Either way the task is gone from the API
+			if rr := env.serve(GetTask, asUser(env.newRequest("GET", path, nil), owner)); rr.Code != http.StatusNotFound {
+				t.Errorf("Expected status 404 after delete, got %d", rr.Code)
+			}
+
+			var rows int64
+			env.tx.Unscoped().Model(&models.Task{}).Where("id = ?", task.ID).Count(&rows)
+			var dependents int64
+			for _, model := range []interface{}{&models.Attachment{}, &models.ChecklistItem{}, &models.TaskShare{}, &models.TaskStatusHistory{}} {
+				var count int64
+				env.tx.Model(model).Where("task_id = ?", task.ID).Count(&count)
+				dependents += count
+			}
+			file, openErr := store.Open(context.Background(), attachment.StorageKey)
+			if file != nil {
+				file.Close()
+			}
+
+			if tc.hardDelete {
+				if rows != 0 || dependents != 0 {
+					t.Errorf("Expected no task or dependent rows, found %d and %d", rows, dependents)
+				}
+				if !errors.Is(openErr, storage.ErrNotFound) {
+					t.Errorf("Expected the attachment file to be removed, got %v", openErr)
+				}
+				return
+			}
+
+			var deleted models.Task
+			env.tx.Unscoped().First(&deleted, task.ID)
+			if !deleted.DeletedAt.Valid {
+				t.Errorf("Expected deleted_at to be set")
+			}
+			if dependents == 0 || openErr != nil {
+				t.Errorf("Expected a soft delete to keep dependent rows and files, got %d rows, %v", dependents, openErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// StatusChangeResponse represents one entry of a task's status history
+type StatusChangeResponse struct {
+	FromStatus models.TaskStatus `json:"from_status"`
+	ToStatus   models.TaskStatus `json:"to_status"`
+	UserID     uint              `json:"user_id"`    // User who changed the status
+	ChangedAt  Timestamp         `json:"changed_at"` // When the change happened
+}
+
+// TaskHistoryResponse represents the status history of a task
+type TaskHistoryResponse struct {
+	TaskID  any                    `json:"task_id"` // The public UUID with TASK_ID_FORMAT=uuid
+	History []StatusChangeResponse `json:"history"` // Oldest change first
+}
+
+// GetTaskHistory handles GET /api/tasks/{id}/history - Get the status changes of a task
+func GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/history
+	taskID, err := taskIDFromPath(r.URL.Path, "/history")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	// Only the task owner may read its history
+	db, cancel := requestDB(r)
+	defer cancel()
+	task, err := findOwnedTask(db, taskID, user)
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+		return
+	}
+
+	var entries []models.TaskStatusHistory
+	if err := db.Where("task_id = ?", task.ID).Order("created_at ASC, id ASC").Find(&entries).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch history for task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch task history")
+		return
+	}
+
+	response := TaskHistoryResponse{
+		TaskID:  taskResponseID(TaskResponse{ID: task.ID, PublicID: task.PublicID}),
+		History: make([]StatusChangeResponse, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		response.History = append(response.History, StatusChangeResponse{
+			FromStatus: entry.FromStatus,
+			ToStatus:   entry.ToStatus,
+			UserID:     entry.UserID,
+			ChangedAt:  newTimestamp(entry.CreatedAt),
+		})
+	}
+
+	writeResponse(w, r, http.StatusOK, response)
+}
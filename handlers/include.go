@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"gorm.io/gorm"
+)
+
+// taskIncludes maps the ?include= values of GET /api/tasks and
+// GET /api/tasks/{id} to the task association they preload, with the
+// order the association's own endpoint lists it in
+var taskIncludes = map[string]struct {
+	association string
+	order       string
+}{
+	"checklist":   {"Checklist", "position ASC, id ASC"},
+	"attachments": {"Attachments", "id ASC"},
+}
+
+// maxTaskIncludes caps how many associations one request may include
+// Each one costs a query per request (not per task), and a large response.
+const maxTaskIncludes = 2
+
+// invalidIncludeMessage is the INVALID_INCLUDE error message, naming the associations
+const invalidIncludeMessage = "Invalid include. Use a comma-separated list of checklist, attachments"
+
+// parseTaskIncludes reads ?include=checklist,attachments
+// It writes an error response and returns false for values that aren't
+// associations, or too many of them. Repeated values count once.
+func parseTaskIncludes(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	value := r.URL.Query().Get("include")
+	if value == "" {
+		return nil, true
+	}
+
+	var includes []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := taskIncludes[name]; !ok {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidInclude, invalidIncludeMessage)
+			return nil, false
+		}
+		if !seen[name] {
+			seen[name] = true
+			includes = append(includes, name)
+		}
+	}
+	if len(includes) > maxTaskIncludes {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidInclude, fmt.Sprintf("At most %d associations can be included", maxTaskIncludes))
+		return nil, false
+	}
+	return includes, true
+}
+
+// preloadTaskIncludes preloads the included associations of the tasks a query finds
+// GORM loads each association for all of them with one extra query
+// (WHERE task_id IN ...), so a page of tasks doesn't cost a query per task.
+func preloadTaskIncludes(includes []string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		for _, name := range includes {
+			include := taskIncludes[name]
+			tx = tx.Preload(include.association, func(db *gorm.DB) *gorm.DB {
+				return db.Order(include.order)
+			})
+		}
+		return tx
+	}
+}
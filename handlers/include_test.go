@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// TestTaskIncludes tests preloading associations with ?include= on the task and the list
+func TestTaskIncludes(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-include")
+
+	withItems := env.createTask(user, CreateTaskRequest{Title: "With checklist"})
+	withoutItems := env.createTask(user, CreateTaskRequest{Title: "Without checklist"})
+	checklistPath := fmt.Sprintf("/api/tasks/%d/checklist", withItems.ID)
+	for _, text := range []string{"First", "Second"} {
+		rr := env.serve(AddChecklistItem, asUser(env.newRequest("POST", checklistPath, ChecklistItemRequest{Text: text}), user))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Failed to add checklist item: status %d, body %s", rr.Code, rr.Body.String())
+		}
+	}
+	taskPath := fmt.Sprintf("/api/tasks/%d", withItems.ID)
+
+	testCases := []struct {
+		name     string
+		list     bool // GET /api/tasks rather than GET /api/tasks/{id}
+		path     string
+		expected map[uint]string // Task ID -> its checklist texts and attachment count, "-" for left out
+	}{
+		{"nothing included by default", false, taskPath, map[uint]string{withItems.ID: "- -"}},
+		{"task with checklist", false, taskPath + "?include=checklist", map[uint]string{withItems.ID: "[First Second] -"}},
+		{"task with both", false, taskPath + "?include=attachments,checklist", map[uint]string{withItems.ID: "[First Second] 0"}},
+		{"repeated values count once", false, taskPath + "?include=checklist,checklist", map[uint]string{withItems.ID: "[First Second] -"}},
+		{"list without include", true, "/api/tasks", map[uint]string{withItems.ID: "- -", withoutItems.ID: "- -"}},
+		{"list with both", true, "/api/tasks?include=checklist,%20attachments", map[uint]string{withItems.ID: "[First Second] 0", withoutItems.ID: "[] 0"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := GetTask
+			if tc.list {
+				handler = GetTasks
+			}
+			rr := env.serve(handler, asUser(env.newRequest("GET", tc.path, nil), user))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var tasks []TaskResponse
+			if tc.list {
+				var page PaginatedTaskResponse
+				env.decode(rr, &page)
+				tasks = page.Tasks
+			} else {
+				var task TaskResponse
+				env.decode(rr, &task)
+				tasks = append(tasks, task)
+			}
+
+			got := make(map[uint]string)
+			for _, task := range tasks {
+				checklist, attachments := "-", "-"
+				if task.Checklist != nil {
+					texts := make([]string, 0)
+					for _, item := range *task.Checklist {
+						texts = append(texts, item.Text)
+					}
+					checklist = fmt.Sprint(texts)
+				}
+				if task.Attachments != nil {
+					attachments = fmt.Sprint(len(*task.Attachments))
+				}
+				got[task.ID] = checklist + " " + attachments
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	// Left out means the keys aren't there at all, not null
+	rr := env.serve(GetTask, asUser(env.newRequest("GET", taskPath, nil), user))
+	var raw map[string]json.RawMessage
+	env.decode(rr, &raw)
+	if _, ok := raw["checklist"]; ok {
+		t.Errorf("Expected no checklist key without include, got %s", raw["checklist"])
+	}
+
+	invalidCases := []struct {
+		handler http.HandlerFunc
+		path    string
+	}{
+		{GetTask, taskPath + "?include=comments"},
+		{GetTasks, "/api/tasks?include=checklist,tags"},
+		{GetTasks, "/api/tasks?include=checklist,"},
+	}
+	for _, tc := range invalidCases {
+		rr := env.serve(tc.handler, asUser(env.newRequest("GET", tc.path, nil), user))
+		var errResp ErrorResponse
+		env.decode(rr, &errResp)
+		if rr.Code != http.StatusBadRequest || errResp.Code != apierror.InvalidInclude {
+			t.Errorf("%s: expected 400 INVALID_INCLUDE, got %d %s", tc.path, rr.Code, errResp.Code)
+		}
+	}
+}
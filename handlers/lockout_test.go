@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestLoginLockout tests locking an account after repeated wrong passwords
+// Not parallel: it changes the lockout settings
+func TestLoginLockout(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.LoginMaxAttempts = 3
+		cfg.LoginLockoutDuration = time.Hour
+	})
+	env := newTestEnv(t)
+	user := env.createUser("test-lockout") // Registered with "testpassword123"
+
+	login := func(password string) int {
+		return env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: user.Email, Password: password})).Code
+	}
+	loadUser := func() models.User {
+		var stored models.User
+		env.tx.First(&stored, user.UserID)
+		return stored
+	}
+
+	// A successful login resets the count, so two failures on either side don't lock
+	login("wrong-1")
+	login("wrong-2")
+	if code := login("testpassword123"); code != http.StatusOK {
+		t.Fatalf("Expected login before the threshold to succeed, got %d", code)
+	}
+	if stored := loadUser(); stored.FailedLoginAttempts != 0 {
+		t.Errorf("Expected the count to be reset after a successful login, got %d", stored.FailedLoginAttempts)
+	}
+
+	// The third failure in a row locks the account
+	for i := 0; i < 3; i++ {
+		if code := login("wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("Expected 401 for a wrong password, got %d", code)
+		}
+	}
+	if stored := loadUser(); !stored.IsLocked(time.Now()) {
+		t.Fatalf("Expected the account to be locked, locked_until=%v", stored.LockedUntil)
+	}
+
+	// The right password doesn't get through while locked, and the response is the generic one
+	rr := env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: user.Email, Password: "testpassword123"}))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 during lockout, got %d", rr.Code)
+	}
+	var response ErrorResponse
+	env.decode(rr, &response)
+	if response.Error != "Invalid email or password" {
+		t.Errorf("Expected the generic error message, got %q", response.Error)
+	}
+
+	// Once the lockout has passed the right password works again
+	env.tx.Model(&models.User{}).Where("id = ?", user.UserID).UpdateColumn("locked_until", time.Now().Add(-time.Minute))
+	if code := login("testpassword123"); code != http.StatusOK {
+		t.Fatalf("Expected login after the lockout to succeed, got %d", code)
+	}
+	if stored := loadUser(); stored.LockedUntil != nil || stored.FailedLoginAttempts != 0 {
+		t.Errorf("Expected lockout state to be cleared, got attempts=%d locked_until=%v", stored.FailedLoginAttempts, stored.LockedUntil)
+	}
+}
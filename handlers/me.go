@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// GetCurrentUser handles GET /api/auth/me - Get the authenticated user's account
+// Besides the profile it includes when and from which IP address the user
+// last logged in, so they can spot logins that weren't theirs
+func GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	authUser, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// A valid token for a deleted user doesn't make the account exist again
+	var user models.User
+	if err := db.Where("org_id = ?", authUser.OrgID).First(&user, authUser.UserID).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to load user %d: %v", authUser.UserID, err)
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, user)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestLastLogin tests recording logins and showing them in /api/auth/me
+// Not parallel: it sets TRUSTED_PROXIES
+func TestLastLogin(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TrustedProxies = []string{"10.0.0.0/8"}
+	})
+	env := newTestEnv(t)
+	user := env.createUser("test-last-login") // Registered with "testpassword123"
+
+	me := func() models.User {
+		rr := env.serve(GetCurrentUser, asUser(env.newRequest("GET", "/api/auth/me", nil), user))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var account models.User
+		env.decode(rr, &account)
+		return account
+	}
+	login := func(password, remoteAddr, forwardedFor string) int {
+		req := env.newRequest("POST", "/api/auth/login", LoginRequest{Email: user.Email, Password: password})
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		return env.serve(Login, req).Code
+	}
+
+	if account := me(); account.LastLoginAt != nil || account.LastLoginIP != "" {
+		t.Errorf("Expected no recorded login after registering, got %v from %q", account.LastLoginAt, account.LastLoginIP)
+	}
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expectedIP   string
+	}{
+		{"direct connection", "203.0.113.7:51000", "", "203.0.113.7"},
+		{"spoofed header from an untrusted peer", "203.0.113.7:51000", "198.51.100.1", "203.0.113.7"},
+		{"behind a trusted proxy", "10.0.0.2:443", "6.6.6.6, 198.51.100.1", "198.51.100.1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := time.Now().Add(-time.Second)
+			if code := login("testpassword123", tc.remoteAddr, tc.forwardedFor); code != http.StatusOK {
+				t.Fatalf("Expected login to succeed, got %d", code)
+			}
+
+			account := me()
+			if account.LastLoginIP != tc.expectedIP {
+				t.Errorf("Expected last login from %s, got %q", tc.expectedIP, account.LastLoginIP)
+			}
+			if account.LastLoginAt == nil || account.LastLoginAt.Before(before) {
+				t.Errorf("Expected a fresh last login time, got %v", account.LastLoginAt)
+			}
+		})
+	}
+
+	// Failed logins aren't recorded
+	previous := me()
+	if code := login("wrong-password", "192.0.2.99:51000", ""); code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 for a wrong password, got %d", code)
+	}
+	if account := me(); account.LastLoginIP != previous.LastLoginIP {
+		t.Errorf("Expected a failed login to leave the record alone, got %q", account.LastLoginIP)
+	}
+}
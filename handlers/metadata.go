@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// newTaskMetadata returns the metadata a new task is created with
+// Keys sent as null are left out, as they would be by an update. It writes a
+// 400 response and returns false when raw isn't a JSON object or is too large.
+func newTaskMetadata(w http.ResponseWriter, r *http.Request, raw json.RawMessage) (models.TaskMetadata, bool) {
+	return mergeTaskMetadata(w, r, nil, raw)
+}
+
+// mergeTaskMetadata shallow-merges the metadata object sent in an update into current
+// Top-level keys in raw replace those of current, keys sent as null are
+// removed, and the rest are kept. An absent or null raw leaves current as it is.
+// It writes a 400 response and returns false when raw isn't a JSON object or
+// the merged metadata is larger than MAX_METADATA_SIZE.
+func mergeTaskMetadata(w http.ResponseWriter, r *http.Request, current models.TaskMetadata, raw json.RawMessage) (models.TaskMetadata, bool) {
+	merged, fieldErr := mergeMetadata(current, raw)
+	if fieldErr != nil {
+		writeFieldError(w, r, *fieldErr)
+		return nil, false
+	}
+	return merged, true
+}
+
+// mergeMetadata is mergeTaskMetadata without the response
+func mergeMetadata(current models.TaskMetadata, raw json.RawMessage) (models.TaskMetadata, *TaskFieldError) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return current, nil
+	}
+
+	// Arrays and scalars would decode into nothing (or fail) as a map, so check first
+	var patch models.TaskMetadata
+	if raw[0] != '{' || json.Unmarshal(raw, &patch) != nil {
+		return nil, &TaskFieldError{"metadata", apierror.InvalidMetadata, "metadata must be a JSON object"}
+	}
+	merged := current.Merge(patch)
+
+	// The limit applies to what's stored, so many small updates can't grow past it
+	if limit := config.Get().MaxMetadataSize; limit > 0 {
+		data, err := json.Marshal(merged)
+		if err != nil || len(data) > limit {
+			return nil, &TaskFieldError{"metadata", apierror.MetadataTooLarge, fmt.Sprintf("metadata cannot be larger than %d bytes", limit)}
+		}
+	}
+	return merged, nil
+}
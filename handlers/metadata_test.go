@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+)
+
+// TestTaskMetadata tests creating tasks with metadata and shallow-merging it on updates
+func TestTaskMetadata(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-metadata")
+
+	// metadataOf returns the task's metadata as the API sends it
+	metadataOf := func(rr *httptest.ResponseRecorder) string {
+		var task struct {
+			Metadata json.RawMessage `json:"metadata"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &task); err != nil {
+			t.Fatalf("Failed to decode task: %v", err)
+		}
+		return string(task.Metadata)
+	}
+
+	plain := env.createTask(user, CreateTaskRequest{Title: "No metadata"})
+	rr := env.serve(GetTask, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks/%d", plain.ID), nil), user))
+	if got := metadataOf(rr); got != "{}" {
+		t.Errorf("Expected {} without metadata, got %s", got)
+	}
+
+	body := `{"title":"Synced","metadata":{"jira":{"key":"OPS-1","url":"https://jira.example.com/OPS-1"},"points":3,"draft":null}}`
+	rr = env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", body), user))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if got := metadataOf(rr); got != `{"jira":{"key":"OPS-1","url":"https://jira.example.com/OPS-1"},"points":3}` {
+		t.Errorf("Expected the metadata without null keys, got %s", got)
+	}
+	var task TaskResponse
+	env.decode(rr, &task)
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	testCases := []struct {
+		name     string
+		body     string
+		expected string // Metadata after the update
+	}{
+		{"other fields leave it alone", `{"title":"Renamed"}`, `{"jira":{"key":"OPS-1","url":"https://jira.example.com/OPS-1"},"points":3}`},
+		{"keys are added and replaced whole", `{"metadata":{"jira":{"key":"OPS-2"},"owner":"team-a"}}`, `{"jira":{"key":"OPS-2"},"owner":"team-a","points":3}`},
+		{"null removes a key", `{"metadata":{"points":null}}`, `{"jira":{"key":"OPS-2"},"owner":"team-a"}`},
+		{"empty object changes nothing", `{"metadata":{}}`, `{"jira":{"key":"OPS-2"},"owner":"team-a"}`},
+		{"null clears it", `{"metadata":null}`, `{}`},
+	}
+	for _, tc := range testCases {
+		rr := env.serve(UpdateTask, asUser(env.newRequest("PATCH", path, tc.body), user))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", tc.name, http.StatusOK, rr.Code, rr.Body.String())
+		}
+		if got := metadataOf(rr); got != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, got)
+		}
+		// What's returned is what was saved
+		rr = env.serve(GetTask, asUser(env.newRequest("GET", path, nil), user))
+		if got := metadataOf(rr); got != tc.expected {
+			t.Errorf("%s: expected %s after reading it back, got %s", tc.name, tc.expected, got)
+		}
+	}
+
+	// Only objects are metadata
+	for _, metadata := range []string{`[1,2]`, `"text"`, `42`, `true`} {
+		rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", `{"title":"Bad","metadata":`+metadata+`}`), user))
+		var errResp ErrorResponse
+		env.decode(rr, &errResp)
+		if rr.Code != http.StatusBadRequest || errResp.Code != apierror.InvalidMetadata {
+			t.Errorf("Expected 400 %s for %s on create, got %d %s", apierror.InvalidMetadata, metadata, rr.Code, errResp.Code)
+		}
+		rr = env.serve(UpdateTask, asUser(env.newRequest("PATCH", path, `{"metadata":`+metadata+`}`), user))
+		env.decode(rr, &errResp)
+		if rr.Code != http.StatusBadRequest || errResp.Code != apierror.InvalidMetadata {
+			t.Errorf("Expected 400 %s for %s on update, got %d %s", apierror.InvalidMetadata, metadata, rr.Code, errResp.Code)
+		}
+	}
+}
+
+// TestTaskMetadataSize tests MAX_METADATA_SIZE on create and on the merged result of updates
+func TestTaskMetadataSize(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser("test-metadata-size")
+	withConfig(t, func(cfg *config.Config) {
+		cfg.MaxMetadataSize = 40
+	})
+
+	value := strings.Repeat("x", 20)
+	rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", `{"title":"Small","metadata":{"a":"`+value+`"}}`), user))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var task TaskResponse
+	env.decode(rr, &task)
+
+	// Each half fits, but not both together
+	rr = env.serve(UpdateTask, asUser(env.newRequest("PATCH", fmt.Sprintf("/api/tasks/%d", task.ID), `{"metadata":{"b":"`+value+`"}}`), user))
+	var errResp ErrorResponse
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusBadRequest || errResp.Code != apierror.MetadataTooLarge {
+		t.Errorf("Expected 400 %s, got %d %s", apierror.MetadataTooLarge, rr.Code, errResp.Code)
+	}
+
+	rr = env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", `{"title":"Big","metadata":{"a":"`+value+value+`"}}`), user))
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusBadRequest || errResp.Code != apierror.MetadataTooLarge {
+		t.Errorf("Expected 400 %s on create, got %d %s", apierror.MetadataTooLarge, rr.Code, errResp.Code)
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// NotificationResponse represents a notification in API responses
+type NotificationResponse struct {
+	ID        uint       `json:"id"`
+	Type      string     `json:"type"`       // e.g. mention
+	TaskID    any        `json:"task_id"`    // The public UUID with TASK_ID_FORMAT=uuid
+	CommentID *uint      `json:"comment_id"` // The comment with the mention; null for other types
+	ActorID   uint       `json:"actor_id"`   // Who caused it
+	ReadAt    *Timestamp `json:"read_at"`    // null until marked read
+	CreatedAt Timestamp  `json:"created_at"`
+}
+
+// PaginatedNotificationResponse represents a page of notifications
+type PaginatedNotificationResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	PaginationMeta
+}
+
+// newNotificationResponse converts a notification model to its API representation
+// publicIDs maps task IDs to public IDs (see taskPublicIDs)
+func newNotificationResponse(notification models.Notification, publicIDs map[uint]string) NotificationResponse {
+	return NotificationResponse{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		TaskID:    taskResponseID(TaskResponse{ID: notification.TaskID, PublicID: publicIDs[notification.TaskID]}),
+		CommentID: notification.CommentID,
+		ActorID:   notification.ActorID,
+		ReadAt:    newOptionalTimestamp(notification.ReadAt),
+		CreatedAt: newTimestamp(notification.CreatedAt),
+	}
+}
+
+// GetNotifications handles GET /api/notifications - List the caller's notifications, newest first
+// Paginated like GET /api/tasks; ?unread=true leaves out the ones already read.
+func GetNotifications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	page, pageSize, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	scope := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("user_id = ?", user.UserID)
+		if r.URL.Query().Get("unread") == "true" {
+			tx = tx.Where("read_at IS NULL")
+		}
+		return tx
+	}
+
+	var total int64
+	var notifications []models.Notification
+	err := db.Model(&models.Notification{}).Scopes(scope).Count(&total).Error
+	if err == nil {
+		err = db.Scopes(scope).Order("created_at DESC, id DESC").
+			Limit(pageSize).Offset((page - 1) * pageSize).
+			Find(&notifications).Error
+	}
+	var publicIDs map[uint]string
+	if err == nil {
+		taskIDs := make([]uint, 0, len(notifications))
+		for _, notification := range notifications {
+			taskIDs = append(taskIDs, notification.TaskID)
+		}
+		publicIDs, err = taskPublicIDs(db, taskIDs)
+	}
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch notifications for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch notifications")
+		return
+	}
+
+	responses := make([]NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		responses = append(responses, newNotificationResponse(notification, publicIDs))
+	}
+
+	meta := newPaginationMeta(page, pageSize, total)
+	if wantsEnvelope(r) {
+		w.Header().Set("Content-Type", apierror.EnvelopeMediaType)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Envelope{
+			Data:  inUserTimezone(r, responses),
+			Meta:  &meta,
+			Links: newPaginationLinks(r, meta),
+		})
+		return
+	}
+	writeResponse(w, r, http.StatusOK, PaginatedNotificationResponse{Notifications: responses, PaginationMeta: meta})
+}
+
+// MarkNotificationRead handles POST /api/notifications/{id}/read - Mark one of the caller's notifications read
+// Marking it again keeps the time it was first read.
+func MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract notification ID from URL: /api/notifications/123/read
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/notifications/"), "/read")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidNotificationID, "Invalid notification ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Only unread ones are touched, so the first read time sticks
+	if err := db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", id, user.UserID).
+		Update("read_at", time.Now()).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to mark notification %d read: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to mark notification read")
+		return
+	}
+
+	var notification models.Notification
+	if err := db.Where("id = ? AND user_id = ?", id, user.UserID).First(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, apierror.NotificationNotFound, "Notification not found")
+			return
+		}
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch notification %d: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to mark notification read")
+		return
+	}
+
+	publicIDs, err := taskPublicIDs(db, []uint{notification.TaskID})
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch notification %d: %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to mark notification read")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, newNotificationResponse(notification, publicIDs))
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/utils"
+)
+
+// CreateMemberRequest represents the data needed to add a user to the caller's organization
+type CreateMemberRequest struct {
+	Email    string          `json:"email"`    // New user's email address
+	Password string          `json:"password"` // Initial password (will be hashed)
+	Role     models.UserRole `json:"role"`     // member or admin (defaults to member)
+}
+
+// CreateOrganizationMember handles POST /api/organization/members - Add a user to the organization
+// Only admins can add users. Registering without an organization always joins
+// the default one, so this is the only way into any other organization.
+func CreateOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	if !admin.IsAdmin() {
+		writeError(w, r, http.StatusForbidden, apierror.AdminRequired, "Only organization admins can add members") // 403
+		return
+	}
+
+	// Parse request body
+	var req CreateMemberRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if strings.TrimSpace(req.Email) == "" {
+		writeError(w, r, http.StatusBadRequest, apierror.EmailRequired, "Email is required")
+		return
+	}
+	if strings.TrimSpace(req.Password) == "" {
+		writeError(w, r, http.StatusBadRequest, apierror.PasswordRequired, "Password is required")
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = models.RoleMember
+	}
+	if !req.Role.IsValid() {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidRole, "Invalid role. Use: member, admin")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Emails are unique across all organizations
+	var existingUser models.User
+	err := db.Where("email = ?", req.Email).First(&existingUser).Error
+	if writeQueryTimeout(w, r, err) {
+		return
+	}
+	if err == nil {
+		writeError(w, r, http.StatusConflict, apierror.EmailTaken, "User with this email already exists") // 409 Conflict
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password, config.Get().PasswordHashAlgorithm)
+	if err != nil {
+		log.Printf("Failed to hash password: %v", err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to process password")
+		return
+	}
+
+	user := models.User{
+		Email:    req.Email,
+		Password: hashedPassword,
+		OrgID:    admin.OrgID,
+		Role:     req.Role,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to create member of organization %d: %v", admin.OrgID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to create user")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(inUserTimezone(r, user))
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// addMember adds a user to admin's organization through CreateOrganizationMember
+func (e *testEnv) addMember(admin middleware.UserContext, prefix string) middleware.UserContext {
+	e.t.Helper()
+
+	req := e.newRequest("POST", "/api/organization/members", CreateMemberRequest{
+		Email:    uniqueEmail(prefix),
+		Password: "testpassword123",
+	})
+	rr := e.serve(CreateOrganizationMember, asUser(req, admin))
+	if rr.Code != http.StatusCreated {
+		e.t.Fatalf("Failed to add member: status %d, body %s", rr.Code, rr.Body.String())
+	}
+
+	var user models.User
+	e.decode(rr, &user)
+	return middleware.UserContext{UserID: user.ID, Email: user.Email, OrgID: user.OrgID, Role: user.Role}
+}
+
+// TestRegisterOrganization tests joining the default organization or creating a new one
+func TestRegisterOrganization(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+
+	member := env.createUser("test-org-default")
+	if member.OrgID == 0 || member.IsAdmin() {
+		t.Errorf("Expected a member of the default organization, got org %d role %s", member.OrgID, member.Role)
+	}
+
+	admin := env.createOrgAdmin("test-org-founder")
+	if admin.OrgID == member.OrgID || !admin.IsAdmin() {
+		t.Errorf("Expected the admin of a new organization, got org %d role %s", admin.OrgID, admin.Role)
+	}
+
+	// Organization names are unique
+	var org models.Organization
+	env.tx.First(&org, admin.OrgID)
+	rr := env.serve(Register, env.newRequest("POST", "/api/auth/register", RegisterRequest{
+		Email:        uniqueEmail("test-org-squatter"),
+		Password:     "testpassword123",
+		Organization: org.Name,
+	}))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a taken organization name, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+// TestCreateOrganizationMember tests validation when admins add users
+func TestCreateOrganizationMember(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	admin := env.createOrgAdmin("test-members-admin")
+	taken := env.createUser("test-members-taken")
+
+	testCases := []struct {
+		name           string
+		user           middleware.UserContext
+		requestBody    interface{}
+		expectedStatus int
+	}{
+		{"non-admin", taken, CreateMemberRequest{Email: uniqueEmail("test-members-new"), Password: "secret"}, http.StatusForbidden},
+		{"missing email", admin, CreateMemberRequest{Password: "secret"}, http.StatusBadRequest},
+		{"missing password", admin, CreateMemberRequest{Email: uniqueEmail("test-members-new")}, http.StatusBadRequest},
+		{"invalid role", admin, CreateMemberRequest{Email: uniqueEmail("test-members-new"), Password: "secret", Role: "owner"}, http.StatusBadRequest},
+		{"email taken in another organization", admin, CreateMemberRequest{Email: taken.Email, Password: "secret"}, http.StatusConflict},
+		{"invalid JSON", admin, "not-json", http.StatusBadRequest},
+		{"valid admin", admin, CreateMemberRequest{Email: uniqueEmail("test-members-new"), Password: "secret", Role: models.RoleAdmin}, http.StatusCreated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := env.newRequest("POST", "/api/organization/members", tc.requestBody)
+			rr := env.serve(CreateOrganizationMember, asUser(req, tc.user))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	// New members land in the admin's organization
+	member := env.addMember(admin, "test-members-added")
+	if member.OrgID != admin.OrgID || member.IsAdmin() {
+		t.Errorf("Expected a member of organization %d, got org %d role %s", admin.OrgID, member.OrgID, member.Role)
+	}
+}
+
+// TestCrossOrganizationIsolation tests that nothing crosses organization boundaries
+func TestCrossOrganizationIsolation(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+
+	admin := env.createOrgAdmin("test-iso-admin")
+	member := env.addMember(admin, "test-iso-member")
+	colleague := env.addMember(admin, "test-iso-colleague")
+	outsideAdmin := env.createOrgAdmin("test-iso-outside-admin")
+	outsider := env.createUser("test-iso-outsider")
+
+	task := env.createTask(member, CreateTaskRequest{Title: "Org task"})
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+	update := map[string]string{"title": "Edited"}
+
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		method         string
+		path           string
+		body           interface{}
+		user           middleware.UserContext
+		expectedStatus int
+	}{
+		{"admin can view", GetTask, "GET", path, nil, admin, http.StatusOK},
+		{"admin cannot update", UpdateTask, "PUT", path, update, admin, http.StatusForbidden},
+		{"admin cannot delete", DeleteTask, "DELETE", path, nil, admin, http.StatusForbidden},
+		{"colleague cannot view", GetTask, "GET", path, nil, colleague, http.StatusNotFound},
+		{"outside admin cannot view", GetTask, "GET", path, nil, outsideAdmin, http.StatusNotFound},
+		{"outside admin cannot update", UpdateTask, "PUT", path, update, outsideAdmin, http.StatusNotFound},
+		{"outside admin cannot delete", DeleteTask, "DELETE", path, nil, outsideAdmin, http.StatusNotFound},
+		{"outsider cannot view", GetTask, "GET", path, nil, outsider, http.StatusNotFound},
+		{"outside admin cannot read history", GetTaskHistory, "GET", path + "/history", nil, outsideAdmin, http.StatusNotFound},
+		{"cannot share outside the organization", ShareTask, "POST", path + "/shares", ShareTaskRequest{UserID: outsider.UserID}, member, http.StatusBadRequest},
+		{"cannot transfer outside the organization", TransferTask, "POST", path + "/transfer", TransferTaskRequest{NewOwnerID: outsideAdmin.UserID}, member, http.StatusBadRequest},
+		{"can share inside the organization", ShareTask, "POST", path + "/shares", ShareTaskRequest{UserID: colleague.UserID}, member, http.StatusCreated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(tc.handler, asUser(env.newRequest(tc.method, tc.path, tc.body), tc.user))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	// Batch updates by an outsider skip the task instead of touching it
+	rr := env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status", BatchStatusRequest{
+		IDs:    taskRefs(task.ID),
+		Status: models.TaskStatusCompleted,
+	}), outsideAdmin))
+	var batch BatchStatusResponse
+	env.decode(rr, &batch)
+	if batch.Updated != 0 {
+		t.Errorf("Expected an outsider's batch update to change nothing, got %+v", batch)
+	}
+
+	// Admins list every task in their organization and nothing else
+	listed := func(user middleware.UserContext) bool {
+		rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks?page_size=100", nil), user))
+		var response PaginatedTaskResponse
+		env.decode(rr, &response)
+		for _, listedTask := range response.Tasks {
+			if listedTask.ID == task.ID {
+				return true
+			}
+		}
+		return false
+	}
+	if !listed(admin) {
+		t.Errorf("Expected the admin to list the member's task")
+	}
+	if listed(outsideAdmin) || listed(outsider) {
+		t.Errorf("Expected the task to stay hidden from other organizations")
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// ownedBy limits a task query to the tasks the user owns in their organization
+// Owner-only lookups (sharing, history, transfers, checklists, attachments,
+// batch updates, reordering) all go through it, so none can leave out the
+// user_id or org_id filter. Admins get no more than anyone else here; reads
+// that may show other users' tasks use findAccessibleTask or visibleTasks.
+func ownedBy(user middleware.UserContext) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("user_id = ? AND org_id = ?", user.UserID, user.OrgID)
+	}
+}
+
+// findOwnedTask loads a task the user owns in their organization
+// A task owned by anyone else is gorm.ErrRecordNotFound, like a missing one.
+func findOwnedTask(db *gorm.DB, taskID uint, user middleware.UserContext) (models.Task, error) {
+	var task models.Task
+	err := db.Scopes(ownedBy(user)).Where("id = ?", taskID).First(&task).Error
+	return task, err
+}
+
+// ownedTaskIDs returns which of ids are tasks the user owns, in the order
+// given and without repeats
+// IDs of other users' tasks, deleted tasks and tasks that don't exist are
+// left out alike, so the result can't be used to probe for other users' tasks.
+func ownedTaskIDs(db *gorm.DB, ids []uint, user middleware.UserContext) ([]uint, error) {
+	owned := make([]uint, 0, len(ids))
+	if len(ids) == 0 {
+		return owned, nil
+	}
+
+	var found []uint
+	if err := db.Model(&models.Task{}).Scopes(ownedBy(user)).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+	isOwned := make(map[uint]bool, len(found))
+	for _, id := range found {
+		isOwned[id] = true
+	}
+	for _, id := range ids {
+		if isOwned[id] {
+			owned = append(owned, id)
+			delete(isOwned, id) // Each ID once
+		}
+	}
+	return owned, nil
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// TestOwnedTaskIDs tests that only the caller's own tasks are kept, whoever else can see the rest
+func TestOwnedTaskIDs(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	admin := env.createOrgAdmin("test-owned-admin")
+	member := env.addMember(admin, "test-owned-member")
+	outsider := env.createUser("test-owned-outsider")
+
+	first := env.createTask(admin, CreateTaskRequest{Title: "First"}).ID
+	second := env.createTask(admin, CreateTaskRequest{Title: "Second"}).ID
+	members := env.createTask(member, CreateTaskRequest{Title: "Member's"}).ID
+	outsiders := env.createTask(outsider, CreateTaskRequest{Title: "Outsider's"}).ID
+	deleted := env.createTask(admin, CreateTaskRequest{Title: "Deleted"}).ID
+	env.tx.Delete(&models.Task{}, deleted)
+
+	// Admins can see every task in the organization, but own only theirs
+	ids := []uint{second, members, outsiders, deleted, first, 999999, second}
+	owned, err := ownedTaskIDs(env.tx, ids, admin)
+	if err != nil {
+		t.Fatalf("Failed to check ownership: %v", err)
+	}
+	if expected := []uint{second, first}; !slices.Equal(owned, expected) {
+		t.Errorf("Expected %v in request order, got %v", expected, owned)
+	}
+
+	if owned, err := ownedTaskIDs(env.tx, ids, member); err != nil || !slices.Equal(owned, []uint{members}) {
+		t.Errorf("Expected only the member's task, got %v (%v)", owned, err)
+	}
+	if owned, err := ownedTaskIDs(env.tx, nil, admin); err != nil || owned == nil || len(owned) != 0 {
+		t.Errorf("Expected an empty list for no IDs, got %v (%v)", owned, err)
+	}
+}
+
+// TestFindOwnedTask tests that other users' tasks look missing, even to admins
+func TestFindOwnedTask(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	admin := env.createOrgAdmin("test-find-owned-admin")
+	member := env.addMember(admin, "test-find-owned-member")
+	task := env.createTask(member, CreateTaskRequest{Title: "Member's"})
+
+	found, err := findOwnedTask(env.tx, task.ID, member)
+	if err != nil || found.ID != task.ID || found.Title != "Member's" {
+		t.Fatalf("Expected the owner to find the task, got %+v (%v)", found, err)
+	}
+	if _, err := findOwnedTask(env.tx, task.ID, admin); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for the admin, got %v", err)
+	}
+
+	// The organization counts too, not just the user ID
+	otherOrg := member
+	otherOrg.OrgID++
+	if _, err := findOwnedTask(env.tx, task.ID, otherOrg); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound in another organization, got %v", err)
+	}
+}
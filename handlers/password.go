@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/utils"
+	"gorm.io/gorm"
+)
+
+// ChangePasswordRequest represents the data needed to change the caller's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"` // Must match the stored password
+	NewPassword     string `json:"new_password"`     // Must not match a recent password
+}
+
+// dummyPasswordHash is compared against when there's less history than
+// PASSWORD_HISTORY_SIZE, so every reuse check costs the same number of hash runs
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := utils.HashPassword("password-history-padding", config.Get().PasswordHashAlgorithm)
+	if err != nil {
+		log.Printf("Failed to hash password history padding: %v", err)
+	}
+	return hash
+})
+
+// passwordReused reports whether password matches any of the given hashes
+// It always runs size comparisons (or one per hash, if there are more) and
+// never stops at the first match, so the response time doesn't reveal
+// whether a password matched, which one, or how much history exists.
+func passwordReused(password string, hashes []string, size int) bool {
+	reused := false
+	for i := 0; i < max(size, len(hashes)); i++ {
+		hash := dummyPasswordHash()
+		if i < len(hashes) {
+			hash = hashes[i]
+		}
+		// Always run the comparison; padding entries can never count as a match
+		matched := utils.CheckPassword(password, hash)
+		if matched && i < len(hashes) {
+			reused = true
+		}
+	}
+	return reused
+}
+
+// ChangePassword handles POST /api/auth/change-password - Change the caller's password
+// The new password can't be one of the user's last PASSWORD_HISTORY_SIZE
+// passwords (counting the current one)
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	authUser, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Parse request body
+	var req ChangePasswordRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.CurrentPassword == "" || strings.TrimSpace(req.NewPassword) == "" {
+		writeError(w, r, http.StatusBadRequest, apierror.PasswordRequired, "current_password and new_password are required")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	var user models.User
+	if err := db.First(&user, authUser.UserID).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		// The account was deleted after the token was issued
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found")
+		return
+	}
+
+	if !utils.CheckPassword(req.CurrentPassword, user.Password) {
+		writeError(w, r, http.StatusUnauthorized, apierror.WrongPassword, "Current password is incorrect")
+		return
+	}
+
+	// The current password plus the most recent history entries are off limits
+	historySize := config.Get().PasswordHistorySize
+	if historySize > 0 {
+		recent, err := recentPasswordHashes(db, user, historySize)
+		if err != nil {
+			if writeQueryTimeout(w, r, err) {
+				return
+			}
+			log.Printf("Failed to load password history for user %d: %v", user.ID, err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to change password")
+			return
+		}
+
+		if passwordReused(req.NewPassword, recent, historySize) {
+			writeError(w, r, http.StatusBadRequest, apierror.PasswordReused, "New password must not match one of your recent passwords")
+			return
+		}
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword, config.Get().PasswordHashAlgorithm)
+	if err != nil {
+		log.Printf("Failed to hash password: %v", err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to process password")
+		return
+	}
+
+	// Swap the password, remember the old one and prune what's no longer needed
+	// (Update writes the new hash back into user, so keep the old one first)
+	oldHash := user.Password
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		return recordPasswordHistory(tx, user.ID, oldHash, historySize)
+	})
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to change password for user %d: %v", user.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to change password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// recentPasswordHashes returns the user's current hash followed by the newest size-1 old ones
+func recentPasswordHashes(db *gorm.DB, user models.User, size int) ([]string, error) {
+	hashes := []string{user.Password}
+	if size <= 1 {
+		return hashes, nil
+	}
+
+	var previous []string
+	if err := db.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", user.ID).
+		Order("id DESC").
+		Limit(size-1).
+		Pluck("password_hash", &previous).Error; err != nil {
+		return nil, err
+	}
+	return append(hashes, previous...), nil
+}
+
+// recordPasswordHistory stores oldHash and keeps only the newest size-1 entries
+// The current password lives on the user, so size-1 entries complete the window
+func recordPasswordHistory(tx *gorm.DB, userID uint, oldHash string, size int) error {
+	keep := size - 1
+	if keep <= 0 {
+		// No history needed (e.g. PASSWORD_HISTORY_SIZE was lowered): drop what's left
+		return tx.Where("user_id = ?", userID).Delete(&models.PasswordHistory{}).Error
+	}
+
+	if err := tx.Create(&models.PasswordHistory{UserID: userID, PasswordHash: oldHash}).Error; err != nil {
+		return err
+	}
+
+	newest := tx.Model(&models.PasswordHistory{}).Select("id").Where("user_id = ?", userID).Order("id DESC").Limit(keep)
+	return tx.Where("user_id = ? AND id NOT IN (?)", userID, newest).Delete(&models.PasswordHistory{}).Error
+}
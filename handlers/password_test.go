@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/utils"
+)
+
+// TestPasswordReused tests the comparison against recent password hashes
+func TestPasswordReused(t *testing.T) {
+	t.Parallel()
+
+	var hashes []string
+	for _, password := range []string{"current-pass", "older-pass"} {
+		hash, err := utils.HashPassword(password, utils.PasswordHashBcrypt)
+		if err != nil {
+			t.Fatalf("Failed to hash password: %v", err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	testCases := []struct {
+		name     string
+		password string
+		size     int
+		expected bool
+	}{
+		{"reused current password", "current-pass", 2, true},
+		{"reused older password", "older-pass", 2, true},
+		{"fresh password", "brand-new-pass", 2, false},
+		{"fresh password with padding", "brand-new-pass", 5, false},
+		{"padding never matches", "password-history-padding", 5, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := passwordReused(tc.password, hashes, tc.size); got != tc.expected {
+				t.Errorf("Expected reused=%t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestChangePasswordHandler tests changing passwords with a history of 3
+// Not parallel: it changes PASSWORD_HISTORY_SIZE
+func TestChangePasswordHandler(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) { cfg.PasswordHistorySize = 3 })
+	env := newTestEnv(t)
+	user := env.createUser("test-change-password") // Registered with "testpassword123"
+
+	// Each step runs in order against the same user
+	steps := []struct {
+		name           string
+		requestBody    interface{}
+		expectedStatus int
+	}{
+		{"invalid JSON", "not-json", http.StatusBadRequest},
+		{"missing new password", ChangePasswordRequest{CurrentPassword: "testpassword123"}, http.StatusBadRequest},
+		{"wrong current password", ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "second-pass"}, http.StatusUnauthorized},
+		{"same as current", ChangePasswordRequest{CurrentPassword: "testpassword123", NewPassword: "testpassword123"}, http.StatusBadRequest},
+		{"fresh password", ChangePasswordRequest{CurrentPassword: "testpassword123", NewPassword: "second-pass"}, http.StatusNoContent},
+		{"back to the previous one", ChangePasswordRequest{CurrentPassword: "second-pass", NewPassword: "testpassword123"}, http.StatusBadRequest},
+		{"another fresh password", ChangePasswordRequest{CurrentPassword: "second-pass", NewPassword: "third-pass"}, http.StatusNoContent},
+		{"and another", ChangePasswordRequest{CurrentPassword: "third-pass", NewPassword: "fourth-pass"}, http.StatusNoContent},
+		{"first password left the window", ChangePasswordRequest{CurrentPassword: "fourth-pass", NewPassword: "testpassword123"}, http.StatusNoContent},
+	}
+
+	for _, step := range steps {
+		rr := env.serve(ChangePassword, asUser(env.newRequest("POST", "/api/auth/change-password", step.requestBody), user))
+		if rr.Code != step.expectedStatus {
+			t.Fatalf("%s: expected status %d, got %d: %s", step.name, step.expectedStatus, rr.Code, rr.Body.String())
+		}
+	}
+
+	// Only PASSWORD_HISTORY_SIZE-1 old hashes are kept
+	var count int64
+	env.tx.Model(&models.PasswordHistory{}).Where("user_id = ?", user.UserID).Count(&count)
+	if count != 2 {
+		t.Errorf("Expected 2 history entries after pruning, got %d", count)
+	}
+
+	// The final password is the one that works for login
+	rr := env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: user.Email, Password: "testpassword123"}))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected login with the new password to succeed, got %d", rr.Code)
+	}
+}
+
+// TestLoginRehashesPassword tests that logging in upgrades a bcrypt hash to Argon2id
+// Not parallel: it changes the global configuration
+func TestLoginRehashesPassword(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) { cfg.PasswordHashAlgorithm = utils.PasswordHashArgon2id })
+	env := newTestEnv(t)
+	user := env.createUser("test-rehash")
+
+	// Pretend the user registered back when passwords were hashed with bcrypt
+	legacy, err := utils.HashPassword("testpassword123", utils.PasswordHashBcrypt)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	env.tx.Model(&models.User{}).Where("id = ?", user.UserID).UpdateColumn("password", legacy)
+
+	login := func(password string) int {
+		return env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: user.Email, Password: password})).Code
+	}
+
+	// A wrong password leaves the hash alone
+	if code := login("wrongpassword"); code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, got %d", code)
+	}
+	var stored models.User
+	env.tx.First(&stored, user.UserID)
+	if stored.Password != legacy {
+		t.Errorf("Expected a failed login to keep the bcrypt hash")
+	}
+
+	if code := login("testpassword123"); code != http.StatusOK {
+		t.Fatalf("Expected login with the bcrypt hash to succeed, got %d", code)
+	}
+	env.tx.First(&stored, user.UserID)
+	if algorithm := utils.PasswordHashAlgorithm(stored.Password); algorithm != utils.PasswordHashArgon2id {
+		t.Errorf("Expected the hash to be upgraded to argon2id, got %q", algorithm)
+	}
+
+	// The upgraded hash works for the next login
+	if code := login("testpassword123"); code != http.StatusOK {
+		t.Errorf("Expected login with the upgraded hash to succeed, got %d", code)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// maxTaskProgress is the progress of a finished task, in percent
+const maxTaskProgress = 100
+
+// invalidProgressMessage is the INVALID_PROGRESS error message
+const invalidProgressMessage = "Progress must be a whole number from 0 to 100"
+
+// validateTaskProgress checks the progress of an update before anything is loaded
+// Progress can't be cleared, so null is rejected like a value over 100. It
+// writes a 400 response and returns false for an invalid progress.
+func validateTaskProgress(w http.ResponseWriter, r *http.Request, progress Optional[int]) bool {
+	if progress.Null() {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidProgress, "Progress cannot be null")
+		return false
+	}
+	if fieldErr := taskProgressError(progress.Value); fieldErr != nil {
+		writeFieldError(w, r, *fieldErr)
+		return false
+	}
+	return true
+}
+
+// taskProgressError returns the error for a progress outside 0-100, or nil
+// nil isn't being set and always passes.
+func taskProgressError(progress *int) *TaskFieldError {
+	if progress == nil || (*progress >= 0 && *progress <= maxTaskProgress) {
+		return nil
+	}
+	return &TaskFieldError{"progress", apierror.InvalidProgress, invalidProgressMessage}
+}
+
+// progressCompletesTask reports whether setting a task's progress to progress
+// also completes it (PROGRESS_COMPLETES_TASK)
+func progressCompletesTask(progress int) bool {
+	return progress == maxTaskProgress && config.Get().ProgressCompletesTask
+}
+
+// completeTaskProgress sets the progress of a completed task to 100
+// Every change that may complete a task calls it, so completed tasks are
+// always at 100 whatever progress the request asked for.
+func completeTaskProgress(task *models.Task) {
+	if task.Status == models.TaskStatusCompleted {
+		task.Progress = maxTaskProgress
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestTaskProgressError tests which progress values are accepted
+func TestTaskProgressError(t *testing.T) {
+	t.Parallel()
+
+	for _, progress := range []int{0, 1, 50, 100} {
+		if fieldErr := taskProgressError(&progress); fieldErr != nil {
+			t.Errorf("Expected %d to be accepted, got %+v", progress, fieldErr)
+		}
+	}
+	for _, progress := range []int{-1, 101, 255, 256} {
+		if fieldErr := taskProgressError(&progress); fieldErr == nil || fieldErr.Code != apierror.InvalidProgress {
+			t.Errorf("Expected %d to be rejected with %s, got %+v", progress, apierror.InvalidProgress, fieldErr)
+		}
+	}
+	if fieldErr := taskProgressError(nil); fieldErr != nil {
+		t.Errorf("Expected an unset progress to pass, got %+v", fieldErr)
+	}
+}
+
+// TestTaskProgress tests setting progress and how it goes along with the status
+// Not parallel: it sets PROGRESS_COMPLETES_TASK
+func TestTaskProgress(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.ProgressCompletesTask = false
+		cfg.TaskTransitions = nil
+	})
+	env := newTestEnv(t)
+	user := env.createUser("test-progress")
+
+	update := func(task TaskResponse, body string) (int, TaskResponse, apierror.Code) {
+		t.Helper()
+		rr := env.serve(UpdateTask, asUser(env.newRequest("PATCH", fmt.Sprintf("/api/tasks/%d", task.ID), body), user))
+		var response TaskResponse
+		var errResponse ErrorResponse
+		if rr.Code == http.StatusOK {
+			env.decode(rr, &response)
+		} else {
+			env.decode(rr, &errResponse)
+		}
+		return rr.Code, response, errResponse.Code
+	}
+
+	task := env.createTask(user, CreateTaskRequest{Title: "Halfway", Progress: 50})
+	if task.Progress != 50 || task.Status != models.TaskStatusPending {
+		t.Fatalf("Expected a pending task at 50%%, got %s at %d%%", task.Status, task.Progress)
+	}
+
+	// Out of range values are rejected on create and update
+	rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", `{"title":"Too far","progress":101}`), user))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for progress 101 on create, got %d", rr.Code)
+	}
+	for _, body := range []string{`{"progress":101}`, `{"progress":-1}`, `{"progress":null}`} {
+		if status, _, code := update(task, body); status != http.StatusBadRequest || code != apierror.InvalidProgress {
+			t.Errorf("Expected 400 %s for %s, got %d %s", apierror.InvalidProgress, body, status, code)
+		}
+	}
+
+	// Progress alone doesn't change the status by default
+	if status, updated, _ := update(task, `{"progress":100}`); status != http.StatusOK || updated.Progress != 100 || updated.Status != models.TaskStatusPending {
+		t.Fatalf("Expected a pending task at 100%%, got %d %s at %d%%", status, updated.Status, updated.Progress)
+	}
+
+	// Completing a task forces its progress to 100, whatever the request says
+	task = env.createTask(user, CreateTaskRequest{Title: "Done", Progress: 20})
+	if status, updated, _ := update(task, `{"status":"completed","progress":40}`); status != http.StatusOK || updated.Progress != 100 {
+		t.Fatalf("Expected a completed task at 100%%, got %d at %d%%", status, updated.Progress)
+	}
+	created := env.createTask(user, CreateTaskRequest{Title: "Born done", Status: models.TaskStatusCompleted, Progress: 10})
+	if created.Progress != 100 {
+		t.Errorf("Expected a task created completed to be at 100%%, got %d%%", created.Progress)
+	}
+
+	// Reopening keeps the progress, which can then be lowered
+	if status, updated, _ := update(task, `{"status":"pending"}`); status != http.StatusOK || updated.Progress != 100 {
+		t.Fatalf("Expected a reopened task to keep its progress, got %d at %d%%", status, updated.Progress)
+	}
+	if status, updated, _ := update(task, `{"progress":60}`); status != http.StatusOK || updated.Progress != 60 {
+		t.Fatalf("Expected progress 60, got %d at %d%%", status, updated.Progress)
+	}
+
+	// With PROGRESS_COMPLETES_TASK reaching 100% completes the task
+	withConfig(t, func(cfg *config.Config) {
+		cfg.ProgressCompletesTask = true
+	})
+	if status, updated, _ := update(task, `{"progress":100}`); status != http.StatusOK || updated.Status != models.TaskStatusCompleted {
+		t.Fatalf("Expected progress 100 to complete the task, got %d %s", status, updated.Status)
+	}
+	if created := env.createTask(user, CreateTaskRequest{Title: "Already done", Progress: 100}); created.Status != models.TaskStatusCompleted {
+		t.Errorf("Expected a task created at 100%% to be completed, got %s", created.Status)
+	}
+
+	// ... unless the request sets the status itself
+	other := env.createTask(user, CreateTaskRequest{Title: "Still reviewing"})
+	if status, updated, _ := update(other, `{"progress":100,"status":"in_progress"}`); status != http.StatusOK || updated.Status != models.TaskStatusInProgress {
+		t.Errorf("Expected the requested status to win, got %d %s", status, updated.Status)
+	}
+
+	// ... and the workflow must allow completing it
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskTransitions = []string{"pending>in_progress"}
+	})
+	blocked := env.createTask(user, CreateTaskRequest{Title: "Blocked"})
+	if status, _, code := update(blocked, `{"progress":100}`); status != http.StatusConflict || code != apierror.InvalidStatusTransition {
+		t.Errorf("Expected 409 %s, got %d %s", apierror.InvalidStatusTransition, status, code)
+	}
+}
+
+// TestBatchCompleteProgress tests that batch completing tasks sets their progress to 100
+func TestBatchCompleteProgress(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-batch-progress")
+	task := env.createTask(user, CreateTaskRequest{Title: "Batch", Progress: 30})
+
+	rr := env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status", BatchStatusRequest{
+		IDs: taskRefs(task.ID), Status: models.TaskStatusCompleted,
+	}), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to batch update: %d %s", rr.Code, rr.Body.String())
+	}
+
+	var stored models.Task
+	if err := env.tx.First(&stored, task.ID).Error; err != nil {
+		t.Fatalf("Failed to load task: %v", err)
+	}
+	if stored.Progress != 100 {
+		t.Errorf("Expected progress 100, got %d", stored.Progress)
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// PublicTaskResponse is a public task as anyone without a token sees it
+// It leaves out everything about the owner and the owner's integrations:
+// user_id, client_id, external_id and metadata.
+type PublicTaskResponse struct {
+	ID                any               `json:"id"` // The public ID with TASK_ID_FORMAT=uuid, the sequential ID otherwise
+	Title             string            `json:"title"`
+	Description       string            `json:"description"`
+	Status            models.TaskStatus `json:"status"`
+	DueDate           *Timestamp        `json:"due_date"`
+	Color             string            `json:"color"`
+	Progress          uint8             `json:"progress"`
+	CompletedAt       *Timestamp        `json:"completed_at"`
+	ChecklistProgress ChecklistProgress `json:"checklist_progress"`
+	CreatedAt         Timestamp         `json:"created_at"`
+	UpdatedAt         Timestamp         `json:"updated_at"`
+}
+
+// newPublicTaskResponse converts a public task to its unauthenticated representation
+func newPublicTaskResponse(task models.Task) PublicTaskResponse {
+	response := newTaskResponse(task)
+	return PublicTaskResponse{
+		ID:                taskResponseID(response),
+		Title:             response.Title,
+		Description:       response.Description,
+		Status:            response.Status,
+		DueDate:           response.DueDate,
+		Color:             response.Color,
+		Progress:          response.Progress,
+		CompletedAt:       response.CompletedAt,
+		ChecklistProgress: response.ChecklistProgress,
+		CreatedAt:         response.CreatedAt,
+		UpdatedAt:         response.UpdatedAt,
+	}
+}
+
+// validateTaskVisibility checks the visibility of an update before anything is loaded
+// Visibility can't be cleared, so null is rejected like an unknown value. It
+// writes a 400 response and returns false for an invalid visibility.
+func validateTaskVisibility(w http.ResponseWriter, r *http.Request, visibility Optional[models.TaskVisibility]) bool {
+	if visibility.Null() {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidVisibility, "Visibility cannot be null")
+		return false
+	}
+	if fieldErr := taskVisibilityError(visibility.Value); fieldErr != nil {
+		writeFieldError(w, r, *fieldErr)
+		return false
+	}
+	return true
+}
+
+// taskVisibilityError returns the error for a visibility other than private
+// or public, or nil. nil isn't being set and always passes; "" is taken as
+// private, the default.
+func taskVisibilityError(visibility *models.TaskVisibility) *TaskFieldError {
+	if visibility == nil {
+		return nil
+	}
+	switch *visibility {
+	case "":
+		*visibility = models.TaskVisibilityPrivate
+		return nil
+	case models.TaskVisibilityPrivate, models.TaskVisibilityPublic:
+		return nil
+	}
+	return &TaskFieldError{"visibility", apierror.InvalidVisibility, "Invalid visibility. Use: private, public"}
+}
+
+// GetPublicTask handles GET /api/public/tasks/{id} - Read a public task without a token
+// It's the only task route outside AuthMiddleware: there is no user, so the
+// task is looked up by ID alone, in any organization, and only public tasks
+// are found. Private, deleted and missing tasks all get the same 404. The
+// {id} follows TASK_ID_FORMAT, so with uuid the sequential IDs can't be
+// used to walk through the public tasks either.
+func GetPublicTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/public/tasks/")
+	if path == "" {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Task ID is required")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	query := db.Where("visibility = ?", models.TaskVisibilityPublic)
+	if usePublicTaskIDs() {
+		// Public IDs are UUIDs, like client IDs
+		publicID, ok := normalizeClientID(path)
+		if !ok {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+			return
+		}
+		query = query.Where("public_id = ?", publicID)
+	} else {
+		taskID, err := strconv.ParseUint(path, 10, 32)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+			return
+		}
+		query = query.Where("id = ?", taskID)
+	}
+
+	var task models.Task
+	if err := query.First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+			return
+		}
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch public task %s: %v", path, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch task")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, newPublicTaskResponse(task))
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestGetPublicTask tests reading tasks without a token
+func TestGetPublicTask(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-public-task")
+
+	private := env.createTask(user, CreateTaskRequest{Title: "Private plans"})
+	if private.Visibility != models.TaskVisibilityPrivate {
+		t.Fatalf("Expected new tasks to be private, got %q", private.Visibility)
+	}
+	public := env.createTask(user, CreateTaskRequest{
+		Title:      "Roadmap",
+		Visibility: models.TaskVisibilityPublic,
+		Metadata:   json.RawMessage(`{"jira":"OPS-12"}`),
+	})
+
+	// No user in the request, as behind the public route
+	get := func(id uint) (int, map[string]any) {
+		t.Helper()
+		rr := env.serve(GetPublicTask, env.newRequest("GET", fmt.Sprintf("/api/public/tasks/%d", id), nil))
+		var body map[string]any
+		env.decode(rr, &body)
+		return rr.Code, body
+	}
+
+	code, body := get(public.ID)
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200 for a public task, got %d: %v", code, body)
+	}
+	if body["title"] != "Roadmap" {
+		t.Errorf("Expected the task's title, got %v", body["title"])
+	}
+	for _, field := range []string{"user_id", "email", "user", "org_id", "metadata", "client_id", "external_id"} {
+		if _, ok := body[field]; ok {
+			t.Errorf("Expected no %s in the public payload, got %v", field, body[field])
+		}
+	}
+
+	// Private tasks look the same as missing ones
+	code, body = get(private.ID)
+	if code != http.StatusNotFound || body["code"] != string(apierror.TaskNotFound) {
+		t.Errorf("Expected 404 TASK_NOT_FOUND for a private task, got %d: %v", code, body)
+	}
+
+	// Making the task private again takes it offline
+	rr := env.serve(UpdateTask, asUser(env.newRequest("PATCH", fmt.Sprintf("/api/tasks/%d", public.ID), `{"visibility":"private"}`), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to make the task private: %d %s", rr.Code, rr.Body.String())
+	}
+	if code, _ := get(public.ID); code != http.StatusNotFound {
+		t.Errorf("Expected 404 once the task is private, got %d", code)
+	}
+
+	rr = env.serve(GetPublicTask, env.newRequest("GET", "/api/public/tasks/abc", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid ID, got %d", rr.Code)
+	}
+}
+
+// TestTaskVisibility tests setting and validating a task's visibility
+func TestTaskVisibility(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	admin := env.createOrgAdmin("test-visibility-admin")
+	owner := env.addMember(admin, "test-visibility-owner")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Launch"})
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", `{"title":"Leak","visibility":"everyone"}`), owner))
+	var errResp ErrorResponse
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusBadRequest || errResp.Code != apierror.InvalidVisibility {
+		t.Errorf("Expected 400 INVALID_VISIBILITY on create, got %d %s", rr.Code, errResp.Code)
+	}
+
+	rr = env.serve(UpdateTask, asUser(env.newRequest("PATCH", path, `{"visibility":null}`), owner))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a null visibility, got %d", rr.Code)
+	}
+
+	// Only the owner can publish the task, even with a write share
+	rr = env.serve(ShareTask, asUser(env.newRequest("POST", path+"/shares", ShareTaskRequest{UserID: admin.UserID, Permission: models.SharePermissionWrite}), owner))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to share the task: %d %s", rr.Code, rr.Body.String())
+	}
+	rr = env.serve(UpdateTask, asUser(env.newRequest("PATCH", path, `{"visibility":"public"}`), admin))
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusForbidden || errResp.Code != apierror.NotTaskOwner {
+		t.Errorf("Expected 403 NOT_TASK_OWNER for a non-owner, got %d %s", rr.Code, errResp.Code)
+	}
+	// Other changes by the share are still allowed
+	rr = env.serve(UpdateTask, asUser(env.newRequest("PATCH", path, `{"title":"Launch v2"}`), admin))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected a write share to rename the task, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = env.serve(UpdateTask, asUser(env.newRequest("PATCH", path, `{"visibility":"public"}`), owner))
+	var updated TaskResponse
+	env.decode(rr, &updated)
+	if rr.Code != http.StatusOK || updated.Visibility != models.TaskVisibilityPublic {
+		t.Errorf("Expected the owner to make the task public, got %d %q", rr.Code, updated.Visibility)
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// queryCountingOnce registers the counting callbacks on the shared test database once
+var queryCountingOnce sync.Once
+
+// TestCountQueries tests that concurrent requests each count only their own queries
+func TestCountQueries(t *testing.T) {
+	t.Parallel()
+	if err := connectTestDB(); err != nil {
+		t.Skipf("Test database unavailable: %v", err)
+	}
+	queryCountingOnce.Do(func() {
+		if err := database.EnableQueryCounting(database.GetDB()); err != nil {
+			t.Fatalf("Failed to enable query counting: %v", err)
+		}
+	})
+
+	// Runs ?n= queries
+	handler := middleware.CountQueries(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		db, cancel := requestDB(r)
+		defer cancel()
+		for i := 0; i < n; i++ {
+			var count int64
+			db.Model(&models.Task{}).Count(&count)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for n := 0; n < 8; n++ {
+		t.Run(fmt.Sprintf("%d queries", n), func(t *testing.T) {
+			t.Parallel()
+			env := newTestEnv(t)
+			rr := env.serve(handler, env.newRequest("GET", fmt.Sprintf("/?n=%d", n), nil))
+			if got := rr.Header().Get(middleware.QueryCountHeader); got != strconv.Itoa(n) {
+				t.Errorf("Expected %s: %d, got %q", middleware.QueryCountHeader, n, got)
+			}
+		})
+	}
+
+	t.Run("real handler", func(t *testing.T) {
+		t.Parallel()
+		env := newTestEnv(t)
+		user := env.createUser("test-query-count")
+		env.createTask(user, CreateTaskRequest{Title: "Counted"})
+		rr := env.serve(middleware.CountQueries(GetTasks), asUser(env.newRequest("GET", "/api/tasks", nil), user))
+		count, err := strconv.Atoi(rr.Header().Get(middleware.QueryCountHeader))
+		if rr.Code != http.StatusOK || err != nil || count == 0 {
+			t.Errorf("Expected a positive query count, got %d %q", rr.Code, rr.Header().Get(middleware.QueryCountHeader))
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/mail"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/reminders"
+)
+
+// TestTaskDueDate tests setting, changing and clearing a task's due date
+func TestTaskDueDate(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-due-date")
+
+	due := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	created := env.createTask(user, CreateTaskRequest{Title: "With due date", DueDate: &due})
+	if created.DueDate == nil || created.DueDate.String() != due.Format("2006-01-02T15:04:05Z07:00") {
+		t.Fatalf("Expected due_date %s, got %v", due.Format("2006-01-02T15:04:05Z07:00"), created.DueDate)
+	}
+
+	// Pretend a reminder already went out for the old due date
+	env.tx.Model(&models.Task{}).Where("id = ?", created.ID).UpdateColumn("reminder_sent", true)
+
+	path := fmt.Sprintf("/api/tasks/%d", created.ID)
+	createdDueDate := created.DueDate.String()
+	newDueDate := "2030-01-02T03:04:05Z"
+	testCases := []struct {
+		name            string
+		requestBody     string
+		expectedDueDate *string
+		expectReminder  bool // Whether reminder_sent is still set afterwards
+	}{
+		{"other fields keep the due date", `{"title":"Renamed"}`, &createdDueDate, true},
+		{"new due date re-arms the reminder", `{"due_date":"2030-01-02T03:04:05Z"}`, &newDueDate, false},
+		{"null clears the due date", `{"due_date":null}`, nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(UpdateTask, asUser(env.newRequest("PUT", path, tc.requestBody), user))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var response TaskResponse
+			env.decode(rr, &response)
+			switch {
+			case tc.expectedDueDate == nil && response.DueDate != nil:
+				t.Errorf("Expected no due_date, got %s", *response.DueDate)
+			case tc.expectedDueDate != nil && (response.DueDate == nil || response.DueDate.String() != *tc.expectedDueDate):
+				t.Errorf("Expected due_date %s, got %v", *tc.expectedDueDate, response.DueDate)
+			}
+
+			var task models.Task
+			env.tx.First(&task, created.ID)
+			if task.ReminderSent != tc.expectReminder {
+				t.Errorf("Expected reminder_sent=%t, got %t", tc.expectReminder, task.ReminderSent)
+			}
+		})
+	}
+}
+
+// TestClaimDueTasks tests which tasks the reminder scheduler picks up
+func TestClaimDueTasks(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-reminders")
+
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		due := now.Add(d)
+		return &due
+	}
+	soon := env.createTask(user, CreateTaskRequest{Title: "Due soon", DueDate: at(time.Hour)})
+	later := env.createTask(user, CreateTaskRequest{Title: "Due later", DueDate: at(72 * time.Hour)})
+	overdue := env.createTask(user, CreateTaskRequest{Title: "Already overdue", DueDate: at(-time.Hour)})
+	undated := env.createTask(user, CreateTaskRequest{Title: "No due date"})
+
+	tasks, err := reminders.ClaimDueTasks(env.tx, now, 24*time.Hour, 100)
+	if err != nil {
+		t.Fatalf("Failed to claim due tasks: %v", err)
+	}
+
+	claimed := map[uint]bool{}
+	for _, task := range tasks {
+		claimed[task.ID] = true
+	}
+	if !claimed[soon.ID] {
+		t.Errorf("Expected task due within the window to be claimed")
+	}
+	for _, skipped := range []TaskResponse{later, overdue, undated} {
+		if claimed[skipped.ID] {
+			t.Errorf("Task %q should not be claimed", skipped.Title)
+		}
+	}
+
+	// A claimed task is only reminded once
+	again, err := reminders.ClaimDueTasks(env.tx, now, 24*time.Hour, 100)
+	if err != nil {
+		t.Fatalf("Failed to claim due tasks: %v", err)
+	}
+	for _, task := range again {
+		if task.ID == soon.ID {
+			t.Errorf("Expected task %d to be claimed only once", soon.ID)
+		}
+	}
+}
+
+// recordingMailer hands every message it's asked to send to a channel
+type recordingMailer chan mail.Message
+
+func (m recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	m <- msg
+	return nil
+}
+
+// TestReminderEmails tests that the scheduler emails each reminder to the task's owner
+func TestReminderEmails(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-reminder-email")
+
+	due := time.Now().Add(time.Hour)
+	env.createTask(user, CreateTaskRequest{Title: "Send the invoice", DueDate: &due})
+
+	mailer := make(recordingMailer, 10)
+	scheduler := &reminders.Scheduler{
+		DB:     env.tx,
+		Window: 24 * time.Hour,
+		Notify: func(task models.Task) {},
+		Mailer: mailer,
+	}
+	if _, err := scheduler.RunOnce(context.Background()); err != nil {
+		t.Fatalf("Reminder run failed: %v", err)
+	}
+
+	select {
+	case msg := <-mailer:
+		if len(msg.To) != 1 || msg.To[0] != user.Email {
+			t.Errorf("Expected the reminder to go to %s, got %v", user.Email, msg.To)
+		}
+		if !strings.Contains(msg.Subject, "Send the invoice") {
+			t.Errorf("Expected the task title in the subject, got %q", msg.Subject)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a reminder email")
+	}
+}
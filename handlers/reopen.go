@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// ReopenTask handles POST /api/tasks/{id}/reopen - Move a completed task back to TASK_REOPEN_STATUS
+// It's the explicit form of setting the status with a PUT, for automation
+// and reports: the same users may do it (the owner and write shares), the
+// workflow's transitions still apply, and the change is recorded in the
+// status history. Tasks that aren't completed get 409 (TASK_NOT_COMPLETED).
+func ReopenTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/reopen
+	taskID, err := taskIDFromPath(r.URL.Path, "/reopen")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	workflow, err := taskWorkflow()
+	if err != nil {
+		log.Printf("Invalid task workflow configuration: %v", err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to reopen task")
+		return
+	}
+	target := workflow.DefaultStatus
+	if status := config.Get().TaskReopenStatus; status != "" {
+		target = models.TaskStatus(status)
+	}
+
+	// Like any status change, it waits for (or is refused by) other updates of the task
+	unlock, ok := lockTaskForUpdate(w, r, taskID)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	// The owner and users with a write share may reopen it
+	db, cancel := requestDB(r)
+	defer cancel()
+	task, err := findAccessibleTask(db, taskID, user, true)
+	if err != nil {
+		writeTaskAccessError(w, r, err)
+		return
+	}
+
+	if task.Status != models.TaskStatusCompleted {
+		writeError(w, r, http.StatusConflict, apierror.TaskNotCompleted, "Only completed tasks can be reopened; this one is "+string(task.Status)) // 409 Conflict
+		return
+	}
+
+	// Saved, recorded in the history and announced like a status PUT
+	applyTaskUpdate(w, r, db, user, task, UpdateTaskRequest{
+		Status: Optional[models.TaskStatus]{Set: true, Value: &target},
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestReopenTask tests reopening completed tasks, recording it and who may do it
+func TestReopenTask(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-reopen-owner")
+	reader := env.createUser("test-reopen-reader")
+	stranger := env.createUser("test-reopen-stranger")
+
+	task := env.createTask(owner, CreateTaskRequest{Title: "Done too soon", Status: models.TaskStatusCompleted})
+	path := fmt.Sprintf("/api/tasks/%d/reopen", task.ID)
+	env.serve(ShareTask, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/shares", task.ID), ShareTaskRequest{UserID: reader.UserID}), owner))
+
+	testCases := []struct {
+		name           string
+		caller         middleware.UserContext
+		path           string
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"read-only share", reader, path, http.StatusForbidden, apierror.TaskReadOnly},
+		{"someone else's task", stranger, path, http.StatusNotFound, apierror.TaskNotFound},
+		{"invalid ID", owner, "/api/tasks/abc/reopen", http.StatusBadRequest, apierror.InvalidTaskID},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(ReopenTask, asUser(env.newRequest("POST", tc.path, nil), tc.caller))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			var errResp ErrorResponse
+			env.decode(rr, &errResp)
+			if errResp.Code != tc.expectedCode {
+				t.Errorf("Expected code %s, got %s", tc.expectedCode, errResp.Code)
+			}
+		})
+	}
+
+	rr := env.serve(ReopenTask, asUser(env.newRequest("POST", path, nil), owner))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var reopened TaskResponse
+	env.decode(rr, &reopened)
+	if reopened.ID != task.ID || reopened.Status != models.TaskStatusPending {
+		t.Errorf("Expected the task back in pending, got %+v", reopened)
+	}
+
+	rr = env.serve(GetTaskHistory, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks/%d/history", task.ID), nil), owner))
+	var history TaskHistoryResponse
+	env.decode(rr, &history)
+	if len(history.History) != 1 {
+		t.Fatalf("Expected one history entry, got %+v", history.History)
+	}
+	if entry := history.History[0]; entry.FromStatus != models.TaskStatusCompleted || entry.ToStatus != models.TaskStatusPending || entry.UserID != owner.UserID {
+		t.Errorf("Expected completed -> pending by the owner, got %+v", entry)
+	}
+
+	// Only completed tasks can be reopened
+	rr = env.serve(ReopenTask, asUser(env.newRequest("POST", path, nil), owner))
+	var errResp ErrorResponse
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusConflict || errResp.Code != apierror.TaskNotCompleted {
+		t.Errorf("Expected 409 %s, got %d %s", apierror.TaskNotCompleted, rr.Code, errResp.Code)
+	}
+}
+
+// TestReopenTaskConfig tests TASK_REOPEN_STATUS and that the workflow's transitions still apply
+func TestReopenTaskConfig(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser("test-reopen-config")
+
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskReopenStatus = "in_progress"
+		cfg.TaskTransitions = []string{"pending>completed", "completed>in_progress"}
+	})
+	task := env.createTask(user, CreateTaskRequest{Title: "Needs more work", Status: models.TaskStatusCompleted})
+	rr := env.serve(ReopenTask, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/reopen", task.ID), nil), user))
+	var reopened TaskResponse
+	env.decode(rr, &reopened)
+	if rr.Code != http.StatusOK || reopened.Status != models.TaskStatusInProgress {
+		t.Errorf("Expected the task in in_progress, got %d %+v", rr.Code, reopened)
+	}
+
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskReopenStatus = "pending"
+	})
+	task = env.createTask(user, CreateTaskRequest{Title: "Stays done", Status: models.TaskStatusCompleted})
+	rr = env.serve(ReopenTask, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/reopen", task.ID), nil), user))
+	var errResp ErrorResponse
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusConflict || errResp.Code != apierror.InvalidStatusTransition {
+		t.Errorf("Expected 409 %s when the workflow forbids it, got %d %s", apierror.InvalidStatusTransition, rr.Code, errResp.Code)
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReorderTasksRequest represents a new manual order for some of the caller's tasks
+type ReorderTasksRequest struct {
+	IDs []TaskRef `json:"ids"` // Task IDs in their new order (required); public IDs with TASK_ID_FORMAT=uuid
+}
+
+// tasksNotOwnedError means some reordered IDs aren't the caller's tasks
+// missingTaskIDs holds them so the response can name them
+type tasksNotOwnedError struct {
+	missingTaskIDs []TaskRef
+}
+
+func (e *tasksNotOwnedError) Error() string {
+	return fmt.Sprintf("tasks %v not found", e.missingTaskIDs)
+}
+
+// ReorderTasks handles POST /api/tasks/reorder - Save a new manual order for tasks
+// The listed tasks swap the positions they already hold among themselves, so
+// tasks that aren't listed never move and nothing else has to be renumbered.
+// Positions are floats, which leaves room between any two of them.
+func ReorderTasks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Parse request body
+	var req ReorderTasksRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		writeError(w, r, http.StatusBadRequest, apierror.BatchIDsRequired, "ids is required")
+		return
+	}
+
+	if len(req.IDs) > maxBatchSize {
+		writeError(w, r, http.StatusBadRequest, apierror.BatchTooLarge, fmt.Sprintf("Too many ids (maximum is %d)", maxBatchSize))
+		return
+	}
+
+	// A task can only have one place in the order
+	seen := make(map[TaskRef]bool, len(req.IDs))
+	for _, ref := range req.IDs {
+		if seen[ref] {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, fmt.Sprintf("Task %s is listed more than once", ref))
+			return
+		}
+		seen[ref] = true
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Public IDs are looked up first, so the rest works with sequential IDs
+	if err := resolveTaskRefs(db, req.IDs, user); err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to resolve task IDs for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to reorder tasks")
+		return
+	}
+	ids := make([]uint, 0, len(req.IDs))
+	for _, ref := range req.IDs {
+		ids = append(ids, ref.ID)
+	}
+
+	var reordered []models.Task // The tasks in their new order
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Only the caller's own tasks can be reordered, even for admins: the
+		// order is personal. Locking the rows keeps two concurrent reorders
+		// from handing out the same positions.
+		owned, err := ownedTaskIDs(tx.Clauses(clause.Locking{Strength: "UPDATE"}), ids, user)
+		if err != nil {
+			return err
+		}
+		var tasks []models.Task
+		if err := tx.Where("id IN ?", owned).Find(&tasks).Error; err != nil {
+			return err
+		}
+
+		byID := make(map[uint]models.Task, len(tasks))
+		slots := make([]float64, 0, len(tasks))
+		for _, task := range tasks {
+			byID[task.ID] = task
+			slots = append(slots, task.Position)
+		}
+
+		var missing []TaskRef
+		for _, ref := range req.IDs {
+			if _, found := byID[ref.ID]; !found {
+				missing = append(missing, ref)
+			}
+		}
+		if len(missing) > 0 {
+			return &tasksNotOwnedError{missingTaskIDs: missing}
+		}
+
+		// Hand the occupied positions out again in the requested order
+		slots = reorderSlots(slots)
+		for i, id := range ids {
+			task := byID[id]
+			if task.Position != slots[i] {
+				// UpdateColumn leaves updated_at alone: the task itself didn't change
+				if err := tx.Model(&task).UpdateColumn("position", slots[i]).Error; err != nil {
+					return err
+				}
+				task.Position = slots[i]
+			}
+			reordered = append(reordered, task)
+		}
+		return nil
+	})
+
+	var notOwned *tasksNotOwnedError
+	if errors.As(err, &notOwned) {
+		// Tasks of other users look the same as tasks that don't exist
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, fmt.Sprintf("Tasks not found: %v", notOwned.missingTaskIDs))
+		return
+	}
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to reorder tasks for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to reorder tasks")
+		return
+	}
+
+	// Reordered tasks must be read fresh by GetTask
+	response := make([]TaskResponse, 0, len(reordered))
+	for _, task := range reordered {
+		forgetCachedTaskForAll(db, task)
+		response = append(response, newTaskResponse(task))
+	}
+
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// reorderSlots sorts the positions a set of tasks holds so they can be handed out in a new order
+// Tasks can share a position (e.g. after a transfer from another user); such
+// duplicates are nudged up to the next representable float so every task gets
+// its own place without passing any task that isn't being reordered.
+func reorderSlots(positions []float64) []float64 {
+	slots := slices.Clone(positions)
+	slices.Sort(slots)
+	for i := 1; i < len(slots); i++ {
+		if slots[i] <= slots[i-1] {
+			slots[i] = math.Nextafter(slots[i-1], math.Inf(1))
+		}
+	}
+	return slots
+}
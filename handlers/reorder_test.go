@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// TestReorderTasks tests saving a manual order and listing tasks in it
+func TestReorderTasks(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-reorder")
+	other := env.createUser("test-reorder-other")
+
+	a := env.createTask(user, CreateTaskRequest{Title: "A"})
+	b := env.createTask(user, CreateTaskRequest{Title: "B"})
+	c := env.createTask(user, CreateTaskRequest{Title: "C"})
+	d := env.createTask(user, CreateTaskRequest{Title: "D"})
+	foreign := env.createTask(other, CreateTaskRequest{Title: "Not yours"})
+
+	// New tasks are appended to the order
+	if !(a.Position < b.Position && b.Position < c.Position && c.Position < d.Position) {
+		t.Fatalf("Expected increasing positions, got %v %v %v %v", a.Position, b.Position, c.Position, d.Position)
+	}
+
+	listTitles := func() []string {
+		t.Helper()
+		rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks?sort=position", nil), user))
+		var resp PaginatedTaskResponse
+		env.decode(rr, &resp)
+		titles := make([]string, 0, len(resp.Tasks))
+		for _, task := range resp.Tasks {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+
+	// Moving D before B only reshuffles B, C and D; A keeps its place
+	rr := env.serve(ReorderTasks, asUser(env.newRequest("POST", "/api/tasks/reorder", ReorderTasksRequest{IDs: taskRefs(d.ID, b.ID, c.ID)}), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var reordered []TaskResponse
+	env.decode(rr, &reordered)
+	if len(reordered) != 3 || reordered[0].ID != d.ID || reordered[0].Position != b.Position {
+		t.Errorf("Expected D to take B's position, got %+v", reordered)
+	}
+	if titles := listTitles(); !reflect.DeepEqual(titles, []string{"A", "D", "B", "C"}) {
+		t.Errorf("Expected order A D B C, got %v", titles)
+	}
+
+	// Invalid requests change nothing
+	tests := []struct {
+		name           string
+		ids            []TaskRef
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"no ids", nil, http.StatusBadRequest, apierror.BatchIDsRequired},
+		{"duplicate id", taskRefs(a.ID, b.ID, a.ID), http.StatusBadRequest, apierror.InvalidTaskID},
+		{"another user's task", taskRefs(c.ID, foreign.ID), http.StatusNotFound, apierror.TaskNotFound},
+		{"missing task", taskRefs(c.ID, 999999), http.StatusNotFound, apierror.TaskNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := env.serve(ReorderTasks, asUser(env.newRequest("POST", "/api/tasks/reorder", ReorderTasksRequest{IDs: tt.ids}), user))
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			var resp ErrorResponse
+			env.decode(rr, &resp)
+			if resp.Code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s", tt.expectedCode, resp.Code)
+			}
+		})
+	}
+	if titles := listTitles(); !reflect.DeepEqual(titles, []string{"A", "D", "B", "C"}) {
+		t.Errorf("Expected order A D B C after rejected requests, got %v", titles)
+	}
+
+	// Unknown sort columns are rejected
+	rr = env.serve(GetTasks, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks?sort=%s", "user_id"), nil), user))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for sort=user_id, got %d", rr.Code)
+	}
+}
+
+// TestReorderSlots tests that shared positions are split without reordering anything else
+func TestReorderSlots(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		positions []float64
+		expected  []float64
+	}{
+		{"already distinct", []float64{3, 1, 2}, []float64{1, 2, 3}},
+		{"shared position", []float64{2, 1, 2}, []float64{1, 2, math.Nextafter(2, 3)}},
+		{"all the same", []float64{0, 0, 0}, []float64{0, math.Nextafter(0, 1), math.Nextafter(math.Nextafter(0, 1), 1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if slots := reorderSlots(tt.positions); !reflect.DeepEqual(slots, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, slots)
+			}
+		})
+	}
+}
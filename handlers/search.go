@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// taskSortColumns maps the sort values GetTasks and SearchTasks accept to their columns
+// Only these can be sorted on, so a client can never inject SQL through sort
+var taskSortColumns = map[string]string{
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+	"due_date":     "due_date",
+	"title":        "title",
+	"status":       "status",
+	"position":     "position",
+	"progress":     "progress",
+	"completed_at": "completed_at",
+}
+
+// invalidSortMessage is the INVALID_SORT error message, naming the sortable columns
+const invalidSortMessage = "Invalid sort. Use one of created_at, updated_at, due_date, title, status, position, progress, completed_at, optionally prefixed with -"
+
+// defaultTaskSort is the order used when neither the listing nor DEFAULT_TASK_SORT sets one: newest first
+const defaultTaskSort = "-created_at"
+
+// TaskSearchRequest represents the filters for POST /api/tasks/search
+// Every filter is optional and they all apply together (AND)
+type TaskSearchRequest struct {
+	Statuses       []models.TaskStatus `json:"statuses"`        // Tasks with any of these statuses
+	TitleContains  string              `json:"title_contains"`  // Case-insensitive substring of the title
+	CreatedBetween *TimeRange          `json:"created_between"` // Created within this range
+	Shared         bool                `json:"shared"`          // Also search tasks shared with the caller, like ?shared=true
+	Sort           string              `json:"sort"`            // Column to sort by; a leading "-" sorts descending
+	Page           int                 `json:"page"`            // 1-based page number (default 1)
+	PageSize       int                 `json:"page_size"`       // Tasks per page (default DEFAULT_PAGE_SIZE, clamped to MAX_PAGE_SIZE)
+}
+
+// TimeRange is an inclusive range of times; either end may be left open
+type TimeRange struct {
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+}
+
+// SearchTasks handles POST /api/tasks/search - List tasks matching combined filters
+// It's the structured counterpart of GET /api/tasks for filters that don't fit
+// in a query string, and returns the same PaginatedTaskResponse.
+func SearchTasks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	var req TaskSearchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// Pagination works like GetTasks: 0 (or missing) means the default
+	cfg := config.Get()
+	if req.Page < 0 || req.PageSize < 0 {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidPagination, "page and page_size must be positive integers")
+		return
+	}
+	page := max(req.Page, 1)
+	pageSize := cfg.DefaultPageSize
+	if req.PageSize > 0 {
+		pageSize = min(req.PageSize, cfg.MaxPageSize)
+	}
+
+	if len(req.Statuses) > 0 {
+		workflow, err := taskWorkflow()
+		if err != nil {
+			log.Printf("Invalid task workflow configuration: %v", err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to search tasks")
+			return
+		}
+		for _, status := range req.Statuses {
+			if !workflow.IsValid(status) {
+				writeError(w, r, http.StatusBadRequest, apierror.InvalidStatus, "Invalid status in statuses. Use: "+workflow.StatusList())
+				return
+			}
+		}
+	}
+
+	if req.CreatedBetween != nil && req.CreatedBetween.From != nil && req.CreatedBetween.To != nil &&
+		req.CreatedBetween.From.After(*req.CreatedBetween.To) {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidDateRange, "created_between.from must not be after created_between.to")
+		return
+	}
+
+	order, ok := taskSortOrder(req.Sort)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidSort, invalidSortMessage)
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// The same scope is used for the count and the page, so total always
+	// matches the filtered results
+	scope := func(tx *gorm.DB) *gorm.DB {
+		tx = visibleTasks(db, user, req.Shared)(tx)
+		if len(req.Statuses) > 0 {
+			tx = tx.Where("status IN ?", req.Statuses)
+		}
+		if title := strings.TrimSpace(req.TitleContains); title != "" {
+			tx = tx.Where(`LOWER(title) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(title))+"%")
+		}
+		if req.CreatedBetween != nil {
+			if req.CreatedBetween.From != nil {
+				tx = tx.Where("created_at >= ?", *req.CreatedBetween.From)
+			}
+			if req.CreatedBetween.To != nil {
+				tx = tx.Where("created_at <= ?", *req.CreatedBetween.To)
+			}
+		}
+		return tx
+	}
+
+	var total int64
+	if err := db.Model(&models.Task{}).Scopes(scope).Count(&total).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to count search results for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to search tasks")
+		return
+	}
+
+	var tasks []models.Task
+	if err := db.Scopes(scope).
+		Order(order).
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&tasks).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to search tasks for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to search tasks")
+		return
+	}
+
+	// Pages are requested in the body, so there are no links to follow
+	writeTaskPage(w, r, tasks, nil, newPaginationMeta(page, pageSize, total), nil)
+}
+
+// taskSortOrder turns a sort value like "-due_date" into an ORDER BY clause
+// An empty sort uses DEFAULT_TASK_SORT. The ID breaks ties so tasks with
+// equal values don't move between pages. ok is false for columns that can't
+// be sorted on.
+func taskSortOrder(sort string) (order string, ok bool) {
+	if sort == "" {
+		sort = config.Get().DefaultTaskSort
+	}
+	if sort == "" {
+		sort = defaultTaskSort
+	}
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = sort[1:]
+	}
+	column, ok := taskSortColumns[sort]
+	if !ok {
+		return "", false
+	}
+	return column + " " + direction + ", id " + direction, true
+}
+
+// escapeLike escapes the LIKE wildcards in s so they match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestTaskSortColumnsMatchConfig tests that every sort field DEFAULT_TASK_SORT accepts can be sorted on
+func TestTaskSortColumnsMatchConfig(t *testing.T) {
+	if len(taskSortColumns) != len(config.TaskSortFields) {
+		t.Errorf("Expected %d sort columns, got %d", len(config.TaskSortFields), len(taskSortColumns))
+	}
+	for _, field := range config.TaskSortFields {
+		if _, ok := taskSortColumns[field]; !ok {
+			t.Errorf("Sort field %s has no column", field)
+		}
+	}
+}
+
+// TestSearchTasks tests combining search filters, sorting and pagination
+func TestSearchTasks(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-search")
+	other := env.createUser("test-search-other")
+
+	env.createTask(user, CreateTaskRequest{Title: "Write report", Status: models.TaskStatusPending})
+	env.createTask(user, CreateTaskRequest{Title: "Review REPORT draft", Status: models.TaskStatusInProgress})
+	env.createTask(user, CreateTaskRequest{Title: "Report sent", Status: models.TaskStatusCompleted})
+	env.createTask(user, CreateTaskRequest{Title: "Buy milk", Status: models.TaskStatusPending})
+	env.createTask(user, CreateTaskRequest{Title: "100%_done", Status: models.TaskStatusPending})
+	env.createTask(other, CreateTaskRequest{Title: "Other's report", Status: models.TaskStatusPending})
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name           string
+		body           interface{}
+		expectedTitles []string
+		expectedTotal  int64
+	}{
+		{
+			name:           "no filters sorts newest first",
+			body:           TaskSearchRequest{},
+			expectedTitles: []string{"100%_done", "Buy milk", "Report sent", "Review REPORT draft", "Write report"},
+			expectedTotal:  5,
+		},
+		{
+			name:           "title is matched case-insensitively",
+			body:           TaskSearchRequest{TitleContains: "report", Sort: "title"},
+			expectedTitles: []string{"Report sent", "Review REPORT draft", "Write report"},
+			expectedTotal:  3,
+		},
+		{
+			name:           "filters AND together",
+			body:           TaskSearchRequest{TitleContains: "report", Statuses: []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress}, Sort: "title"},
+			expectedTitles: []string{"Review REPORT draft", "Write report"},
+			expectedTotal:  2,
+		},
+		{
+			name:           "LIKE wildcards match literally",
+			body:           TaskSearchRequest{TitleContains: "%_"},
+			expectedTitles: []string{"100%_done"},
+			expectedTotal:  1,
+		},
+		{
+			name:           "created range includes now",
+			body:           TaskSearchRequest{CreatedBetween: &TimeRange{From: &past, To: &future}, Statuses: []models.TaskStatus{models.TaskStatusCompleted}},
+			expectedTitles: []string{"Report sent"},
+			expectedTotal:  1,
+		},
+		{
+			name:           "created range in the future",
+			body:           TaskSearchRequest{CreatedBetween: &TimeRange{From: &future}},
+			expectedTitles: []string{},
+			expectedTotal:  0,
+		},
+		{
+			name:           "total counts all matches, not just the page",
+			body:           TaskSearchRequest{TitleContains: "report", Sort: "-title", Page: 2, PageSize: 2},
+			expectedTitles: []string{"Report sent"},
+			expectedTotal:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := env.serve(SearchTasks, asUser(env.newRequest("POST", "/api/tasks/search", tt.body), user))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var resp PaginatedTaskResponse
+			env.decode(rr, &resp)
+			titles := make([]string, 0)
+			for _, task := range resp.Tasks {
+				titles = append(titles, task.Title)
+			}
+			if len(titles) != len(tt.expectedTitles) {
+				t.Fatalf("Expected %v, got %v", tt.expectedTitles, titles)
+			}
+			for i := range titles {
+				if titles[i] != tt.expectedTitles[i] {
+					t.Fatalf("Expected %v, got %v", tt.expectedTitles, titles)
+				}
+			}
+			if resp.Total != tt.expectedTotal {
+				t.Errorf("Expected total %d, got %d", tt.expectedTotal, resp.Total)
+			}
+		})
+	}
+}
+
+// TestSearchTasksValidation tests that invalid search filters are rejected
+func TestSearchTasksValidation(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-search-invalid")
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name         string
+		body         interface{}
+		expectedCode apierror.Code
+	}{
+		{"unknown status", TaskSearchRequest{Statuses: []models.TaskStatus{"archived"}}, apierror.InvalidStatus},
+		{"unknown sort column", TaskSearchRequest{Sort: "password"}, apierror.InvalidSort},
+		{"sort with SQL", TaskSearchRequest{Sort: "title; DROP TABLE tasks"}, apierror.InvalidSort},
+		{"backwards range", TaskSearchRequest{CreatedBetween: &TimeRange{From: &future, To: &past}}, apierror.InvalidDateRange},
+		{"negative page", TaskSearchRequest{Page: -1}, apierror.InvalidPagination},
+		{"negative page size", TaskSearchRequest{PageSize: -5}, apierror.InvalidPagination},
+		{"unknown filter", map[string]interface{}{"priorities": []string{"high"}}, apierror.InvalidJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := env.serve(SearchTasks, asUser(env.newRequest("POST", "/api/tasks/search", tt.body), user))
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var resp ErrorResponse
+			env.decode(rr, &resp)
+			if resp.Code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s", tt.expectedCode, resp.Code)
+			}
+		})
+	}
+}
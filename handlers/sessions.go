@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// SignOutMember handles POST /api/organization/members/{id}/sign-out - Sign a user out everywhere
+// Only admins can do this, for any member of their organization (themselves
+// included), e.g. after a suspected compromise. It bumps the member's token
+// version, so every token issued so far gets 401 on its next request; logging
+// in again issues a token with the new version.
+func SignOutMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	admin, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	if !admin.IsAdmin() {
+		writeError(w, r, http.StatusForbidden, apierror.AdminRequired, "Only organization admins can sign members out") // 403
+		return
+	}
+
+	// Extract the member ID from /api/organization/members/45/sign-out
+	idPart := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/organization/members/"), "/sign-out")
+	memberID, err := strconv.ParseUint(idPart, 10, 32)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidUserID, "Invalid user ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// One atomic increment: two concurrent sign-outs both take effect
+	result := db.Model(&models.User{}).
+		Where("id = ? AND org_id = ?", memberID, admin.OrgID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1"))
+	if result.Error != nil {
+		if writeQueryTimeout(w, r, result.Error) {
+			return
+		}
+		log.Printf("Failed to sign out user %d: %v", memberID, result.Error)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to sign out user")
+		return
+	}
+	// Users of other organizations look like missing ones
+	if result.RowsAffected == 0 {
+		writeError(w, r, http.StatusNotFound, apierror.MemberNotFound, "User not found in your organization")
+		return
+	}
+
+	log.Printf("Admin %d signed out user %d everywhere", admin.UserID, memberID)
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
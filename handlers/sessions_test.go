@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestSignOutMember tests that signing a member out invalidates their existing tokens only
+func TestSignOutMember(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	admin := env.createOrgAdmin("test-sign-out-admin")
+	outsider := env.createUser("test-sign-out-outsider")
+
+	email := uniqueEmail("test-sign-out-member")
+	rr := env.serve(CreateOrganizationMember, asUser(env.newRequest("POST", "/api/organization/members", CreateMemberRequest{Email: email, Password: "memberpass123"}), admin))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to create member: status %d, body %s", rr.Code, rr.Body.String())
+	}
+	var member models.User
+	env.decode(rr, &member)
+
+	login := func() string {
+		rr := env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: email, Password: "memberpass123"}))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Failed to log in: status %d, body %s", rr.Code, rr.Body.String())
+		}
+		var response AuthResponse
+		env.decode(rr, &response)
+		return response.Token
+	}
+
+	// authenticate runs a request with token through AuthMiddleware
+	// and remembers the user it put in the context
+	var gotUser middleware.UserContext
+	protected := middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _ = middleware.GetUserFromContext(r)
+		w.WriteHeader(http.StatusOK)
+	})
+	authenticate := func(token string) int {
+		req := env.newRequest("GET", "/api/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return env.serve(protected, req).Code
+	}
+
+	phone, laptop := login(), login()
+	if code := authenticate(phone); code != http.StatusOK {
+		t.Fatalf("Expected the token to work before signing out, got %d", code)
+	}
+	if gotUser.UserID != member.ID || gotUser.Email != email || gotUser.OrgID != admin.OrgID || gotUser.IsAdmin() {
+		t.Errorf("Expected member %d of organization %d in the context, got %+v", member.ID, admin.OrgID, gotUser)
+	}
+
+	path := fmt.Sprintf("/api/organization/members/%d/sign-out", member.ID)
+	rr = env.serve(SignOutMember, asUser(env.newRequest("POST", path, nil), admin))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Every token issued before is dead, wherever it's used
+	for _, token := range []string{phone, laptop} {
+		if code := authenticate(token); code != http.StatusUnauthorized {
+			t.Errorf("Expected an old token to get 401, got %d", code)
+		}
+	}
+
+	// Logging in again works
+	if code := authenticate(login()); code != http.StatusOK {
+		t.Errorf("Expected a token issued after signing out to work, got %d", code)
+	}
+
+	testCases := []struct {
+		name           string
+		caller         middleware.UserContext
+		path           string
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"members can't sign others out", middleware.UserContext{UserID: member.ID, Email: email, OrgID: admin.OrgID, Role: models.RoleMember}, fmt.Sprintf("/api/organization/members/%d/sign-out", admin.UserID), http.StatusForbidden, apierror.AdminRequired},
+		{"users of other organizations look missing", admin, fmt.Sprintf("/api/organization/members/%d/sign-out", outsider.UserID), http.StatusNotFound, apierror.MemberNotFound},
+		{"invalid user ID", admin, "/api/organization/members/abc/sign-out", http.StatusBadRequest, apierror.InvalidUserID},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(SignOutMember, asUser(env.newRequest("POST", tc.path, nil), tc.caller))
+			var errResp ErrorResponse
+			env.decode(rr, &errResp)
+			if rr.Code != tc.expectedStatus || errResp.Code != tc.expectedCode {
+				t.Errorf("Expected %d %s, got %d %s", tc.expectedStatus, tc.expectedCode, rr.Code, errResp.Code)
+			}
+		})
+	}
+}
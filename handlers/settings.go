@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// UserSettingsRequest represents the caller's new settings
+// PUT replaces every setting: a missing or null field resets it to the default
+type UserSettingsRequest struct {
+	DefaultTaskStatus *models.TaskStatus `json:"default_task_status"` // Status for new tasks created without one
+	Timezone          *string            `json:"timezone"`            // IANA time zone for response timestamps, e.g. Europe/Istanbul
+}
+
+// UserSettingsResponse represents the caller's settings in API responses
+// Settings that were never set (or were reset) are null
+type UserSettingsResponse struct {
+	DefaultTaskStatus *models.TaskStatus `json:"default_task_status"`
+	Timezone          *string            `json:"timezone"` // null writes timestamps in UTC
+}
+
+// newUserSettingsResponse converts settings to their API representation
+func newUserSettingsResponse(settings models.UserSettings) UserSettingsResponse {
+	return UserSettingsResponse{DefaultTaskStatus: settings.DefaultTaskStatus, Timezone: settings.Timezone}
+}
+
+// loadUserSettings returns a user's settings, or empty settings if they never saved any
+func loadUserSettings(db *gorm.DB, userID uint) (models.UserSettings, error) {
+	var settings models.UserSettings
+	err := db.Where("user_id = ?", userID).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.UserSettings{UserID: userID}, nil
+	}
+	return settings, err
+}
+
+// GetUserSettings handles GET /api/user/settings - Get the caller's settings
+func GetUserSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	settings, err := loadUserSettings(db, user.UserID)
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to load settings for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch settings")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, newUserSettingsResponse(settings))
+}
+
+// UpdateUserSettings handles PUT /api/user/settings - Replace the caller's settings
+func UpdateUserSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "PUT" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	var req UserSettingsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// The default status has to be one the workflow accepts for new tasks
+	if req.DefaultTaskStatus != nil {
+		workflow, err := taskWorkflow()
+		if err != nil {
+			log.Printf("Invalid task workflow configuration: %v", err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to update settings")
+			return
+		}
+		if !workflow.IsValid(*req.DefaultTaskStatus) {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidStatus, "Invalid default_task_status. Use: "+workflow.StatusList())
+			return
+		}
+	}
+
+	// "" resets the time zone like null does
+	if req.Timezone != nil && *req.Timezone == "" {
+		req.Timezone = nil
+	}
+	if req.Timezone != nil {
+		if _, ok := loadTimezone(*req.Timezone); !ok {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidTimezone, "Invalid timezone. Use an IANA time zone such as Europe/Istanbul")
+			return
+		}
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Create the settings row on first save, update it afterwards
+	var settings models.UserSettings
+	err := db.Where("user_id = ?", user.UserID).First(&settings).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		settings = models.UserSettings{UserID: user.UserID, DefaultTaskStatus: req.DefaultTaskStatus, Timezone: req.Timezone}
+		err = db.Create(&settings).Error
+	case err == nil:
+		// Select makes GORM write the columns even when they're being reset to NULL
+		settings.DefaultTaskStatus = req.DefaultTaskStatus
+		settings.Timezone = req.Timezone
+		err = db.Model(&settings).Select("default_task_status", "timezone").Updates(&settings).Error
+	}
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to update settings for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to update settings")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, newUserSettingsResponse(settings))
+}
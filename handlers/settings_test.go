@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestUserSettings tests reading and replacing settings and how the default status is picked
+func TestUserSettings(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-settings")
+	other := env.createUser("test-settings-other")
+
+	getSettings := func() UserSettingsResponse {
+		rr := env.serve(GetUserSettings, asUser(env.newRequest("GET", "/api/user/settings", nil), user))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var settings UserSettingsResponse
+		env.decode(rr, &settings)
+		return settings
+	}
+	putSettings := func(body interface{}) int {
+		rr := env.serve(UpdateUserSettings, asUser(env.newRequest("PUT", "/api/user/settings", body), user))
+		return rr.Code
+	}
+
+	// Users without a settings row get null (the global default)
+	if settings := getSettings(); settings.DefaultTaskStatus != nil {
+		t.Errorf("Expected no default status before saving, got %s", *settings.DefaultTaskStatus)
+	}
+
+	inProgress := models.TaskStatusInProgress
+	unknown := models.TaskStatus("someday")
+	testCases := []struct {
+		name           string
+		requestBody    interface{}
+		expectedStatus int
+	}{
+		{"unknown status", UserSettingsRequest{DefaultTaskStatus: &unknown}, http.StatusBadRequest},
+		{"invalid JSON", "not-json", http.StatusBadRequest},
+		{"first save creates", UserSettingsRequest{DefaultTaskStatus: &inProgress}, http.StatusOK},
+		{"saving again updates", UserSettingsRequest{DefaultTaskStatus: &inProgress}, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if status := putSettings(tc.requestBody); status != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, status)
+			}
+		})
+	}
+
+	if settings := getSettings(); settings.DefaultTaskStatus == nil || *settings.DefaultTaskStatus != inProgress {
+		t.Fatalf("Expected the saved default status, got %+v", settings)
+	}
+
+	// Precedence: request value > preference > global default
+	if task := env.createTask(user, CreateTaskRequest{Title: "Preference"}); task.Status != inProgress {
+		t.Errorf("Expected the preferred status %s, got %s", inProgress, task.Status)
+	}
+	if task := env.createTask(user, CreateTaskRequest{Title: "Explicit", Status: models.TaskStatusCompleted}); task.Status != models.TaskStatusCompleted {
+		t.Errorf("Expected the request's status to win, got %s", task.Status)
+	}
+	if task := env.createTask(other, CreateTaskRequest{Title: "Other user"}); task.Status != models.TaskStatusPending {
+		t.Errorf("Expected another user's task to use the global default, got %s", task.Status)
+	}
+
+	// null resets the setting
+	if status := putSettings(map[string]interface{}{"default_task_status": nil}); status != http.StatusOK {
+		t.Fatalf("Expected 200 resetting settings, got %d", status)
+	}
+	if settings := getSettings(); settings.DefaultTaskStatus != nil {
+		t.Errorf("Expected the default status to be reset, got %s", *settings.DefaultTaskStatus)
+	}
+	if task := env.createTask(user, CreateTaskRequest{Title: "After reset"}); task.Status != models.TaskStatusPending {
+		t.Errorf("Expected the global default after a reset, got %s", task.Status)
+	}
+}
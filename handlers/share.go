@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// ShareTaskRequest represents the data needed to share a task with another user
+type ShareTaskRequest struct {
+	UserID     uint                   `json:"user_id"`    // User to share with (required)
+	Permission models.SharePermission `json:"permission"` // read or write (defaults to read)
+}
+
+// TaskShareResponse represents a task share in API responses
+type TaskShareResponse struct {
+	TaskID           any                    `json:"task_id"` // The public UUID with TASK_ID_FORMAT=uuid
+	SharedWithUserID uint                   `json:"shared_with_user_id"`
+	Permission       models.SharePermission `json:"permission"`
+	CreatedAt        Timestamp              `json:"created_at"`
+	UpdatedAt        Timestamp              `json:"updated_at"`
+}
+
+// newTaskShareResponse converts a share of task to its API representation
+func newTaskShareResponse(share models.TaskShare, task models.Task) TaskShareResponse {
+	return TaskShareResponse{
+		TaskID:           referencedTaskID(task),
+		SharedWithUserID: share.SharedWithUserID,
+		Permission:       share.Permission,
+		CreatedAt:        newTimestamp(share.CreatedAt),
+		UpdatedAt:        newTimestamp(share.UpdatedAt),
+	}
+}
+
+// errTaskReadOnly signals that the caller can see the task but not change it
+var errTaskReadOnly = errors.New("task is shared read-only")
+
+// findAccessibleTask loads a task the user owns, has been shared with, or -
+// for organization admins - any task in their organization
+// Tasks in other organizations are never found. With needWrite, a read-only
+// share (or admin access to someone else's task) yields errTaskReadOnly.
+// Without any access the error is gorm.ErrRecordNotFound, so other users'
+// tasks stay indistinguishable from missing ones.
+// Owners, the common case, are found through findOwnedTask; for anyone else
+// the task is loaded again to check for a share or admin access.
+func findAccessibleTask(db *gorm.DB, taskID uint, user middleware.UserContext, needWrite bool) (models.Task, error) {
+	task, err := findOwnedTask(db, taskID, user)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return task, err
+	}
+
+	if err := db.Where("org_id = ?", user.OrgID).First(&task, taskID).Error; err != nil {
+		return models.Task{}, err
+	}
+
+	var share models.TaskShare
+	err = db.Where("task_id = ? AND shared_with_user_id = ?", taskID, user.UserID).First(&share).Error
+	switch {
+	case err == nil && (!needWrite || share.Permission == models.SharePermissionWrite):
+		return task, nil
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		return models.Task{}, err
+	case err == nil || user.IsAdmin():
+		// Admins see every task in the organization, but only change their own
+		// or ones shared with them for writing
+		if needWrite {
+			return models.Task{}, errTaskReadOnly
+		}
+		return task, nil
+	}
+	return models.Task{}, gorm.ErrRecordNotFound
+}
+
+// writeTaskAccessError responds to an error from findAccessibleTask
+func writeTaskAccessError(w http.ResponseWriter, r *http.Request, err error) {
+	if writeQueryTimeout(w, r, err) {
+		return
+	}
+	if errors.Is(err, errTaskReadOnly) {
+		writeError(w, r, http.StatusForbidden, apierror.TaskReadOnly, "Task is shared with you read-only") // 403
+		return
+	}
+	writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+}
+
+// ShareTask handles POST /api/tasks/{id}/shares - Share a task with another user
+// Sharing with a user who already has access changes their permission
+func ShareTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/shares
+	taskID, err := taskIDFromPath(r.URL.Path, "/shares")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	// Parse request body
+	var req ShareTaskRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.UserID == 0 {
+		writeError(w, r, http.StatusBadRequest, apierror.ShareUserRequired, "user_id is required")
+		return
+	}
+	if req.UserID == user.UserID {
+		writeError(w, r, http.StatusBadRequest, apierror.ShareWithOwner, "Cannot share a task with its owner")
+		return
+	}
+
+	// Read-only is the safe default
+	if req.Permission == "" {
+		req.Permission = models.SharePermissionRead
+	}
+	if !req.Permission.IsValid() {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidSharePermission, "Invalid permission. Use: read, write")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Only the owner can share a task
+	task, err := findOwnedTask(db, taskID, user)
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+		return
+	}
+
+	// Soft-deleted users are excluded by GORM's default scope, and users in
+	// other organizations look the same as ones that don't exist
+	var target models.User
+	if err := db.Where("org_id = ?", user.OrgID).First(&target, req.UserID).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		writeError(w, r, http.StatusBadRequest, apierror.ShareUserNotFound, "User to share with does not exist")
+		return
+	}
+
+	// Create the share, or update the permission of an existing one
+	var share models.TaskShare
+	status := http.StatusOK
+	err = db.Where("task_id = ? AND shared_with_user_id = ?", task.ID, target.ID).First(&share).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		share = models.TaskShare{TaskID: task.ID, SharedWithUserID: target.ID, Permission: req.Permission}
+		err = db.Create(&share).Error
+		status = http.StatusCreated
+	case err == nil && share.Permission != req.Permission:
+		err = db.Model(&share).Update("permission", req.Permission).Error
+		// Downgrading to read-only must take effect on cached reads too
+		forgetCachedTask(target.ID, task.ID)
+	}
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to share task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to share task")
+		return
+	}
+
+	writeResponse(w, r, status, newTaskShareResponse(share, task))
+}
+
+// GetTaskShares handles GET /api/tasks/{id}/shares - List who a task is shared with
+func GetTaskShares(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/shares
+	taskID, err := taskIDFromPath(r.URL.Path, "/shares")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Only the owner can see who else has access
+	task, err := findOwnedTask(db, taskID, user)
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+		return
+	}
+
+	var shares []models.TaskShare
+	if err := db.Where("task_id = ?", task.ID).Order("id ASC").Find(&shares).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch shares for task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch task shares")
+		return
+	}
+
+	response := make([]TaskShareResponse, 0, len(shares))
+	for _, share := range shares {
+		response = append(response, newTaskShareResponse(share, task))
+	}
+
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// RevokeTaskShare handles DELETE /api/tasks/{id}/shares/{user_id} - Stop sharing a task with a user
+func RevokeTaskShare(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "DELETE" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Split /api/tasks/123/shares/45 into the task and user IDs
+	taskPath, userPath, _ := strings.Cut(r.URL.Path, "/shares/")
+	taskID, err := taskIDFromPath(taskPath, "")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+	sharedWithID, err := strconv.ParseUint(userPath, 10, 32)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidUserID, "Invalid user ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Only the owner can revoke access
+	task, err := findOwnedTask(db, taskID, user)
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+		return
+	}
+
+	result := db.Where("task_id = ? AND shared_with_user_id = ?", task.ID, sharedWithID).Delete(&models.TaskShare{})
+	if result.Error != nil {
+		if writeQueryTimeout(w, r, result.Error) {
+			return
+		}
+		log.Printf("Failed to revoke share on task %d: %v", task.ID, result.Error)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to revoke task share")
+		return
+	}
+	if result.RowsAffected == 0 {
+		writeError(w, r, http.StatusNotFound, apierror.ShareNotFound, "Task is not shared with this user")
+		return
+	}
+
+	// The user must not keep reading the task from the cache
+	forgetCachedTask(uint(sharedWithID), task.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestShareTaskHandler tests validation when sharing a task
+func TestShareTaskHandler(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-share-owner")
+	colleague := env.createUser("test-share-colleague")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Shared"})
+	path := fmt.Sprintf("/api/tasks/%d/shares", task.ID)
+
+	testCases := []struct {
+		name           string
+		requestBody    interface{}
+		expectedStatus int
+	}{
+		{"missing user", ShareTaskRequest{Permission: models.SharePermissionRead}, http.StatusBadRequest},
+		{"share with owner", ShareTaskRequest{UserID: owner.UserID}, http.StatusBadRequest},
+		{"invalid permission", ShareTaskRequest{UserID: colleague.UserID, Permission: "admin"}, http.StatusBadRequest},
+		{"unknown user", ShareTaskRequest{UserID: 999999}, http.StatusBadRequest},
+		{"invalid JSON", "not-json", http.StatusBadRequest},
+		{"new share defaults to read", ShareTaskRequest{UserID: colleague.UserID}, http.StatusCreated},
+		{"sharing again changes the permission", ShareTaskRequest{UserID: colleague.UserID, Permission: models.SharePermissionWrite}, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(ShareTask, asUser(env.newRequest("POST", path, tc.requestBody), owner))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	// Only the owner can share
+	rr := env.serve(ShareTask, asUser(env.newRequest("POST", path, ShareTaskRequest{UserID: owner.UserID}), colleague))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when a non-owner shares, got %d", rr.Code)
+	}
+
+	rr = env.serve(GetTaskShares, asUser(env.newRequest("GET", path, nil), owner))
+	var shares []TaskShareResponse
+	env.decode(rr, &shares)
+	if len(shares) != 1 || shares[0].SharedWithUserID != colleague.UserID || shares[0].Permission != models.SharePermissionWrite {
+		t.Errorf("Expected one write share for the colleague, got %+v", shares)
+	}
+}
+
+// TestTaskShareAccess tests what read and write shares allow
+func TestTaskShareAccess(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-access-owner")
+	reader := env.createUser("test-access-reader")
+	writer := env.createUser("test-access-writer")
+	stranger := env.createUser("test-access-stranger")
+
+	task := env.createTask(owner, CreateTaskRequest{Title: "Team task"})
+	sharesPath := fmt.Sprintf("/api/tasks/%d/shares", task.ID)
+	for _, share := range []ShareTaskRequest{
+		{UserID: reader.UserID, Permission: models.SharePermissionRead},
+		{UserID: writer.UserID, Permission: models.SharePermissionWrite},
+	} {
+		rr := env.serve(ShareTask, asUser(env.newRequest("POST", sharesPath, share), owner))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Failed to share task: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+	update := map[string]string{"title": "Edited"}
+
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		method         string
+		body           interface{}
+		user           middleware.UserContext
+		expectedStatus int
+	}{
+		{"reader can view", GetTask, "GET", nil, reader, http.StatusOK},
+		{"reader cannot update", UpdateTask, "PUT", update, reader, http.StatusForbidden},
+		{"reader cannot delete", DeleteTask, "DELETE", nil, reader, http.StatusForbidden},
+		{"writer can view", GetTask, "GET", nil, writer, http.StatusOK},
+		{"writer can update", UpdateTask, "PUT", update, writer, http.StatusOK},
+		{"stranger cannot view", GetTask, "GET", nil, stranger, http.StatusNotFound},
+		{"stranger cannot update", UpdateTask, "PUT", update, stranger, http.StatusNotFound},
+		{"stranger cannot delete", DeleteTask, "DELETE", nil, stranger, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(tc.handler, asUser(env.newRequest(tc.method, path, tc.body), tc.user))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	// Shared tasks are only listed when asked for
+	listed := func(user middleware.UserContext, query string) bool {
+		rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks"+query, nil), user))
+		var response PaginatedTaskResponse
+		env.decode(rr, &response)
+		for _, listedTask := range response.Tasks {
+			if listedTask.ID == task.ID {
+				return true
+			}
+		}
+		return false
+	}
+	if listed(reader, "") {
+		t.Errorf("Expected shared task to be left out without ?shared=true")
+	}
+	if !listed(reader, "?shared=true") {
+		t.Errorf("Expected shared task to be listed with ?shared=true")
+	}
+	if listed(stranger, "?shared=true") {
+		t.Errorf("Expected unshared task to stay hidden with ?shared=true")
+	}
+
+	// Revoking takes access away
+	revokePath := fmt.Sprintf("%s/%d", sharesPath, reader.UserID)
+	rr := env.serve(RevokeTaskShare, asUser(env.newRequest("DELETE", revokePath, nil), owner))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 when revoking, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = env.serve(GetTask, asUser(env.newRequest("GET", path, nil), reader))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after the share was revoked, got %d", rr.Code)
+	}
+	rr = env.serve(RevokeTaskShare, asUser(env.newRequest("DELETE", revokePath, nil), owner))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when revoking a missing share, got %d", rr.Code)
+	}
+
+	// A write share also allows deleting
+	rr = env.serve(DeleteTask, asUser(env.newRequest("DELETE", path, nil), writer))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected writer to delete the task, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+// TestGetTasksScope tests listing owned tasks only or every visible task
+func TestGetTasksScope(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-scope-owner")
+	user := env.createUser("test-scope-user")
+	stranger := env.createUser("test-scope-stranger")
+
+	env.createTask(user, CreateTaskRequest{Title: "Own task"})
+	shared := env.createTask(owner, CreateTaskRequest{Title: "Shared with user"})
+	transferred := env.createTask(owner, CreateTaskRequest{Title: "Shared, then transferred"})
+	env.createTask(stranger, CreateTaskRequest{Title: "Not shared"})
+
+	for _, task := range []TaskResponse{shared, transferred} {
+		path := fmt.Sprintf("/api/tasks/%d/shares", task.ID)
+		rr := env.serve(ShareTask, asUser(env.newRequest("POST", path, ShareTaskRequest{UserID: user.UserID}), owner))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Failed to share task: %d %s", rr.Code, rr.Body.String())
+		}
+	}
+	// The share outlives the transfer, so this task is both owned and shared
+	path := fmt.Sprintf("/api/tasks/%d/transfer", transferred.ID)
+	rr := env.serve(TransferTask, asUser(env.newRequest("POST", path, TransferTaskRequest{NewOwnerID: user.UserID}), owner))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to transfer task: %d %s", rr.Code, rr.Body.String())
+	}
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedTotal  int64
+	}{
+		{"default is owned", "", http.StatusOK, 2},
+		{"owned", "?scope=owned", http.StatusOK, 2},
+		{"all counts each task once", "?scope=all", http.StatusOK, 3},
+		{"all across pages", "?scope=all&page=2&page_size=2", http.StatusOK, 3},
+		{"shared=true still works", "?shared=true", http.StatusOK, 3},
+		{"explicit scope wins over shared", "?scope=owned&shared=true", http.StatusOK, 2},
+		{"unknown scope", "?scope=assigned", http.StatusBadRequest, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks"+tc.query, nil), user))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var response PaginatedTaskResponse
+			env.decode(rr, &response)
+			if response.Total != tc.expectedTotal {
+				t.Errorf("Expected total %d, got %d", tc.expectedTotal, response.Total)
+			}
+			seen := map[uint]bool{}
+			for _, task := range response.Tasks {
+				if seen[task.ID] {
+					t.Errorf("Task %d listed twice", task.ID)
+				}
+				seen[task.ID] = true
+				if task.Title == "Not shared" {
+					t.Errorf("Expected the stranger's task to stay hidden")
+				}
+			}
+		})
+	}
+
+	// Pages of the full listing add up to every visible task, each once
+	seen := map[uint]bool{}
+	for page := 1; page <= 2; page++ {
+		rr := env.serve(GetTasks, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks?scope=all&page=%d&page_size=2", page), nil), user))
+		var response PaginatedTaskResponse
+		env.decode(rr, &response)
+		for _, task := range response.Tasks {
+			if seen[task.ID] {
+				t.Errorf("Task %d listed on two pages", task.ID)
+			}
+			seen[task.ID] = true
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("Expected 3 tasks across the pages, got %d", len(seen))
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/kcansari/task-management-api/cache"
+)
+
+// maxPageSnapshots bounds how many snapshot totals are kept at once
+// The oldest are dropped first; a dropped token just means one more count.
+const maxPageSnapshots = 10000
+
+// pageSnapshot is the total a listing had when its snapshot token was issued
+type pageSnapshot struct {
+	Listing string // The user and filters the total was counted for
+	Total   int64
+}
+
+// pageSnapshots maps snapshot tokens to totals; nil when snapshots are disabled
+var pageSnapshots *cache.LRU[pageSnapshot]
+
+// EnablePaginationSnapshots lets GET /api/tasks clients reuse a total for ttl via ?snapshot=
+// main calls it at startup when PAGINATION_SNAPSHOTS_ENABLED is set
+func EnablePaginationSnapshots(ttl time.Duration) {
+	pageSnapshots = cache.New[pageSnapshot](maxPageSnapshots, ttl)
+}
+
+// snapshotTotal returns the total saved under token for the same listing
+// Tokens that expired, were never issued or were issued for another user or
+// other filters are misses, so the caller counts instead.
+func snapshotTotal(token, listing string) (int64, bool) {
+	if pageSnapshots == nil || token == "" {
+		return 0, false
+	}
+	snapshot, ok := pageSnapshots.Get(token)
+	if !ok || snapshot.Listing != listing {
+		return 0, false
+	}
+	return snapshot.Total, true
+}
+
+// saveSnapshotTotal remembers total for listing under a new random token
+func saveSnapshotTotal(listing string, total int64) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	pageSnapshots.Set(token, pageSnapshot{Listing: listing, Total: total})
+	return token, nil
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestGetTasksSnapshot tests that snapshot tokens skip the count only for the same listing
+// Not parallel: it swaps the package-level snapshot cache
+func TestGetTasksSnapshot(t *testing.T) {
+	EnablePaginationSnapshots(time.Minute)
+	t.Cleanup(func() { pageSnapshots = nil })
+
+	env := newTestEnv(t)
+	owner := env.createUser("test-snapshot-owner")
+	other := env.createUser("test-snapshot-other")
+	for _, title := range []string{"One", "Two", "Three"} {
+		env.createTask(owner, CreateTaskRequest{Title: title})
+	}
+
+	list := func(t *testing.T, query string, asOwner bool) PaginatedTaskResponse {
+		t.Helper()
+		caller := owner
+		if !asOwner {
+			caller = other
+		}
+		rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks?"+query, nil), caller))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var response PaginatedTaskResponse
+		env.decode(rr, &response)
+		return response
+	}
+
+	// Without ?snapshot= nothing changes
+	if first := list(t, "page_size=2", true); first.Snapshot != "" {
+		t.Errorf("Expected no snapshot token unless asked for, got %q", first.Snapshot)
+	}
+
+	first := list(t, "page_size=2&snapshot=true", true)
+	if first.Total != 3 || first.Snapshot == "" {
+		t.Fatalf("Expected a total of 3 and a snapshot token, got %+v", first.PaginationMeta)
+	}
+	token := first.Snapshot
+
+	// A task added later isn't counted while the snapshot lives, but is listed
+	env.createTask(owner, CreateTaskRequest{Title: "Four"})
+	second := list(t, "page=2&page_size=2&snapshot="+url.QueryEscape(token), true)
+	if second.Total != 3 || second.Snapshot != token {
+		t.Errorf("Expected the snapshot total 3 and the same token, got %+v", second.PaginationMeta)
+	}
+	if len(second.Tasks) != 2 {
+		t.Errorf("Expected the live second page of 2 tasks, got %d", len(second.Tasks))
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		owner         bool
+		expectedTotal int64
+	}{
+		{"unknown token", "snapshot=expired", true, 4},
+		{"other filters", "shared=true&snapshot=" + token, true, 4},
+		{"other user", "snapshot=" + token, false, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			response := list(t, tc.query, tc.owner)
+			if response.Total != tc.expectedTotal {
+				t.Errorf("Expected a fresh count of %d, got %d", tc.expectedTotal, response.Total)
+			}
+			if response.Snapshot == "" || response.Snapshot == token {
+				t.Errorf("Expected a new snapshot token, got %q", response.Snapshot)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TaskStatusCount is how many of the caller's tasks have a given status
+type TaskStatusCount struct {
+	Status models.TaskStatus `json:"status"`
+	Count  int64             `json:"count"`
+}
+
+// GetTaskStatusCounts handles GET /api/tasks/statuses - Count the caller's tasks per status
+// Only statuses that have tasks are listed, e.g. for building a filter dropdown.
+// Counting happens in the database (GROUP BY), so no tasks are loaded.
+func GetTaskStatusCounts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Strictly the caller's own tasks: shared tasks and, for admins, the rest
+	// of the organization's tasks aren't counted
+	counts := make([]TaskStatusCount, 0) // Encodes as [] rather than null
+	if err := db.Model(&models.Task{}).
+		Select("status, COUNT(*) AS count").
+		Scopes(ownedBy(user)).
+		Group("status").
+		Order("status").
+		Scan(&counts).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to count task statuses for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch task statuses")
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, counts)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestGetTaskStatusCounts tests counting the caller's own tasks per status
+func TestGetTaskStatusCounts(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	admin := env.createOrgAdmin("test-statuses-admin")
+	user := env.addMember(admin, "test-statuses")
+	other := env.addMember(admin, "test-statuses-other")
+
+	// A user without tasks gets an empty array, not null
+	rr := env.serve(GetTaskStatusCounts, asUser(env.newRequest("GET", "/api/tasks/statuses", nil), user))
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Fatalf("Expected 200 with [], got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	env.createTask(user, CreateTaskRequest{Title: "One", Status: models.TaskStatusPending})
+	env.createTask(user, CreateTaskRequest{Title: "Two", Status: models.TaskStatusPending})
+	env.createTask(user, CreateTaskRequest{Title: "Three", Status: models.TaskStatusCompleted})
+	shared := env.createTask(other, CreateTaskRequest{Title: "Someone else's", Status: models.TaskStatusInProgress})
+	// Tasks shared with the user don't count
+	rr = env.serve(ShareTask, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/shares", shared.ID), ShareTaskRequest{UserID: user.UserID}), other))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to share task: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = env.serve(GetTaskStatusCounts, asUser(env.newRequest("GET", "/api/tasks/statuses", nil), user))
+	var counts []TaskStatusCount
+	env.decode(rr, &counts)
+	expected := []TaskStatusCount{{models.TaskStatusCompleted, 1}, {models.TaskStatusPending, 2}}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, counts)
+	}
+
+	// Admins only count their own tasks too
+	rr = env.serve(GetTaskStatusCounts, asUser(env.newRequest("GET", "/api/tasks/statuses", nil), admin))
+	if strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("Expected an admin without tasks to get [], got %s", rr.Body.String())
+	}
+}
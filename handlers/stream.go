@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/events"
+	"github.com/kcansari/task-management-api/middleware"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment line, so
+// proxies and load balancers don't close the connection as inactive
+var streamKeepAlive = 15 * time.Second
+
+// StreamTasks handles GET /api/tasks/stream - Server-Sent Events for the user's task changes
+// Each change is sent as "event: task.created|task.updated|task.completed|task.deleted"
+// with the same JSON payload webhooks receive
+func StreamTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Events must reach the client as they happen, which needs flushing
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, apierror.StreamingUnsupported, "Streaming is not supported")
+		return
+	}
+
+	// The server's write timeout would cut the stream off; lift it for this response
+	// (not every ResponseWriter supports deadlines, which is fine)
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	// Subscribe before sending headers so no change is missed after the client sees 200
+	stream, unsubscribe := events.Default.Subscribe(user.UserID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			// Client disconnected (or the server is shutting down)
+			return
+		case event := <-stream:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Name, event.Data)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
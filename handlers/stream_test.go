@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/events"
+	"github.com/kcansari/task-management-api/middleware"
+)
+
+// noFlushWriter is a ResponseWriter without http.Flusher support
+type noFlushWriter struct {
+	header http.Header
+	code   int
+}
+
+func (w *noFlushWriter) Header() http.Header         { return w.header }
+func (w *noFlushWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *noFlushWriter) WriteHeader(code int)        { w.code = code }
+
+// TestStreamTasksRequiresFlusher tests that streaming fails cleanly without flush support
+func TestStreamTasksRequiresFlusher(t *testing.T) {
+	user := middleware.UserContext{UserID: 424201}
+	w := &noFlushWriter{header: make(http.Header)}
+	StreamTasks(w, asUser(httptest.NewRequest("GET", "/api/tasks/stream", nil), user))
+
+	if w.code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", w.code)
+	}
+}
+
+// TestStreamTasks tests receiving events, keep-alives and cleanup on disconnect
+// Not parallel: it shortens the global keep-alive interval
+func TestStreamTasks(t *testing.T) {
+	previous := streamKeepAlive
+	streamKeepAlive = 50 * time.Millisecond
+	t.Cleanup(func() { streamKeepAlive = previous })
+
+	user := middleware.UserContext{UserID: 424202}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		StreamTasks(w, asUser(r, user))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %q", ct)
+	}
+
+	lines := make(chan string, 100)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	// expectLine waits for a line starting with prefix, skipping others
+	expectLine := func(prefix string) string {
+		t.Helper()
+		deadline := time.After(5 * time.Second)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatalf("Stream closed while waiting for %q", prefix)
+				}
+				if strings.HasPrefix(line, prefix) {
+					return line
+				}
+			case <-deadline:
+				t.Fatalf("Timed out waiting for %q", prefix)
+			}
+		}
+	}
+
+	expectLine(": connected")
+
+	// Another user's events must not show up, this user's must
+	events.Default.Publish(424299, events.Event{Name: "task.deleted", Data: []byte(`{"other":true}`)})
+	events.Default.Publish(user.UserID, events.Event{Name: "task.created", Data: []byte(`{"id":1}`)})
+	if line := expectLine("event: "); line != "event: task.created" {
+		t.Errorf("Expected the user's task.created event, got %q", line)
+	}
+	if line := expectLine("data: "); line != `data: {"id":1}` {
+		t.Errorf("Unexpected data line %q", line)
+	}
+
+	expectLine(": keep-alive")
+
+	// Disconnecting unsubscribes the stream
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for events.Default.Subscribers(user.UserID) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the stream to unsubscribe after disconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
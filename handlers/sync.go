@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TaskChange is one entry of a sync changelog
+// Task is left out for deleted tasks: all a client needs is the ID to remove.
+type TaskChange struct {
+	ID        any           `json:"id"`             // Same as the task's "id"
+	Deleted   bool          `json:"deleted"`        // The task was deleted; remove it locally
+	ChangedAt Timestamp     `json:"changed_at"`     // When it was last updated, or deleted
+	Task      *TaskResponse `json:"task,omitempty"` // The task as it is now, unless deleted
+}
+
+// TaskSyncResponse is what GET /api/tasks/sync sends back
+type TaskSyncResponse struct {
+	Changes    []TaskChange `json:"changes"`     // Oldest change first
+	ServerTime Timestamp    `json:"server_time"` // Pass as since on the next sync
+}
+
+// parseSince reads ?since= as an RFC 3339 timestamp or Unix seconds, the
+// two TIMESTAMP_FORMATs server_time may come in
+func parseSince(value string) (time.Time, bool) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), true
+	}
+	since, err := time.Parse(time.RFC3339Nano, value)
+	return since, err == nil
+}
+
+// SyncTasks handles GET /api/tasks/sync?since= - What changed since the last sync
+// Offline clients call it periodically with the server_time of their previous
+// sync and apply the changelog: tasks created or updated since then come
+// whole, deleted ones with just their ID and deleted: true. Each task appears
+// once, with its latest state. since=0 makes a first sync return everything.
+func SyncTasks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	since, ok := parseSince(r.URL.Query().Get("since"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidSince, "since must be an RFC 3339 timestamp or Unix seconds")
+		return
+	}
+
+	// Taken before querying and rounded down to the second timestamps are
+	// written with, so the next sync's since can only overlap this one: a
+	// change made while this request runs is sent again rather than missed
+	serverTime := time.Now().Truncate(time.Second)
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Unscoped so soft-deleted tasks are found too; deleting only sets
+	// deleted_at, which is then the latest change
+	var tasks []models.Task
+	if err := db.Unscoped().Scopes(visibleTasks(db, user, false)).
+		Where("updated_at >= ? OR deleted_at >= ?", since, since).
+		Order("COALESCE(deleted_at, updated_at) ASC, id ASC").
+		Find(&tasks).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to sync tasks for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to sync tasks")
+		return
+	}
+
+	changes := make([]TaskChange, 0, len(tasks)) // Encodes as [] rather than null
+	for _, task := range tasks {
+		response := newTaskResponse(task)
+		change := TaskChange{ID: taskResponseID(response), ChangedAt: newTimestamp(task.UpdatedAt)}
+		if task.DeletedAt.Valid {
+			change.Deleted = true
+			change.ChangedAt = newTimestamp(task.DeletedAt.Time)
+		} else {
+			change.Task = &response
+		}
+		changes = append(changes, change)
+	}
+
+	writeResponse(w, r, http.StatusOK, TaskSyncResponse{Changes: changes, ServerTime: newTimestamp(serverTime)})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestSyncTasks tests the changelog returned for a delta sync
+func TestSyncTasks(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-sync")
+	stranger := env.createUser("test-sync-stranger")
+
+	unchanged := env.createTask(user, CreateTaskRequest{Title: "Synced before"})
+	updated := env.createTask(user, CreateTaskRequest{Title: "Updated since"})
+	deleted := env.createTask(user, CreateTaskRequest{Title: "Deleted since"})
+	created := env.createTask(user, CreateTaskRequest{Title: "Created since"})
+	env.createTask(stranger, CreateTaskRequest{Title: "Someone else's"})
+
+	// Pretend the first three were last changed well before the previous sync
+	lastSync := time.Now().Add(-time.Hour)
+	env.tx.Model(&models.Task{}).Where("id IN ?", []uint{unchanged.ID, updated.ID, deleted.ID}).
+		UpdateColumn("updated_at", lastSync.Add(-time.Hour))
+
+	rr := env.serve(UpdateTask, asUser(env.newRequest("PUT", fmt.Sprintf("/api/tasks/%d", updated.ID), `{"title":"Renamed"}`), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Failed to update task: %d %s", rr.Code, rr.Body.String())
+	}
+	rr = env.serve(DeleteTask, asUser(env.newRequest("DELETE", fmt.Sprintf("/api/tasks/%d", deleted.ID), nil), user))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Failed to delete task: %d %s", rr.Code, rr.Body.String())
+	}
+
+	path := "/api/tasks/sync?since=" + url.QueryEscape(lastSync.Format(time.RFC3339))
+	rr = env.serve(SyncTasks, asUser(env.newRequest("GET", path, nil), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response TaskSyncResponse
+	env.decode(rr, &response)
+
+	changes := map[float64]TaskChange{} // IDs decode as JSON numbers
+	for _, change := range response.Changes {
+		changes[change.ID.(float64)] = change
+	}
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes, got %+v", response.Changes)
+	}
+	if change, ok := changes[float64(deleted.ID)]; !ok || !change.Deleted || change.Task != nil {
+		t.Errorf("Expected the deleted task with its deletion marker, got %+v", change)
+	}
+	if change, ok := changes[float64(updated.ID)]; !ok || change.Deleted || change.Task == nil || change.Task.Title != "Renamed" {
+		t.Errorf("Expected the updated task with its new title, got %+v", change)
+	}
+	if change, ok := changes[float64(created.ID)]; !ok || change.Deleted || change.Task == nil {
+		t.Errorf("Expected the created task, got %+v", change)
+	}
+	if _, ok := changes[float64(unchanged.ID)]; ok {
+		t.Errorf("Expected the task unchanged since the last sync to be left out")
+	}
+	if response.ServerTime.Time().Before(lastSync) || response.ServerTime.Time().After(time.Now()) {
+		t.Errorf("Expected server_time to be the time of this sync, got %s", response.ServerTime)
+	}
+
+	// Syncing again from server_time returns nothing new
+	path = "/api/tasks/sync?since=" + fmt.Sprint(response.ServerTime.Time().Add(time.Minute).Unix())
+	rr = env.serve(SyncTasks, asUser(env.newRequest("GET", path, nil), user))
+	var next TaskSyncResponse
+	env.decode(rr, &next)
+	if rr.Code != http.StatusOK || len(next.Changes) != 0 {
+		t.Errorf("Expected no changes for a later since, got %d %+v", rr.Code, next.Changes)
+	}
+
+	for _, since := range []string{"", "yesterday"} {
+		rr := env.serve(SyncTasks, asUser(env.newRequest("GET", "/api/tasks/sync?since="+since, nil), user))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for since=%q, got %d", since, rr.Code)
+		}
+	}
+}
@@ -2,50 +2,212 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
-	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
 	"github.com/kcansari/task-management-api/middleware"
 	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
 )
 
 // CreateTaskRequest represents the data needed to create a new task
 type CreateTaskRequest struct {
-	Title       string             `json:"title"`       // Task title (required)
-	Description string             `json:"description"` // Task description (optional)
-	Status      models.TaskStatus  `json:"status"`      // Task status (optional, defaults to pending)
+	Title       string                `json:"title"`       // Task title (required)
+	Description string                `json:"description"` // Task description (optional)
+	Status      models.TaskStatus     `json:"status"`      // Task status (optional, defaults to pending)
+	DueDate     *time.Time            `json:"due_date"`    // Deadline in RFC 3339 format (optional)
+	Color       string                `json:"color"`       // #RRGGBB or a TASK_COLORS name (optional)
+	Progress    int                   `json:"progress"`    // Percent complete, 0-100 (optional, defaults to 0; completed tasks are always at 100)
+	Visibility  models.TaskVisibility `json:"visibility"`  // private or public (optional, defaults to private)
+	ExternalID  string                `json:"external_id"` // ID in the system the task is synced from (optional); repeating it returns the existing task
+	Metadata    json.RawMessage       `json:"metadata"`    // Any JSON object, e.g. integration references (optional)
 }
 
 // UpdateTaskRequest represents the data that can be updated for a task
+// Every field has three states: missing leaves it unchanged, null clears it,
+// and a value sets it. Title and status can't be cleared, so null is rejected.
 type UpdateTaskRequest struct {
-	Title       *string            `json:"title,omitempty"`       // Pointer allows nil for "not provided"
-	Description *string            `json:"description,omitempty"` // Pointer allows nil for "not provided"
-	Status      *models.TaskStatus `json:"status,omitempty"`      // Pointer allows nil for "not provided"
+	Title       Optional[string]                `json:"title,omitzero"`
+	Description Optional[string]                `json:"description,omitzero"` // null or "" clears the description
+	Status      Optional[models.TaskStatus]     `json:"status,omitzero"`
+	DueDate     Optional[time.Time]             `json:"due_date,omitzero"`   // null clears the due date
+	Color       Optional[string]                `json:"color,omitzero"`      // null or "" clears the color
+	Progress    Optional[int]                   `json:"progress,omitzero"`   // 0-100; can't be cleared
+	Visibility  Optional[models.TaskVisibility] `json:"visibility,omitzero"` // private or public; only the owner can change it
+	Metadata    Optional[json.RawMessage]       `json:"metadata,omitzero"`   // Shallow-merged into the task's metadata; null clears it
+}
+
+// Optional is a nullable request field that remembers whether it was sent at all
+// A pointer can't tell "due_date": null (clear it) apart from a missing field (keep it)
+type Optional[T any] struct {
+	Set   bool // The field was present in the JSON
+	Value *T   // nil when the field was null
+}
+
+// UnmarshalJSON is only called for fields present in the JSON, so it marks the value as set
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	return json.Unmarshal(data, &o.Value)
+}
+
+// MarshalJSON writes the value, or null when it's unset or cleared
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Value)
+}
+
+// Null reports whether the field was sent as an explicit null
+func (o Optional[T]) Null() bool {
+	return o.Set && o.Value == nil
 }
 
 // TaskResponse represents a task in API responses
 type TaskResponse struct {
-	ID          uint               `json:"id"`
-	Title       string             `json:"title"`
-	Description string             `json:"description"`
-	Status      models.TaskStatus  `json:"status"`
-	UserID      uint               `json:"user_id"`
-	CreatedAt   string             `json:"created_at"`
-	UpdatedAt   string             `json:"updated_at"`
+	ID          uint                  `json:"id"`
+	PublicID    string                `json:"-"` // Sent as "id" instead of ID with TASK_ID_FORMAT=uuid
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Status      models.TaskStatus     `json:"status"`
+	UserID      uint                  `json:"user_id"`
+	DueDate     *Timestamp            `json:"due_date"`     // null when the task has no due date
+	Color       string                `json:"color"`        // "" when the task has no color
+	Progress    uint8                 `json:"progress"`     // Percent complete, 0-100
+	CompletedAt *Timestamp            `json:"completed_at"` // When the task was completed; null while it isn't
+	Visibility  models.TaskVisibility `json:"visibility"`   // public tasks can also be read at GET /api/public/tasks/{id}
+	Position    float64               `json:"position"`     // Manual order set with POST /api/tasks/reorder
+	ClientID    *string               `json:"client_id"`    // UUID set by PUT /api/tasks/by-client-id/{uuid}; null otherwise
+	ExternalID  *string               `json:"external_id"`  // Set by POST /api/tasks with external_id; null otherwise
+	CreatedAt   Timestamp             `json:"created_at"`
+	UpdatedAt   Timestamp             `json:"updated_at"`
+	// How many checklist items are done, e.g. {"done": 3, "total": 5}
+	ChecklistProgress ChecklistProgress `json:"checklist_progress"`
+	// Seconds logged with POST /api/tasks/{id}/time-entries and stopped timers
+	TotalTimeSeconds int64 `json:"total_time_seconds"`
+	// Client-defined JSON object; {} when there is none
+	Metadata models.TaskMetadata `json:"metadata"`
+	// Non-fatal issues found by create and update (e.g. a past due date); omitted when there are none
+	Warnings []string `json:"warnings,omitempty"`
+	// Associations asked for with ?include=; omitted otherwise, [] when there are none
+	Checklist   *[]ChecklistItemResponse `json:"checklist,omitempty"`
+	Attachments *[]AttachmentResponse    `json:"attachments,omitempty"`
 }
 
 // PaginatedTaskResponse represents a paginated list of tasks
+// The embedded metadata is flattened into the top level of the JSON; the
+// enveloped format moves it under "meta" instead
 type PaginatedTaskResponse struct {
-	Tasks      []TaskResponse `json:"tasks"`       // The actual task data
-	Page       int           `json:"page"`        // Current page number (1-based)
-	PageSize   int           `json:"page_size"`   // Number of items per page
-	Total      int64         `json:"total"`       // Total number of tasks
-	TotalPages int           `json:"total_pages"` // Total number of pages
-	HasNext    bool          `json:"has_next"`    // Whether there's a next page
-	HasPrev    bool          `json:"has_prev"`    // Whether there's a previous page
+	Tasks []TaskResponse `json:"tasks"` // The actual task data
+	PaginationMeta
+}
+
+// newTaskResponse converts a task model to its API representation
+func newTaskResponse(task models.Task) TaskResponse {
+	response := TaskResponse{
+		ID:                task.ID,
+		PublicID:          task.PublicID,
+		Title:             task.Title,
+		Description:       task.Description,
+		Status:            task.Status,
+		UserID:            task.UserID,
+		DueDate:           newOptionalTimestamp(task.DueDate),
+		Color:             task.Color,
+		Progress:          task.Progress,
+		CompletedAt:       newOptionalTimestamp(task.CompletedAt),
+		Visibility:        task.Visibility,
+		Position:          task.Position,
+		ClientID:          task.ClientID,
+		ExternalID:        task.ExternalID,
+		CreatedAt:         newTimestamp(task.CreatedAt),
+		UpdatedAt:         newTimestamp(task.UpdatedAt),
+		ChecklistProgress: ChecklistProgress{Done: task.ChecklistDone, Total: task.ChecklistTotal},
+		TotalTimeSeconds:  task.TotalTimeSeconds,
+		Metadata:          task.Metadata,
+	}
+	if response.Metadata == nil {
+		response.Metadata = models.TaskMetadata{}
+	}
+	// Preloaded associations are never nil, even when empty
+	if task.Checklist != nil {
+		checklist := make([]ChecklistItemResponse, 0, len(task.Checklist))
+		for _, item := range task.Checklist {
+			checklist = append(checklist, newChecklistItemResponse(item, task))
+		}
+		response.Checklist = &checklist
+	}
+	if task.Attachments != nil {
+		attachments := make([]AttachmentResponse, 0, len(task.Attachments))
+		for _, attachment := range task.Attachments {
+			attachments = append(attachments, newAttachmentResponse(attachment, task))
+		}
+		response.Attachments = &attachments
+	}
+	return response
+}
+
+// taskWorkflow builds the task status workflow from the active configuration
+func taskWorkflow() (*models.Workflow, error) {
+	cfg := config.Get()
+	return models.NewWorkflow(cfg.TaskStatuses, cfg.DefaultTaskStatus, cfg.TaskTransitions)
+}
+
+// validateTaskStatus checks status against the configured workflow for a status change
+// On failure it writes the error response (failMsg for configuration errors) and returns false
+func validateTaskStatus(w http.ResponseWriter, r *http.Request, status models.TaskStatus, failMsg string) (*models.Workflow, bool) {
+	workflow, err := taskWorkflow()
+	if err != nil {
+		log.Printf("Invalid task workflow configuration: %v", err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, failMsg)
+		return nil, false
+	}
+
+	if !workflow.IsValid(status) {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidStatus, "Invalid status. Use: "+workflow.StatusList())
+		return nil, false
+	}
+
+	return workflow, true
+}
+
+// validateTaskText checks title and description against the configured length limits
+// nil values aren't being set and always pass. Lengths are counted in runes so
+// multibyte characters (accents, emoji) count as one character each.
+// It writes a 400 response and returns false when a limit is exceeded.
+func validateTaskText(w http.ResponseWriter, r *http.Request, title, description *string) bool {
+	if fieldErr := taskTextError(title, description); fieldErr != nil {
+		writeFieldError(w, r, *fieldErr)
+		return false
+	}
+	return true
+}
+
+// taskTextError is validateTaskText without the response: nil when both fit
+func taskTextError(title, description *string) *TaskFieldError {
+	cfg := config.Get()
+	if title != nil && cfg.MaxTitleLength > 0 && utf8.RuneCountInString(*title) > cfg.MaxTitleLength {
+		return &TaskFieldError{"title", apierror.TitleTooLong, fmt.Sprintf("Title cannot be longer than %d characters", cfg.MaxTitleLength)}
+	}
+	if description != nil && cfg.MaxDescriptionLength > 0 && utf8.RuneCountInString(*description) > cfg.MaxDescriptionLength {
+		return &TaskFieldError{"description", apierror.DescriptionTooLong, fmt.Sprintf("Description cannot be longer than %d characters", cfg.MaxDescriptionLength)}
+	}
+	return nil
+}
+
+// taskIDFromPath extracts the task ID from paths like /api/tasks/123/history
+// suffix is the sub-resource part after the ID ("" for /api/tasks/123)
+func taskIDFromPath(path, suffix string) (uint, error) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(path, "/api/tasks/"), suffix)
+	taskID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(taskID), nil
 }
 
 // GetTasks handles GET /api/tasks - Get all tasks for authenticated user with pagination
@@ -55,8 +217,7 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 
 	// Only allow GET method
 	if r.Method != "GET" {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -64,36 +225,61 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
 		// This should never happen if middleware is working correctly
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found in context"})
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
 		return
 	}
 
 	// Parse pagination parameters from query string
 	// URL format: /api/tasks?page=2&page_size=10
 	query := r.URL.Query()
-	
-	// Default pagination values
-	page := 1
-	pageSize := 10 // Default page size
-	maxPageSize := 100 // Maximum allowed page size to prevent abuse
-
-	// Parse page parameter
-	if pageStr := query.Get("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	page, pageSize, ok := parsePageParams(w, r)
+	if !ok {
+		return
 	}
+	maxPageSize := config.Get().MaxPageSize
 
-	// Parse page_size parameter
-	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
-			pageSize = ps
-			// Enforce maximum page size to prevent performance issues
-			if pageSize > maxPageSize {
-				pageSize = maxPageSize
+	// ?ids=1,2,3 narrows the listing to those tasks, e.g. to refresh cached
+	// ones in one request. IDs the caller can't see are simply left out.
+	// With TASK_ID_FORMAT=uuid they're public IDs, like everywhere else.
+	var ids []any
+	idColumn := "id"
+	if usePublicTaskIDs() {
+		idColumn = "public_id"
+	}
+	if idsStr := query.Get("ids"); idsStr != "" {
+		for _, idStr := range strings.Split(idsStr, ",") {
+			idStr = strings.TrimSpace(idStr)
+			if usePublicTaskIDs() {
+				publicID, ok := normalizeClientID(idStr)
+				if !ok {
+					writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "ids must be a comma-separated list of task IDs")
+					return
+				}
+				ids = append(ids, publicID)
+				continue
+			}
+			id, err := strconv.ParseUint(idStr, 10, 32)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "ids must be a comma-separated list of task IDs")
+				return
 			}
+			ids = append(ids, uint(id))
 		}
+		if len(ids) > maxBatchSize {
+			writeError(w, r, http.StatusBadRequest, apierror.BatchTooLarge, fmt.Sprintf("Too many ids (maximum is %d)", maxBatchSize))
+			return
+		}
+		// Without an explicit page_size all requested tasks fit on one page
+		if query.Get("page_size") == "" {
+			pageSize = min(len(ids), maxPageSize)
+		}
+	}
+
+	// ?sort=position lists tasks in their manual order; see taskSortColumns for the rest
+	order, ok := taskSortOrder(query.Get("sort"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidSort, invalidSortMessage)
+		return
 	}
 
 	// Calculate offset for database query
@@ -101,66 +287,206 @@ func GetTasks(w http.ResponseWriter, r *http.Request) {
 	// Example: page 2 with size 10 = offset 10
 	offset := (page - 1) * pageSize
 
-	// Get database connection
-	db := database.GetDB()
-	
-	// Count total tasks for this user (needed for pagination metadata)
-	var total int64
-	if err := db.Model(&models.Task{}).Where("user_id = ?", user.UserID).Count(&total).Error; err != nil {
-		log.Printf("Failed to count tasks for user %d: %v", user.UserID, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch tasks"})
+	// ?scope=all (or the older ?shared=true) also lists tasks other users
+	// have shared with the caller
+	includeShared, ok := parseTaskScope(w, r)
+	if !ok {
+		return
+	}
+
+	// ?include=checklist,attachments adds those associations to every task
+	includes, ok := parseTaskIncludes(w, r)
+	if !ok {
+		return
+	}
+
+	// ?fields=id,title,status reads and returns only those fields
+	fields, ok := parseTaskFields(w, r)
+	if !ok {
 		return
 	}
 
+	// ?completed_after= and ?completed_before= list tasks completed in that range
+	completed, ok := parseCompletedRange(w, r)
+	if !ok {
+		return
+	}
+
+	// Get a database handle bound to this request (with query timeout)
+	// The listing is read from the read replica when there is one, so a task
+	// created a moment ago may be missing from it until the replica catches up
+	db, cancel := requestReadDB(r)
+	defer cancel()
+
+	// Nothing outside the caller's organization is listed (see visibleTasks),
+	// and ?ids= and the completion range narrow that further
+	scope := func(tx *gorm.DB) *gorm.DB {
+		tx = visibleTasks(db, user, includeShared)(tx)
+		if ids != nil {
+			tx = tx.Where(idColumn+" IN ?", ids)
+		}
+		return completed.scope(tx)
+	}
+
+	// ?snapshot= opts in to reusing the total counted for an earlier page
+	// (see snapshotTotal); a missing or expired token counts again and
+	// hands out a new one
+	snapshotToken := query.Get("snapshot")
+	useSnapshot := snapshotToken != "" && pageSnapshots != nil
+	listing := fmt.Sprintf("%d:%s:%t:%v:%s:%s", user.UserID, user.Role, includeShared, ids,
+		query.Get("completed_after"), query.Get("completed_before"))
+
+	// Count total tasks for this user (needed for pagination metadata)
+	total, cached := snapshotTotal(snapshotToken, listing)
+	if !cached {
+		if err := db.Model(&models.Task{}).Scopes(scope).Count(&total).Error; err != nil {
+			if writeQueryTimeout(w, r, err) {
+				return
+			}
+			log.Printf("Failed to count tasks for user %d: %v", user.UserID, err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch tasks")
+			return
+		}
+		if useSnapshot {
+			token, err := saveSnapshotTotal(listing, total)
+			if err != nil {
+				log.Printf("Failed to create pagination snapshot: %v", err)
+			}
+			snapshotToken = token
+		}
+	}
+
 	// Query tasks with pagination
 	// LIMIT controls how many records to return
 	// OFFSET controls how many records to skip
 	// ORDER BY ensures consistent ordering across pages
 	var tasks []models.Task
-	if err := db.Where("user_id = ?", user.UserID).
-		Order("created_at DESC"). // Most recent first
+	if err := db.Scopes(scope, preloadTaskIncludes(includes), selectTaskFields(fields)).
+		Order(order). // DEFAULT_TASK_SORT (newest first) unless ?sort= says otherwise
 		Limit(pageSize).
 		Offset(offset).
 		Find(&tasks).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
 		log.Printf("Failed to fetch tasks for user %d: %v", user.UserID, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to fetch tasks"})
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch tasks")
 		return
 	}
 
+	meta := newPaginationMeta(page, pageSize, total)
+	if useSnapshot {
+		meta.Snapshot = snapshotToken
+	}
+	writeTaskPage(w, r, tasks, fields, meta, newPaginationLinks(r, meta))
+}
+
+// parsePageParams reads ?page= and ?page_size= for a paginated listing
+// Page sizes come from DEFAULT_PAGE_SIZE / MAX_PAGE_SIZE. Absent parameters
+// use the defaults, but malformed ones are rejected with a 400 (and ok false)
+// so client bugs (e.g. page=0 from an off-by-one) don't go unnoticed.
+func parsePageParams(w http.ResponseWriter, r *http.Request) (page, pageSize int, ok bool) {
+	query := r.URL.Query()
+	cfg := config.Get()
+	page = 1
+	pageSize = cfg.DefaultPageSize
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		p, err := strconv.Atoi(pageStr)
+		if err != nil || p <= 0 {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidPagination, "page must be a positive integer")
+			return 0, 0, false
+		}
+		page = p
+	}
+
+	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
+		ps, err := strconv.Atoi(pageSizeStr)
+		if err != nil || ps <= 0 {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidPagination, "page_size must be a positive integer")
+			return 0, 0, false
+		}
+		// Enforce maximum page size to prevent performance issues
+		pageSize = min(ps, cfg.MaxPageSize)
+	}
+	return page, pageSize, true
+}
+
+// visibleTasks limits a query to the tasks a listing may show the user
+// Nothing outside the caller's organization is ever listed; admins see all
+// of the organization's tasks, everyone else only their own (and, with
+// includeShared, the ones shared with them)
+func visibleTasks(db *gorm.DB, user middleware.UserContext, includeShared bool) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("org_id = ?", user.OrgID)
+		if user.IsAdmin() {
+			return tx
+		}
+		if !includeShared {
+			return tx.Where("user_id = ?", user.UserID)
+		}
+		sharedIDs := db.Model(&models.TaskShare{}).Select("task_id").Where("shared_with_user_id = ?", user.UserID)
+		return tx.Where("user_id = ? OR id IN (?)", user.UserID, sharedIDs)
+	}
+}
+
+// parseTaskScope reads ?scope= for a task listing: "owned" (the default) or
+// "all", which adds the tasks shared with the caller
+// ?shared=true predates it and still means "all". It writes a 400 response
+// and returns ok false for any other scope.
+func parseTaskScope(w http.ResponseWriter, r *http.Request) (includeShared bool, ok bool) {
+	query := r.URL.Query()
+	switch query.Get("scope") {
+	case "":
+		return query.Get("shared") == "true", true
+	case "owned":
+		return false, true
+	case "all":
+		return true, true
+	default:
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidScope, "scope must be owned or all")
+		return false, false
+	}
+}
+
+// writeTaskPage sends one page of a task listing as a PaginatedTaskResponse
+// fields (from parseTaskFields) trims every task down to those fields; nil
+// sends them whole. links only appear in enveloped responses; nil leaves them out
+func writeTaskPage(w http.ResponseWriter, r *http.Request, tasks []models.Task, fields []string, meta PaginationMeta, links *PaginationLinks) {
 	// Convert models to response format
 	taskResponses := make([]TaskResponse, 0)
 	for _, task := range tasks {
-		taskResponses = append(taskResponses, TaskResponse{
-			ID:          task.ID,
-			Title:       task.Title,
-			Description: task.Description,
-			Status:      task.Status,
-			UserID:      task.UserID,
-			CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		})
+		taskResponses = append(taskResponses, newTaskResponse(task))
 	}
+	var data any = taskResponses
+	if fields != nil {
+		sparse := make([]map[string]any, 0, len(taskResponses))
+		for _, response := range taskResponses {
+			sparse = append(sparse, sparseTaskResponse(response, fields))
+		}
+		data = sparse
+	}
+	data = inUserTimezone(r, data)
 
-	// Calculate pagination metadata
-	// Total pages = ceiling(total / pageSize)
-	// In Go, integer division truncates, so we add (pageSize-1) to get ceiling effect
-	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
-	
-	// Check if there are more pages
-	hasNext := page < totalPages
-	hasPrev := page > 1
+	// Enveloped clients get the tasks under "data" and the pagination under "meta"
+	if wantsEnvelope(r) {
+		w.Header().Set("Content-Type", apierror.EnvelopeMediaType)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Envelope{
+			Data:  data,
+			Meta:  &meta,
+			Links: links,
+		})
+		return
+	}
 
-	// Create paginated response
-	response := PaginatedTaskResponse{
-		Tasks:      taskResponses,
-		Page:       page,
-		PageSize:   pageSize,
-		Total:      total,
-		TotalPages: totalPages,
-		HasNext:    hasNext,
-		HasPrev:    hasPrev,
+	// Create paginated response, shaped like PaginatedTaskResponse even when the tasks are sparse
+	response := struct {
+		Tasks any `json:"tasks"`
+		PaginationMeta
+	}{
+		Tasks:          data,
+		PaginationMeta: meta,
 	}
 
 	// Return paginated tasks
@@ -173,16 +499,14 @@ func GetTask(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method != "GET" {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Get authenticated user from context
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found in context"})
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
 		return
 	}
 
@@ -191,8 +515,7 @@ func GetTask(w http.ResponseWriter, r *http.Request) {
 	// We need to parse the ID from the path
 	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
 	if path == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Task ID is required"})
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Task ID is required")
 		return
 	}
 
@@ -200,37 +523,86 @@ func GetTask(w http.ResponseWriter, r *http.Request) {
 	// strconv.ParseUint converts string to unsigned integer
 	taskID, err := strconv.ParseUint(path, 10, 32) // base 10, 32-bit uint
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid task ID"})
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	// ?include=checklist,attachments adds those associations to the task
+	includes, ok := parseTaskIncludes(w, r)
+	if !ok {
 		return
 	}
 
-	// Get database connection
-	db := database.GetDB()
-	
-	// Find task by ID and user ID (for security)
-	// This ensures users can only access their own tasks
-	var task models.Task
-	if err := db.Where("id = ? AND user_id = ?", taskID, user.UserID).First(&task).Error; err != nil {
-		// Task not found or doesn't belong to user
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Task not found"})
+	// ?fields=id,title,status returns only those fields
+	// Unlike listings, the one row is still read (and cached) whole
+	fields, ok := parseTaskFields(w, r)
+	if !ok {
 		return
 	}
 
+	// Get a database handle bound to this request (with query timeout)
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Serve repeated reads from the cache when it's enabled (entries are per user)
+	task, cached := cachedTask(user.UserID, uint(taskID))
+	if !cached {
+		// Find the task if the user owns it or it's shared with them (for security)
+		// Any share, read or write, allows viewing, and admins can view every
+		// task in their organization
+		var err error
+		if task, err = findAccessibleTask(db, uint(taskID), user, false); err != nil {
+			// Task not found or not accessible to the user
+			writeTaskAccessError(w, r, err)
+			return
+		}
+		cacheTask(user.UserID, task)
+	}
+
+	// Associations aren't cached: they're read fresh, along with the task,
+	// once access has been checked
+	if len(includes) > 0 {
+		if err := db.Scopes(preloadTaskIncludes(includes)).First(&task, task.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				writeTaskAccessError(w, r, err) // Deleted in the meantime
+				return
+			}
+			if writeQueryTimeout(w, r, err) {
+				return
+			}
+			log.Printf("Failed to load the associations of task %d: %v", task.ID, err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch task")
+			return
+		}
+	}
+
 	// Convert to response format
-	response := TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      task.Status,
-		UserID:      task.UserID,
-		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	var response any = newTaskResponse(task)
+	if fields != nil {
+		response = sparseTaskResponse(newTaskResponse(task), fields)
+	}
+
+	// Serialize up front so the ETag can be derived from the exact representation
+	body, err := encodeResponse(r, response)
+	if err != nil {
+		log.Printf("Failed to encode task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch task")
+		return
+	}
+
+	// Let polling clients skip re-downloading an unchanged task
+	etag := weakETag(body)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified) // 304 - no body
+		return
 	}
 
+	if wantsEnvelope(r) {
+		w.Header().Set("Content-Type", apierror.EnvelopeMediaType)
+	}
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	w.Write(append(body, '\n'))
 }
 
 // CreateTask handles POST /api/tasks - Create a new task
@@ -238,204 +610,416 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method != "POST" {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Get authenticated user from context
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found in context"})
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
 		return
 	}
 
 	// Parse request body
 	var req CreateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	// Validate required fields
-	if strings.TrimSpace(req.Title) == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Title is required"})
+	// Validate the fields, the same way POST /api/tasks/validate does
+	metadata, fieldErrors, err := validateNewTask(&req)
+	if err != nil {
+		log.Printf("Invalid task workflow configuration: %v", err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to create task")
+		return
+	}
+	if len(fieldErrors) > 0 {
+		writeFieldError(w, r, fieldErrors[0])
 		return
 	}
 
-	// Validate status if provided
-	if req.Status != "" {
-		// Check if status is one of the valid values
-		validStatuses := []models.TaskStatus{
-			models.TaskStatusPending,
-			models.TaskStatusInProgress,
-			models.TaskStatusCompleted,
-		}
-		
-		valid := false
-		for _, validStatus := range validStatuses {
-			if req.Status == validStatus {
-				valid = true
-				break
-			}
+	// Get a database handle bound to this request (with query timeout)
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// A task synced before is returned as it is instead of being created again
+	if req.ExternalID != "" {
+		existing, err := findTaskByExternalID(db, req.ExternalID, user)
+		if err == nil {
+			writeResponse(w, r, http.StatusOK, newTaskResponse(existing)) // 200 OK
+			return
 		}
-		
-		if !valid {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid status. Use: pending, in_progress, or completed"})
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			if writeQueryTimeout(w, r, err) {
+				return
+			}
+			log.Printf("Failed to look up external ID %q for user %d: %v", req.ExternalID, user.UserID, err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to create task")
 			return
 		}
-	} else {
-		// Set default status if not provided
-		req.Status = models.TaskStatusPending
+	}
+
+	// With PROGRESS_COMPLETES_TASK a task created at 100% starts out completed,
+	// unless the request asks for another status
+	requested := req.Status
+	if requested == "" && progressCompletesTask(req.Progress) {
+		requested = models.TaskStatusCompleted
+	}
+	status, ok := newTaskStatus(w, r, db, user, requested)
+	if !ok {
+		return
 	}
 
 	// Create new task
 	task := models.Task{
 		Title:       req.Title,
 		Description: req.Description,
-		Status:      req.Status,
+		Status:      status,
 		UserID:      user.UserID, // Associate task with authenticated user
+		OrgID:       user.OrgID,  // Tasks live in their owner's organization
+		DueDate:     req.DueDate,
+		Color:       req.Color,
+		Progress:    uint8(req.Progress),
+		Visibility:  req.Visibility,
+		Metadata:    metadata,
 	}
+	completeTaskProgress(&task)
+	trackTaskCompletion(&task, "", time.Now())
+	if req.ExternalID != "" {
+		task.ExternalID = &req.ExternalID
+	}
+
+	// Check for non-fatal issues before saving, so the task isn't its own duplicate
+	warnings := taskWarnings(db, task, true, true)
 
-	// Save to database
-	db := database.GetDB()
-	if err := db.Create(&task).Error; err != nil {
-		log.Printf("Failed to create task: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to create task"})
+	if err := insertTask(db, user, &task); err != nil {
+		// A concurrent request with the same external ID may have created the
+		// task first (the unique index rejected this one); return that instead
+		if req.ExternalID != "" {
+			if existing, findErr := findTaskByExternalID(db, req.ExternalID, user); findErr == nil {
+				writeResponse(w, r, http.StatusOK, newTaskResponse(existing)) // 200 OK
+				return
+			}
+		}
+		writeInsertTaskError(w, r, err)
 		return
 	}
 
+	// Let the user's webhooks know (delivered in the background)
+	publishTaskEvents(db, task, models.WebhookEventTaskCreated)
+
 	// Convert to response format
-	response := TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      task.Status,
-		UserID:      task.UserID,
-		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	response := newTaskResponse(task)
+	response.Warnings = warnings
+
+	writeResponse(w, r, http.StatusCreated, response) // 201 Created
+}
+
+// newTaskStatus returns the status a new task gets, writing a response and returning false on failure
+// Precedence: the request's status, then the user's default_task_status
+// setting, then the workflow's global default
+func newTaskStatus(w http.ResponseWriter, r *http.Request, db *gorm.DB, user middleware.UserContext, requested models.TaskStatus) (models.TaskStatus, bool) {
+	// Load the configured status workflow
+	workflow, err := taskWorkflow()
+	if err != nil {
+		log.Printf("Invalid task workflow configuration: %v", err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to create task")
+		return "", false
 	}
 
-	w.WriteHeader(http.StatusCreated) // 201 Created
-	json.NewEncoder(w).Encode(response)
+	// Validate status if provided
+	if requested != "" {
+		// Check if status is one of the configured values
+		if !workflow.IsValid(requested) {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidStatus, "Invalid status. Use: "+workflow.StatusList())
+			return "", false
+		}
+		return requested, true
+	}
+
+	settings, err := loadUserSettings(db, user.UserID)
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return "", false
+		}
+		log.Printf("Failed to load settings for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to create task")
+		return "", false
+	}
+
+	// Set default status if not provided
+	// A preference the workflow no longer accepts (TASK_STATUSES changed
+	// since it was saved) is ignored rather than failing every create
+	if pref := settings.DefaultTaskStatus; pref != nil && workflow.IsValid(*pref) {
+		return *pref, true
+	}
+	return workflow.DefaultStatus, true
+}
+
+// insertTask saves a new task at the end of its owner's manual order
+// Nothing is saved if the user already has MAX_TASKS_PER_USER tasks, or
+// TASK_STATUS_LIMITS tasks with its status: the limit checks and the insert
+// share a transaction so concurrent creates can't exceed the caps together
+func insertTask(db *gorm.DB, user middleware.UserContext, task *models.Task) error {
+	cfg := config.Get()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := checkTaskLimit(tx, user.UserID, cfg); err != nil {
+			return err
+		}
+		if err := checkStatusLimit(tx, user.UserID, task.Status, cfg); err != nil {
+			return err
+		}
+		// New tasks go to the end of the owner's manual order
+		if err := tx.Model(&models.Task{}).Where("user_id = ?", user.UserID).
+			Select("COALESCE(MAX(position), 0) + 1").Scan(&task.Position).Error; err != nil {
+			return err
+		}
+		return tx.Create(task).Error
+	})
+}
+
+// writeInsertTaskError writes the response for an error from insertTask
+func writeInsertTaskError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errTaskLimitReached) {
+		writeError(w, r, http.StatusForbidden, apierror.TaskLimitReached,
+			fmt.Sprintf("Task limit reached: you can have at most %d tasks", config.Get().MaxTasksPerUser)) // 403 Forbidden
+		return
+	}
+	if writeStatusLimitError(w, r, err) {
+		return
+	}
+	if writeQueryTimeout(w, r, err) {
+		return
+	}
+	log.Printf("Failed to create task: %v", err)
+	writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to create task")
 }
 
-// UpdateTask handles PUT /api/tasks/{id} - Update existing task
+// UpdateTask handles PUT and PATCH /api/tasks/{id} - Update existing task
 func UpdateTask(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	if r.Method != "PUT" {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+	// PUT has always been a partial update, so PATCH is simply the same thing
+	if r.Method != "PUT" && r.Method != "PATCH" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Get authenticated user from context
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found in context"})
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
 		return
 	}
 
 	// Extract task ID from URL
 	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
 	if path == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Task ID is required"})
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Task ID is required")
 		return
 	}
 
 	taskID, err := strconv.ParseUint(path, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid task ID"})
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
 		return
 	}
 
 	// Parse request body
 	var req UpdateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// Reject oversized text, unknown colors and bad progress before loading anything
+	if !validateTaskText(w, r, req.Title.Value, req.Description.Value) {
+		return
+	}
+	if !validateTaskColor(w, r, req.Color.Value) {
+		return
+	}
+	if !validateTaskProgress(w, r, req.Progress) {
+		return
+	}
+	if !validateTaskVisibility(w, r, req.Visibility) {
 		return
 	}
 
-	// Find existing task
-	db := database.GetDB()
-	var task models.Task
-	if err := db.Where("id = ? AND user_id = ?", taskID, user.UserID).First(&task).Error; err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Task not found"})
+	// Concurrent updates of this task wait for (or are refused by) this one
+	unlock, ok := lockTaskForUpdate(w, r, uint(taskID))
+	if !ok {
 		return
 	}
+	defer unlock()
+
+	// Find existing task; the owner and users with a write share may change it
+	db, cancel := requestDB(r)
+	defer cancel()
+	task, err := findAccessibleTask(db, uint(taskID), user, true)
+	if err != nil {
+		writeTaskAccessError(w, r, err)
+		return
+	}
+
+	applyTaskUpdate(w, r, db, user, task, req)
+}
+
+// applyTaskUpdate changes task as req asks, saves it and writes the updated task
+// The caller has validated the text, color, progress and visibility of req and checked
+// that the user may change the task.
+func applyTaskUpdate(w http.ResponseWriter, r *http.Request, db *gorm.DB, user middleware.UserContext, task models.Task, req UpdateTaskRequest) {
+	// Remember the current status so the change can be recorded in the history
+	previousStatus := task.Status
 
 	// Update fields if provided (partial update)
-	// Using pointers allows us to distinguish between "not provided" and "empty string"
-	if req.Title != nil {
-		if strings.TrimSpace(*req.Title) == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(ErrorResponse{Error: "Title cannot be empty"})
+	// Optional distinguishes "not provided" from null and from "empty string"
+	if req.Title.Set {
+		if req.Title.Null() || strings.TrimSpace(*req.Title.Value) == "" {
+			writeError(w, r, http.StatusBadRequest, apierror.TitleRequired, "Title cannot be empty")
 			return
 		}
-		task.Title = *req.Title
+		task.Title = *req.Title.Value
 	}
 
-	if req.Description != nil {
-		task.Description = *req.Description
+	// Description and color aren't nullable columns: clearing them stores ""
+	if req.Description.Set {
+		task.Description = ""
+		if req.Description.Value != nil {
+			task.Description = *req.Description.Value
+		}
 	}
 
-	if req.Status != nil {
-		// Validate status
-		validStatuses := []models.TaskStatus{
-			models.TaskStatusPending,
-			models.TaskStatusInProgress,
-			models.TaskStatusCompleted,
+	if req.Color.Set {
+		task.Color = ""
+		if req.Color.Value != nil {
+			task.Color = *req.Color.Value
 		}
-		
-		valid := false
-		for _, validStatus := range validStatuses {
-			if *req.Status == validStatus {
-				valid = true
-				break
-			}
+	}
+
+	// Making a task public publishes it to anyone with the link, so neither
+	// write shares nor admins can do it for the owner
+	if req.Visibility.Set {
+		if task.UserID != user.UserID {
+			writeError(w, r, http.StatusForbidden, apierror.NotTaskOwner, "Only the task owner can change its visibility") // 403
+			return
+		}
+		task.Visibility = *req.Visibility.Value
+	}
+
+	// Metadata is shallow-merged: sent keys replace the task's (null removes
+	// one), the others are kept; "metadata": null clears all of it
+	if req.Metadata.Null() {
+		task.Metadata = nil
+	} else if req.Metadata.Set {
+		metadata, ok := mergeTaskMetadata(w, r, task.Metadata, *req.Metadata.Value)
+		if !ok {
+			return
+		}
+		task.Metadata = metadata
+	}
+
+	if req.DueDate.Set {
+		task.DueDate = req.DueDate.Value
+		// A new deadline deserves a new reminder
+		task.ReminderSent = false
+	}
+
+	if req.Status.Set {
+		if req.Status.Null() {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidStatus, "Status cannot be null")
+			return
+		}
+		status := *req.Status.Value
+
+		// Validate status against the configured workflow
+		workflow, ok := validateTaskStatus(w, r, status, "Failed to update task")
+		if !ok {
+			return
 		}
-		
-		if !valid {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid status. Use: pending, in_progress, or completed"})
+
+		// Enforce the allowed transitions (e.g. completed -> pending may be disallowed)
+		if !workflow.CanTransition(task.Status, status) {
+			writeError(w, r, http.StatusConflict, apierror.InvalidStatusTransition, "Cannot change status from "+string(task.Status)+" to "+string(status)) // 409 Conflict
 			return
 		}
-		
-		task.Status = *req.Status
+
+		task.Status = status
+	}
+
+	if req.Progress.Set {
+		task.Progress = uint8(*req.Progress.Value)
+
+		// With PROGRESS_COMPLETES_TASK reaching 100% completes the task, as if
+		// the request had asked for it, unless it set a status itself
+		if !req.Status.Set && task.Status != models.TaskStatusCompleted && progressCompletesTask(*req.Progress.Value) {
+			workflow, err := taskWorkflow()
+			if err != nil {
+				log.Printf("Invalid task workflow configuration: %v", err)
+				writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to update task")
+				return
+			}
+			if !workflow.CanTransition(task.Status, models.TaskStatusCompleted) {
+				writeError(w, r, http.StatusConflict, apierror.InvalidStatusTransition, "Cannot change status from "+string(task.Status)+" to "+string(models.TaskStatusCompleted)) // 409 Conflict
+				return
+			}
+			task.Status = models.TaskStatusCompleted
+		}
 	}
+	completeTaskProgress(&task)
+	trackTaskCompletion(&task, previousStatus, time.Now())
 
-	// Save updated task
-	if err := db.Save(&task).Error; err != nil {
+	// Only the fields this request changes are checked for non-fatal issues
+	warnings := taskWarnings(db, task, req.Title.Set, req.DueDate.Set)
+
+	// Save updated task together with its status-history entry
+	// Only real status changes are logged, not PUTs that keep the same status
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Moving into a status counts against the owner's limit for it, even
+		// when someone the task is shared with moves it
+		if task.Status != previousStatus {
+			if err := checkStatusLimit(tx, task.UserID, task.Status, config.Get()); err != nil {
+				return err
+			}
+		}
+		// The checklist counts are maintained by the checklist endpoints; saving
+		// the copy loaded above could undo a concurrent checklist change
+		if err := tx.Omit("checklist_total", "checklist_done").Save(&task).Error; err != nil {
+			return err
+		}
+		if task.Status == previousStatus {
+			return nil
+		}
+		return tx.Create(&models.TaskStatusHistory{
+			TaskID:     task.ID,
+			FromStatus: previousStatus,
+			ToStatus:   task.Status,
+			UserID:     user.UserID,
+		}).Error
+	})
+	if err != nil {
+		if writeStatusLimitError(w, r, err) {
+			return
+		}
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
 		log.Printf("Failed to update task: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to update task"})
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to update task")
 		return
 	}
 
+	// Make the next GetTask read the new version, for the owner and everyone it's shared with
+	forgetCachedTaskForAll(db, task)
+
+	// Let the user's webhooks know (delivered in the background)
+	publishTaskEvents(db, task, taskUpdateEvents(previousStatus, task)...)
+
 	// Convert to response format
-	response := TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      task.Status,
-		UserID:      task.UserID,
-		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	response := newTaskResponse(task)
+	response.Warnings = warnings
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeResponse(w, r, http.StatusOK, response)
 }
 
 // DeleteTask handles DELETE /api/tasks/{id} - Delete a task
@@ -443,51 +1027,73 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method != "DELETE" {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Get authenticated user from context
 	user, ok := middleware.GetUserFromContext(r)
 	if !ok {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "User not found in context"})
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
 		return
 	}
 
 	// Extract task ID from URL
 	path := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
 	if path == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Task ID is required"})
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Task ID is required")
 		return
 	}
 
 	taskID, err := strconv.ParseUint(path, 10, 32)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid task ID"})
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
 		return
 	}
 
-	// Find and delete task
-	db := database.GetDB()
-	var task models.Task
-	if err := db.Where("id = ? AND user_id = ?", taskID, user.UserID).First(&task).Error; err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Task not found"})
+	// ?dry_run=true checks the delete without doing it
+	dryRun, ok := parseDryRun(w, r)
+	if !ok {
+		return
+	}
+
+	// Find and delete task; the owner and users with a write share may delete it
+	db, cancel := requestDB(r)
+	defer cancel()
+	task, err := findAccessibleTask(db, uint(taskID), user, true)
+	if err != nil {
+		writeTaskAccessError(w, r, err)
+		return
+	}
+
+	// A dry run shows the task that would be deleted and leaves it alone
+	if dryRun {
+		writeResponse(w, r, http.StatusOK, DryRunDeleteResponse{DryRun: true, WouldDelete: newTaskResponse(task)})
 		return
 	}
 
-	// Soft delete the task (GORM sets deleted_at timestamp)
-	if err := db.Delete(&task).Error; err != nil {
+	// Soft delete the task (GORM sets deleted_at timestamp), unless
+	// TASK_HARD_DELETE asks to remove it for good
+	if config.Get().TaskHardDelete {
+		err = hardDeleteTask(r.Context(), db, task)
+	} else {
+		err = db.Delete(&task).Error
+	}
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
 		log.Printf("Failed to delete task: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to delete task"})
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to delete task")
 		return
 	}
 
+	// Stop serving the deleted task from the cache
+	forgetCachedTaskForAll(db, task)
+
+	// Tell the owner's live streams the task is gone
+	streamTaskEvent(task, taskEventDeleted)
+
 	// Return success with no content
 	w.WriteHeader(http.StatusNoContent) // 204 No Content
-}
\ No newline at end of file
+}
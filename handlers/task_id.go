@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// usePublicTaskIDs reports whether tasks are identified by their public UUID
+// (TASK_ID_FORMAT=uuid) instead of their sequential ID
+func usePublicTaskIDs() bool {
+	return config.Get().TaskIDFormat == "uuid"
+}
+
+// MarshalJSON writes the task with its public ID as "id" when
+// TASK_ID_FORMAT=uuid, and as it is otherwise
+func (t TaskResponse) MarshalJSON() ([]byte, error) {
+	type plain TaskResponse // Same fields, without this method
+	if !usePublicTaskIDs() || t.PublicID == "" {
+		return json.Marshal(plain(t))
+	}
+	// The outer id hides the embedded one
+	return json.Marshal(struct {
+		plain
+		ID string `json:"id"`
+	}{plain(t), t.PublicID})
+}
+
+// taskResponseID is a task's "id" as clients see it
+func taskResponseID(t TaskResponse) any {
+	if usePublicTaskIDs() && t.PublicID != "" {
+		return t.PublicID
+	}
+	return t.ID
+}
+
+// referencedTaskID is the "task_id" of responses about task, such as its
+// comments, as clients see it: the public UUID with TASK_ID_FORMAT=uuid
+func referencedTaskID(task models.Task) any {
+	return taskResponseID(TaskResponse{ID: task.ID, PublicID: task.PublicID})
+}
+
+// ResolvePublicTaskID lets /api/tasks/{id}/... handlers take public task IDs
+// With TASK_ID_FORMAT=uuid the {id} in the path must be a task's public ID:
+// it's looked up in the caller's organization and swapped for the task's
+// sequential ID before next runs, so the handlers keep working with those.
+// Sequential IDs are rejected as invalid, which is what keeps them from being
+// guessed. With the default format requests pass through untouched.
+func ResolvePublicTaskID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/api/tasks/")
+		if !ok || !usePublicTaskIDs() {
+			next(w, r)
+			return
+		}
+		publicID, suffix, _ := strings.Cut(rest, "/")
+
+		user, ok := middleware.GetUserFromContext(r)
+		if !ok {
+			writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+			return
+		}
+		// Public IDs are UUIDs, like client IDs
+		publicID, ok = normalizeClientID(publicID)
+		if !ok {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+			return
+		}
+
+		db, cancel := requestDB(r)
+		var ids []uint
+		err := db.Model(&models.Task{}).Where("public_id = ? AND org_id = ?", publicID, user.OrgID).Pluck("id", &ids).Error
+		cancel()
+		if err != nil {
+			writeTaskAccessError(w, r, err)
+			return
+		}
+		if len(ids) == 0 {
+			writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+			return
+		}
+
+		path := "/api/tasks/" + strconv.FormatUint(uint64(ids[0]), 10)
+		if suffix != "" {
+			path += "/" + suffix
+		}
+		resolved := r.Clone(r.Context())
+		resolved.URL.Path = path
+		resolved.URL.RawPath = ""
+		next(w, resolved)
+	}
+}
+
+// TaskRef is a task ID in a request body, such as the ids of batch requests
+// By default it's the task's sequential ID; with TASK_ID_FORMAT=uuid it's
+// the public UUID, and sequential IDs are rejected like in paths. Handlers
+// resolve refs with resolveTaskRefs and report them back as they were sent.
+type TaskRef struct {
+	ID       uint   // Sequential ID; 0 for a public ID no task in the organization has
+	PublicID string // Public ID as sent (normalized if it's a UUID); empty by default
+}
+
+func (t *TaskRef) UnmarshalJSON(data []byte) error {
+	if !usePublicTaskIDs() {
+		return json.Unmarshal(data, &t.ID)
+	}
+	if err := json.Unmarshal(data, &t.PublicID); err != nil {
+		return err
+	}
+	// Anything but a UUID is kept as sent: it matches no task
+	if publicID, ok := normalizeClientID(t.PublicID); ok {
+		t.PublicID = publicID
+	}
+	return nil
+}
+
+func (t TaskRef) MarshalJSON() ([]byte, error) {
+	if t.PublicID != "" {
+		return json.Marshal(t.PublicID)
+	}
+	return json.Marshal(t.ID)
+}
+
+// String is the ref as the client sent it, e.g. for error messages
+func (t TaskRef) String() string {
+	if t.PublicID != "" {
+		return t.PublicID
+	}
+	return strconv.FormatUint(uint64(t.ID), 10)
+}
+
+// resolveTaskRefs fills in the sequential IDs of refs given by public ID,
+// looking them up in the user's organization
+// Ownership isn't checked: the handlers do that with the sequential IDs.
+func resolveTaskRefs(db *gorm.DB, refs []TaskRef, user middleware.UserContext) error {
+	publicIDs := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.PublicID != "" {
+			publicIDs = append(publicIDs, ref.PublicID)
+		}
+	}
+	if len(publicIDs) == 0 {
+		return nil
+	}
+
+	var tasks []models.Task
+	if err := db.Select("id", "public_id").Where("public_id IN ? AND org_id = ?", publicIDs, user.OrgID).Find(&tasks).Error; err != nil {
+		return err
+	}
+	ids := make(map[string]uint, len(tasks))
+	for _, task := range tasks {
+		ids[task.PublicID] = task.ID
+	}
+	for i := range refs {
+		if refs[i].PublicID != "" {
+			refs[i].ID = ids[refs[i].PublicID]
+		}
+	}
+	return nil
+}
+
+// taskPublicIDs maps the given task IDs to their public IDs, deleted tasks
+// included, for responses that reference tasks by ID
+// It's nil without a query unless TASK_ID_FORMAT=uuid.
+func taskPublicIDs(db *gorm.DB, ids []uint) (map[uint]string, error) {
+	if !usePublicTaskIDs() || len(ids) == 0 {
+		return nil, nil
+	}
+
+	var tasks []models.Task
+	if err := db.Unscoped().Select("id", "public_id").Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	publicIDs := make(map[uint]string, len(tasks))
+	for _, task := range tasks {
+		publicIDs[task.ID] = task.PublicID
+	}
+	return publicIDs, nil
+}
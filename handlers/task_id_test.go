@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/storage"
+)
+
+// TestPublicTaskIDs tests TASK_ID_FORMAT=uuid: public IDs in responses and
+// paths, and sequential IDs being refused
+func TestPublicTaskIDs(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser("test-public-id")
+	other := env.createUser("test-public-id-other")
+
+	// Tasks created before the switch have a public ID as well
+	before := env.createTask(user, CreateTaskRequest{Title: "Older"})
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskIDFormat = "uuid"
+	})
+
+	// idOf returns the "id" of a task response as clients see it
+	idOf := func(rr *httptest.ResponseRecorder) any {
+		var task map[string]any
+		env.decode(rr, &task)
+		return task["id"]
+	}
+
+	rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", CreateTaskRequest{Title: "Newer"}), user))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	publicID, ok := idOf(rr).(string)
+	if _, valid := normalizeClientID(publicID); !ok || !valid {
+		t.Fatalf("Expected a UUID id, got %s", rr.Body.String())
+	}
+
+	get := ResolvePublicTaskID(GetTask)
+	rr = env.serve(get, asUser(env.newRequest("GET", "/api/tasks/"+publicID, nil), user))
+	if rr.Code != http.StatusOK || idOf(rr) != publicID {
+		t.Errorf("Expected the task by its public ID, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Sub-resources resolve the same way
+	rr = env.serve(ResolvePublicTaskID(UpdateTask), asUser(env.newRequest("PATCH", "/api/tasks/"+publicID, `{"status":"completed"}`), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	rr = env.serve(ResolvePublicTaskID(GetTaskHistory), asUser(env.newRequest("GET", "/api/tasks/"+publicID+"/history", nil), user))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the history by public ID, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	testCases := []struct {
+		name           string
+		path           string
+		user           middleware.UserContext
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"sequential IDs are refused", fmt.Sprintf("/api/tasks/%d", before.ID), user, http.StatusBadRequest, apierror.InvalidTaskID},
+		{"unknown public ID", "/api/tasks/00000000-0000-4000-8000-000000000000", user, http.StatusNotFound, apierror.TaskNotFound},
+		{"other users' tasks", "/api/tasks/" + publicID, other, http.StatusNotFound, apierror.TaskNotFound},
+	}
+	for _, tc := range testCases {
+		rr := env.serve(get, asUser(env.newRequest("GET", tc.path, nil), tc.user))
+		var errResp ErrorResponse
+		env.decode(rr, &errResp)
+		if rr.Code != tc.expectedStatus || errResp.Code != tc.expectedCode {
+			t.Errorf("%s: expected %d %s, got %d %s", tc.name, tc.expectedStatus, tc.expectedCode, rr.Code, errResp.Code)
+		}
+	}
+
+	// Lists, sparse fieldsets and GraphQL use the public ID too
+	var page struct {
+		Tasks []map[string]any `json:"tasks"`
+	}
+	env.decode(env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks?fields=title", nil), user)), &page)
+	for _, task := range page.Tasks {
+		if id, ok := task["id"].(string); !ok || len(id) != 36 {
+			t.Errorf("Expected a public ID in the list, got %v", task)
+		}
+	}
+	_, result := env.runGraphQL(user, fmt.Sprintf(`{ task(id: "%s") { id title } }`, publicID), nil)
+	if expected := fmt.Sprintf(`{"task":{"id":"%s","title":"Newer"}}`, publicID); string(result.Data) != expected {
+		t.Errorf("Expected %s from GraphQL, got %s %v", expected, result.Data, result.Errors)
+	}
+}
+
+// TestPublicTaskIDLists tests TASK_ID_FORMAT=uuid for IDs outside the path:
+// ?ids=, the ids of batch and reorder requests, and task_id in history and
+// notifications
+func TestPublicTaskIDLists(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser("test-public-ids")
+	other := env.createUser("test-public-ids-other")
+	first := env.createTask(user, CreateTaskRequest{Title: "First"})
+	second := env.createTask(user, CreateTaskRequest{Title: "Second"})
+	foreign := env.createTask(other, CreateTaskRequest{Title: "Foreign"})
+	env.tx.Create(&models.Notification{UserID: user.UserID, Type: models.NotificationTypeMention, TaskID: first.ID, ActorID: other.UserID})
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskIDFormat = "uuid"
+	})
+
+	publicID := func(task TaskResponse) string {
+		t.Helper()
+		var publicID string
+		if err := env.tx.Model(&models.Task{}).Where("id = ?", task.ID).Select("public_id").Scan(&publicID).Error; err != nil {
+			t.Fatalf("Failed to load public ID: %v", err)
+		}
+		return publicID
+	}
+	firstID, secondID, foreignID := publicID(first), publicID(second), publicID(foreign)
+	unknownID := "00000000-0000-4000-8000-000000000000"
+	serve := func(handler http.HandlerFunc, method, path string, body any) map[string]any {
+		t.Helper()
+		rr := env.serve(handler, asUser(env.newRequest(method, path, body), user))
+		if rr.Code != http.StatusOK && rr.Code != http.StatusMultiStatus {
+			t.Fatalf("%s %s: expected success, got %d: %s", method, path, rr.Code, rr.Body.String())
+		}
+		var response map[string]any
+		env.decode(rr, &response)
+		return response
+	}
+
+	// ?ids= takes public IDs, and only those
+	page := serve(GetTasks, "GET", "/api/tasks?ids="+strings.ToUpper(secondID)+","+foreignID, nil)
+	if tasks, _ := page["tasks"].([]any); len(tasks) != 1 || tasks[0].(map[string]any)["id"] != secondID {
+		t.Errorf("Expected only the second task, got %v", page["tasks"])
+	}
+	if rr := env.serve(GetTasks, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks?ids=%d", first.ID), nil), user)); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for sequential ?ids=, got %d", rr.Code)
+	}
+
+	// Batch updates report the IDs back the way they were sent
+	batch := serve(BatchUpdateTaskStatus, "POST", "/api/tasks/batch-status",
+		map[string]any{"ids": []string{firstID, foreignID, unknownID, "not-a-uuid", firstID}, "status": models.TaskStatusInProgress})
+	if updated := fmt.Sprint(batch["updated_ids"]); updated != fmt.Sprint([]any{firstID}) {
+		t.Errorf("Expected [%s] updated, got %s", firstID, updated)
+	}
+	if skipped := fmt.Sprint(batch["skipped"]); skipped != fmt.Sprint([]any{foreignID, unknownID, "not-a-uuid"}) {
+		t.Errorf("Expected the other IDs skipped, got %s", skipped)
+	}
+	items := serve(BatchUpdateTaskStatus, "POST", "/api/tasks/batch-status?atomic=false",
+		map[string]any{"ids": []string{secondID, unknownID}, "status": models.TaskStatusInProgress})
+	results, _ := items["results"].([]any)
+	if len(results) != 2 || results[0].(map[string]any)["id"] != secondID || results[1].(map[string]any)["status"] != float64(http.StatusNotFound) {
+		t.Errorf("Expected a result per public ID, got %v", items["results"])
+	}
+	rr := env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status", BatchStatusRequest{IDs: taskRefs(first.ID), Status: models.TaskStatusCompleted}), user))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for sequential batch ids, got %d", rr.Code)
+	}
+
+	// Reordering resolves them too, and names unknown ones as sent
+	rr = env.serve(ReorderTasks, asUser(env.newRequest("POST", "/api/tasks/reorder", map[string]any{"ids": []string{secondID, firstID}}), user))
+	var reordered []map[string]any
+	env.decode(rr, &reordered)
+	if rr.Code != http.StatusOK || len(reordered) != 2 || reordered[0]["id"] != secondID {
+		t.Errorf("Expected the tasks in their new order, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = env.serve(ReorderTasks, asUser(env.newRequest("POST", "/api/tasks/reorder", map[string]any{"ids": []string{firstID, foreignID}}), user))
+	if rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), foreignID) {
+		t.Errorf("Expected 404 naming %s, got %d: %s", foreignID, rr.Code, rr.Body.String())
+	}
+
+	// Responses that reference a task use its public ID
+	history := serve(ResolvePublicTaskID(GetTaskHistory), "GET", "/api/tasks/"+firstID+"/history", nil)
+	if history["task_id"] != firstID {
+		t.Errorf("Expected the history's task_id to be %s, got %v", firstID, history["task_id"])
+	}
+	notifications := serve(GetNotifications, "GET", "/api/notifications", nil)
+	if list, _ := notifications["notifications"].([]any); len(list) != 1 || list[0].(map[string]any)["task_id"] != firstID {
+		t.Errorf("Expected the notification's task_id to be %s, got %v", firstID, notifications["notifications"])
+	}
+}
+
+// TestPublicTaskIDSubresources tests that responses about a task's shares,
+// comments, checklist, attachments and time use its public ID as task_id
+// Not parallel: it sets TASK_ID_FORMAT and the attachment storage
+func TestPublicTaskIDSubresources(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.AttachmentAllowedTypes = []string{"text/plain"}
+	})
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	previous := attachmentStorage
+	SetAttachmentStorage(store)
+	t.Cleanup(func() { SetAttachmentStorage(previous) })
+
+	env := newTestEnv(t)
+	owner := env.createUser("test-public-sub-owner")
+	reader := env.createUser("test-public-sub-reader")
+	var task, other models.Task
+	for i, target := range []*models.Task{&task, &other} {
+		if err := env.tx.Where("id = ?", env.createTask(owner, CreateTaskRequest{Title: fmt.Sprintf("Task %d", i)}).ID).First(target).Error; err != nil {
+			t.Fatalf("Failed to load task: %v", err)
+		}
+	}
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskIDFormat = "uuid"
+	})
+	path := "/api/tasks/" + task.PublicID
+
+	// serve sends a request for the task's public path and decodes the response
+	serve := func(handler http.HandlerFunc, req *http.Request, expectedStatus int, response any) {
+		t.Helper()
+		rr := env.serve(ResolvePublicTaskID(handler), asUser(req, owner))
+		if rr.Code != expectedStatus {
+			t.Fatalf("%s %s: expected %d, got %d: %s", req.Method, req.URL.Path, expectedStatus, rr.Code, rr.Body.String())
+		}
+		env.decode(rr, response)
+	}
+	expectTaskID := func(what string, items ...map[string]any) {
+		t.Helper()
+		if len(items) == 0 {
+			t.Errorf("%s: expected a response", what)
+		}
+		for _, item := range items {
+			if item["task_id"] != task.PublicID {
+				t.Errorf("%s: expected task_id %s, got %v", what, task.PublicID, item["task_id"])
+			}
+		}
+	}
+
+	var item map[string]any
+	var list []map[string]any
+	serve(ShareTask, env.newRequest("POST", path+"/shares", ShareTaskRequest{UserID: reader.UserID}), http.StatusCreated, &item)
+	expectTaskID("share", item)
+	serve(GetTaskShares, env.newRequest("GET", path+"/shares", nil), http.StatusOK, &list)
+	expectTaskID("shares", list...)
+
+	serve(AddComment, env.newRequest("POST", path+"/comments", CommentRequest{Body: "Looks good"}), http.StatusCreated, &item)
+	expectTaskID("comment", item)
+	serve(GetComments, env.newRequest("GET", path+"/comments", nil), http.StatusOK, &list)
+	expectTaskID("comments", list...)
+
+	serve(AddChecklistItem, env.newRequest("POST", path+"/checklist", ChecklistItemRequest{Text: "Step one"}), http.StatusCreated, &item)
+	expectTaskID("checklist item", item)
+	serve(GetChecklist, env.newRequest("GET", path+"/checklist", nil), http.StatusOK, &list)
+	expectTaskID("checklist", list...)
+
+	serve(UploadTaskAttachment, env.uploadRequest(path+"/attachments", "file", "notes.txt", "text/plain", "hello"), http.StatusCreated, &item)
+	expectTaskID("attachment", item)
+	serve(GetTaskAttachments, env.newRequest("GET", path+"/attachments", nil), http.StatusOK, &list)
+	expectTaskID("attachments", list...)
+
+	started, ended := time.Now().Add(-time.Hour), time.Now()
+	serve(LogTimeEntry, env.newRequest("POST", path+"/time-entries", TimeEntryRequest{StartedAt: &started, EndedAt: &ended}), http.StatusCreated, &item)
+	expectTaskID("time entry", item)
+	serve(StartTimer, env.newRequest("POST", path+"/timer/start", nil), http.StatusCreated, &item)
+	expectTaskID("started timer", item)
+	serve(GetTimeEntries, env.newRequest("GET", path+"/time-entries", nil), http.StatusOK, &list)
+	expectTaskID("time entries", list...)
+
+	// A timer running on the task is named by its public ID from another task
+	var errResp ErrorResponse
+	serve(StartTimer, env.newRequest("POST", "/api/tasks/"+other.PublicID+"/timer/start", nil), http.StatusConflict, &errResp)
+	if !strings.Contains(errResp.Error, task.PublicID) || strings.Contains(errResp.Error, fmt.Sprintf(" %d;", task.ID)) {
+		t.Errorf("Expected the running timer's task named by its public ID, got %q", errResp.Error)
+	}
+	serve(StopTimer, env.newRequest("POST", path+"/timer/stop", nil), http.StatusOK, &item)
+	expectTaskID("stopped timer", item)
+
+	// So do the associations loaded with ?include=
+	var included struct {
+		Checklist   []map[string]any `json:"checklist"`
+		Attachments []map[string]any `json:"attachments"`
+	}
+	serve(GetTask, env.newRequest("GET", path+"?include=checklist,attachments", nil), http.StatusOK, &included)
+	expectTaskID("included checklist", included.Checklist...)
+	expectTaskID("included attachments", included.Attachments...)
+}
+
+// taskRefs refers to tasks by their sequential IDs in request bodies
+func taskRefs(ids ...uint) []TaskRef {
+	refs := make([]TaskRef, 0, len(ids))
+	for _, id := range ids {
+		refs = append(refs, TaskRef{ID: id})
+	}
+	return refs
+}
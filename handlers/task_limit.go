@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errTaskLimitReached means the user already owns MAX_TASKS_PER_USER tasks
+var errTaskLimitReached = errors.New("task limit reached")
+
+// checkTaskLimit returns errTaskLimitReached if the user can't own another task
+// It must run in the transaction that creates the task: it locks the user's
+// row first, so concurrent creates for the same user wait for each other and
+// each one counts the tasks the previous one committed. Without the lock two
+// requests could both count limit-1 and both insert.
+func checkTaskLimit(tx *gorm.DB, userID uint, cfg *config.Config) error {
+	if cfg.MaxTasksPerUser <= 0 {
+		return nil
+	}
+
+	var owner models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&owner, userID).Error; err != nil {
+		return err
+	}
+
+	// Soft-deleted tasks are skipped by GORM's default scope unless configured to count
+	query := tx.Model(&models.Task{})
+	if cfg.TaskLimitCountDeleted {
+		query = query.Unscoped()
+	}
+	var count int64
+	if err := query.Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= int64(cfg.MaxTasksPerUser) {
+		return errTaskLimitReached
+	}
+	return nil
+}
+
+// statusLimitError means the task's owner already has TASK_STATUS_LIMITS tasks
+// with the status
+type statusLimitError struct {
+	Status models.TaskStatus
+	Limit  int
+}
+
+func (e *statusLimitError) Error() string {
+	return fmt.Sprintf("status limit reached: at most %d %s tasks", e.Limit, e.Status)
+}
+
+// message is the STATUS_LIMIT_REACHED error message
+func (e *statusLimitError) message() string {
+	return fmt.Sprintf("Status limit reached: there can be at most %d %s tasks", e.Limit, e.Status)
+}
+
+// checkStatusLimit returns a *statusLimitError if the owner can't have another
+// task with status
+// Like checkTaskLimit it locks the owner's row and must run in the
+// transaction that saves the task, so concurrent moves into the same status
+// can't exceed the limit together. Soft-deleted tasks never count.
+func checkStatusLimit(tx *gorm.DB, ownerID uint, status models.TaskStatus, cfg *config.Config) error {
+	room, limit, err := statusLimitRoom(tx, ownerID, status, cfg)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && room <= 0 {
+		return &statusLimitError{Status: status, Limit: limit}
+	}
+	return nil
+}
+
+// statusLimitRoom returns how many more tasks the owner may move into status,
+// and its limit; a limit of 0 means the status isn't limited (room is then 0 too)
+// It locks the owner's row like checkStatusLimit.
+func statusLimitRoom(tx *gorm.DB, ownerID uint, status models.TaskStatus, cfg *config.Config) (room, limit int, err error) {
+	limit = cfg.TaskStatusLimit(string(status))
+	if limit <= 0 {
+		return 0, 0, nil
+	}
+
+	var owner models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&owner, ownerID).Error; err != nil {
+		return 0, 0, err
+	}
+
+	var count int64
+	if err := tx.Model(&models.Task{}).Where("user_id = ? AND status = ?", ownerID, status).Count(&count).Error; err != nil {
+		return 0, 0, err
+	}
+	return limit - int(count), limit, nil
+}
+
+// writeStatusLimitError writes the 409 for a *statusLimitError in err and
+// reports whether it did
+func writeStatusLimitError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var limitErr *statusLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	writeError(w, r, http.StatusConflict, apierror.StatusLimitReached, limitErr.message()) // 409 Conflict
+	return true
+}
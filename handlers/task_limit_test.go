@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestTaskLimit tests capping how many tasks a user can have
+// Not parallel: it sets MAX_TASKS_PER_USER
+func TestTaskLimit(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.MaxTasksPerUser = 2
+		cfg.TaskLimitCountDeleted = false
+	})
+	env := newTestEnv(t)
+	user := env.createUser("test-limit")
+	other := env.createUser("test-limit-other")
+
+	create := func(user middleware.UserContext) (int, apierror.Code) {
+		rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", CreateTaskRequest{Title: "Task"}), user))
+		var response ErrorResponse
+		if rr.Code != http.StatusCreated {
+			env.decode(rr, &response)
+		}
+		return rr.Code, response.Code
+	}
+
+	first := env.createTask(user, CreateTaskRequest{Title: "First"})
+	env.createTask(user, CreateTaskRequest{Title: "Second"})
+	if status, code := create(user); status != http.StatusForbidden || code != apierror.TaskLimitReached {
+		t.Fatalf("Expected 403 %s at the limit, got %d %s", apierror.TaskLimitReached, status, code)
+	}
+
+	// The cap is per user
+	if status, _ := create(other); status != http.StatusCreated {
+		t.Errorf("Expected another user to be unaffected, got %d", status)
+	}
+
+	// Deleting a task frees a slot, unless deleted tasks are configured to count
+	rr := env.serve(DeleteTask, asUser(env.newRequest("DELETE", fmt.Sprintf("/api/tasks/%d", first.ID), nil), user))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Failed to delete task: %d %s", rr.Code, rr.Body.String())
+	}
+
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskLimitCountDeleted = true
+	})
+	if status, _ := create(user); status != http.StatusForbidden {
+		t.Errorf("Expected deleted tasks to count with TASK_LIMIT_COUNT_DELETED, got %d", status)
+	}
+
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskLimitCountDeleted = false
+	})
+	if status, _ := create(user); status != http.StatusCreated {
+		t.Errorf("Expected the deleted task's slot to be free, got %d", status)
+	}
+}
+
+// TestStatusLimit tests capping how many tasks a user can have in a status
+// Not parallel: it sets TASK_STATUS_LIMITS
+func TestStatusLimit(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskStatusLimits = []string{"in_progress=2"}
+		cfg.TaskTransitions = nil
+	})
+	env := newTestEnv(t)
+	user := env.createUser("test-status-limit")
+	other := env.createUser("test-status-limit-other")
+
+	expectLimit := func(rr *httptest.ResponseRecorder) {
+		t.Helper()
+		var response ErrorResponse
+		env.decode(rr, &response)
+		if rr.Code != http.StatusConflict || response.Code != apierror.StatusLimitReached {
+			t.Fatalf("Expected 409 %s, got %d %s", apierror.StatusLimitReached, rr.Code, response.Code)
+		}
+		if !strings.Contains(response.Error, "in_progress") {
+			t.Errorf("Expected the message to name the status, got %q", response.Error)
+		}
+	}
+	move := func(task TaskResponse, status models.TaskStatus) *httptest.ResponseRecorder {
+		return env.serve(UpdateTask, asUser(env.newRequest("PATCH", fmt.Sprintf("/api/tasks/%d", task.ID), map[string]any{"status": status}), user))
+	}
+
+	first := env.createTask(user, CreateTaskRequest{Title: "First", Status: models.TaskStatusInProgress})
+	env.createTask(user, CreateTaskRequest{Title: "Second", Status: models.TaskStatusInProgress})
+	pending := env.createTask(user, CreateTaskRequest{Title: "Pending"})
+
+	// Creating into the full status is refused, other statuses aren't limited
+	expectLimit(env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", CreateTaskRequest{Title: "Third", Status: models.TaskStatusInProgress}), user)))
+
+	// So is moving a task into it; updates that keep the status still work
+	expectLimit(move(pending, models.TaskStatusInProgress))
+	if rr := move(first, models.TaskStatusInProgress); rr.Code != http.StatusOK {
+		t.Errorf("Expected an update within the status to succeed, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// The limit is per user
+	env.createTask(other, CreateTaskRequest{Title: "Other", Status: models.TaskStatusInProgress})
+
+	// Moving a task out frees a slot, and so does deleting one
+	if rr := move(first, models.TaskStatusCompleted); rr.Code != http.StatusOK {
+		t.Fatalf("Failed to complete task: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := move(pending, models.TaskStatusInProgress); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the freed slot to be usable, got %d %s", rr.Code, rr.Body.String())
+	}
+	rr := env.serve(DeleteTask, asUser(env.newRequest("DELETE", fmt.Sprintf("/api/tasks/%d", pending.ID), nil), user))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Failed to delete task: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// A batch moves in only as many tasks as there is room for
+	a := env.createTask(user, CreateTaskRequest{Title: "A"})
+	b := env.createTask(user, CreateTaskRequest{Title: "B"})
+	rr = env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status", BatchStatusRequest{
+		IDs: taskRefs(a.ID, b.ID), Status: models.TaskStatusInProgress,
+	}), user))
+	var batch BatchStatusResponse
+	env.decode(rr, &batch)
+	if batch.Updated != 1 || len(batch.Skipped) != 1 || batch.Skipped[0].ID != b.ID {
+		t.Errorf("Expected A moved and B skipped, got %+v", batch)
+	}
+}
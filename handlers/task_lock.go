@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+)
+
+// taskLock serializes the updates of one task
+type taskLock struct {
+	held chan struct{} // Holds a value while an update runs; a channel so waiting can be abandoned
+	refs int           // Updates holding or waiting for the lock, guarded by taskLocksMu
+}
+
+var (
+	// taskLocks are the locks of the tasks being updated right now, by task ID
+	// Entries are removed once no update holds or waits for them, so the map
+	// only ever has the tasks in flight.
+	taskLocks   = make(map[uint]*taskLock)
+	taskLocksMu sync.Mutex
+
+	// How many updates had to wait, and how many were refused, for metrics
+	taskUpdatesWaited   atomic.Int64
+	taskUpdatesRejected atomic.Int64
+)
+
+// TaskUpdateLockStats returns how many task updates waited for another
+// update of the same task (TASK_UPDATE_LOCK=wait), and how many were refused
+// because of one (reject), since the process started
+func TaskUpdateLockStats() (waited, rejected int64) {
+	return taskUpdatesWaited.Load(), taskUpdatesRejected.Load()
+}
+
+// errTaskUpdateInProgress is acquireTaskLock's answer with TASK_UPDATE_LOCK=reject
+// when another update of the task is running
+var errTaskUpdateInProgress = errors.New("task is being updated by another request")
+
+// lockTaskForUpdate takes the in-process lock of a task before it's loaded and changed
+// With TASK_UPDATE_LOCK=wait a concurrent update of the same task waits for
+// the running one, so it reads the task as that one saved it instead of
+// overwriting it; with reject it gets 409 instead. Other tasks are never
+// held up. The lock only covers this process, so it's a safeguard, not a
+// guarantee, when several instances run.
+//
+// On success the caller must call unlock (usually via defer, so a panic
+// releases it as well). Otherwise a response has been written.
+func lockTaskForUpdate(w http.ResponseWriter, r *http.Request, taskID uint) (unlock func(), ok bool) {
+	return lockTasksForUpdate(w, r, []uint{taskID})
+}
+
+// lockTasksForUpdate is lockTaskForUpdate for several tasks, e.g. a batch update
+// The locks are taken in ID order, so two requests sharing tasks can't each
+// hold one the other waits for. Either all of them are taken or none.
+func lockTasksForUpdate(w http.ResponseWriter, r *http.Request, taskIDs []uint) (unlock func(), ok bool) {
+	ids := slices.Clone(taskIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids) // The same lock can't be taken twice
+
+	unlocks := make([]func(), 0, len(ids))
+	unlock = func() {
+		for _, unlock := range unlocks {
+			unlock()
+		}
+	}
+	for _, id := range ids {
+		unlockTask, err := acquireTaskLock(r.Context(), id)
+		if err != nil {
+			unlock()
+			writeTaskLockError(w, r, err)
+			return nil, false
+		}
+		unlocks = append(unlocks, unlockTask)
+	}
+	return unlock, true
+}
+
+// writeTaskLockError writes the response for an update that didn't get its task's lock
+func writeTaskLockError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errTaskUpdateInProgress) {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, http.StatusConflict, apierror.TaskUpdateInProgress, "Task is being updated by another request; try again") // 409
+		return
+	}
+	// The client went away, or REQUEST_TIMEOUT answered it, while waiting
+	writeQueryTimeout(w, r, err)
+}
+
+// acquireTaskLock takes the lock of one task as TASK_UPDATE_LOCK says
+// It returns errTaskUpdateInProgress when refused, or ctx's error when ctx
+// ended while waiting. On success the caller must call unlock.
+func acquireTaskLock(ctx context.Context, taskID uint) (unlock func(), err error) {
+	mode := config.Get().TaskUpdateLock
+	if mode == "" || mode == config.TaskUpdateLockOff {
+		return func() {}, nil
+	}
+
+	taskLocksMu.Lock()
+	lock := taskLocks[taskID]
+	if lock == nil {
+		lock = &taskLock{held: make(chan struct{}, 1)}
+		taskLocks[taskID] = lock
+	}
+	lock.refs++
+	taskLocksMu.Unlock()
+
+	release := func() {
+		taskLocksMu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(taskLocks, taskID)
+		}
+		taskLocksMu.Unlock()
+	}
+	unlock = func() {
+		<-lock.held
+		release()
+	}
+
+	select {
+	case lock.held <- struct{}{}:
+		return unlock, nil
+	default:
+	}
+
+	if mode == config.TaskUpdateLockReject {
+		release()
+		taskUpdatesRejected.Add(1)
+		return nil, errTaskUpdateInProgress
+	}
+
+	taskUpdatesWaited.Add(1)
+	select {
+	case lock.held <- struct{}{}:
+		return unlock, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// lockTaskRequest takes the update lock of a task for a bare request
+func lockTaskRequest(ctx context.Context, taskID uint) (*httptest.ResponseRecorder, func(), bool) {
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest("PATCH", "/api/tasks/1", nil).WithContext(ctx)
+	unlock, ok := lockTaskForUpdate(rr, r, taskID)
+	return rr, unlock, ok
+}
+
+// TestLockTaskForUpdate tests that updates of one task wait for each other
+// Not parallel: it sets TASK_UPDATE_LOCK
+func TestLockTaskForUpdate(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskUpdateLock = config.TaskUpdateLockWait
+	})
+	ctx := context.Background()
+
+	_, unlock, ok := lockTaskRequest(ctx, 1)
+	if !ok {
+		t.Fatal("Expected the first update to get the lock")
+	}
+
+	// Other tasks aren't held up
+	_, unlockOther, ok := lockTaskRequest(ctx, 2)
+	if !ok {
+		t.Fatal("Expected an update of another task to get its lock")
+	}
+	unlockOther()
+
+	waitedBefore, _ := TaskUpdateLockStats()
+	acquired := make(chan func())
+	go func() {
+		_, unlock, ok := lockTaskRequest(ctx, 1)
+		if ok {
+			acquired <- unlock
+		}
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Expected the second update to wait while the first holds the lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+
+	select {
+	case unlock, ok := <-acquired:
+		if !ok {
+			t.Fatal("Expected the second update to get the lock")
+		}
+		unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the second update to get the lock once the first released it")
+	}
+	if waited, _ := TaskUpdateLockStats(); waited != waitedBefore+1 {
+		t.Errorf("Expected one wait to be counted, got %d", waited-waitedBefore)
+	}
+
+	// A waiting request whose client goes away gives up
+	_, unlock, _ = lockTaskRequest(ctx, 1)
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	rr, _, ok := lockTaskRequest(cancelled, 1)
+	if ok || rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for a cancelled waiting request, got %v %d", ok, rr.Code)
+	}
+	unlock()
+
+	// Nothing is left behind once no update holds a lock
+	taskLocksMu.Lock()
+	left := len(taskLocks)
+	taskLocksMu.Unlock()
+	if left != 0 {
+		t.Errorf("Expected no task locks left, got %d", left)
+	}
+}
+
+// TestLockTaskForUpdateReject tests 409 for a concurrent update with TASK_UPDATE_LOCK=reject
+// Not parallel: it sets TASK_UPDATE_LOCK
+func TestLockTaskForUpdateReject(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskUpdateLock = config.TaskUpdateLockReject
+	})
+	ctx := context.Background()
+
+	_, unlock, ok := lockTaskRequest(ctx, 1)
+	if !ok {
+		t.Fatal("Expected the first update to get the lock")
+	}
+
+	_, rejectedBefore := TaskUpdateLockStats()
+	rr, _, ok := lockTaskRequest(ctx, 1)
+	var errResp ErrorResponse
+	json.NewDecoder(rr.Body).Decode(&errResp)
+	if ok || rr.Code != http.StatusConflict || errResp.Code != apierror.TaskUpdateInProgress {
+		t.Errorf("Expected 409 TASK_UPDATE_IN_PROGRESS, got %v %d %s", ok, rr.Code, errResp.Code)
+	}
+	if _, rejected := TaskUpdateLockStats(); rejected != rejectedBefore+1 {
+		t.Errorf("Expected one rejection to be counted, got %d", rejected-rejectedBefore)
+	}
+
+	// A panicking update still releases the lock through its deferred unlock
+	func() {
+		defer func() { recover() }()
+		defer unlock()
+		panic("update failed")
+	}()
+	_, unlock, ok = lockTaskRequest(ctx, 1)
+	if !ok {
+		t.Fatal("Expected the lock to be free after the panic")
+	}
+	unlock()
+}
+
+// TestLockTasksForUpdate tests that a batch gets the locks of all its tasks or none
+// Not parallel: it sets TASK_UPDATE_LOCK
+func TestLockTasksForUpdate(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskUpdateLock = config.TaskUpdateLockReject
+	})
+	ctx := context.Background()
+	r := httptest.NewRequest("POST", "/api/tasks/batch-status", nil)
+
+	_, unlockBusy, ok := lockTaskRequest(ctx, 2)
+	if !ok {
+		t.Fatal("Expected the single update to get the lock")
+	}
+
+	rr := httptest.NewRecorder()
+	if _, ok := lockTasksForUpdate(rr, r, []uint{3, 2, 1}); ok || rr.Code != http.StatusConflict {
+		t.Fatalf("Expected 409 while one of the tasks is busy, got %v %d", ok, rr.Code)
+	}
+	taskLocksMu.Lock()
+	_, heldOne := taskLocks[1]
+	taskLocksMu.Unlock()
+	if heldOne {
+		t.Error("Expected the locks taken before the busy task to be released")
+	}
+	unlockBusy()
+
+	// The same task listed twice takes its lock once
+	unlock, ok := lockTasksForUpdate(httptest.NewRecorder(), r, []uint{3, 2, 1, 3})
+	if !ok {
+		t.Fatal("Expected the batch to get every lock")
+	}
+	if _, _, ok := lockTaskRequest(ctx, 1); ok {
+		t.Error("Expected the batch to hold the lock of each of its tasks")
+	}
+	unlock()
+
+	taskLocksMu.Lock()
+	left := len(taskLocks)
+	taskLocksMu.Unlock()
+	if left != 0 {
+		t.Errorf("Expected no task locks left, got %d", left)
+	}
+}
+
+// TestTaskUpdateLockStatusChanges tests that reopening and batch status updates take the lock too
+// Not parallel: it sets TASK_UPDATE_LOCK
+func TestTaskUpdateLockStatusChanges(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskUpdateLock = config.TaskUpdateLockReject
+	})
+	env := newTestEnv(t)
+	user := env.createUser("test-lock-status")
+	busy := env.createTask(user, CreateTaskRequest{Title: "Busy", Status: models.TaskStatusCompleted})
+	free := env.createTask(user, CreateTaskRequest{Title: "Free"})
+
+	// As if a PATCH of the task were running
+	_, unlock, ok := lockTaskRequest(context.Background(), busy.ID)
+	if !ok {
+		t.Fatal("Expected to get the lock")
+	}
+	defer unlock()
+
+	rr := env.serve(ReopenTask, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/reopen", busy.ID), nil), user))
+	if rr.Code != http.StatusConflict || rr.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected reopening a busy task to get 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	body := BatchStatusRequest{IDs: taskRefs(free.ID, busy.ID), Status: models.TaskStatusInProgress}
+	rr = env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status", body), user))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected an atomic batch with a busy task to get 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var stored models.Task
+	env.tx.First(&stored, free.ID)
+	if stored.Status != models.TaskStatusPending {
+		t.Errorf("Expected the refused batch to change nothing, got %s", stored.Status)
+	}
+
+	rr = env.serve(BatchUpdateTaskStatus, asUser(env.newRequest("POST", "/api/tasks/batch-status?atomic=false", body), user))
+	var response BatchItemsResponse
+	env.decode(rr, &response)
+	if rr.Code != http.StatusMultiStatus || len(response.Results) != 2 {
+		t.Fatalf("Expected 207 with two results, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if response.Results[0].Status != http.StatusOK {
+		t.Errorf("Expected the free task to be updated, got %+v", response.Results[0])
+	}
+	if result := response.Results[1]; result.Status != http.StatusConflict || result.Error == nil || result.Error.Code != apierror.TaskUpdateInProgress {
+		t.Errorf("Expected 409 TASK_UPDATE_IN_PROGRESS for the busy task, got %+v", result)
+	}
+}
@@ -0,0 +1,731 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// createTask creates a task for user through the CreateTask handler
+func (e *testEnv) createTask(user middleware.UserContext, req CreateTaskRequest) TaskResponse {
+	e.t.Helper()
+
+	rr := e.serve(CreateTask, asUser(e.newRequest("POST", "/api/tasks", req), user))
+	if rr.Code != http.StatusCreated {
+		e.t.Fatalf("Failed to create task: status %d, body %s", rr.Code, rr.Body.String())
+	}
+
+	var task TaskResponse
+	e.decode(rr, &task)
+	return task
+}
+
+// TestCreateTaskHandler tests the task creation endpoint
+func TestCreateTaskHandler(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-create-task")
+
+	testCases := []struct {
+		name               string
+		requestBody        interface{}
+		expectedStatus     int
+		expectedTaskStatus models.TaskStatus // Status the created task should have
+	}{
+		{
+			name:               "valid task with status",
+			requestBody:        CreateTaskRequest{Title: "Write tests", Status: models.TaskStatusInProgress},
+			expectedStatus:     http.StatusCreated,
+			expectedTaskStatus: models.TaskStatusInProgress,
+		},
+		{
+			name:               "status defaults to pending",
+			requestBody:        CreateTaskRequest{Title: "No status"},
+			expectedStatus:     http.StatusCreated,
+			expectedTaskStatus: models.TaskStatusPending,
+		},
+		{
+			name:           "missing title",
+			requestBody:    CreateTaskRequest{Title: "   "},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid status",
+			requestBody:    CreateTaskRequest{Title: "Bad status", Status: "archived"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid JSON",
+			requestBody:    "not-json",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", tc.requestBody), user))
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tc.expectedStatus == http.StatusCreated {
+				var task TaskResponse
+				env.decode(rr, &task)
+
+				if task.ID == 0 {
+					t.Errorf("Expected task ID in response, got 0")
+				}
+				if task.UserID != user.UserID {
+					t.Errorf("Expected task owned by user %d, got %d", user.UserID, task.UserID)
+				}
+				if task.Status != tc.expectedTaskStatus {
+					t.Errorf("Expected status %s, got %s", tc.expectedTaskStatus, task.Status)
+				}
+			}
+		})
+	}
+}
+
+// TestGetTasksPagination tests listing tasks and the pagination metadata
+func TestGetTasksPagination(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-list-tasks")
+	other := env.createUser("test-list-other")
+
+	// 15 tasks for the user, plus one for somebody else that must never show up
+	for i := 1; i <= 15; i++ {
+		env.createTask(user, CreateTaskRequest{Title: fmt.Sprintf("Task %d", i)})
+	}
+	env.createTask(other, CreateTaskRequest{Title: "Other user's task"})
+
+	testCases := []struct {
+		name          string
+		query         string
+		expectedCount int
+		expectedPage  int
+		expectedTotal int64
+		expectedPages int
+		hasNext       bool
+		hasPrev       bool
+	}{
+		{"default page", "", 10, 1, 15, 2, true, false},
+		{"second page", "?page=2", 5, 2, 15, 2, false, true},
+		{"custom page size", "?page=2&page_size=4", 4, 2, 15, 4, true, true},
+		{"page past the end", "?page=999", 0, 999, 15, 2, false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks"+tc.query, nil), user))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var response PaginatedTaskResponse
+			env.decode(rr, &response)
+
+			if len(response.Tasks) != tc.expectedCount {
+				t.Errorf("Expected %d tasks, got %d", tc.expectedCount, len(response.Tasks))
+			}
+			if response.Page != tc.expectedPage {
+				t.Errorf("Expected page %d, got %d", tc.expectedPage, response.Page)
+			}
+			if response.Total != tc.expectedTotal {
+				t.Errorf("Expected total %d, got %d", tc.expectedTotal, response.Total)
+			}
+			if response.TotalPages != tc.expectedPages {
+				t.Errorf("Expected %d total pages, got %d", tc.expectedPages, response.TotalPages)
+			}
+			if response.HasNext != tc.hasNext || response.HasPrev != tc.hasPrev {
+				t.Errorf("Expected has_next=%t has_prev=%t, got %t/%t",
+					tc.hasNext, tc.hasPrev, response.HasNext, response.HasPrev)
+			}
+
+			for _, task := range response.Tasks {
+				if task.UserID != user.UserID {
+					t.Errorf("Got task %d owned by user %d", task.ID, task.UserID)
+				}
+			}
+		})
+	}
+}
+
+// TestGetTasksPastLastPage tests the links of a page past the end, and of one
+// past the end of an empty list
+func TestGetTasksPastLastPage(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-past-last-page")
+	empty := env.createUser("test-past-last-page-empty")
+	for i := 1; i <= 3; i++ {
+		env.createTask(user, CreateTaskRequest{Title: fmt.Sprintf("Task %d", i)})
+	}
+
+	var response Envelope
+	req := asUser(env.newRequest("GET", "/api/tasks?page=5&page_size=1", nil), user)
+	req.Header.Set("Accept", apierror.EnvelopeMediaType)
+	env.decode(env.serve(GetTasks, req), &response)
+	if response.Links == nil || response.Links.Next != "" || response.Links.Prev != "/api/tasks?page=3&page_size=1" {
+		t.Errorf("Expected only a prev link to the last page, got %+v", response.Links)
+	}
+
+	// There's no earlier page with anything on it
+	var page PaginatedTaskResponse
+	env.decode(env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks?page=2", nil), empty)), &page)
+	if len(page.Tasks) != 0 || page.TotalPages != 0 || page.HasNext || page.HasPrev {
+		t.Errorf("Expected an empty page without next or prev, got %+v", page)
+	}
+}
+
+// TestGetTasksInvalidPagination tests that malformed pagination parameters are rejected
+func TestGetTasksInvalidPagination(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-bad-pagination")
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{"page zero", "?page=0", http.StatusBadRequest},
+		{"negative page", "?page=-1", http.StatusBadRequest},
+		{"non-numeric page", "?page=abc", http.StatusBadRequest},
+		{"non-numeric page_size", "?page_size=foo", http.StatusBadRequest},
+		{"page_size zero", "?page_size=0", http.StatusBadRequest},
+		{"absent params use defaults", "", http.StatusOK},
+		{"empty params use defaults", "?page=&page_size=", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks"+tc.query, nil), user))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+// TestGetTasksByIDs tests narrowing the listing with ?ids=
+func TestGetTasksByIDs(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-ids")
+	other := env.createUser("test-ids-other")
+
+	var mine []TaskResponse
+	for i := 1; i <= 12; i++ {
+		mine = append(mine, env.createTask(user, CreateTaskRequest{Title: fmt.Sprintf("Task %d", i)}))
+	}
+	theirs := env.createTask(other, CreateTaskRequest{Title: "Other user's task"})
+
+	// Twelve IDs: more than the default page size, so they only fit on one page
+	// because page_size defaults to the number of IDs
+	var allIDs []string
+	for _, task := range mine {
+		allIDs = append(allIDs, fmt.Sprint(task.ID))
+	}
+	tooMany := strings.Repeat("1,", maxBatchSize) + "1"
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []uint
+	}{
+		{"subset", fmt.Sprintf("?ids=%d,%d", mine[0].ID, mine[2].ID), http.StatusOK, []uint{mine[2].ID, mine[0].ID}},
+		{"spaces around IDs", fmt.Sprintf("?ids=%d,%%20%d", mine[0].ID, mine[1].ID), http.StatusOK, []uint{mine[1].ID, mine[0].ID}},
+		{"other users' tasks are left out", fmt.Sprintf("?ids=%d,%d", mine[0].ID, theirs.ID), http.StatusOK, []uint{mine[0].ID}},
+		{"missing tasks are left out", "?ids=999999", http.StatusOK, []uint{}},
+		{"all fit on one page", "?ids=" + strings.Join(allIDs, ","), http.StatusOK, nil},
+		{"combined with pagination", fmt.Sprintf("?ids=%d,%d&page_size=1&page=2", mine[0].ID, mine[1].ID), http.StatusOK, []uint{mine[0].ID}},
+		{"non-numeric ID", "?ids=1,abc", http.StatusBadRequest, nil},
+		{"empty entry", "?ids=1,,2", http.StatusBadRequest, nil},
+		{"too many IDs", "?ids=" + tooMany, http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks"+tc.query, nil), user))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var response PaginatedTaskResponse
+			env.decode(rr, &response)
+			if tc.expectedIDs == nil {
+				if len(response.Tasks) != len(mine) || response.HasNext {
+					t.Errorf("Expected all %d tasks on one page, got %d (has_next=%t)", len(mine), len(response.Tasks), response.HasNext)
+				}
+				return
+			}
+			gotIDs := []uint{}
+			for _, task := range response.Tasks {
+				gotIDs = append(gotIDs, task.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tc.expectedIDs) {
+				t.Errorf("Expected tasks %v, got %v", tc.expectedIDs, gotIDs)
+			}
+		})
+	}
+}
+
+// TestGetTasksPageSizeConfig tests that the configured page sizes are applied
+// Not parallel: it overrides the global configuration
+func TestGetTasksPageSizeConfig(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.DefaultPageSize = 3
+		cfg.MaxPageSize = 5
+	})
+
+	env := newTestEnv(t)
+	user := env.createUser("test-page-size")
+	for i := 1; i <= 8; i++ {
+		env.createTask(user, CreateTaskRequest{Title: fmt.Sprintf("Task %d", i)})
+	}
+
+	testCases := []struct {
+		name             string
+		query            string
+		expectedPageSize int
+	}{
+		{"configured default", "", 3},
+		{"within max", "?page_size=4", 4},
+		{"over max is capped", "?page_size=50", 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks"+tc.query, nil), user))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var response PaginatedTaskResponse
+			env.decode(rr, &response)
+			if response.PageSize != tc.expectedPageSize {
+				t.Errorf("Expected page size %d, got %d", tc.expectedPageSize, response.PageSize)
+			}
+			if len(response.Tasks) != tc.expectedPageSize {
+				t.Errorf("Expected %d tasks, got %d", tc.expectedPageSize, len(response.Tasks))
+			}
+		})
+	}
+}
+
+// TestGetTasksStablePaging tests that tasks with identical timestamps page without duplicates or gaps
+func TestGetTasksStablePaging(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-stable-paging")
+
+	var ids []uint
+	for i := 1; i <= 7; i++ {
+		ids = append(ids, env.createTask(user, CreateTaskRequest{Title: "Same"}).ID)
+	}
+	// Created in the same instant, as bulk imports often are
+	same := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	env.tx.Model(&models.Task{}).Where("id IN ?", ids).UpdateColumns(map[string]interface{}{"created_at": same, "updated_at": same})
+
+	testCases := []struct {
+		name       string
+		sort       string
+		descending bool
+	}{
+		{"default", "", true},
+		{"created_at ascending", "created_at", false},
+		{"title descending", "-title", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var seen []uint
+			for page := 1; page <= 3; page++ {
+				path := fmt.Sprintf("/api/tasks?page=%d&page_size=3&sort=%s", page, tc.sort)
+				rr := env.serve(GetTasks, asUser(env.newRequest("GET", path, nil), user))
+				if rr.Code != http.StatusOK {
+					t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+				}
+				var response PaginatedTaskResponse
+				env.decode(rr, &response)
+				for _, task := range response.Tasks {
+					seen = append(seen, task.ID)
+				}
+			}
+
+			// Ties are broken by ID, in the same direction as the sort
+			expected := slices.Clone(ids)
+			if tc.descending {
+				slices.Reverse(expected)
+			}
+			if !slices.Equal(seen, expected) {
+				t.Errorf("Expected tasks %v across the pages, got %v", expected, seen)
+			}
+		})
+	}
+}
+
+// TestGetTasksDefaultSortConfig tests that DEFAULT_TASK_SORT applies when ?sort= is absent
+// Not parallel: it overrides the global configuration
+func TestGetTasksDefaultSortConfig(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.DefaultTaskSort = "title"
+	})
+
+	env := newTestEnv(t)
+	user := env.createUser("test-default-sort")
+	for _, title := range []string{"Banana", "Apple", "Cherry"} {
+		env.createTask(user, CreateTaskRequest{Title: title})
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"configured default", "", []string{"Apple", "Banana", "Cherry"}},
+		{"explicit sort wins", "?sort=-title", []string{"Cherry", "Banana", "Apple"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(GetTasks, asUser(env.newRequest("GET", "/api/tasks"+tc.query, nil), user))
+			var response PaginatedTaskResponse
+			env.decode(rr, &response)
+			var titles []string
+			for _, task := range response.Tasks {
+				titles = append(titles, task.Title)
+			}
+			if !slices.Equal(titles, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, titles)
+			}
+		})
+	}
+}
+
+// TestTaskTextLimits tests the title and description length limits
+// Not parallel: it overrides the global configuration
+func TestTaskTextLimits(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.MaxTitleLength = 5
+		cfg.MaxDescriptionLength = 10
+	})
+
+	env := newTestEnv(t)
+	user := env.createUser("test-text-limits")
+	task := env.createTask(user, CreateTaskRequest{Title: "Short"})
+	taskPath := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	// "🚀" is 4 bytes but one character, so five of them are exactly at the limit
+	emojiTitle := strings.Repeat("🚀", 5)
+
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		method         string
+		path           string
+		body           interface{}
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"title at the limit", CreateTask, "POST", "/api/tasks", CreateTaskRequest{Title: "12345"}, http.StatusCreated, ""},
+		{"title over the limit", CreateTask, "POST", "/api/tasks", CreateTaskRequest{Title: "123456"}, http.StatusBadRequest, apierror.TitleTooLong},
+		{"emoji title at the limit", CreateTask, "POST", "/api/tasks", CreateTaskRequest{Title: emojiTitle}, http.StatusCreated, ""},
+		{"emoji title over the limit", CreateTask, "POST", "/api/tasks", CreateTaskRequest{Title: emojiTitle + "🚀"}, http.StatusBadRequest, apierror.TitleTooLong},
+		{"description at the limit", CreateTask, "POST", "/api/tasks", CreateTaskRequest{Title: "x", Description: "ğüşöçıİ123"}, http.StatusCreated, ""},
+		{"description over the limit", CreateTask, "POST", "/api/tasks", CreateTaskRequest{Title: "x", Description: "12345678901"}, http.StatusBadRequest, apierror.DescriptionTooLong},
+		{"update title at the limit", UpdateTask, "PUT", taskPath, map[string]string{"title": emojiTitle}, http.StatusOK, ""},
+		{"update title over the limit", UpdateTask, "PUT", taskPath, map[string]string{"title": "123456"}, http.StatusBadRequest, apierror.TitleTooLong},
+		{"update description over the limit", UpdateTask, "PUT", taskPath, map[string]string{"description": "12345678901"}, http.StatusBadRequest, apierror.DescriptionTooLong},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(tc.handler, asUser(env.newRequest(tc.method, tc.path, tc.body), user))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tc.expectedCode == "" {
+				return
+			}
+
+			var response ErrorResponse
+			env.decode(rr, &response)
+			if response.Code != tc.expectedCode {
+				t.Errorf("Expected code %s, got %s", tc.expectedCode, response.Code)
+			}
+		})
+	}
+}
+
+// TestTaskErrorCodes tests the machine-readable codes of common task errors
+func TestTaskErrorCodes(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-error-codes")
+	task := env.createTask(user, CreateTaskRequest{Title: "Coded"})
+	taskPath := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		request        *http.Request
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"wrong method", GetTask, asUser(env.newRequest("POST", taskPath, nil), user), http.StatusMethodNotAllowed, apierror.MethodNotAllowed},
+		{"no user in context", GetTask, env.newRequest("GET", taskPath, nil), http.StatusUnauthorized, apierror.Unauthorized},
+		{"invalid task ID", GetTask, asUser(env.newRequest("GET", "/api/tasks/abc", nil), user), http.StatusBadRequest, apierror.InvalidTaskID},
+		{"task not found", GetTask, asUser(env.newRequest("GET", "/api/tasks/999999", nil), user), http.StatusNotFound, apierror.TaskNotFound},
+		{"invalid JSON", CreateTask, asUser(env.newRequest("POST", "/api/tasks", "not-json"), user), http.StatusBadRequest, apierror.InvalidJSON},
+		{"missing title", CreateTask, asUser(env.newRequest("POST", "/api/tasks", CreateTaskRequest{}), user), http.StatusBadRequest, apierror.TitleRequired},
+		{"invalid status", CreateTask, asUser(env.newRequest("POST", "/api/tasks", CreateTaskRequest{Title: "x", Status: "archived"}), user), http.StatusBadRequest, apierror.InvalidStatus},
+		{"invalid pagination", GetTasks, asUser(env.newRequest("GET", "/api/tasks?page=0", nil), user), http.StatusBadRequest, apierror.InvalidPagination},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(tc.handler, tc.request)
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			var response ErrorResponse
+			env.decode(rr, &response)
+			if response.Code != tc.expectedCode {
+				t.Errorf("Expected code %s, got %s", tc.expectedCode, response.Code)
+			}
+			if response.Error == "" {
+				t.Errorf("Expected a human-readable message alongside the code")
+			}
+		})
+	}
+}
+
+// TestLocalizedErrors tests that error messages follow Accept-Language while codes stay the same
+func TestLocalizedErrors(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-localized-errors")
+
+	testCases := []struct {
+		name            string
+		acceptLanguage  string
+		expectedMessage string
+	}{
+		{"default is English", "", "Task not found"},
+		{"English", "en-GB,en;q=0.9", "Task not found"},
+		{"Turkish", "tr-TR,tr;q=0.9,en;q=0.8", "Görev bulunamadı"},
+		{"unknown language falls back to English", "ja", "Task not found"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := asUser(env.newRequest("GET", "/api/tasks/999999", nil), user)
+			if tc.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+
+			rr := env.serve(GetTask, req)
+			if rr.Code != http.StatusNotFound {
+				t.Fatalf("Expected status 404, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var response ErrorResponse
+			env.decode(rr, &response)
+			if response.Error != tc.expectedMessage {
+				t.Errorf("Expected message %q, got %q", tc.expectedMessage, response.Error)
+			}
+			if response.Code != apierror.TaskNotFound {
+				t.Errorf("Expected code %s regardless of language, got %s", apierror.TaskNotFound, response.Code)
+			}
+		})
+	}
+}
+
+// TestGetTaskHandler tests fetching a single task, including ownership isolation
+func TestGetTaskHandler(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-get-owner")
+	intruder := env.createUser("test-get-intruder")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Private task"})
+
+	testCases := []struct {
+		name           string
+		user           middleware.UserContext
+		path           string
+		expectedStatus int
+	}{
+		{"owner", owner, fmt.Sprintf("/api/tasks/%d", task.ID), http.StatusOK},
+		{"other user gets 404", intruder, fmt.Sprintf("/api/tasks/%d", task.ID), http.StatusNotFound},
+		{"missing task", owner, "/api/tasks/999999", http.StatusNotFound},
+		{"invalid ID", owner, "/api/tasks/abc", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(GetTask, asUser(env.newRequest("GET", tc.path, nil), tc.user))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tc.expectedStatus == http.StatusOK {
+				var got TaskResponse
+				env.decode(rr, &got)
+				if got.ID != task.ID || got.Title != task.Title {
+					t.Errorf("Expected task %d %q, got %d %q", task.ID, task.Title, got.ID, got.Title)
+				}
+			}
+		})
+	}
+}
+
+// TestUpdateTaskHandler tests partial updates and ownership checks
+func TestUpdateTaskHandler(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-update-owner")
+	intruder := env.createUser("test-update-intruder")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Original", Description: "Keep me"})
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	testCases := []struct {
+		name           string
+		user           middleware.UserContext
+		requestBody    interface{}
+		expectedStatus int
+	}{
+		{"update title and status", owner, map[string]string{"title": "Updated", "status": "completed"}, http.StatusOK},
+		{"empty title", owner, map[string]string{"title": " "}, http.StatusBadRequest},
+		{"invalid status", owner, map[string]string{"status": "archived"}, http.StatusBadRequest},
+		{"other user gets 404", intruder, map[string]string{"title": "Hijacked"}, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(UpdateTask, asUser(env.newRequest("PUT", path, tc.requestBody), tc.user))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	// Only the successful update should have been applied
+	rr := env.serve(GetTask, asUser(env.newRequest("GET", path, nil), owner))
+	var got TaskResponse
+	env.decode(rr, &got)
+
+	if got.Title != "Updated" || got.Status != models.TaskStatusCompleted {
+		t.Errorf("Expected updated title and status, got %q/%s", got.Title, got.Status)
+	}
+	if got.Description != "Keep me" {
+		t.Errorf("Expected description to be untouched, got %q", got.Description)
+	}
+}
+
+// TestPatchTaskNullableFields tests that PATCH tells missing, null and set fields apart
+func TestPatchTaskNullableFields(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-patch-nullable")
+	due := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	task := env.createTask(user, CreateTaskRequest{Title: "Patch me", Description: "Details", DueDate: &due})
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	testCases := []struct {
+		name                string
+		requestBody         string
+		expectedStatus      int
+		expectedDueDate     string // "" when the task should have no due date
+		expectedDescription string
+	}{
+		{"missing leaves the due date alone", `{"title":"Renamed"}`, http.StatusOK, "2030-01-02T03:04:05Z", "Details"},
+		{"value sets the due date", `{"due_date":"2031-05-06T07:08:09Z"}`, http.StatusOK, "2031-05-06T07:08:09Z", "Details"},
+		{"null clears the due date", `{"due_date":null}`, http.StatusOK, "", "Details"},
+		{"null clears the description", `{"description":null}`, http.StatusOK, "", ""},
+		{"null title is rejected", `{"title":null}`, http.StatusBadRequest, "", ""},
+		{"null status is rejected", `{"status":null}`, http.StatusBadRequest, "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(UpdateTask, asUser(env.newRequest("PATCH", path, tc.requestBody), user))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var got TaskResponse
+			env.decode(rr, &got)
+			switch {
+			case tc.expectedDueDate == "" && got.DueDate != nil:
+				t.Errorf("Expected no due_date, got %s", got.DueDate)
+			case tc.expectedDueDate != "" && (got.DueDate == nil || got.DueDate.String() != tc.expectedDueDate):
+				t.Errorf("Expected due_date %s, got %v", tc.expectedDueDate, got.DueDate)
+			}
+			if got.Description != tc.expectedDescription {
+				t.Errorf("Expected description %q, got %q", tc.expectedDescription, got.Description)
+			}
+		})
+	}
+
+	// The rejected requests didn't touch the title
+	rr := env.serve(GetTask, asUser(env.newRequest("GET", path, nil), user))
+	var got TaskResponse
+	env.decode(rr, &got)
+	if got.Title != "Renamed" || got.Status != models.TaskStatusPending {
+		t.Errorf("Expected title Renamed and status pending, got %q/%s", got.Title, got.Status)
+	}
+}
+
+// TestDeleteTaskHandler tests deleting tasks and ownership checks
+func TestDeleteTaskHandler(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-delete-owner")
+	intruder := env.createUser("test-delete-intruder")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Delete me"})
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	// Another user can't delete the task
+	rr := env.serve(DeleteTask, asUser(env.newRequest("DELETE", path, nil), intruder))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404 for other user, got %d", rr.Code)
+	}
+
+	// The owner can
+	rr = env.serve(DeleteTask, asUser(env.newRequest("DELETE", path, nil), owner))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// And afterwards the task is gone
+	rr = env.serve(GetTask, asUser(env.newRequest("GET", path, nil), owner))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", rr.Code)
+	}
+
+	// Deleting again is a 404 as well
+	rr = env.serve(DeleteTask, asUser(env.newRequest("DELETE", path, nil), owner))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 on second delete, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kcansari/task-management-api/cache"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// taskCache holds recently fetched tasks for GetTask; nil when caching is disabled
+// Keys include the user ID, so a cached task is only ever served to the user
+// whose query loaded it.
+var taskCache *cache.LRU[models.Task]
+
+// EnableTaskCache turns on the GetTask cache with room for size tasks, each kept for ttl
+// main calls it at startup when TASK_CACHE_ENABLED is set
+func EnableTaskCache(size int, ttl time.Duration) {
+	taskCache = cache.New[models.Task](size, ttl)
+}
+
+// taskCacheKey scopes a cached task to the user who fetched it
+func taskCacheKey(userID, taskID uint) string {
+	return fmt.Sprintf("%d:%d", userID, taskID)
+}
+
+// cachedTask returns the user's task from the cache, if caching is on and it's there
+func cachedTask(userID, taskID uint) (models.Task, bool) {
+	if taskCache == nil {
+		return models.Task{}, false
+	}
+	return taskCache.Get(taskCacheKey(userID, taskID))
+}
+
+// cacheTask remembers a task fetched by userID
+func cacheTask(userID uint, task models.Task) {
+	if taskCache != nil {
+		taskCache.Set(taskCacheKey(userID, task.ID), task)
+	}
+}
+
+// forgetCachedTask evicts a task after it changes so the next GetTask reads it fresh
+func forgetCachedTask(userID, taskID uint) {
+	if taskCache != nil {
+		taskCache.Delete(taskCacheKey(userID, taskID))
+	}
+}
+
+// forgetCachedTaskForAll evicts task for its owner, everyone it's shared with
+// and the admins of its organization
+// Used after changes that any of them could otherwise keep reading from the cache
+func forgetCachedTaskForAll(db *gorm.DB, task models.Task) {
+	if taskCache == nil {
+		return
+	}
+
+	forgetCachedTask(task.UserID, task.ID)
+
+	var userIDs []uint
+	if err := db.Model(&models.TaskShare{}).Where("task_id = ?", task.ID).Pluck("shared_with_user_id", &userIDs).Error; err != nil {
+		log.Printf("Failed to load shares of task %d for cache eviction: %v", task.ID, err)
+		return
+	}
+
+	var adminIDs []uint
+	if err := db.Model(&models.User{}).Where("org_id = ? AND role = ?", task.OrgID, models.RoleAdmin).Pluck("id", &adminIDs).Error; err != nil {
+		log.Printf("Failed to load admins of task %d for cache eviction: %v", task.ID, err)
+		return
+	}
+
+	for _, userID := range append(userIDs, adminIDs...) {
+		forgetCachedTask(userID, task.ID)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestGetTaskCache tests that cached tasks are user-scoped and evicted by updates
+// Not parallel: it swaps the package-level cache
+func TestGetTaskCache(t *testing.T) {
+	EnableTaskCache(100, time.Minute)
+	t.Cleanup(func() { taskCache = nil })
+
+	env := newTestEnv(t)
+	owner := env.createUser("test-cache-owner")
+	intruder := env.createUser("test-cache-intruder")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Original"})
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	fetch := func(t *testing.T) (int, TaskResponse) {
+		t.Helper()
+		rr := env.serve(GetTask, asUser(env.newRequest("GET", path, nil), owner))
+		var response TaskResponse
+		if rr.Code == http.StatusOK {
+			env.decode(rr, &response)
+		}
+		return rr.Code, response
+	}
+
+	// The first read populates the cache
+	if code, response := fetch(t); code != http.StatusOK || response.Title != "Original" {
+		t.Fatalf("Expected 200 with the original title, got %d %q", code, response.Title)
+	}
+
+	// A change made behind the handlers' back isn't seen: the read is served from the cache
+	env.tx.Model(&models.Task{}).Where("id = ?", task.ID).UpdateColumn("title", "Changed directly")
+	if _, response := fetch(t); response.Title != "Original" {
+		t.Fatalf("Expected the cached title, got %q", response.Title)
+	}
+
+	// Another user never gets the owner's cached entry
+	rr := env.serve(GetTask, asUser(env.newRequest("GET", path, nil), intruder))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another user, got %d", rr.Code)
+	}
+
+	// Updating through the API busts the cache
+	rr = env.serve(UpdateTask, asUser(env.newRequest("PUT", path, map[string]string{"title": "Updated"}), owner))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected update to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, response := fetch(t); response.Title != "Updated" {
+		t.Errorf("Expected the updated title after the update, got %q", response.Title)
+	}
+
+	// So does deleting
+	rr = env.serve(DeleteTask, asUser(env.newRequest("DELETE", path, nil), owner))
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected delete to succeed, got %d", rr.Code)
+	}
+	if code, _ := fetch(t); code != http.StatusNotFound {
+		t.Errorf("Expected 404 after delete, got %d", code)
+	}
+}
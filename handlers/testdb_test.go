@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/middleware"
+	"gorm.io/gorm"
+)
+
+var (
+	// testDBOnce makes sure the test database is connected and migrated only once per run
+	testDBOnce sync.Once
+	testDBErr  error
+
+	// testEmailSeq keeps generated emails unique across parallel tests
+	testEmailSeq int64
+)
+
+// testEnv is an isolated database environment for one test
+// Every request created through it runs inside the test's own transaction,
+// which is rolled back when the test ends, so tests leave no residue and can
+// run with t.Parallel() without clobbering each other
+type testEnv struct {
+	t  *testing.T
+	tx *gorm.DB
+}
+
+// connectTestDB connects to the test database and applies the migrations
+// Set TEST_DB_NAME to keep tests away from the development database
+func connectTestDB() error {
+	testDBOnce.Do(func() {
+		cfg := *config.Get()
+		if name := os.Getenv("TEST_DB_NAME"); name != "" {
+			cfg.DBName = name
+		}
+
+		if testDBErr = database.Connect(&cfg); testDBErr != nil {
+			return
+		}
+
+		if cfg.DBAutoMigrate {
+			testDBErr = database.AutoMigrate()
+		} else {
+			testDBErr = database.RunMigrations()
+		}
+	})
+	return testDBErr
+}
+
+// newTestEnv starts a transaction for the test and rolls it back on cleanup
+// Tests are skipped (not failed) when no database is reachable
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	if err := connectTestDB(); err != nil {
+		t.Skipf("Test database unavailable: %v", err)
+	}
+
+	tx := database.GetDB().Begin()
+	if tx.Error != nil {
+		t.Fatalf("Failed to begin test transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+	})
+
+	return &testEnv{t: t, tx: tx}
+}
+
+// uniqueEmail returns an email address no other test in this run uses
+func uniqueEmail(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d@example.com", prefix, os.Getpid(), atomic.AddInt64(&testEmailSeq, 1))
+}
+
+// newRequest builds a request bound to the test transaction
+// body may be nil, a raw string (sent as-is) or any value to marshal as JSON
+func (e *testEnv) newRequest(method, path string, body interface{}) *http.Request {
+	e.t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			e.t.Fatalf("Failed to marshal request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req.WithContext(database.ContextWithDB(req.Context(), e.tx))
+}
+
+// serve runs handler against req and returns the recorded response
+func (e *testEnv) serve(handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
+
+// createUser registers a new user through the Register handler
+// They join the default organization as a member, like any user registering
+// without an organization name.
+// It returns the user as AuthMiddleware would put it in the request context
+func (e *testEnv) createUser(prefix string) middleware.UserContext {
+	e.t.Helper()
+	return e.register(RegisterRequest{Email: uniqueEmail(prefix), Password: "testpassword123"})
+}
+
+// createOrgAdmin registers a user who creates (and administers) a new organization
+func (e *testEnv) createOrgAdmin(prefix string) middleware.UserContext {
+	e.t.Helper()
+	email := uniqueEmail(prefix)
+	return e.register(RegisterRequest{Email: email, Password: "testpassword123", Organization: "org-" + email})
+}
+
+// register runs the Register handler and returns the new user's context
+func (e *testEnv) register(body RegisterRequest) middleware.UserContext {
+	e.t.Helper()
+
+	req := e.newRequest("POST", "/api/auth/register", body)
+	rr := e.serve(Register, req)
+	if rr.Code != http.StatusCreated {
+		e.t.Fatalf("Failed to register test user: status %d, body %s", rr.Code, rr.Body.String())
+	}
+
+	var response AuthResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		e.t.Fatalf("Failed to unmarshal register response: %v", err)
+	}
+	if response.Token == "" {
+		e.t.Fatalf("Expected a token for the test user")
+	}
+
+	return middleware.UserContext{
+		UserID: response.User.ID,
+		Email:  response.User.Email,
+		OrgID:  response.User.OrgID,
+		Role:   response.User.Role,
+	}
+}
+
+// asUser attaches an authenticated user to req, like AuthMiddleware does
+func asUser(req *http.Request, user middleware.UserContext) *http.Request {
+	ctx := context.WithValue(req.Context(), middleware.UserContextKey, user)
+	return req.WithContext(ctx)
+}
+
+// decode unmarshals a JSON response body into v
+func (e *testEnv) decode(rr *httptest.ResponseRecorder, v interface{}) {
+	e.t.Helper()
+	if err := json.Unmarshal(rr.Body.Bytes(), v); err != nil {
+		e.t.Fatalf("Failed to unmarshal response %q: %v", rr.Body.String(), err)
+	}
+}
+
+// withConfig applies change to a copy of the active configuration for the rest of the test
+// The previous configuration is restored on cleanup. Config is global, so tests
+// using this must not call t.Parallel()
+func withConfig(t *testing.T, change func(cfg *config.Config)) {
+	t.Helper()
+
+	previous := config.Get()
+	cfg := *previous
+	change(&cfg)
+	config.Set(&cfg)
+	t.Cleanup(func() {
+		config.Set(previous)
+	})
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TimeEntryRequest represents time spent on a task, logged after the fact
+type TimeEntryRequest struct {
+	StartedAt *time.Time `json:"started_at"` // RFC 3339 (required)
+	EndedAt   *time.Time `json:"ended_at"`   // RFC 3339, after started_at (required)
+}
+
+// TimeEntryResponse represents a time entry in API responses
+type TimeEntryResponse struct {
+	ID        uint       `json:"id"`
+	TaskID    any        `json:"task_id"` // The public UUID with TASK_ID_FORMAT=uuid
+	UserID    uint       `json:"user_id"` // Who spent the time
+	StartedAt Timestamp  `json:"started_at"`
+	EndedAt   *Timestamp `json:"ended_at"` // null while the timer runs
+	Seconds   int64      `json:"seconds"`  // 0 while the timer runs
+	CreatedAt Timestamp  `json:"created_at"`
+}
+
+// newTimeEntryResponse converts a time entry on task to its API representation
+func newTimeEntryResponse(entry models.TimeEntry, task models.Task) TimeEntryResponse {
+	return TimeEntryResponse{
+		ID:        entry.ID,
+		TaskID:    referencedTaskID(task),
+		UserID:    entry.UserID,
+		StartedAt: newTimestamp(entry.StartedAt),
+		EndedAt:   newOptionalTimestamp(entry.EndedAt),
+		Seconds:   entry.Seconds,
+		CreatedAt: newTimestamp(entry.CreatedAt),
+	}
+}
+
+// errTimerNotRunning means the caller has no running timer on the task
+var errTimerNotRunning = errors.New("timer not running")
+
+// findRunningTimer loads the user's running timer, on any task
+func findRunningTimer(db *gorm.DB, userID uint) (models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := db.Where("user_id = ? AND ended_at IS NULL", userID).First(&entry).Error
+	return entry, err
+}
+
+// writeTimerRunning writes the 409 for starting a timer while another one runs
+// With TASK_ID_FORMAT=uuid the task is named by its public ID, or not at all
+// if that can't be looked up.
+func writeTimerRunning(w http.ResponseWriter, r *http.Request, db *gorm.DB, running models.TimeEntry) {
+	message := fmt.Sprintf("A timer is already running on task %d; stop it first", running.TaskID)
+	if usePublicTaskIDs() {
+		message = "A timer is already running on another task; stop it first"
+		if publicIDs, err := taskPublicIDs(db, []uint{running.TaskID}); err == nil && publicIDs[running.TaskID] != "" {
+			message = fmt.Sprintf("A timer is already running on task %s; stop it first", publicIDs[running.TaskID])
+		}
+	}
+	writeError(w, r, http.StatusConflict, apierror.TimerAlreadyRunning, message) // 409 Conflict
+}
+
+// changeTimeEntries runs change inside a transaction on the locked task, then saves its time total
+// The task row is locked first, so concurrent changes take turns and the
+// total is never summed from a stale view; the sum is a single SUM query
+// over the finished entries. Like a checklist change it counts as a change to
+// the task, so updated_at moves as well. Access is checked by the caller.
+func changeTimeEntries(db *gorm.DB, taskID uint, change func(tx *gorm.DB, task models.Task) error) (models.Task, error) {
+	var task models.Task
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Unscoped: a timer may still be running on a task deleted since it started
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, taskID).Error; err != nil {
+			return err
+		}
+
+		if err := change(tx, task); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.TimeEntry{}).
+			Select("COALESCE(SUM(seconds), 0)").
+			Where("task_id = ? AND ended_at IS NOT NULL", task.ID).
+			Scan(&task.TotalTimeSeconds).Error; err != nil {
+			return err
+		}
+
+		task.UpdatedAt = time.Now()
+		return tx.Unscoped().Model(&task).UpdateColumns(map[string]interface{}{
+			"total_time_seconds": task.TotalTimeSeconds,
+			"updated_at":         task.UpdatedAt,
+		}).Error
+	})
+	return task, err
+}
+
+// timeEntriesChanged tells everyone who may hold the old task about its new time total
+func timeEntriesChanged(db *gorm.DB, task models.Task) {
+	forgetCachedTaskForAll(db, task)
+	if !task.DeletedAt.Valid {
+		publishTaskEvents(db, task, models.WebhookEventTaskUpdated)
+	}
+}
+
+// writeTimeEntryError writes the response for an error from changeTimeEntries
+func writeTimeEntryError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	if writeQueryTimeout(w, r, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, errTimerNotRunning):
+		writeError(w, r, http.StatusConflict, apierror.TimerNotRunning, "No timer of yours is running on this task") // 409 Conflict
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+	default:
+		log.Printf("Failed to %s: %v", action, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to "+action)
+	}
+}
+
+// GetTimeEntries handles GET /api/tasks/{id}/time-entries - List the time logged on a task
+// Anyone who can see the task sees all of its entries, oldest first,
+// including running timers.
+func GetTimeEntries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/time-entries
+	taskID, err := taskIDFromPath(r.URL.Path, "/time-entries")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	task, err := findAccessibleTask(db, taskID, user, false)
+	if err != nil {
+		writeTaskAccessError(w, r, err)
+		return
+	}
+
+	var entries []models.TimeEntry
+	if err := db.Where("task_id = ?", task.ID).Order("started_at ASC, id ASC").Find(&entries).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch time entries of task %d: %v", task.ID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch time entries")
+		return
+	}
+
+	response := make([]TimeEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, newTimeEntryResponse(entry, task))
+	}
+
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// LogTimeEntry handles POST /api/tasks/{id}/time-entries - Log time spent on a task after the fact
+// Only the owner and users the task is shared with for writing can log time.
+func LogTimeEntry(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/time-entries
+	taskID, err := taskIDFromPath(r.URL.Path, "/time-entries")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	// Parse request body
+	var req TimeEntryRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.StartedAt == nil || req.EndedAt == nil {
+		writeError(w, r, http.StatusBadRequest, apierror.TimeEntryTimesRequired, "started_at and ended_at are required")
+		return
+	}
+	if !req.EndedAt.After(*req.StartedAt) {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTimeRange, "ended_at must be after started_at")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	if _, err := findAccessibleTask(db, taskID, user, true); err != nil {
+		writeTaskAccessError(w, r, err)
+		return
+	}
+
+	entry := models.TimeEntry{
+		TaskID:    taskID,
+		UserID:    user.UserID,
+		StartedAt: *req.StartedAt,
+		EndedAt:   req.EndedAt,
+		Seconds:   int64(req.EndedAt.Sub(*req.StartedAt) / time.Second),
+	}
+	task, err := changeTimeEntries(db, taskID, func(tx *gorm.DB, task models.Task) error {
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		writeTimeEntryError(w, r, err, "log time")
+		return
+	}
+
+	timeEntriesChanged(db, task)
+	writeResponse(w, r, http.StatusCreated, newTimeEntryResponse(entry, task)) // 201 Created
+}
+
+// StartTimer handles POST /api/tasks/{id}/timer/start - Start timing work on a task
+// Each user has one timer at a time: starting a second one, on any task, is a
+// 409 until the first is stopped. A unique index on running timers backs the
+// check, so two concurrent starts can't both succeed. Only the owner and users
+// the task is shared with for writing can start one.
+func StartTimer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/timer/start
+	taskID, err := taskIDFromPath(r.URL.Path, "/timer/start")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	task, err := findAccessibleTask(db, taskID, user, true)
+	if err != nil {
+		writeTaskAccessError(w, r, err)
+		return
+	}
+
+	running, err := findRunningTimer(db, user.UserID)
+	if err == nil {
+		writeTimerRunning(w, r, db, running)
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		writeTimeEntryError(w, r, err, "start timer")
+		return
+	}
+
+	// A running timer doesn't count towards the total yet, so the task is left alone
+	entry := models.TimeEntry{TaskID: taskID, UserID: user.UserID, StartedAt: time.Now()}
+	if err := db.Create(&entry).Error; err != nil {
+		// A concurrent start may have won the race (the unique index rejected this one)
+		if running, findErr := findRunningTimer(db, user.UserID); findErr == nil {
+			writeTimerRunning(w, r, db, running)
+			return
+		}
+		writeTimeEntryError(w, r, err, "start timer")
+		return
+	}
+
+	writeResponse(w, r, http.StatusCreated, newTimeEntryResponse(entry, task)) // 201 Created
+}
+
+// StopTimer handles POST /api/tasks/{id}/timer/stop - Stop the caller's running timer on a task
+// The finished entry is added to the task's total. Users can always stop
+// their own timer, even if they lost access to the task since starting it.
+func StopTimer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/timer/stop
+	taskID, err := taskIDFromPath(r.URL.Path, "/timer/stop")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	var entry models.TimeEntry
+	task, err := changeTimeEntries(db, taskID, func(tx *gorm.DB, task models.Task) error {
+		if err := tx.Where("task_id = ? AND user_id = ? AND ended_at IS NULL", task.ID, user.UserID).First(&entry).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errTimerNotRunning
+			}
+			return err
+		}
+		endedAt := time.Now()
+		entry.EndedAt = &endedAt
+		entry.Seconds = int64(endedAt.Sub(entry.StartedAt) / time.Second)
+		return tx.Save(&entry).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errTimerNotRunning // No such task, so certainly no timer on it
+	}
+	if err != nil {
+		writeTimeEntryError(w, r, err, "stop timer")
+		return
+	}
+
+	timeEntriesChanged(db, task)
+	writeResponse(w, r, http.StatusOK, newTimeEntryResponse(entry, task))
+}
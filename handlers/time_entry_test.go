@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// TestTimeEntries tests logging time, the task's total and who may log it
+func TestTimeEntries(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-time-owner")
+	writer := env.createUser("test-time-writer")
+	reader := env.createUser("test-time-reader")
+	stranger := env.createUser("test-time-stranger")
+	task := env.createTask(owner, CreateTaskRequest{Title: "Timed"})
+	path := fmt.Sprintf("/api/tasks/%d/time-entries", task.ID)
+	sharesPath := fmt.Sprintf("/api/tasks/%d/shares", task.ID)
+	env.serve(ShareTask, asUser(env.newRequest("POST", sharesPath, ShareTaskRequest{UserID: writer.UserID, Permission: models.SharePermissionWrite}), owner))
+	env.serve(ShareTask, asUser(env.newRequest("POST", sharesPath, ShareTaskRequest{UserID: reader.UserID}), owner))
+
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	hour := map[string]string{"started_at": start.Format(time.RFC3339), "ended_at": start.Add(time.Hour).Format(time.RFC3339)}
+
+	testCases := []struct {
+		name           string
+		user           string
+		body           interface{}
+		expectedStatus int
+		expectedCode   apierror.Code
+	}{
+		{"owner", "owner", hour, http.StatusCreated, ""},
+		{"write share", "writer", map[string]string{"started_at": "2025-06-03T10:00:00Z", "ended_at": "2025-06-03T10:30:00Z"}, http.StatusCreated, ""},
+		{"read share", "reader", hour, http.StatusForbidden, apierror.TaskReadOnly},
+		{"no access", "stranger", hour, http.StatusNotFound, apierror.TaskNotFound},
+		{"missing ended_at", "owner", map[string]string{"started_at": "2025-06-03T10:00:00Z"}, http.StatusBadRequest, apierror.TimeEntryTimesRequired},
+		{"ends before it starts", "owner", map[string]string{"started_at": "2025-06-03T10:00:00Z", "ended_at": "2025-06-03T09:00:00Z"}, http.StatusBadRequest, apierror.InvalidTimeRange},
+		{"ends when it starts", "owner", map[string]string{"started_at": "2025-06-03T10:00:00Z", "ended_at": "2025-06-03T10:00:00Z"}, http.StatusBadRequest, apierror.InvalidTimeRange},
+	}
+	users := map[string]middleware.UserContext{"owner": owner, "writer": writer, "reader": reader, "stranger": stranger}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(LogTimeEntry, asUser(env.newRequest("POST", path, tc.body), users[tc.user]))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tc.expectedCode != "" {
+				var errResp ErrorResponse
+				env.decode(rr, &errResp)
+				if errResp.Code != tc.expectedCode {
+					t.Errorf("Expected code %s, got %s", tc.expectedCode, errResp.Code)
+				}
+			}
+		})
+	}
+
+	// The total is the sum of both entries, and the reader can see it and the entries
+	rr := env.serve(GetTask, asUser(env.newRequest("GET", fmt.Sprintf("/api/tasks/%d", task.ID), nil), reader))
+	var fetched TaskResponse
+	env.decode(rr, &fetched)
+	if fetched.TotalTimeSeconds != 5400 {
+		t.Errorf("Expected 5400 seconds in total, got %d", fetched.TotalTimeSeconds)
+	}
+
+	rr = env.serve(GetTimeEntries, asUser(env.newRequest("GET", path, nil), reader))
+	var entries []TimeEntryResponse
+	env.decode(rr, &entries)
+	if len(entries) != 2 || entries[0].UserID != owner.UserID || entries[0].Seconds != 3600 || entries[1].UserID != writer.UserID {
+		t.Errorf("Expected the owner's then the writer's entry, got %+v", entries)
+	}
+}
+
+// TestTimer tests starting and stopping timers
+func TestTimer(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-timer")
+	task := env.createTask(user, CreateTaskRequest{Title: "Timed"})
+	otherTask := env.createTask(user, CreateTaskRequest{Title: "Also timed"})
+	timerPath := func(id uint, action string) string { return fmt.Sprintf("/api/tasks/%d/timer/%s", id, action) }
+
+	rr := env.serve(StartTimer, asUser(env.newRequest("POST", timerPath(task.ID, "start"), nil), user))
+	var started TimeEntryResponse
+	env.decode(rr, &started)
+	if rr.Code != http.StatusCreated || started.EndedAt != nil {
+		t.Fatalf("Expected a running timer, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// One timer at a time, on any task
+	testCases := []struct {
+		name         string
+		handler      http.HandlerFunc
+		path         string
+		expectedCode apierror.Code
+	}{
+		{"start it again", StartTimer, timerPath(task.ID, "start"), apierror.TimerAlreadyRunning},
+		{"start on another task", StartTimer, timerPath(otherTask.ID, "start"), apierror.TimerAlreadyRunning},
+		{"stop on another task", StopTimer, timerPath(otherTask.ID, "stop"), apierror.TimerNotRunning},
+		{"stop on a missing task", StopTimer, timerPath(999999, "stop"), apierror.TimerNotRunning},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(tc.handler, asUser(env.newRequest("POST", tc.path, nil), user))
+			var errResp ErrorResponse
+			env.decode(rr, &errResp)
+			if rr.Code != http.StatusConflict || errResp.Code != tc.expectedCode {
+				t.Errorf("Expected 409 %s, got %d %s", tc.expectedCode, rr.Code, errResp.Code)
+			}
+		})
+	}
+
+	// The database itself refuses a second running timer (in a savepoint, so
+	// the failed insert doesn't abort the test's transaction)
+	err := env.tx.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&models.TimeEntry{TaskID: otherTask.ID, UserID: user.UserID, StartedAt: time.Now()}).Error
+	})
+	if err == nil {
+		t.Errorf("Expected the unique index to reject a second running timer")
+	}
+
+	// Backdate the start so stopping adds a known amount
+	env.tx.Model(&models.TimeEntry{}).Where("id = ?", started.ID).Update("started_at", time.Now().Add(-90*time.Second))
+	rr = env.serve(StopTimer, asUser(env.newRequest("POST", timerPath(task.ID, "stop"), nil), user))
+	var stopped TimeEntryResponse
+	env.decode(rr, &stopped)
+	if rr.Code != http.StatusOK || stopped.ID != started.ID || stopped.EndedAt == nil || stopped.Seconds < 90 || stopped.Seconds > 100 {
+		t.Fatalf("Expected the timer to stop after about 90 seconds, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	var saved models.Task
+	env.tx.First(&saved, task.ID)
+	if saved.TotalTimeSeconds != stopped.Seconds {
+		t.Errorf("Expected the total to be %d, got %d", stopped.Seconds, saved.TotalTimeSeconds)
+	}
+
+	// Stopped, so another one can start
+	rr = env.serve(StartTimer, asUser(env.newRequest("POST", timerPath(otherTask.ID, "start"), nil), user))
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected a new timer after stopping, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// timestampLayout is how timestamps are written in the default rfc3339 format
+const timestampLayout = "2006-01-02T15:04:05Z07:00"
+
+// Timestamp is a point in time in an API response
+// Every response timestamp uses this type so they all follow TIMESTAMP_FORMAT:
+// an RFC 3339 string by default, or integer seconds since the Unix epoch.
+type Timestamp time.Time
+
+// newTimestamp wraps t for a response, in UTC
+// Responses to a user with a timezone setting are moved to that zone when
+// they are written (see inUserTimezone).
+func newTimestamp(t time.Time) Timestamp {
+	return Timestamp(t.UTC())
+}
+
+// newOptionalTimestamp wraps t for a response, keeping nil as nil (JSON null)
+func newOptionalTimestamp(t *time.Time) *Timestamp {
+	if t == nil {
+		return nil
+	}
+	ts := newTimestamp(*t)
+	return &ts
+}
+
+// Time returns the wrapped time
+func (t Timestamp) Time() time.Time {
+	return time.Time(t)
+}
+
+// String formats the timestamp as RFC 3339, whatever TIMESTAMP_FORMAT says
+func (t Timestamp) String() string {
+	return time.Time(t).Format(timestampLayout)
+}
+
+// MarshalJSON writes the timestamp in the configured TIMESTAMP_FORMAT
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if config.Get().TimestampFormat == config.TimestampFormatUnix {
+		return strconv.AppendInt(nil, time.Time(t).Unix(), 10), nil
+	}
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON reads either format, so clients (and tests) can decode
+// responses however the server is configured
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var seconds int64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*t = Timestamp(time.Unix(seconds, 0).UTC())
+		return nil
+	}
+
+	var value time.Time
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*t = Timestamp(value)
+	return nil
+}
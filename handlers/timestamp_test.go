@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// TestTimestampFormat tests that task timestamps follow TIMESTAMP_FORMAT
+// Not parallel: it changes the global configuration
+func TestTimestampFormat(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser("test-timestamp-format")
+	due := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	task := env.createTask(user, CreateTaskRequest{Title: "Timestamps", DueDate: &due})
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	testCases := []struct {
+		name            string
+		format          string
+		expectedDueDate string // The raw JSON value
+	}{
+		{"default is RFC 3339", "", `"2030-01-02T03:04:05Z"`},
+		{"rfc3339", config.TimestampFormatRFC3339, `"2030-01-02T03:04:05Z"`},
+		{"unix epoch seconds", config.TimestampFormatUnix, "1893553445"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withConfig(t, func(cfg *config.Config) {
+				cfg.TimestampFormat = tc.format
+			})
+
+			rr := env.serve(GetTask, asUser(env.newRequest("GET", path, nil), user))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var raw map[string]json.RawMessage
+			env.decode(rr, &raw)
+			if string(raw["due_date"]) != tc.expectedDueDate {
+				t.Errorf("Expected due_date %s, got %s", tc.expectedDueDate, raw["due_date"])
+			}
+			// created_at and updated_at are written the same way
+			isNumber := tc.format == config.TimestampFormatUnix
+			for _, field := range []string{"created_at", "updated_at"} {
+				if (raw[field][0] != '"') != isNumber {
+					t.Errorf("Expected %s in the same format as due_date, got %s", field, raw[field])
+				}
+			}
+
+			// Either format decodes back to the same time
+			var response TaskResponse
+			env.decode(rr, &response)
+			if response.DueDate == nil || !response.DueDate.Time().Equal(due) {
+				t.Errorf("Expected due_date to decode to %s, got %v", due, response.DueDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/kcansari/task-management-api/middleware"
+)
+
+// locations caches the time zones loaded by name; loading one reads tzdata
+var locations sync.Map // string -> *time.Location
+
+// loadTimezone returns the IANA time zone with the given name, e.g. Europe/Istanbul
+// ok is false for unknown names and for "Local", which would be the
+// server's zone. "" is UTC, as for time.LoadLocation.
+func loadTimezone(name string) (location *time.Location, ok bool) {
+	if cached, found := locations.Load(name); found {
+		return cached.(*time.Location), true
+	}
+	if name == "Local" {
+		return nil, false
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, false
+	}
+	locations.Store(name, location)
+	return location, true
+}
+
+// userLocation returns the time zone r's response timestamps are written in:
+// the caller's timezone setting, or UTC when they have none (or there is no caller)
+func userLocation(r *http.Request) *time.Location {
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok || user.Timezone == "" {
+		return time.UTC
+	}
+	// The setting was checked when it was saved; a zone that has since
+	// disappeared from tzdata falls back to UTC
+	if location, ok := loadTimezone(user.Timezone); ok {
+		return location
+	}
+	return time.UTC
+}
+
+var (
+	timestampType = reflect.TypeOf(Timestamp{})
+	timeType      = reflect.TypeOf(time.Time{})
+)
+
+// inUserTimezone returns a copy of the response data with every timestamp in
+// it moved to r's time zone (see userLocation)
+// The copy is deep, since the data may share pointers and slices with cached
+// values that other users' responses are built from. Only exported fields
+// are walked, like encoding/json does.
+func inUserTimezone(r *http.Request, data any) any {
+	if data == nil {
+		return nil
+	}
+	return inLocation(reflect.ValueOf(data), userLocation(r)).Interface()
+}
+
+// inLocation deep-copies v with its Timestamp and time.Time values in location
+func inLocation(v reflect.Value, location *time.Location) reflect.Value {
+	switch v.Type() {
+	case timestampType:
+		return reflect.ValueOf(Timestamp(time.Time(v.Interface().(Timestamp)).In(location)))
+	case timeType:
+		return reflect.ValueOf(v.Interface().(time.Time).In(location))
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(inLocation(v.Elem(), location))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(inLocation(v.Elem(), location))
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				copied.Field(i).Set(inLocation(v.Field(i), location))
+			}
+		}
+		return copied
+	case reflect.Slice:
+		// Byte slices (e.g. json.RawMessage) can't hold a timestamp
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(inLocation(v.Index(i), location))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(inLocation(v.Index(i), location))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), inLocation(iter.Value(), location))
+		}
+		return copied
+	}
+	return v
+}
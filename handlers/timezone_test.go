@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+)
+
+// TestInUserTimezone tests that response timestamps move to the caller's zone without touching the original
+func TestInUserTimezone(t *testing.T) {
+	t.Parallel()
+	due := time.Date(2025, 6, 22, 14, 0, 0, 0, time.UTC)
+	original := PaginatedTaskResponse{Tasks: []TaskResponse{{
+		ID:        1,
+		DueDate:   newOptionalTimestamp(&due),
+		CreatedAt: newTimestamp(due),
+	}}}
+
+	request := func(timezone string) *http.Request {
+		r := httptest.NewRequest("GET", "/api/tasks", nil)
+		ctx := context.WithValue(r.Context(), middleware.UserContextKey, middleware.UserContext{UserID: 1, Timezone: timezone})
+		return r.WithContext(ctx)
+	}
+	encode := func(data any) string {
+		body, err := json.Marshal(data)
+		if err != nil {
+			t.Fatalf("Failed to encode: %v", err)
+		}
+		return string(body)
+	}
+
+	zoned := encode(inUserTimezone(request("Europe/Istanbul"), original))
+	if !strings.Contains(zoned, `"due_date":"2025-06-22T17:00:00+03:00"`) || !strings.Contains(zoned, `"created_at":"2025-06-22T17:00:00+03:00"`) {
+		t.Errorf("Expected the timestamps in Istanbul time, got %s", zoned)
+	}
+
+	// The copy is deep: the original, which a cache may share, stays in UTC
+	if utc := encode(original); !strings.Contains(utc, `"due_date":"2025-06-22T14:00:00Z"`) {
+		t.Errorf("Expected the original to be unchanged, got %s", utc)
+	}
+
+	for _, r := range []*http.Request{request(""), httptest.NewRequest("GET", "/api/public/tasks/1", nil)} {
+		if utc := encode(inUserTimezone(r, original)); !strings.Contains(utc, `"due_date":"2025-06-22T14:00:00Z"`) {
+			t.Errorf("Expected UTC without a timezone setting, got %s", utc)
+		}
+	}
+}
+
+// TestUserSettingsTimezone tests saving the timezone setting
+func TestUserSettingsTimezone(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-settings-timezone")
+
+	put := func(body string) *httptest.ResponseRecorder {
+		return env.serve(UpdateUserSettings, asUser(env.newRequest("PUT", "/api/user/settings", body), user))
+	}
+
+	for _, tz := range []string{"Mars/Olympus", "Local", "+03:00", "europe/istanbul"} {
+		rr := put(fmt.Sprintf(`{"timezone":%q}`, tz))
+		var errResp ErrorResponse
+		env.decode(rr, &errResp)
+		if rr.Code != http.StatusBadRequest || errResp.Code != apierror.InvalidTimezone {
+			t.Errorf("Expected 400 INVALID_TIMEZONE for %q, got %d %s", tz, rr.Code, errResp.Code)
+		}
+	}
+
+	rr := put(`{"timezone":"Europe/Istanbul"}`)
+	var settings UserSettingsResponse
+	env.decode(rr, &settings)
+	if rr.Code != http.StatusOK || settings.Timezone == nil || *settings.Timezone != "Europe/Istanbul" {
+		t.Fatalf("Expected the timezone to be saved, got %d %+v", rr.Code, settings)
+	}
+
+	// AuthMiddleware picks the setting up, and responses use it
+	rr = env.serve(Login, env.newRequest("POST", "/api/auth/login", LoginRequest{Email: user.Email, Password: "testpassword123"}))
+	var login AuthResponse
+	env.decode(rr, &login)
+	due := time.Date(2025, 6, 22, 14, 0, 0, 0, time.UTC)
+	task := env.createTask(user, CreateTaskRequest{Title: "Zoned", DueDate: &due})
+	req := env.newRequest("GET", fmt.Sprintf("/api/tasks/%d", task.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+login.Token)
+	rr = env.serve(middleware.AuthMiddleware(GetTask), req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"due_date":"2025-06-22T17:00:00+03:00"`) {
+		t.Errorf("Expected the due date in Istanbul time, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	// Like every setting, leaving it out resets it
+	rr = put(`{}`)
+	env.decode(rr, &settings)
+	if rr.Code != http.StatusOK || settings.Timezone != nil {
+		t.Errorf("Expected the timezone to be reset, got %d %+v", rr.Code, settings)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// TransferTaskRequest represents the data needed to hand a task to another user
+type TransferTaskRequest struct {
+	NewOwnerID uint `json:"new_owner_id"` // ID of the user who will own the task
+}
+
+// Errors about the transfer target
+var (
+	errNewOwnerNotFound = errors.New("new owner not found") // Doesn't exist (or was deleted)
+	errNewOwnerInactive = errors.New("new owner is deactivated")
+	errAlreadyOwner     = errors.New("task already belongs to the new owner")
+)
+
+// TransferTask handles POST /api/tasks/{id}/transfer - Change the owner of a task
+// Unlike assigning, this changes true ownership: the previous owner loses access.
+// The owner can transfer a task, and so can the admins of its organization.
+// The new owner's MAX_TASKS_PER_USER and TASK_STATUS_LIMITS apply as if they
+// created the task.
+func TransferTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract task ID from URL: /api/tasks/123/transfer
+	taskID, err := taskIDFromPath(r.URL.Path, "/transfer")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidTaskID, "Invalid task ID")
+		return
+	}
+
+	// Parse request body
+	var req TransferTaskRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.NewOwnerID == 0 {
+		writeError(w, r, http.StatusBadRequest, apierror.NewOwnerRequired, "new_owner_id is required")
+		return
+	}
+
+	cfg := config.Get()
+	db, cancel := requestDB(r)
+	defer cancel()
+
+	// Verify access, check the target and reassign in one transaction
+	var task models.Task
+	var previousOwnerID uint
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Admins may transfer any task in their organization, anyone else only their own
+		var err error
+		if user.IsAdmin() {
+			err = tx.Where("org_id = ?", user.OrgID).First(&task, taskID).Error
+		} else {
+			task, err = findOwnedTask(tx, taskID, user)
+		}
+		if err != nil {
+			return err
+		}
+		previousOwnerID = task.UserID
+		if req.NewOwnerID == previousOwnerID {
+			return errAlreadyOwner
+		}
+
+		// Soft-deleted users are excluded by GORM's default scope
+		// Tasks never leave their organization, so users elsewhere count as missing
+		var newOwner models.User
+		if err := tx.Where("org_id = ?", task.OrgID).First(&newOwner, req.NewOwnerID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNewOwnerNotFound
+			}
+			return err
+		}
+		if !newOwner.Active {
+			return errNewOwnerInactive
+		}
+
+		// Both checks lock the new owner's row, so concurrent creates and
+		// transfers can't take them past a limit together
+		if err := checkTaskLimit(tx, newOwner.ID, cfg); err != nil {
+			return err
+		}
+		if err := checkStatusLimit(tx, newOwner.ID, task.Status, cfg); err != nil {
+			return err
+		}
+
+		// Guard the update with the previous owner so a concurrent transfer can't be overwritten
+		// The client and external IDs belong to the previous owner's devices and
+		// syncs (and could clash with the new owner's), so they don't move with the task
+		result := tx.Model(&task).Where("user_id = ?", previousOwnerID).
+			Updates(map[string]interface{}{"user_id": newOwner.ID, "client_id": nil, "external_id": nil})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		if writeStatusLimitError(w, r, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			writeError(w, r, http.StatusNotFound, apierror.TaskNotFound, "Task not found")
+		case errors.Is(err, errAlreadyOwner):
+			writeError(w, r, http.StatusBadRequest, apierror.AlreadyOwner, "Task is already owned by this user")
+		case errors.Is(err, errNewOwnerNotFound):
+			writeError(w, r, http.StatusBadRequest, apierror.NewOwnerNotFound, "New owner does not exist")
+		case errors.Is(err, errNewOwnerInactive):
+			writeError(w, r, http.StatusBadRequest, apierror.NewOwnerInactive, "New owner's account is deactivated")
+		case errors.Is(err, errTaskLimitReached):
+			writeError(w, r, http.StatusForbidden, apierror.TaskLimitReached,
+				fmt.Sprintf("Task limit reached: the new owner can have at most %d tasks", cfg.MaxTasksPerUser)) // 403 Forbidden
+		default:
+			log.Printf("Failed to transfer task %d: %v", taskID, err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to transfer task")
+		}
+		return
+	}
+
+	// The previous owner must no longer be served the task from the cache
+	forgetCachedTask(previousOwnerID, task.ID)
+	forgetCachedTaskForAll(db, task)
+
+	writeResponse(w, r, http.StatusOK, newTaskResponse(task))
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestTransferTaskHandler tests moving task ownership between users
+func TestTransferTaskHandler(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-transfer-owner")
+	colleague := env.createUser("test-transfer-colleague")
+	intruder := env.createUser("test-transfer-intruder")
+	departed := env.createUser("test-transfer-departed")
+
+	// A soft-deleted user can't receive tasks
+	if err := env.tx.Delete(&models.User{ID: departed.UserID}).Error; err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	task := env.createTask(owner, CreateTaskRequest{Title: "Hand over"})
+	path := fmt.Sprintf("/api/tasks/%d/transfer", task.ID)
+
+	// Someone who doesn't own the task can't transfer it
+	rr := env.serve(TransferTask, asUser(env.newRequest("POST", path, TransferTaskRequest{NewOwnerID: colleague.UserID}), intruder))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when transferring someone else's task, got %d", rr.Code)
+	}
+
+	// Missing, non-existent and deleted targets are rejected
+	for name, newOwnerID := range map[string]uint{
+		"missing target":     0,
+		"non-existent":       999999,
+		"deleted user":       departed.UserID,
+		"transfer to itself": owner.UserID,
+	} {
+		rr := env.serve(TransferTask, asUser(env.newRequest("POST", path, TransferTaskRequest{NewOwnerID: newOwnerID}), owner))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, rr.Code, rr.Body.String())
+		}
+	}
+
+	// A valid transfer returns the task with its new owner
+	rr = env.serve(TransferTask, asUser(env.newRequest("POST", path, TransferTaskRequest{NewOwnerID: colleague.UserID}), owner))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var transferred TaskResponse
+	env.decode(rr, &transferred)
+	if transferred.UserID != colleague.UserID {
+		t.Errorf("Expected new owner %d, got %d", colleague.UserID, transferred.UserID)
+	}
+
+	// The previous owner loses access, the new owner gains it
+	getPath := fmt.Sprintf("/api/tasks/%d", task.ID)
+	if rr := env.serve(GetTask, asUser(env.newRequest("GET", getPath, nil), owner)); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected previous owner to get 404, got %d", rr.Code)
+	}
+	if rr := env.serve(GetTask, asUser(env.newRequest("GET", getPath, nil), colleague)); rr.Code != http.StatusOK {
+		t.Errorf("Expected new owner to get 200, got %d", rr.Code)
+	}
+}
+
+// TestTransferTaskTargets tests who may transfer a task and to whom
+func TestTransferTaskTargets(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	admin := env.createOrgAdmin("test-transfer-admin")
+	member := env.addMember(admin, "test-transfer-member")
+	colleague := env.addMember(admin, "test-transfer-colleague")
+	inactive := env.addMember(admin, "test-transfer-inactive")
+	if err := env.tx.Model(&models.User{ID: inactive.UserID}).Update("active", false).Error; err != nil {
+		t.Fatalf("Failed to deactivate user: %v", err)
+	}
+
+	task := env.createTask(member, CreateTaskRequest{Title: "Hand over"})
+	path := fmt.Sprintf("/api/tasks/%d/transfer", task.ID)
+	transfer := func(caller middleware.UserContext, newOwnerID uint) *httptest.ResponseRecorder {
+		return env.serve(TransferTask, asUser(env.newRequest("POST", path, TransferTaskRequest{NewOwnerID: newOwnerID}), caller))
+	}
+
+	// Deactivated users can't receive tasks
+	rr := transfer(member, inactive.UserID)
+	var errResponse ErrorResponse
+	env.decode(rr, &errResponse)
+	if rr.Code != http.StatusBadRequest || errResponse.Code != apierror.NewOwnerInactive {
+		t.Errorf("Expected 400 %s, got %d: %s", apierror.NewOwnerInactive, rr.Code, rr.Body.String())
+	}
+
+	// Other members can't transfer the task, but the organization's admin can
+	if rr := transfer(colleague, colleague.UserID); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another member, got %d", rr.Code)
+	}
+	if rr := transfer(admin, member.UserID); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 transferring to the current owner, got %d", rr.Code)
+	}
+	rr = transfer(admin, colleague.UserID)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected the admin's transfer to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var transferred TaskResponse
+	env.decode(rr, &transferred)
+	if transferred.UserID != colleague.UserID {
+		t.Errorf("Expected new owner %d, got %d", colleague.UserID, transferred.UserID)
+	}
+
+	// Admins can take a task over themselves too
+	if rr := transfer(admin, admin.UserID); rr.Code != http.StatusOK {
+		t.Errorf("Expected the admin to take the task over, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+// TestTransferTaskLimits tests that the new owner's task and status limits apply
+// Not parallel: it overrides the global configuration
+func TestTransferTaskLimits(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.MaxTasksPerUser = 2
+		cfg.TaskStatusLimits = []string{"in_progress=1"}
+	})
+	env := newTestEnv(t)
+	owner := env.createUser("test-transfer-limit-owner")
+	full := env.createUser("test-transfer-limit-full")
+	busy := env.createUser("test-transfer-limit-busy")
+
+	env.createTask(full, CreateTaskRequest{Title: "One"})
+	env.createTask(full, CreateTaskRequest{Title: "Two"})
+	env.createTask(busy, CreateTaskRequest{Title: "Working", Status: models.TaskStatusInProgress})
+	pending := env.createTask(owner, CreateTaskRequest{Title: "Pending"})
+	started := env.createTask(owner, CreateTaskRequest{Title: "Started", Status: models.TaskStatusInProgress})
+
+	transfer := func(task TaskResponse, newOwner middleware.UserContext) (int, apierror.Code) {
+		t.Helper()
+		path := fmt.Sprintf("/api/tasks/%d/transfer", task.ID)
+		rr := env.serve(TransferTask, asUser(env.newRequest("POST", path, TransferTaskRequest{NewOwnerID: newOwner.UserID}), owner))
+		var response ErrorResponse
+		if rr.Code != http.StatusOK {
+			env.decode(rr, &response)
+		}
+		return rr.Code, response.Code
+	}
+
+	if status, code := transfer(pending, full); status != http.StatusForbidden || code != apierror.TaskLimitReached {
+		t.Errorf("Expected 403 %s, got %d %s", apierror.TaskLimitReached, status, code)
+	}
+	if status, code := transfer(started, busy); status != http.StatusConflict || code != apierror.StatusLimitReached {
+		t.Errorf("Expected 409 %s, got %d %s", apierror.StatusLimitReached, status, code)
+	}
+
+	// A pending task still fits where only in_progress is full
+	if status, code := transfer(pending, busy); status != http.StatusOK {
+		t.Errorf("Expected the pending task to move, got %d %s", status, code)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// APIUsageResponse is the caller's request count for the current period
+type APIUsageResponse struct {
+	Tracking    bool      `json:"tracking"`     // Whether API_USAGE_TRACKING counts requests at all
+	Period      string    `json:"period"`       // "daily" or "monthly"
+	PeriodStart time.Time `json:"period_start"` // Start of the current period (UTC)
+	ResetsAt    time.Time `json:"resets_at"`    // When the count starts over
+	Used        int64     `json:"used"`         // Requests counted so far this period
+	Quota       *int      `json:"quota"`        // Requests allowed per period; null when unlimited
+	Remaining   *int64    `json:"remaining"`    // Requests left this period; null when unlimited
+}
+
+// GetAPIUsage handles GET /api/auth/usage - The caller's API usage and quota
+// This endpoint isn't counted itself, so a user over their quota can still
+// see when it resets.
+func GetAPIUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	cfg := config.Get()
+	period := cfg.APIUsagePeriod
+	if period == "" {
+		period = models.UsagePeriodMonthly
+	}
+	periodStart, periodEnd := models.UsagePeriodBounds(period, time.Now())
+	resp := APIUsageResponse{
+		Tracking:    cfg.APIUsageTracking,
+		Period:      period,
+		PeriodStart: periodStart,
+		ResetsAt:    periodEnd,
+	}
+
+	if cfg.APIUsageTracking {
+		db, cancel := requestDB(r)
+		defer cancel()
+
+		// No row yet just means no requests this period
+		var usage models.APIUsage
+		err := db.Where("user_id = ? AND period_start = ?", user.UserID, periodStart).First(&usage).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			if writeQueryTimeout(w, r, err) {
+				return
+			}
+			log.Printf("Failed to load API usage of user %d: %v", user.UserID, err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch API usage")
+			return
+		}
+		resp.Used = usage.Count
+	}
+
+	if cfg.APIQuota > 0 {
+		quota := cfg.APIQuota
+		remaining := max(int64(quota)-resp.Used, 0)
+		resp.Quota = &quota
+		resp.Remaining = &remaining
+	}
+
+	writeResponse(w, r, http.StatusOK, resp)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+)
+
+// TestAPIUsageQuota tests that requests are counted per user and cut off at API_QUOTA
+// Not parallel: it changes the global configuration
+func TestAPIUsageQuota(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser("test-usage-quota")
+	other := env.createUser("test-usage-quota-other")
+	withConfig(t, func(cfg *config.Config) {
+		cfg.APIUsageTracking = true
+		cfg.APIUsagePeriod = "daily"
+		cfg.APIQuota = 2
+	})
+
+	handler := middleware.TrackUsage(GetTaskStatusCounts)
+	for i := 1; i <= 3; i++ {
+		rr := env.serve(handler, asUser(env.newRequest("GET", "/api/tasks/statuses", nil), user))
+		if i <= 2 && rr.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status 200, got %d: %s", i, rr.Code, rr.Body.String())
+		}
+		if i == 3 {
+			var errResp ErrorResponse
+			env.decode(rr, &errResp)
+			if rr.Code != http.StatusTooManyRequests || errResp.Code != apierror.QuotaExceeded {
+				t.Fatalf("Expected 429 QUOTA_EXCEEDED, got %d %s", rr.Code, errResp.Code)
+			}
+			if rr.Header().Get("Retry-After") == "" {
+				t.Errorf("Expected a Retry-After header")
+			}
+		}
+	}
+
+	// Other users have their own count
+	rr := env.serve(handler, asUser(env.newRequest("GET", "/api/tasks/statuses", nil), other))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected another user's request to pass, got %d", rr.Code)
+	}
+
+	// The usage endpoint reports the count (the rejected request included) and isn't blocked
+	rr = env.serve(GetAPIUsage, asUser(env.newRequest("GET", "/api/auth/usage", nil), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var usage APIUsageResponse
+	env.decode(rr, &usage)
+	if !usage.Tracking || usage.Period != "daily" || usage.Used != 3 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+	if usage.Quota == nil || *usage.Quota != 2 || usage.Remaining == nil || *usage.Remaining != 0 {
+		t.Errorf("Expected quota 2 with none remaining, got %+v", usage)
+	}
+	if !usage.ResetsAt.Equal(usage.PeriodStart.AddDate(0, 0, 1)) {
+		t.Errorf("Expected a daily period, got %s - %s", usage.PeriodStart, usage.ResetsAt)
+	}
+}
+
+// TestAPIUsageDisabled tests that nothing is counted or limited without API_USAGE_TRACKING
+func TestAPIUsageDisabled(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser("test-usage-disabled")
+	withConfig(t, func(cfg *config.Config) {
+		cfg.APIUsageTracking = false
+		cfg.APIQuota = 0
+	})
+
+	rr := env.serve(middleware.TrackUsage(GetTaskStatusCounts), asUser(env.newRequest("GET", "/api/tasks/statuses", nil), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	rr = env.serve(GetAPIUsage, asUser(env.newRequest("GET", "/api/auth/usage", nil), user))
+	var usage APIUsageResponse
+	env.decode(rr, &usage)
+	if usage.Tracking || usage.Used != 0 || usage.Quota != nil || usage.Remaining != nil {
+		t.Errorf("Expected no tracking and no quota, got %+v", usage)
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// TestDeleteUserCascade tests that deleting a user soft-deletes their tasks
+// and removes their shares in the same transaction
+func TestDeleteUserCascade(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	leaver := env.createUser("test-delete-leaver")
+	colleague := env.createUser("test-delete-colleague")
+
+	first := env.createTask(leaver, CreateTaskRequest{Title: "First"})
+	second := env.createTask(leaver, CreateTaskRequest{Title: "Second"})
+	kept := env.createTask(colleague, CreateTaskRequest{Title: "Colleague's"})
+	rr := env.serve(ShareTask, asUser(env.newRequest("POST", fmt.Sprintf("/api/tasks/%d/shares", kept.ID), ShareTaskRequest{UserID: leaver.UserID}), colleague))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to share task: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// counts returns how many of the leaver's tasks and shares are left
+	counts := func(db *gorm.DB) (tasks, shares int64) {
+		t.Helper()
+		db.Model(&models.Task{}).Where("id IN ?", []uint{first.ID, second.ID}).Count(&tasks)
+		db.Model(&models.TaskShare{}).Where("shared_with_user_id = ?", leaver.UserID).Count(&shares)
+		return tasks, shares
+	}
+
+	// A failure after the cascade (here, in the user's own DELETE) takes the
+	// cascade back with it
+	err := env.tx.Transaction(func(tx *gorm.DB) error {
+		return tx.Where("no_such_column = ?", 1).Delete(&models.User{ID: leaver.UserID}).Error
+	})
+	if err == nil {
+		t.Fatal("Expected the delete to fail")
+	}
+	if tasks, shares := counts(env.tx); tasks != 2 || shares != 1 {
+		t.Errorf("Expected the failed delete to leave 2 tasks and 1 share, got %d and %d", tasks, shares)
+	}
+	var user models.User
+	if err := env.tx.First(&user, leaver.UserID).Error; err != nil {
+		t.Errorf("Expected the user to survive the failed delete: %v", err)
+	}
+
+	if err := env.tx.Delete(&models.User{ID: leaver.UserID}).Error; err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	if tasks, shares := counts(env.tx); tasks != 0 || shares != 0 {
+		t.Errorf("Expected no tasks or shares left, got %d and %d", tasks, shares)
+	}
+
+	// The tasks are only soft-deleted, and other users' tasks aren't touched
+	var deleted int64
+	env.tx.Unscoped().Model(&models.Task{}).Where("id IN ? AND deleted_at IS NOT NULL", []uint{first.ID, second.ID}).Count(&deleted)
+	if deleted != 2 {
+		t.Errorf("Expected 2 soft-deleted tasks, got %d", deleted)
+	}
+	if err := env.tx.First(&models.Task{}, kept.ID).Error; err != nil {
+		t.Errorf("Expected the colleague's task to remain: %v", err)
+	}
+
+	// Bulk deletes can't cascade, so they're refused
+	if err := env.tx.Where("id = ?", colleague.UserID).Delete(&models.User{}).Error; err == nil {
+		t.Error("Expected a bulk user delete to be refused")
+	}
+}
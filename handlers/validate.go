@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TaskFieldError is one problem with a field of a task payload
+// Code and Message are what the error response would carry if the task were
+// created with this field.
+type TaskFieldError struct {
+	Field   string        `json:"field"`   // JSON name of the field, e.g. "title"
+	Code    apierror.Code `json:"code"`    // Machine-readable error code
+	Message string        `json:"message"` // Human-readable (localized) message
+}
+
+// TaskValidationResponse is what POST /api/tasks/validate sends back
+type TaskValidationResponse struct {
+	Valid  bool             `json:"valid"`
+	Errors []TaskFieldError `json:"errors,omitempty"` // Every invalid field
+}
+
+// writeFieldError sends a field error as the usual 400 error response
+func writeFieldError(w http.ResponseWriter, r *http.Request, fieldErr TaskFieldError) {
+	writeError(w, r, http.StatusBadRequest, fieldErr.Code, fieldErr.Message)
+}
+
+// validateNewTask checks every field of a task about to be created
+// It normalizes req in place (the color) and returns the metadata the task
+// would be created with, along with all the problems found rather than just
+// the first. An error means the status workflow is misconfigured.
+func validateNewTask(req *CreateTaskRequest) (models.TaskMetadata, []TaskFieldError, error) {
+	var fieldErrors []TaskFieldError
+	add := func(fieldErr *TaskFieldError) {
+		if fieldErr != nil {
+			fieldErrors = append(fieldErrors, *fieldErr)
+		}
+	}
+
+	if strings.TrimSpace(req.Title) == "" {
+		add(&TaskFieldError{"title", apierror.TitleRequired, "Title is required"})
+	} else {
+		add(taskTextError(&req.Title, nil))
+	}
+	add(taskTextError(nil, &req.Description))
+	add(taskColorError(&req.Color))
+	add(taskProgressError(&req.Progress))
+	add(taskVisibilityError(&req.Visibility))
+	add(externalIDError(req.ExternalID))
+	metadata, fieldErr := mergeMetadata(nil, req.Metadata)
+	add(fieldErr)
+
+	if req.Status != "" {
+		workflow, err := taskWorkflow()
+		if err != nil {
+			return nil, nil, err
+		}
+		if !workflow.IsValid(req.Status) {
+			add(&TaskFieldError{"status", apierror.InvalidStatus, "Invalid status. Use: " + workflow.StatusList()})
+		}
+	}
+	return metadata, fieldErrors, nil
+}
+
+// ValidateTask handles POST /api/tasks/validate - Check a task payload without creating it
+// The body is the same as for POST /api/tasks and goes through the same
+// validation, but nothing is written: 200 with {"valid": true} when creating
+// it would pass validation, 422 with every invalid field otherwise. Checks
+// that depend on the database, like MAX_TASKS_PER_USER, aren't made.
+func ValidateTask(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if _, ok := middleware.GetUserFromContext(r); !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	var req CreateTaskRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	_, fieldErrors, err := validateNewTask(&req)
+	if err != nil {
+		log.Printf("Invalid task workflow configuration: %v", err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to validate task")
+		return
+	}
+	if len(fieldErrors) > 0 {
+		for i := range fieldErrors {
+			fieldErrors[i].Message = apierror.Localize(r.Header.Get("Accept-Language"), fieldErrors[i].Code, fieldErrors[i].Message)
+		}
+		writeResponse(w, r, http.StatusUnprocessableEntity, TaskValidationResponse{Errors: fieldErrors}) // 422
+		return
+	}
+	writeResponse(w, r, http.StatusOK, TaskValidationResponse{Valid: true})
+}
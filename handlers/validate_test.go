@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// TestValidateTask tests validating task payloads without creating them
+func TestValidateTask(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-validate")
+
+	rr := env.serve(ValidateTask, asUser(env.newRequest("POST", "/api/tasks/validate", CreateTaskRequest{Title: "Fine", Color: "#00FF00"}), user))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response TaskValidationResponse
+	env.decode(rr, &response)
+	if !response.Valid || response.Errors != nil {
+		t.Errorf("Expected a valid payload, got %s", rr.Body.String())
+	}
+
+	// Every invalid field is reported, not just the first
+	invalid := CreateTaskRequest{
+		Title:      " ",
+		Status:     models.TaskStatus("someday"),
+		Color:      "red!",
+		ExternalID: strings.Repeat("x", maxExternalIDLength+1),
+	}
+	rr = env.serve(ValidateTask, asUser(env.newRequest("POST", "/api/tasks/validate", invalid), user))
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+	response = TaskValidationResponse{}
+	env.decode(rr, &response)
+	expected := map[string]apierror.Code{
+		"title":       apierror.TitleRequired,
+		"status":      apierror.InvalidStatus,
+		"color":       apierror.InvalidColor,
+		"external_id": apierror.InvalidExternalID,
+	}
+	if response.Valid || len(response.Errors) != len(expected) {
+		t.Fatalf("Expected %d field errors, got %s", len(expected), rr.Body.String())
+	}
+	for _, fieldErr := range response.Errors {
+		if expected[fieldErr.Field] != fieldErr.Code || fieldErr.Message == "" {
+			t.Errorf("Unexpected error for %s: %+v", fieldErr.Field, fieldErr)
+		}
+	}
+
+	// Nothing was created
+	var count int64
+	env.tx.Model(&models.Task{}).Where("user_id = ?", user.UserID).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no tasks to be created, got %d", count)
+	}
+
+	// POST /api/tasks rejects the same payload with the first of those errors
+	rr = env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", invalid), user))
+	var errResp ErrorResponse
+	env.decode(rr, &errResp)
+	if rr.Code != http.StatusBadRequest || errResp.Code != apierror.TitleRequired {
+		t.Errorf("Expected 400 %s from create, got %d %s", apierror.TitleRequired, rr.Code, errResp.Code)
+	}
+}
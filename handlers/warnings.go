@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+// taskWarnings lists the non-fatal issues with a task about to be saved
+// Only the fields being set are checked (titleSet/dueDateSet), so updating one
+// field doesn't keep warning about another that was accepted before.
+// Which checks run is configured with TASK_WARNINGS.
+func taskWarnings(db *gorm.DB, task models.Task, titleSet, dueDateSet bool) []string {
+	cfg := config.Get()
+	var warnings []string
+
+	if dueDateSet && cfg.TaskWarningEnabled(config.TaskWarningPastDueDate) &&
+		task.DueDate != nil && task.DueDate.Before(time.Now()) {
+		warnings = append(warnings, "due_date is in the past")
+	}
+
+	if titleSet && cfg.TaskWarningEnabled(config.TaskWarningLongTitle) &&
+		cfg.TaskWarningTitleLength > 0 && utf8.RuneCountInString(task.Title) > cfg.TaskWarningTitleLength {
+		warnings = append(warnings, fmt.Sprintf("title is longer than %d characters", cfg.TaskWarningTitleLength))
+	}
+
+	if titleSet && cfg.TaskWarningEnabled(config.TaskWarningDuplicateTitle) {
+		// "Duplicate-ish": case and surrounding whitespace don't make titles different
+		// A failed lookup only costs the warning, never the request. The task's
+		// owner counts, not the caller (a shared task may be edited by someone else)
+		var count int64
+		owner := middleware.UserContext{UserID: task.UserID, OrgID: task.OrgID}
+		err := db.Model(&models.Task{}).
+			Scopes(ownedBy(owner)).
+			Where("id <> ?", task.ID).
+			Where("LOWER(TRIM(title)) = ?", strings.ToLower(strings.TrimSpace(task.Title))).
+			Count(&count).Error
+		if err != nil {
+			log.Printf("Failed to check for duplicate titles of user %d: %v", task.UserID, err)
+		} else if count > 0 {
+			warnings = append(warnings, "another task already has this title")
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// TestTaskWarnings tests that non-fatal issues are reported without failing the request
+// Not parallel: it overrides the global configuration
+func TestTaskWarnings(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskWarnings = config.TaskWarningChecks
+		cfg.TaskWarningTitleLength = 10
+	})
+
+	env := newTestEnv(t)
+	user := env.createUser("test-warnings")
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	env.createTask(user, CreateTaskRequest{Title: "Existing"})
+
+	createCases := []struct {
+		name     string
+		request  CreateTaskRequest
+		expected []string
+	}{
+		{"no issues", CreateTaskRequest{Title: "Fine", DueDate: &future}, nil},
+		{"past due date", CreateTaskRequest{Title: "Late", DueDate: &past}, []string{"due_date is in the past"}},
+		{"long title", CreateTaskRequest{Title: "Much too long"}, []string{"title is longer than 10 characters"}},
+		{"duplicate title ignoring case and spaces", CreateTaskRequest{Title: " EXISTING "}, []string{"another task already has this title"}},
+		{"several issues", CreateTaskRequest{Title: "Existing", DueDate: &past}, []string{"due_date is in the past", "another task already has this title"}},
+	}
+
+	for _, tc := range createCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", tc.request), user))
+			if rr.Code != http.StatusCreated {
+				t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var response TaskResponse
+			env.decode(rr, &response)
+			if !reflect.DeepEqual(response.Warnings, tc.expected) {
+				t.Errorf("Expected warnings %q, got %q", tc.expected, response.Warnings)
+			}
+
+			// No warnings means no "warnings" key at all, not null or []
+			var raw map[string]json.RawMessage
+			env.decode(rr, &raw)
+			if _, ok := raw["warnings"]; ok != (tc.expected != nil) {
+				t.Errorf("Unexpected warnings field in %s", rr.Body.String())
+			}
+		})
+	}
+
+	// Updates only check the fields they change
+	task := env.createTask(user, CreateTaskRequest{Title: "Existing", DueDate: &past})
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+	updateCases := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{"unrelated field", `{"description":"x"}`, nil},
+		{"unique title", `{"title":"Renamed"}`, nil},
+		{"past due date", fmt.Sprintf(`{"due_date":%q}`, past.Format(time.RFC3339)), []string{"due_date is in the past"}},
+		{"cleared due date", `{"due_date":null}`, nil},
+		{"duplicate title", `{"title":"existing"}`, []string{"another task already has this title"}},
+	}
+
+	for _, tc := range updateCases {
+		t.Run("update "+tc.name, func(t *testing.T) {
+			rr := env.serve(UpdateTask, asUser(env.newRequest("PUT", path, tc.body), user))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			var response TaskResponse
+			env.decode(rr, &response)
+			if !reflect.DeepEqual(response.Warnings, tc.expected) {
+				t.Errorf("Expected warnings %q, got %q", tc.expected, response.Warnings)
+			}
+		})
+	}
+
+	// Checks can be switched off
+	withConfig(t, func(cfg *config.Config) {
+		cfg.TaskWarnings = []string{config.TaskWarningLongTitle}
+	})
+	rr := env.serve(CreateTask, asUser(env.newRequest("POST", "/api/tasks", CreateTaskRequest{Title: "Existing", DueDate: &past}), user))
+	if strings.Contains(rr.Body.String(), "warnings") {
+		t.Errorf("Expected disabled checks not to warn, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/webhooks"
+)
+
+// CreateWebhookRequest represents the data needed to register a webhook
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`    // http(s) endpoint to POST events to (required)
+	Secret string   `json:"secret"` // HMAC key (optional, generated when empty)
+	Events []string `json:"events"` // Events to subscribe to (optional, defaults to all)
+}
+
+// WebhookResponse represents a webhook in API responses
+type WebhookResponse struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"` // Only returned when the webhook is created
+	CreatedAt Timestamp `json:"created_at"`
+}
+
+// newWebhookResponse converts a webhook model to its API representation (without the secret)
+func newWebhookResponse(hook models.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    hook.EventList(),
+		CreatedAt: newTimestamp(hook.CreatedAt),
+	}
+}
+
+// generateWebhookSecret returns a random 32-byte hex secret
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// CreateWebhook handles POST /api/webhooks - Register a webhook for task events
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Parse request body
+	var req CreateWebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// Only absolute http(s) URLs can receive deliveries
+	target, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidWebhookURL, "A valid http or https URL is required")
+		return
+	}
+
+	// Nor can users point the server at itself or its private network
+	if err := webhooks.CheckURL(r.Context(), target); err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidWebhookURL, "Webhook URLs can't point to loopback, private or link-local addresses")
+		return
+	}
+
+	// Subscribe to every event unless a subset was requested
+	events := req.Events
+	if len(events) == 0 {
+		events = models.WebhookEvents
+	}
+	for _, event := range events {
+		if !models.IsWebhookEvent(event) {
+			writeError(w, r, http.StatusBadRequest, apierror.InvalidWebhookEvent, "Invalid event. Use: "+strings.Join(models.WebhookEvents, ", "))
+			return
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateWebhookSecret(); err != nil {
+			log.Printf("Failed to generate webhook secret: %v", err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to create webhook")
+			return
+		}
+	}
+
+	hook := models.Webhook{
+		UserID: user.UserID,
+		URL:    target.String(),
+		Secret: secret,
+		Events: strings.Join(events, ","),
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+	if err := db.Create(&hook).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to create webhook: %v", err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to create webhook")
+		return
+	}
+
+	// The secret is shown once so the receiver can verify signatures
+	response := newWebhookResponse(hook)
+	response.Secret = hook.Secret
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(inUserTimezone(r, response))
+}
+
+// GetWebhooks handles GET /api/webhooks - List the user's webhooks
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+	var hooks []models.Webhook
+	if err := db.Where("user_id = ?", user.UserID).Order("id ASC").Find(&hooks).Error; err != nil {
+		if writeQueryTimeout(w, r, err) {
+			return
+		}
+		log.Printf("Failed to fetch webhooks for user %d: %v", user.UserID, err)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to fetch webhooks")
+		return
+	}
+
+	response := make([]WebhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		response = append(response, newWebhookResponse(hook))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(inUserTimezone(r, response))
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/{id} - Remove a webhook
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "DELETE" {
+		writeError(w, r, http.StatusMethodNotAllowed, apierror.MethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Get authenticated user from context
+	user, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "User not found in context")
+		return
+	}
+
+	// Extract webhook ID from URL: /api/webhooks/123
+	hookID, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/webhooks/"), 10, 32)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apierror.InvalidWebhookID, "Invalid webhook ID")
+		return
+	}
+
+	db, cancel := requestDB(r)
+	defer cancel()
+	result := db.Where("id = ? AND user_id = ?", hookID, user.UserID).Delete(&models.Webhook{})
+	if result.Error != nil {
+		if writeQueryTimeout(w, r, result.Error) {
+			return
+		}
+		log.Printf("Failed to delete webhook: %v", result.Error)
+		writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to delete webhook")
+		return
+	}
+	if result.RowsAffected == 0 {
+		writeError(w, r, http.StatusNotFound, apierror.WebhookNotFound, "Webhook not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
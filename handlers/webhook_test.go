@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/webhooks"
+)
+
+// TestCreateWebhookHandler tests registering webhooks
+func TestCreateWebhookHandler(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	user := env.createUser("test-create-webhook")
+
+	testCases := []struct {
+		name           string
+		requestBody    interface{}
+		expectedStatus int
+		expectedEvents int
+	}{
+		{"all events by default", CreateWebhookRequest{URL: "https://example.com/hook"}, http.StatusCreated, len(models.WebhookEvents)},
+		{"selected events", CreateWebhookRequest{URL: "https://example.com/hook", Events: []string{"task.completed"}}, http.StatusCreated, 1},
+		{"missing URL", CreateWebhookRequest{}, http.StatusBadRequest, 0},
+		{"non-http URL", CreateWebhookRequest{URL: "ftp://example.com/hook"}, http.StatusBadRequest, 0},
+		{"relative URL", CreateWebhookRequest{URL: "/hook"}, http.StatusBadRequest, 0},
+		{"unknown event", CreateWebhookRequest{URL: "https://example.com/hook", Events: []string{"task.exploded"}}, http.StatusBadRequest, 0},
+		{"loopback address", CreateWebhookRequest{URL: "http://127.0.0.1:8080/hook"}, http.StatusBadRequest, 0},
+		{"localhost", CreateWebhookRequest{URL: "http://localhost/hook"}, http.StatusBadRequest, 0},
+		{"metadata service", CreateWebhookRequest{URL: "http://169.254.169.254/latest/meta-data"}, http.StatusBadRequest, 0},
+		{"private network", CreateWebhookRequest{URL: "https://10.0.0.5/hook"}, http.StatusBadRequest, 0},
+		{"private IPv6", CreateWebhookRequest{URL: "https://[fd00::1]/hook"}, http.StatusBadRequest, 0},
+		{"invalid JSON", "not-json", http.StatusBadRequest, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := env.serve(CreateWebhook, asUser(env.newRequest("POST", "/api/webhooks", tc.requestBody), user))
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			if tc.expectedStatus == http.StatusCreated {
+				var hook WebhookResponse
+				env.decode(rr, &hook)
+				if hook.Secret == "" {
+					t.Errorf("Expected a generated secret in the create response")
+				}
+				if len(hook.Events) != tc.expectedEvents {
+					t.Errorf("Expected %d events, got %v", tc.expectedEvents, hook.Events)
+				}
+			}
+		})
+	}
+}
+
+// TestListAndDeleteWebhooks tests that webhooks are user-scoped and can be removed
+func TestListAndDeleteWebhooks(t *testing.T) {
+	t.Parallel()
+	env := newTestEnv(t)
+	owner := env.createUser("test-list-webhook")
+	intruder := env.createUser("test-list-webhook-other")
+
+	rr := env.serve(CreateWebhook, asUser(env.newRequest("POST", "/api/webhooks", CreateWebhookRequest{URL: "https://example.com/hook"}), owner))
+	var created WebhookResponse
+	env.decode(rr, &created)
+
+	// The secret is only shown on creation
+	rr = env.serve(GetWebhooks, asUser(env.newRequest("GET", "/api/webhooks", nil), owner))
+	var hooks []WebhookResponse
+	env.decode(rr, &hooks)
+	if len(hooks) != 1 || hooks[0].ID != created.ID || hooks[0].Secret != "" {
+		t.Errorf("Expected the created webhook without its secret, got %+v", hooks)
+	}
+
+	rr = env.serve(GetWebhooks, asUser(env.newRequest("GET", "/api/webhooks", nil), intruder))
+	env.decode(rr, &hooks)
+	if len(hooks) != 0 {
+		t.Errorf("Expected other users to see no webhooks, got %d", len(hooks))
+	}
+
+	path := fmt.Sprintf("/api/webhooks/%d", created.ID)
+	if rr := env.serve(DeleteWebhook, asUser(env.newRequest("DELETE", path, nil), intruder)); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting another user's webhook, got %d", rr.Code)
+	}
+	if rr := env.serve(DeleteWebhook, asUser(env.newRequest("DELETE", path, nil), owner)); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rr.Code)
+	}
+	if rr := env.serve(DeleteWebhook, asUser(env.newRequest("DELETE", path, nil), owner)); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an already deleted webhook, got %d", rr.Code)
+	}
+}
+
+// TestTaskEventsTriggerWebhooks tests that task changes are delivered to subscribed webhooks
+// Not parallel: it sets WEBHOOK_ALLOW_PRIVATE_URLS for the test server on 127.0.0.1
+func TestTaskEventsTriggerWebhooks(t *testing.T) {
+	withConfig(t, func(cfg *config.Config) {
+		cfg.WebhookAllowPrivateURLs = true
+	})
+	env := newTestEnv(t)
+	user := env.createUser("test-webhook-events")
+
+	type received struct {
+		event     string
+		signature string
+		body      []byte
+	}
+	deliveries := make(chan received, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- received{r.Header.Get(webhooks.EventHeader), r.Header.Get(webhooks.SignatureHeader), body}
+	}))
+	defer server.Close()
+
+	hook := CreateWebhookRequest{URL: server.URL, Secret: "test-secret", Events: []string{"task.created", "task.completed"}}
+	if rr := env.serve(CreateWebhook, asUser(env.newRequest("POST", "/api/webhooks", hook), user)); rr.Code != http.StatusCreated {
+		t.Fatalf("Failed to create webhook: %d %s", rr.Code, rr.Body.String())
+	}
+
+	// waitFor checks the next delivery, failing the test if none arrives
+	waitFor := func(event string) {
+		t.Helper()
+		select {
+		case got := <-deliveries:
+			if got.event != event {
+				t.Errorf("Expected %s delivery, got %s", event, got.event)
+			}
+			if got.signature != webhooks.Sign("test-secret", got.body) {
+				t.Errorf("Signature %s doesn't match the body", got.signature)
+			}
+			var payload TaskEventPayload
+			if err := json.Unmarshal(got.body, &payload); err != nil || payload.Event != event || payload.Task.ID == 0 {
+				t.Errorf("Unexpected payload %s (%v)", got.body, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %s delivery", event)
+		}
+	}
+
+	task := env.createTask(user, CreateTaskRequest{Title: "Ship it"})
+	waitFor("task.created")
+
+	// task.updated isn't subscribed, so only task.completed arrives
+	completed := models.TaskStatusCompleted
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+	if rr := env.serve(UpdateTask, asUser(env.newRequest("PUT", path, UpdateTaskRequest{Status: Optional[models.TaskStatus]{Set: true, Value: &completed}}), user)); rr.Code != http.StatusOK {
+		t.Fatalf("Failed to update task: %d %s", rr.Code, rr.Body.String())
+	}
+	waitFor("task.completed")
+
+	select {
+	case got := <-deliveries:
+		t.Errorf("Unexpected extra delivery %s", got.event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,96 @@
+// Package mail sends email, e.g. due-date reminders
+// Messages go through a Mailer: SMTP when SMTP_HOST is set, otherwise a
+// no-op that only logs, so development and tests never send real email.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email
+type Mailer interface {
+	// Send delivers msg, returning once the server has accepted it
+	Send(ctx context.Context, msg Message) error
+}
+
+// Nop is a Mailer that logs messages instead of sending them
+type Nop struct{}
+
+// Send logs who msg would have gone to
+func (Nop) Send(ctx context.Context, msg Message) error {
+	log.Printf("Email not sent (SMTP_HOST is not set): %q to %v", msg.Subject, msg.To)
+	return nil
+}
+
+// New returns the mailer selected by SMTP_HOST
+func New(cfg *config.Config) Mailer {
+	if cfg.SMTPHost == "" {
+		return Nop{}
+	}
+	return &SMTP{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	}
+}
+
+// retryBackoff is the wait before the first retry; it doubles on each attempt
+// It's a variable so tests don't have to wait for real backoff delays
+var retryBackoff = time.Second
+
+// sendTimeout bounds each attempt, so a stuck server can't hold a retry loop forever
+const sendTimeout = 30 * time.Second
+
+// SendAsync sends msg with m in the background, retrying failures with
+// exponential backoff (MAIL_MAX_RETRIES)
+// Failures are logged, never returned: callers like the reminder scheduler
+// carry on whether or not the mail server is reachable.
+func SendAsync(m Mailer, msg Message) {
+	maxRetries := config.Get().MailMaxRetries
+	go func() {
+		// A bug in a mailer must never crash the server
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("Sending email %q panicked: %v", msg.Subject, rec)
+			}
+		}()
+
+		if err := deliver(m, msg, maxRetries); err != nil {
+			log.Printf("Sending email %q to %v failed: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
+
+// deliver sends msg, making up to maxRetries additional attempts after a failure
+func deliver(m Mailer, msg Message, maxRetries int) error {
+	backoff := retryBackoff
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = m.Send(ctx, msg)
+		cancel()
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", maxRetries+1, err)
+}
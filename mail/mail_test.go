@@ -0,0 +1,67 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyMailer fails a set number of times before sending
+type flakyMailer struct {
+	failures int
+	attempts int
+}
+
+func (m *flakyMailer) Send(ctx context.Context, msg Message) error {
+	m.attempts++
+	if m.attempts <= m.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+// TestDeliverRetries tests that failed sends are retried up to the limit
+func TestDeliverRetries(t *testing.T) {
+	previous := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = previous })
+
+	msg := Message{To: []string{"user@example.com"}, Subject: "Hello"}
+
+	recovers := &flakyMailer{failures: 2}
+	if err := deliver(recovers, msg, 3); err != nil {
+		t.Errorf("Expected the third attempt to succeed, got %v", err)
+	}
+	if recovers.attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", recovers.attempts)
+	}
+
+	fails := &flakyMailer{failures: 10}
+	if err := deliver(fails, msg, 2); err == nil {
+		t.Errorf("Expected an error once the retries run out")
+	}
+	if fails.attempts != 3 {
+		t.Errorf("Expected 1 attempt and 2 retries, got %d attempts", fails.attempts)
+	}
+}
+
+// panickingMailer panics instead of sending
+type panickingMailer struct{ done chan struct{} }
+
+func (m panickingMailer) Send(ctx context.Context, msg Message) error {
+	defer close(m.done)
+	panic("broken mailer")
+}
+
+// TestSendAsyncRecovers tests that a panicking mailer doesn't take the server down
+func TestSendAsyncRecovers(t *testing.T) {
+	m := panickingMailer{done: make(chan struct{})}
+	SendAsync(m, Message{To: []string{"user@example.com"}, Subject: "Hello"})
+
+	select {
+	case <-m.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the message to be sent in the background")
+	}
+}
@@ -0,0 +1,134 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP sends email through an SMTP server (SMTP_HOST, SMTP_PORT, ...)
+// Port 465 uses implicit TLS; on any other port the connection is upgraded
+// with STARTTLS when the server offers it. Credentials are only sent over TLS.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string // Empty skips authentication
+	Password string
+	From     string // Sender address, e.g. "Tasks <tasks@example.com>"
+}
+
+// Send delivers msg to every recipient in one SMTP transaction
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("mail: message has no recipients")
+	}
+	from, err := mailAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("mail: invalid sender: %w", err)
+	}
+	var recipients []string
+	for _, to := range msg.To {
+		address, err := mailAddress(to)
+		if err != nil {
+			return fmt.Errorf("mail: invalid recipient: %w", err)
+		}
+		recipients = append(recipients, address)
+	}
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	// The smtp package has no contexts; a deadline keeps a stuck server from hanging us
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if _, isTLS := conn.(*tls.Conn); !isTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+				return err
+			}
+		}
+	}
+	if s.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, to := range recipients {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.format(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial connects to the server, with TLS from the start on port 465
+func (s *SMTP) dial(ctx context.Context) (net.Conn, error) {
+	address := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	if s.Port == 465 {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: s.Host}}
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+// format renders msg with its headers
+// Line breaks are stripped from header values so a subject can't add headers.
+func (s *SMTP) format(msg Message) []byte {
+	header := func(value string) string {
+		return strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+	}
+
+	var b strings.Builder
+	b.WriteString("From: " + header(s.From) + "\r\n")
+	b.WriteString("To: " + header(strings.Join(msg.To, ", ")) + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", header(msg.Subject)) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// mailAddress returns the bare address of "Name <address>" or "address"
+func mailAddress(value string) (string, error) {
+	parsed, err := netmail.ParseAddress(value)
+	if err != nil {
+		return "", err
+	}
+	return parsed.Address, nil
+}
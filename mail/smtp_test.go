@@ -0,0 +1,112 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer accepts one message and sends back what it received
+// It speaks just enough SMTP for net/smtp, without STARTTLS or AUTH.
+func fakeSMTPServer(t *testing.T) (host string, port int, received <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+
+		var transcript strings.Builder
+		text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"):
+				text.PrintfLine("250 localhost")
+			case strings.HasPrefix(command, "MAIL"), strings.HasPrefix(command, "RCPT"):
+				transcript.WriteString(line + "\n")
+				text.PrintfLine("250 OK")
+			case command == "DATA":
+				text.PrintfLine("354 Go ahead")
+				data, err := text.ReadDotLines()
+				if err != nil {
+					return
+				}
+				transcript.WriteString(strings.Join(data, "\n"))
+				text.PrintfLine("250 Queued")
+			case command == "QUIT":
+				text.PrintfLine("221 Bye")
+				messages <- transcript.String()
+				return
+			default:
+				text.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, messages
+}
+
+// TestSMTPSend tests the SMTP conversation and the message sent
+func TestSMTPSend(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+	mailer := &SMTP{Host: host, Port: port, From: "Tasks <tasks@example.com>"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := mailer.Send(ctx, Message{
+		To:      []string{"user@example.com"},
+		Subject: "Due soon\r\nBcc: attacker@example.com",
+		Body:    "Line one\n.\nLine three",
+	})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	var transcript string
+	select {
+	case transcript = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the server to receive a message")
+	}
+	for _, expected := range []string{
+		"MAIL FROM:<tasks@example.com>",
+		"RCPT TO:<user@example.com>",
+		"From: Tasks <tasks@example.com>",
+		"Subject: Due soon Bcc: attacker@example.com",
+		"Line one\n.\nLine three",
+	} {
+		if !strings.Contains(transcript, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, transcript)
+		}
+	}
+	if strings.Contains(transcript, "\nBcc:") {
+		t.Errorf("Expected line breaks in the subject not to add headers:\n%s", transcript)
+	}
+}
+
+// TestSMTPSendRejectsBadAddresses tests that nothing is sent to invalid recipients
+func TestSMTPSendRejectsBadAddresses(t *testing.T) {
+	mailer := &SMTP{Host: "127.0.0.1", Port: 1, From: "tasks@example.com"}
+	for _, to := range [][]string{nil, {"not an address"}} {
+		if err := mailer.Send(context.Background(), Message{To: to, Subject: "Hi"}); err == nil {
+			t.Errorf("Expected recipients %v to be rejected", to)
+		}
+	}
+}
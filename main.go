@@ -1,27 +1,182 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/kcansari/task-management-api/config"
 	"github.com/kcansari/task-management-api/database"
 	"github.com/kcansari/task-management-api/handlers"
+	"github.com/kcansari/task-management-api/mail"
 	"github.com/kcansari/task-management-api/middleware"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/reminders"
+	"github.com/kcansari/task-management-api/storage"
+	"github.com/kcansari/task-management-api/tracing"
+	"github.com/kcansari/task-management-api/utils"
 )
 
 func main() {
+	// -migrate-down rolls back the most recent schema migration and exits
+	migrateDown := flag.Bool("migrate-down", false, "roll back the most recently applied migration and exit")
+	// -seed-bulk=N creates N tasks for load testing and exits (never with ENV=production)
+	seedBulk := flag.Int("seed-bulk", 0, "create this many tasks for load testing and exit")
+	seedBulkUser := flag.String("seed-bulk-user", "loadtest@example.com", "owner of the -seed-bulk tasks, created if missing")
+	seedBulkPassword := flag.String("seed-bulk-password", "loadtest-password", "password for a user created by -seed-bulk")
+	flag.Parse()
+
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	config.Set(cfg)
+
+	// Mask passwords, tokens and the like (LOG_REDACT_KEYS) in everything logged
+	// through log and slog's default logger, which writes through log
+	log.SetOutput(utils.NewRedactor(cfg.LogRedactKeys).Writer(os.Stderr))
+
+	// Show which settings are in effect (secrets masked), to catch env vars that weren't picked up
+	cfg.LogEffective()
+
+	// Fail fast on a broken status workflow rather than on the first request
+	if _, err := models.NewWorkflow(cfg.TaskStatuses, cfg.DefaultTaskStatus, cfg.TaskTransitions); err != nil {
+		log.Fatalf("Invalid task workflow configuration: %v", err)
+	}
+
+	// Refuse to sign tokens with a secret shorter than JWT_MIN_SECRET_LENGTH (already validated)
+	utils.SetMinSecretLength(cfg.JWTMinSecretLength)
+
+	// Encrypt task descriptions at rest when ENCRYPTION_KEY is set (already validated)
+	encryptionKey, _ := cfg.EncryptionKeyBytes()
+	if err := models.SetEncryptionKey(encryptionKey); err != nil {
+		log.Fatalf("Invalid encryption key: %v", err)
+	}
+
+	if *migrateDown {
+		if err := database.Connect(cfg); err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		defer database.Close()
+
+		if err := database.RollbackMigration(); err != nil {
+			log.Fatalf("Failed to roll back migration: %v", err)
+		}
+		log.Println("Rolled back the most recent migration")
+		return
+	}
+
+	// Checked before touching the database, so a mistake never reaches production data
+	if *seedBulk < 0 {
+		log.Fatalf("-seed-bulk must be a positive number of tasks, got %d", *seedBulk)
+	}
+	if *seedBulk > 0 && cfg.Env == "production" {
+		log.Fatalf("Refusing to run -seed-bulk with ENV=production")
+	}
 
 	if err := database.Initialize(cfg); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer database.Close()
 
+	if *seedBulk > 0 {
+		err := database.SeedBulk(cfg, database.BulkSeedOptions{
+			Tasks:     *seedBulk,
+			UserEmail: *seedBulkUser,
+			Password:  *seedBulkPassword,
+		})
+		if err != nil {
+			log.Fatalf("Failed to seed bulk data: %v", err)
+		}
+		return
+	}
+
 	if err := database.HealthCheck(); err != nil {
 		log.Fatalf("Database health check failed: %v", err)
 	}
 
+	// SIGHUP re-reads the maintenance settings, so writes can be paused for a
+	// migration without restarting (the /health endpoint is never affected)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := config.ReloadMaintenance(); err != nil {
+				log.Printf("Failed to reload maintenance settings: %v", err)
+				continue
+			}
+			log.Printf("Maintenance mode: %t", config.Get().MaintenanceMode)
+		}
+	}()
+
+	// Cache single-task reads for dashboards that poll the same tasks
+	if cfg.TaskCacheEnabled {
+		handlers.EnableTaskCache(cfg.TaskCacheSize, cfg.TaskCacheTTL)
+		log.Printf("Task cache enabled (%d entries, TTL %s)", cfg.TaskCacheSize, cfg.TaskCacheTTL)
+	}
+
+	// Keep serving task reads, stale, while the database is down
+	if cfg.DegradedReads {
+		handlers.EnableStaleReads(cfg.DegradedCacheSize, cfg.DegradedCacheTTL)
+		log.Printf("Degraded reads enabled (%d responses, TTL %s)", cfg.DegradedCacheSize, cfg.DegradedCacheTTL)
+	}
+
+	// Notice tokens used from more than one IP
+	if cfg.TokenReplayCheck == config.TokenReplayLog || cfg.TokenReplayCheck == config.TokenReplayBlock {
+		middleware.EnableTokenReplayCheck(cfg.TokenReplayMaxTokens)
+		log.Printf("Token replay check enabled (%s, up to %d tokens)", cfg.TokenReplayCheck, cfg.TokenReplayMaxTokens)
+	}
+
+	// Log the TASK_UPDATE_LOCK and COLLAPSE_DUPLICATE_GETS counters now and then
+	if cfg.DedupStatsInterval > 0 {
+		go logDedupStats(cfg.DedupStatsInterval)
+	}
+
+	// Let deep pagination through GET /api/tasks skip counting on every page
+	if cfg.PageSnapshotsEnabled {
+		handlers.EnablePaginationSnapshots(cfg.PageSnapshotTTL)
+		log.Printf("Pagination snapshots enabled (TTL %s)", cfg.PageSnapshotTTL)
+	}
+
+	// Attachments go to a local directory or an S3-compatible bucket (ATTACHMENT_STORAGE)
+	attachments, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to set up attachment storage: %v", err)
+	}
+	handlers.SetAttachmentStorage(attachments)
+
+	// Remind users about tasks that are due soon (REMINDER_INTERVAL=0 turns this off)
+	// Each due date is claimed by exactly one instance, so running replicas is safe
+	if cfg.ReminderInterval > 0 {
+		scheduler := &reminders.Scheduler{
+			DB:       database.GetDB(),
+			Interval: cfg.ReminderInterval,
+			Window:   cfg.ReminderWindow,
+			Timeout:  cfg.DBQueryTimeout,
+			Notify:   handlers.NotifyTaskDue,
+			Mailer:   mail.New(cfg), // Logs instead of sending without SMTP_HOST
+		}
+		go scheduler.Run(context.Background())
+	}
+
+	// Send request and query spans to an OpenTelemetry collector (OTEL_EXPORTER_OTLP_ENDPOINT)
+	if cfg.OTLPEndpoint != "" {
+		exporter := tracing.NewOTLPExporter(cfg.OTLPEndpoint, cfg.OTLPHeaderMap(), cfg.OTelServiceName)
+		tracing.SetDefault(tracing.NewTracer(exporter))
+		for _, db := range database.Connections() {
+			if err := database.EnableTracing(db); err != nil {
+				log.Fatalf("Failed to enable query tracing: %v", err)
+			}
+		}
+		log.Printf("Tracing enabled (exporting to %s)", cfg.OTLPEndpoint)
+	}
+
 	// Root endpoint - simple welcome message
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -38,47 +193,336 @@ func main() {
 		w.Write([]byte("OK"))
 	})
 
-	// Authentication endpoints (public - no middleware required)
+	// Authentication endpoints (public - no auth middleware required)
+	// MaintenanceAuth returns 503 during maintenance when MAINTENANCE_BLOCK_AUTH is set
+	// RequireJSON returns 415 for write requests that aren't sent as application/json
 	// POST /api/auth/register - Register a new user
-	http.HandleFunc("/api/auth/register", handlers.Register)
+	http.HandleFunc("/api/auth/register", middleware.MaintenanceAuth(middleware.RequireJSON(handlers.Register)))
 	
 	// POST /api/auth/login - Login existing user
-	http.HandleFunc("/api/auth/login", handlers.Login)
+	http.HandleFunc("/api/auth/login", middleware.MaintenanceAuth(middleware.RequireJSON(handlers.Login)))
+
+	// GET /api/public/tasks/{id} - Read a task its owner made public
+	// The one task route without AuthMiddleware: anyone with the link can read
+	// it, and the response leaves out everything about the owner
+	http.HandleFunc("/api/public/tasks/", middleware.Maintenance(handlers.GetPublicTask))
+
+	// POST /api/auth/change-password - Change the authenticated user's password
+	// Unlike register/login this needs a token, and it's a write blocked during maintenance
+	http.HandleFunc("/api/auth/change-password", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(handlers.ChangePassword)))))
+
+	// GET /api/auth/me - The authenticated user's account, including their last login
+	http.HandleFunc("/api/auth/me", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.GetCurrentUser))))
+
+	// GET /api/auth/usage - The authenticated user's request count and quota for the current period
+	// Deliberately not wrapped in TrackUsage: checking usage doesn't use any up,
+	// and a user over their quota can still see when it resets
+	http.HandleFunc("/api/auth/usage", middleware.Maintenance(middleware.AuthMiddleware(handlers.GetAPIUsage)))
 
 	// Protected Task endpoints (require authentication)
 	// These routes use middleware.AuthMiddleware to ensure user is authenticated
 	// The middleware extracts JWT token, validates it, and adds user info to context
+	// TrackUsage then counts the request against the user's quota (API_USAGE_TRACKING)
+	// Maintenance returns 503 for writes while MAINTENANCE_MODE is on (reads still work)
 	
 	// Handle /api/tasks (without trailing slash) - for listing and creating tasks
-	http.HandleFunc("/api/tasks", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/api/tasks", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(func(w http.ResponseWriter, r *http.Request) {
 		// Route based on HTTP method
 		switch r.Method {
 		case "GET":
-			handlers.GetTasks(w, r)    // Get all tasks for user
+			middleware.CollapseDuplicates(handlers.ServeStaleOnOutage(handlers.GetTasks))(w, r) // Get all tasks for user (stale while the database is down)
 		case "POST":
 			handlers.CreateTask(w, r)  // Create new task
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
-			w.Write([]byte(`{"error":"Method not allowed"}`))
+			w.Write([]byte(`{"error":"Method not allowed","code":"METHOD_NOT_ALLOWED"}`))
 		}
-	}))
+	})))))
 	
+	// POST /api/tasks/batch-status - Change the status of several tasks at once
+	// Registered as an exact path so it takes precedence over /api/tasks/{id}
+	http.HandleFunc("/api/tasks/batch-status", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(handlers.BatchUpdateTaskStatus)))))
+
+	// GET /api/tasks/sync?since= - Tasks changed or deleted since the last sync, for offline clients
+	http.HandleFunc("/api/tasks/sync", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.SyncTasks))))
+
+	// GET /api/tasks/statuses - How many of the user's tasks have each status
+	// Registered as an exact path so it takes precedence over /api/tasks/{id}
+	http.HandleFunc("/api/tasks/statuses", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.GetTaskStatusCounts))))
+
+	// PUT /api/tasks/by-client-id/{uuid} - Create or update a task by the UUID an offline client gave it
+	// Registered as a subtree so it takes precedence over /api/tasks/{id}
+	http.HandleFunc("/api/tasks/by-client-id/", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(handlers.UpsertTaskByClientID)))))
+
+	// GET /api/tasks/board - The user's tasks grouped by status, for a kanban board
+	// Registered as an exact path so it takes precedence over /api/tasks/{id}
+	http.HandleFunc("/api/tasks/board", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.GetTaskBoard))))
+
+	// GET /api/tasks/due?range=today|week|overdue - Unfinished tasks due in a window, computed in the user's time zone
+	// Registered as an exact path so it takes precedence over /api/tasks/{id}
+	http.HandleFunc("/api/tasks/due", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.GetDueTasks))))
+
+	// POST /api/tasks/reorder - Save a new manual order for some of the user's tasks
+	// Registered as an exact path so it takes precedence over /api/tasks/{id}
+	http.HandleFunc("/api/tasks/reorder", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(handlers.ReorderTasks)))))
+
+	// POST /api/tasks/validate - Check a task payload the way POST /api/tasks would, without creating it
+	// Registered as an exact path so it takes precedence over /api/tasks/{id}
+	// Not wrapped in Maintenance: it never writes
+	http.HandleFunc("/api/tasks/validate", middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(handlers.ValidateTask))))
+
+	// POST /api/tasks/search - List tasks matching filters sent as JSON
+	// Registered as an exact path so it takes precedence over /api/tasks/{id}
+	// Not wrapped in Maintenance: it's a POST, but it only reads
+	http.HandleFunc("/api/tasks/search", middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(handlers.SearchTasks))))
+
+	// GET /api/tasks/stream - Server-Sent Events with the user's task changes
+	// Not wrapped in RequireJSON: it only serves GET and answers with text/event-stream
+	http.HandleFunc("/api/tasks/stream", middleware.AuthMiddleware(middleware.TrackUsage(handlers.StreamTasks)))
+
 	// Handle /api/tasks/{id} (with trailing slash) - for individual task operations
-	http.HandleFunc("/api/tasks/", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	taskRoutes := middleware.RequireJSON(func(w http.ResponseWriter, r *http.Request) {
+		// Sub-resources of a task: /api/tasks/{id}/...
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/history"):
+			handlers.GetTaskHistory(w, r) // Get status change history
+			return
+		case strings.HasSuffix(r.URL.Path, "/transfer"):
+			handlers.TransferTask(w, r) // Hand the task to another user
+			return
+		case strings.HasSuffix(r.URL.Path, "/export"):
+			handlers.ExportTask(w, r) // Render the task as Markdown
+			return
+		case strings.HasSuffix(r.URL.Path, "/reopen"):
+			handlers.ReopenTask(w, r) // Move a completed task back to TASK_REOPEN_STATUS
+			return
+		case strings.HasSuffix(r.URL.Path, "/shares"):
+			switch r.Method {
+			case "GET":
+				handlers.GetTaskShares(w, r) // List who the task is shared with
+			case "POST":
+				handlers.ShareTask(w, r) // Share the task with another user
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				w.Write([]byte(`{"error":"Method not allowed","code":"METHOD_NOT_ALLOWED"}`))
+			}
+			return
+		case strings.Contains(r.URL.Path, "/shares/"):
+			handlers.RevokeTaskShare(w, r) // Stop sharing the task with a user
+			return
+		case strings.HasSuffix(r.URL.Path, "/checklist"):
+			switch r.Method {
+			case "GET":
+				handlers.GetChecklist(w, r) // List the checklist items in order
+			case "POST":
+				handlers.AddChecklistItem(w, r) // Add an item to the end
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				w.Write([]byte(`{"error":"Method not allowed","code":"METHOD_NOT_ALLOWED"}`))
+			}
+			return
+		case strings.HasSuffix(r.URL.Path, "/time-entries"):
+			switch r.Method {
+			case "GET":
+				handlers.GetTimeEntries(w, r) // List the time logged on the task
+			case "POST":
+				handlers.LogTimeEntry(w, r) // Log time spent after the fact
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				w.Write([]byte(`{"error":"Method not allowed","code":"METHOD_NOT_ALLOWED"}`))
+			}
+			return
+		case strings.HasSuffix(r.URL.Path, "/comments"):
+			switch r.Method {
+			case "GET":
+				handlers.GetComments(w, r) // List the task's comments
+			case "POST":
+				handlers.AddComment(w, r) // Comment, notifying @-mentioned users
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				w.Write([]byte(`{"error":"Method not allowed","code":"METHOD_NOT_ALLOWED"}`))
+			}
+			return
+		case strings.HasSuffix(r.URL.Path, "/timer/start"):
+			handlers.StartTimer(w, r) // Start timing work on the task
+			return
+		case strings.HasSuffix(r.URL.Path, "/timer/stop"):
+			handlers.StopTimer(w, r) // Stop the timer and add it to the total
+			return
+		case strings.HasSuffix(r.URL.Path, "/checklist/reorder"):
+			handlers.ReorderChecklist(w, r) // Save a new order for the items
+			return
+		case strings.Contains(r.URL.Path, "/checklist/"):
+			switch r.Method {
+			case "PUT", "PATCH":
+				handlers.UpdateChecklistItem(w, r) // Tick off, untick or rename an item
+			case "DELETE":
+				handlers.DeleteChecklistItem(w, r) // Remove an item
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				w.Write([]byte(`{"error":"Method not allowed","code":"METHOD_NOT_ALLOWED"}`))
+			}
+			return
+		}
+
 		// Route to appropriate handler based on HTTP method
 		switch r.Method {
 		case "GET":
-			handlers.GetTask(w, r)     // Get specific task
-		case "PUT":
-			handlers.UpdateTask(w, r)  // Update specific task
+			middleware.CollapseDuplicates(handlers.ServeStaleOnOutage(handlers.GetTask))(w, r) // Get specific task (stale while the database is down)
+		case "PUT", "PATCH":
+			handlers.UpdateTask(w, r)  // Update specific task (both are partial updates)
 		case "DELETE":
 			handlers.DeleteTask(w, r)  // Delete specific task
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
-			w.Write([]byte(`{"error":"Method not allowed"}`))
+			w.Write([]byte(`{"error":"Method not allowed","code":"METHOD_NOT_ALLOWED"}`))
+		}
+	})
+	// With TASK_ID_FORMAT=uuid, ResolvePublicTaskID swaps the task's public ID in the path for its sequential ID
+	http.HandleFunc("/api/tasks/", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.ResolvePublicTaskID(func(w http.ResponseWriter, r *http.Request) {
+		// Attachments are uploaded as multipart/form-data, so they skip RequireJSON
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/attachments"):
+			switch r.Method {
+			case "GET":
+				handlers.GetTaskAttachments(w, r) // List the task's attachments
+			case "POST":
+				handlers.UploadTaskAttachment(w, r) // Upload a file
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				w.Write([]byte(`{"error":"Method not allowed","code":"METHOD_NOT_ALLOWED"}`))
+			}
+		case strings.Contains(r.URL.Path, "/attachments/"):
+			handlers.DownloadTaskAttachment(w, r) // Download a file
+		default:
+			taskRoutes(w, r)
+		}
+	})))))
+
+	// Webhook endpoints (require authentication)
+	// Handle /api/webhooks - list and register webhooks
+	http.HandleFunc("/api/webhooks", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			handlers.GetWebhooks(w, r)   // List the user's webhooks
+		case "POST":
+			handlers.CreateWebhook(w, r) // Register a new webhook
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			w.Write([]byte(`{"error":"Method not allowed","code":"METHOD_NOT_ALLOWED"}`))
+		}
+	})))))
+
+	// DELETE /api/webhooks/{id} - Remove a webhook
+	http.HandleFunc("/api/webhooks/", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.DeleteWebhook))))
+
+	// Notification endpoints (require authentication)
+	// GET /api/notifications - List the caller's notifications, newest first
+	http.HandleFunc("/api/notifications", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.GetNotifications))))
+
+	// POST /api/notifications/{id}/read - Mark a notification read
+	http.HandleFunc("/api/notifications/", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.MarkNotificationRead))))
+
+	// GET /api/activity - The caller's task activity feed, newest first
+	http.HandleFunc("/api/activity", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.GetActivity))))
+
+	// Organization endpoints (require authentication)
+	// POST /api/organization/members - Add a user to the caller's organization (admins only)
+	http.HandleFunc("/api/organization/members", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(handlers.CreateOrganizationMember)))))
+
+	// POST /api/organization/members/{id}/sign-out - Invalidate all of a member's tokens (admins only)
+	http.HandleFunc("/api/organization/members/", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.SignOutMember))))
+
+	// Admin endpoints (require authentication, organization admins only)
+	// GET /api/admin/users - List the organization's users
+	http.HandleFunc("/api/admin/users", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(handlers.GetAdminUsers))))
+
+	// PATCH /api/admin/users/{id} - Deactivate, reactivate or change the role of a user
+	http.HandleFunc("/api/admin/users/", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(handlers.UpdateAdminUser)))))
+
+	// User settings endpoints (require authentication)
+	// Handle /api/user/settings - read and replace the caller's preferences
+	http.HandleFunc("/api/user/settings", middleware.Maintenance(middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			handlers.GetUserSettings(w, r)    // Current settings (null = default)
+		case "PUT":
+			handlers.UpdateUserSettings(w, r) // Replace all settings
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			w.Write([]byte(`{"error":"Method not allowed","code":"METHOD_NOT_ALLOWED"}`))
+		}
+	})))))
+
+	// GraphQL endpoint (requires authentication)
+	// POST /graphql - Queries and mutations, resolved by the REST handlers above
+	// Not wrapped in Maintenance: queries are reads, and mutations are checked one by one
+	http.HandleFunc("/graphql", middleware.AuthMiddleware(middleware.TrackUsage(middleware.RequireJSON(handlers.GraphQL))))
+
+	// Timeout answers requests still running after REQUEST_TIMEOUT with 504
+	// Attachment transfers take as long as the client's connection needs, so they aren't timed;
+	// their handlers lift the server's read and write deadlines for the same reason
+	timed := middleware.Timeout(cfg.RequestTimeout)(http.DefaultServeMux.ServeHTTP)
+
+	// Limit answers requests beyond MAX_CONCURRENT_REQUESTS with 503 instead of queueing them
+	// Event streams stay open as long as the client listens, so they don't take a slot (or time out)
+	limited := middleware.Limit(cfg.MaxConcurrentRequests)(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/attachments") {
+			http.DefaultServeMux.ServeHTTP(w, r)
+			return
+		}
+		timed(w, r)
+	})
+	routes := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tasks/stream" {
+			http.DefaultServeMux.ServeHTTP(w, r)
+			return
+		}
+		limited(w, r)
+	}
+
+	// Use an explicit http.Server so slow or idle clients can't hold connections open forever
+	// Long-lived responses (e.g. streaming) must extend their own write deadline
+	// LogSlowRequests wraps every route and logs the ones slower than SLOW_REQUEST_THRESHOLD
+	// CORS answers browser preflights before auth, the limit or maintenance see them
+	// Trace records a span per request when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	// RequireHTTPS redirects plain HTTP to HTTPS first when FORCE_HTTPS is set
+	handler := middleware.LogSlowRequests(middleware.Trace(http.DefaultServeMux)(middleware.RequireHTTPS(middleware.CORS(routes))))
+	// DB_QUERY_COUNT reports each request's SQL query count, to catch N+1 queries
+	if cfg.DBQueryCount {
+		for _, db := range database.Connections() {
+			if err := database.EnableQueryCounting(db); err != nil {
+				log.Fatalf("Failed to enable query counting: %v", err)
+			}
 		}
-	}))
+		handler = middleware.CountQueries(handler)
+		log.Printf("Query counting enabled (%s header)", middleware.QueryCountHeader)
+	}
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
+	}
 
 	log.Printf("Server starting on port %s", cfg.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Port, nil))
+	log.Fatal(server.ListenAndServe())
+}
+
+// logDedupStats logs every interval how many task updates waited for or were
+// refused by another update of the same task, and how many GETs got another
+// request's response, whenever those counts changed
+func logDedupStats(interval time.Duration) {
+	var logged [3]int64
+	for range time.Tick(interval) {
+		waited, rejected := handlers.TaskUpdateLockStats()
+		counts := [3]int64{waited, rejected, middleware.CollapsedRequests()}
+		if counts == logged {
+			continue
+		}
+		logged = counts
+		log.Printf("Deduplication since startup: %d task updates waited and %d were refused (TASK_UPDATE_LOCK), %d GETs were collapsed (COLLAPSE_DUPLICATE_GETS)", waited, rejected, counts[2])
+	}
 }
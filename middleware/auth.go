@@ -3,11 +3,18 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
 
+	"github.com/kcansari/task-management-api/apierror"
 	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/models"
+	"github.com/kcansari/task-management-api/tracing"
 	"github.com/kcansari/task-management-api/utils"
+	"gorm.io/gorm"
 )
 
 // ContextKey is a custom type for context keys to avoid collisions
@@ -22,13 +29,40 @@ const UserContextKey ContextKey = "user"
 // UserContext represents the user data we store in request context
 // This is what protected handlers will have access to
 type UserContext struct {
-	UserID uint   `json:"user_id"` // ID of the authenticated user
-	Email  string `json:"email"`   // Email of the authenticated user
+	UserID uint            `json:"user_id"` // ID of the authenticated user
+	Email  string          `json:"email"`   // Email of the authenticated user
+	OrgID  uint            `json:"org_id"`  // Organization every query is scoped to
+	Role   models.UserRole `json:"role"`    // Role within that organization
+	// IANA time zone from the user's settings; "" when they have none (UTC)
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// IsAdmin reports whether the user administers their organization
+func (u UserContext) IsAdmin() bool {
+	return u.Role == models.RoleAdmin
 }
 
 // ErrorResponse represents an error message for middleware responses
 type ErrorResponse struct {
-	Error string `json:"error"` // Human-readable error message
+	Error string        `json:"error"` // Human-readable error message
+	Code  apierror.Code `json:"code"`  // Machine-readable error code
+}
+
+// writeError sends a middleware error response with the given status and code
+// It matches the handlers' error format: localized from Accept-Language, and
+// enveloped when the client's Accept header asks for apierror.EnvelopeMediaType
+func writeError(w http.ResponseWriter, r *http.Request, status int, code apierror.Code, message string) {
+	message = apierror.Localize(r.Header.Get("Accept-Language"), code, message)
+	if apierror.WantsEnvelope(r.Header.Get("Accept")) {
+		w.Header().Set("Content-Type", apierror.EnvelopeMediaType)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(apierror.NewErrorEnvelope(status, code, message))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Code: code})
 }
 
 // AuthMiddleware is a higher-order function that returns HTTP middleware
@@ -48,8 +82,7 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// Check if Authorization header is present
 		if authHeader == "" {
 			// No authorization header provided
-			w.WriteHeader(http.StatusUnauthorized) // 401 Unauthorized
-			json.NewEncoder(w).Encode(ErrorResponse{Error: "Authorization header required"})
+			writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "Authorization header required") // 401 Unauthorized
 			return // Stop processing, don't call next handler
 		}
 
@@ -61,8 +94,7 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// Validate Authorization header format
 		if len(parts) != 2 {
 			// Header doesn't have exactly 2 parts (scheme and token)
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid authorization header format"})
+			writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "Invalid authorization header format")
 			return
 		}
 
@@ -73,19 +105,61 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// Verify the authentication scheme is Bearer
 		// Bearer token is the standard for JWT authentication
 		if scheme != "Bearer" {
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid authorization scheme. Use Bearer"})
+			writeError(w, r, http.StatusUnauthorized, apierror.Unauthorized, "Invalid authorization scheme. Use Bearer")
 			return
 		}
 
 		// Validate the JWT token using our utility function
-		// Load configuration to get the JWT secret key
-		cfg := config.Load()
-		claims, err := utils.ValidateToken(token, cfg.JWTSecret)
+		// Load configuration to get the JWT secret keys (current and, during a rotation, previous)
+		cfg := config.Get()
+		claims, err := utils.ValidateToken(token, cfg.JWTVerificationSecrets(), cfg.JWTIssuer)
 		if err != nil {
 			// Token validation failed (expired, invalid signature, malformed, etc.)
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid or expired token"})
+			writeError(w, r, http.StatusUnauthorized, apierror.InvalidToken, "Invalid or expired token")
+			return
+		}
+
+		// Tokens issued before organizations existed can't be scoped to one
+		// Rejecting them makes the client log in again and get a current token
+		if claims.OrgID == 0 {
+			writeError(w, r, http.StatusUnauthorized, apierror.InvalidToken, "Invalid or expired token")
+			return
+		}
+
+		// With TOKEN_REPLAY_CHECK, a token showing up from a second IP is
+		// logged or refused (see checkTokenReplay)
+		if !checkTokenReplay(w, r, claims, cfg) {
+			return
+		}
+
+		// "Sign out everywhere" bumps the user's token version, so the token's
+		// version must still be the current one. This also rejects tokens of
+		// deleted users. It costs one primary key lookup per request, which
+		// also picks up the user's timezone setting for the response.
+		var current struct {
+			TokenVersion int
+			Timezone     *string
+		}
+		err = database.WithContext(r.Context()).Model(&models.User{}).
+			Select("users.token_version, user_settings.timezone").
+			Joins("LEFT JOIN user_settings ON user_settings.user_id = users.id").
+			Where("users.id = ?", claims.UserID).Take(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && current.TokenVersion != claims.TokenVersion) {
+			writeError(w, r, http.StatusUnauthorized, apierror.InvalidToken, "Invalid or expired token")
+			return
+		}
+		if err != nil && database.IsUnavailable(err) {
+			// With DEGRADED_READS, reads go on with the signed token alone so
+			// they can be served stale; a revoked token is only noticed again
+			// once the database is back
+			if !config.Get().DegradedReads || (r.Method != "GET" && r.Method != "HEAD") {
+				writeError(w, r, http.StatusServiceUnavailable, apierror.DatabaseUnavailable, "Database is unavailable, try again shortly") // 503
+				return
+			}
+			log.Printf("Database unavailable, accepting token of user %d without checking its version: %v", claims.UserID, err)
+		} else if err != nil {
+			log.Printf("Failed to look up token version of user %d: %v", claims.UserID, err)
+			writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Failed to verify token")
 			return
 		}
 
@@ -93,6 +167,11 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		userCtx := UserContext{
 			UserID: claims.UserID,
 			Email:  claims.Email,
+			OrgID:  claims.OrgID,
+			Role:   models.UserRole(claims.Role),
+		}
+		if current.Timezone != nil {
+			userCtx.Timezone = *current.Timezone
 		}
 
 		// Add user information to the request context
@@ -104,6 +183,9 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// In Go, context is immutable, so we need to create a new request
 		r = r.WithContext(ctx)
 
+		// Traced requests (see Trace) record who made them
+		tracing.SpanFromContext(ctx).SetAttribute("user_id", claims.UserID)
+
 		// Authentication successful! Call the next handler in the chain
 		// This is where the actual route handler (like GetTasks) will execute
 		next(w, r)
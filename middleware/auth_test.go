@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/utils"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestAuthMiddleware tests the authentication gate on protected routes
+func TestAuthMiddleware(t *testing.T) {
+	// Tokens must be signed with the same secret the middleware validates against
+	secret := config.Get().JWTSecret
+
+	validToken, err := utils.GenerateToken(42, "auth-test@example.com", 3, "admin", 0, secret, config.Get().JWTIssuer)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	wrongSecretToken, err := utils.GenerateToken(42, "auth-test@example.com", 3, "admin", 0, secret+"-other", config.Get().JWTIssuer)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// Tokens from before organizations existed carry no org_id
+	noOrgToken, err := utils.GenerateToken(42, "auth-test@example.com", 0, "", 0, secret, config.Get().JWTIssuer)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// Build an already expired token by hand
+	expiredClaims := utils.Claims{
+		UserID: 42,
+		Email:  "auth-test@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-2 * time.Hour)),
+		},
+	}
+	expiredToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, expiredClaims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("Failed to sign expired token: %v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		authHeader     string
+		expectedStatus int
+		expectedCode   apierror.Code // Error code for rejected requests
+	}{
+		{"missing header", "", http.StatusUnauthorized, apierror.Unauthorized},
+		{"malformed header without space", "Bearer" + validToken, http.StatusUnauthorized, apierror.Unauthorized},
+		{"wrong scheme", "Basic " + validToken, http.StatusUnauthorized, apierror.Unauthorized},
+		{"garbage token", "Bearer not-a-jwt", http.StatusUnauthorized, apierror.InvalidToken},
+		{"wrong secret", "Bearer " + wrongSecretToken, http.StatusUnauthorized, apierror.InvalidToken},
+		{"expired token", "Bearer " + expiredToken, http.StatusUnauthorized, apierror.InvalidToken},
+		{"token without organization", "Bearer " + noOrgToken, http.StatusUnauthorized, apierror.InvalidToken},
+		// Valid tokens are checked against the user's token version in the
+		// database; handlers' TestSignOutMember covers them
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			handler := AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/api/tasks", nil)
+			if tc.authHeader != "" {
+				req.Header.Set("Authorization", tc.authHeader)
+			}
+			rr := httptest.NewRecorder()
+
+			handler(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+
+			// Rejected requests must never reach the protected handler
+			if called {
+				t.Errorf("Expected next handler not to be called")
+			}
+
+			var response ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal error response: %v", err)
+			}
+			if response.Code != tc.expectedCode {
+				t.Errorf("Expected code %s, got %s", tc.expectedCode, response.Code)
+			}
+		})
+	}
+}
+
+// TestGetUserFromContextMissing tests that requests without the middleware have no user
+func TestGetUserFromContextMissing(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/tasks", nil)
+
+	if _, ok := GetUserFromContext(req); ok {
+		t.Errorf("Expected no user in a request that didn't pass through AuthMiddleware")
+	}
+}
+
+// TestAuthMiddlewareEnvelope tests that rejections use the envelope when the client asks for it
+func TestAuthMiddlewareEnvelope(t *testing.T) {
+	handler := AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected next handler not to be called")
+	})
+
+	req := httptest.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("Accept", apierror.EnvelopeMediaType)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Header().Get("Content-Type") != apierror.EnvelopeMediaType {
+		t.Errorf("Expected Content-Type %s, got %s", apierror.EnvelopeMediaType, rr.Header().Get("Content-Type"))
+	}
+	var response apierror.ErrorEnvelope
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal error response: %v", err)
+	}
+	if len(response.Errors) != 1 || response.Errors[0].Status != "401" || response.Errors[0].Code != apierror.Unauthorized {
+		t.Errorf("Unexpected enveloped error %s", rr.Body.String())
+	}
+}
+
+// TestAuthMiddlewareDatabaseDown tests valid tokens while the token version can't be checked
+// Not parallel: it sets DEGRADED_READS
+func TestAuthMiddlewareDatabaseDown(t *testing.T) {
+	token, err := utils.GenerateToken(42, "auth-test@example.com", 3, "member", 0, config.Get().JWTSecret, config.Get().JWTIssuer)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// Nothing listens on port 1, so every query fails to connect
+	down, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1 user=test dbname=test sslmode=disable"),
+		&gorm.Config{DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Failed to open database handle: %v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		degradedReads  bool
+		method         string
+		expectedStatus int
+	}{
+		{"reads without degraded mode", false, "GET", http.StatusServiceUnavailable},
+		{"reads in degraded mode", true, "GET", http.StatusOK},
+		{"writes in degraded mode", true, "POST", http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			previous := config.Get()
+			cfg := *previous
+			cfg.DegradedReads = tc.degradedReads
+			config.Set(&cfg)
+			t.Cleanup(func() { config.Set(previous) })
+
+			handler := AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+				if user, ok := GetUserFromContext(r); !ok || user.UserID != 42 {
+					t.Errorf("Expected user 42 in the context, got %+v", user)
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tc.method, "/api/tasks", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req = req.WithContext(database.ContextWithDB(req.Context(), down))
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code == http.StatusServiceUnavailable {
+				var response ErrorResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal error response: %v", err)
+				}
+				if response.Code != apierror.DatabaseUnavailable {
+					t.Errorf("Expected code %s, got %s", apierror.DatabaseUnavailable, response.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// flight is one GET being answered on behalf of every identical request that
+// arrives while it runs
+type flight struct {
+	done   chan struct{} // Closed once the response below is complete
+	header http.Header
+	status int // 0 if the handler panicked
+	body   bytes.Buffer
+}
+
+func (f *flight) Header() http.Header {
+	return f.header
+}
+
+func (f *flight) WriteHeader(status int) {
+	if f.status == 0 {
+		f.status = status
+	}
+}
+
+func (f *flight) Write(b []byte) (int, error) {
+	if f.status == 0 {
+		f.status = http.StatusOK
+	}
+	return f.body.Write(b)
+}
+
+var (
+	// flights are the GETs in progress, by collapseKey
+	flights   = make(map[string]*flight)
+	flightsMu sync.Mutex
+
+	// collapsed counts the requests answered with another request's response
+	collapsed atomic.Int64
+)
+
+// CollapsedRequests returns how many GETs were answered with the response of
+// an identical concurrent one instead of querying themselves, for metrics
+func CollapsedRequests() int64 {
+	return collapsed.Load()
+}
+
+// collapseKey identifies requests that get the same response: the same user
+// asking for the same URL in the same shape
+func collapseKey(user UserContext, r *http.Request) string {
+	return fmt.Sprintf("%d:%d:%s:%s:%s:%s", user.UserID, user.OrgID, user.Role,
+		r.Header.Get("Accept"), r.Header.Get("Accept-Language"), r.URL.RequestURI())
+}
+
+// CollapseDuplicates lets concurrent identical GETs share one response
+// (COLLAPSE_DUPLICATE_GETS), e.g. when several dashboards poll the task list
+// at the same moment. The first request runs the handler; identical requests
+// (same user, URL and Accept header) arriving before it finishes wait and get
+// a copy of its response instead of querying the database themselves.
+//
+// Only successful (200) responses are shared. When the first request fails,
+// for whatever reason, each waiting request runs the handler on its own, as
+// it does when it has waited COLLAPSE_MAX_WAIT without an answer, so a slow
+// or stuck query never holds the others for longer than that. A waiting
+// request whose client goes away stops waiting. Must run after AuthMiddleware.
+func CollapseDuplicates(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Get()
+		user, ok := GetUserFromContext(r)
+		if !cfg.CollapseDuplicateGets || !ok || r.Method != http.MethodGet {
+			next(w, r)
+			return
+		}
+
+		key := collapseKey(user, r)
+		flightsMu.Lock()
+		if f, ok := flights[key]; ok {
+			flightsMu.Unlock()
+			waitForFlight(w, r, f, cfg.CollapseMaxWait, next)
+			return
+		}
+		f := &flight{done: make(chan struct{}), header: make(http.Header)}
+		flights[key] = f
+		flightsMu.Unlock()
+
+		// Waiters must be released even if the handler panics
+		defer func() {
+			flightsMu.Lock()
+			delete(flights, key)
+			flightsMu.Unlock()
+			close(f.done)
+		}()
+
+		next(f, r)
+		writeFlight(w, f)
+	}
+}
+
+// waitForFlight answers r with the response of f, or by running next itself
+// when f fails or takes longer than maxWait
+func waitForFlight(w http.ResponseWriter, r *http.Request, f *flight, maxWait time.Duration, next http.HandlerFunc) {
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	select {
+	case <-f.done:
+		if f.status == http.StatusOK {
+			collapsed.Add(1)
+			writeFlight(w, f)
+			return
+		}
+	case <-timer.C:
+	case <-r.Context().Done():
+		return // The client went away (or REQUEST_TIMEOUT answered it)
+	}
+	next(w, r)
+}
+
+// writeFlight sends the response recorded in f; it must be complete
+func writeFlight(w http.ResponseWriter, f *flight) {
+	for key, values := range f.header {
+		w.Header()[key] = append([]string(nil), values...)
+	}
+	w.WriteHeader(f.status)
+	w.Write(f.body.Bytes())
+}
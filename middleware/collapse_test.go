@@ -0,0 +1,341 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/models"
+)
+
+// setCollapse turns duplicate GET collapsing on for the test
+func setCollapse(t *testing.T, maxWait time.Duration) {
+	t.Helper()
+	previous := config.Get()
+	cfg := *previous
+	cfg.CollapseDuplicateGets = true
+	cfg.CollapseMaxWait = maxWait
+	config.Set(&cfg)
+	t.Cleanup(func() { config.Set(previous) })
+}
+
+// waitingContext notices when a collapsed request starts waiting: only
+// waiting requests watch their context while the handler runs
+type waitingContext struct {
+	context.Context
+	once    sync.Once
+	waiting chan struct{}
+}
+
+// waitingContextKey finds the request's waitingContext
+type waitingContextKey struct{}
+
+func (c *waitingContext) Done() <-chan struct{} {
+	c.once.Do(func() { close(c.waiting) })
+	return c.Context.Done()
+}
+
+func (c *waitingContext) Value(key any) any {
+	if key == (waitingContextKey{}) {
+		return c
+	}
+	return c.Context.Value(key)
+}
+
+// collapseRequest builds a GET made by the given user
+func collapseRequest(ctx context.Context, userID uint, target string) *http.Request {
+	ctx = &waitingContext{Context: ctx, waiting: make(chan struct{})}
+	r := httptest.NewRequest("GET", target, nil).WithContext(ctx)
+	user := UserContext{UserID: userID, OrgID: 1, Role: models.RoleMember}
+	return r.WithContext(context.WithValue(r.Context(), UserContextKey, user))
+}
+
+// waitForWaiters blocks until each of the requests waits for another one's response
+func waitForWaiters(t *testing.T, requests ...*http.Request) {
+	t.Helper()
+	for _, r := range requests {
+		select {
+		case <-r.Context().Value(waitingContextKey{}).(*waitingContext).waiting:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected %s to wait for an identical request", r.URL)
+		}
+	}
+}
+
+// blockingHandler answers with status once release is closed, counting its calls
+func blockingHandler(calls *atomic.Int32, release <-chan struct{}, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call":%d}`, n)
+	}
+}
+
+// serveAll runs the requests concurrently, the first one before the others
+func serveAll(t *testing.T, handler http.HandlerFunc, release chan struct{}, requests ...*http.Request) []*httptest.ResponseRecorder {
+	t.Helper()
+	recorders := make([]*httptest.ResponseRecorder, len(requests))
+	var wg sync.WaitGroup
+	for i, r := range requests {
+		recorders[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(rr *httptest.ResponseRecorder, r *http.Request) {
+			defer wg.Done()
+			handler(rr, r)
+		}(recorders[i], r)
+		if i == 0 {
+			// Let the first request become the leader
+			waitForLeader(t, r)
+		}
+	}
+	waitForWaiters(t, requests[1:]...)
+	close(release)
+	wg.Wait()
+	return recorders
+}
+
+// waitForLeader blocks until r is being answered
+func waitForLeader(t *testing.T, r *http.Request) {
+	t.Helper()
+	user, _ := GetUserFromContext(r)
+	key := collapseKey(user, r)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		flightsMu.Lock()
+		_, ok := flights[key]
+		flightsMu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected %s to be answered", r.URL)
+}
+
+// TestCollapseDuplicates tests that identical concurrent GETs share one response
+func TestCollapseDuplicates(t *testing.T) {
+	setCollapse(t, 5*time.Second)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := CollapseDuplicates(blockingHandler(&calls, release, http.StatusOK))
+
+	before := CollapsedRequests()
+	ctx := context.Background()
+	recorders := serveAll(t, handler, release,
+		collapseRequest(ctx, 1, "/api/tasks?status=pending"),
+		collapseRequest(ctx, 1, "/api/tasks?status=pending"),
+		collapseRequest(ctx, 1, "/api/tasks?status=pending"),
+	)
+	if calls.Load() != 1 {
+		t.Errorf("Expected the handler to run once, ran %d times", calls.Load())
+	}
+	for i, rr := range recorders {
+		if rr.Code != http.StatusOK || rr.Body.String() != `{"call":1}` || rr.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Request %d: expected the shared response, got %d %q", i, rr.Code, rr.Body.String())
+		}
+	}
+	if n := CollapsedRequests() - before; n != 2 {
+		t.Errorf("Expected 2 collapsed requests to be counted, got %d", n)
+	}
+
+	// Nothing is left behind for later requests
+	flightsMu.Lock()
+	left := len(flights)
+	flightsMu.Unlock()
+	if left != 0 {
+		t.Errorf("Expected no requests in flight, got %d", left)
+	}
+}
+
+// TestCollapseDuplicatesDistinct tests that different users and queries don't share
+func TestCollapseDuplicatesDistinct(t *testing.T) {
+	setCollapse(t, 5*time.Second)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := CollapseDuplicates(blockingHandler(&calls, release, http.StatusOK))
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for _, r := range []*http.Request{
+		collapseRequest(ctx, 1, "/api/tasks?status=pending"),
+		collapseRequest(ctx, 2, "/api/tasks?status=pending"),
+		collapseRequest(ctx, 1, "/api/tasks?status=completed"),
+	} {
+		wg.Add(1)
+		go func(r *http.Request) {
+			defer wg.Done()
+			handler(httptest.NewRecorder(), r)
+		}(r)
+		waitForLeader(t, r)
+	}
+	close(release)
+	wg.Wait()
+	if calls.Load() != 3 {
+		t.Errorf("Expected each request to run the handler, got %d calls", calls.Load())
+	}
+}
+
+// TestCollapseDuplicatesFailure tests that waiters query on their own when the leader fails
+func TestCollapseDuplicatesFailure(t *testing.T) {
+	setCollapse(t, 5*time.Second)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := CollapseDuplicates(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-release
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx := context.Background()
+	recorders := serveAll(t, handler, release,
+		collapseRequest(ctx, 1, "/api/tasks"),
+		collapseRequest(ctx, 1, "/api/tasks"),
+	)
+	if recorders[0].Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the leader's own failure, got %d", recorders[0].Code)
+	}
+	if recorders[1].Code != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("Expected the waiter to run the handler itself, got %d after %d calls", recorders[1].Code, calls.Load())
+	}
+}
+
+// TestCollapseDuplicatesPanic tests that waiters are released when the leader panics
+func TestCollapseDuplicatesPanic(t *testing.T) {
+	setCollapse(t, 5*time.Second)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := CollapseDuplicates(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-release
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	leader := collapseRequest(context.Background(), 1, "/api/tasks")
+	panicked := make(chan any, 1)
+	go func() {
+		defer func() { panicked <- recover() }()
+		handler(httptest.NewRecorder(), leader)
+	}()
+	waitForLeader(t, leader)
+
+	rr := httptest.NewRecorder()
+	waiter := collapseRequest(context.Background(), 1, "/api/tasks")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler(rr, waiter)
+	}()
+	waitForWaiters(t, waiter)
+	close(release)
+
+	if p := <-panicked; p != "boom" {
+		t.Errorf("Expected the panic on the leader, got %v", p)
+	}
+	<-done
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the waiter to run the handler itself, got %d", rr.Code)
+	}
+}
+
+// TestCollapseDuplicatesMaxWait tests that waiters stop waiting for a slow leader
+func TestCollapseDuplicatesMaxWait(t *testing.T) {
+	setCollapse(t, 20*time.Millisecond)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := CollapseDuplicates(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	leader := collapseRequest(context.Background(), 1, "/api/tasks")
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		handler(httptest.NewRecorder(), leader)
+	}()
+	// The leader must be gone before the next test starts
+	defer func() {
+		close(release)
+		<-leaderDone
+	}()
+	waitForLeader(t, leader)
+
+	rr := httptest.NewRecorder()
+	handler(rr, collapseRequest(context.Background(), 1, "/api/tasks"))
+	if rr.Code != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("Expected the waiter to run the handler itself, got %d after %d calls", rr.Code, calls.Load())
+	}
+}
+
+// TestCollapseDuplicatesCancel tests that waiters whose client went away stop waiting
+func TestCollapseDuplicatesCancel(t *testing.T) {
+	setCollapse(t, time.Minute)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := CollapseDuplicates(blockingHandler(&calls, release, http.StatusOK))
+
+	leader := collapseRequest(context.Background(), 1, "/api/tasks")
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		handler(httptest.NewRecorder(), leader)
+	}()
+	// The leader must be gone before the next test starts
+	defer func() {
+		close(release)
+		<-leaderDone
+	}()
+	waitForLeader(t, leader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waiter := collapseRequest(ctx, 1, "/api/tasks")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler(httptest.NewRecorder(), waiter)
+	}()
+	waitForWaiters(t, waiter)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the cancelled waiter to return")
+	}
+	if calls.Load() > 1 {
+		t.Errorf("Expected the cancelled waiter not to run the handler, got %d calls", calls.Load())
+	}
+}
+
+// TestCollapseDuplicatesDisabled tests that requests run on their own by default
+func TestCollapseDuplicatesDisabled(t *testing.T) {
+	var calls atomic.Int32
+	handler := CollapseDuplicates(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	})
+	handler(httptest.NewRecorder(), collapseRequest(context.Background(), 1, "/api/tasks"))
+	if calls.Load() != 1 {
+		t.Errorf("Expected the handler to run, got %d calls", calls.Load())
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// RequireJSON rejects write requests whose body isn't labelled as JSON
+// Without this check a form POST or a mislabelled body reaches json.NewDecoder
+// and the client only gets a cryptic "Invalid JSON" back
+func RequireJSON(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			// Requests without a body (e.g. action endpoints) don't need a Content-Type
+			// ContentLength is -1 when the size is unknown (chunked), so treat that as a body
+			if r.ContentLength == 0 {
+				break
+			}
+
+			// mime.ParseMediaType accepts parameters like "; charset=utf-8"
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				writeError(w, r, http.StatusUnsupportedMediaType, apierror.UnsupportedMediaType, "Content-Type must be application/json") // 415
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRequireJSON tests that write requests must carry a JSON Content-Type
+func TestRequireJSON(t *testing.T) {
+	testCases := []struct {
+		name           string
+		method         string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "json body",
+			method:         "POST",
+			contentType:    "application/json",
+			body:           `{"title":"Task"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "json with charset",
+			method:         "PUT",
+			contentType:    "application/json; charset=utf-8",
+			body:           `{"title":"Task"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "plain text body",
+			method:         "POST",
+			contentType:    "text/plain",
+			body:           `{"title":"Task"}`,
+			expectedStatus: http.StatusUnsupportedMediaType, // 415
+		},
+		{
+			name:           "form body",
+			method:         "PATCH",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "title=Task",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "missing content type",
+			method:         "POST",
+			contentType:    "",
+			body:           `{"title":"Task"}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "empty body without content type",
+			method:         "POST",
+			contentType:    "",
+			body:           "",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET is not checked",
+			method:         "GET",
+			contentType:    "text/plain",
+			body:           "",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			handler := RequireJSON(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tc.method, "/api/tasks", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rr := httptest.NewRecorder()
+
+			handler(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+
+			// The wrapped handler must only run when the request is accepted
+			if called != (tc.expectedStatus == http.StatusOK) {
+				t.Errorf("Expected next handler called=%t, got %t", tc.expectedStatus == http.StatusOK, called)
+			}
+
+			if tc.expectedStatus == http.StatusUnsupportedMediaType &&
+				!strings.Contains(rr.Body.String(), "Content-Type must be application/json") {
+				t.Errorf("Expected a clear error message, got %s", rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// CORS preflight answers: what browsers may send, and for how long to remember it
+const (
+	corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE"
+	corsAllowedHeaders = "Authorization, Content-Type, Accept, Accept-Language, If-None-Match"
+	corsMaxAge         = "600" // seconds
+)
+
+// CORS lets browser clients on the origins in CORS_ALLOWED_ORIGINS call the API
+// Preflight requests (OPTIONS with Access-Control-Request-Method) are answered
+// here with 204; other requests get their CORS headers and go on. Requests
+// from origins that aren't allowed get no CORS headers, so the browser keeps
+// the response from the page. With CORS_ALLOW_CREDENTIALS the request's own
+// origin is echoed, as browsers refuse credentials for "*" (Validate rejects
+// that combination). Wrap it around the whole mux so preflights skip auth.
+func CORS(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Get()
+		origin := r.Header.Get("Origin")
+		if origin == "" || len(cfg.CORSAllowedOrigins) == 0 {
+			next(w, r)
+			return
+		}
+
+		wildcard := slices.Contains(cfg.CORSAllowedOrigins, "*")
+		allowed := wildcard || slices.ContainsFunc(cfg.CORSAllowedOrigins, func(o string) bool {
+			return strings.EqualFold(o, origin)
+		})
+		if !wildcard || cfg.CORSAllowCredentials {
+			// The answer depends on the origin, so caches must keep one per origin
+			w.Header().Add("Vary", "Origin")
+		}
+		if !allowed {
+			next(w, r)
+			return
+		}
+
+		h := w.Header()
+		if wildcard && !cfg.CORSAllowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.CORSAllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			h.Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			h.Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if len(cfg.CORSExposedHeaders) > 0 {
+			h.Set("Access-Control-Expose-Headers", strings.Join(cfg.CORSExposedHeaders, ", "))
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// TestCORS tests the CORS headers for allowed, unknown and wildcard origins
+func TestCORS(t *testing.T) {
+	testCases := []struct {
+		name                string
+		origins             []string
+		credentials         bool
+		method              string
+		origin              string
+		expectedStatus      int
+		expectedAllowOrigin string
+		expectedCredentials string
+		expectedExpose      string
+	}{
+		{"disabled", nil, false, "GET", "https://app.example.com", http.StatusOK, "", "", ""},
+		{"no origin", []string{"https://app.example.com"}, false, "GET", "", http.StatusOK, "", "", ""},
+		{"allowed origin", []string{"https://app.example.com"}, false, "GET", "https://app.example.com", http.StatusOK, "https://app.example.com", "", "ETag, X-Total-Count"},
+		{"unknown origin", []string{"https://app.example.com"}, false, "GET", "https://evil.example.com", http.StatusOK, "", "", ""},
+		{"wildcard", []string{"*"}, false, "GET", "https://any.example.com", http.StatusOK, "*", "", "ETag, X-Total-Count"},
+		{"credentials echo the origin", []string{"https://app.example.com"}, true, "GET", "https://app.example.com", http.StatusOK, "https://app.example.com", "true", "ETag, X-Total-Count"},
+		{"preflight", []string{"https://app.example.com"}, true, "OPTIONS", "https://app.example.com", http.StatusNoContent, "https://app.example.com", "true", ""},
+		{"preflight from an unknown origin", []string{"https://app.example.com"}, false, "OPTIONS", "https://evil.example.com", http.StatusOK, "", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			previous := config.Get()
+			cfg := *previous
+			cfg.CORSAllowedOrigins = tc.origins
+			cfg.CORSAllowCredentials = tc.credentials
+			cfg.CORSExposedHeaders = []string{"ETag", "X-Total-Count"}
+			config.Set(&cfg)
+			t.Cleanup(func() { config.Set(previous) })
+
+			handler := CORS(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			req := httptest.NewRequest(tc.method, "/api/tasks", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if tc.method == "OPTIONS" {
+				req.Header.Set("Access-Control-Request-Method", "PATCH")
+			}
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tc.expectedAllowOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tc.expectedAllowOrigin, got)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != tc.expectedCredentials {
+				t.Errorf("Expected Access-Control-Allow-Credentials %q, got %q", tc.expectedCredentials, got)
+			}
+			if got := rr.Header().Get("Access-Control-Expose-Headers"); got != tc.expectedExpose {
+				t.Errorf("Expected Access-Control-Expose-Headers %q, got %q", tc.expectedExpose, got)
+			}
+			if tc.expectedStatus == http.StatusNoContent && rr.Header().Get("Access-Control-Allow-Methods") == "" {
+				t.Errorf("Expected the preflight to list the allowed methods")
+			}
+		})
+	}
+}
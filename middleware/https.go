@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// RequireHTTPS enforces HTTPS behind a TLS-terminating proxy (FORCE_HTTPS)
+// Requests the proxy received over plain HTTP, as its X-Forwarded-Proto
+// header says, are redirected to the same host, path and query over https://
+// (301 for GET and HEAD, 308 otherwise so the method and body are kept).
+// Responses to HTTPS requests get Strict-Transport-Security (HSTS_MAX_AGE);
+// browsers ignore it over plain HTTP, so it isn't sent there. Paths in
+// HTTPS_EXEMPT_PATHS (by default /health) are never redirected, so probes
+// over HTTP keep working. Requests without the header (made directly rather
+// than through the proxy) pass through. With FORCE_HTTPS off it does nothing.
+func RequireHTTPS(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Get()
+		if !cfg.ForceHTTPS {
+			next(w, r)
+			return
+		}
+
+		switch requestScheme(r) {
+		case "https":
+			if cfg.HSTSMaxAge > 0 {
+				value := "max-age=" + strconv.FormatInt(int64(cfg.HSTSMaxAge.Seconds()), 10)
+				if cfg.HSTSIncludeSubdomains {
+					value += "; includeSubDomains"
+				}
+				w.Header().Set("Strict-Transport-Security", value)
+			}
+		case "http":
+			if !httpsExempt(r.URL.Path, cfg.HTTPSExemptPaths) {
+				status := http.StatusPermanentRedirect // 308
+				if r.Method == http.MethodGet || r.Method == http.MethodHead {
+					status = http.StatusMovedPermanently // 301
+				}
+				http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), status)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// requestScheme is the scheme the client used: "https" for TLS connections,
+// otherwise what the proxy put in X-Forwarded-Proto ("" without the header)
+// With several proxies the first (the client-facing one) counts.
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.ToLower(strings.TrimSpace(proto))
+}
+
+// httpsExempt reports whether path matches one of the exempt paths: exactly,
+// or inside it for entries ending in /
+func httpsExempt(path string, exempt []string) bool {
+	for _, p := range exempt {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
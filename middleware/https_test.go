@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// TestRequireHTTPS tests redirects and HSTS headers behind a TLS-terminating proxy
+func TestRequireHTTPS(t *testing.T) {
+	testCases := []struct {
+		name             string
+		enabled          bool
+		method           string
+		target           string
+		proto            string
+		expectedStatus   int
+		expectedLocation string
+		expectedHSTS     string
+	}{
+		{"disabled", false, "GET", "/api/tasks", "http", http.StatusOK, "", ""},
+		{"plain HTTP is redirected", true, "GET", "/api/tasks?page=2&sort=-title", "http", http.StatusMovedPermanently, "https://api.example.com/api/tasks?page=2&sort=-title", ""},
+		{"writes keep their method", true, "POST", "/api/tasks", "http", http.StatusPermanentRedirect, "https://api.example.com/api/tasks", ""},
+		{"first proxy counts", true, "GET", "/api/tasks", "http, https", http.StatusMovedPermanently, "https://api.example.com/api/tasks", ""},
+		{"HTTPS gets HSTS", true, "GET", "/api/tasks", "https", http.StatusOK, "", "max-age=3600; includeSubDomains"},
+		{"exempt path", true, "GET", "/health", "http", http.StatusOK, "", ""},
+		{"exempt subtree", true, "GET", "/internal/metrics", "http", http.StatusOK, "", ""},
+		{"direct request", true, "GET", "/api/tasks", "", http.StatusOK, "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			previous := config.Get()
+			cfg := *previous
+			cfg.ForceHTTPS = tc.enabled
+			cfg.HSTSMaxAge = time.Hour
+			cfg.HSTSIncludeSubdomains = true
+			cfg.HTTPSExemptPaths = []string{"/health", "/internal/"}
+			config.Set(&cfg)
+			t.Cleanup(func() { config.Set(previous) })
+
+			handler := RequireHTTPS(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			req := httptest.NewRequest(tc.method, "http://api.example.com"+tc.target, nil)
+			if tc.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tc.proto)
+			}
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+			if got := rr.Header().Get("Location"); got != tc.expectedLocation {
+				t.Errorf("Expected Location %q, got %q", tc.expectedLocation, got)
+			}
+			if got := rr.Header().Get("Strict-Transport-Security"); got != tc.expectedHSTS {
+				t.Errorf("Expected Strict-Transport-Security %q, got %q", tc.expectedHSTS, got)
+			}
+		})
+	}
+}
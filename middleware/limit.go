@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// inFlight counts the requests running inside Limit right now
+var inFlight atomic.Int64
+
+// InFlightRequests returns how many requests are being served, for metrics
+// Requests shed by Limit aren't counted.
+func InFlightRequests() int64 {
+	return inFlight.Load()
+}
+
+// overloadRetryAfter is the Retry-After sent with shed requests, in seconds
+// Spikes that overflow the limit usually pass quickly.
+const overloadRetryAfter = "1"
+
+// Limit serves at most maxConcurrent requests at a time (MAX_CONCURRENT_REQUESTS)
+// Requests beyond that get 503 with Retry-After straight away: queueing them
+// would only pile up memory and database connections during a spike. 0 serves
+// any number. A panicking handler still frees its slot; the panic is logged
+// and answered with 500.
+func Limit(maxConcurrent int) func(http.HandlerFunc) http.HandlerFunc {
+	// A buffered channel is the semaphore: each request holds one slot
+	var slots chan struct{}
+	if maxConcurrent > 0 {
+		slots = make(chan struct{}, maxConcurrent)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if slots != nil {
+				select {
+				case slots <- struct{}{}:
+				default:
+					w.Header().Set("Retry-After", overloadRetryAfter)
+					writeError(w, r, http.StatusServiceUnavailable, apierror.Overloaded, "Server is too busy; try again shortly") // 503
+					return
+				}
+			}
+
+			inFlight.Add(1)
+			defer func() {
+				inFlight.Add(-1)
+				if slots != nil {
+					<-slots
+				}
+			}()
+
+			// Deferred after the release, so it runs first; the release runs either way
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// ErrAbortHandler is how handlers deliberately abort a response
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				log.Printf("Panic serving %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack())
+				writeError(w, r, http.StatusInternalServerError, apierror.InternalError, "Internal server error")
+			}()
+
+			next(w, r)
+		}
+	}
+}
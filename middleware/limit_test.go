@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// TestLimit tests that requests beyond the limit are shed while the others run
+func TestLimit(t *testing.T) {
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	handler := Limit(2)(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started.Done()
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	// Fill both slots with requests that wait
+	var done sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			serve("/slow")
+		}()
+	}
+	started.Wait()
+
+	if got := InFlightRequests(); got != 2 {
+		t.Errorf("Expected 2 requests in flight, got %d", got)
+	}
+
+	rr := serve("/fast")
+	var errResp struct {
+		Code apierror.Code `json:"code"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &errResp)
+	if rr.Code != http.StatusServiceUnavailable || errResp.Code != apierror.Overloaded {
+		t.Errorf("Expected 503 OVERLOADED beyond the limit, got %d %s", rr.Code, errResp.Code)
+	}
+	if rr.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected Retry-After 1, got %q", rr.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	done.Wait()
+	if got := InFlightRequests(); got != 0 {
+		t.Errorf("Expected no requests in flight, got %d", got)
+	}
+	if rr := serve("/fast"); rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once the slots are free, got %d", rr.Code)
+	}
+}
+
+// TestLimitPanic tests that a panicking handler frees its slot
+func TestLimitPanic(t *testing.T) {
+	testCases := []struct {
+		name  string
+		limit int
+	}{
+		{"limited", 1},
+		{"unlimited", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := Limit(tc.limit)(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/panic" {
+					panic("boom")
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			for i := 0; i < 3; i++ {
+				rr := httptest.NewRecorder()
+				handler(rr, httptest.NewRequest("GET", "/panic", nil))
+				if rr.Code != http.StatusInternalServerError {
+					t.Fatalf("Expected status 500 for a panic, got %d", rr.Code)
+				}
+			}
+
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest("GET", "/ok", nil))
+			if rr.Code != http.StatusOK {
+				t.Errorf("Expected the slot to be freed after a panic, got %d", rr.Code)
+			}
+			if got := InFlightRequests(); got != 0 {
+				t.Errorf("Expected no requests in flight, got %d", got)
+			}
+		})
+	}
+
+	// Deliberate aborts are passed on to net/http, which drops the connection
+	t.Run("abort handler", func(t *testing.T) {
+		handler := Limit(1)(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/abort" {
+				panic(http.ErrAbortHandler)
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+		func() {
+			defer func() {
+				if rec := recover(); rec != http.ErrAbortHandler {
+					t.Errorf("Expected ErrAbortHandler to be re-raised, got %v", rec)
+				}
+			}()
+			handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+		}()
+
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("GET", "/ok", nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected the slot to be freed after an abort, got %d", rr.Code)
+		}
+	})
+}
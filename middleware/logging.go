@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/database"
+)
+
+// LogSlowRequests logs requests that take longer than SLOW_REQUEST_THRESHOLD
+// Slow requests are logged at WARN with their route, status and duration, so
+// production logs only show performance problems; the rest are logged at DEBUG,
+// which the default logger leaves out. A threshold of 0 logs every request at
+// INFO, for debugging. Wrap it around the whole mux so the route is known.
+// Only the path is logged: never headers (like Authorization) or the query
+// string, which may carry credentials.
+func LogSlowRequests(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next(rec, r)
+
+		// Event streams stay open on purpose, so their duration says nothing
+		if rec.Header().Get("Content-Type") == "text/event-stream" {
+			return
+		}
+
+		duration := time.Since(start)
+		threshold := config.Get().SlowRequestThreshold
+		level := slog.LevelDebug
+		switch {
+		case threshold == 0:
+			level = slog.LevelInfo
+		case duration > threshold:
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"method", r.Method,
+			"route", requestRoute(r),
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", duration.Milliseconds(),
+		}
+		// Set by CountQueries (DB_QUERY_COUNT)
+		if count, ok := database.QueryCount(r.Context()); ok {
+			attrs = append(attrs, "db_queries", count)
+		}
+		slog.Log(r.Context(), level, "request", attrs...)
+	}
+}
+
+// requestRoute returns the mux pattern that served r, e.g. "/api/tasks/"
+// Grouping by pattern keeps task IDs out of the route. ServeMux sets it on
+// the request while routing; without a mux (or a match) the path is used.
+func requestRoute(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.URL.Path
+}
+
+// statusRecorder remembers the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Flush passes flushes on, so streaming handlers still see an http.Flusher
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying connection (e.g. for write deadlines)
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
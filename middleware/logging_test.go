@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// TestLogSlowRequests tests which requests are logged at which level
+func TestLogSlowRequests(t *testing.T) {
+	testCases := []struct {
+		name          string
+		threshold     time.Duration
+		delay         time.Duration
+		expectedLevel string // "" when nothing should be logged at INFO or above
+	}{
+		{"fast request is quiet", time.Second, 0, ""},
+		{"slow request warns", 10 * time.Millisecond, 20 * time.Millisecond, "WARN"},
+		{"zero threshold logs everything", 0, 0, "INFO"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			previous := config.Get()
+			cfg := *previous
+			cfg.SlowRequestThreshold = tc.threshold
+			config.Set(&cfg)
+			t.Cleanup(func() { config.Set(previous) })
+
+			// Capture log output as JSON; the default logger stays at INFO
+			var buf bytes.Buffer
+			previousLogger := slog.Default()
+			slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+			t.Cleanup(func() { slog.SetDefault(previousLogger) })
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/tasks/", func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(tc.delay)
+				w.WriteHeader(http.StatusTeapot)
+			})
+			handler := LogSlowRequests(mux.ServeHTTP)
+			handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/tasks/42", nil))
+
+			if tc.expectedLevel == "" {
+				if buf.Len() != 0 {
+					t.Errorf("Expected no log output, got %s", buf.String())
+				}
+				return
+			}
+
+			var entry struct {
+				Level  string `json:"level"`
+				Route  string `json:"route"`
+				Path   string `json:"path"`
+				Status int    `json:"status"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Expected one JSON log entry, got %q: %v", buf.String(), err)
+			}
+			if entry.Level != tc.expectedLevel {
+				t.Errorf("Expected level %s, got %s", tc.expectedLevel, entry.Level)
+			}
+			// The route is the mux pattern, not the path with its ID
+			if entry.Route != "/api/tasks/" || entry.Path != "/api/tasks/42" || entry.Status != http.StatusTeapot {
+				t.Errorf("Unexpected log entry %s", buf.String())
+			}
+		})
+	}
+}
+
+// TestLogSlowRequestsCredentials tests that credentials in headers and the query string aren't logged
+func TestLogSlowRequestsCredentials(t *testing.T) {
+	previous := config.Get()
+	cfg := *previous
+	cfg.SlowRequestThreshold = 0
+	config.Set(&cfg)
+	t.Cleanup(func() { config.Set(previous) })
+
+	var buf bytes.Buffer
+	previousLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previousLogger) })
+
+	handler := LogSlowRequests(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	req := httptest.NewRequest("GET", "/api/tasks?token=query-secret", nil)
+	req.Header.Set("Authorization", "Bearer header-secret")
+	handler(httptest.NewRecorder(), req)
+
+	if buf.Len() == 0 {
+		t.Fatalf("Expected the request to be logged")
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("Expected no credentials in the log, got %s", buf.String())
+	}
+}
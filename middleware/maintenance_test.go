@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// setMaintenance installs a config with the given maintenance settings for one test
+func setMaintenance(t *testing.T, enabled, blockAuth bool) {
+	t.Helper()
+	previous := config.Get()
+	cfg := *previous
+	cfg.MaintenanceMode = enabled
+	cfg.MaintenanceBlockAuth = blockAuth
+	cfg.MaintenanceRetryAfter = 2 * time.Minute
+	config.Set(&cfg)
+	t.Cleanup(func() { config.Set(previous) })
+}
+
+// TestMaintenance tests that only writes are rejected during maintenance
+func TestMaintenance(t *testing.T) {
+	testCases := []struct {
+		name           string
+		enabled        bool
+		method         string
+		expectedStatus int
+	}{
+		{"off allows writes", false, "POST", http.StatusOK},
+		{"on allows GET", true, "GET", http.StatusOK},
+		{"on allows HEAD", true, "HEAD", http.StatusOK},
+		{"on rejects POST", true, "POST", http.StatusServiceUnavailable},
+		{"on rejects PUT", true, "PUT", http.StatusServiceUnavailable},
+		{"on rejects PATCH", true, "PATCH", http.StatusServiceUnavailable},
+		{"on rejects DELETE", true, "DELETE", http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setMaintenance(t, tc.enabled, false)
+
+			handler := Maintenance(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest(tc.method, "/api/tasks", nil))
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+			if rr.Code == http.StatusServiceUnavailable && rr.Header().Get("Retry-After") != "120" {
+				t.Errorf("Expected Retry-After 120, got %q", rr.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+// TestMaintenanceAuth tests that auth endpoints are only blocked when asked to
+func TestMaintenanceAuth(t *testing.T) {
+	testCases := []struct {
+		name           string
+		enabled        bool
+		blockAuth      bool
+		expectedStatus int
+	}{
+		{"off", false, true, http.StatusOK},
+		{"on without blocking auth", true, false, http.StatusOK},
+		{"on and blocking auth", true, true, http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setMaintenance(t, tc.enabled, tc.blockAuth)
+
+			handler := MaintenanceAuth(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest("POST", "/api/auth/login", nil))
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
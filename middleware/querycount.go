@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/kcansari/task-management-api/database"
+)
+
+// QueryCountHeader reports how many SQL queries a request ran (DB_QUERY_COUNT)
+const QueryCountHeader = "X-DB-Query-Count"
+
+// CountQueries counts the SQL queries each request runs, to catch N+1 queries
+// The count goes out in the X-DB-Query-Count header and LogSlowRequests adds
+// it to the request log. It only works once database.EnableQueryCounting has
+// registered its callbacks, and main only wires both up with DB_QUERY_COUNT,
+// which is refused in production. Wrap it outside LogSlowRequests.
+func CountQueries(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, _ := database.ContextWithQueryCounter(r.Context())
+		r = r.WithContext(ctx)
+		next(&queryCountWriter{ResponseWriter: w, r: r}, r)
+	}
+}
+
+// queryCountWriter sets the query count header just before the response goes out
+// Queries made after that (e.g. while streaming) are only in the log.
+type queryCountWriter struct {
+	http.ResponseWriter
+	r           *http.Request
+	wroteHeader bool
+}
+
+func (qw *queryCountWriter) WriteHeader(status int) {
+	if !qw.wroteHeader {
+		qw.wroteHeader = true
+		if count, ok := database.QueryCount(qw.r.Context()); ok {
+			qw.Header().Set(QueryCountHeader, strconv.FormatInt(count, 10))
+		}
+	}
+	qw.ResponseWriter.WriteHeader(status)
+}
+
+func (qw *queryCountWriter) Write(b []byte) (int, error) {
+	if !qw.wroteHeader {
+		qw.WriteHeader(http.StatusOK)
+	}
+	return qw.ResponseWriter.Write(b)
+}
+
+// Flush passes flushes on, so streaming handlers still see an http.Flusher
+func (qw *queryCountWriter) Flush() {
+	if !qw.wroteHeader {
+		qw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := qw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying connection
+func (qw *queryCountWriter) Unwrap() http.ResponseWriter {
+	return qw.ResponseWriter
+}
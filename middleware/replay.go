@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/cache"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/utils"
+)
+
+// seenTokens maps the ID ("jti") of every token used so far to the IP it was
+// first used from (TOKEN_REPLAY_CHECK). Entries expire with their token, and
+// at most TOKEN_REPLAY_MAX_TOKENS are kept, the least recently used going
+// first. nil while the check is off.
+var seenTokens *cache.LRU[string]
+
+// EnableTokenReplayCheck starts remembering where up to size tokens were first used
+// Call it once at startup, before serving requests.
+func EnableTokenReplayCheck(size int) {
+	seenTokens = cache.New[string](size, utils.TokenLifetime)
+}
+
+// tokenFirstUsedFrom records that the token with claims is being used from ip
+// and returns the IP it was first used from, which is ip for a new token.
+// Tokens without an ID (issued before they had one) aren't tracked.
+func tokenFirstUsedFrom(claims *utils.Claims, ip string) string {
+	if seenTokens == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return ip
+	}
+	first, _ := seenTokens.GetOrSet(claims.ID, ip, claims.ExpiresAt.Time)
+	return first
+}
+
+// checkTokenReplay flags a token used from another IP than the one it was
+// first used from, which may mean it was stolen: TOKEN_REPLAY_CHECK=log logs
+// it, and =block also writes a 401 response and returns false. Only the
+// other IP is refused; the token keeps working where it was first used.
+func checkTokenReplay(w http.ResponseWriter, r *http.Request, claims *utils.Claims, cfg *config.Config) bool {
+	if cfg.TokenReplayCheck != config.TokenReplayLog && cfg.TokenReplayCheck != config.TokenReplayBlock {
+		return true
+	}
+
+	// TRUSTED_PROXIES was checked when the configuration was loaded
+	trustedProxies, _ := utils.ParseTrustedProxies(cfg.TrustedProxies)
+	ip := utils.ClientIP(r, trustedProxies)
+	first := tokenFirstUsedFrom(claims, ip)
+	if first == ip {
+		return true
+	}
+
+	log.Printf("Token %s of user %d used from %s, but first used from %s", claims.ID, claims.UserID, ip, first)
+	if cfg.TokenReplayCheck != config.TokenReplayBlock {
+		return true
+	}
+	writeError(w, r, http.StatusUnauthorized, apierror.TokenReplayed, "This token was used from another IP address; log in again") // 401 Unauthorized
+	return false
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/utils"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setTokenReplayCheck turns the replay check on in mode, with an empty seen-set
+func setTokenReplayCheck(t *testing.T, mode string, size int) {
+	t.Helper()
+	previous := config.Get()
+	cfg := *previous
+	cfg.TokenReplayCheck = mode
+	cfg.TrustedProxies = nil
+	config.Set(&cfg)
+
+	previousSeen := seenTokens
+	EnableTokenReplayCheck(size)
+	t.Cleanup(func() {
+		config.Set(previous)
+		seenTokens = previousSeen
+	})
+}
+
+// TestTokenReplay tests that a token used from a second IP is flagged
+// Not parallel: it sets TOKEN_REPLAY_CHECK
+func TestTokenReplay(t *testing.T) {
+	// The token version lookup fails with nothing listening on port 1, so
+	// requests that get past the replay check end with 503
+	down, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1 user=test dbname=test sslmode=disable"),
+		&gorm.Config{DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Failed to open database handle: %v", err)
+	}
+
+	handler := AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(token, remoteAddr string) (int, apierror.Code) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/tasks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.RemoteAddr = remoteAddr
+		req = req.WithContext(database.ContextWithDB(req.Context(), down))
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		var response ErrorResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr.Code, response.Code
+	}
+	newToken := func() string {
+		t.Helper()
+		token, err := utils.GenerateToken(42, "replay-test@example.com", 3, "member", 0, config.Get().JWTSecret, config.Get().JWTIssuer)
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		return token
+	}
+
+	t.Run("block", func(t *testing.T) {
+		setTokenReplayCheck(t, config.TokenReplayBlock, 10)
+		token := newToken()
+
+		if _, code := serve(token, "203.0.113.1:1234"); code == apierror.TokenReplayed {
+			t.Fatalf("Expected the first use to pass the check")
+		}
+		// Another connection from the same IP is the same client
+		if _, code := serve(token, "203.0.113.1:5678"); code == apierror.TokenReplayed {
+			t.Errorf("Expected reuse from the same IP to pass the check")
+		}
+		if status, code := serve(token, "198.51.100.7:1234"); status != http.StatusUnauthorized || code != apierror.TokenReplayed {
+			t.Errorf("Expected 401 %s from another IP, got %d %s", apierror.TokenReplayed, status, code)
+		}
+		// The first IP keeps working, and other tokens aren't affected
+		if _, code := serve(token, "203.0.113.1:1234"); code == apierror.TokenReplayed {
+			t.Errorf("Expected the first IP to keep passing the check")
+		}
+		if _, code := serve(newToken(), "198.51.100.7:1234"); code == apierror.TokenReplayed {
+			t.Errorf("Expected a new token to pass the check")
+		}
+	})
+
+	t.Run("log", func(t *testing.T) {
+		setTokenReplayCheck(t, config.TokenReplayLog, 10)
+		token := newToken()
+
+		serve(token, "203.0.113.1:1234")
+		if _, code := serve(token, "198.51.100.7:1234"); code == apierror.TokenReplayed {
+			t.Errorf("Expected reuse from another IP only to be logged")
+		}
+	})
+
+	t.Run("off", func(t *testing.T) {
+		setTokenReplayCheck(t, config.TokenReplayOff, 10)
+		token := newToken()
+
+		serve(token, "203.0.113.1:1234")
+		if _, code := serve(token, "198.51.100.7:1234"); code == apierror.TokenReplayed {
+			t.Errorf("Expected no check while it's off")
+		}
+		if seenTokens.Len() != 0 {
+			t.Errorf("Expected no tokens to be remembered while the check is off, got %d", seenTokens.Len())
+		}
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		setTokenReplayCheck(t, config.TokenReplayBlock, 2)
+		for i := 0; i < 5; i++ {
+			serve(newToken(), "203.0.113.1:1234")
+		}
+		if seenTokens.Len() != 2 {
+			t.Errorf("Expected at most 2 remembered tokens, got %d", seenTokens.Len())
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// Timeout answers requests that take longer than d with 504 (REQUEST_TIMEOUT)
+// The deadline is set on the request context, so database queries made
+// through it (see handlers.requestDB) are cancelled too. Responses are
+// buffered until the handler returns: a client gets either the whole response
+// or the timeout error, never half of one. Writes after the deadline fail with
+// http.ErrHandlerTimeout. Handler panics are re-raised on the calling
+// goroutine, so Limit still recovers them. 0 disables the timeout.
+//
+// Buffering rules out streaming, so long-lived routes (like the event
+// stream) and file transfers must not be wrapped.
+func Timeout(d time.Duration) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if d <= 0 {
+			return next
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header), status: http.StatusOK}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if rec := recover(); rec != nil {
+						panicked <- rec
+					}
+				}()
+				next(tw, r)
+				close(done)
+			}()
+
+			select {
+			case rec := <-panicked:
+				panic(rec)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for key, values := range tw.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.body.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if ctx.Err() == context.DeadlineExceeded {
+					writeError(w, r, http.StatusGatewayTimeout, apierror.RequestTimeout, "Request took too long") // 504
+				}
+				// Otherwise the client went away; there's nobody to answer
+			}
+		}
+	}
+}
+
+// timeoutWriter buffers a response until Timeout decides whether to send it
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.status = status
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	return tw.body.Write(b)
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+)
+
+// TestTimeout tests that slow handlers get 504 without their partial response
+func TestTimeout(t *testing.T) {
+	writeErr := make(chan error, 1)
+	handler := Timeout(50 * time.Millisecond)(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Errorf("Expected a deadline on the request context")
+		}
+		if r.URL.Path == "/slow" {
+			w.Header().Set("X-Partial", "true")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"partial":`))
+			<-r.Context().Done()
+			_, err := w.Write([]byte(`true}`))
+			writeErr <- err
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	// Fast handlers are answered as they wrote it
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest("POST", "/fast", nil))
+	if rr.Code != http.StatusCreated || rr.Body.String() != `{"ok":true}` || rr.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the handler's own response, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler(rr, httptest.NewRequest("GET", "/slow", nil))
+	if rr.Code != http.StatusGatewayTimeout {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusGatewayTimeout, rr.Code, rr.Body.String())
+	}
+	var errResp ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &errResp); err != nil {
+		t.Fatalf("Expected a JSON error without the partial body, got %q", rr.Body.String())
+	}
+	if errResp.Code != apierror.RequestTimeout {
+		t.Errorf("Expected code %s, got %s", apierror.RequestTimeout, errResp.Code)
+	}
+	if rr.Header().Get("X-Partial") != "" || strings.Contains(rr.Body.String(), "partial") {
+		t.Errorf("Expected nothing of the partial response, got headers %v body %q", rr.Header(), rr.Body.String())
+	}
+
+	// The handler finds out its writes are going nowhere
+	select {
+	case err := <-writeErr:
+		if err != http.ErrHandlerTimeout {
+			t.Errorf("Expected http.ErrHandlerTimeout for writes after the deadline, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the slow handler to finish once its context ended")
+	}
+}
+
+// TestTimeoutPanic tests that a panicking handler panics on the caller's goroutine
+func TestTimeoutPanic(t *testing.T) {
+	handler := Limit(1)(Timeout(time.Second)(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected Limit to recover the panic with %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
+
+// TestTimeoutDisabled tests that a zero timeout leaves requests alone
+func TestTimeoutDisabled(t *testing.T) {
+	handler := Timeout(0)(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Errorf("Expected no deadline with the timeout disabled")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/kcansari/task-management-api/tracing"
+)
+
+// Trace records a server span for each request (OTEL_EXPORTER_OTLP_ENDPOINT)
+// An incoming traceparent header makes the span part of the caller's trace.
+// The span is named by method and the mux pattern that serves the request,
+// e.g. "GET /api/tasks/", so task IDs never become part of span names. It
+// records the status code and marks 5xx responses as errors; AuthMiddleware
+// adds user_id. The span travels in the request context, so database queries
+// made with it (see database.EnableTracing) become its children. With tracing
+// disabled requests pass through untouched. Wrap it outside Timeout, so timed
+// out requests are recorded too.
+func Trace(mux *http.ServeMux) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !tracing.Enabled() {
+				next(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			if parent, ok := tracing.ParseTraceparent(r.Header.Get(tracing.TraceparentHeader)); ok {
+				ctx = tracing.ContextWithRemoteParent(ctx, parent)
+			}
+			route := r.URL.Path
+			if _, pattern := mux.Handler(r); pattern != "" {
+				route = pattern
+			}
+			ctx, span := tracing.Start(ctx, r.Method+" "+route, tracing.SpanKindServer)
+			defer span.End()
+			span.SetAttribute("http.request.method", r.Method)
+			span.SetAttribute("http.route", route)
+			span.SetAttribute("url.path", r.URL.Path)
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next(rec, r.WithContext(ctx))
+
+			span.SetAttribute("http.response.status_code", rec.status)
+			if rec.status >= 500 {
+				span.SetStatus(tracing.StatusError, strconv.Itoa(rec.status)+" "+http.StatusText(rec.status))
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kcansari/task-management-api/tracing"
+)
+
+// recordingExporter keeps exported spans in memory
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+func (e *recordingExporter) Export(span *tracing.Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error { return nil }
+
+// TestTrace tests the server span recorded for each request
+func TestTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracing.SetDefault(tracing.NewTracer(exporter))
+	t.Cleanup(func() { tracing.SetDefault(nil) })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tasks/", func(w http.ResponseWriter, r *http.Request) {})
+	handler := Trace(mux)(func(w http.ResponseWriter, r *http.Request) {
+		if tracing.SpanFromContext(r.Context()) == nil {
+			t.Errorf("Expected the span in the request context")
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest("GET", "/api/tasks/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler(httptest.NewRecorder(), req)
+
+	if len(exporter.spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(exporter.spans))
+	}
+	span := exporter.spans[0]
+	if span.Name() != "GET /api/tasks/" {
+		t.Errorf("Expected the span to be named after the route, got %q", span.Name())
+	}
+	if got := span.SpanContext().Traceparent()[3:35]; got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the span to join the caller's trace, got %s", got)
+	}
+	if status, ok := span.Attribute("http.response.status_code"); !ok || status != 500 {
+		t.Errorf("Expected status code 500, got %v", status)
+	}
+	if code, _ := span.Status(); code != tracing.StatusError {
+		t.Errorf("Expected a 5xx response to mark the span as failed")
+	}
+}
+
+// TestTraceDisabled tests that requests pass through without a tracer
+func TestTraceDisabled(t *testing.T) {
+	tracing.SetDefault(nil)
+	called := false
+	handler := Trace(http.NewServeMux())(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if tracing.SpanFromContext(r.Context()) != nil {
+			t.Errorf("Expected no span with tracing disabled")
+		}
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/tasks", nil))
+	if !called {
+		t.Errorf("Expected the request to reach the handler")
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kcansari/task-management-api/apierror"
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TrackUsage counts the authenticated user's requests and enforces API_QUOTA
+// Wrap it inside AuthMiddleware, which puts the user in the context. Requests
+// over the quota get 429 until the period (API_USAGE_PERIOD) rolls over; they
+// still count, since they were calls all the same. If the counter can't be
+// updated the request goes through: a usage hiccup shouldn't take the API down.
+func TrackUsage(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := config.Get()
+		user, ok := GetUserFromContext(r)
+		if !cfg.APIUsageTracking || !ok {
+			next(w, r)
+			return
+		}
+
+		now := time.Now()
+		periodStart, periodEnd := models.UsagePeriodBounds(cfg.APIUsagePeriod, now)
+		count, err := IncrementUsage(database.WithContext(r.Context()), user.UserID, periodStart)
+		if err != nil {
+			log.Printf("Failed to count request of user %d: %v", user.UserID, err)
+			next(w, r)
+			return
+		}
+
+		if cfg.APIQuota > 0 && count > int64(cfg.APIQuota) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(periodEnd.Sub(now).Seconds())+1))
+			writeError(w, r, http.StatusTooManyRequests, apierror.QuotaExceeded,
+				fmt.Sprintf("API quota of %d requests exceeded; it resets at %s", cfg.APIQuota, periodEnd.Format(time.RFC3339))) // 429
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// IncrementUsage adds one request to a user's count for the period and returns the new count
+// It's a single upsert, so concurrent requests (on any instance) never lose an
+// increment: the database serializes the updates of the row.
+func IncrementUsage(db *gorm.DB, userID uint, periodStart time.Time) (int64, error) {
+	usage := models.APIUsage{UserID: userID, PeriodStart: periodStart, Count: 1}
+	err := db.Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "period_start"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("api_usages.count + 1")}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "count"}}},
+	).Create(&usage).Error
+	return usage.Count, err
+}
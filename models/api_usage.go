@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// API usage periods (API_USAGE_PERIOD)
+const (
+	UsagePeriodDaily   = "daily"
+	UsagePeriodMonthly = "monthly"
+)
+
+// APIUsage counts one user's API requests in one period
+// A row is created by the user's first request in the period; older rows are
+// kept, so past periods stay available for billing
+type APIUsage struct {
+	UserID      uint      `gorm:"primaryKey;autoIncrement:false"`
+	PeriodStart time.Time `gorm:"primaryKey"` // Start of the day or month, in UTC
+	Count       int64     `gorm:"not null;default:0"`
+}
+
+// UsagePeriodBounds returns the period containing now: its start and the start of the next one
+// Periods follow UTC so every instance agrees on when they roll over.
+func UsagePeriodBounds(period string, now time.Time) (start, end time.Time) {
+	now = now.UTC()
+	if period == UsagePeriodDaily {
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	}
+	start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
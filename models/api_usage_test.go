@@ -0,0 +1,58 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// TestUsagePeriodBounds tests the day and month a usage count belongs to
+func TestUsagePeriodBounds(t *testing.T) {
+	// 23:30 in UTC+3 is still 20:30 UTC on the same day
+	istanbul := time.FixedZone("UTC+3", 3*60*60)
+
+	testCases := []struct {
+		name          string
+		period        string
+		now           time.Time
+		expectedStart time.Time
+		expectedEnd   time.Time
+	}{
+		{
+			name:          "daily",
+			period:        UsagePeriodDaily,
+			now:           time.Date(2025, 3, 14, 15, 9, 26, 0, time.UTC),
+			expectedStart: time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "daily in another time zone",
+			period:        UsagePeriodDaily,
+			now:           time.Date(2025, 3, 15, 1, 30, 0, 0, istanbul),
+			expectedStart: time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "monthly rolls over the year",
+			period:        UsagePeriodMonthly,
+			now:           time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC),
+			expectedStart: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "unset period is monthly",
+			period:        "",
+			now:           time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+			expectedStart: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end := UsagePeriodBounds(tc.period, tc.now)
+			if !start.Equal(tc.expectedStart) || !end.Equal(tc.expectedEnd) {
+				t.Errorf("Expected %s - %s, got %s - %s", tc.expectedStart, tc.expectedEnd, start, end)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Attachment is a file uploaded to a task
+// The file itself lives in the configured storage backend under StorageKey;
+// the row only keeps what's needed to find and serve it.
+type Attachment struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TaskID      uint      `gorm:"not null;index" json:"task_id"`
+	Filename    string    `gorm:"not null" json:"filename"`     // Name the file was uploaded with
+	ContentType string    `gorm:"not null" json:"content_type"` // One of ATTACHMENT_ALLOWED_TYPES
+	Size        int64     `gorm:"not null" json:"size"`         // In bytes
+	StorageKey  string    `gorm:"not null;uniqueIndex" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ChecklistItem is a checkbox inside a task
+// Items are lighter than tasks: just a line of text that's done or not, kept
+// in a manual order. Every change also updates the task's checklist counts.
+type ChecklistItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TaskID    uint      `gorm:"not null;index:idx_checklist_items_task_position,priority:1" json:"task_id"`
+	Text      string    `gorm:"not null" json:"text"`
+	Done      bool      `gorm:"not null;default:false" json:"done"`
+	Position  float64   `gorm:"not null;default:0;index:idx_checklist_items_task_position,priority:2" json:"position"` // Ascending order within the task
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Comment is a note someone left on a task
+// Anyone who can see the task can comment on it. Comments can @-mention
+// other users by email, which sends them a Notification.
+type Comment struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TaskID    uint      `gorm:"not null;index" json:"task_id"`
+	UserID    uint      `gorm:"not null" json:"user_id"` // Author
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+)
+
+// encryptedPrefix tags values written by EncryptedSerializer, so encrypted
+// and plaintext rows can live side by side; v1 is AES-GCM with a random
+// nonce, base64-encoded as nonce followed by ciphertext
+const encryptedPrefix = "enc:v1:"
+
+// ErrEncryptionKeyMissing is returned when reading an encrypted value while
+// no key is set (ENCRYPTION_KEY was removed after values were encrypted)
+var ErrEncryptionKeyMissing = errors.New("value is encrypted but no encryption key is set")
+
+// encryptionAEAD encrypts and decrypts column values; nil while no key is set
+var encryptionAEAD atomic.Pointer[cipher.AEAD]
+
+// SetEncryptionKey sets the AES key (16, 24 or 32 bytes) that columns tagged
+// serializer:encrypted are encrypted with; an empty key turns encryption off
+// main calls it at startup with ENCRYPTION_KEY.
+func SetEncryptionKey(key []byte) error {
+	if len(key) == 0 {
+		encryptionAEAD.Store(nil)
+		return nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	encryptionAEAD.Store(&aead)
+	return nil
+}
+
+func init() {
+	schema.RegisterSerializer("encrypted", EncryptedSerializer{})
+}
+
+// EncryptedSerializer encrypts string columns at rest (gorm:"serializer:encrypted")
+// With a key set, values are written encrypted; without one they're written
+// as plaintext. Reads decrypt values carrying the version tag and return
+// untagged (plaintext) ones as they are, so existing rows keep working and
+// get encrypted the next time they're saved. The database only ever sees
+// ciphertext, so encrypted columns can't be searched, filtered or sorted on.
+type EncryptedSerializer struct{}
+
+// Scan decrypts a stored value into the field
+func (EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		stored = string(v)
+	case string:
+		stored = v
+	default:
+		return fmt.Errorf("cannot scan %T into an encrypted column", dbValue)
+	}
+
+	plaintext, err := decryptValue(stored)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", field.DBName, err)
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+// Value encrypts the field for storage
+func (EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue any) (any, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot encrypt %T, only strings", fieldValue)
+	}
+	return encryptValue(value)
+}
+
+// encryptValue encrypts plaintext with the current key; empty values and
+// values written without a key are stored as they are
+func encryptValue(plaintext string) (string, error) {
+	aead := encryptionAEAD.Load()
+	if aead == nil || plaintext == "" {
+		return plaintext, nil
+	}
+	nonce := make([]byte, (*aead).NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := (*aead).Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue reverses encryptValue; untagged values are plaintext
+func decryptValue(stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, encryptedPrefix)
+	if !ok {
+		return stored, nil
+	}
+	aead := encryptionAEAD.Load()
+	if aead == nil {
+		return "", ErrEncryptionKeyMissing
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	nonceSize := (*aead).NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("encrypted value is too short")
+	}
+	plaintext, err := (*aead).Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestEncryptValue tests the round trip through encryptValue and decryptValue
+func TestEncryptValue(t *testing.T) {
+	t.Cleanup(func() { SetEncryptionKey(nil) })
+	if err := SetEncryptionKey([]byte("0123456789abcdef0123456789abcdef")); err != nil {
+		t.Fatalf("Failed to set key: %v", err)
+	}
+
+	const plaintext = "Door code is 4711 🔑"
+	stored, err := encryptValue(plaintext)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if !strings.HasPrefix(stored, encryptedPrefix) || strings.Contains(stored, "4711") {
+		t.Fatalf("Expected a tagged ciphertext, got %q", stored)
+	}
+	if again, _ := encryptValue(plaintext); again == stored {
+		t.Errorf("Expected a fresh nonce for every encryption")
+	}
+	if got, err := decryptValue(stored); err != nil || got != plaintext {
+		t.Errorf("Expected %q back, got %q (%v)", plaintext, got, err)
+	}
+
+	// Rows written before encryption was turned on are read as they are
+	if got, err := decryptValue("Written in plaintext"); err != nil || got != "Written in plaintext" {
+		t.Errorf("Expected plaintext to pass through, got %q (%v)", got, err)
+	}
+	if stored, _ := encryptValue(""); stored != "" {
+		t.Errorf("Expected an empty value to stay empty, got %q", stored)
+	}
+
+	// Tampering with the ciphertext is detected
+	tampered := stored[:len(stored)-2] + "AA"
+	if _, err := decryptValue(tampered); err == nil {
+		t.Errorf("Expected tampered ciphertext to be rejected")
+	}
+
+	// So is a different key
+	SetEncryptionKey([]byte("fedcba9876543210fedcba9876543210"))
+	if _, err := decryptValue(stored); err == nil {
+		t.Errorf("Expected decryption with another key to fail")
+	}
+
+	// Without a key new values are plaintext and encrypted ones can't be read
+	SetEncryptionKey(nil)
+	if got, _ := encryptValue(plaintext); got != plaintext {
+		t.Errorf("Expected plaintext without a key, got %q", got)
+	}
+	if _, err := decryptValue(stored); !errors.Is(err, ErrEncryptionKeyMissing) {
+		t.Errorf("Expected ErrEncryptionKeyMissing, got %v", err)
+	}
+
+	if err := SetEncryptionKey([]byte("short")); err == nil {
+		t.Errorf("Expected a key of the wrong length to be rejected")
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// TaskMetadata is arbitrary structured data clients attach to a task, e.g.
+// references into the systems they integrate with
+// It's always a JSON object, stored in a jsonb column. Values are kept as
+// raw JSON, so numbers and nested objects come back exactly as they were sent.
+type TaskMetadata map[string]json.RawMessage
+
+// Merge returns m with the top-level keys of patch applied (a shallow merge)
+// Keys set to null in patch are removed; nested objects are replaced whole.
+// m itself is left unchanged.
+func (m TaskMetadata) Merge(patch TaskMetadata) TaskMetadata {
+	merged := make(TaskMetadata, len(m)+len(patch))
+	for key, value := range m {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		if string(value) == "null" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// Value stores the metadata as JSON; empty metadata is stored as NULL
+func (m TaskMetadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads metadata stored by Value
+func (m *TaskMetadata) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into TaskMetadata", value)
+	}
+	return json.Unmarshal(data, m)
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestTaskMetadataMerge tests the shallow merge used by task updates
+func TestTaskMetadataMerge(t *testing.T) {
+	var current TaskMetadata
+	if err := json.Unmarshal([]byte(`{"jira":"OPS-1","sync":{"source":"jira","at":1},"keep":true}`), &current); err != nil {
+		t.Fatalf("Failed to decode metadata: %v", err)
+	}
+	var patch TaskMetadata
+	if err := json.Unmarshal([]byte(`{"jira":"OPS-2","sync":{"source":"github"},"keep":null,"new":[1,2]}`), &patch); err != nil {
+		t.Fatalf("Failed to decode patch: %v", err)
+	}
+
+	merged := current.Merge(patch)
+	data, err := json.Marshal(merged)
+	if err != nil {
+		t.Fatalf("Failed to encode merged metadata: %v", err)
+	}
+	// Keys are replaced whole (sync loses "at"), null removes keep
+	expected := `{"jira":"OPS-2","new":[1,2],"sync":{"source":"github"}}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+	if string(current["jira"]) != `"OPS-1"` || current["keep"] == nil {
+		t.Errorf("Expected the original metadata to be left unchanged, got %v", current)
+	}
+}
+
+// TestTaskMetadataValue tests storing and scanning metadata
+func TestTaskMetadataValue(t *testing.T) {
+	if value, err := (TaskMetadata{}).Value(); err != nil || value != nil {
+		t.Errorf("Expected empty metadata to be stored as NULL, got %v, %v", value, err)
+	}
+
+	value, err := TaskMetadata{"n": json.RawMessage(`12345678901234567890`)}.Value()
+	if err != nil {
+		t.Fatalf("Failed to store metadata: %v", err)
+	}
+	var scanned TaskMetadata
+	if err := scanned.Scan([]byte(value.(string))); err != nil {
+		t.Fatalf("Failed to scan metadata: %v", err)
+	}
+	// Large numbers survive without float rounding
+	if string(scanned["n"]) != `12345678901234567890` {
+		t.Errorf("Expected the number unchanged, got %s", scanned["n"])
+	}
+	if err := scanned.Scan(nil); err != nil || scanned != nil {
+		t.Errorf("Expected NULL to scan as nil metadata, got %v, %v", scanned, err)
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Kinds of notification
+const (
+	NotificationTypeMention = "mention" // Someone @-mentioned the user in a comment
+)
+
+// Notification tells a user about something that happened to a task
+// Notifications stay until the user marks them read, and are listed newest
+// first.
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index:idx_notifications_user_created,priority:1" json:"user_id"` // Recipient
+	Type      string     `gorm:"type:varchar(30);not null" json:"type"`
+	TaskID    uint       `gorm:"not null;index" json:"task_id"`
+	CommentID *uint      `json:"comment_id"`               // The comment with the mention, for mentions
+	ActorID   uint       `gorm:"not null" json:"actor_id"` // Who caused it
+	ReadAt    *time.Time `json:"read_at"`                  // nil until marked read
+	CreatedAt time.Time  `gorm:"index:idx_notifications_user_created,priority:2" json:"created_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultOrganizationName is the organization users join when they register
+// without naming one of their own. Migration 0008 creates it and moves every
+// existing user and task into it.
+const DefaultOrganizationName = "Default"
+
+// UserRole is a user's role within their organization
+type UserRole string
+
+const (
+	RoleMember UserRole = "member" // Sees their own tasks and tasks shared with them
+	RoleAdmin  UserRole = "admin"  // Also sees every task in the organization
+)
+
+// IsValid reports whether r is a known role
+func (r UserRole) IsValid() bool {
+	return r == RoleMember || r == RoleAdmin
+}
+
+// Organization is a tenant: users and tasks never cross organization boundaries
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null;uniqueIndex" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultOrganization loads the default organization, creating it if needed
+// Both migration paths create it up front, so this normally just reads it
+func DefaultOrganization(tx *gorm.DB) (Organization, error) {
+	var org Organization
+	err := tx.Where(Organization{Name: DefaultOrganizationName}).FirstOrCreate(&org).Error
+	return org, err
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PasswordHistory stores a password hash a user has used before
+// A row is added whenever a user changes their password, and only the most
+// recent PASSWORD_HISTORY_SIZE-1 rows per user are kept (the current password
+// lives on the user itself)
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	UserID       uint      `gorm:"not null;index" json:"-"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	CreatedAt    time.Time `json:"-"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"crypto/rand"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -14,14 +16,66 @@ const (
 	TaskStatusCompleted  TaskStatus = "completed"
 )
 
+// TaskVisibility decides who can read a task besides its owner and the users it's shared with
+type TaskVisibility string
+
+const (
+	TaskVisibilityPrivate TaskVisibility = "private" // Only through the authenticated API (default)
+	TaskVisibilityPublic  TaskVisibility = "public"  // Also to anyone, without a token, at GET /api/public/tasks/{id}
+)
+
 type Task struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	Title       string         `gorm:"not null" json:"title"`
-	Description string         `json:"description"`
-	Status      TaskStatus     `gorm:"type:varchar(20);default:'pending'" json:"status"`
-	UserID      uint           `gorm:"not null" json:"user_id"`
-	User        User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
-}
\ No newline at end of file
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	Title            string         `gorm:"not null" json:"title"`
+	Description      string         `gorm:"serializer:encrypted" json:"description"` // Encrypted at rest when ENCRYPTION_KEY is set
+	Status           TaskStatus     `gorm:"type:varchar(20);default:'pending'" json:"status"`
+	UserID           uint           `gorm:"not null;uniqueIndex:idx_tasks_user_client_id,priority:1;uniqueIndex:idx_tasks_user_external_id,priority:1" json:"user_id"`
+	User             User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	OrgID            uint           `gorm:"not null;index" json:"org_id"`                                                                                    // Always the owner's organization
+	DueDate          *time.Time     `json:"due_date,omitempty"`                                                                                              // Optional deadline
+	Color            string         `gorm:"type:varchar(20);not null;default:''" json:"color"`                                                               // Card color: #rrggbb or a TASK_COLORS name; empty for none
+	Progress         uint8          `gorm:"not null;default:0" json:"progress"`                                                                              // Percent complete, 0-100; 100 whenever the task is completed
+	CompletedAt      *time.Time     `gorm:"index" json:"completed_at,omitempty"`                                                                             // When the task last moved into completed; nil while it isn't completed
+	Visibility       TaskVisibility `gorm:"type:varchar(10);not null;default:'private'" json:"visibility"`                                                   // private or public; public tasks can be read without a token
+	Position         float64        `gorm:"not null;default:0" json:"position"`                                                                              // Manual order, ascending; set by POST /api/tasks/reorder
+	ClientID         *string        `gorm:"type:varchar(36);uniqueIndex:idx_tasks_user_client_id,priority:2,where:deleted_at IS NULL" json:"client_id"`      // UUID chosen by an offline client, unique per owner
+	ExternalID       *string        `gorm:"type:varchar(255);uniqueIndex:idx_tasks_user_external_id,priority:2,where:deleted_at IS NULL" json:"external_id"` // ID in a system the task was synced from, unique per owner
+	PublicID         string         `gorm:"type:varchar(36);not null;uniqueIndex" json:"-"`                                                                  // Random UUID that stands in for ID with TASK_ID_FORMAT=uuid
+	ReminderSent     bool           `gorm:"not null;default:false" json:"-"`                                                                                 // Set once the due-date reminder went out
+	ChecklistTotal   int            `gorm:"not null;default:0" json:"checklist_total"`                                                                       // Number of checklist items, kept in step with every checklist change
+	ChecklistDone    int            `gorm:"not null;default:0" json:"checklist_done"`                                                                        // Number of those items that are done
+	TotalTimeSeconds int64          `gorm:"not null;default:0" json:"total_time_seconds"`                                                                    // Sum of the finished time entries, kept in step with every time entry change
+	Metadata         TaskMetadata   `gorm:"type:jsonb" json:"metadata,omitempty"`                                                                            // Client-defined JSON object; NULL when there is none
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Associations, only loaded when a request asks for them with ?include=
+	Checklist   []ChecklistItem `gorm:"foreignKey:TaskID" json:"-"`
+	Attachments []Attachment    `gorm:"foreignKey:TaskID" json:"-"`
+}
+
+// BeforeCreate gives every new task a public ID, whichever TASK_ID_FORMAT is
+// in use, so the format can be switched without backfilling
+func (t *Task) BeforeCreate(tx *gorm.DB) error {
+	if t.PublicID != "" {
+		return nil
+	}
+	id, err := NewUUID()
+	if err != nil {
+		return err
+	}
+	t.PublicID = id
+	return nil
+}
+
+// NewUUID returns a random (version 4) UUID in its lowercase 8-4-4-4-12 form
+func NewUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate UUID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// SharePermission is the level of access a task share grants
+type SharePermission string
+
+const (
+	SharePermissionRead  SharePermission = "read"  // View the task
+	SharePermissionWrite SharePermission = "write" // View, update and delete the task
+)
+
+// IsValid reports whether p is a known permission
+func (p SharePermission) IsValid() bool {
+	return p == SharePermissionRead || p == SharePermissionWrite
+}
+
+// TaskShare gives another user access to a single task
+// The owner keeps full control: only they can share, revoke or transfer the
+// task. Each user has at most one share per task; sharing again changes the
+// permission.
+type TaskShare struct {
+	ID               uint            `gorm:"primaryKey" json:"id"`
+	TaskID           uint            `gorm:"not null;uniqueIndex:idx_task_shares_task_user" json:"task_id"`
+	SharedWithUserID uint            `gorm:"not null;uniqueIndex:idx_task_shares_task_user;index" json:"shared_with_user_id"`
+	Permission       SharePermission `gorm:"type:varchar(10);not null" json:"permission"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TaskStatusHistory records a single status change on a task
+// Rows are only written when the status actually changes, giving an
+// audit trail of who moved a task and when
+type TaskStatusHistory struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TaskID     uint       `gorm:"not null;index" json:"task_id"`
+	FromStatus TaskStatus `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus   TaskStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	UserID     uint       `gorm:"not null" json:"user_id"` // User who made the change
+	CreatedAt  time.Time  `json:"created_at"`
+}
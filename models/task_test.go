@@ -0,0 +1,25 @@
+package models
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestNewUUID tests that public task IDs are distinct version 4 UUIDs
+func TestNewUUID(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		id, err := NewUUID()
+		if err != nil {
+			t.Fatalf("Failed to generate UUID: %v", err)
+		}
+		if !uuidV4.MatchString(id) {
+			t.Errorf("Expected a version 4 UUID, got %q", id)
+		}
+		if seen[id] {
+			t.Errorf("Got %q twice", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// TimeEntry is a stretch of time someone spent working on a task
+// Entries are either logged after the fact, with both ends, or started as a
+// timer that is still running (EndedAt nil) until it's stopped. Each user
+// has at most one running timer. Finished entries add up to the task's
+// TotalTimeSeconds.
+type TimeEntry struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	TaskID    uint       `gorm:"not null;index" json:"task_id"`
+	UserID    uint       `gorm:"not null;uniqueIndex:idx_time_entries_user_running,where:ended_at IS NULL" json:"user_id"` // Who spent the time
+	StartedAt time.Time  `gorm:"not null" json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`                          // nil while the timer runs
+	Seconds   int64      `gorm:"not null;default:0" json:"seconds"` // EndedAt - StartedAt in whole seconds; 0 while running
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,8 +11,50 @@ type User struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	Email     string         `gorm:"unique;not null" json:"email"`
 	Password  string         `gorm:"not null" json:"-"`
+	OrgID     uint           `gorm:"not null;index" json:"org_id"`                           // Organization the user belongs to
+	Role      UserRole       `gorm:"type:varchar(20);not null;default:'member'" json:"role"` // Role within the organization
+	Active    bool           `gorm:"not null;default:true" json:"active"`                    // Deactivated users can't log in
 	Tasks     []Task         `json:"tasks,omitempty"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
-}
\ No newline at end of file
+
+	// Brute-force protection: wrong passwords since the last successful login
+	// (or lockout), and when the current lockout ends
+	FailedLoginAttempts int        `gorm:"not null;default:0" json:"-"`
+	LockedUntil         *time.Time `json:"-"`
+
+	// Most recent successful login, for reviewing account activity
+	LastLoginAt *time.Time `json:"last_login_at"`
+	LastLoginIP string     `gorm:"type:varchar(45)" json:"last_login_ip,omitempty"` // Fits any IPv4 or IPv6 address
+
+	// Tokens carry the version they were issued with; bumping it signs the
+	// user out everywhere by invalidating every token issued before
+	TokenVersion int `gorm:"not null;default:0" json:"-"`
+}
+
+// IsLocked reports whether logins are blocked at the given time
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// BeforeDelete soft-deletes all of the user's tasks when the user is deleted,
+// and removes the shares that gave them access to other users' tasks
+// GORM runs delete hooks inside the same transaction as the delete itself,
+// so if any step fails the whole operation rolls back and no orphaned
+// tasks or shares are left behind.
+// Shares have no deleted_at, so they're removed permanently. Restoring a user
+// (clearing deleted_at) intentionally does NOT restore their tasks - those
+// have to be restored explicitly - and the shares have to be granted again.
+func (u *User) BeforeDelete(tx *gorm.DB) error {
+	// Bulk deletes like db.Where(...).Delete(&User{}) don't carry the user's ID,
+	// so refuse them instead of silently skipping the cascade
+	if u.ID == 0 {
+		return errors.New("users must be deleted by loaded record so their tasks can be cascaded")
+	}
+
+	if err := tx.Where("user_id = ?", u.ID).Delete(&Task{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("shared_with_user_id = ?", u.ID).Delete(&TaskShare{}).Error
+}
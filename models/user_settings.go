@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// UserSettings holds a user's preferences
+// Users start without a row; every setting then falls back to its global default
+type UserSettings struct {
+	UserID uint `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	// Status for new tasks created without one; nil uses the workflow's default
+	DefaultTaskStatus *TaskStatus `gorm:"type:varchar(20)" json:"default_task_status"`
+	// IANA time zone response timestamps are written in; nil writes them in UTC
+	Timezone  *string   `gorm:"type:varchar(64)" json:"timezone"`
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
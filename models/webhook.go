@@ -0,0 +1,65 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Task events that webhooks can subscribe to
+const (
+	WebhookEventTaskCreated   = "task.created"
+	WebhookEventTaskUpdated   = "task.updated"
+	WebhookEventTaskCompleted = "task.completed" // Sent in addition to task.updated
+	WebhookEventTaskDueSoon   = "task.due_soon"  // Reminder that the due date is near
+)
+
+// WebhookEvents lists every event a webhook may subscribe to
+var WebhookEvents = []string{
+	WebhookEventTaskCreated,
+	WebhookEventTaskUpdated,
+	WebhookEventTaskCompleted,
+	WebhookEventTaskDueSoon,
+}
+
+// Webhook is a user's subscription to task events
+// Matching events are POSTed to URL with an HMAC-SHA256 signature made with Secret
+type Webhook struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"`
+	URL       string         `gorm:"not null" json:"url"`
+	Secret    string         `gorm:"not null" json:"-"`                        // Never echoed back after creation
+	Events    string         `gorm:"type:varchar(255);not null" json:"events"` // Comma-separated event names
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// EventList returns the subscribed events as a slice
+func (h *Webhook) EventList() []string {
+	if h.Events == "" {
+		return nil
+	}
+	return strings.Split(h.Events, ",")
+}
+
+// Subscribes reports whether the webhook wants to receive event
+func (h *Webhook) Subscribes(event string) bool {
+	for _, e := range h.EventList() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWebhookEvent reports whether event is one webhooks can subscribe to
+func IsWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxStatusLength matches the varchar(20) size of the tasks.status column
+const maxStatusLength = 20
+
+// Workflow describes which task statuses exist and how tasks may move between them
+type Workflow struct {
+	Statuses      []TaskStatus // Allowed statuses, in display order
+	DefaultStatus TaskStatus   // Status for new tasks that don't specify one
+
+	// transitions maps a status to the statuses it may change to
+	// A nil map means any change between valid statuses is allowed
+	transitions map[TaskStatus]map[TaskStatus]bool
+}
+
+// NewWorkflow builds a Workflow from configuration values
+// transitions are "from>to" pairs, e.g. "pending>in_progress"; leaving them
+// empty allows every change, which matches the original three-status behavior
+func NewWorkflow(statuses []string, defaultStatus string, transitions []string) (*Workflow, error) {
+	if len(statuses) == 0 {
+		return nil, fmt.Errorf("at least one task status must be configured")
+	}
+
+	w := &Workflow{}
+	seen := make(map[TaskStatus]bool)
+	for _, s := range statuses {
+		status := TaskStatus(strings.TrimSpace(s))
+		if status == "" {
+			return nil, fmt.Errorf("task status names cannot be empty")
+		}
+		if len(status) > maxStatusLength {
+			return nil, fmt.Errorf("task status %q is longer than %d characters", status, maxStatusLength)
+		}
+		if seen[status] {
+			return nil, fmt.Errorf("task status %q is listed more than once", status)
+		}
+		seen[status] = true
+		w.Statuses = append(w.Statuses, status)
+	}
+
+	w.DefaultStatus = TaskStatus(strings.TrimSpace(defaultStatus))
+	if !w.IsValid(w.DefaultStatus) {
+		return nil, fmt.Errorf("default task status %q is not one of the configured statuses", defaultStatus)
+	}
+
+	if len(transitions) > 0 {
+		w.transitions = make(map[TaskStatus]map[TaskStatus]bool)
+		for _, t := range transitions {
+			parts := strings.Split(t, ">")
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("task transition %q must be in the form from>to", t)
+			}
+
+			from := TaskStatus(strings.TrimSpace(parts[0]))
+			to := TaskStatus(strings.TrimSpace(parts[1]))
+			if !w.IsValid(from) || !w.IsValid(to) {
+				return nil, fmt.Errorf("task transition %q uses an unknown status", t)
+			}
+
+			if w.transitions[from] == nil {
+				w.transitions[from] = make(map[TaskStatus]bool)
+			}
+			w.transitions[from][to] = true
+		}
+	}
+
+	return w, nil
+}
+
+// IsValid reports whether status is part of the workflow
+func (w *Workflow) IsValid(status TaskStatus) bool {
+	for _, s := range w.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransition reports whether a task may change from one status to another
+// Keeping the same status is always allowed
+func (w *Workflow) CanTransition(from, to TaskStatus) bool {
+	if !w.IsValid(to) {
+		return false
+	}
+	if from == to || w.transitions == nil {
+		return true
+	}
+	return w.transitions[from][to]
+}
+
+// StatusList renders the statuses for error messages, e.g. "pending, in_progress, or completed"
+func (w *Workflow) StatusList() string {
+	names := make([]string, len(w.Statuses))
+	for i, s := range w.Statuses {
+		names[i] = string(s)
+	}
+
+	switch len(names) {
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " or " + names[1]
+	default:
+		return strings.Join(names[:len(names)-1], ", ") + ", or " + names[len(names)-1]
+	}
+}
@@ -0,0 +1,136 @@
+package models
+
+import "testing"
+
+// TestNewWorkflow tests validation of the workflow configuration
+func TestNewWorkflow(t *testing.T) {
+	defaultStatuses := []string{"pending", "in_progress", "completed"}
+
+	testCases := []struct {
+		name          string
+		statuses      []string
+		defaultStatus string
+		transitions   []string
+		wantErr       bool
+	}{
+		{
+			name:          "default three statuses",
+			statuses:      defaultStatuses,
+			defaultStatus: "pending",
+			wantErr:       false,
+		},
+		{
+			name:          "custom statuses with transitions",
+			statuses:      []string{"todo", "review", "done"},
+			defaultStatus: "todo",
+			transitions:   []string{"todo>review", "review>done", "review>todo"},
+			wantErr:       false,
+		},
+		{
+			name:          "no statuses",
+			statuses:      nil,
+			defaultStatus: "pending",
+			wantErr:       true,
+		},
+		{
+			name:          "default not in set",
+			statuses:      defaultStatuses,
+			defaultStatus: "todo",
+			wantErr:       true,
+		},
+		{
+			name:          "duplicate status",
+			statuses:      []string{"pending", "pending"},
+			defaultStatus: "pending",
+			wantErr:       true,
+		},
+		{
+			name:          "status longer than column",
+			statuses:      []string{"waiting_for_customer_reply"},
+			defaultStatus: "waiting_for_customer_reply",
+			wantErr:       true,
+		},
+		{
+			name:          "malformed transition",
+			statuses:      defaultStatuses,
+			defaultStatus: "pending",
+			transitions:   []string{"pending-completed"},
+			wantErr:       true,
+		},
+		{
+			name:          "transition to unknown status",
+			statuses:      defaultStatuses,
+			defaultStatus: "pending",
+			transitions:   []string{"pending>archived"},
+			wantErr:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewWorkflow(tc.statuses, tc.defaultStatus, tc.transitions)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("NewWorkflow() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// TestWorkflowCanTransition tests the transition rules
+func TestWorkflowCanTransition(t *testing.T) {
+	statuses := []string{"pending", "in_progress", "completed"}
+
+	// Without transition rules every valid status change is allowed
+	open, err := NewWorkflow(statuses, "pending", nil)
+	if err != nil {
+		t.Fatalf("Failed to build workflow: %v", err)
+	}
+
+	// With rules, completed tasks can't go straight back to pending
+	strict, err := NewWorkflow(statuses, "pending", []string{
+		"pending>in_progress",
+		"pending>completed",
+		"in_progress>completed",
+		"completed>in_progress",
+	})
+	if err != nil {
+		t.Fatalf("Failed to build workflow: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		workflow *Workflow
+		from     TaskStatus
+		to       TaskStatus
+		want     bool
+	}{
+		{"open: completed to pending", open, TaskStatusCompleted, TaskStatusPending, true},
+		{"open: unknown target", open, TaskStatusPending, "archived", false},
+		{"strict: pending to in_progress", strict, TaskStatusPending, TaskStatusInProgress, true},
+		{"strict: completed to pending", strict, TaskStatusCompleted, TaskStatusPending, false},
+		{"strict: in_progress to pending", strict, TaskStatusInProgress, TaskStatusPending, false},
+		{"strict: same status", strict, TaskStatusCompleted, TaskStatusCompleted, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.workflow.CanTransition(tc.from, tc.to); got != tc.want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestWorkflowStatusList tests the status list used in error messages
+func TestWorkflowStatusList(t *testing.T) {
+	w, err := NewWorkflow([]string{"pending", "in_progress", "completed"}, "pending", nil)
+	if err != nil {
+		t.Fatalf("Failed to build workflow: %v", err)
+	}
+
+	// The default set must keep producing the original error message
+	want := "pending, in_progress, or completed"
+	if got := w.StatusList(); got != want {
+		t.Errorf("StatusList() = %q, want %q", got, want)
+	}
+}
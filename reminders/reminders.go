@@ -0,0 +1,155 @@
+// Package reminders notifies users about tasks whose due date is coming up
+// A background scheduler periodically claims due tasks and hands them to a
+// notifier, and emails their owners; each task is reminded at most once per
+// due date.
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kcansari/task-management-api/mail"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// batchSize limits how many tasks a single run claims
+// Anything left over is picked up on the next tick
+const batchSize = 100
+
+// ClaimDueTasks marks up to limit tasks due between now and now+window as
+// reminded and returns them
+// Completed tasks need no reminder and are never claimed.
+// The rows are locked with FOR UPDATE SKIP LOCKED and flagged in the same
+// transaction, so replicas running at the same time claim different tasks
+// and no task is reminded twice.
+func ClaimDueTasks(db *gorm.DB, now time.Time, window time.Duration, limit int) ([]models.Task, error) {
+	var tasks []models.Task
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("reminder_sent = ? AND due_date IS NOT NULL AND due_date >= ? AND due_date <= ?", false, now, now.Add(window)).
+			Where("status <> ?", models.TaskStatusCompleted).
+			Order("due_date ASC, id ASC").
+			Limit(limit).
+			Find(&tasks).Error; err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(tasks))
+		for i, task := range tasks {
+			ids[i] = task.ID
+		}
+		return tx.Model(&models.Task{}).Where("id IN ?", ids).UpdateColumn("reminder_sent", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// Scheduler checks for due tasks on a fixed interval
+type Scheduler struct {
+	DB       *gorm.DB               // Database to scan
+	Interval time.Duration          // Time between runs
+	Window   time.Duration          // How far ahead a due date counts as "due soon"
+	Timeout  time.Duration          // Bound for each run's queries (0 for none)
+	Notify   func(task models.Task) // Called for every claimed task
+	Mailer   mail.Mailer            // Emails each reminder to the task's owner (nil sends no email)
+}
+
+// Run checks for due tasks every Interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("Reminder scheduler started (every %s, window %s)", s.Interval, s.Window)
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunOnce(ctx); err != nil {
+				log.Printf("Reminder scheduler run failed: %v", err)
+			}
+		}
+	}
+}
+
+// RunOnce claims the tasks that are due soon and notifies about each of them
+// It keeps claiming batches until none are left and returns how many were reminded
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	reminded := 0
+	for {
+		tasks, err := ClaimDueTasks(s.DB.WithContext(ctx), time.Now(), s.Window, batchSize)
+		if err != nil {
+			return reminded, err
+		}
+
+		// Notify only after the claim is committed: a crash in between skips a
+		// reminder rather than sending it twice
+		for _, task := range tasks {
+			s.Notify(task)
+		}
+		s.email(ctx, tasks)
+		reminded += len(tasks)
+
+		if len(tasks) < batchSize {
+			return reminded, nil
+		}
+	}
+}
+
+// email sends a reminder for each task to its owner in the background
+// Failing to load the owners is only logged: the tasks are already claimed,
+// and the other notifications have gone out.
+func (s *Scheduler) email(ctx context.Context, tasks []models.Task) {
+	if s.Mailer == nil || len(tasks) == 0 {
+		return
+	}
+
+	ids := make([]uint, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.UserID
+	}
+	var users []models.User
+	if err := s.DB.WithContext(ctx).Select("id", "email").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		log.Printf("Failed to load owners for reminder emails: %v", err)
+		return
+	}
+	emails := make(map[uint]string, len(users))
+	for _, user := range users {
+		emails[user.ID] = user.Email
+	}
+
+	for _, task := range tasks {
+		email, ok := emails[task.UserID]
+		if !ok {
+			continue
+		}
+		mail.SendAsync(s.Mailer, reminderMessage(email, task))
+	}
+}
+
+// reminderMessage is the reminder email about task, addressed to to
+func reminderMessage(to string, task models.Task) mail.Message {
+	due := task.DueDate.UTC().Format("Mon, 02 Jan 2006 15:04 MST")
+	return mail.Message{
+		To:      []string{to},
+		Subject: fmt.Sprintf("Reminder: %q is due %s", task.Title, due),
+		Body: fmt.Sprintf("Your task %q is due %s.\n\nStatus: %s\n",
+			task.Title, due, task.Status),
+	}
+}
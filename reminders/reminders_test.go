@@ -0,0 +1,144 @@
+package reminders
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+	"github.com/kcansari/task-management-api/database"
+	"github.com/kcansari/task-management-api/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// testDBOnce makes sure the test database is connected and migrated only once per run
+	testDBOnce sync.Once
+	testDBErr  error
+)
+
+// testTx connects to the test database like the handlers tests do and
+// returns a transaction that is rolled back when the test ends
+// Tests are skipped (not failed) when no database is reachable
+func testTx(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	testDBOnce.Do(func() {
+		cfg := *config.Get()
+		if name := os.Getenv("TEST_DB_NAME"); name != "" {
+			cfg.DBName = name
+		}
+
+		if testDBErr = database.Connect(&cfg); testDBErr != nil {
+			return
+		}
+
+		if cfg.DBAutoMigrate {
+			testDBErr = database.AutoMigrate()
+		} else {
+			testDBErr = database.RunMigrations()
+		}
+	})
+	if testDBErr != nil {
+		t.Skipf("Test database unavailable: %v", testDBErr)
+	}
+
+	tx := database.GetDB().Begin()
+	if tx.Error != nil {
+		t.Fatalf("Failed to begin test transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+	})
+	return tx
+}
+
+// TestClaimDueTasks tests which tasks are claimed and that each is claimed once
+func TestClaimDueTasks(t *testing.T) {
+	tx := testTx(t)
+
+	org := models.Organization{Name: fmt.Sprintf("test-reminders-%d", time.Now().UnixNano())}
+	if err := tx.Create(&org).Error; err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	user := models.User{Email: org.Name + "@example.com", Password: "x", OrgID: org.ID}
+	if err := tx.Create(&user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// Far enough ahead that no other task in the database is due in the window
+	now := time.Date(2090, 1, 1, 12, 0, 0, 0, time.UTC)
+	task := func(title string, due time.Time, status models.TaskStatus, sent bool) uint {
+		t.Helper()
+		task := models.Task{Title: title, UserID: user.ID, OrgID: org.ID, DueDate: &due, Status: status}
+		if err := tx.Create(&task).Error; err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		// Set separately: the column's default would override a false in Create
+		if err := tx.Model(&task).UpdateColumn("reminder_sent", sent).Error; err != nil {
+			t.Fatalf("Failed to set reminder_sent: %v", err)
+		}
+		return task.ID
+	}
+	soon := task("Soon", now.Add(time.Hour), models.TaskStatusPending, false)
+	sooner := task("Sooner", now.Add(time.Minute), models.TaskStatusInProgress, false)
+	task("Already reminded", now.Add(time.Hour), models.TaskStatusPending, true)
+	task("Completed", now.Add(time.Hour), models.TaskStatusCompleted, false)
+	task("Too late", now.Add(48*time.Hour), models.TaskStatusPending, false)
+	task("Overdue", now.Add(-time.Hour), models.TaskStatusPending, false)
+
+	tasks, err := ClaimDueTasks(tx, now, 24*time.Hour, batchSize)
+	if err != nil {
+		t.Fatalf("Failed to claim tasks: %v", err)
+	}
+	claimed := make([]uint, 0, len(tasks))
+	for _, task := range tasks {
+		claimed = append(claimed, task.ID)
+	}
+	if expected := []uint{sooner, soon}; !slices.Equal(claimed, expected) {
+		t.Fatalf("Expected %v claimed by due date, got %v", expected, claimed)
+	}
+
+	var sent []uint
+	tx.Model(&models.Task{}).Where("id IN ? AND reminder_sent = ?", claimed, true).Order("id").Pluck("id", &sent)
+	if len(sent) != 2 {
+		t.Errorf("Expected both claimed tasks flagged as reminded, got %v", sent)
+	}
+
+	// The flag keeps a task from being claimed again
+	if tasks, err := ClaimDueTasks(tx, now, 24*time.Hour, batchSize); err != nil || len(tasks) != 0 {
+		t.Errorf("Expected nothing left to claim, got %d tasks (%v)", len(tasks), err)
+	}
+}
+
+// TestClaimDueTasksLimit tests that a claim takes at most limit tasks, leaving the rest for the next
+func TestClaimDueTasksLimit(t *testing.T) {
+	tx := testTx(t)
+
+	org := models.Organization{Name: fmt.Sprintf("test-reminders-limit-%d", time.Now().UnixNano())}
+	if err := tx.Create(&org).Error; err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	user := models.User{Email: org.Name + "@example.com", Password: "x", OrgID: org.ID}
+	if err := tx.Create(&user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	now := time.Date(2091, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		due := now.Add(time.Duration(i) * time.Minute)
+		if err := tx.Create(&models.Task{Title: fmt.Sprintf("Task %d", i), UserID: user.ID, OrgID: org.ID, DueDate: &due}).Error; err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+
+	for _, expected := range []int{2, 1, 0} {
+		tasks, err := ClaimDueTasks(tx, now, time.Hour, 2)
+		if err != nil || len(tasks) != expected {
+			t.Fatalf("Expected %d tasks claimed, got %d (%v)", expected, len(tasks), err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestLocalRoundTrip tests storing, reading and deleting a file
+func TestLocalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+
+	if err := store.Put(ctx, "tasks/1/abc", strings.NewReader("hello"), "text/plain"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	file, err := store.Open(ctx, "tasks/1/abc")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	contents, _ := io.ReadAll(file)
+	file.Close()
+	if string(contents) != "hello" {
+		t.Errorf("Expected %q, got %q", "hello", contents)
+	}
+
+	if err := store.Delete(ctx, "tasks/1/abc"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Open(ctx, "tasks/1/abc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after Delete, got %v", err)
+	}
+
+	// Deleting twice is fine
+	if err := store.Delete(ctx, "tasks/1/abc"); err != nil {
+		t.Errorf("Expected deleting a missing file to succeed, got %v", err)
+	}
+}
+
+// failingReader returns some data and then an error, like an aborted upload
+type failingReader struct{ sent bool }
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.sent {
+		return 0, errors.New("upload aborted")
+	}
+	f.sent = true
+	return copy(p, "partial"), nil
+}
+
+// TestLocalPutFailure tests that a failed upload leaves no file behind
+func TestLocalPutFailure(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := NewLocal(dir)
+
+	if err := store.Put(context.Background(), "tasks/1/broken", &failingReader{}, "text/plain"); err == nil {
+		t.Fatalf("Expected Put to fail")
+	}
+
+	if _, err := store.Open(context.Background(), "tasks/1/broken"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected no file after a failed Put, got %v", err)
+	}
+	entries, _ := os.ReadDir(dir + "/tasks/1")
+	if len(entries) != 0 {
+		t.Errorf("Expected no leftover temporary files, got %d", len(entries))
+	}
+}
+
+// TestLocalRejectsEscapingKeys tests that keys can't reach outside the directory
+func TestLocalRejectsEscapingKeys(t *testing.T) {
+	store, _ := NewLocal(t.TempDir())
+
+	for _, key := range []string{"../outside", "/etc/passwd", "tasks/../../outside"} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), "text/plain"); err == nil {
+			t.Errorf("Expected Put(%q) to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPartSize is how much of an upload S3 buffers in memory at a time
+// 5 MiB is the smallest part size S3 accepts for multipart uploads.
+const DefaultPartSize = 5 << 20
+
+// S3 stores files in a bucket of an S3-compatible object store (AWS S3, MinIO, ...)
+// It speaks the REST API directly, signing requests with AWS Signature Version 4,
+// and addresses objects path-style (Endpoint/Bucket/key).
+type S3 struct {
+	Endpoint  string // Base URL, e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+	Region    string // Region used for signing, e.g. us-east-1
+	Bucket    string
+	AccessKey string
+	SecretKey string
+
+	PartSize int          // Bytes per upload part (DefaultPartSize when 0)
+	Client   *http.Client // http.DefaultClient when nil
+
+	now func() time.Time // Clock used for signing; swapped in tests
+}
+
+// Put uploads r to key
+// Files that fit in one part are sent with a single PUT. Larger ones use a
+// multipart upload, so at most one part is held in memory however big the file is.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	buf := make([]byte, s.partSize())
+	n, err := io.ReadFull(r, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		header := http.Header{"Content-Type": {contentType}}
+		resp, err := s.do(ctx, "PUT", key, nil, header, buf[:n])
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	uploadID, err := s.createMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return err
+	}
+	if err := s.uploadParts(ctx, key, uploadID, buf, r); err != nil {
+		// Don't leave the parts behind: S3 bills for them until aborted
+		s.abortMultipartUpload(ctx, key, uploadID)
+		return err
+	}
+	return nil
+}
+
+// Open downloads the object stored under key
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, "GET", key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object stored under key (S3 treats missing objects as deleted)
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, "DELETE", key, nil, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// completedPart is one entry of a CompleteMultipartUpload request
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// uploadParts sends first and then the rest of r in parts and completes the upload
+func (s *S3) uploadParts(ctx context.Context, key, uploadID string, first []byte, r io.Reader) error {
+	var parts []completedPart
+	buf := first
+	for partNumber := 1; ; partNumber++ {
+		query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+		resp, err := s.do(ctx, "PUT", key, query, nil, buf)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		parts = append(parts, completedPart{PartNumber: partNumber, ETag: resp.Header.Get("ETag")})
+
+		// The first buffer is reused for the following parts
+		n, err := io.ReadFull(r, first)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		buf = first[:n]
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, "POST", key, url.Values{"uploadId": {uploadID}}, nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// S3 can report a failed completion with 200 OK and an <Error> body
+	var result struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to read multipart upload result: %w", err)
+	}
+	if result.XMLName.Local == "Error" {
+		return fmt.Errorf("s3: completing upload failed: %s: %s", result.Code, result.Message)
+	}
+	return nil
+}
+
+// createMultipartUpload starts a multipart upload and returns its ID
+func (s *S3) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	header := http.Header{"Content-Type": {contentType}}
+	resp, err := s.do(ctx, "POST", key, url.Values{"uploads": {""}}, header, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("failed to start multipart upload: %v", err)
+	}
+	return result.UploadID, nil
+}
+
+// abortMultipartUpload discards the parts of an unfinished upload
+func (s *S3) abortMultipartUpload(ctx context.Context, key, uploadID string) {
+	// The request context may already be cancelled (that's often why we're aborting)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	resp, err := s.do(ctx, "DELETE", key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// do sends a signed request for key and turns error statuses into errors
+// 404 becomes ErrNotFound; the caller closes the body of successful responses.
+func (s *S3) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+
+	objectPath := strings.TrimSuffix(endpoint.Path, "/") + "/" + s.Bucket + "/" + key
+	reqURL := &url.URL{
+		Scheme:   endpoint.Scheme,
+		Host:     endpoint.Host,
+		Path:     objectPath,
+		RawPath:  uriEncode(objectPath, false),
+		RawQuery: canonicalQuery(query),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.sign(req, body)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3: %s %s: status %d: %s", method, key, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req
+// Every header already set on req is signed, along with Host.
+func (s *S3) sign(req *http.Request, body []byte) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	// Canonical headers: lowercase names, sorted, with trimmed values
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, s.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
+
+// partSize returns the configured part size or the default
+func (s *S3) partSize() int {
+	if s.PartSize > 0 {
+		return s.PartSize
+	}
+	return DefaultPartSize
+}
+
+// hmacSHA256 computes HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query the way Signature Version 4 expects:
+// sorted by name, strictly percent-encoded, and "name=" for empty values
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything except unreserved characters (and,
+// unless encodeSlash is set, '/'), as Signature Version 4 requires
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+// Package storage keeps uploaded files (task attachments) outside the database
+// Files are addressed by an opaque key chosen by the caller; the backend is
+// either a local directory or an S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// ErrNotFound is returned by Open when no file is stored under the key
+var ErrNotFound = errors.New("storage: file not found")
+
+// Storage is a place to put files and read them back
+type Storage interface {
+	// Put stores everything read from r under key, replacing any existing file
+	// r is consumed as a stream, so a failing read (e.g. an upload that's too
+	// large) aborts the write and leaves nothing behind
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+
+	// Open returns the file stored under key; the caller must close it
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the file stored under key; missing files aren't an error
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the backend selected by ATTACHMENT_STORAGE
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.AttachmentStorage {
+	case "", "local":
+		return NewLocal(cfg.AttachmentDir)
+	case "s3":
+		return &S3{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKeyID,
+			SecretKey: cfg.S3SecretAccessKey,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown attachment storage %q", cfg.AttachmentStorage)
+	}
+}
@@ -0,0 +1,257 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// otlpQueueSize is how many ended spans may wait to be sent; beyond it
+	// spans are dropped rather than slowing requests down
+	otlpQueueSize = 2048
+	// otlpBatchSize is the most spans sent in one request
+	otlpBatchSize = 512
+	// otlpInterval is the longest a span waits before being sent
+	otlpInterval = 5 * time.Second
+	// otlpTimeout bounds each request to the collector
+	otlpTimeout = 10 * time.Second
+	// otlpScope names the instrumentation that recorded the spans
+	otlpScope = "github.com/kcansari/task-management-api"
+)
+
+// OTLPExporter sends spans to an OpenTelemetry collector over OTLP/HTTP
+// Spans are queued as they end and sent in batches, JSON-encoded, by a
+// background goroutine, so exporting never holds up a request. Failed
+// batches are logged and dropped: traces are diagnostics, not data.
+type OTLPExporter struct {
+	url         string            // The collector's traces endpoint, e.g. http://localhost:4318/v1/traces
+	headers     map[string]string // Sent with every request, e.g. for authentication
+	serviceName string
+	client      *http.Client
+
+	queue chan *Span
+	flush chan chan struct{}
+	done  chan struct{}
+}
+
+// NewOTLPExporter starts an exporter for the collector at endpoint
+// endpoint is the base URL (OTEL_EXPORTER_OTLP_ENDPOINT); spans go to its
+// /v1/traces path. serviceName becomes the spans' service.name.
+func NewOTLPExporter(endpoint string, headers map[string]string, serviceName string) *OTLPExporter {
+	e := &OTLPExporter{
+		url:         strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		headers:     headers,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: otlpTimeout},
+		queue:       make(chan *Span, otlpQueueSize),
+		flush:       make(chan chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Export queues a span to be sent, dropping it when the queue is full
+func (e *OTLPExporter) Export(span *Span) {
+	select {
+	case e.queue <- span:
+	default:
+	}
+}
+
+// Shutdown sends the queued spans and stops the exporter
+// It gives up when ctx ends first. Spans exported afterwards are dropped.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	flushed := make(chan struct{})
+	select {
+	case e.flush <- flushed:
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run batches queued spans until Shutdown
+func (e *OTLPExporter) run() {
+	ticker := time.NewTicker(otlpInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	send := func() {
+		if len(batch) > 0 {
+			if err := e.send(batch); err != nil {
+				log.Printf("Failed to export %d spans: %v", len(batch), err)
+			}
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) >= otlpBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case flushed := <-e.flush:
+			// Take whatever is still queued, then stop
+			for len(e.queue) > 0 {
+				batch = append(batch, <-e.queue)
+				if len(batch) >= otlpBatchSize {
+					send()
+				}
+			}
+			send()
+			close(e.done)
+			close(flushed)
+			return
+		}
+	}
+}
+
+// send POSTs one batch of spans to the collector
+func (e *OTLPExporter) send(spans []*Span) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+// The OTLP/JSON request body, trimmed to the fields recorded here
+// IDs are hex strings and 64-bit integers decimal strings, as OTLP/JSON requires.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScopeInfo `json:"scope"`
+		Spans []otlpSpan    `json:"spans"`
+	}
+	otlpScopeInfo struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              SpanKind        `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpStatus struct {
+		Code    StatusCode `json:"code"`
+		Message string     `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+// request builds the request body for a batch of spans
+func (e *OTLPExporter) request(spans []*Span) otlpRequest {
+	converted := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		converted = append(converted, newOTLPSpan(span))
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{newOTLPAttribute("service.name", e.serviceName)}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScopeInfo{Name: otlpScope},
+			Spans: converted,
+		}},
+	}}}
+}
+
+// newOTLPSpan converts an ended span
+func newOTLPSpan(span *Span) otlpSpan {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	converted := otlpSpan{
+		TraceID:           hex.EncodeToString(span.context.TraceID[:]),
+		SpanID:            hex.EncodeToString(span.context.SpanID[:]),
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		Status:            otlpStatus{Code: span.status, Message: span.message},
+	}
+	if span.parentID != ([8]byte{}) {
+		converted.ParentSpanID = hex.EncodeToString(span.parentID[:])
+	}
+	for _, attr := range span.attributes {
+		converted.Attributes = append(converted.Attributes, newOTLPAttribute(attr.Key, attr.Value))
+	}
+	return converted
+}
+
+// newOTLPAttribute converts an attribute, typing its value the OTLP way
+// Types other than strings, bools and numbers are sent as their %v text.
+func newOTLPAttribute(key string, value any) otlpAttribute {
+	var typed map[string]any
+	switch v := value.(type) {
+	case string:
+		typed = map[string]any{"stringValue": v}
+	case bool:
+		typed = map[string]any{"boolValue": v}
+	case int:
+		typed = map[string]any{"intValue": strconv.FormatInt(int64(v), 10)}
+	case int64:
+		typed = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case uint:
+		typed = map[string]any{"intValue": strconv.FormatUint(uint64(v), 10)}
+	case float64:
+		typed = map[string]any{"doubleValue": v}
+	default:
+		typed = map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+	return otlpAttribute{Key: key, Value: typed}
+}
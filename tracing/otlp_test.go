@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestOTLPExporter tests that Shutdown sends queued spans to the collector
+func TestOTLPExporter(t *testing.T) {
+	requests := make(chan otlpRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Unexpected request to %s with Authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode the request: %v", err)
+		}
+		requests <- body
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(collector.URL+"/", map[string]string{"Authorization": "Bearer secret"}, "tasks")
+	tracer := NewTracer(exporter)
+	_, span := tracer.Start(context.Background(), "GET /api/tasks", SpanKindServer)
+	span.SetAttribute("user_id", uint(7))
+	span.End()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	var body otlpRequest
+	select {
+	case body = <-requests:
+	default:
+		t.Fatal("Expected the span to be sent by Shutdown")
+	}
+	resource := body.ResourceSpans[0]
+	if got := resource.Resource.Attributes[0].Value["stringValue"]; got != "tasks" {
+		t.Errorf("Expected service.name tasks, got %v", got)
+	}
+	spans := resource.ScopeSpans[0].Spans
+	if len(spans) != 1 || spans[0].Name != "GET /api/tasks" || spans[0].Kind != SpanKindServer {
+		t.Fatalf("Unexpected spans: %+v", spans)
+	}
+	if spans[0].TraceID != span.SpanContext().Traceparent()[3:35] {
+		t.Errorf("Expected the trace ID in hex, got %q", spans[0].TraceID)
+	}
+	if got := spans[0].Attributes[0].Value["intValue"]; got != "7" {
+		t.Errorf("Expected user_id as an integer string, got %v", got)
+	}
+}
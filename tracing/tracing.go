@@ -0,0 +1,347 @@
+// Package tracing records OpenTelemetry-compatible spans for distributed tracing
+// Spans join the caller's trace through the W3C traceparent header and are
+// sent to an OTLP collector (see OTLPExporter). Until SetDefault installs a
+// tracer nothing is recorded, and every span is a nil *Span whose methods do
+// nothing, so callers never need to check whether tracing is enabled.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceparentHeader carries the caller's trace context (W3C Trace Context)
+const TraceparentHeader = "traceparent"
+
+// SpanContext identifies a span within a trace
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool // Whether the trace is being recorded
+}
+
+// IsValid reports whether sc has both IDs; all-zero IDs are invalid
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formats sc as a traceparent header value, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent reads a traceparent header value
+// ok is false for anything malformed, which callers treat as no parent: a new
+// trace is started rather than the request being refused. Versions after 00
+// may append fields, which are ignored as the spec asks.
+func ParseTraceparent(value string) (sc SpanContext, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+	return sc, true
+}
+
+// SpanKind says which side of a request a span represents
+// The values are OTLP's.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2 // Handling an incoming request
+	SpanKindClient   SpanKind = 3 // Making an outgoing call, e.g. a database query
+)
+
+// StatusCode is the outcome of a span; the values are OTLP's
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute is a key/value pair describing a span
+// Values are strings, bools, integers or floats.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Span is one timed operation in a trace
+// A nil *Span is a valid, disabled span: all its methods do nothing.
+type Span struct {
+	tracer   *Tracer
+	context  SpanContext
+	parentID [8]byte // Zero for the root span of a trace
+	kind     SpanKind
+	start    time.Time
+
+	mu         sync.Mutex
+	name       string
+	end        time.Time
+	attributes []Attribute
+	status     StatusCode
+	message    string // Description of an error status
+	ended      bool
+}
+
+// SpanContext returns the span's IDs, e.g. to propagate them to another service
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+// Name returns the span's name
+func (s *Span) Name() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.name
+}
+
+// ParentSpanID returns the ID of the span's parent, zero for a root span
+func (s *Span) ParentSpanID() [8]byte {
+	if s == nil {
+		return [8]byte{}
+	}
+	return s.parentID
+}
+
+// Attribute returns the value of one of the span's attributes
+func (s *Span) Attribute(key string) (any, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, attr := range s.attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Status returns the span's outcome and, for StatusError, its description
+func (s *Span) Status() (StatusCode, string) {
+	if s == nil {
+		return StatusUnset, ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status, s.message
+}
+
+// SetName renames the span, e.g. once the route that served a request is known
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+// SetAttribute adds an attribute to the span, replacing one with the same key
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attributes {
+		if s.attributes[i].Key == key {
+			s.attributes[i].Value = value
+			return
+		}
+	}
+	s.attributes = append(s.attributes, Attribute{Key: key, Value: value})
+}
+
+// SetStatus records the outcome of the span; message only matters for StatusError
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = code
+	s.message = message
+}
+
+// RecordError marks the span as failed with err; a nil err changes nothing
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End finishes the span and hands it to the exporter
+// Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if s.context.Sampled {
+		s.tracer.exporter.Export(s)
+	}
+}
+
+// Exporter sends finished spans somewhere
+// Export is called once per span as it ends and must not block.
+type Exporter interface {
+	Export(span *Span)
+	Shutdown(ctx context.Context) error
+}
+
+// Tracer starts spans and sends them to its exporter when they end
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer creates a tracer that exports to exporter
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Shutdown sends the spans still waiting in the exporter
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	return t.exporter.Shutdown(ctx)
+}
+
+// defaultTracer is the tracer Start uses; nil while tracing is disabled
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault installs the tracer Start uses (nil disables tracing again)
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Enabled reports whether a tracer is installed
+func Enabled() bool {
+	return defaultTracer.Load() != nil
+}
+
+type spanKey struct{}
+type remoteParentKey struct{}
+
+// ContextWithRemoteParent returns a copy of ctx whose next span continues the
+// trace sc came from, e.g. one read from an incoming traceparent header
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteParentKey{}, sc)
+}
+
+// SpanFromContext returns the span started with ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Start starts a span with the default tracer
+// The span is a child of the span in ctx, or continues the remote trace in
+// ctx, or else starts a new trace. The returned context carries the span, so
+// work done with it becomes its children. With tracing disabled ctx is
+// returned as it is, with a nil span.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	tracer := defaultTracer.Load()
+	if tracer == nil {
+		return ctx, nil
+	}
+	return tracer.Start(ctx, name, kind)
+}
+
+// StartChild is Start, but only when ctx already has a span
+// It suits operations (like database queries) that are worth tracing as part
+// of a request, but would only be noise as traces of their own.
+func StartChild(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if SpanFromContext(ctx) == nil {
+		return ctx, nil
+	}
+	return Start(ctx, name, kind)
+}
+
+// Start starts a span with t; see the package-level Start
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{tracer: t, name: name, kind: kind, start: time.Now()}
+
+	var parent SpanContext
+	if parentSpan := SpanFromContext(ctx); parentSpan != nil {
+		parent = parentSpan.context
+	} else if remote, ok := ctx.Value(remoteParentKey{}).(SpanContext); ok {
+		parent = remote
+	}
+
+	if parent.IsValid() {
+		// Follow the caller's sampling decision so traces are complete or absent
+		span.context.TraceID = parent.TraceID
+		span.context.Sampled = parent.Sampled
+		span.parentID = parent.SpanID
+	} else {
+		span.context.TraceID = randomID16()
+		span.context.Sampled = true
+	}
+	span.context.SpanID = randomID8()
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// randomID16 returns a random, non-zero trace ID
+func randomID16() (id [16]byte) {
+	for id == ([16]byte{}) {
+		if _, err := rand.Read(id[:]); err != nil {
+			panic(fmt.Sprintf("tracing: failed to generate trace ID: %v", err))
+		}
+	}
+	return id
+}
+
+// randomID8 returns a random, non-zero span ID
+func randomID8() (id [8]byte) {
+	for id == ([8]byte{}) {
+		if _, err := rand.Read(id[:]); err != nil {
+			panic(fmt.Sprintf("tracing: failed to generate span ID: %v", err))
+		}
+	}
+	return id
+}
@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// recordingExporter keeps exported spans in memory
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *recordingExporter) Export(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error { return nil }
+
+// TestParseTraceparent tests reading and writing traceparent headers
+func TestParseTraceparent(t *testing.T) {
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(header)
+	if !ok || !sc.Sampled {
+		t.Fatalf("Expected a sampled span context, got %+v (ok=%t)", sc, ok)
+	}
+	if got := sc.Traceparent(); got != header {
+		t.Errorf("Expected %q to round-trip, got %q", header, got)
+	}
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",          // No flags
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",       // Zero trace ID
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",       // Zero span ID
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",       // Invalid version
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", // Extra field in version 00
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",       // Not hex
+	} {
+		if _, ok := ParseTraceparent(value); ok {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+	if _, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); !ok {
+		t.Errorf("Expected later versions to allow extra fields")
+	}
+}
+
+// TestStart tests how spans join traces and reach the exporter
+func TestStart(t *testing.T) {
+	exporter := &recordingExporter{}
+	SetDefault(NewTracer(exporter))
+	t.Cleanup(func() { SetDefault(nil) })
+
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, root := Start(ContextWithRemoteParent(context.Background(), remote), "root", SpanKindServer)
+	_, child := StartChild(ctx, "child", SpanKindClient)
+	child.RecordError(errors.New("boom"))
+	child.End()
+	root.End()
+	root.End() // Only the first End counts
+
+	if len(exporter.spans) != 2 {
+		t.Fatalf("Expected 2 exported spans, got %d", len(exporter.spans))
+	}
+	if root.SpanContext().TraceID != remote.TraceID || root.ParentSpanID() != remote.SpanID {
+		t.Errorf("Expected the root span to continue the remote trace")
+	}
+	if child.SpanContext().TraceID != remote.TraceID || child.ParentSpanID() != root.SpanContext().SpanID {
+		t.Errorf("Expected the child span to be the root span's child")
+	}
+	if code, message := child.Status(); code != StatusError || message != "boom" {
+		t.Errorf("Expected an error status, got %d %q", code, message)
+	}
+
+	// Unsampled traces are followed but not exported
+	unsampled := remote
+	unsampled.Sampled = false
+	_, span := Start(ContextWithRemoteParent(context.Background(), unsampled), "unsampled", SpanKindServer)
+	span.End()
+	if len(exporter.spans) != 2 {
+		t.Errorf("Expected an unsampled span not to be exported")
+	}
+
+	// Without a span in the context there's nothing to be a child of
+	if _, span := StartChild(context.Background(), "orphan", SpanKindClient); span != nil {
+		t.Errorf("Expected no span without a parent")
+	}
+}
+
+// TestDisabled tests that nothing is recorded without a tracer
+func TestDisabled(t *testing.T) {
+	SetDefault(nil)
+	ctx := context.Background()
+	gotCtx, span := Start(ctx, "request", SpanKindServer)
+	if span != nil || gotCtx != ctx {
+		t.Fatalf("Expected a nil span and the same context with tracing disabled")
+	}
+	// A nil span is safe to use
+	span.SetName("renamed")
+	span.SetAttribute("user_id", 1)
+	span.RecordError(errors.New("boom"))
+	span.End()
+	if span.SpanContext().IsValid() || span.Name() != "" {
+		t.Errorf("Expected a nil span to be empty")
+	}
+}
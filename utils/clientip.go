@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseTrustedProxies parses proxy addresses given as IPs or CIDR ranges
+// (e.g. "10.0.0.0/8", "127.0.0.1") into prefixes; single IPs match only themselves
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// ClientIP returns the address of the client that sent r
+// X-Forwarded-For is only believed when the connection comes from a trusted
+// proxy, and then only as far as the chain of trusted proxies goes: the header
+// is read right to left (each proxy appends the address it saw) and the first
+// address that isn't a trusted proxy is the client. Anything to its left was
+// written by the client itself and could be forged, so it's ignored.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	// RemoteAddr is "host:port" ("[::1]:8080" for IPv6); tests may leave out the port
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	client := remote.Unmap()
+
+	if !isTrustedProxy(client, trustedProxies) {
+		return client.String()
+	}
+
+	// Several X-Forwarded-For headers count as one comma-separated list
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// A garbled entry ends the trustworthy part of the chain
+			break
+		}
+		client = hop.Unmap()
+		if !isTrustedProxy(client, trustedProxies) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// isTrustedProxy reports whether addr belongs to one of the trusted proxy ranges
+func isTrustedProxy(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
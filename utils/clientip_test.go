@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// TestClientIP tests finding the client address behind trusted proxies
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		trusted      bool // Use the trusted proxies above (otherwise none are trusted)
+		expected     string
+	}{
+		{"direct IPv4", "203.0.113.7:52100", nil, true, "203.0.113.7"},
+		{"direct IPv6", "[2001:db8::1]:52100", nil, true, "2001:db8::1"},
+		{"IPv4-mapped IPv6", "[::ffff:203.0.113.7]:52100", nil, true, "203.0.113.7"},
+		{"no port", "203.0.113.7", nil, true, "203.0.113.7"},
+		{"header ignored without trusted proxies", "10.0.0.2:443", []string{"198.51.100.1"}, false, "10.0.0.2"},
+		{"header ignored from an untrusted peer", "203.0.113.7:443", []string{"198.51.100.1"}, true, "203.0.113.7"},
+		{"one trusted proxy", "10.0.0.2:443", []string{"198.51.100.1"}, true, "198.51.100.1"},
+		{"spoofed entries left of the client are ignored", "10.0.0.2:443", []string{"1.2.3.4, 198.51.100.1"}, true, "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.2:443", []string{"198.51.100.1, 192.168.1.1, 10.1.2.3"}, true, "198.51.100.1"},
+		{"several headers", "10.0.0.2:443", []string{"1.2.3.4", "198.51.100.1"}, true, "198.51.100.1"},
+		{"garbled entry stops the walk", "10.0.0.2:443", []string{"198.51.100.1, not-an-ip, 10.1.2.3"}, true, "10.1.2.3"},
+		{"only trusted addresses", "10.0.0.2:443", []string{"10.9.9.9"}, true, "10.9.9.9"},
+		{"empty header", "10.0.0.2:443", []string{""}, true, "10.0.0.2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/auth/login", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			proxies := trusted
+			if !tc.trusted {
+				proxies = nil
+			}
+			if got := ClientIP(req, proxies); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestParseTrustedProxies tests accepting IPs and CIDR ranges and rejecting anything else
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"127.0.0.1", "::1", "172.16.0.0/12", "fd00::/8"}); err != nil {
+		t.Errorf("Expected valid proxies to parse, got %v", err)
+	}
+	for _, invalid := range []string{"localhost", "10.0.0.0/33", "10.0.0"} {
+		if _, err := ParseTrustedProxies([]string{invalid}); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
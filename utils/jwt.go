@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	// JWT library for creating and validating tokens
@@ -14,28 +18,84 @@ import (
 type Claims struct {
 	UserID uint   `json:"user_id"` // Custom field: which user this token belongs to
 	Email  string `json:"email"`   // Custom field: user's email for convenience
+	OrgID  uint   `json:"org_id"`  // Custom field: organization every request is scoped to
+	Role   string `json:"role"`    // Custom field: user's role within the organization
+	// Custom field: the user's token version when the token was issued
+	// AuthMiddleware rejects it once the user's version has moved on
+	TokenVersion int `json:"token_version"`
 	// Embedding jwt.RegisteredClaims gives us standard fields like exp, iat, etc.
 	jwt.RegisteredClaims
 }
 
+// TokenLifetime is how long a token from GenerateToken stays valid
+const TokenLifetime = 24 * time.Hour
+
+// ErrWeakSecret is returned by GenerateToken for a secret shorter than the
+// minimum set with SetMinSecretLength
+var ErrWeakSecret = errors.New("JWT secret is too short")
+
+// minSecretLength is the shortest secret GenerateToken signs with, in bytes
+var minSecretLength atomic.Int64
+
+// SetMinSecretLength makes GenerateToken refuse to sign with secrets shorter
+// than n bytes; 0 (the default) accepts any secret
+// main calls it at startup with JWT_MIN_SECRET_LENGTH. Test binaries never
+// run main, so tests sign with short secrets unless they turn the check on;
+// the previous minimum is returned so they can restore it.
+func SetMinSecretLength(n int) (previous int) {
+	return int(minSecretLength.Swap(int64(n)))
+}
+
 // GenerateToken creates a new JWT token for a user
-// It takes userID, email, and secret key as parameters
+// It takes the user's ID, email, organization, role and current token version,
+// plus the secret key and issuer (JWT_ISSUER) as parameters
 // Returns the token string and any error that occurred
-func GenerateToken(userID uint, email, secretKey string) (string, error) {
+func GenerateToken(userID uint, email string, orgID uint, role string, tokenVersion int, secretKey, issuer string) (string, error) {
+	token, _, err := GenerateTokenWithExpiry(userID, email, orgID, role, tokenVersion, secretKey, issuer)
+	return token, err
+}
+
+// GenerateTokenWithExpiry is GenerateToken that also returns when the token
+// expires, exactly as written to its "exp" claim, so clients can be told when
+// to get a new one
+func GenerateTokenWithExpiry(userID uint, email string, orgID uint, role string, tokenVersion int, secretKey, issuer string) (string, time.Time, error) {
+	// A short secret can be brute-forced from any token it signed, which
+	// would let anyone forge tokens for every user
+	if minimum := minSecretLength.Load(); int64(len(secretKey)) < minimum {
+		return "", time.Time{}, fmt.Errorf("%w: %d bytes, at least %d required", ErrWeakSecret, len(secretKey), minimum)
+	}
+
+	// Tokens expire TokenLifetime from now
+	// JWT timestamps are whole seconds, so drop the fraction the claim would lose
+	now := time.Now()
+	expiresAt := now.Add(TokenLifetime).Truncate(time.Second)
+
+	// Every token gets a unique ID ("jti"), so its uses can be told apart
+	// from those of other tokens (see TOKEN_REPLAY_CHECK)
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
 	// Create the claims (payload) for our token
 	// This is the data that will be stored inside the JWT
 	claims := Claims{
 		UserID: userID,
 		Email:  email,
+		OrgID:  orgID,
+		Role:   role,
+		// Stamp the token with the version it has to match
+		TokenVersion: tokenVersion,
 		// RegisteredClaims contains standard JWT fields
 		RegisteredClaims: jwt.RegisteredClaims{
 			// Token expires in 24 hours from now
-			// time.Now().Add() is Go's way to add duration to current time
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			// IssuedAt is when the token was created (now)
-			IssuedAt: jwt.NewNumericDate(time.Now()),
+			IssuedAt: jwt.NewNumericDate(now),
 			// Issuer identifies who created the token (our app)
-			Issuer: "task-management-api",
+			Issuer: issuer,
+			// ID is the unique token ID
+			ID: hex.EncodeToString(id),
 		},
 	}
 
@@ -50,32 +110,51 @@ func GenerateToken(userID uint, email, secretKey string) (string, error) {
 	tokenString, err := token.SignedString([]byte(secretKey))
 	if err != nil {
 		// If signing fails, return empty string and the error
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
 	}
 
-	// Return the token string and nil error (success)
-	return tokenString, nil
+	// Return the token string, its expiry and nil error (success)
+	return tokenString, expiresAt, nil
 }
 
 // ValidateToken takes a JWT token string and validates it
+// The token's "iss" claim must equal issuer, so tokens minted by another
+// service that happens to share the secret are rejected
+// secretKeys lists every secret the signature may have been made with: the
+// current one plus, while a rotation overlaps, the previous ones. Tokens are
+// only ever signed with the current secret (see GenerateToken).
 // Returns the claims if valid, or an error if invalid/expired
-func ValidateToken(tokenString, secretKey string) (*Claims, error) {
+func ValidateToken(tokenString string, secretKeys []string, issuer string) (*Claims, error) {
+	if len(secretKeys) == 0 {
+		return nil, errors.New("no secret keys to validate the token with")
+	}
+
+	// The parser tries each key in turn and accepts the token if any of them verifies it
+	keys := jwt.VerificationKeySet{}
+	for _, secretKey := range secretKeys {
+		keys.Keys = append(keys.Keys, []byte(secretKey))
+	}
+
 	// Parse the token string and validate it
 	// jwt.ParseWithClaims needs:
 	// 1. The token string
 	// 2. A struct to parse claims into (empty Claims struct)
-	// 3. A function that returns the key for validation
+	// 3. A function that returns the key(s) for validation
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		// Verify the signing method is what we expect (HMAC-SHA256)
 		// This prevents attacks where someone changes the algorithm
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		// Return our secret key as bytes for validation
-		return []byte(secretKey), nil
-	})
+		// Return our secret keys as bytes for validation
+		return keys, nil
+	}, jwt.WithIssuer(issuer)) // Require iss to match (a missing iss fails too)
 
 	// Check if parsing failed
+	// Name the issuer explicitly: "token has invalid issuer" alone is hard to debug
+	if errors.Is(err, jwt.ErrTokenInvalidIssuer) || errors.Is(err, jwt.ErrTokenRequiredClaimMissing) {
+		return nil, fmt.Errorf("token issuer does not match expected issuer %q: %w", issuer, err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
@@ -95,4 +174,4 @@ func ValidateToken(tokenString, secretKey string) (*Claims, error) {
 
 	// Return the claims - caller can access UserID, Email, etc.
 	return claims, nil
-}
\ No newline at end of file
+}
@@ -1,12 +1,17 @@
 package utils
 
 import (
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// testIssuer is the issuer the tests sign and validate tokens with
+const testIssuer = "task-management-api"
+
 // TestGenerateToken tests JWT token generation
 func TestGenerateToken(t *testing.T) {
 	testCases := []struct {
@@ -42,14 +47,14 @@ func TestGenerateToken(t *testing.T) {
 			userID:    1,
 			email:     "test@example.com",
 			secretKey: "",
-			wantErr:   false, // JWT allows empty secret (though insecure)
+			wantErr:   false, // Accepted while no minimum length is set (see TestMinSecretLength)
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Generate token
-			token, err := GenerateToken(tc.userID, tc.email, tc.secretKey)
+			token, err := GenerateToken(tc.userID, tc.email, 1, "member", 0, tc.secretKey, testIssuer)
 
 			// Check error expectation
 			if (err != nil) != tc.wantErr {
@@ -95,7 +100,7 @@ func TestGenerateToken(t *testing.T) {
 					}
 
 					// Check if issuer is set correctly
-					if iss, exists := claims["iss"]; !exists || iss != "task-management-api" {
+					if iss, exists := claims["iss"]; !exists || iss != testIssuer {
 						t.Errorf("Token missing or incorrect issuer claim: got %v", iss)
 					}
 
@@ -126,9 +131,10 @@ func TestValidateToken(t *testing.T) {
 	// Setup: create a valid token for testing
 	testUserID := uint(123)
 	testEmail := "test@example.com"
+	testOrgID := uint(7)
 	testSecret := "test-secret-key"
 	
-	validToken, err := GenerateToken(testUserID, testEmail, testSecret)
+	validToken, err := GenerateToken(testUserID, testEmail, testOrgID, "admin", 0, testSecret, testIssuer)
 	if err != nil {
 		t.Fatalf("Failed to generate test token: %v", err)
 	}
@@ -178,7 +184,7 @@ func TestValidateToken(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Validate token
-			claims, err := ValidateToken(tc.token, tc.secretKey)
+			claims, err := ValidateToken(tc.token, []string{tc.secretKey}, testIssuer)
 
 			// Check error expectation
 			if (err != nil) != tc.wantErr {
@@ -202,6 +208,11 @@ func TestValidateToken(t *testing.T) {
 				if tc.checkEmail && claims.Email != testEmail {
 					t.Errorf("ValidateToken() email = %v, want %v", claims.Email, testEmail)
 				}
+
+				// The organization scope must survive the round trip
+				if claims.OrgID != testOrgID || claims.Role != "admin" {
+					t.Errorf("ValidateToken() org/role = %v/%v, want %v/admin", claims.OrgID, claims.Role, testOrgID)
+				}
 			}
 		})
 	}
@@ -217,13 +228,13 @@ func TestTokenExpiration(t *testing.T) {
 	email := "test@example.com"
 	secretKey := "test-secret"
 	
-	token, err := GenerateToken(userID, email, secretKey)
+	token, err := GenerateToken(userID, email, 1, "member", 0, secretKey, testIssuer)
 	if err != nil {
 		t.Fatalf("Failed to generate token: %v", err)
 	}
 
 	// Token should be valid immediately after creation
-	claims, err := ValidateToken(token, secretKey)
+	claims, err := ValidateToken(token, []string{secretKey}, testIssuer)
 	if err != nil {
 		t.Errorf("Newly created token should be valid: %v", err)
 	}
@@ -244,6 +255,46 @@ func TestTokenExpiration(t *testing.T) {
 	}
 }
 
+// TestGenerateTokenWithExpiry tests that the returned expiry is the token's "exp" claim
+func TestGenerateTokenWithExpiry(t *testing.T) {
+	secretKey := "test-secret"
+	token, expiresAt, err := GenerateTokenWithExpiry(1, "test@example.com", 1, "member", 0, secretKey, testIssuer)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	claims, err := ValidateToken(token, []string{secretKey}, testIssuer)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if claims.ExpiresAt == nil || !claims.ExpiresAt.Time.Equal(expiresAt) {
+		t.Errorf("Expected exp claim %v, got %v", expiresAt, claims.ExpiresAt)
+	}
+	if lifetime := time.Until(expiresAt); lifetime > TokenLifetime || lifetime < TokenLifetime-time.Minute {
+		t.Errorf("Expected the token to expire in %v, got %v", TokenLifetime, lifetime)
+	}
+}
+
+// TestTokenID tests that every token gets its own "jti" claim
+func TestTokenID(t *testing.T) {
+	secretKey := "test-secret"
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		token, err := GenerateToken(1, "test@example.com", 1, "member", 0, secretKey, testIssuer)
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+		claims, err := ValidateToken(token, []string{secretKey}, testIssuer)
+		if err != nil {
+			t.Fatalf("Failed to validate token: %v", err)
+		}
+		if len(claims.ID) != 32 || seen[claims.ID] {
+			t.Fatalf("Expected a new 32 character jti, got %q", claims.ID)
+		}
+		seen[claims.ID] = true
+	}
+}
+
 // TestDifferentSecretKeys tests that tokens signed with different keys don't validate
 func TestDifferentSecretKeys(t *testing.T) {
 	userID := uint(1)
@@ -252,20 +303,120 @@ func TestDifferentSecretKeys(t *testing.T) {
 	secret2 := "secret-key-2"
 
 	// Generate token with first secret
-	token, err := GenerateToken(userID, email, secret1)
+	token, err := GenerateToken(userID, email, 1, "member", 0, secret1, testIssuer)
 	if err != nil {
 		t.Fatalf("Failed to generate token: %v", err)
 	}
 
 	// Token should validate with the same secret
-	_, err = ValidateToken(token, secret1)
+	_, err = ValidateToken(token, []string{secret1}, testIssuer)
 	if err != nil {
 		t.Errorf("Token should validate with same secret: %v", err)
 	}
 
 	// Token should NOT validate with different secret
-	_, err = ValidateToken(token, secret2)
+	_, err = ValidateToken(token, []string{secret2}, testIssuer)
 	if err == nil {
 		t.Errorf("Token should not validate with different secret")
 	}
-}
\ No newline at end of file
+}
+
+// TestValidateTokenIssuer tests that tokens from another issuer are rejected
+func TestValidateTokenIssuer(t *testing.T) {
+	secret := "shared-secret"
+
+	foreignToken, err := GenerateToken(1, "test@example.com", 1, "member", 0, secret, "other-service")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// Same secret, different issuer: a clear issuer error, not a generic failure
+	_, err = ValidateToken(foreignToken, []string{secret}, testIssuer)
+	if !errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+		t.Fatalf("Expected an invalid issuer error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), testIssuer) {
+		t.Errorf("Expected the error to name the expected issuer, got %q", err.Error())
+	}
+
+	// Tokens without any issuer are rejected as well
+	noIssuer, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		UserID:           1,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	if _, err := ValidateToken(noIssuer, []string{secret}, testIssuer); err == nil {
+		t.Errorf("Expected a token without an issuer to be rejected")
+	}
+}
+
+// TestValidateTokenRotation tests that tokens keep validating while a secret rotation overlaps
+func TestValidateTokenRotation(t *testing.T) {
+	oldSecret := "old-secret"
+	newSecret := "new-secret"
+
+	oldToken, err := GenerateToken(1, "test@example.com", 1, "member", 0, oldSecret, testIssuer)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	newToken, err := GenerateToken(1, "test@example.com", 1, "member", 0, newSecret, testIssuer)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	strangerToken, err := GenerateToken(1, "test@example.com", 1, "member", 0, "unrelated-secret", testIssuer)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		token      string
+		secretKeys []string
+		wantErr    bool
+	}{
+		{"before rotation", oldToken, []string{oldSecret}, false},
+		{"old token during overlap", oldToken, []string{newSecret, oldSecret}, false},
+		{"new token during overlap", newToken, []string{newSecret, oldSecret}, false},
+		{"old token after overlap", oldToken, []string{newSecret}, true},
+		{"token signed with no configured secret", strangerToken, []string{newSecret, oldSecret}, true},
+		{"no secrets", newToken, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ValidateToken(tc.token, tc.secretKeys, testIssuer)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ValidateToken() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// TestMinSecretLength tests that GenerateToken refuses short secrets once a minimum is set
+func TestMinSecretLength(t *testing.T) {
+	previous := SetMinSecretLength(32)
+	t.Cleanup(func() { SetMinSecretLength(previous) })
+
+	for _, secretKey := range []string{"", "default-secret-change-this", strings.Repeat("x", 31)} {
+		if _, err := GenerateToken(1, "test@example.com", 1, "member", 0, secretKey, testIssuer); !errors.Is(err, ErrWeakSecret) {
+			t.Errorf("Expected ErrWeakSecret for a %d byte secret, got %v", len(secretKey), err)
+		}
+	}
+
+	secretKey := strings.Repeat("x", 32)
+	token, err := GenerateToken(1, "test@example.com", 1, "member", 0, secretKey, testIssuer)
+	if err != nil {
+		t.Fatalf("Expected a 32 byte secret to be accepted, got %v", err)
+	}
+	if _, err := ValidateToken(token, []string{secretKey}, testIssuer); err != nil {
+		t.Errorf("Failed to validate token: %v", err)
+	}
+
+	// Tests that sign with short secrets on purpose turn the check off
+	SetMinSecretLength(0)
+	if _, err := GenerateToken(1, "test@example.com", 1, "member", 0, "short", testIssuer); err != nil {
+		t.Errorf("Expected any secret without a minimum, got %v", err)
+	}
+}
@@ -1,39 +1,179 @@
 package utils
 
 import (
+	"crypto/rand"
+	// crypto/subtle compares byte slices in constant time, so the time a
+	// comparison takes doesn't leak how much of the hash matched
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	// golang.org/x/crypto/argon2 provides Argon2id, the current recommendation for password hashing
+	// Unlike bcrypt it's also memory-hard, which makes GPU cracking expensive
+	"golang.org/x/crypto/argon2"
 	// golang.org/x/crypto/bcrypt provides the bcrypt hashing algorithm
 	// bcrypt is a password hashing function designed to be slow to prevent brute force attacks
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword takes a plain text password and returns a bcrypt hash
-// The cost parameter determines how slow the hashing will be (higher = more secure but slower)
-// bcrypt.DefaultCost (10) is a good balance between security and performance
-func HashPassword(password string) (string, error) {
-	// bcrypt.GenerateFromPassword() does the actual hashing
-	// []byte(password) converts the string to a byte slice (bcrypt works with bytes)
-	// The function returns ([]byte, error) - a common Go pattern
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	
-	// Always check for errors in Go - this is the idiomatic way
-	if err != nil {
-		// Return empty string and the error - Go supports multiple return values
+// Password hashing algorithms for new hashes (PASSWORD_HASH_ALGORITHM)
+// Stored hashes carry their algorithm in their prefix ("$2a$" for bcrypt,
+// "$argon2id$" for Argon2id), so both can be checked whichever one is configured.
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// Argon2id parameters for new hashes (RFC 9106's recommendation for
+// memory-constrained servers). They are written into every hash, so changing
+// them later doesn't break existing ones; NeedsRehash upgrades them on login.
+const (
+	argon2Time    uint32 = 3         // Passes over memory
+	argon2Memory  uint32 = 64 * 1024 // Memory in KiB (64 MiB)
+	argon2Threads uint8  = 4
+	argon2KeyLen  uint32 = 32
+	argon2SaltLen        = 16
+)
+
+// argon2Prefix starts every Argon2id hash (PHC string format)
+const argon2Prefix = "$argon2id$"
+
+// HashPassword takes a plain text password and returns a hash made with the given algorithm
+// algorithm is PasswordHashBcrypt or PasswordHashArgon2id; "" means Argon2id.
+// bcrypt uses bcrypt.DefaultCost (10), a good balance between security and performance.
+func HashPassword(password, algorithm string) (string, error) {
+	switch algorithm {
+	case PasswordHashBcrypt:
+		// bcrypt.GenerateFromPassword() does the actual hashing
+		// []byte(password) converts the string to a byte slice (bcrypt works with bytes)
+		// The function returns ([]byte, error) - a common Go pattern
+		hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+		// Always check for errors in Go - this is the idiomatic way
+		if err != nil {
+			// Return empty string and the error - Go supports multiple return values
+			return "", err
+		}
+
+		// Convert the byte slice back to string and return with nil error
+		// In Go, returning nil for error means "no error occurred"
+		return string(hashedBytes), nil
+	case PasswordHashArgon2id, "":
+		return hashArgon2id(password)
+	default:
+		return "", fmt.Errorf("unknown password hash algorithm %q", algorithm)
+	}
+}
+
+// hashArgon2id hashes password with a random salt into the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+func hashArgon2id(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
 		return "", err
 	}
-	
-	// Convert the byte slice back to string and return with nil error
-	// In Go, returning nil for error means "no error occurred"
-	return string(hashedBytes), nil
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// argon2Hash holds the parts of a decoded Argon2id hash
+type argon2Hash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// parseArgon2id decodes a hash made by hashArgon2id
+func parseArgon2id(hash string) (argon2Hash, bool) {
+	// "$argon2id$v=19$m=..,t=..,p=..$salt$key" splits into
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return argon2Hash{}, false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2Hash{}, false
+	}
+
+	var parsed argon2Hash
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &parsed.memory, &parsed.time, &parsed.threads); err != nil {
+		return argon2Hash{}, false
+	}
+	if parsed.time == 0 || parsed.threads == 0 {
+		return argon2Hash{}, false // argon2.IDKey panics on these
+	}
+
+	var err error
+	if parsed.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return argon2Hash{}, false
+	}
+	if parsed.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(parsed.key) == 0 {
+		return argon2Hash{}, false
+	}
+	return parsed, true
+}
+
+// PasswordHashAlgorithm tells which algorithm made a stored hash
+// It returns "" for hashes it doesn't recognize.
+func PasswordHashAlgorithm(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, argon2Prefix):
+		return PasswordHashArgon2id
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return PasswordHashBcrypt
+	default:
+		return ""
+	}
 }
 
 // CheckPassword compares a plain text password with a hash to see if they match
-// This is used during login to verify the user's password
+// This is used during login to verify the user's password. The hash can be
+// bcrypt or Argon2id, whichever algorithm is configured for new hashes.
 func CheckPassword(password, hash string) bool {
-	// bcrypt.CompareHashAndPassword compares the hash with the plain password
-	// It returns an error if they don't match, nil if they do match
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	
-	// Return true if no error (passwords match), false if error (passwords don't match)
-	// This is a concise way to convert an error to a boolean
-	return err == nil
-}
\ No newline at end of file
+	switch PasswordHashAlgorithm(hash) {
+	case PasswordHashArgon2id:
+		parsed, ok := parseArgon2id(hash)
+		if !ok {
+			return false
+		}
+		// Hash the password again with the stored salt and parameters
+		key := argon2.IDKey([]byte(password), parsed.salt, parsed.time, parsed.memory, parsed.threads, uint32(len(parsed.key)))
+		return subtle.ConstantTimeCompare(key, parsed.key) == 1
+	default:
+		// bcrypt.CompareHashAndPassword compares the hash with the plain password
+		// It returns an error if they don't match, nil if they do match
+		// (unknown formats are rejected by bcrypt too)
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+
+		// Return true if no error (passwords match), false if error (passwords don't match)
+		// This is a concise way to convert an error to a boolean
+		return err == nil
+	}
+}
+
+// NeedsRehash reports whether a stored hash should be replaced by a new one
+// made with algorithm: it was made with another algorithm, or with weaker
+// Argon2id parameters than the current ones. Call it after CheckPassword
+// succeeded, when the plain text password is at hand.
+func NeedsRehash(hash, algorithm string) bool {
+	if algorithm == "" {
+		algorithm = PasswordHashArgon2id
+	}
+	if PasswordHashAlgorithm(hash) != algorithm {
+		return true
+	}
+	if algorithm == PasswordHashArgon2id {
+		parsed, ok := parseArgon2id(hash)
+		return !ok || parsed.memory < argon2Memory || parsed.time < argon2Time ||
+			parsed.threads < argon2Threads || uint32(len(parsed.key)) < argon2KeyLen
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -43,7 +44,7 @@ func TestHashPassword(t *testing.T) {
 		// This allows better isolation and reporting of individual test failures
 		t.Run(tc.name, func(t *testing.T) {
 			// Call the function we're testing
-			hash, err := HashPassword(tc.password)
+			hash, err := HashPassword(tc.password, PasswordHashBcrypt)
 
 			// Check if error expectation matches reality
 			if (err != nil) != tc.wantErr {
@@ -79,7 +80,7 @@ func TestHashPassword(t *testing.T) {
 func TestCheckPassword(t *testing.T) {
 	// First, create a known hash for testing
 	testPassword := "testpassword123"
-	hash, err := HashPassword(testPassword)
+	hash, err := HashPassword(testPassword, PasswordHashBcrypt)
 	if err != nil {
 		// t.Fatalf stops the test immediately on fatal error
 		// Use this when the test cannot continue without this setup
@@ -143,8 +144,8 @@ func TestHashPasswordConsistency(t *testing.T) {
 	password := "testpassword123"
 
 	// Generate two hashes for the same password
-	hash1, err1 := HashPassword(password)
-	hash2, err2 := HashPassword(password)
+	hash1, err1 := HashPassword(password, PasswordHashBcrypt)
+	hash2, err2 := HashPassword(password, PasswordHashBcrypt)
 
 	// Both should succeed
 	if err1 != nil {
@@ -167,4 +168,82 @@ func TestHashPasswordConsistency(t *testing.T) {
 	if !CheckPassword(password, hash2) {
 		t.Errorf("Second hash does not validate against original password")
 	}
-}
\ No newline at end of file
+}
+// TestArgon2idPassword tests hashing with Argon2id and checking hashes of both algorithms
+func TestArgon2idPassword(t *testing.T) {
+	password := "testpassword123"
+
+	hash, err := HashPassword(password, PasswordHashArgon2id)
+	if err != nil {
+		t.Fatalf("HashPassword() failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=4$") {
+		t.Errorf("HashPassword() returned invalid argon2id hash format: %s", hash)
+	}
+
+	// A bcrypt hash stored before the switch to Argon2id
+	const storedBcrypt = "$2a$04$Gc0/wlq09sSrla64fz7NSexzSR3RaHq6SjPfiVqakbDVMyjkELSLW"
+
+	testCases := []struct {
+		name      string
+		password  string
+		hash      string
+		want      bool   // Expected CheckPassword result
+		algorithm string // Expected PasswordHashAlgorithm result
+	}{
+		{"correct password", password, hash, true, PasswordHashArgon2id},
+		{"incorrect password", "wrongpassword", hash, false, PasswordHashArgon2id},
+		{"stored bcrypt hash", password, storedBcrypt, true, PasswordHashBcrypt},
+		{"wrong password against stored bcrypt hash", "wrongpassword", storedBcrypt, false, PasswordHashBcrypt},
+		{"truncated argon2id hash", password, hash[:len(hash)-10], false, PasswordHashArgon2id},
+		{"argon2id hash with zero passes", password, strings.Replace(hash, "t=3", "t=0", 1), false, PasswordHashArgon2id},
+		{"unknown format", password, "invalid-hash-format", false, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := CheckPassword(tc.password, tc.hash); got != tc.want {
+				t.Errorf("CheckPassword() = %v, want %v", got, tc.want)
+			}
+			if got := PasswordHashAlgorithm(tc.hash); got != tc.algorithm {
+				t.Errorf("PasswordHashAlgorithm() = %q, want %q", got, tc.algorithm)
+			}
+		})
+	}
+
+	if _, err := HashPassword(password, "md5"); err == nil {
+		t.Errorf("HashPassword() accepted an unknown algorithm")
+	}
+}
+
+// TestNeedsRehash tests which stored hashes are upgraded on login
+func TestNeedsRehash(t *testing.T) {
+	const storedBcrypt = "$2a$04$Gc0/wlq09sSrla64fz7NSexzSR3RaHq6SjPfiVqakbDVMyjkELSLW"
+	current, err := HashPassword("testpassword123", PasswordHashArgon2id)
+	if err != nil {
+		t.Fatalf("HashPassword() failed: %v", err)
+	}
+	weaker := strings.Replace(current, "m=65536", "m=32768", 1)
+
+	testCases := []struct {
+		name      string
+		hash      string
+		algorithm string
+		want      bool
+	}{
+		{"bcrypt hash, argon2id configured", storedBcrypt, PasswordHashArgon2id, true},
+		{"bcrypt hash, default algorithm", storedBcrypt, "", true},
+		{"bcrypt hash, bcrypt configured", storedBcrypt, PasswordHashBcrypt, false},
+		{"current argon2id hash", current, PasswordHashArgon2id, false},
+		{"argon2id hash with less memory", weaker, PasswordHashArgon2id, true},
+		{"argon2id hash, bcrypt configured", current, PasswordHashBcrypt, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := NeedsRehash(tc.hash, tc.algorithm); got != tc.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces every masked value in log output
+const Redacted = "[REDACTED]"
+
+var (
+	// bearerPattern matches credentials after an auth scheme, e.g. "Bearer eyJ..."
+	bearerPattern = regexp.MustCompile(`(?i)\b(Bearer|Basic)\s+[A-Za-z0-9\-._~+/]+=*`)
+	// jwtPattern matches a JWT on its own, wherever it ends up in a message
+	jwtPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// passwordHashPattern matches argon2 and bcrypt hashes, e.g. in logged SQL
+	passwordHashPattern = regexp.MustCompile(`\$(argon2(id|i|d)\$[A-Za-z0-9+/=$,]+|2[aby]\$\d{2}\$[./A-Za-z0-9]{53})`)
+)
+
+// Redactor masks sensitive values in log lines before they're written
+// Values of the configured keys are masked wherever they appear as key=value,
+// key:value or "key": "value" (as slog, fmt's %+v and JSON write them), and
+// keys match as part of a longer name too, so "password" also masks
+// new_password and password_hash. Bearer credentials, JWTs and password
+// hashes are masked whatever they're logged under.
+type Redactor struct {
+	keyPattern *regexp.Regexp // nil without keys
+}
+
+// NewRedactor returns a Redactor masking the values of keys (case-insensitive)
+func NewRedactor(keys []string) *Redactor {
+	quoted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			quoted = append(quoted, regexp.QuoteMeta(key))
+		}
+	}
+	if len(quoted) == 0 {
+		return &Redactor{}
+	}
+	// A JSON key and colon, or a bare key and = or a colon right before the
+	// value (prose like "failed to hash password: ..." is left alone), then a
+	// quoted or bare value
+	key := `[\w-]*(?:` + strings.Join(quoted, "|") + `)[\w-]*`
+	pattern := `(?i)("` + key + `"\s*:\s*|\b` + key + `(?:\s*=\s*|:))("(?:[^"\\]|\\.)*"|[^\s,;&}\]]+)`
+	return &Redactor{keyPattern: regexp.MustCompile(pattern)}
+}
+
+// Redact returns s with every sensitive value masked
+func (rd *Redactor) Redact(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "$1 "+Redacted)
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = passwordHashPattern.ReplaceAllString(s, Redacted)
+	if rd.keyPattern != nil {
+		s = rd.keyPattern.ReplaceAllStringFunc(s, func(match string) string {
+			parts := rd.keyPattern.FindStringSubmatch(match)
+			if strings.HasPrefix(parts[2], `"`) {
+				return parts[1] + `"` + Redacted + `"`
+			}
+			return parts[1] + Redacted
+		})
+	}
+	return s
+}
+
+// Writer returns an io.Writer that redacts everything written through it to w
+// The log package writes each entry with a single Write, so entries are
+// redacted whole; use it with log.SetOutput or log.New.
+func (rd *Redactor) Writer(w io.Writer) io.Writer {
+	return &redactingWriter{redactor: rd, w: w}
+}
+
+// redactingWriter is the io.Writer returned by Redactor.Writer
+type redactingWriter struct {
+	redactor *Redactor
+	w        io.Writer
+}
+
+// Write reports len(p) on success, since callers don't care that the redacted length differs
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, rw.redactor.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
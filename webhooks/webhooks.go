@@ -0,0 +1,194 @@
+// Package webhooks delivers task events to user-registered URLs
+// Deliveries run in the background so a slow or failing endpoint never
+// delays (or breaks) the API request that triggered the event.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+// Headers sent with every delivery
+const (
+	SignatureHeader = "X-Webhook-Signature" // "sha256=<hex HMAC of the body>"
+	EventHeader     = "X-Webhook-Event"     // Event name, e.g. task.created
+)
+
+// retryBackoff is the wait before the first retry; it doubles on each attempt
+// It's a variable so tests don't have to wait for real backoff delays
+var retryBackoff = time.Second
+
+// ErrBlockedAddress is returned for webhook URLs that point to this server or
+// its private network, e.g. 127.0.0.1 or the 169.254.169.254 metadata service
+var ErrBlockedAddress = errors.New("webhook address is not publicly routable")
+
+// blockedPrefixes are non-public ranges that netip doesn't classify itself
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "This network"; Linux dials it locally
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT, used by some clouds internally
+}
+
+// lookupHost resolves webhook host names; tests replace it
+var lookupHost = net.DefaultResolver.LookupNetIP
+
+// client is shared by all deliveries; each attempt gets its own timeout via context
+// Deliveries connect directly, never through a proxy, so the dialer sees
+// (and checks) the address each one really goes to.
+var client = &http.Client{
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 30 * time.Second, Control: checkDial}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+		MaxIdleConns:        100,
+	},
+}
+
+// Delivery is a single event to send to one webhook
+type Delivery struct {
+	URL    string
+	Secret string
+	Event  string
+	Body   []byte // JSON payload
+}
+
+// CheckAddress returns ErrBlockedAddress unless ip is a public unicast address
+// Loopback, private, link-local, multicast and unspecified addresses are
+// blocked, also when written as IPv4-mapped IPv6. WEBHOOK_ALLOW_PRIVATE_URLS
+// turns the check off, e.g. for receivers on the same machine in development.
+func CheckAddress(ip netip.Addr) error {
+	if config.Get().WebhookAllowPrivateURLs {
+		return nil
+	}
+
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+		}
+	}
+	return nil
+}
+
+// CheckURL returns ErrBlockedAddress when target's host is, or resolves to,
+// an address CheckAddress blocks
+// A host name that can't be resolved now is let through: what it resolves to
+// at delivery time is checked again when connecting, which also catches
+// names that are pointed somewhere else after registration.
+func CheckURL(ctx context.Context, target *url.URL) error {
+	host := target.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return CheckAddress(ip)
+	}
+
+	ips, err := lookupHost(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, ip := range ips {
+		if err := CheckAddress(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDial refuses connections to blocked addresses
+// It runs after DNS resolution, for every address actually dialed, redirects
+// included, so a host name can't be used to get around CheckURL.
+func checkDial(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	return CheckAddress(addrPort.Addr())
+}
+
+// Sign returns the signature header value for body
+// Receivers recompute the HMAC with their secret and compare to verify the sender
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send delivers d in the background, retrying failures with exponential backoff
+// Retry count and per-attempt timeout come from WEBHOOK_MAX_RETRIES and WEBHOOK_TIMEOUT
+func Send(d Delivery) {
+	cfg := config.Get()
+	go func() {
+		// A bug in delivery must never crash the server
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("Webhook delivery to %s panicked: %v", d.URL, rec)
+			}
+		}()
+
+		if err := deliver(d, cfg.WebhookMaxRetries, cfg.WebhookTimeout); err != nil {
+			log.Printf("Webhook delivery of %s to %s failed: %v", d.Event, d.URL, err)
+		}
+	}()
+}
+
+// deliver sends d, making up to maxRetries additional attempts after a failure
+func deliver(d Delivery, maxRetries int, timeout time.Duration) error {
+	backoff := retryBackoff
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		if err = post(d, timeout); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", maxRetries+1, err)
+}
+
+// post makes a single delivery attempt
+// Any 2xx response counts as success
+func post(d Delivery, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", d.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(SignatureHeader, Sign(d.Secret, d.Body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
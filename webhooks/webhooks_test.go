@@ -0,0 +1,248 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kcansari/task-management-api/config"
+)
+
+func init() {
+	// Keep retry tests fast
+	retryBackoff = time.Millisecond
+}
+
+// setAllowPrivateURLs sets WEBHOOK_ALLOW_PRIVATE_URLS for the test
+// Test servers listen on 127.0.0.1, which is blocked by default
+func setAllowPrivateURLs(t *testing.T, allow bool) {
+	t.Helper()
+	previous := config.Get()
+	cfg := *previous
+	cfg.WebhookAllowPrivateURLs = allow
+	config.Set(&cfg)
+	t.Cleanup(func() { config.Set(previous) })
+}
+
+// TestSign tests the HMAC signature format
+func TestSign(t *testing.T) {
+	// Known HMAC-SHA256 of "hello" with key "secret"
+	expected := "sha256=88aab3ede8d3adf94d26ab90d3bafd4a2083070c3bcce9c014ee04a443847c0b"
+	if got := Sign("secret", []byte("hello")); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	if Sign("secret", []byte("hello")) == Sign("other", []byte("hello")) {
+		t.Errorf("Expected different secrets to give different signatures")
+	}
+}
+
+// TestDeliverSendsSignedRequest tests the headers and body of a delivery
+func TestDeliverSendsSignedRequest(t *testing.T) {
+	setAllowPrivateURLs(t, true)
+	body := []byte(`{"event":"task.created"}`)
+	var gotSignature, gotEvent, gotBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		gotSignature = r.Header.Get(SignatureHeader)
+		gotEvent = r.Header.Get(EventHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d := Delivery{URL: server.URL, Secret: "s3cret", Event: "task.created", Body: body}
+	if err := deliver(d, 0, time.Second); err != nil {
+		t.Fatalf("Expected delivery to succeed, got %v", err)
+	}
+
+	if gotBody != string(body) {
+		t.Errorf("Expected body %s, got %s", body, gotBody)
+	}
+	if gotEvent != "task.created" {
+		t.Errorf("Expected event header task.created, got %q", gotEvent)
+	}
+	if gotSignature != Sign("s3cret", body) {
+		t.Errorf("Expected signature %s, got %s", Sign("s3cret", body), gotSignature)
+	}
+}
+
+// TestDeliverRetries tests retrying failed deliveries
+func TestDeliverRetries(t *testing.T) {
+	setAllowPrivateURLs(t, true)
+	testCases := []struct {
+		name             string
+		failures         int32 // Requests that fail before the endpoint recovers
+		maxRetries       int
+		expectError      bool
+		expectedAttempts int32
+	}{
+		{"succeeds first time", 0, 3, false, 1},
+		{"recovers within retries", 2, 3, false, 3},
+		{"gives up after max retries", 10, 2, true, 3},
+		{"no retries", 10, 0, true, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) <= tc.failures {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			err := deliver(Delivery{URL: server.URL, Secret: "s", Event: "task.updated", Body: []byte("{}")}, tc.maxRetries, time.Second)
+			if tc.expectError && err == nil {
+				t.Errorf("Expected an error, got nil")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tc.expectedAttempts {
+				t.Errorf("Expected %d attempts, got %d", tc.expectedAttempts, got)
+			}
+		})
+	}
+}
+
+// TestDeliverTimeout tests that a hanging endpoint is abandoned after the timeout
+func TestDeliverTimeout(t *testing.T) {
+	setAllowPrivateURLs(t, true)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	err := deliver(Delivery{URL: server.URL, Secret: "s", Event: "task.updated", Body: []byte("{}")}, 0, 50*time.Millisecond)
+	if err == nil {
+		t.Fatalf("Expected a timeout error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected delivery to give up quickly, took %v", elapsed)
+	}
+}
+
+// TestCheckAddress tests which addresses webhooks may be delivered to
+func TestCheckAddress(t *testing.T) {
+	setAllowPrivateURLs(t, false)
+
+	testCases := []struct {
+		address string
+		blocked bool
+	}{
+		{"8.8.8.8", false},
+		{"2606:4700:4700::1111", false},
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"100.100.100.200", true},
+		{"224.0.0.1", true},
+		{"255.255.255.255", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.address, func(t *testing.T) {
+			err := CheckAddress(netip.MustParseAddr(tc.address))
+			if blocked := errors.Is(err, ErrBlockedAddress); blocked != tc.blocked {
+				t.Errorf("Expected blocked=%v, got %v", tc.blocked, err)
+			}
+		})
+	}
+
+	setAllowPrivateURLs(t, true)
+	if err := CheckAddress(netip.MustParseAddr("127.0.0.1")); err != nil {
+		t.Errorf("Expected WEBHOOK_ALLOW_PRIVATE_URLS to allow loopback, got %v", err)
+	}
+}
+
+// TestCheckURL tests that host names are checked by what they resolve to
+func TestCheckURL(t *testing.T) {
+	setAllowPrivateURLs(t, false)
+	previous := lookupHost
+	t.Cleanup(func() { lookupHost = previous })
+	lookupHost = func(ctx context.Context, network, host string) ([]netip.Addr, error) {
+		switch host {
+		case "public.example":
+			return []netip.Addr{netip.MustParseAddr("93.184.216.34")}, nil
+		case "internal.example":
+			return []netip.Addr{netip.MustParseAddr("93.184.216.34"), netip.MustParseAddr("10.0.0.5")}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	testCases := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://public.example/hook", false},
+		{"https://internal.example/hook", true}, // Any private address is enough
+		{"https://unresolvable.example/hook", false},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://[::1]:8080/hook", true},
+		{"https://93.184.216.34/hook", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			target, _ := url.Parse(tc.url)
+			err := CheckURL(context.Background(), target)
+			if blocked := errors.Is(err, ErrBlockedAddress); blocked != tc.blocked {
+				t.Errorf("Expected blocked=%v, got %v", tc.blocked, err)
+			}
+		})
+	}
+}
+
+// TestDeliverRefusesBlockedAddresses tests that deliveries never connect to a
+// blocked address, however the URL names it
+func TestDeliverRefusesBlockedAddresses(t *testing.T) {
+	setAllowPrivateURLs(t, false)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	// A host name resolving to loopback is caught when dialing, as it would
+	// be after a DNS record changes since registration
+	for _, target := range []string{server.URL, fmt.Sprintf("http://localhost:%d/hook", port)} {
+		err := deliver(Delivery{URL: target, Secret: "s", Event: "task.updated", Body: []byte("{}")}, 0, time.Second)
+		if !errors.Is(err, ErrBlockedAddress) {
+			t.Errorf("Expected delivery to %s to be blocked, got %v", target, err)
+		}
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("Expected no request to reach the server, got %d", n)
+	}
+}